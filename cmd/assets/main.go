@@ -0,0 +1,25 @@
+// Команда assets подготавливает статические файлы сайта: добавляет хеш содержимого в имена,
+// создает сжатые копии brotli и gzip и записывает манифест.
+// Запускается через go generate ./internal/web/static.
+package main
+
+import (
+	"flag"
+	"log/slog"
+	"os"
+
+	"github.com/LigeronAhill/luxcarpets-go/pkg/assets"
+)
+
+func main() {
+	src := flag.String("src", "assets", "directory with source static files")
+	dst := flag.String("dst", "internal/web/static/dist", "output directory (cleared before build)")
+	flag.Parse()
+
+	manifest, err := assets.Build(os.DirFS(*src), *dst)
+	if err != nil {
+		slog.Error("Failed to build static assets", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+	slog.Info("Static assets built", slog.String("dst", *dst), slog.Int("files", len(manifest.Files)))
+}
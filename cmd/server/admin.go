@@ -0,0 +1,478 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/LigeronAhill/luxcarpets-go/internal/database"
+	"github.com/LigeronAhill/luxcarpets-go/internal/database/types"
+	"github.com/LigeronAhill/luxcarpets-go/internal/service"
+	"github.com/LigeronAhill/luxcarpets-go/pkg/config"
+	"github.com/LigeronAhill/luxcarpets-go/pkg/logger"
+	"github.com/google/uuid"
+	"golang.org/x/term"
+)
+
+// adminUsage - справка server admin
+const adminUsage = `usage: server admin <command> [flags]
+
+commands:
+  create-owner     create an owner account, the password is prompted without echo
+  reset-password   set a new password and sign the user out of all browsers
+  set-role         change the role of a user
+  users            list and search users
+  restore          restore a soft-deleted user
+  revoke-sessions  sign the user out of all browsers
+  unlock-account   lift the temporary sign-in lockout after failed passwords
+  reset-2fa        remove the authenticator of a user who lost the device and recovery codes
+
+Users are selected by ID or email. Run "server admin <command> -h" for flags.
+`
+
+// adminCommands - команды server admin
+var adminCommands = map[string]func(ctx context.Context, args []string) error{
+	"create-owner":    adminCreateOwner,
+	"reset-password":  adminResetPassword,
+	"set-role":        adminSetRole,
+	"users":           adminUsers,
+	"restore":         adminRestore,
+	"revoke-sessions": adminRevokeSessions,
+	"unlock-account":  adminUnlockAccount,
+	"reset-2fa":       adminResetTwoFactor,
+}
+
+// stdin читает ответы на вопросы команд; один буфер на все вопросы,
+// чтобы ввод через pipe не терялся между ними
+var stdin = bufio.NewReader(os.Stdin)
+
+// admin выполняет операции сопровождения через сервисный слой: управление
+// пользователями, ролями и сессиями без HTTP API. Миграции должны быть применены.
+//
+//	server admin create-owner -email owner@example.com
+//	server admin users -role admin -q ivan
+//	echo "$PASSWORD" | server admin reset-password -user owner@example.com
+func admin(ctx context.Context, args []string) error {
+	if len(args) == 0 || args[0] == "-h" || args[0] == "help" {
+		fmt.Fprint(os.Stderr, adminUsage)
+		return nil
+	}
+	command, ok := adminCommands[args[0]]
+	if !ok {
+		fmt.Fprint(os.Stderr, adminUsage)
+		return fmt.Errorf("unknown admin command %q", args[0])
+	}
+	return command(ctx, args[1:])
+}
+
+// adminEnv - сервисы для команд server admin
+type adminEnv struct {
+	users         *service.UsersService
+	sessions      *service.SessionsService
+	twoFactor     *service.TwoFactorService
+	loginAttempts string // Хранилище счетчиков неудачных входов (LOGIN_ATTEMPTS_BACKEND)
+	close         func()
+}
+
+// openAdmin загружает конфигурацию сервера и подключается к базе.
+// Пароли проверяются и хешируются по тем же настройкам, что и в HTTP API.
+func openAdmin(ctx context.Context) (*adminEnv, error) {
+	// Команды выводят результат в stdout, поэтому журнал - только предупреждения и ошибки
+	logger.Init(logger.WARN)
+	cfg, err := config.Init("")
+	if err != nil {
+		return nil, fmt.Errorf("failed to load config: %w", err)
+	}
+	passwordPolicy, err := newPasswordPolicy(cfg.PasswordSettings)
+	if err != nil {
+		return nil, err
+	}
+	argon2Params, err := newArgon2Params(cfg.PasswordSettings)
+	if err != nil {
+		return nil, err
+	}
+	pool, err := database.NewPool(ctx, cfg.DatabaseSettings.URL, cfg.DatabaseSettings.ConnectTimeout)
+	if err != nil {
+		return nil, err
+	}
+	loginGuard, err := newLoginGuard(cfg.AuthSettings, pool)
+	if err != nil {
+		pool.Close()
+		return nil, err
+	}
+	usersStorage := database.NewUsersStorage(pool)
+	twoFactor, err := newTwoFactorService(cfg.TwoFactorSettings, pool, usersStorage)
+	if err != nil {
+		pool.Close()
+		return nil, err
+	}
+	return &adminEnv{
+		users: service.NewUsersService(usersStorage,
+			service.WithLoginGuard(loginGuard),
+			service.WithPasswordPolicy(passwordPolicy),
+			service.WithArgon2Params(argon2Params),
+		),
+		sessions:      service.NewSessionsService(database.NewSessionsStorage(pool), usersStorage, cfg.AuthSettings.SessionTTL),
+		twoFactor:     twoFactor,
+		loginAttempts: cfg.AuthSettings.LoginAttemptsBackend,
+		close:         pool.Close,
+	}, nil
+}
+
+// findUser ищет пользователя по ID или email
+func (e *adminEnv) findUser(ctx context.Context, ref string) (*types.PublicUser, error) {
+	ref = strings.TrimSpace(ref)
+	if ref == "" {
+		return nil, errors.New("-user is required")
+	}
+	if _, err := uuid.Parse(ref); err == nil {
+		return e.users.GetByID(ctx, ref)
+	}
+	return e.users.GetByEmail(ctx, ref)
+}
+
+// adminCreateOwner создает владельца магазина с подтвержденным email.
+// Не указанные флагами email и имя запрашиваются интерактивно.
+func adminCreateOwner(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("create-owner", flag.ContinueOnError)
+	email := flags.String("email", "", "owner email")
+	username := flags.String("username", "", "owner display name")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	var err error
+	if *email == "" {
+		if *email, err = prompt("Email"); err != nil {
+			return err
+		}
+	}
+	if *username == "" {
+		if *username, err = prompt("Username"); err != nil {
+			return err
+		}
+	}
+	password, err := promptPassword()
+	if err != nil {
+		return err
+	}
+
+	env, err := openAdmin(ctx)
+	if err != nil {
+		return err
+	}
+	defer env.close()
+
+	role := types.RoleOwner.String()
+	user, err := env.users.SignUp(ctx, *email, *username, &password, &role, nil)
+	if err != nil {
+		return err
+	}
+	verified := true
+	if _, err := env.users.Update(ctx, types.UpdateUserParams{ID: user.ID, EmailVerified: &verified}); err != nil {
+		return err
+	}
+	fmt.Printf("Created owner %s (%s)\n", user.Email, user.ID)
+	return nil
+}
+
+// adminResetPassword задает пользователю новый пароль и завершает его сессии
+func adminResetPassword(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("reset-password", flag.ContinueOnError)
+	ref := flags.String("user", "", "user ID or email")
+	keepSessions := flags.Bool("keep-sessions", false, "do not sign the user out of browsers")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	env, err := openAdmin(ctx)
+	if err != nil {
+		return err
+	}
+	defer env.close()
+
+	user, err := env.findUser(ctx, *ref)
+	if err != nil {
+		return err
+	}
+	password, err := promptPassword()
+	if err != nil {
+		return err
+	}
+	if _, err := env.users.Update(ctx, types.UpdateUserParams{ID: user.ID, PasswordHash: &password}); err != nil {
+		return err
+	}
+	fmt.Printf("Password of %s changed\n", userLabel(user))
+	if *keepSessions {
+		return nil
+	}
+	return revokeSessions(ctx, env, user)
+}
+
+// adminSetRole меняет роль пользователя
+func adminSetRole(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("set-role", flag.ContinueOnError)
+	ref := flags.String("user", "", "user ID or email")
+	roleName := flags.String("role", "", "new role: customer, employee, admin or owner")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	role, err := types.RoleFromString(*roleName)
+	if err != nil {
+		return err
+	}
+	env, err := openAdmin(ctx)
+	if err != nil {
+		return err
+	}
+	defer env.close()
+
+	user, err := env.findUser(ctx, *ref)
+	if err != nil {
+		return err
+	}
+	if _, err := env.users.Update(ctx, types.UpdateUserParams{ID: user.ID, Role: &role}); err != nil {
+		return err
+	}
+	fmt.Printf("Role of %s changed: %s -> %s\n", userLabel(user), user.Role, role)
+	return nil
+}
+
+// adminUsers выводит таблицу пользователей; флаги соответствуют полям types.ListUsersParams
+func adminUsers(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("users", flag.ContinueOnError)
+	limit := flags.Int("limit", 20, "page size, 1-100")
+	offset := flags.Int("offset", 0, "number of users to skip")
+	roleName := flags.String("role", "", "only users with the role")
+	email := flags.String("email", "", "email contains")
+	username := flags.String("username", "", "username contains")
+	search := flags.String("q", "", "email or username contains")
+	deleted := flags.Bool("deleted", false, "include soft-deleted users")
+	orderBy := flags.String("order-by", "created_at", "sort field: created_at, updated_at, email, username or role")
+	order := flags.String("order", "DESC", "sort direction: ASC or DESC")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	params := types.ListUsersParams{
+		Limit:          *limit,
+		Offset:         *offset,
+		IncludeDeleted: *deleted,
+		OrderBy:        *orderBy,
+		Order:          *order,
+	}
+	if *roleName != "" {
+		role, err := types.RoleFromString(*roleName)
+		if err != nil {
+			return err
+		}
+		params.Role = &role
+	}
+	if *email != "" {
+		params.Email = email
+	}
+	if *username != "" {
+		params.Username = username
+	}
+	if *search != "" {
+		params.SearchQuery = search
+	}
+
+	env, err := openAdmin(ctx)
+	if err != nil {
+		return err
+	}
+	defer env.close()
+
+	page, err := env.users.List(ctx, params)
+	if err != nil {
+		return err
+	}
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "ID\tEMAIL\tPHONE\tUSERNAME\tROLE\tVERIFIED\tCREATED")
+	for _, u := range page.Data {
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%t\t%s\n",
+			u.ID, dash(u.Email), dash(u.Phone), u.Username, u.Role,
+			u.EmailVerified || u.PhoneVerified, u.CreatedAt.Format(time.DateTime),
+		)
+	}
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	fmt.Printf("%d-%d of %d\n", min(params.Offset+1, page.Total), params.Offset+len(page.Data), page.Total)
+	return nil
+}
+
+// adminRestore восстанавливает мягко удаленного пользователя. Удаленные не ищутся
+// по email, поэтому нужен ID: его показывает server admin users -deleted.
+func adminRestore(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("restore", flag.ContinueOnError)
+	id := flags.String("id", "", "ID of the deleted user")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	env, err := openAdmin(ctx)
+	if err != nil {
+		return err
+	}
+	defer env.close()
+
+	if err := env.users.Restore(ctx, *id); err != nil {
+		return err
+	}
+	user, err := env.users.GetByID(ctx, *id)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Restored %s\n", userLabel(user))
+	return nil
+}
+
+// adminRevokeSessions завершает все сессии браузера пользователя.
+// API-токены не отзываются: ими управляет владелец в личном кабинете.
+func adminRevokeSessions(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("revoke-sessions", flag.ContinueOnError)
+	ref := flags.String("user", "", "user ID or email")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	env, err := openAdmin(ctx)
+	if err != nil {
+		return err
+	}
+	defer env.close()
+
+	user, err := env.findUser(ctx, *ref)
+	if err != nil {
+		return err
+	}
+	return revokeSessions(ctx, env, user)
+}
+
+// adminUnlockAccount снимает временную блокировку входа по паролю. Блокировка привязана к email,
+// а не к учетной записи, поэтому пользователь не ищется. Команда видит только счетчики в базе:
+// при LOGIN_ATTEMPTS_BACKEND=memory они живут в процессе сервера, и блокировку снимает
+// администратор запросом POST /api/v1/admin/sign-in/unlock.
+func adminUnlockAccount(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("unlock-account", flag.ContinueOnError)
+	email := flags.String("email", "", "email of the locked account")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	env, err := openAdmin(ctx)
+	if err != nil {
+		return err
+	}
+	defer env.close()
+
+	if env.loginAttempts != "postgres" {
+		return fmt.Errorf("failed sign-ins are counted in server memory (LOGIN_ATTEMPTS_BACKEND=%s): unlock with POST /api/v1/admin/sign-in/unlock", env.loginAttempts)
+	}
+	if err := env.users.UnlockAccount(ctx, strings.TrimSpace(*email)); err != nil {
+		return err
+	}
+	fmt.Printf("Unlocked %s\n", strings.TrimSpace(*email))
+	return nil
+}
+
+// adminResetTwoFactor удаляет аутентификатор и коды восстановления пользователя, потерявшего устройство.
+// Если роль требует 2FA, при следующем входе пользователь подключит новый аутентификатор.
+func adminResetTwoFactor(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("reset-2fa", flag.ContinueOnError)
+	ref := flags.String("user", "", "user ID or email")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	env, err := openAdmin(ctx)
+	if err != nil {
+		return err
+	}
+	defer env.close()
+
+	user, err := env.findUser(ctx, *ref)
+	if err != nil {
+		return err
+	}
+	if err := env.twoFactor.Reset(ctx, user.ID); err != nil {
+		return err
+	}
+	fmt.Printf("Two-factor authentication of %s reset\n", userLabel(user))
+	return nil
+}
+
+func revokeSessions(ctx context.Context, env *adminEnv, user *types.PublicUser) error {
+	n, err := env.sessions.RevokeAll(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Revoked %d sessions of %s\n", n, userLabel(user))
+	return nil
+}
+
+// userLabel возвращает email пользователя, а для вошедших по телефону - телефон
+func userLabel(user *types.PublicUser) string {
+	if user.Email != "" {
+		return user.Email
+	}
+	return user.Phone
+}
+
+func dash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+// prompt выводит вопрос в stderr и читает строку ответа
+func prompt(label string) (string, error) {
+	fmt.Fprint(os.Stderr, label+": ")
+	line, err := stdin.ReadString('\n')
+	if err != nil && (!errors.Is(err, io.EOF) || line == "") {
+		return "", fmt.Errorf("failed to read %s: %w", strings.ToLower(label), err)
+	}
+	return strings.TrimSpace(line), nil
+}
+
+// promptPassword читает пароль без отображения на экране и просит повторить его.
+// Если stdin не терминал, пароль читается первой строкой ввода без подтверждения:
+// так его можно передать через pipe в скриптах.
+func promptPassword() (string, error) {
+	fd := int(os.Stdin.Fd())
+	if !term.IsTerminal(fd) {
+		line, err := stdin.ReadString('\n')
+		if err != nil && !errors.Is(err, io.EOF) {
+			return "", fmt.Errorf("failed to read password: %w", err)
+		}
+		password := strings.TrimRight(line, "\r\n")
+		if password == "" {
+			return "", errors.New("password is required")
+		}
+		return password, nil
+	}
+	read := func(label string) ([]byte, error) {
+		fmt.Fprint(os.Stderr, label+": ")
+		defer fmt.Fprintln(os.Stderr)
+		return term.ReadPassword(fd)
+	}
+	password, err := read("Password")
+	if err != nil {
+		return "", fmt.Errorf("failed to read password: %w", err)
+	}
+	if len(password) == 0 {
+		return "", errors.New("password is required")
+	}
+	repeated, err := read("Repeat password")
+	if err != nil {
+		return "", fmt.Errorf("failed to read password: %w", err)
+	}
+	if !bytes.Equal(password, repeated) {
+		return "", errors.New("passwords do not match")
+	}
+	return string(password), nil
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/LigeronAhill/luxcarpets-go/pkg/config"
+)
+
+// healthcheckTimeout ограничивает проверку: Docker сам повторяет ее по расписанию
+const healthcheckTimeout = 3 * time.Second
+
+// healthcheck запрашивает /healthz сервера, запущенного в этом же контейнере.
+// Образ не содержит curl и wget, поэтому проверку выполняет сам бинарный файл.
+func healthcheck(ctx context.Context) error {
+	cfg, err := config.InitServer()
+	if err != nil {
+		return err
+	}
+	_, port, err := net.SplitHostPort(cfg.Address)
+	if err != nil {
+		return fmt.Errorf("invalid SERVER_ADDRESS: %w", err)
+	}
+	ctx, cancel := context.WithTimeout(ctx, healthcheckTimeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://"+net.JoinHostPort("127.0.0.1", port)+"/healthz", nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("healthz returned %s", resp.Status)
+	}
+	return nil
+}
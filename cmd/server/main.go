@@ -1,15 +1,45 @@
+// Сервер интернет-магазина LuxCarpets.
+//
+// Команды:
+//
+//	server              запуск HTTP-сервера (по умолчанию)
+//	server migrate      применение миграций базы данных и выход
+//	server seed         заполнение базы данными для разработки (server seed -h - параметры)
+//	server admin        операции сопровождения: пользователи, роли, сессии (server admin -h - команды)
+//	server healthcheck  проверка /healthz запущенного сервера (HEALTHCHECK образа Docker)
 package main
 
 import (
 	"context"
+	"fmt"
 	"log/slog"
 	"os"
 )
 
 func main() {
 	ctx := context.Background()
-	if err := run(ctx); err != nil {
-		slog.Error("Error running server", slog.String("error", err.Error()))
+	command := "serve"
+	if len(os.Args) > 1 {
+		command = os.Args[1]
+	}
+	var err error
+	switch command {
+	case "serve":
+		err = run(ctx)
+	case "migrate":
+		err = migrate(ctx)
+	case "seed":
+		err = seed(ctx, os.Args[2:])
+	case "admin":
+		err = admin(ctx, os.Args[2:])
+	case "healthcheck":
+		err = healthcheck(ctx)
+	default:
+		fmt.Fprintf(os.Stderr, "unknown command %q: expected serve, migrate, seed, admin or healthcheck\n", command)
+		os.Exit(2)
+	}
+	if err != nil {
+		slog.Error("Error running "+command, slog.String("error", err.Error()))
 		os.Exit(1)
 	}
 }
@@ -0,0 +1,28 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/LigeronAhill/luxcarpets-go/internal/database"
+	"github.com/LigeronAhill/luxcarpets-go/pkg/config"
+	"github.com/LigeronAhill/luxcarpets-go/pkg/logger"
+)
+
+// migrate применяет миграции базы данных. Запускается отдельно от сервера: в docker compose -
+// одноразовым сервисом перед запуском сервера, при развертывании - перед обновлением реплик.
+func migrate(ctx context.Context) error {
+	logger.Init(logger.INFO)
+	cfg, err := config.InitDatabase()
+	if err != nil {
+		slog.Error("Failed to load config", slog.String("error", err.Error()))
+		return err
+	}
+	// База может запускаться одновременно с командой: ждем ее так же, как сервер
+	pool, err := database.NewPool(ctx, cfg.URL, cfg.ConnectTimeout)
+	if err != nil {
+		return err
+	}
+	pool.Close()
+	return database.Migrate(ctx, cfg.URL)
+}
@@ -2,12 +2,29 @@ package main
 
 import (
 	"context"
+	"fmt"
 	"log/slog"
+	"net/url"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
 
 	"github.com/LigeronAhill/luxcarpets-go/internal/database"
+	"github.com/LigeronAhill/luxcarpets-go/internal/database/types"
+	"github.com/LigeronAhill/luxcarpets-go/internal/metrics"
+	"github.com/LigeronAhill/luxcarpets-go/internal/server"
 	"github.com/LigeronAhill/luxcarpets-go/internal/service"
+	"github.com/LigeronAhill/luxcarpets-go/internal/tracing"
+	"github.com/LigeronAhill/luxcarpets-go/internal/web/seo"
+	"github.com/LigeronAhill/luxcarpets-go/internal/web/static"
+	"github.com/LigeronAhill/luxcarpets-go/pkg/blob"
 	"github.com/LigeronAhill/luxcarpets-go/pkg/config"
+	"github.com/LigeronAhill/luxcarpets-go/pkg/encryption"
+	"github.com/LigeronAhill/luxcarpets-go/pkg/imaging"
 	"github.com/LigeronAhill/luxcarpets-go/pkg/logger"
+	"github.com/LigeronAhill/luxcarpets-go/pkg/mailer"
+	"github.com/LigeronAhill/luxcarpets-go/pkg/sms"
 )
 
 func run(ctx context.Context) error {
@@ -24,9 +41,362 @@ func run(ctx context.Context) error {
 	customLogger := logger.Init(level)
 	slog.Info("Starting server", slog.String("level", level.String()))
 	customLogger.Debug("DEBUG")
-	pool := database.NewPool(ctx, cfg.DatabaseSettings.URL)
+	shutdownTracing, err := tracing.Init(ctx, tracing.Config{
+		Exporter:    cfg.TracingSettings.Exporter,
+		Endpoint:    cfg.TracingSettings.Endpoint,
+		ServiceName: cfg.TracingSettings.ServiceName,
+		Environment: environment,
+		SampleRatio: cfg.TracingSettings.SampleRatio,
+	})
+	if err != nil {
+		return err
+	}
+	defer func() {
+		// Контекст запуска к этому моменту отменен: отправка оставшихся span ограничена отдельно
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), cfg.ServerSettings.ShutdownTimeout)
+		defer cancel()
+		if err := shutdownTracing(ctx); err != nil {
+			slog.Error("Failed to flush traces", slog.String("error", err.Error()))
+		}
+	}()
+	pool, err := database.NewPool(ctx, cfg.DatabaseSettings.URL, cfg.DatabaseSettings.ConnectTimeout)
+	if err != nil {
+		return err
+	}
 	defer pool.Close()
+	metrics.Registry.MustRegister(metrics.NewPoolCollector(pool))
+	latestMigration, err := database.LatestMigration()
+	if err != nil {
+		return err
+	}
 	usersSorage := database.NewUsersStorage(pool)
-	service.NewUsersService(usersSorage)
-	return nil
+	loginGuard, err := newLoginGuard(cfg.AuthSettings, pool)
+	if err != nil {
+		return err
+	}
+	rateLimiter, rateLimits, err := newRateLimiter(cfg.RateLimitSettings, pool)
+	if err != nil {
+		return err
+	}
+	passwordPolicy, err := newPasswordPolicy(cfg.PasswordSettings)
+	if err != nil {
+		return err
+	}
+	argon2Params, err := newArgon2Params(cfg.PasswordSettings)
+	if err != nil {
+		return err
+	}
+	twoFactorService, err := newTwoFactorService(cfg.TwoFactorSettings, pool, usersSorage)
+	if err != nil {
+		return err
+	}
+	phoneOTPService, err := newPhoneOTPService(cfg.SMSSettings, pool)
+	if err != nil {
+		return err
+	}
+	magicLinkService, err := newMagicLinkService(cfg.MailSettings, cfg.ServerSettings.PublicBaseURL, pool, usersSorage)
+	if err != nil {
+		return err
+	}
+	usersOptions := []service.UsersServiceOption{
+		service.WithLoginGuard(loginGuard),
+		service.WithPasswordPolicy(passwordPolicy),
+		service.WithArgon2Params(argon2Params),
+		service.WithTwoFactor(twoFactorService),
+	}
+	if phoneOTPService != nil {
+		usersOptions = append(usersOptions, service.WithPhoneOTP(phoneOTPService))
+	}
+	if magicLinkService != nil {
+		usersOptions = append(usersOptions, service.WithMagicLinks(magicLinkService))
+	}
+	usersService := service.NewUsersService(usersSorage, usersOptions...)
+	apiTokensService := service.NewAPITokensService(database.NewAPITokensStorage(pool), usersSorage)
+	profileService := service.NewProfileService(usersSorage, database.NewProfileStorage(pool), database.NewAddressesStorage(pool))
+
+	sessionsService := service.NewSessionsService(database.NewSessionsStorage(pool), usersSorage, cfg.AuthSettings.SessionTTL)
+
+	blobStorage, err := newBlobStorage(cfg.BlobSettings)
+	if err != nil {
+		return err
+	}
+	avatarService := service.NewAvatarService(usersService, blobStorage, imaging.JPEGEncoder{Quality: cfg.AvatarSettings.Quality}, service.AvatarConfig{
+		MaxBytes:  cfg.AvatarSettings.MaxBytes,
+		MaxPixels: cfg.AvatarSettings.MaxPixels,
+	})
+	mediaService := service.NewMediaService(database.NewMediaStorage(pool), blobStorage, imaging.JPEGEncoder{Quality: cfg.MediaSettings.Quality}, service.MediaConfig{
+		MaxBytes:  cfg.MediaSettings.MaxBytes,
+		MaxPixels: cfg.MediaSettings.MaxPixels,
+		Widths:    cfg.MediaSettings.Widths,
+	})
+
+	staticAssets, err := static.Load()
+	if err != nil {
+		return err
+	}
+	csrfKey, err := encryption.ParseKey(cfg.AuthSettings.CSRFKey)
+	if err != nil {
+		return fmt.Errorf("invalid CSRF_KEY: %w", err)
+	}
+
+	serverConfig := server.Config{
+		Address:         cfg.ServerSettings.Address,
+		ShutdownTimeout: cfg.ServerSettings.ShutdownTimeout,
+		SecureCookies:   environment == "production",
+		Production:      environment == "production",
+		// Запас сверх размера самого большого загружаемого файла на заголовки multipart
+		BodyLimit:      int(max(avatarService.MaxBytes(), mediaService.MaxBytes())) + 64<<10,
+		Assets:         staticAssets,
+		CSRFKey:        csrfKey,
+		CORSOrigins:    cfg.ServerSettings.CORSOrigins,
+		ImageSources:   mediaOrigins(cfg.BlobSettings),
+		RateLimits:     rateLimits,
+		MetricsToken:   cfg.ServerSettings.MetricsToken,
+		ProxyHeader:    cfg.ServerSettings.ProxyHeader,
+		TrustedProxies: cfg.ServerSettings.TrustedProxies,
+		Site: seo.Site{
+			Name:         cfg.ShopSettings.Name,
+			URL:          strings.TrimSuffix(cfg.ServerSettings.PublicBaseURL, "/"),
+			Description:  cfg.ShopSettings.About,
+			Logo:         staticAssets.URL("img/favicon.svg"),
+			Phone:        cfg.ShopSettings.Phone,
+			Email:        cfg.ShopSettings.Email,
+			Address:      cfg.ShopSettings.Address,
+			OpeningHours: cfg.ShopSettings.OpeningHours,
+		},
+	}
+	if environment == "production" {
+		serverConfig.HSTSMaxAge = cfg.ServerSettings.HSTSMaxAge
+	}
+	if local, ok := blobStorage.(*blob.Local); ok {
+		serverConfig.MediaDir = local.Root()
+		serverConfig.MediaURL = cfg.BlobSettings.LocalURL
+	}
+	srv := server.New(serverConfig, server.Services{
+		Users:      usersService,
+		APITokens:  apiTokensService,
+		Profile:    profileService,
+		PhoneOTP:   phoneOTPService,
+		MagicLinks: magicLinkService,
+		TwoFactor:  twoFactorService,
+		Sessions:   sessionsService,
+		Avatars:    avatarService,
+		Media:      mediaService,
+		Catalog:    service.NewCatalogService(database.NewCatalogStorage(pool), blobStorage),
+		Sitemap:    service.NewSitemapService(database.NewSitemapStorage(pool), blobStorage, cfg.ServerSettings.PublicBaseURL),
+		LLMs:       service.NewLLMsService(database.NewCatalogStorage(pool), shopInfo(cfg.ShopSettings), cfg.ServerSettings.PublicBaseURL),
+		RateLimits: rateLimiter,
+		Health:     service.NewHealthService(database.NewHealthStorage(pool), latestMigration),
+	})
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	err = srv.Run(ctx)
+	if magicLinkService != nil {
+		// Письма со ссылками отправляются в фоне: запрошенные до остановки дописываются
+		magicLinkService.Wait()
+	}
+	return err
+}
+
+// shopInfo переносит сведения о магазине из настроек
+func shopInfo(cfg config.ShopSettings) service.ShopInfo {
+	return service.ShopInfo{
+		Name:         cfg.Name,
+		About:        cfg.About,
+		Phone:        cfg.Phone,
+		Email:        cfg.Email,
+		Address:      cfg.Address,
+		OpeningHours: cfg.OpeningHours,
+	}
+}
+
+// mediaOrigins возвращает источник адресов файлов S3 для политики CSP.
+// Файлы локального хранилища раздаются с адреса сайта и отдельного источника не требуют.
+func mediaOrigins(cfg config.BlobSettings) []string {
+	if cfg.Driver != "s3" {
+		return nil
+	}
+	public := cfg.S3PublicURL
+	if public == "" {
+		public = cfg.S3Endpoint
+	}
+	u, err := url.Parse(public)
+	if err != nil || u.Host == "" {
+		return nil
+	}
+	return []string{u.Scheme + "://" + u.Host}
+}
+
+func newTwoFactorService(cfg config.TwoFactorSettings, pool database.PgxPoolIface, users *database.UsersStorage) (*service.TwoFactorService, error) {
+	key, err := encryption.ParseKey(cfg.EncryptionKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid TWO_FACTOR_ENCRYPTION_KEY: %w", err)
+	}
+	var requiredRole types.UserRole
+	if cfg.RequiredRole != "" && cfg.RequiredRole != "none" {
+		requiredRole, err = types.RoleFromString(cfg.RequiredRole)
+		if err != nil {
+			return nil, fmt.Errorf("invalid TWO_FACTOR_REQUIRED_ROLE: %w", err)
+		}
+	}
+	return service.NewTwoFactorService(database.NewTwoFactorStorage(pool), users, service.TwoFactorConfig{
+		EncryptionKey: key,
+		Issuer:        cfg.Issuer,
+		RequiredRole:  requiredRole,
+	})
+}
+
+// newPhoneOTPService создает сервис кодов из SMS. Для SMS_PROVIDER=none возвращает nil - вход по телефону отключен.
+func newPhoneOTPService(cfg config.SMSSettings, pool database.PgxPoolIface) (*service.PhoneOTPService, error) {
+	var sender sms.Sender
+	switch cfg.Provider {
+	case "none":
+		return nil, nil
+	case "log":
+		sender = sms.LogSender{}
+	case "smsru":
+		if cfg.SMSRuAPIID == "" {
+			return nil, fmt.Errorf("SMSRU_API_ID is required for sms provider smsru")
+		}
+		sender = sms.NewSMSRu(cfg.SMSRuAPIID, sms.WithSMSRuSender(cfg.Sender))
+	default:
+		return nil, fmt.Errorf("unknown sms provider: %s", cfg.Provider)
+	}
+	key, err := encryption.ParseKey(cfg.OTPKey)
+	if err != nil {
+		return nil, fmt.Errorf("invalid PHONE_OTP_KEY: %w", err)
+	}
+	otpConfig := service.DefaultPhoneOTPConfig
+	otpConfig.Key = key
+	return service.NewPhoneOTPService(database.NewPhoneOTPStorage(pool), sender, otpConfig)
+}
+
+// newMagicLinkService создает сервис ссылок для входа по email. Для MAIL_PROVIDER=none возвращает nil -
+// вход по ссылке из письма отключен.
+func newMagicLinkService(cfg config.MailSettings, baseURL string, pool database.PgxPoolIface, users *database.UsersStorage) (*service.MagicLinkService, error) {
+	var sender mailer.Sender
+	switch cfg.Provider {
+	case "none":
+		return nil, nil
+	case "log":
+		sender = mailer.LogSender{}
+	case "smtp":
+		if cfg.SMTPHost == "" {
+			return nil, fmt.Errorf("SMTP_HOST is required for mail provider smtp")
+		}
+		smtpSender, err := mailer.NewSMTP(cfg.SMTPHost, cfg.SMTPPort, cfg.SMTPUsername, cfg.SMTPPassword, cfg.From)
+		if err != nil {
+			return nil, fmt.Errorf("invalid MAIL_FROM: %w", err)
+		}
+		sender = smtpSender
+	default:
+		return nil, fmt.Errorf("unknown mail provider: %s", cfg.Provider)
+	}
+	linkConfig := service.DefaultMagicLinkConfig
+	linkConfig.BaseURL = baseURL
+	linkConfig.TTL = cfg.MagicLinkTTL
+	return service.NewMagicLinkService(database.NewMagicLinksStorage(pool), users, sender, linkConfig)
+}
+
+// newBlobStorage создает хранилище файлов. Файлы локального хранилища раздает HTTP-сервер.
+func newBlobStorage(cfg config.BlobSettings) (blob.Storage, error) {
+	switch cfg.Driver {
+	case "local":
+		return blob.NewLocal(cfg.LocalDir, cfg.LocalURL)
+	case "s3":
+		return blob.NewS3(blob.S3Config{
+			Endpoint:  cfg.S3Endpoint,
+			Region:    cfg.S3Region,
+			Bucket:    cfg.S3Bucket,
+			AccessKey: cfg.S3AccessKey,
+			SecretKey: cfg.S3SecretKey,
+			PublicURL: cfg.S3PublicURL,
+		})
+	default:
+		return nil, fmt.Errorf("unknown blob driver: %s", cfg.Driver)
+	}
+}
+
+func newPasswordPolicy(cfg config.PasswordSettings) (service.PasswordPolicy, error) {
+	policy := service.PasswordPolicy{
+		MinLength:      cfg.MinLength,
+		MaxLength:      cfg.MaxLength,
+		RequireUpper:   cfg.RequireUpper,
+		RequireLower:   cfg.RequireLower,
+		RequireDigit:   cfg.RequireDigit,
+		RequireSpecial: cfg.RequireSpecial,
+	}
+	if cfg.CheckBreached {
+		policy.Breached = service.BundledBreachedPasswords()
+		if cfg.BreachedFile != "" {
+			list, err := service.LoadBreachedPasswordsFile(cfg.BreachedFile)
+			if err != nil {
+				return policy, err
+			}
+			policy.Breached = list
+		}
+	}
+	return policy, policy.Check()
+}
+
+// newArgon2Params возвращает параметры хеширования новых паролей из настроек
+func newArgon2Params(cfg config.PasswordSettings) (service.Argon2Params, error) {
+	params := service.Argon2Params{
+		MemoryKB:    cfg.Argon2MemoryKB,
+		Iterations:  cfg.Argon2Iterations,
+		Parallelism: cfg.Argon2Parallelism,
+	}
+	return params, params.Validate()
+}
+
+func newLoginGuard(cfg config.AuthSettings, pool database.PgxPoolIface) (*service.LoginGuard, error) {
+	var store service.LoginAttemptsStore
+	switch cfg.LoginAttemptsBackend {
+	case "memory":
+		store = service.NewMemoryLoginAttemptsStore()
+	case "postgres":
+		store = database.NewLoginAttemptsStorage(pool)
+	default:
+		return nil, fmt.Errorf("unknown login attempts backend: %s", cfg.LoginAttemptsBackend)
+	}
+	account := service.DefaultAccountLockoutPolicy
+	account.MaxFailures = cfg.LoginMaxFailures
+	account.LockoutDuration = cfg.LoginLockout
+	ip := service.DefaultIPLockoutPolicy
+	ip.MaxFailures = cfg.LoginIPMaxFailures
+	ip.LockoutDuration = cfg.LoginLockout
+	return service.NewLoginGuard(store, account, ip), nil
+}
+
+// newRateLimiter создает ограничитель частоты запросов и разбирает политики групп маршрутов
+func newRateLimiter(cfg config.RateLimitSettings, pool database.PgxPoolIface) (*service.RateLimiter, server.RateLimits, error) {
+	var limits server.RateLimits
+	var store service.RateLimitStore
+	switch cfg.Backend {
+	case "memory":
+		store = service.NewMemoryRateLimitStore()
+	case "postgres":
+		store = database.NewRateLimitsStorage(pool)
+	default:
+		return nil, limits, fmt.Errorf("unknown rate limit backend: %s", cfg.Backend)
+	}
+	policies := []struct {
+		name   string
+		env    string
+		value  string
+		target *service.RateLimit
+	}{
+		{"otp", "RATE_LIMIT_OTP", cfg.OTP, &limits.OTP},
+		{"auth", "RATE_LIMIT_AUTH", cfg.Auth, &limits.Auth},
+		{"search", "RATE_LIMIT_SEARCH", cfg.Search, &limits.Search},
+		{"api", "RATE_LIMIT_API", cfg.API, &limits.API},
+	}
+	for _, p := range policies {
+		limit, err := service.ParseRateLimit(p.name, p.value)
+		if err != nil {
+			return nil, limits, fmt.Errorf("invalid %s: %w", p.env, err)
+		}
+		*p.target = limit
+	}
+	return service.NewRateLimiter(store), limits, nil
 }
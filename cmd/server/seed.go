@@ -0,0 +1,94 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+
+	"github.com/LigeronAhill/luxcarpets-go/internal/database"
+	"github.com/LigeronAhill/luxcarpets-go/internal/service"
+	"github.com/LigeronAhill/luxcarpets-go/pkg/config"
+	"github.com/LigeronAhill/luxcarpets-go/pkg/imaging"
+	"github.com/LigeronAhill/luxcarpets-go/pkg/logger"
+)
+
+// seedPassword - пароль пользователей по умолчанию; проходит политику паролей по умолчанию.
+// Используется только в окружении development: пароль опубликован в README.
+const seedPassword = "LuxCarpets-Dev-2026!"
+
+// seed заполняет базу данными для разработки (см. service.SeedService). Миграции должны быть применены.
+// В окружении production команда отказывается работать без -force, а вне development
+// пароль пользователей нужно задать явно.
+//
+//	server seed                     пользователи и 500 товаров
+//	server seed -products 100000    каталог для нагрузочных тестов
+func seed(ctx context.Context, args []string) error {
+	flags := flag.NewFlagSet("seed", flag.ContinueOnError)
+	products := flags.Int("products", service.DefaultSeedProducts, "number of catalog products")
+	password := flags.String("password", "", "password of the seeded users (required outside development)")
+	force := flags.Bool("force", false, "seed a production database")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	logger.Init(logger.INFO)
+	cfg, err := config.Init("")
+	if err != nil {
+		slog.Error("Failed to load config", slog.String("error", err.Error()))
+		return err
+	}
+	if cfg.Environment == "production" && !*force {
+		return errors.New("refusing to seed a production database: pass -force to continue")
+	}
+	if *password == "" {
+		if cfg.Environment != "development" {
+			return fmt.Errorf("-password is required in %s environment", cfg.Environment)
+		}
+		*password = seedPassword
+	}
+	pool, err := database.NewPool(ctx, cfg.DatabaseSettings.URL, cfg.DatabaseSettings.ConnectTimeout)
+	if err != nil {
+		return err
+	}
+	defer pool.Close()
+
+	passwordPolicy, err := newPasswordPolicy(cfg.PasswordSettings)
+	if err != nil {
+		return err
+	}
+	argon2Params, err := newArgon2Params(cfg.PasswordSettings)
+	if err != nil {
+		return err
+	}
+	usersService := service.NewUsersService(database.NewUsersStorage(pool),
+		service.WithPasswordPolicy(passwordPolicy),
+		service.WithArgon2Params(argon2Params),
+	)
+	blobStorage, err := newBlobStorage(cfg.BlobSettings)
+	if err != nil {
+		return err
+	}
+	mediaService := service.NewMediaService(database.NewMediaStorage(pool), blobStorage, imaging.JPEGEncoder{Quality: cfg.MediaSettings.Quality}, service.MediaConfig{
+		MaxBytes:  cfg.MediaSettings.MaxBytes,
+		MaxPixels: cfg.MediaSettings.MaxPixels,
+		Widths:    cfg.MediaSettings.Widths,
+	})
+
+	report, err := service.NewSeedService(usersService, mediaService, database.NewSeedStorage(pool)).Run(ctx, service.SeedConfig{
+		Products: *products,
+		Password: *password,
+	})
+	if err != nil {
+		return err
+	}
+	slog.Info("Database seeded",
+		slog.Int("users", report.Users),
+		slog.Int("images", report.Images),
+		slog.Int64("products", report.Products),
+		slog.Int64("variants", report.Variants),
+		slog.Int64("gallery", report.Gallery),
+	)
+	return nil
+}
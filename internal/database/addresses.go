@@ -0,0 +1,258 @@
+package database
+
+import (
+	"context"
+	"errors"
+
+	"github.com/LigeronAhill/luxcarpets-go/internal/database/types"
+	"github.com/LigeronAhill/luxcarpets-go/pkg/utils"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// AddressesStorage хранит адреса доставки пользователей.
+// У пользователя с адресами всегда ровно один адрес по умолчанию.
+type AddressesStorage struct {
+	pool PgxPoolIface
+}
+
+func NewAddressesStorage(pool PgxPoolIface) *AddressesStorage {
+	return &AddressesStorage{
+		pool: pool,
+	}
+}
+
+// ListByUser возвращает адреса пользователя: сначала адрес по умолчанию, затем новые
+func (s *AddressesStorage) ListByUser(ctx context.Context, userID uuid.UUID) ([]*types.Address, error) {
+	op := "list addresses for user " + userID.String()
+	query := `
+		SELECT * FROM user_addresses
+		WHERE user_id = @user_id
+		ORDER BY is_default DESC, created_at DESC
+	`
+	args := pgx.NamedArgs{
+		"user_id": userID,
+	}
+	rows, err := s.pool.Query(ctx, query, args)
+	if err != nil {
+		return nil, utils.Wrap(op, err)
+	}
+	defer rows.Close()
+	res, err := pgx.CollectRows(rows, pgx.RowToAddrOfStructByName[types.Address])
+	if err != nil {
+		return nil, utils.Wrap(op, err)
+	}
+	return res, nil
+}
+
+// Count возвращает количество адресов пользователя
+func (s *AddressesStorage) Count(ctx context.Context, userID uuid.UUID) (int, error) {
+	op := "count addresses for user " + userID.String()
+	query := `
+		SELECT COUNT(*) FROM user_addresses WHERE user_id = @user_id
+	`
+	args := pgx.NamedArgs{
+		"user_id": userID,
+	}
+	var total int
+	if err := s.pool.QueryRow(ctx, query, args).Scan(&total); err != nil {
+		return 0, utils.Wrap(op, err)
+	}
+	return total, nil
+}
+
+// Create сохраняет новый адрес. Первый адрес пользователя всегда становится адресом по умолчанию.
+func (s *AddressesStorage) Create(ctx context.Context, userID uuid.UUID, params types.AddressParams) (*types.Address, error) {
+	op := "create address for user " + userID.String()
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return nil, utils.Wrap(op, err)
+	}
+	defer tx.Rollback(ctx)
+
+	if params.IsDefault {
+		if err := clearDefaultAddress(ctx, tx, userID); err != nil {
+			return nil, utils.Wrap(op, err)
+		}
+	}
+	query := `
+		INSERT INTO user_addresses (
+		    user_id, label, recipient_name, recipient_phone, postal_code,
+		    region, city, street, house, building, apartment,
+		    entrance, intercom, floor, elevator, comment, is_default
+		)
+		VALUES (
+		    @user_id, @label, @recipient_name, @recipient_phone, @postal_code,
+		    @region, @city, @street, @house, @building, @apartment,
+		    @entrance, @intercom, @floor, @elevator, @comment,
+		    @is_default OR NOT EXISTS (SELECT 1 FROM user_addresses WHERE user_id = @user_id)
+		)
+		RETURNING *
+	`
+	rows, err := tx.Query(ctx, query, addressArgs(userID, params))
+	if err != nil {
+		return nil, utils.Wrap(op, err)
+	}
+	res, err := pgx.CollectExactlyOneRow(rows, pgx.RowToAddrOfStructByName[types.Address])
+	if err != nil {
+		return nil, utils.Wrap(op, err)
+	}
+	return res, utils.Wrap(op, tx.Commit(ctx))
+}
+
+// Update полностью заменяет поля адреса.
+// Снять отметку "по умолчанию" нельзя - только назначить другой адрес через SetDefault.
+// Возвращает nil, если адрес не найден или принадлежит другому пользователю.
+func (s *AddressesStorage) Update(ctx context.Context, userID, addressID uuid.UUID, params types.AddressParams) (*types.Address, error) {
+	op := "update address " + addressID.String()
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return nil, utils.Wrap(op, err)
+	}
+	defer tx.Rollback(ctx)
+
+	if params.IsDefault {
+		if err := clearDefaultAddress(ctx, tx, userID); err != nil {
+			return nil, utils.Wrap(op, err)
+		}
+	}
+	query := `
+		UPDATE user_addresses
+		SET
+		    label = @label,
+		    recipient_name = @recipient_name,
+		    recipient_phone = @recipient_phone,
+		    postal_code = @postal_code,
+		    region = @region,
+		    city = @city,
+		    street = @street,
+		    house = @house,
+		    building = @building,
+		    apartment = @apartment,
+		    entrance = @entrance,
+		    intercom = @intercom,
+		    floor = @floor,
+		    elevator = @elevator,
+		    comment = @comment,
+		    is_default = is_default OR @is_default
+		WHERE id = @id AND user_id = @user_id
+		RETURNING *
+	`
+	args := addressArgs(userID, params)
+	args["id"] = addressID
+	rows, err := tx.Query(ctx, query, args)
+	if err != nil {
+		return nil, utils.Wrap(op, err)
+	}
+	res, err := pgx.CollectExactlyOneRow(rows, pgx.RowToAddrOfStructByName[types.Address])
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, utils.Wrap(op, err)
+	}
+	return res, utils.Wrap(op, tx.Commit(ctx))
+}
+
+// SetDefault делает адрес адресом по умолчанию.
+// Возвращает false, если адрес не найден или принадлежит другому пользователю.
+func (s *AddressesStorage) SetDefault(ctx context.Context, userID, addressID uuid.UUID) (bool, error) {
+	op := "set default address " + addressID.String()
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return false, utils.Wrap(op, err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := clearDefaultAddress(ctx, tx, userID); err != nil {
+		return false, utils.Wrap(op, err)
+	}
+	query := `
+		UPDATE user_addresses SET is_default = TRUE WHERE id = @id AND user_id = @user_id
+	`
+	args := pgx.NamedArgs{
+		"id":      addressID,
+		"user_id": userID,
+	}
+	res, err := tx.Exec(ctx, query, args)
+	if err != nil {
+		return false, utils.Wrap(op, err)
+	}
+	if res.RowsAffected() == 0 {
+		return false, nil
+	}
+	return true, utils.Wrap(op, tx.Commit(ctx))
+}
+
+// Delete удаляет адрес. Если удален адрес по умолчанию, им становится самый новый из оставшихся.
+// Возвращает false, если адрес не найден или принадлежит другому пользователю.
+func (s *AddressesStorage) Delete(ctx context.Context, userID, addressID uuid.UUID) (bool, error) {
+	op := "delete address " + addressID.String()
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return false, utils.Wrap(op, err)
+	}
+	defer tx.Rollback(ctx)
+
+	args := pgx.NamedArgs{
+		"id":      addressID,
+		"user_id": userID,
+	}
+	var wasDefault bool
+	query := `
+		DELETE FROM user_addresses WHERE id = @id AND user_id = @user_id RETURNING is_default
+	`
+	if err := tx.QueryRow(ctx, query, args).Scan(&wasDefault); err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return false, nil
+		}
+		return false, utils.Wrap(op, err)
+	}
+	if wasDefault {
+		query := `
+			UPDATE user_addresses SET is_default = TRUE
+			WHERE id = (
+			    SELECT id FROM user_addresses
+			    WHERE user_id = @user_id
+			    ORDER BY created_at DESC
+			    LIMIT 1
+			)
+		`
+		if _, err := tx.Exec(ctx, query, pgx.NamedArgs{"user_id": userID}); err != nil {
+			return false, utils.Wrap(op, err)
+		}
+	}
+	return true, utils.Wrap(op, tx.Commit(ctx))
+}
+
+// clearDefaultAddress снимает отметку "по умолчанию" со всех адресов пользователя
+func clearDefaultAddress(ctx context.Context, tx pgx.Tx, userID uuid.UUID) error {
+	query := `
+		UPDATE user_addresses SET is_default = FALSE WHERE user_id = @user_id AND is_default
+	`
+	_, err := tx.Exec(ctx, query, pgx.NamedArgs{"user_id": userID})
+	return err
+}
+
+// addressArgs формирует именованные аргументы запроса из полей адреса
+func addressArgs(userID uuid.UUID, params types.AddressParams) pgx.NamedArgs {
+	return pgx.NamedArgs{
+		"user_id":         userID,
+		"label":           params.Label,
+		"recipient_name":  params.RecipientName,
+		"recipient_phone": params.RecipientPhone,
+		"postal_code":     params.PostalCode,
+		"region":          params.Region,
+		"city":            params.City,
+		"street":          params.Street,
+		"house":           params.House,
+		"building":        params.Building,
+		"apartment":       params.Apartment,
+		"entrance":        params.Entrance,
+		"intercom":        params.Intercom,
+		"floor":           params.Floor,
+		"elevator":        params.Elevator,
+		"comment":         params.Comment,
+		"is_default":      params.IsDefault,
+	}
+}
@@ -0,0 +1,171 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/LigeronAhill/luxcarpets-go/internal/database/types"
+	"github.com/google/uuid"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var addressColumns = []string{
+	"id", "user_id", "label", "recipient_name", "recipient_phone", "postal_code",
+	"region", "city", "street", "house", "building", "apartment",
+	"entrance", "intercom", "floor", "elevator", "comment", "is_default",
+	"created_at", "updated_at",
+}
+
+func testAddressParams() types.AddressParams {
+	floor := int16(7)
+	return types.AddressParams{
+		RecipientName:  "Иванов Иван",
+		RecipientPhone: "+79123456789",
+		Region:         "Московская область",
+		City:           "Химки",
+		Street:         "Ленинградская",
+		House:          "12",
+		Floor:          &floor,
+		Elevator:       types.ElevatorFreight,
+		IsDefault:      true,
+	}
+}
+
+func TestAddressesStorage_Create_Default(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	storage := NewAddressesStorage(mock)
+	userID := uuid.New()
+	addressID := uuid.New()
+	params := testAddressParams()
+	now := time.Now()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE user_addresses SET is_default = FALSE WHERE user_id = @user_id AND is_default`).
+		WithArgs(userID).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	mock.ExpectQuery(`INSERT INTO user_addresses`).
+		WithArgs(
+			userID, params.Label, params.RecipientName, params.RecipientPhone, params.PostalCode,
+			params.Region, params.City, params.Street, params.House, params.Building, params.Apartment,
+			params.Entrance, params.Intercom, params.Floor, params.Elevator, params.Comment, true,
+		).
+		WillReturnRows(pgxmock.NewRows(addressColumns).AddRow(
+			addressID, userID, nil, params.RecipientName, params.RecipientPhone, nil,
+			params.Region, params.City, params.Street, params.House, nil, nil,
+			nil, nil, params.Floor, params.Elevator, nil, true,
+			now, now,
+		))
+	mock.ExpectCommit()
+
+	res, err := storage.Create(context.Background(), userID, params)
+
+	require.NoError(t, err)
+	assert.Equal(t, addressID, res.ID)
+	assert.True(t, res.IsDefault)
+	assert.Equal(t, types.ElevatorFreight, res.Elevator)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAddressesStorage_Update_NotFound(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	storage := NewAddressesStorage(mock)
+	params := testAddressParams()
+	params.IsDefault = false
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`UPDATE user_addresses
+		SET`).
+		WithArgs(
+			pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(),
+			pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(),
+			pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(),
+			pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(),
+		).
+		WillReturnRows(pgxmock.NewRows(addressColumns))
+	mock.ExpectRollback()
+
+	res, err := storage.Update(context.Background(), uuid.New(), uuid.New(), params)
+
+	assert.NoError(t, err)
+	assert.Nil(t, res)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAddressesStorage_Delete_PromotesNewestAddress(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	storage := NewAddressesStorage(mock)
+	userID := uuid.New()
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`DELETE FROM user_addresses WHERE id = @id AND user_id = @user_id RETURNING is_default`).
+		WithArgs(pgxmock.AnyArg(), userID).
+		WillReturnRows(pgxmock.NewRows([]string{"is_default"}).AddRow(true))
+	mock.ExpectExec(`UPDATE user_addresses SET is_default = TRUE
+			WHERE id = \(`).
+		WithArgs(userID).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	mock.ExpectCommit()
+
+	ok, err := storage.Delete(context.Background(), userID, uuid.New())
+
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAddressesStorage_Delete_NotFound(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	storage := NewAddressesStorage(mock)
+
+	mock.ExpectBegin()
+	mock.ExpectQuery(`DELETE FROM user_addresses`).
+		WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg()).
+		WillReturnRows(pgxmock.NewRows([]string{"is_default"}))
+	mock.ExpectRollback()
+
+	ok, err := storage.Delete(context.Background(), uuid.New(), uuid.New())
+
+	require.NoError(t, err)
+	assert.False(t, ok)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAddressesStorage_SetDefault(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	storage := NewAddressesStorage(mock)
+	userID := uuid.New()
+	addressID := uuid.New()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`UPDATE user_addresses SET is_default = FALSE`).
+		WithArgs(userID).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	mock.ExpectExec(`UPDATE user_addresses SET is_default = TRUE WHERE id = @id AND user_id = @user_id`).
+		WithArgs(addressID, userID).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	mock.ExpectCommit()
+
+	ok, err := storage.SetDefault(context.Background(), userID, addressID)
+
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
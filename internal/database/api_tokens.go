@@ -0,0 +1,142 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/LigeronAhill/luxcarpets-go/internal/database/types"
+	"github.com/LigeronAhill/luxcarpets-go/pkg/utils"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// APITokensStorage хранит персональные токены доступа к API
+type APITokensStorage struct {
+	pool PgxPoolIface
+}
+
+func NewAPITokensStorage(pool PgxPoolIface) *APITokensStorage {
+	return &APITokensStorage{
+		pool: pool,
+	}
+}
+
+// Create сохраняет новый токен
+func (s *APITokensStorage) Create(ctx context.Context, params types.CreateAPITokenParams) (*types.APIToken, error) {
+	op := "create api token for user " + params.UserID.String()
+	query := `
+		INSERT INTO api_tokens (user_id, name, token_prefix, token_hash, scopes, expires_at)
+		VALUES (@user_id, @name, @token_prefix, @token_hash, @scopes, @expires_at)
+		RETURNING *
+	`
+	scopes := make([]string, len(params.Scopes))
+	for i, scope := range params.Scopes {
+		scopes[i] = scope.String()
+	}
+	args := pgx.NamedArgs{
+		"user_id":      params.UserID,
+		"name":         params.Name,
+		"token_prefix": params.TokenPrefix,
+		"token_hash":   params.TokenHash,
+		"scopes":       scopes,
+		"expires_at":   params.ExpiresAt,
+	}
+	rows, err := s.pool.Query(ctx, query, args)
+	if err != nil {
+		return nil, utils.Wrap(op, err)
+	}
+	defer rows.Close()
+	res, err := pgx.CollectExactlyOneRow(rows, pgx.RowToAddrOfStructByName[types.APIToken])
+	if err != nil {
+		return nil, utils.Wrap(op, err)
+	}
+	return res, nil
+}
+
+// GetByHash возвращает токен по хешу или nil, если такого токена нет
+func (s *APITokensStorage) GetByHash(ctx context.Context, tokenHash string) (*types.APIToken, error) {
+	op := "get api token by hash"
+	query := `
+		SELECT * FROM api_tokens WHERE token_hash = @token_hash
+	`
+	args := pgx.NamedArgs{
+		"token_hash": tokenHash,
+	}
+	rows, err := s.pool.Query(ctx, query, args)
+	if err != nil {
+		return nil, utils.Wrap(op, err)
+	}
+	defer rows.Close()
+	res, err := pgx.CollectExactlyOneRow(rows, pgx.RowToAddrOfStructByName[types.APIToken])
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, utils.Wrap(op, err)
+	}
+	return res, nil
+}
+
+// ListByUser возвращает неотозванные токены пользователя, начиная с новых
+func (s *APITokensStorage) ListByUser(ctx context.Context, userID uuid.UUID) ([]*types.APIToken, error) {
+	op := "list api tokens for user " + userID.String()
+	query := `
+		SELECT * FROM api_tokens
+		WHERE user_id = @user_id AND revoked_at IS NULL
+		ORDER BY created_at DESC
+	`
+	args := pgx.NamedArgs{
+		"user_id": userID,
+	}
+	rows, err := s.pool.Query(ctx, query, args)
+	if err != nil {
+		return nil, utils.Wrap(op, err)
+	}
+	defer rows.Close()
+	res, err := pgx.CollectRows(rows, pgx.RowToAddrOfStructByName[types.APIToken])
+	if err != nil {
+		return nil, utils.Wrap(op, err)
+	}
+	return res, nil
+}
+
+// Revoke отзывает токен пользователя.
+// Возвращает false, если токен не найден, принадлежит другому пользователю или уже отозван.
+func (s *APITokensStorage) Revoke(ctx context.Context, userID, tokenID uuid.UUID) (bool, error) {
+	op := "revoke api token " + tokenID.String()
+	query := `
+		UPDATE api_tokens
+		SET revoked_at = NOW()
+		WHERE id = @id AND user_id = @user_id AND revoked_at IS NULL
+	`
+	args := pgx.NamedArgs{
+		"id":      tokenID,
+		"user_id": userID,
+	}
+	res, err := s.pool.Exec(ctx, query, args)
+	if err != nil {
+		return false, utils.Wrap(op, err)
+	}
+	return res.RowsAffected() == 1, nil
+}
+
+// TouchLastUsed обновляет время последнего использования токена,
+// если предыдущее значение старше staleBefore. Так частые запросы не создают запись на каждый вызов.
+func (s *APITokensStorage) TouchLastUsed(ctx context.Context, tokenID uuid.UUID, now, staleBefore time.Time) error {
+	op := "touch api token " + tokenID.String()
+	query := `
+		UPDATE api_tokens
+		SET last_used_at = @now
+		WHERE id = @id AND (last_used_at IS NULL OR last_used_at < @stale_before)
+	`
+	args := pgx.NamedArgs{
+		"id":           tokenID,
+		"now":          now,
+		"stale_before": staleBefore,
+	}
+	if _, err := s.pool.Exec(ctx, query, args); err != nil {
+		return utils.Wrap(op, err)
+	}
+	return nil
+}
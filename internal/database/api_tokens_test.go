@@ -0,0 +1,123 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/LigeronAhill/luxcarpets-go/internal/database/types"
+	"github.com/google/uuid"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var apiTokenColumns = []string{
+	"id", "user_id", "name", "token_prefix", "token_hash", "scopes",
+	"expires_at", "last_used_at", "revoked_at", "created_at",
+}
+
+func TestAPITokensStorage_Create(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	storage := NewAPITokensStorage(mock)
+	tokenID := uuid.New()
+	userID := uuid.New()
+	now := time.Now()
+	expiresAt := now.Add(24 * time.Hour)
+	scopes := []types.TokenScope{types.ScopeCatalogRead}
+
+	mock.ExpectQuery(`INSERT INTO api_tokens \(user_id, name, token_prefix, token_hash, scopes, expires_at\)`).
+		WithArgs(userID, "1C", "lxc_pat_abcd", "hash", []string{"catalog:read"}, expiresAt).
+		WillReturnRows(pgxmock.NewRows(apiTokenColumns).AddRow(
+			tokenID, userID, "1C", "lxc_pat_abcd", "hash", scopes,
+			expiresAt, nil, nil, now,
+		))
+
+	res, err := storage.Create(context.Background(), types.CreateAPITokenParams{
+		UserID:      userID,
+		Name:        "1C",
+		TokenPrefix: "lxc_pat_abcd",
+		TokenHash:   "hash",
+		Scopes:      scopes,
+		ExpiresAt:   expiresAt,
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, tokenID, res.ID)
+	assert.Equal(t, scopes, res.Scopes)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAPITokensStorage_GetByHash_NotFound(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	storage := NewAPITokensStorage(mock)
+
+	mock.ExpectQuery(`SELECT \* FROM api_tokens WHERE token_hash = @token_hash`).
+		WithArgs("hash").
+		WillReturnRows(pgxmock.NewRows(apiTokenColumns))
+
+	res, err := storage.GetByHash(context.Background(), "hash")
+
+	assert.NoError(t, err)
+	assert.Nil(t, res)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAPITokensStorage_Revoke(t *testing.T) {
+	tests := []struct {
+		name     string
+		affected int64
+		expected bool
+	}{
+		{"токен отозван", 1, true},
+		{"токен не найден или уже отозван", 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock, err := pgxmock.NewPool()
+			require.NoError(t, err)
+			defer mock.Close()
+
+			storage := NewAPITokensStorage(mock)
+
+			mock.ExpectExec(`UPDATE api_tokens
+		SET revoked_at = NOW\(\)
+		WHERE id = @id AND user_id = @user_id AND revoked_at IS NULL`).
+				WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg()).
+				WillReturnResult(pgxmock.NewResult("UPDATE", tt.affected))
+
+			ok, err := storage.Revoke(context.Background(), uuid.New(), uuid.New())
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, ok)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestAPITokensStorage_TouchLastUsed(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	storage := NewAPITokensStorage(mock)
+	now := time.Now()
+
+	mock.ExpectExec(`UPDATE api_tokens
+		SET last_used_at = @now
+		WHERE id = @id AND \(last_used_at IS NULL OR last_used_at < @stale_before\)`).
+		WithArgs(now, pgxmock.AnyArg(), now.Add(-time.Minute)).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+
+	err = storage.TouchLastUsed(context.Background(), uuid.New(), now, now.Add(-time.Minute))
+
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,132 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/LigeronAhill/luxcarpets-go/internal/database/types"
+	"github.com/LigeronAhill/luxcarpets-go/pkg/utils"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// CatalogStorage читает витрину каталога: карточки товаров, значения фильтров и страницы товаров
+type CatalogStorage struct {
+	pool PgxPoolIface
+}
+
+func NewCatalogStorage(pool PgxPoolIface) *CatalogStorage {
+	return &CatalogStorage{
+		pool: pool,
+	}
+}
+
+// List возвращает страницу карточек товаров и общее количество товаров по фильтрам
+func (s *CatalogStorage) List(ctx context.Context, q types.CatalogQuery) ([]*types.ProductCard, int, error) {
+	op := fmt.Sprintf("list catalog\nquery:%#v", q)
+	countQuery, countArgs := q.BuildCountQuery()
+	var total int
+	if err := s.pool.QueryRow(ctx, countQuery, countArgs).Scan(&total); err != nil {
+		return nil, 0, utils.Wrap(op, err)
+	}
+	query, args := q.BuildQuery()
+	rows, err := s.pool.Query(ctx, query, args)
+	if err != nil {
+		return nil, 0, utils.Wrap(op, err)
+	}
+	defer rows.Close()
+	res, err := pgx.CollectRows(rows, pgx.RowToAddrOfStructByName[types.ProductCard])
+	if err != nil {
+		return nil, 0, utils.Wrap(op, err)
+	}
+	return res, total, nil
+}
+
+// Facet возвращает значения фильтра с количеством товаров.
+// Фильтр самого фасета не применяется, остальные - применяются.
+func (s *CatalogStorage) Facet(ctx context.Context, q types.CatalogQuery, facet string) ([]types.FacetValue, error) {
+	op := "list catalog facet " + facet
+	query, args := q.BuildFacetQuery(facet)
+	rows, err := s.pool.Query(ctx, query, args)
+	if err != nil {
+		return nil, utils.Wrap(op, err)
+	}
+	defer rows.Close()
+	res, err := pgx.CollectRows(rows, pgx.RowToStructByName[types.FacetValue])
+	if err != nil {
+		return nil, utils.Wrap(op, err)
+	}
+	return res, nil
+}
+
+// Product возвращает активный товар по части URL или nil, если товара нет, он удален или скрыт
+func (s *CatalogStorage) Product(ctx context.Context, slug string) (*types.Product, error) {
+	op := "get catalog product " + slug
+	query := `
+		SELECT * FROM products WHERE slug = @slug AND deleted_at IS NULL AND is_active
+	`
+	args := pgx.NamedArgs{
+		"slug": slug,
+	}
+	rows, err := s.pool.Query(ctx, query, args)
+	if err != nil {
+		return nil, utils.Wrap(op, err)
+	}
+	defer rows.Close()
+	res, err := pgx.CollectExactlyOneRow(rows, pgx.RowToAddrOfStructByName[types.Product])
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, utils.Wrap(op, err)
+	}
+	return res, nil
+}
+
+// Category возвращает категорию или nil, если категории нет или она удалена
+func (s *CatalogStorage) Category(ctx context.Context, id uuid.UUID) (*types.Category, error) {
+	op := "get catalog category " + id.String()
+	query := `
+		SELECT * FROM categories WHERE id = @id AND deleted_at IS NULL
+	`
+	args := pgx.NamedArgs{
+		"id": id,
+	}
+	rows, err := s.pool.Query(ctx, query, args)
+	if err != nil {
+		return nil, utils.Wrap(op, err)
+	}
+	defer rows.Close()
+	res, err := pgx.CollectExactlyOneRow(rows, pgx.RowToAddrOfStructByName[types.Category])
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, utils.Wrap(op, err)
+	}
+	return res, nil
+}
+
+// Variants возвращает варианты товара в продаже по возрастанию цены
+func (s *CatalogStorage) Variants(ctx context.Context, productID uuid.UUID) ([]*types.ProductVariant, error) {
+	op := "list catalog variants of product " + productID.String()
+	query := `
+		SELECT * FROM product_variants
+		WHERE product_id = @product_id AND deleted_at IS NULL
+		ORDER BY price_kopecks, name
+	`
+	args := pgx.NamedArgs{
+		"product_id": productID,
+	}
+	rows, err := s.pool.Query(ctx, query, args)
+	if err != nil {
+		return nil, utils.Wrap(op, err)
+	}
+	defer rows.Close()
+	res, err := pgx.CollectRows(rows, pgx.RowToAddrOfStructByName[types.ProductVariant])
+	if err != nil {
+		return nil, utils.Wrap(op, err)
+	}
+	return res, nil
+}
@@ -0,0 +1,190 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/LigeronAhill/luxcarpets-go/internal/database/types"
+	"github.com/google/uuid"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var productCardColumns = []string{
+	"id", "slug", "name", "brand", "color_family", "dominant_color", "updated_at",
+	"min_price_kopecks", "in_stock", "cover_alt_text", "cover_renditions",
+}
+
+func TestCatalogStorage_List(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	storage := NewCatalogStorage(mock)
+	productID := uuid.New()
+	price := int64(1299000)
+	renditions := []types.Rendition{{Width: 320, Height: 240, Format: "jpg", Key: "media/ab/abcd/320.jpg"}}
+
+	mock.ExpectQuery(`SELECT COUNT\(\*\)\s+FROM products p .+ WHERE p.deleted_at IS NULL AND p.is_active AND p.color_family = ANY\(@colors\)`).
+		WithArgs([]string{"beige"}).
+		WillReturnRows(pgxmock.NewRows([]string{"count"}).AddRow(25))
+	mock.ExpectQuery(`SELECT p.id, p.slug, .+ ORDER BY p.created_at DESC, p.id LIMIT @limit OFFSET @offset`).
+		WithArgs([]string{"beige"}, types.CatalogPageSize, types.CatalogPageSize).
+		WillReturnRows(pgxmock.NewRows(productCardColumns).AddRow(
+			productID, "kover-osta", "Ковер Osta", strPtr("Osta"), nil, strPtr("#c8b496"), time.Now(),
+			&price, true, strPtr("Ковер в гостиной"), renditions,
+		))
+
+	res, total, err := storage.List(context.Background(), types.CatalogQuery{
+		Colors: []types.ColorFamily{types.ColorBeige},
+		Page:   2,
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, 25, total)
+	require.Len(t, res, 1)
+	assert.Equal(t, productID, res[0].ID)
+	assert.Equal(t, price, *res[0].MinPriceKopecks)
+	assert.Equal(t, renditions, res[0].CoverRenditions)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCatalogStorage_Facet(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	storage := NewCatalogStorage(mock)
+
+	mock.ExpectQuery(`SELECT p.brand AS value, p.brand AS label, COUNT\(\*\) AS count .+ GROUP BY p.brand`).
+		WithArgs([]string{"beige"}).
+		WillReturnRows(pgxmock.NewRows([]string{"value", "label", "count"}).
+			AddRow("Merinos", "Merinos", int64(3)).
+			AddRow("Osta", "Osta", int64(7)))
+
+	res, err := storage.Facet(context.Background(), types.CatalogQuery{
+		Colors: []types.ColorFamily{types.ColorBeige},
+		Brands: []string{"Osta"},
+	}, types.FacetBrand)
+
+	require.NoError(t, err)
+	assert.Equal(t, []types.FacetValue{
+		{Value: "Merinos", Label: "Merinos", Count: 3},
+		{Value: "Osta", Label: "Osta", Count: 7},
+	}, res)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+var categoryColumns = []string{
+	"id", "parent_id", "slug", "name", "description", "position", "created_at", "updated_at", "deleted_at",
+}
+
+var productColumns = []string{
+	"id", "category_id", "slug", "name", "description", "brand", "material", "color_family", "dominant_color",
+	"is_active", "created_at", "updated_at", "deleted_at",
+}
+
+var variantColumns = []string{
+	"id", "product_id", "sku", "name", "width_cm", "length_cm", "unit", "price_kopecks", "stock",
+	"created_at", "updated_at", "deleted_at",
+}
+
+func TestCatalogStorage_Product(t *testing.T) {
+	productID := uuid.New()
+	categoryID := uuid.New()
+	now := time.Now()
+
+	tests := []struct {
+		name       string
+		rows       *pgxmock.Rows
+		expectedID *uuid.UUID
+	}{
+		{
+			name: "активный товар",
+			rows: pgxmock.NewRows(productColumns).AddRow(
+				productID, categoryID, "kover-osta", "Ковер Osta", nil, strPtr("Osta"), nil, nil, nil,
+				true, now, now, nil,
+			),
+			expectedID: &productID,
+		},
+		{
+			name: "товар не найден",
+			rows: pgxmock.NewRows(productColumns),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock, err := pgxmock.NewPool()
+			require.NoError(t, err)
+			defer mock.Close()
+
+			storage := NewCatalogStorage(mock)
+			mock.ExpectQuery(`SELECT \* FROM products WHERE slug = @slug AND deleted_at IS NULL AND is_active`).
+				WithArgs("kover-osta").
+				WillReturnRows(tt.rows)
+
+			res, err := storage.Product(context.Background(), "kover-osta")
+
+			require.NoError(t, err)
+			if tt.expectedID == nil {
+				assert.Nil(t, res)
+			} else {
+				require.NotNil(t, res)
+				assert.Equal(t, *tt.expectedID, res.ID)
+				assert.Equal(t, categoryID, res.CategoryID)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestCatalogStorage_Category(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	storage := NewCatalogStorage(mock)
+	categoryID := uuid.New()
+	now := time.Now()
+
+	mock.ExpectQuery(`SELECT \* FROM categories WHERE id = @id AND deleted_at IS NULL`).
+		WithArgs(categoryID).
+		WillReturnRows(pgxmock.NewRows(categoryColumns).AddRow(
+			categoryID, nil, "kovry", "Ковры", nil, 1, now, now, nil,
+		))
+
+	res, err := storage.Category(context.Background(), categoryID)
+
+	require.NoError(t, err)
+	require.NotNil(t, res)
+	assert.Equal(t, "kovry", res.Slug)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCatalogStorage_Variants(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	storage := NewCatalogStorage(mock)
+	productID := uuid.New()
+	width, length := 200, 300
+	now := time.Now()
+
+	mock.ExpectQuery(`SELECT \* FROM product_variants\s+WHERE product_id = @product_id AND deleted_at IS NULL\s+ORDER BY price_kopecks, name`).
+		WithArgs(productID).
+		WillReturnRows(pgxmock.NewRows(variantColumns).
+			AddRow(uuid.New(), productID, "OD-200", "200x300 см", &width, &length, types.UnitPiece, int64(4599000), 2, now, now, nil).
+			AddRow(uuid.New(), productID, "OD-R4", "Рулон 4 м", nil, nil, types.UnitSqm, int64(5599000), 0, now, now, nil))
+
+	res, err := storage.Variants(context.Background(), productID)
+
+	require.NoError(t, err)
+	require.Len(t, res, 2)
+	assert.Equal(t, "OD-200", res[0].SKU)
+	assert.True(t, res[0].InStock())
+	assert.False(t, res[1].InStock())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
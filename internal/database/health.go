@@ -0,0 +1,51 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+
+	"github.com/LigeronAhill/luxcarpets-go/pkg/utils"
+)
+
+// HealthStorage проверяет доступность базы данных для проверки готовности сервера
+type HealthStorage struct {
+	pool PgxPoolIface
+}
+
+func NewHealthStorage(pool PgxPoolIface) *HealthStorage {
+	return &HealthStorage{
+		pool: pool,
+	}
+}
+
+// Ping проверяет соединение с базой данных
+func (s *HealthStorage) Ping(ctx context.Context) error {
+	if err := s.pool.Ping(ctx); err != nil {
+		return utils.Wrap("ping database", err)
+	}
+	return nil
+}
+
+// SchemaVersion возвращает номер последней примененной миграции
+func (s *HealthStorage) SchemaVersion(ctx context.Context) (int32, error) {
+	op := "get schema version"
+	query := fmt.Sprintf(`
+		SELECT version FROM %s
+	`, versionTable)
+	var version int32
+	if err := s.pool.QueryRow(ctx, query).Scan(&version); err != nil {
+		return 0, utils.Wrap(op, err)
+	}
+	return version, nil
+}
+
+// LatestMigration возвращает номер последней миграции, встроенной в приложение.
+// Tern нумерует миграции подряд с 1, поэтому номер равен количеству файлов.
+func LatestMigration() (int32, error) {
+	files, err := fs.Glob(migrationFS, "migrations/*.sql")
+	if err != nil {
+		return 0, fmt.Errorf("error listing migrations: %w", err)
+	}
+	return int32(len(files)), nil
+}
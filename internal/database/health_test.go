@@ -0,0 +1,26 @@
+package database
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLatestMigration(t *testing.T) {
+	entries, err := migrationFS.ReadDir("migrations")
+	require.NoError(t, err)
+	require.NotEmpty(t, entries)
+
+	// Номер последней миграции совпадает с префиксом имени последнего файла
+	prefix, _, _ := strings.Cut(entries[len(entries)-1].Name(), "_")
+	expected, err := strconv.Atoi(prefix)
+	require.NoError(t, err)
+
+	latest, err := LatestMigration()
+
+	require.NoError(t, err)
+	assert.Equal(t, int32(expected), latest)
+}
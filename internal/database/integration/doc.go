@@ -0,0 +1,21 @@
+// Пакет integration содержит интеграционные тесты хранилищ на настоящем PostgreSQL.
+//
+// Модульные тесты пакета database проверяют текст запросов на pgxmock, но не то, что запросы
+// выполняются на реальной схеме. Эти тесты применяют встроенные миграции к временной базе
+// и вызывают хранилища целиком, включая ограничения таблиц.
+//
+// Тесты собираются только с тегом integration:
+//
+//	go test -tags integration ./internal/database/integration/...
+//
+// База выбирается так (см. TestMain):
+//
+//   - TEST_DATABASE_URL - существующий сервер; для тестов в нем создается и затем удаляется
+//     отдельная база;
+//   - initdb и pg_ctl из PATH (или из PG_BIN) - временный кластер в каталоге /tmp,
+//     доступный только через unix-сокет (initdb не запускается от root);
+//   - docker - одноразовый контейнер postgres:17-alpine.
+//
+// Каждый тест работает в своей транзакции, которая откатывается по завершении теста,
+// поэтому тесты не видят данных друг друга и могут выполняться параллельно.
+package integration
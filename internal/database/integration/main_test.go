@@ -0,0 +1,212 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/LigeronAhill/luxcarpets-go/internal/database"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/require"
+)
+
+// postgresImage - образ контейнера, та же версия, что в docker-compose.yml
+const postgresImage = "postgres:17-alpine"
+
+// connectTimeout ограничивает ожидание запуска базы: контейнеру может понадобиться скачать образ
+const connectTimeout = 2 * time.Minute
+
+// pool - пул соединений с временной базой, в которой применены миграции
+var pool *pgxpool.Pool
+
+func TestMain(m *testing.M) {
+	os.Exit(runTests(m))
+}
+
+func runTests(m *testing.M) int {
+	ctx := context.Background()
+	dbURL, stop, err := startPostgres(ctx)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "failed to start postgres:", err)
+		return 1
+	}
+	defer stop()
+
+	pool, err = database.NewPool(ctx, dbURL, connectTimeout)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "failed to connect to postgres:", err)
+		return 1
+	}
+	defer pool.Close()
+	if err := database.Migrate(ctx, dbURL); err != nil {
+		fmt.Fprintln(os.Stderr, "failed to apply migrations:", err)
+		return 1
+	}
+	return m.Run()
+}
+
+// startPostgres возвращает адрес пустой базы и функцию, которая ее удаляет
+func startPostgres(ctx context.Context) (dbURL string, stop func(), err error) {
+	if adminURL := os.Getenv("TEST_DATABASE_URL"); adminURL != "" {
+		return createDatabase(ctx, adminURL)
+	}
+	if initdb, err := lookupPG("initdb"); err == nil {
+		return startCluster(ctx, filepath.Dir(initdb))
+	}
+	if _, err := exec.LookPath("docker"); err == nil {
+		return startContainer(ctx)
+	}
+	return "", nil, errors.New("no postgres available: set TEST_DATABASE_URL, PG_BIN or install docker")
+}
+
+// lookupPG ищет программу PostgreSQL в каталоге PG_BIN, а без него - в PATH
+func lookupPG(name string) (string, error) {
+	if dir := os.Getenv("PG_BIN"); dir != "" {
+		return exec.LookPath(filepath.Join(dir, name))
+	}
+	return exec.LookPath(name)
+}
+
+// createDatabase создает на сервере adminURL базу со случайным именем
+func createDatabase(ctx context.Context, adminURL string) (string, func(), error) {
+	u, err := url.Parse(adminURL)
+	if err != nil {
+		return "", nil, fmt.Errorf("invalid TEST_DATABASE_URL: %w", err)
+	}
+	name := fmt.Sprintf("luxcarpets_test_%d", time.Now().UnixNano())
+	if err := adminExec(ctx, adminURL, "CREATE DATABASE "+name); err != nil {
+		return "", nil, err
+	}
+	stop := func() {
+		if err := adminExec(context.Background(), adminURL, "DROP DATABASE IF EXISTS "+name+" WITH (FORCE)"); err != nil {
+			fmt.Fprintln(os.Stderr, "failed to drop test database:", err)
+		}
+	}
+	u.Path = "/" + name
+	return u.String(), stop, nil
+}
+
+func adminExec(ctx context.Context, adminURL, sql string) error {
+	conn, err := pgx.Connect(ctx, adminURL)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(ctx)
+	_, err = conn.Exec(ctx, sql)
+	return err
+}
+
+// startCluster создает временный кластер в /tmp и запускает его без TCP: сервер слушает
+// только unix-сокет в каталоге кластера, поэтому не конфликтует с другими экземплярами
+func startCluster(ctx context.Context, binDir string) (string, func(), error) {
+	dir, err := os.MkdirTemp("", "luxcarpets-pg-")
+	if err != nil {
+		return "", nil, err
+	}
+	data := filepath.Join(dir, "data")
+	if _, err := command(ctx, filepath.Join(binDir, "initdb"),
+		"-D", data, "-U", "postgres", "-A", "trust", "-E", "UTF8", "--locale=C", "--no-sync",
+	); err != nil {
+		_ = os.RemoveAll(dir)
+		return "", nil, err
+	}
+	pgCtl := filepath.Join(binDir, "pg_ctl")
+	if _, err := command(ctx, pgCtl,
+		"-D", data, "-l", filepath.Join(dir, "postgres.log"), "-w",
+		"-o", "-k "+dir+" -c listen_addresses='' -c fsync=off", "start",
+	); err != nil {
+		_ = os.RemoveAll(dir)
+		return "", nil, err
+	}
+	stop := func() {
+		if _, err := command(context.Background(), pgCtl, "-D", data, "-m", "immediate", "stop"); err != nil {
+			fmt.Fprintln(os.Stderr, "failed to stop postgres:", err)
+		}
+		_ = os.RemoveAll(dir)
+	}
+	return "postgres://postgres@/postgres?host=" + url.QueryEscape(dir), stop, nil
+}
+
+// startContainer запускает одноразовый контейнер Postgres на случайном порту localhost.
+// Готовность базы ждет database.NewPool: при первом запуске контейнер инициализирует
+// кластер и перезапускает сервер.
+func startContainer(ctx context.Context) (string, func(), error) {
+	id, err := command(ctx, "docker", "run", "--detach", "--rm",
+		"--env", "POSTGRES_PASSWORD=postgres",
+		"--publish", "127.0.0.1::5432",
+		postgresImage, "-c", "fsync=off",
+	)
+	if err != nil {
+		return "", nil, err
+	}
+	stop := func() {
+		if _, err := command(context.Background(), "docker", "rm", "--force", id); err != nil {
+			fmt.Fprintln(os.Stderr, "failed to remove postgres container:", err)
+		}
+	}
+	// "127.0.0.1:49153"; для нескольких адресов docker выводит по одному в строке
+	addr, err := command(ctx, "docker", "port", id, "5432/tcp")
+	if err != nil {
+		stop()
+		return "", nil, err
+	}
+	addr, _, _ = strings.Cut(addr, "\n")
+	return "postgres://postgres:postgres@" + addr + "/postgres?sslmode=disable", stop, nil
+}
+
+// command выполняет программу и возвращает ее вывод без пробелов по краям
+func command(ctx context.Context, name string, args ...string) (string, error) {
+	out, err := exec.CommandContext(ctx, name, args...).CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("%s %s: %w\n%s", filepath.Base(name), strings.Join(args, " "), err, out)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// txPool выполняет запросы хранилища в транзакции теста. Begin внутри нее
+// создает точку сохранения, поэтому методы с транзакциями тоже работают.
+type txPool struct {
+	pgx.Tx
+}
+
+var _ database.PgxPoolIface = txPool{}
+
+// Close не закрывает соединение: транзакцию откатывает newTx
+func (txPool) Close() {}
+
+func (p txPool) Ping(ctx context.Context) error {
+	return p.Conn().Ping(ctx)
+}
+
+// newTx начинает транзакцию, которая откатывается по завершении теста.
+// Параллельные тесты должны вставлять разные уникальные значения (email, телефон):
+// вставка занятого значения ждет завершения чужой транзакции.
+func newTx(t *testing.T) database.PgxPoolIface {
+	t.Helper()
+	tx, err := pool.Begin(context.Background())
+	require.NoError(t, err)
+	t.Cleanup(func() {
+		_ = tx.Rollback(context.Background())
+	})
+	return txPool{tx}
+}
+
+// constraintName возвращает имя нарушенного ограничения таблицы или пустую строку
+func constraintName(err error) string {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.ConstraintName
+	}
+	return ""
+}
@@ -0,0 +1,27 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"testing"
+
+	"github.com/LigeronAhill/luxcarpets-go/internal/database"
+	"github.com/LigeronAhill/luxcarpets-go/pkg/requestid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Соединение из пула получает идентификатор запроса в application_name, а без него - возвращает исходное имя
+func TestPool_ApplicationName(t *testing.T) {
+	id := requestid.New()
+
+	var name string
+	err := pool.QueryRow(requestid.WithID(context.Background(), id), "SHOW application_name").Scan(&name)
+	require.NoError(t, err)
+	assert.Equal(t, database.ApplicationName+" "+id, name)
+
+	err = pool.QueryRow(context.Background(), "SHOW application_name").Scan(&name)
+	require.NoError(t, err)
+	assert.Equal(t, database.ApplicationName, name)
+}
@@ -0,0 +1,81 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"testing"
+
+	"github.com/LigeronAhill/luxcarpets-go/internal/database"
+	"github.com/LigeronAhill/luxcarpets-go/internal/database/types"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSeedStorage(t *testing.T) {
+	t.Parallel()
+	tx := newTx(t)
+	storage := database.NewSeedStorage(tx)
+	media := database.NewMediaStorage(tx)
+	ctx := context.Background()
+
+	category, err := storage.EnsureCategory(ctx, types.Category{Slug: "seed-kovry", Name: "Ковры"})
+	require.NoError(t, err)
+	again, err := storage.EnsureCategory(ctx, types.Category{Slug: "seed-kovry", Name: "Другое название"})
+	require.NoError(t, err)
+	assert.Equal(t, category, again, "существующая категория не меняется")
+
+	image, err := media.Create(ctx, types.CreateMediaParams{
+		ContentHash:   "seed-test-image",
+		Width:         960,
+		Height:        1280,
+		DominantColor: "#2c4f8c",
+		ColorFamily:   types.ColorBlue,
+		Renditions:    []types.Rendition{{Width: 320, Height: 427, Format: "jpg", Key: "media/se/seed/320.jpg"}},
+	})
+	require.NoError(t, err)
+
+	color := types.ColorBlue
+	brand := "Merinos"
+	products := []types.Product{
+		{ID: uuid.New(), CategoryID: category.ID, Slug: "seed-kover-1", Name: "Ковер 1", Brand: &brand, ColorFamily: &color, DominantColor: &image.DominantColor, IsActive: true},
+		{ID: uuid.New(), CategoryID: category.ID, Slug: "seed-kover-2", Name: "Ковер 2", IsActive: true},
+	}
+	width, length := 160, 230
+	missingProduct := uuid.New()
+	variants := []types.ProductVariant{
+		{ID: uuid.New(), ProductID: products[0].ID, SKU: "SEED-1-160x230", Name: "160x230 см", WidthCm: &width, LengthCm: &length, Unit: types.UnitPiece, PriceKopecks: 1_990_000, Stock: 3},
+		{ID: uuid.New(), ProductID: products[1].ID, SKU: "SEED-2-W400", Name: "Ширина 4 м", WidthCm: &width, Unit: types.UnitSqm, PriceKopecks: 150_000},
+		{ID: uuid.New(), ProductID: missingProduct, SKU: "SEED-3-W400", Name: "Ширина 4 м", WidthCm: &width, Unit: types.UnitSqm, PriceKopecks: 150_000},
+	}
+	gallery := []types.ProductMedia{
+		{ProductID: products[0].ID, MediaID: image.ID, Position: 0, AltText: "Ковер 1"},
+		{ProductID: missingProduct, MediaID: image.ID, Position: 0, AltText: "Ковер 3"},
+	}
+
+	// Повторная вставка тех же записей ничего не добавляет
+	for _, want := range []struct{ products, variants, gallery int64 }{{2, 2, 1}, {0, 0, 0}} {
+		n, err := storage.InsertProducts(ctx, products)
+		require.NoError(t, err)
+		assert.Equal(t, want.products, n)
+
+		n, err = storage.InsertVariants(ctx, variants)
+		require.NoError(t, err)
+		assert.Equal(t, want.variants, n, "варианты отсутствующего товара пропускаются")
+
+		n, err = storage.InsertProductMedia(ctx, gallery)
+		require.NoError(t, err)
+		assert.Equal(t, want.gallery, n)
+	}
+
+	// Товары видны в каталоге с ценой, наличием и главным изображением
+	cards, total, err := database.NewCatalogStorage(tx).List(ctx, types.CatalogQuery{Category: "seed-kovry", Sort: types.SortName})
+	require.NoError(t, err)
+	assert.Equal(t, 2, total)
+	require.Len(t, cards, 2)
+	assert.Equal(t, "Ковер 1", cards[0].Name)
+	assert.True(t, cards[0].InStock)
+	assert.Equal(t, image.Renditions, cards[0].CoverRenditions)
+	assert.False(t, cards[1].InStock)
+}
@@ -0,0 +1,408 @@
+//go:build integration
+
+package integration
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/LigeronAhill/luxcarpets-go/internal/database"
+	"github.com/LigeronAhill/luxcarpets-go/internal/database/types"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func strPtr(s string) *string {
+	return &s
+}
+
+// createUser создает покупателя с паролем и проверяет, что запрос выполнился
+func createUser(t *testing.T, storage *database.UsersStorage, email, username string) *types.User {
+	t.Helper()
+	user, err := storage.Create(context.Background(), types.CreateUserParams{
+		Email:        email,
+		Username:     username,
+		PasswordHash: strPtr("$argon2id$v=19$m=65536,t=1,p=4$c2FsdA$aGFzaA"),
+		Role:         types.RoleCustomer,
+	})
+	require.NoError(t, err)
+	return user
+}
+
+// Моки users_test.go пакета database возвращают колонки в этом порядке;
+// тест следит, чтобы после миграций он совпадал с SELECT * и с полями types.User
+func TestUsersTable_Columns(t *testing.T) {
+	t.Parallel()
+	tx := newTx(t)
+
+	rows, err := tx.Query(context.Background(), "SELECT * FROM users LIMIT 0")
+	require.NoError(t, err)
+	var columns []string
+	for _, field := range rows.FieldDescriptions() {
+		columns = append(columns, field.Name)
+	}
+	rows.Close()
+	require.NoError(t, rows.Err())
+
+	assert.Equal(t, []string{
+		"id", "email", "email_verified", "username", "role", "image_url",
+		"password_hash", "created_at", "updated_at", "deleted_at", "phone", "phone_verified",
+	}, columns)
+
+	var fields []string
+	userType := reflect.TypeFor[types.User]()
+	for i := range userType.NumField() {
+		fields = append(fields, userType.Field(i).Tag.Get("db"))
+	}
+	assert.ElementsMatch(t, fields, columns, "у каждой колонки users должно быть поле в types.User")
+}
+
+func TestUsersStorage_Create(t *testing.T) {
+	t.Parallel()
+	tests := []struct {
+		name       string
+		params     types.CreateUserParams
+		constraint string // Нарушенное ограничение; пустое - пользователь создается
+	}{
+		{
+			name: "пользователь с паролем",
+			params: types.CreateUserParams{
+				Email:        "buyer@example.com",
+				Username:     "buyer",
+				PasswordHash: strPtr("hash"),
+				Role:         types.RoleCustomer,
+			},
+		},
+		{
+			name: "email в верхнем регистре",
+			params: types.CreateUserParams{
+				Email:    "Upper@Example.COM",
+				Username: "upper",
+				Role:     types.RoleGuest,
+				ImageURL: strPtr("https://example.com/avatar.jpg"),
+			},
+		},
+		{
+			name: "имя из 3 символов",
+			params: types.CreateUserParams{
+				Email:    "abc@example.com",
+				Username: "abc",
+				Role:     types.RoleCustomer,
+			},
+		},
+		{
+			name: "имя из 50 символов кириллицей",
+			params: types.CreateUserParams{
+				Email:    "long@example.com",
+				Username: strings.Repeat("ж", 50),
+				Role:     types.RoleCustomer,
+			},
+		},
+		{
+			name: "email без домена",
+			params: types.CreateUserParams{
+				Email:    "buyer@example",
+				Username: "buyer",
+				Role:     types.RoleCustomer,
+			},
+			constraint: "chk_email",
+		},
+		{
+			name: "email без @",
+			params: types.CreateUserParams{
+				Email:    "buyer.example.com",
+				Username: "buyer",
+				Role:     types.RoleCustomer,
+			},
+			constraint: "chk_email",
+		},
+		{
+			name: "имя из 2 символов",
+			params: types.CreateUserParams{
+				Email:    "ab@example.com",
+				Username: "ab",
+				Role:     types.RoleCustomer,
+			},
+			constraint: "chk_username_length",
+		},
+		{
+			name: "имя из 51 символа",
+			params: types.CreateUserParams{
+				Email:    "long51@example.com",
+				Username: strings.Repeat("ж", 51),
+				Role:     types.RoleCustomer,
+			},
+			constraint: "chk_username_length",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			storage := database.NewUsersStorage(newTx(t))
+
+			user, err := storage.Create(context.Background(), tt.params)
+
+			if tt.constraint != "" {
+				require.Error(t, err)
+				assert.Nil(t, user)
+				assert.Equal(t, tt.constraint, constraintName(err))
+				return
+			}
+			require.NoError(t, err)
+			assert.NotEqual(t, uuid.Nil, user.ID)
+			require.NotNil(t, user.Email)
+			assert.Equal(t, strings.ToLower(tt.params.Email), *user.Email)
+			assert.Equal(t, tt.params.Username, user.Username)
+			assert.Equal(t, tt.params.Role, user.Role)
+			assert.Equal(t, tt.params.PasswordHash, user.PasswordHash)
+			assert.Equal(t, tt.params.ImageURL, user.ImageURL)
+			assert.False(t, user.EmailVerified)
+			assert.Nil(t, user.Phone)
+			assert.False(t, user.PhoneVerified)
+			assert.False(t, user.CreatedAt.IsZero())
+			assert.Nil(t, user.DeletedAt)
+		})
+	}
+}
+
+func TestUsersStorage_Create_DuplicateEmail(t *testing.T) {
+	t.Parallel()
+	storage := database.NewUsersStorage(newTx(t))
+	createUser(t, storage, "duplicate@example.com", "buyer")
+
+	user, err := storage.Create(context.Background(), types.CreateUserParams{
+		Email:    "DUPLICATE@example.com",
+		Username: "another",
+		Role:     types.RoleCustomer,
+	})
+
+	assert.Nil(t, user)
+	assert.EqualError(t, err, "email already exists")
+}
+
+func TestUsersStorage_CreateByPhone(t *testing.T) {
+	t.Parallel()
+	storage := database.NewUsersStorage(newTx(t))
+	ctx := context.Background()
+
+	created, err := storage.CreateByPhone(ctx, "+79123456789", "Покупатель 6789", types.RoleCustomer)
+	require.NoError(t, err)
+	assert.Nil(t, created.Email)
+	assert.True(t, created.PhoneVerified)
+	assert.Equal(t, "+79123456789", created.Login())
+
+	found, err := storage.GetByPhone(ctx, "+79123456789")
+	require.NoError(t, err)
+	assert.Equal(t, created.ID, found.ID)
+
+	_, err = storage.CreateByPhone(ctx, "89123456789", "Покупатель 6789", types.RoleCustomer)
+	assert.Equal(t, "chk_users_phone", constraintName(err))
+}
+
+func TestUsersStorage_Get(t *testing.T) {
+	t.Parallel()
+	storage := database.NewUsersStorage(newTx(t))
+	ctx := context.Background()
+	created := createUser(t, storage, "get@example.com", "buyer")
+
+	byID, err := storage.GetByID(ctx, created.ID)
+	require.NoError(t, err)
+	assert.Equal(t, created, byID)
+
+	byEmail, err := storage.GetByEmail(ctx, "Get@Example.com")
+	require.NoError(t, err)
+	assert.Equal(t, created, byEmail)
+
+	_, err = storage.GetByID(ctx, uuid.New())
+	assert.ErrorIs(t, err, pgx.ErrNoRows)
+
+	_, err = storage.GetByEmail(ctx, "nobody@example.com")
+	assert.ErrorIs(t, err, pgx.ErrNoRows)
+}
+
+func TestUsersStorage_Update(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+
+	t.Run("меняются только переданные поля", func(t *testing.T) {
+		t.Parallel()
+		storage := database.NewUsersStorage(newTx(t))
+		created := createUser(t, storage, "update-role@example.com", "buyer")
+		role := types.RoleEmployee
+		verified := true
+
+		updated, err := storage.Update(ctx, types.UpdateUserParams{
+			ID:            created.ID,
+			Role:          &role,
+			EmailVerified: &verified,
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, types.RoleEmployee, updated.Role)
+		assert.True(t, updated.EmailVerified)
+		assert.Equal(t, created.Username, updated.Username)
+		assert.Equal(t, created.PasswordHash, updated.PasswordHash)
+		assert.Equal(t, created.Email, updated.Email)
+		assert.Nil(t, updated.ImageURL)
+	})
+
+	t.Run("имя и аватар", func(t *testing.T) {
+		t.Parallel()
+		storage := database.NewUsersStorage(newTx(t))
+		created := createUser(t, storage, "update-name@example.com", "buyer")
+
+		updated, err := storage.Update(ctx, types.UpdateUserParams{
+			ID:       created.ID,
+			Username: strPtr("Иван Петров"),
+			ImageURL: strPtr("https://example.com/avatar.jpg"),
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, "Иван Петров", updated.Username)
+		assert.Equal(t, strPtr("https://example.com/avatar.jpg"), updated.ImageURL)
+		assert.Equal(t, created.Role, updated.Role)
+	})
+
+	t.Run("слишком короткое имя", func(t *testing.T) {
+		t.Parallel()
+		storage := database.NewUsersStorage(newTx(t))
+		created := createUser(t, storage, "update-short@example.com", "buyer")
+
+		_, err := storage.Update(ctx, types.UpdateUserParams{
+			ID:       created.ID,
+			Username: strPtr("ab"),
+		})
+
+		assert.Equal(t, "chk_username_length", constraintName(err))
+	})
+
+	t.Run("удаленный пользователь", func(t *testing.T) {
+		t.Parallel()
+		storage := database.NewUsersStorage(newTx(t))
+		created := createUser(t, storage, "update-deleted@example.com", "buyer")
+		require.NoError(t, storage.Delete(ctx, created.ID))
+
+		_, err := storage.Update(ctx, types.UpdateUserParams{
+			ID:       created.ID,
+			Username: strPtr("another"),
+		})
+
+		assert.ErrorIs(t, err, pgx.ErrNoRows)
+	})
+}
+
+func TestUsersStorage_List(t *testing.T) {
+	t.Parallel()
+	ctx := context.Background()
+	role := types.RoleEmployee
+
+	tests := []struct {
+		name      string
+		params    types.ListUsersParams
+		total     int
+		usernames []string
+	}{
+		{
+			name:      "все активные",
+			params:    types.ListUsersParams{OrderBy: "username", Order: "ASC"},
+			total:     3,
+			usernames: []string{"alice", "bob", "carol"},
+		},
+		{
+			name:      "вторая страница",
+			params:    types.ListUsersParams{Limit: 2, Offset: 2, OrderBy: "username", Order: "ASC"},
+			total:     3,
+			usernames: []string{"carol"},
+		},
+		{
+			name:      "с удаленными по убыванию",
+			params:    types.ListUsersParams{IncludeDeleted: true, OrderBy: "username", Order: "DESC"},
+			total:     4,
+			usernames: []string{"dave", "carol", "bob", "alice"},
+		},
+		{
+			name:      "по роли",
+			params:    types.ListUsersParams{Role: &role, OrderBy: "username"},
+			total:     1,
+			usernames: []string{"bob"},
+		},
+		{
+			name:      "поиск по email и имени",
+			params:    types.ListUsersParams{SearchQuery: strPtr("AR"), OrderBy: "email", Order: "ASC"},
+			total:     1,
+			usernames: []string{"carol"},
+		},
+		{
+			name:      "ничего не найдено",
+			params:    types.ListUsersParams{Email: strPtr("nobody")},
+			total:     0,
+			usernames: []string{},
+		},
+	}
+
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+			storage := database.NewUsersStorage(newTx(t))
+			email := func(name string) string {
+				return fmt.Sprintf("%s.list%d@example.com", name, i)
+			}
+			createUser(t, storage, email("alice"), "alice")
+			bob := createUser(t, storage, email("bob"), "bob")
+			createUser(t, storage, email("carol"), "carol")
+			dave := createUser(t, storage, email("dave"), "dave")
+			_, err := storage.Update(ctx, types.UpdateUserParams{ID: bob.ID, Role: &role})
+			require.NoError(t, err)
+			require.NoError(t, storage.Delete(ctx, dave.ID))
+
+			res, err := storage.List(ctx, tt.params)
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.total, res.Total)
+			usernames := []string{}
+			for _, user := range res.Data {
+				usernames = append(usernames, user.Username)
+			}
+			assert.Equal(t, tt.usernames, usernames)
+		})
+	}
+}
+
+func TestUsersStorage_Delete(t *testing.T) {
+	t.Parallel()
+	storage := database.NewUsersStorage(newTx(t))
+	ctx := context.Background()
+	created := createUser(t, storage, "delete@example.com", "buyer")
+
+	require.NoError(t, storage.Delete(ctx, created.ID))
+
+	_, err := storage.GetByID(ctx, created.ID)
+	assert.ErrorIs(t, err, pgx.ErrNoRows)
+	_, err = storage.GetByEmail(ctx, "delete@example.com")
+	assert.ErrorIs(t, err, pgx.ErrNoRows)
+	assert.EqualError(t, storage.Delete(ctx, created.ID), "user not found")
+	assert.EqualError(t, storage.Delete(ctx, uuid.New()), "user not found")
+}
+
+func TestUsersStorage_Restore(t *testing.T) {
+	t.Parallel()
+	storage := database.NewUsersStorage(newTx(t))
+	ctx := context.Background()
+	created := createUser(t, storage, "restore@example.com", "buyer")
+
+	assert.EqualError(t, storage.Restore(ctx, created.ID), "user not found")
+	require.NoError(t, storage.Delete(ctx, created.ID))
+	require.NoError(t, storage.Restore(ctx, created.ID))
+
+	got, err := storage.GetByEmail(ctx, "restore@example.com")
+	require.NoError(t, err)
+	assert.Equal(t, created.ID, got.ID)
+	assert.Nil(t, got.DeletedAt)
+	assert.EqualError(t, storage.Restore(ctx, uuid.New()), "user not found")
+}
@@ -0,0 +1,131 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/LigeronAhill/luxcarpets-go/internal/database/types"
+	"github.com/LigeronAhill/luxcarpets-go/pkg/utils"
+	"github.com/jackc/pgx/v5"
+)
+
+// LoginAttemptsStorage хранит счетчики неудачных попыток входа в PostgreSQL.
+// Позволяет разделять состояние блокировок между несколькими репликами сервера.
+type LoginAttemptsStorage struct {
+	pool PgxPoolIface
+}
+
+func NewLoginAttemptsStorage(pool PgxPoolIface) *LoginAttemptsStorage {
+	return &LoginAttemptsStorage{
+		pool: pool,
+	}
+}
+
+// Get возвращает счетчик для ключа. Если попыток не было, возвращает nil без ошибки.
+func (l *LoginAttemptsStorage) Get(ctx context.Context, key string) (*types.LoginAttempt, error) {
+	op := "get login attempts for " + key
+	query := `
+		SELECT * FROM login_attempts WHERE key = @key
+	`
+	args := pgx.NamedArgs{
+		"key": key,
+	}
+	rows, err := l.pool.Query(ctx, query, args)
+	if err != nil {
+		return nil, utils.Wrap(op, err)
+	}
+	defer rows.Close()
+	res, err := pgx.CollectExactlyOneRow(rows, pgx.RowToAddrOfStructByName[types.LoginAttempt])
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, utils.Wrap(op, err)
+	}
+	return res, nil
+}
+
+// RegisterAttempt атомарно засчитывает попытку, если ключ не заблокирован, и возвращает счетчик после увеличения.
+// Если последняя попытка была раньше resetBefore, счетчик начинается заново. После n-й попытки ключ
+// блокируется на waits[n-1], а если попыток больше len(waits) - на последний элемент.
+// Если ключ заблокирован, счетчик не меняется и возвращается nil без ошибки.
+//
+// Проверка и обновление выполняются одним INSERT ... ON CONFLICT: строка ключа блокируется,
+// и одновременные попытки с разных реплик не проверят больше учетных данных, чем разрешает политика.
+func (l *LoginAttemptsStorage) RegisterAttempt(ctx context.Context, key string, now, resetBefore time.Time, waits []time.Duration) (*types.LoginAttempt, error) {
+	op := "register login attempt for " + key
+	query := `
+		INSERT INTO login_attempts AS a (key, failures, last_failure_at, blocked_until)
+		VALUES (@key, 1, @now::timestamp, @now::timestamp + (@waits::interval[])[1])
+		ON CONFLICT (key) DO UPDATE
+		SET
+		    failures = CASE
+		        WHEN a.last_failure_at < @reset_before::timestamp THEN 1
+		        ELSE a.failures + 1
+		    END,
+		    last_failure_at = @now::timestamp,
+		    blocked_until = @now::timestamp + (@waits::interval[])[LEAST(
+		        CASE
+		            WHEN a.last_failure_at < @reset_before::timestamp THEN 1
+		            ELSE a.failures + 1
+		        END,
+		        cardinality(@waits::interval[])
+		    )]
+		WHERE a.last_failure_at < @reset_before::timestamp OR a.blocked_until <= @now::timestamp
+		RETURNING *
+	`
+	// Столбцы без часового пояса: время хранится в UTC
+	args := pgx.NamedArgs{
+		"key":          key,
+		"now":          now.UTC(),
+		"reset_before": resetBefore.UTC(),
+		"waits":        waits,
+	}
+	rows, err := l.pool.Query(ctx, query, args)
+	if err != nil {
+		return nil, utils.Wrap(op, err)
+	}
+	defer rows.Close()
+	res, err := pgx.CollectExactlyOneRow(rows, pgx.RowToAddrOfStructByName[types.LoginAttempt])
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, utils.Wrap(op, err)
+	}
+	return res, nil
+}
+
+// Release отменяет попытку, засчитанную RegisterAttempt: уменьшает счетчик и снимает блокировку,
+// которую выставила эта попытка. Предыдущая блокировка к моменту попытки уже истекла.
+func (l *LoginAttemptsStorage) Release(ctx context.Context, key string) error {
+	op := "release login attempt for " + key
+	query := `
+		UPDATE login_attempts
+		SET failures = GREATEST(failures - 1, 0), blocked_until = last_failure_at
+		WHERE key = @key
+	`
+	args := pgx.NamedArgs{
+		"key": key,
+	}
+	if _, err := l.pool.Exec(ctx, query, args); err != nil {
+		return utils.Wrap(op, err)
+	}
+	return nil
+}
+
+// Reset удаляет счетчик для ключа
+func (l *LoginAttemptsStorage) Reset(ctx context.Context, key string) error {
+	op := "reset login attempts for " + key
+	query := `
+		DELETE FROM login_attempts WHERE key = @key
+	`
+	args := pgx.NamedArgs{
+		"key": key,
+	}
+	if _, err := l.pool.Exec(ctx, query, args); err != nil {
+		return utils.Wrap(op, err)
+	}
+	return nil
+}
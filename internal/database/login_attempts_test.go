@@ -0,0 +1,137 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var loginAttemptColumns = []string{"key", "failures", "last_failure_at", "blocked_until"}
+
+func TestLoginAttemptsStorage_Get_Success(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	storage := NewLoginAttemptsStorage(mock)
+	now := time.Now()
+
+	mock.ExpectQuery(`SELECT \* FROM login_attempts WHERE key = @key`).
+		WithArgs(pgxmock.AnyArg()).
+		WillReturnRows(pgxmock.NewRows(loginAttemptColumns).
+			AddRow("account:test@example.com", 3, now, now.Add(time.Minute)))
+
+	attempt, err := storage.Get(context.Background(), "account:test@example.com")
+
+	require.NoError(t, err)
+	require.NotNil(t, attempt)
+	assert.Equal(t, 3, attempt.Failures)
+	assert.Equal(t, now, attempt.LastFailureAt)
+	assert.Equal(t, now.Add(time.Minute), attempt.BlockedUntil)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestLoginAttemptsStorage_Get_NotFound(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	storage := NewLoginAttemptsStorage(mock)
+
+	mock.ExpectQuery(`SELECT \* FROM login_attempts WHERE key = @key`).
+		WithArgs(pgxmock.AnyArg()).
+		WillReturnRows(pgxmock.NewRows(loginAttemptColumns))
+
+	attempt, err := storage.Get(context.Background(), "ip:10.0.0.1")
+
+	assert.NoError(t, err)
+	assert.Nil(t, attempt)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestLoginAttemptsStorage_RegisterAttempt(t *testing.T) {
+	now := time.Now()
+	waits := []time.Duration{time.Second, time.Minute}
+
+	tests := []struct {
+		name     string
+		rows     *pgxmock.Rows
+		expected int // 0 - попытка не засчитана
+	}{
+		{
+			name:     "попытка засчитана",
+			rows:     pgxmock.NewRows(loginAttemptColumns).AddRow("account:test@example.com", 2, now, now.Add(time.Minute)),
+			expected: 2,
+		},
+		{
+			// Условие WHERE не выполнилось: строка не изменилась и не вернулась
+			name: "ключ заблокирован",
+			rows: pgxmock.NewRows(loginAttemptColumns),
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock, err := pgxmock.NewPool()
+			require.NoError(t, err)
+			defer mock.Close()
+
+			storage := NewLoginAttemptsStorage(mock)
+			mock.ExpectQuery(`INSERT INTO login_attempts AS a \(key, failures, last_failure_at, blocked_until\)
+		VALUES \(@key, 1, @now::timestamp, @now::timestamp \+ \(@waits::interval\[\]\)\[1\]\)
+		ON CONFLICT \(key\) DO UPDATE`).
+				WithArgs("account:test@example.com", now.UTC(), waits, now.Add(-time.Hour).UTC()).
+				WillReturnRows(tt.rows)
+
+			attempt, err := storage.RegisterAttempt(context.Background(), "account:test@example.com", now, now.Add(-time.Hour), waits)
+
+			require.NoError(t, err)
+			if tt.expected == 0 {
+				assert.Nil(t, attempt)
+			} else {
+				require.NotNil(t, attempt)
+				assert.Equal(t, tt.expected, attempt.Failures)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestLoginAttemptsStorage_Release(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	storage := NewLoginAttemptsStorage(mock)
+
+	mock.ExpectExec(`UPDATE login_attempts\s+SET failures = GREATEST\(failures - 1, 0\), blocked_until = last_failure_at\s+WHERE key = @key`).
+		WithArgs("ip:10.0.0.1").
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+
+	assert.NoError(t, storage.Release(context.Background(), "ip:10.0.0.1"))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestLoginAttemptsStorage_Reset(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	storage := NewLoginAttemptsStorage(mock)
+
+	mock.ExpectExec(`DELETE FROM login_attempts WHERE key = @key`).
+		WithArgs(pgxmock.AnyArg()).
+		WillReturnResult(pgxmock.NewResult("DELETE", 1))
+	mock.ExpectExec(`DELETE FROM login_attempts WHERE key = @key`).
+		WithArgs(pgxmock.AnyArg()).
+		WillReturnError(errors.New("connection lost"))
+
+	assert.NoError(t, storage.Reset(context.Background(), "account:test@example.com"))
+	err = storage.Reset(context.Background(), "account:test@example.com")
+	assert.ErrorContains(t, err, "connection lost")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,110 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/LigeronAhill/luxcarpets-go/internal/database/types"
+	"github.com/LigeronAhill/luxcarpets-go/pkg/utils"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// MagicLinksStorage хранит одноразовые ссылки для входа по email
+type MagicLinksStorage struct {
+	pool PgxPoolIface
+}
+
+func NewMagicLinksStorage(pool PgxPoolIface) *MagicLinksStorage {
+	return &MagicLinksStorage{
+		pool: pool,
+	}
+}
+
+// Create сохраняет новую ссылку
+func (s *MagicLinksStorage) Create(ctx context.Context, params types.CreateMagicLinkParams) (*types.MagicLink, error) {
+	op := "create magic link for " + params.Email
+	query := `
+		INSERT INTO magic_links (email, token_hash, nonce_hash, expires_at)
+		VALUES (@email, @token_hash, @nonce_hash, @expires_at)
+		RETURNING *
+	`
+	args := pgx.NamedArgs{
+		"email":      params.Email,
+		"token_hash": params.TokenHash,
+		"nonce_hash": params.NonceHash,
+		"expires_at": params.ExpiresAt,
+	}
+	rows, err := s.pool.Query(ctx, query, args)
+	if err != nil {
+		return nil, utils.Wrap(op, err)
+	}
+	defer rows.Close()
+	res, err := pgx.CollectExactlyOneRow(rows, pgx.RowToAddrOfStructByName[types.MagicLink])
+	if err != nil {
+		return nil, utils.Wrap(op, err)
+	}
+	return res, nil
+}
+
+// GetByHash возвращает ссылку по хешу токена или nil, если такой ссылки нет
+func (s *MagicLinksStorage) GetByHash(ctx context.Context, tokenHash string) (*types.MagicLink, error) {
+	op := "get magic link by hash"
+	query := `
+		SELECT * FROM magic_links WHERE token_hash = @token_hash
+	`
+	args := pgx.NamedArgs{
+		"token_hash": tokenHash,
+	}
+	rows, err := s.pool.Query(ctx, query, args)
+	if err != nil {
+		return nil, utils.Wrap(op, err)
+	}
+	defer rows.Close()
+	res, err := pgx.CollectExactlyOneRow(rows, pgx.RowToAddrOfStructByName[types.MagicLink])
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, utils.Wrap(op, err)
+	}
+	return res, nil
+}
+
+// Consume отмечает ссылку использованной. Возвращает false, если ссылка уже использована
+// или истекла - из двух одновременных запросов с одной ссылкой успешен только один.
+func (s *MagicLinksStorage) Consume(ctx context.Context, id uuid.UUID, now time.Time) (bool, error) {
+	op := "consume magic link " + id.String()
+	query := `
+		UPDATE magic_links
+		SET consumed_at = @now
+		WHERE id = @id AND consumed_at IS NULL AND expires_at > @now
+	`
+	args := pgx.NamedArgs{
+		"id":  id,
+		"now": now,
+	}
+	tag, err := s.pool.Exec(ctx, query, args)
+	if err != nil {
+		return false, utils.Wrap(op, err)
+	}
+	return tag.RowsAffected() == 1, nil
+}
+
+// CountSince возвращает количество ссылок, выданных на email начиная с since
+func (s *MagicLinksStorage) CountSince(ctx context.Context, email string, since time.Time) (int, error) {
+	op := "count magic links for " + email
+	query := `
+		SELECT COUNT(*) FROM magic_links WHERE email = @email AND created_at >= @since
+	`
+	args := pgx.NamedArgs{
+		"email": email,
+		"since": since,
+	}
+	var count int
+	if err := s.pool.QueryRow(ctx, query, args).Scan(&count); err != nil {
+		return 0, utils.Wrap(op, err)
+	}
+	return count, nil
+}
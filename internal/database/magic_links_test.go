@@ -0,0 +1,154 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/LigeronAhill/luxcarpets-go/internal/database/types"
+	"github.com/google/uuid"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var magicLinkColumns = []string{
+	"id", "email", "token_hash", "nonce_hash", "expires_at", "consumed_at", "created_at",
+}
+
+func TestMagicLinksStorage_Create(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	storage := NewMagicLinksStorage(mock)
+	linkID := uuid.New()
+	now := time.Now()
+	expiresAt := now.Add(15 * time.Minute)
+
+	mock.ExpectQuery(`INSERT INTO magic_links \(email, token_hash, nonce_hash, expires_at\)`).
+		WithArgs("user@test.com", "token-hash", "nonce-hash", expiresAt).
+		WillReturnRows(pgxmock.NewRows(magicLinkColumns).AddRow(
+			linkID, "user@test.com", "token-hash", "nonce-hash", expiresAt, nil, now,
+		))
+
+	res, err := storage.Create(context.Background(), types.CreateMagicLinkParams{
+		Email:     "user@test.com",
+		TokenHash: "token-hash",
+		NonceHash: "nonce-hash",
+		ExpiresAt: expiresAt,
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, linkID, res.ID)
+	assert.True(t, res.Usable(now))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMagicLinksStorage_GetByHash(t *testing.T) {
+	now := time.Now()
+	consumedAt := now.Add(-time.Minute)
+
+	tests := []struct {
+		name       string
+		rows       *pgxmock.Rows
+		wantNil    bool
+		wantUsable bool
+	}{
+		{
+			name: "действующая ссылка",
+			rows: pgxmock.NewRows(magicLinkColumns).AddRow(
+				uuid.New(), "user@test.com", "hash", "nonce", now.Add(time.Minute), nil, now,
+			),
+			wantUsable: true,
+		},
+		{
+			name: "использованная ссылка",
+			rows: pgxmock.NewRows(magicLinkColumns).AddRow(
+				uuid.New(), "user@test.com", "hash", "nonce", now.Add(time.Minute), &consumedAt, now,
+			),
+		},
+		{
+			name:    "ссылка не найдена",
+			rows:    pgxmock.NewRows(magicLinkColumns),
+			wantNil: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock, err := pgxmock.NewPool()
+			require.NoError(t, err)
+			defer mock.Close()
+
+			storage := NewMagicLinksStorage(mock)
+
+			mock.ExpectQuery(`SELECT \* FROM magic_links WHERE token_hash = @token_hash`).
+				WithArgs("hash").
+				WillReturnRows(tt.rows)
+
+			res, err := storage.GetByHash(context.Background(), "hash")
+
+			require.NoError(t, err)
+			if tt.wantNil {
+				assert.Nil(t, res)
+			} else {
+				require.NotNil(t, res)
+				assert.Equal(t, tt.wantUsable, res.Usable(now))
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestMagicLinksStorage_Consume(t *testing.T) {
+	tests := []struct {
+		name     string
+		affected int64
+		want     bool
+	}{
+		{name: "ссылка использована впервые", affected: 1, want: true},
+		{name: "ссылка уже использована", affected: 0, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock, err := pgxmock.NewPool()
+			require.NoError(t, err)
+			defer mock.Close()
+
+			storage := NewMagicLinksStorage(mock)
+			linkID := uuid.New()
+			now := time.Now()
+
+			mock.ExpectExec(`UPDATE magic_links\s+SET consumed_at = @now\s+WHERE id = @id AND consumed_at IS NULL`).
+				WithArgs(now, linkID).
+				WillReturnResult(pgxmock.NewResult("UPDATE", tt.affected))
+
+			consumed, err := storage.Consume(context.Background(), linkID, now)
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, consumed)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestMagicLinksStorage_CountSince(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	storage := NewMagicLinksStorage(mock)
+	since := time.Now().Add(-time.Hour)
+
+	mock.ExpectQuery(`SELECT COUNT\(\*\) FROM magic_links WHERE email = @email AND created_at >= @since`).
+		WithArgs("user@test.com", since).
+		WillReturnRows(pgxmock.NewRows([]string{"count"}).AddRow(3))
+
+	count, err := storage.CountSince(context.Background(), "user@test.com", since)
+
+	require.NoError(t, err)
+	assert.Equal(t, 3, count)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,218 @@
+package database
+
+import (
+	"context"
+	"errors"
+
+	"github.com/LigeronAhill/luxcarpets-go/internal/database/types"
+	"github.com/LigeronAhill/luxcarpets-go/pkg/utils"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// MediaStorage хранит изображения и галереи товаров
+type MediaStorage struct {
+	pool PgxPoolIface
+}
+
+func NewMediaStorage(pool PgxPoolIface) *MediaStorage {
+	return &MediaStorage{
+		pool: pool,
+	}
+}
+
+// GetByHash возвращает изображение по SHA-256 содержимого или nil, если такого изображения нет
+func (s *MediaStorage) GetByHash(ctx context.Context, contentHash string) (*types.Media, error) {
+	op := "get media by hash " + contentHash
+	query := `
+		SELECT * FROM media WHERE content_hash = @content_hash
+	`
+	args := pgx.NamedArgs{
+		"content_hash": contentHash,
+	}
+	rows, err := s.pool.Query(ctx, query, args)
+	if err != nil {
+		return nil, utils.Wrap(op, err)
+	}
+	defer rows.Close()
+	res, err := pgx.CollectExactlyOneRow(rows, pgx.RowToAddrOfStructByName[types.Media])
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, utils.Wrap(op, err)
+	}
+	return res, nil
+}
+
+// Create сохраняет изображение. Если изображение с таким содержимым уже сохранено
+// (одновременная загрузка одного файла), возвращает существующую запись.
+func (s *MediaStorage) Create(ctx context.Context, params types.CreateMediaParams) (*types.Media, error) {
+	op := "create media " + params.ContentHash
+	query := `
+		INSERT INTO media (content_hash, width, height, dominant_color, color_family, renditions)
+		VALUES (@content_hash, @width, @height, @dominant_color, @color_family, @renditions)
+		ON CONFLICT (content_hash) DO UPDATE SET content_hash = EXCLUDED.content_hash
+		RETURNING *
+	`
+	args := pgx.NamedArgs{
+		"content_hash":   params.ContentHash,
+		"width":          params.Width,
+		"height":         params.Height,
+		"dominant_color": params.DominantColor,
+		"color_family":   params.ColorFamily,
+		"renditions":     params.Renditions,
+	}
+	rows, err := s.pool.Query(ctx, query, args)
+	if err != nil {
+		return nil, utils.Wrap(op, err)
+	}
+	defer rows.Close()
+	res, err := pgx.CollectExactlyOneRow(rows, pgx.RowToAddrOfStructByName[types.Media])
+	if err != nil {
+		return nil, utils.Wrap(op, err)
+	}
+	return res, nil
+}
+
+// Attach добавляет изображение в конец галереи товара
+func (s *MediaStorage) Attach(ctx context.Context, params types.AttachMediaParams) (*types.ProductMedia, error) {
+	op := "attach media to product " + params.ProductID.String()
+	query := `
+		INSERT INTO product_media (product_id, variant_id, media_id, alt_text, position)
+		SELECT @product_id, @variant_id, @media_id, @alt_text, COALESCE(MAX(position) + 1, 0)
+		FROM product_media
+		WHERE product_id = @product_id
+		RETURNING *
+	`
+	args := pgx.NamedArgs{
+		"product_id": params.ProductID,
+		"variant_id": params.VariantID,
+		"media_id":   params.MediaID,
+		"alt_text":   params.AltText,
+	}
+	rows, err := s.pool.Query(ctx, query, args)
+	if err != nil {
+		return nil, utils.Wrap(op, err)
+	}
+	defer rows.Close()
+	res, err := pgx.CollectExactlyOneRow(rows, pgx.RowToAddrOfStructByName[types.ProductMedia])
+	if err != nil {
+		return nil, utils.Wrap(op, err)
+	}
+	return res, nil
+}
+
+// Gallery возвращает изображения товара и его вариантов в порядке галереи
+func (s *MediaStorage) Gallery(ctx context.Context, productID uuid.UUID) ([]*types.GalleryImage, error) {
+	op := "get gallery of product " + productID.String()
+	query := `
+		SELECT pm.*, m.width, m.height, m.dominant_color, m.renditions
+		FROM product_media pm
+		JOIN media m ON m.id = pm.media_id
+		WHERE pm.product_id = @product_id
+		ORDER BY pm.position, pm.created_at
+	`
+	args := pgx.NamedArgs{
+		"product_id": productID,
+	}
+	rows, err := s.pool.Query(ctx, query, args)
+	if err != nil {
+		return nil, utils.Wrap(op, err)
+	}
+	defer rows.Close()
+	res, err := pgx.CollectRows(rows, pgx.RowToAddrOfStructByName[types.GalleryImage])
+	if err != nil {
+		return nil, utils.Wrap(op, err)
+	}
+	return res, nil
+}
+
+// Reorder задает порядок галереи: позиция изображения равна его индексу в ids.
+// Возвращает количество обновленных записей - изображения других товаров не затрагиваются.
+func (s *MediaStorage) Reorder(ctx context.Context, productID uuid.UUID, ids []uuid.UUID) (int64, error) {
+	op := "reorder gallery of product " + productID.String()
+	query := `
+		UPDATE product_media
+		SET position = array_position(@ids::uuid[], id) - 1
+		WHERE product_id = @product_id AND id = ANY(@ids::uuid[])
+	`
+	args := pgx.NamedArgs{
+		"product_id": productID,
+		"ids":        ids,
+	}
+	res, err := s.pool.Exec(ctx, query, args)
+	if err != nil {
+		return 0, utils.Wrap(op, err)
+	}
+	return res.RowsAffected(), nil
+}
+
+// UpdateAltText изменяет альтернативный текст изображения галереи
+func (s *MediaStorage) UpdateAltText(ctx context.Context, productID, id uuid.UUID, altText string) (*types.ProductMedia, error) {
+	op := "update alt text of product media " + id.String()
+	query := `
+		UPDATE product_media
+		SET alt_text = @alt_text
+		WHERE id = @id AND product_id = @product_id
+		RETURNING *
+	`
+	args := pgx.NamedArgs{
+		"id":         id,
+		"product_id": productID,
+		"alt_text":   altText,
+	}
+	rows, err := s.pool.Query(ctx, query, args)
+	if err != nil {
+		return nil, utils.Wrap(op, err)
+	}
+	defer rows.Close()
+	res, err := pgx.CollectExactlyOneRow(rows, pgx.RowToAddrOfStructByName[types.ProductMedia])
+	if err != nil {
+		return nil, utils.Wrap(op, err)
+	}
+	return res, nil
+}
+
+// Detach удаляет изображение из галереи. Само изображение остается - оно может
+// использоваться в галереях других товаров. Возвращает false, если записи не было.
+func (s *MediaStorage) Detach(ctx context.Context, productID, id uuid.UUID) (bool, error) {
+	op := "detach product media " + id.String()
+	query := `
+		DELETE FROM product_media WHERE id = @id AND product_id = @product_id
+	`
+	args := pgx.NamedArgs{
+		"id":         id,
+		"product_id": productID,
+	}
+	res, err := s.pool.Exec(ctx, query, args)
+	if err != nil {
+		return false, utils.Wrap(op, err)
+	}
+	return res.RowsAffected() > 0, nil
+}
+
+// RefreshProductColor копирует цвет главного изображения товара (первого в галерее,
+// не относящегося к варианту) в товар для фильтра каталога. Без изображений цвет сбрасывается.
+func (s *MediaStorage) RefreshProductColor(ctx context.Context, productID uuid.UUID) error {
+	op := "refresh color of product " + productID.String()
+	query := `
+		UPDATE products
+		SET (color_family, dominant_color) = (
+			SELECT m.color_family, m.dominant_color
+			FROM product_media pm
+			JOIN media m ON m.id = pm.media_id
+			WHERE pm.product_id = @product_id AND pm.variant_id IS NULL
+			ORDER BY pm.position, pm.created_at
+			LIMIT 1
+		)
+		WHERE id = @product_id
+	`
+	args := pgx.NamedArgs{
+		"product_id": productID,
+	}
+	if _, err := s.pool.Exec(ctx, query, args); err != nil {
+		return utils.Wrap(op, err)
+	}
+	return nil
+}
@@ -0,0 +1,194 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/LigeronAhill/luxcarpets-go/internal/database/types"
+	"github.com/google/uuid"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var mediaColumns = []string{
+	"id", "content_hash", "width", "height", "dominant_color", "color_family", "renditions", "created_at",
+}
+
+var productMediaColumns = []string{
+	"id", "product_id", "variant_id", "media_id", "position", "alt_text", "created_at",
+}
+
+func TestMediaStorage_GetByHash_NotFound(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	storage := NewMediaStorage(mock)
+
+	mock.ExpectQuery(`SELECT \* FROM media WHERE content_hash = @content_hash`).
+		WithArgs("hash").
+		WillReturnRows(pgxmock.NewRows(mediaColumns))
+
+	res, err := storage.GetByHash(context.Background(), "hash")
+
+	assert.NoError(t, err)
+	assert.Nil(t, res)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMediaStorage_Create(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	storage := NewMediaStorage(mock)
+	mediaID := uuid.New()
+	renditions := []types.Rendition{{Width: 320, Height: 240, Format: "jpg", Key: "media/ab/abcd/320.jpg"}}
+
+	mock.ExpectQuery(`INSERT INTO media .+ ON CONFLICT \(content_hash\) DO UPDATE`).
+		WithArgs("abcd", 1600, 1200, "#c8b496", types.ColorBeige, renditions).
+		WillReturnRows(pgxmock.NewRows(mediaColumns).AddRow(
+			mediaID, "abcd", 1600, 1200, "#c8b496", types.ColorBeige, renditions, time.Now(),
+		))
+
+	res, err := storage.Create(context.Background(), types.CreateMediaParams{
+		ContentHash:   "abcd",
+		Width:         1600,
+		Height:        1200,
+		DominantColor: "#c8b496",
+		ColorFamily:   types.ColorBeige,
+		Renditions:    renditions,
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, mediaID, res.ID)
+	assert.Equal(t, renditions, res.Renditions)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMediaStorage_Attach(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	storage := NewMediaStorage(mock)
+	productID := uuid.New()
+	mediaID := uuid.New()
+	itemID := uuid.New()
+
+	mock.ExpectQuery(`INSERT INTO product_media .+COALESCE\(MAX\(position\) \+ 1, 0\)`).
+		WithArgs(productID, (*uuid.UUID)(nil), mediaID, "Ковер в гостиной").
+		WillReturnRows(pgxmock.NewRows(productMediaColumns).AddRow(
+			itemID, productID, nil, mediaID, 2, "Ковер в гостиной", time.Now(),
+		))
+
+	res, err := storage.Attach(context.Background(), types.AttachMediaParams{
+		ProductID: productID,
+		MediaID:   mediaID,
+		AltText:   "Ковер в гостиной",
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, itemID, res.ID)
+	assert.Equal(t, 2, res.Position)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMediaStorage_Gallery(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	storage := NewMediaStorage(mock)
+	productID := uuid.New()
+	variantID := uuid.New()
+	now := time.Now()
+	renditions := []types.Rendition{{Width: 320, Height: 240, Format: "jpg", Key: "k/320.jpg"}}
+
+	mock.ExpectQuery(`SELECT pm\.\*, m\.width, m\.height, m\.dominant_color, m\.renditions\s+FROM product_media pm`).
+		WithArgs(productID).
+		WillReturnRows(pgxmock.NewRows(append(productMediaColumns, "width", "height", "dominant_color", "renditions")).
+			AddRow(uuid.New(), productID, nil, uuid.New(), 0, "Общий вид", now, 1600, 1200, "#c8b496", renditions).
+			AddRow(uuid.New(), productID, &variantID, uuid.New(), 1, "Фактура ворса", now, 800, 800, "#808080", renditions))
+
+	res, err := storage.Gallery(context.Background(), productID)
+
+	require.NoError(t, err)
+	require.Len(t, res, 2)
+	assert.Equal(t, "Общий вид", res[0].AltText)
+	assert.Equal(t, 1600, res[0].Width)
+	assert.Nil(t, res[0].VariantID)
+	assert.Equal(t, &variantID, res[1].VariantID)
+	assert.Equal(t, renditions, res[1].Renditions)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMediaStorage_Reorder(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	storage := NewMediaStorage(mock)
+	productID := uuid.New()
+	ids := []uuid.UUID{uuid.New(), uuid.New()}
+
+	mock.ExpectExec(`UPDATE product_media\s+SET position = array_position`).
+		WithArgs(ids, productID).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 2))
+
+	affected, err := storage.Reorder(context.Background(), productID, ids)
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), affected)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMediaStorage_Detach(t *testing.T) {
+	tests := []struct {
+		name     string
+		affected int64
+		want     bool
+	}{
+		{name: "изображение удалено из галереи", affected: 1, want: true},
+		{name: "изображение не найдено", affected: 0, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock, err := pgxmock.NewPool()
+			require.NoError(t, err)
+			defer mock.Close()
+
+			storage := NewMediaStorage(mock)
+			productID := uuid.New()
+			itemID := uuid.New()
+
+			mock.ExpectExec(`DELETE FROM product_media WHERE id = @id AND product_id = @product_id`).
+				WithArgs(itemID, productID).
+				WillReturnResult(pgxmock.NewResult("DELETE", tt.affected))
+
+			deleted, err := storage.Detach(context.Background(), productID, itemID)
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, deleted)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestMediaStorage_RefreshProductColor(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	storage := NewMediaStorage(mock)
+	productID := uuid.New()
+
+	mock.ExpectExec(`UPDATE products\s+SET \(color_family, dominant_color\) = \(`).
+		WithArgs(productID).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+
+	require.NoError(t, storage.RefreshProductColor(context.Background(), productID))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
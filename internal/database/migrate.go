@@ -16,7 +16,11 @@ var migrationFS embed.FS
 
 const versionTable = "schema_version"
 
-func migrateDB(ctx context.Context, dbURL string) error {
+// Migrate применяет к базе встроенные миграции, которые еще не применены.
+// Выполняется отдельной командой (server migrate) до запуска или обновления сервера,
+// а не при каждом запуске: несколько реплик не применяют миграции одновременно,
+// а сервер с устаревшей схемой не отвечает на /readyz.
+func Migrate(ctx context.Context, dbURL string) error {
 	conn, err := pgx.Connect(ctx, dbURL)
 	if err != nil {
 		return fmt.Errorf("error connecting to DB: %w", err)
@@ -28,9 +32,6 @@ func migrateDB(ctx context.Context, dbURL string) error {
 		return fmt.Errorf("error getting file system: %w", err)
 	}
 
-	if _, err = conn.Exec(context.Background(), fmt.Sprintf("drop table if exists %s", versionTable)); err != nil {
-		return fmt.Errorf("error cleaning versions table: %w", err)
-	}
 	m, err := migrate.NewMigrator(ctx, conn, versionTable)
 	if err != nil {
 		return fmt.Errorf("error creating migrator: %w", err)
@@ -39,9 +40,14 @@ func migrateDB(ctx context.Context, dbURL string) error {
 	if err = m.LoadMigrations(fsys); err != nil {
 		return fmt.Errorf("error loading migrations: %w", err)
 	}
+	from, err := m.GetCurrentVersion(ctx)
+	if err != nil {
+		return fmt.Errorf("error getting schema version: %w", err)
+	}
 	slog.Debug("migrations loaded", slog.Int("count", len(m.Migrations)))
 	if err = m.Migrate(ctx); err != nil {
 		return fmt.Errorf("error applying migrations: %w", err)
 	}
+	slog.Info("Migrations applied", slog.Int("from", int(from)), slog.Int("to", len(m.Migrations)))
 	return nil
 }
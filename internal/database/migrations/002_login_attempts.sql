@@ -0,0 +1,30 @@
+-- +tern:Up
+-- Создаем таблицу неудачных попыток входа
+CREATE TABLE IF NOT EXISTS login_attempts (
+  key VARCHAR(320) PRIMARY KEY,
+  failures INTEGER NOT NULL DEFAULT 0,
+  last_failure_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  blocked_until TIMESTAMP NOT NULL DEFAULT NOW(),
+  CONSTRAINT chk_failures_positive CHECK (failures >= 0)
+);
+
+-- Создаем индексы
+CREATE INDEX IF NOT EXISTS idx_login_attempts_last_failure_at ON login_attempts (last_failure_at);
+
+-- Комментарии
+COMMENT ON TABLE login_attempts IS 'Счетчики неудачных попыток входа для защиты от перебора';
+
+COMMENT ON COLUMN login_attempts.key IS 'Ключ счетчика: account:<email> или ip:<адрес>';
+
+COMMENT ON COLUMN login_attempts.failures IS 'Количество попыток подряд без успешного входа';
+
+COMMENT ON COLUMN login_attempts.last_failure_at IS 'Время последней засчитанной попытки';
+
+COMMENT ON COLUMN login_attempts.blocked_until IS 'До какого времени следующая попытка не засчитывается';
+
+---- create above / drop below ----
+-- Удаляем индексы
+DROP INDEX IF EXISTS idx_login_attempts_last_failure_at;
+
+-- Удаляем таблицу
+DROP TABLE IF EXISTS login_attempts;
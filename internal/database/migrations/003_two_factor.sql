@@ -0,0 +1,57 @@
+-- +tern:Up
+-- Создаем таблицу TOTP-секретов пользователей
+CREATE TABLE IF NOT EXISTS user_totp (
+  user_id UUID PRIMARY KEY REFERENCES users (id) ON DELETE CASCADE,
+  secret_encrypted BYTEA NOT NULL,
+  confirmed_at TIMESTAMP,
+  last_used_step BIGINT NOT NULL DEFAULT 0,
+  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Создаем таблицу кодов восстановления
+CREATE TABLE IF NOT EXISTS user_recovery_codes (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+  code_hash VARCHAR(64) NOT NULL,
+  used_at TIMESTAMP,
+  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  CONSTRAINT uq_user_recovery_codes_user_code UNIQUE (user_id, code_hash)
+);
+
+-- Создаем индексы
+CREATE INDEX IF NOT EXISTS idx_user_recovery_codes_user_id ON user_recovery_codes (user_id)
+WHERE
+  used_at IS NULL;
+
+-- Создаем триггер для автоматического обновления updated_at
+CREATE OR REPLACE TRIGGER update_user_totp_updated_at BEFORE
+UPDATE ON user_totp FOR EACH ROW
+EXECUTE FUNCTION update_updated_at_column ();
+
+-- Комментарии
+COMMENT ON TABLE user_totp IS 'TOTP-секреты для двухфакторной аутентификации';
+
+COMMENT ON COLUMN user_totp.secret_encrypted IS 'Секрет, зашифрованный AES-256-GCM (nonce || ciphertext)';
+
+COMMENT ON COLUMN user_totp.confirmed_at IS 'Время подтверждения подключения - если NULL, подключение не завершено';
+
+COMMENT ON COLUMN user_totp.last_used_step IS 'Последний принятый шаг времени для защиты от повторного использования кода';
+
+COMMENT ON TABLE user_recovery_codes IS 'Одноразовые коды восстановления доступа при потере устройства';
+
+COMMENT ON COLUMN user_recovery_codes.code_hash IS 'SHA-256 хеш кода восстановления (hex)';
+
+COMMENT ON COLUMN user_recovery_codes.used_at IS 'Время использования - если NULL, код действителен';
+
+---- create above / drop below ----
+-- Удаляем триггер
+DROP TRIGGER IF EXISTS update_user_totp_updated_at ON user_totp;
+
+-- Удаляем индексы
+DROP INDEX IF EXISTS idx_user_recovery_codes_user_id;
+
+-- Удаляем таблицы
+DROP TABLE IF EXISTS user_recovery_codes;
+
+DROP TABLE IF EXISTS user_totp;
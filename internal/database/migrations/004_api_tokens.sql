@@ -0,0 +1,41 @@
+-- +tern:Up
+-- Создаем таблицу персональных токенов доступа к API
+CREATE TABLE IF NOT EXISTS api_tokens (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+  name VARCHAR(100) NOT NULL,
+  token_prefix VARCHAR(16) NOT NULL,
+  token_hash VARCHAR(64) NOT NULL UNIQUE,
+  scopes TEXT[] NOT NULL,
+  expires_at TIMESTAMP NOT NULL,
+  last_used_at TIMESTAMP,
+  revoked_at TIMESTAMP,
+  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  CONSTRAINT chk_api_tokens_name_length CHECK (LENGTH(name) BETWEEN 1 AND 100),
+  CONSTRAINT chk_api_tokens_scopes_not_empty CHECK (CARDINALITY(scopes) > 0)
+);
+
+-- Создаем индексы
+CREATE INDEX IF NOT EXISTS idx_api_tokens_user_id ON api_tokens (user_id)
+WHERE
+  revoked_at IS NULL;
+
+-- Комментарии
+COMMENT ON TABLE api_tokens IS 'Персональные токены доступа к API для интеграций и сервисных учетных записей';
+
+COMMENT ON COLUMN api_tokens.token_prefix IS 'Начало токена для отображения в списке (lxc_pat_XXXX)';
+
+COMMENT ON COLUMN api_tokens.token_hash IS 'SHA-256 хеш токена (hex) - сам токен не хранится';
+
+COMMENT ON COLUMN api_tokens.scopes IS 'Запрошенные права; фактические права ограничиваются текущей ролью пользователя';
+
+COMMENT ON COLUMN api_tokens.last_used_at IS 'Время последнего использования (обновляется не чаще раза в минуту)';
+
+COMMENT ON COLUMN api_tokens.revoked_at IS 'Время отзыва - если NULL, токен действует до expires_at';
+
+---- create above / drop below ----
+-- Удаляем индексы
+DROP INDEX IF EXISTS idx_api_tokens_user_id;
+
+-- Удаляем таблицу
+DROP TABLE IF EXISTS api_tokens;
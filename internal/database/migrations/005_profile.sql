@@ -0,0 +1,109 @@
+-- +tern:Up
+-- Добавляем телефон пользователя в формате E.164
+ALTER TABLE users
+ADD COLUMN IF NOT EXISTS phone VARCHAR(16);
+
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'chk_users_phone') THEN
+        ALTER TABLE users ADD CONSTRAINT chk_users_phone CHECK (phone ~ '^\+[1-9][0-9]{7,14}$');
+    END IF;
+END$$;
+
+-- Создаем таблицу адресов доставки
+CREATE TABLE IF NOT EXISTS user_addresses (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+  label VARCHAR(50),
+  recipient_name VARCHAR(255) NOT NULL,
+  recipient_phone VARCHAR(16) NOT NULL,
+  postal_code VARCHAR(6),
+  region VARCHAR(255) NOT NULL,
+  city VARCHAR(255) NOT NULL,
+  street VARCHAR(255) NOT NULL,
+  house VARCHAR(20) NOT NULL,
+  building VARCHAR(20),
+  apartment VARCHAR(20),
+  entrance VARCHAR(10),
+  intercom VARCHAR(20),
+  floor SMALLINT,
+  elevator VARCHAR(16) NOT NULL DEFAULT 'none',
+  comment VARCHAR(500),
+  is_default BOOLEAN NOT NULL DEFAULT FALSE,
+  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  CONSTRAINT chk_user_addresses_recipient_phone CHECK (recipient_phone ~ '^\+[1-9][0-9]{7,14}$'),
+  CONSTRAINT chk_user_addresses_postal_code CHECK (postal_code ~ '^[0-9]{6}$'),
+  CONSTRAINT chk_user_addresses_floor CHECK (floor BETWEEN -5 AND 200),
+  CONSTRAINT chk_user_addresses_elevator CHECK (elevator IN ('none', 'passenger', 'freight'))
+);
+
+-- Создаем таблицу предпочтений доставки
+CREATE TABLE IF NOT EXISTS user_delivery_preferences (
+  user_id UUID PRIMARY KEY REFERENCES users (id) ON DELETE CASCADE,
+  time_slot VARCHAR(16) NOT NULL DEFAULT 'any',
+  call_before_delivery BOOLEAN NOT NULL DEFAULT TRUE,
+  lifting_required BOOLEAN NOT NULL DEFAULT FALSE,
+  comment VARCHAR(500),
+  updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  CONSTRAINT chk_user_delivery_preferences_time_slot CHECK (time_slot IN ('any', 'morning', 'afternoon', 'evening'))
+);
+
+-- Создаем индексы
+CREATE INDEX IF NOT EXISTS idx_user_addresses_user_id ON user_addresses (user_id);
+
+-- У пользователя может быть только один адрес по умолчанию
+CREATE UNIQUE INDEX IF NOT EXISTS uq_user_addresses_default ON user_addresses (user_id)
+WHERE
+  is_default;
+
+-- Создаем триггеры для автоматического обновления updated_at
+CREATE OR REPLACE TRIGGER update_user_addresses_updated_at BEFORE
+UPDATE ON user_addresses FOR EACH ROW
+EXECUTE FUNCTION update_updated_at_column ();
+
+CREATE OR REPLACE TRIGGER update_user_delivery_preferences_updated_at BEFORE
+UPDATE ON user_delivery_preferences FOR EACH ROW
+EXECUTE FUNCTION update_updated_at_column ();
+
+-- Комментарии
+COMMENT ON COLUMN users.phone IS 'Телефон в формате E.164';
+
+COMMENT ON TABLE user_addresses IS 'Сохраненные адреса доставки';
+
+COMMENT ON COLUMN user_addresses.building IS 'Корпус или строение';
+
+COMMENT ON COLUMN user_addresses.floor IS 'Этаж - нужен для расчета подъема рулонов';
+
+COMMENT ON COLUMN user_addresses.elevator IS 'Лифт: none - нет, passenger - пассажирский, freight - грузовой';
+
+COMMENT ON COLUMN user_addresses.is_default IS 'Адрес по умолчанию при оформлении заказа';
+
+COMMENT ON TABLE user_delivery_preferences IS 'Предпочтения пользователя по доставке';
+
+COMMENT ON COLUMN user_delivery_preferences.time_slot IS 'Удобное время доставки: any, morning, afternoon, evening';
+
+COMMENT ON COLUMN user_delivery_preferences.lifting_required IS 'Нужен подъем на этаж';
+
+---- create above / drop below ----
+-- Удаляем триггеры
+DROP TRIGGER IF EXISTS update_user_delivery_preferences_updated_at ON user_delivery_preferences;
+
+DROP TRIGGER IF EXISTS update_user_addresses_updated_at ON user_addresses;
+
+-- Удаляем индексы
+DROP INDEX IF EXISTS uq_user_addresses_default;
+
+DROP INDEX IF EXISTS idx_user_addresses_user_id;
+
+-- Удаляем таблицы
+DROP TABLE IF EXISTS user_delivery_preferences;
+
+DROP TABLE IF EXISTS user_addresses;
+
+-- Удаляем телефон пользователя
+ALTER TABLE users
+DROP CONSTRAINT IF EXISTS chk_users_phone;
+
+ALTER TABLE users
+DROP COLUMN IF EXISTS phone;
@@ -0,0 +1,90 @@
+-- +tern:Up
+-- Пользователи, вошедшие по телефону, могут не иметь email
+ALTER TABLE users
+ALTER COLUMN email
+DROP NOT NULL;
+
+ALTER TABLE users
+ADD COLUMN IF NOT EXISTS phone_verified BOOLEAN NOT NULL DEFAULT FALSE;
+
+DO $$
+BEGIN
+    IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'chk_users_email_or_phone') THEN
+        ALTER TABLE users ADD CONSTRAINT chk_users_email_or_phone CHECK (email IS NOT NULL OR phone IS NOT NULL);
+    END IF;
+END$$;
+
+-- Подтвержденный телефон однозначно определяет учетную запись
+CREATE UNIQUE INDEX IF NOT EXISTS uq_users_verified_phone ON users (phone)
+WHERE
+  phone_verified
+  AND deleted_at IS NULL;
+
+-- Создаем таблицу одноразовых кодов входа по телефону
+CREATE TABLE IF NOT EXISTS phone_otp_codes (
+  phone VARCHAR(16) PRIMARY KEY,
+  code_hash VARCHAR(64) NOT NULL,
+  attempts INTEGER NOT NULL DEFAULT 0,
+  expires_at TIMESTAMP NOT NULL,
+  sent_at TIMESTAMP NOT NULL,
+  send_count INTEGER NOT NULL DEFAULT 1,
+  window_started_at TIMESTAMP NOT NULL,
+  CONSTRAINT chk_phone_otp_codes_phone CHECK (phone ~ '^\+[1-9][0-9]{7,14}$'),
+  CONSTRAINT chk_phone_otp_codes_attempts CHECK (attempts >= 0)
+);
+
+-- Создаем таблицу сессий браузера
+CREATE TABLE IF NOT EXISTS sessions (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  user_id UUID NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+  token_hash VARCHAR(64) NOT NULL UNIQUE,
+  user_agent VARCHAR(512),
+  ip_address VARCHAR(45),
+  expires_at TIMESTAMP NOT NULL,
+  last_seen_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Создаем индексы
+CREATE INDEX IF NOT EXISTS idx_sessions_user_id ON sessions (user_id);
+
+CREATE INDEX IF NOT EXISTS idx_sessions_expires_at ON sessions (expires_at);
+
+-- Комментарии
+COMMENT ON COLUMN users.phone_verified IS 'Телефон подтвержден одноразовым кодом из SMS';
+
+COMMENT ON TABLE phone_otp_codes IS 'Одноразовые коды входа по телефону - не больше одного действующего кода на номер';
+
+COMMENT ON COLUMN phone_otp_codes.code_hash IS 'HMAC-SHA256 кода (hex) - сам код не хранится';
+
+COMMENT ON COLUMN phone_otp_codes.attempts IS 'Количество неверных попыток ввода текущего кода';
+
+COMMENT ON COLUMN phone_otp_codes.send_count IS 'Количество отправленных SMS с начала window_started_at';
+
+COMMENT ON TABLE sessions IS 'Сессии браузера - cookie содержит токен, в базе хранится только его SHA-256';
+
+COMMENT ON COLUMN sessions.last_seen_at IS 'Время последнего запроса (обновляется не чаще раза в минуту)';
+
+---- create above / drop below ----
+-- Удаляем индексы
+DROP INDEX IF EXISTS idx_sessions_expires_at;
+
+DROP INDEX IF EXISTS idx_sessions_user_id;
+
+DROP INDEX IF EXISTS uq_users_verified_phone;
+
+-- Удаляем таблицы
+DROP TABLE IF EXISTS sessions;
+
+DROP TABLE IF EXISTS phone_otp_codes;
+
+-- Возвращаем обязательный email
+ALTER TABLE users
+DROP CONSTRAINT IF EXISTS chk_users_email_or_phone;
+
+ALTER TABLE users
+DROP COLUMN IF EXISTS phone_verified;
+
+ALTER TABLE users
+ALTER COLUMN email
+SET NOT NULL;
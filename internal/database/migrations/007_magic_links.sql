@@ -0,0 +1,42 @@
+-- +tern:Up
+-- Создаем таблицу ссылок для входа по email
+CREATE TABLE IF NOT EXISTS magic_links (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  email VARCHAR(255) NOT NULL,
+  token_hash VARCHAR(64) NOT NULL UNIQUE,
+  nonce_hash VARCHAR(64) NOT NULL,
+  expires_at TIMESTAMP NOT NULL,
+  consumed_at TIMESTAMP,
+  created_at TIMESTAMP NOT NULL DEFAULT NOW()
+);
+
+-- Создаем индексы
+CREATE INDEX IF NOT EXISTS idx_magic_links_email_created_at ON magic_links (email, created_at);
+
+CREATE INDEX IF NOT EXISTS idx_magic_links_expires_at ON magic_links (expires_at);
+
+-- Долгоживущий токен верификации в открытом виде заменен ссылками для входа
+ALTER TABLE users
+DROP COLUMN IF EXISTS verification_token;
+
+-- Комментарии
+COMMENT ON TABLE magic_links IS 'Одноразовые ссылки для входа по email';
+
+COMMENT ON COLUMN magic_links.token_hash IS 'SHA-256 токена из ссылки (hex) - сам токен не хранится';
+
+COMMENT ON COLUMN magic_links.nonce_hash IS 'SHA-256 значения cookie браузера, запросившего ссылку (hex)';
+
+COMMENT ON COLUMN magic_links.consumed_at IS 'Время использования ссылки - повторно ссылка не действует';
+
+---- create above / drop below ----
+-- Возвращаем токен верификации
+ALTER TABLE users
+ADD COLUMN IF NOT EXISTS verification_token VARCHAR(255);
+
+-- Удаляем индексы
+DROP INDEX IF EXISTS idx_magic_links_expires_at;
+
+DROP INDEX IF EXISTS idx_magic_links_email_created_at;
+
+-- Удаляем таблицы
+DROP TABLE IF EXISTS magic_links;
@@ -0,0 +1,166 @@
+-- +tern:Up
+-- Создаем таблицу категорий каталога
+CREATE TABLE IF NOT EXISTS categories (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  parent_id UUID REFERENCES categories (id) ON DELETE SET NULL,
+  slug VARCHAR(100) NOT NULL UNIQUE,
+  name VARCHAR(255) NOT NULL,
+  description TEXT,
+  position INTEGER NOT NULL DEFAULT 0,
+  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  deleted_at TIMESTAMP,
+  CONSTRAINT chk_categories_slug CHECK (slug ~ '^[a-z0-9]+(-[a-z0-9]+)*$')
+);
+
+-- Создаем таблицу товаров
+CREATE TABLE IF NOT EXISTS products (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  category_id UUID NOT NULL REFERENCES categories (id),
+  slug VARCHAR(150) NOT NULL UNIQUE,
+  name VARCHAR(255) NOT NULL,
+  description TEXT,
+  brand VARCHAR(100),
+  material VARCHAR(100),
+  color_family VARCHAR(16),
+  dominant_color CHAR(7),
+  is_active BOOLEAN NOT NULL DEFAULT TRUE,
+  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  deleted_at TIMESTAMP,
+  CONSTRAINT chk_products_slug CHECK (slug ~ '^[a-z0-9]+(-[a-z0-9]+)*$'),
+  CONSTRAINT chk_products_dominant_color CHECK (dominant_color ~ '^#[0-9a-f]{6}$')
+);
+
+-- Создаем таблицу вариантов товара (размер, цвет, партия)
+CREATE TABLE IF NOT EXISTS product_variants (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  product_id UUID NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+  sku VARCHAR(64) NOT NULL UNIQUE,
+  name VARCHAR(255) NOT NULL,
+  width_cm INTEGER,
+  length_cm INTEGER,
+  unit VARCHAR(16) NOT NULL DEFAULT 'piece',
+  price_kopecks BIGINT NOT NULL,
+  stock INTEGER NOT NULL DEFAULT 0,
+  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  updated_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  deleted_at TIMESTAMP,
+  -- Для составного внешнего ключа product_media (вариант должен принадлежать товару)
+  CONSTRAINT uq_product_variants_id_product UNIQUE (id, product_id),
+  CONSTRAINT chk_product_variants_unit CHECK (unit IN ('piece', 'sqm', 'linear_m')),
+  CONSTRAINT chk_product_variants_price CHECK (price_kopecks >= 0),
+  CONSTRAINT chk_product_variants_stock CHECK (stock >= 0),
+  CONSTRAINT chk_product_variants_size CHECK (width_cm > 0 AND (length_cm IS NULL OR length_cm > 0))
+);
+
+-- Создаем таблицу загруженных изображений (одна запись на уникальное содержимое)
+CREATE TABLE IF NOT EXISTS media (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  content_hash VARCHAR(64) NOT NULL UNIQUE,
+  width INTEGER NOT NULL,
+  height INTEGER NOT NULL,
+  dominant_color CHAR(7) NOT NULL,
+  color_family VARCHAR(16) NOT NULL,
+  renditions JSONB NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  CONSTRAINT chk_media_size CHECK (width > 0 AND height > 0),
+  CONSTRAINT chk_media_dominant_color CHECK (dominant_color ~ '^#[0-9a-f]{6}$')
+);
+
+-- Создаем таблицу галерей товаров
+CREATE TABLE IF NOT EXISTS product_media (
+  id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
+  product_id UUID NOT NULL REFERENCES products (id) ON DELETE CASCADE,
+  variant_id UUID,
+  media_id UUID NOT NULL REFERENCES media (id),
+  position INTEGER NOT NULL DEFAULT 0,
+  alt_text VARCHAR(255) NOT NULL,
+  created_at TIMESTAMP NOT NULL DEFAULT NOW(),
+  CONSTRAINT fk_product_media_variant FOREIGN KEY (variant_id, product_id) REFERENCES product_variants (id, product_id) ON DELETE CASCADE
+);
+
+-- Создаем индексы
+CREATE INDEX IF NOT EXISTS idx_categories_parent_id ON categories (parent_id);
+
+CREATE INDEX IF NOT EXISTS idx_products_category_id ON products (category_id)
+WHERE
+  deleted_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_products_color_family ON products (color_family)
+WHERE
+  deleted_at IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_product_variants_product_id ON product_variants (product_id);
+
+CREATE INDEX IF NOT EXISTS idx_product_media_product_position ON product_media (product_id, position);
+
+CREATE INDEX IF NOT EXISTS idx_product_media_media_id ON product_media (media_id);
+
+-- Одно изображение не добавляется в галерею товара (или варианта) дважды
+CREATE UNIQUE INDEX IF NOT EXISTS uq_product_media_product_media ON product_media (product_id, media_id)
+WHERE
+  variant_id IS NULL;
+
+CREATE UNIQUE INDEX IF NOT EXISTS uq_product_media_variant_media ON product_media (variant_id, media_id)
+WHERE
+  variant_id IS NOT NULL;
+
+-- Триггеры updated_at
+CREATE OR REPLACE TRIGGER update_categories_updated_at BEFORE
+UPDATE ON categories FOR EACH ROW
+EXECUTE FUNCTION update_updated_at_column ();
+
+CREATE OR REPLACE TRIGGER update_products_updated_at BEFORE
+UPDATE ON products FOR EACH ROW
+EXECUTE FUNCTION update_updated_at_column ();
+
+CREATE OR REPLACE TRIGGER update_product_variants_updated_at BEFORE
+UPDATE ON product_variants FOR EACH ROW
+EXECUTE FUNCTION update_updated_at_column ();
+
+-- Комментарии
+COMMENT ON TABLE categories IS 'Категории каталога (ковры, ковролин, ковровая плитка)';
+
+COMMENT ON COLUMN categories.deleted_at IS 'Мягкое удаление - если NULL, то категория активна';
+
+COMMENT ON TABLE products IS 'Товары каталога';
+
+COMMENT ON COLUMN products.color_family IS 'Группа цвета для фильтра каталога - по главному изображению';
+
+COMMENT ON COLUMN products.dominant_color IS 'Преобладающий цвет главного изображения (#rrggbb)';
+
+COMMENT ON COLUMN products.deleted_at IS 'Мягкое удаление - если NULL, то товар активен';
+
+COMMENT ON TABLE product_variants IS 'Варианты товара с ценой и остатком';
+
+COMMENT ON COLUMN product_variants.unit IS 'Единица продажи: piece - штука, sqm - квадратный метр, linear_m - погонный метр';
+
+COMMENT ON COLUMN product_variants.price_kopecks IS 'Цена за единицу в копейках';
+
+COMMENT ON TABLE media IS 'Изображения - одна запись на уникальное содержимое (SHA-256 исходного файла)';
+
+COMMENT ON COLUMN media.renditions IS 'Уменьшенные копии для srcset: [{"width", "height", "format", "key"}]';
+
+COMMENT ON TABLE product_media IS 'Галереи товаров и вариантов: порядок и alt-текст';
+
+COMMENT ON COLUMN product_media.variant_id IS 'Вариант товара - если NULL, изображение относится ко всему товару';
+
+---- create above / drop below ----
+-- Удаляем триггеры
+DROP TRIGGER IF EXISTS update_product_variants_updated_at ON product_variants;
+
+DROP TRIGGER IF EXISTS update_products_updated_at ON products;
+
+DROP TRIGGER IF EXISTS update_categories_updated_at ON categories;
+
+-- Удаляем таблицы (индексы удаляются вместе с ними)
+DROP TABLE IF EXISTS product_media;
+
+DROP TABLE IF EXISTS media;
+
+DROP TABLE IF EXISTS product_variants;
+
+DROP TABLE IF EXISTS products;
+
+DROP TABLE IF EXISTS categories;
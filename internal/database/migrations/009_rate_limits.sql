@@ -0,0 +1,23 @@
+-- +tern:Up
+-- Создаем таблицу состояния ограничителя частоты запросов
+CREATE TABLE IF NOT EXISTS rate_limits (
+  key VARCHAR(400) PRIMARY KEY,
+  tat TIMESTAMP NOT NULL
+);
+
+-- Создаем индексы
+CREATE INDEX IF NOT EXISTS idx_rate_limits_tat ON rate_limits (tat);
+
+-- Комментарии
+COMMENT ON TABLE rate_limits IS 'Ограничение частоты запросов по алгоритму GCRA';
+
+COMMENT ON COLUMN rate_limits.key IS 'Ключ: <политика>:ip:<адрес>, <политика>:user:<id> или <политика>:token:<id>';
+
+COMMENT ON COLUMN rate_limits.tat IS 'Теоретическое время прибытия следующего запроса (TAT)';
+
+---- create above / drop below ----
+-- Удаляем индексы
+DROP INDEX IF EXISTS idx_rate_limits_tat;
+
+-- Удаляем таблицу
+DROP TABLE IF EXISTS rate_limits;
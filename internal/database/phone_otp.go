@@ -0,0 +1,128 @@
+package database
+
+import (
+	"context"
+	"errors"
+
+	"github.com/LigeronAhill/luxcarpets-go/internal/database/types"
+	"github.com/LigeronAhill/luxcarpets-go/pkg/utils"
+	"github.com/jackc/pgx/v5"
+)
+
+// PhoneOTPStorage хранит одноразовые коды входа по телефону
+type PhoneOTPStorage struct {
+	pool PgxPoolIface
+}
+
+func NewPhoneOTPStorage(pool PgxPoolIface) *PhoneOTPStorage {
+	return &PhoneOTPStorage{
+		pool: pool,
+	}
+}
+
+// Get возвращает код для телефона или nil, если кода нет
+func (s *PhoneOTPStorage) Get(ctx context.Context, phone string) (*types.PhoneOTP, error) {
+	op := "get phone otp for " + phone
+	query := `
+		SELECT * FROM phone_otp_codes WHERE phone = @phone
+	`
+	args := pgx.NamedArgs{
+		"phone": phone,
+	}
+	rows, err := s.pool.Query(ctx, query, args)
+	if err != nil {
+		return nil, utils.Wrap(op, err)
+	}
+	defer rows.Close()
+	res, err := pgx.CollectExactlyOneRow(rows, pgx.RowToAddrOfStructByName[types.PhoneOTP])
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, utils.Wrap(op, err)
+	}
+	return res, nil
+}
+
+// Save сохраняет новый код, заменяя предыдущий, и обнуляет счетчик попыток.
+// Код заменяется, только если время отправки сохраненного кода равно params.PreviousSentAt:
+// так из параллельных запросов с одним прочитанным состоянием код отправляет только один.
+// Возвращает false, если код успел измениться.
+func (s *PhoneOTPStorage) Save(ctx context.Context, params types.SavePhoneOTPParams) (bool, error) {
+	op := "save phone otp for " + params.Phone
+	query := `
+		INSERT INTO phone_otp_codes (phone, code_hash, attempts, expires_at, sent_at, send_count, window_started_at)
+		VALUES (@phone, @code_hash, 0, @expires_at, @sent_at, @send_count, @window_started_at)
+		ON CONFLICT (phone) DO UPDATE
+		SET code_hash = EXCLUDED.code_hash,
+		    attempts = 0,
+		    expires_at = EXCLUDED.expires_at,
+		    sent_at = EXCLUDED.sent_at,
+		    send_count = EXCLUDED.send_count,
+		    window_started_at = EXCLUDED.window_started_at
+		WHERE phone_otp_codes.sent_at = @previous_sent_at
+	`
+	args := pgx.NamedArgs{
+		"phone":             params.Phone,
+		"code_hash":         params.CodeHash,
+		"expires_at":        params.ExpiresAt,
+		"sent_at":           params.SentAt,
+		"send_count":        params.SendCount,
+		"window_started_at": params.WindowStartedAt,
+		"previous_sent_at":  params.PreviousSentAt,
+	}
+	res, err := s.pool.Exec(ctx, query, args)
+	if err != nil {
+		return false, utils.Wrap(op, err)
+	}
+	return res.RowsAffected() == 1, nil
+}
+
+// RegisterAttempt засчитывает попытку ввода и возвращает код после увеличения счетчика.
+// Возвращает nil, если кода нет или попытки исчерпаны: проверка лимита и увеличение
+// счетчика выполняются одним запросом, поэтому параллельные запросы не превысят maxAttempts.
+func (s *PhoneOTPStorage) RegisterAttempt(ctx context.Context, phone string, maxAttempts int) (*types.PhoneOTP, error) {
+	op := "register phone otp attempt for " + phone
+	query := `
+		UPDATE phone_otp_codes SET attempts = attempts + 1
+		WHERE phone = @phone AND attempts < @max_attempts
+		RETURNING *
+	`
+	args := pgx.NamedArgs{
+		"phone":        phone,
+		"max_attempts": maxAttempts,
+	}
+	rows, err := s.pool.Query(ctx, query, args)
+	if err != nil {
+		return nil, utils.Wrap(op, err)
+	}
+	defer rows.Close()
+	res, err := pgx.CollectExactlyOneRow(rows, pgx.RowToAddrOfStructByName[types.PhoneOTP])
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, utils.Wrap(op, err)
+	}
+	return res, nil
+}
+
+// Consume помечает код использованным: срок действия истекает, а счетчики отправок сохраняются.
+// Возвращает false, если код уже использован параллельным запросом или попыток больше maxAttempts.
+func (s *PhoneOTPStorage) Consume(ctx context.Context, phone, codeHash string, maxAttempts int) (bool, error) {
+	op := "consume phone otp for " + phone
+	query := `
+		UPDATE phone_otp_codes SET expires_at = sent_at, code_hash = ''
+		WHERE phone = @phone AND code_hash = @code_hash AND attempts <= @max_attempts
+	`
+	args := pgx.NamedArgs{
+		"phone":        phone,
+		"code_hash":    codeHash,
+		"max_attempts": maxAttempts,
+	}
+	res, err := s.pool.Exec(ctx, query, args)
+	if err != nil {
+		return false, utils.Wrap(op, err)
+	}
+	return res.RowsAffected() == 1, nil
+}
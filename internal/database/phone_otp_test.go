@@ -0,0 +1,147 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/LigeronAhill/luxcarpets-go/internal/database/types"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var phoneOTPColumns = []string{
+	"phone", "code_hash", "attempts", "expires_at", "sent_at", "send_count", "window_started_at",
+}
+
+func TestPhoneOTPStorage_Get(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	storage := NewPhoneOTPStorage(mock)
+	phone := "+79123456789"
+	now := time.Now()
+
+	mock.ExpectQuery(`SELECT \* FROM phone_otp_codes WHERE phone = @phone`).
+		WithArgs(phone).
+		WillReturnRows(pgxmock.NewRows(phoneOTPColumns).AddRow(
+			phone, "hash", 2, now.Add(time.Minute), now, 1, now,
+		))
+	mock.ExpectQuery(`SELECT \* FROM phone_otp_codes WHERE phone = @phone`).
+		WithArgs(phone).
+		WillReturnRows(pgxmock.NewRows(phoneOTPColumns))
+
+	res, err := storage.Get(context.Background(), phone)
+	require.NoError(t, err)
+	assert.Equal(t, 2, res.Attempts)
+	assert.False(t, res.Expired(now))
+
+	res, err = storage.Get(context.Background(), phone)
+	assert.NoError(t, err)
+	assert.Nil(t, res)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPhoneOTPStorage_Save(t *testing.T) {
+	now := time.Now()
+	previous := now.Add(-time.Minute)
+	tests := []struct {
+		name     string
+		previous *time.Time
+		affected int64
+		expected bool
+	}{
+		{"первый код", nil, 1, true},
+		{"замена прочитанного кода", &previous, 1, true},
+		{"код заменен параллельным запросом", &previous, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock, err := pgxmock.NewPool()
+			require.NoError(t, err)
+			defer mock.Close()
+
+			storage := NewPhoneOTPStorage(mock)
+			params := types.SavePhoneOTPParams{
+				Phone:           "+79123456789",
+				CodeHash:        "hash",
+				ExpiresAt:       now.Add(5 * time.Minute),
+				SentAt:          now,
+				SendCount:       2,
+				WindowStartedAt: now.Add(-time.Minute),
+				PreviousSentAt:  tt.previous,
+			}
+
+			mock.ExpectExec(`INSERT INTO phone_otp_codes .* ON CONFLICT \(phone\) DO UPDATE .* WHERE phone_otp_codes.sent_at = @previous_sent_at`).
+				WithArgs(params.Phone, params.CodeHash, params.ExpiresAt, params.SentAt, params.SendCount, params.WindowStartedAt, params.PreviousSentAt).
+				WillReturnResult(pgxmock.NewResult("INSERT", tt.affected))
+
+			saved, err := storage.Save(context.Background(), params)
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, saved)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestPhoneOTPStorage_RegisterAttempt(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	storage := NewPhoneOTPStorage(mock)
+	phone := "+79123456789"
+	now := time.Now()
+
+	mock.ExpectQuery(`UPDATE phone_otp_codes SET attempts = attempts \+ 1\s+WHERE phone = @phone AND attempts < @max_attempts`).
+		WithArgs(phone, 5).
+		WillReturnRows(pgxmock.NewRows(phoneOTPColumns).AddRow(
+			phone, "hash", 3, now.Add(time.Minute), now, 1, now,
+		))
+	// Попытки исчерпаны: условие не выполняется, строка не возвращается
+	mock.ExpectQuery(`UPDATE phone_otp_codes SET attempts = attempts \+ 1`).
+		WithArgs(phone, 5).
+		WillReturnRows(pgxmock.NewRows(phoneOTPColumns))
+
+	res, err := storage.RegisterAttempt(context.Background(), phone, 5)
+	require.NoError(t, err)
+	assert.Equal(t, 3, res.Attempts)
+
+	res, err = storage.RegisterAttempt(context.Background(), phone, 5)
+	assert.NoError(t, err)
+	assert.Nil(t, res)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPhoneOTPStorage_Consume(t *testing.T) {
+	tests := []struct {
+		name     string
+		affected int64
+		expected bool
+	}{
+		{"код использован", 1, true},
+		{"код уже использован параллельным запросом или попытки исчерпаны", 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock, err := pgxmock.NewPool()
+			require.NoError(t, err)
+			defer mock.Close()
+
+			storage := NewPhoneOTPStorage(mock)
+
+			mock.ExpectExec(`UPDATE phone_otp_codes SET expires_at = sent_at, code_hash = ''\s+WHERE .* AND attempts <= @max_attempts`).
+				WithArgs("+79123456789", "hash", 5).
+				WillReturnResult(pgxmock.NewResult("UPDATE", tt.affected))
+
+			consumed, err := storage.Consume(context.Background(), "+79123456789", "hash", 5)
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, consumed)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
@@ -2,20 +2,119 @@ package database
 
 import (
 	"context"
+	"fmt"
+	"log/slog"
+	"time"
 
+	"github.com/LigeronAhill/luxcarpets-go/pkg/requestid"
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
-func NewPool(ctx context.Context, dbURL string) *pgxpool.Pool {
-	pool, err := pgxpool.New(ctx, dbURL)
+// ApplicationName - application_name соединений, если он не задан в адресе базы
+const ApplicationName = "luxcarpets"
+
+// maxApplicationName - предел длины application_name в Postgres (NAMEDATALEN - 1)
+const maxApplicationName = 63
+
+// applicationNameKey - ключ текущего application_name соединения в PgConn.CustomData
+const applicationNameKey = "application_name"
+
+// Задержки между попытками подключения к базе: от connectMinDelay с удвоением до connectMaxDelay
+const (
+	connectMinDelay = 250 * time.Millisecond
+	connectMaxDelay = 5 * time.Second
+)
+
+// NewPool создает пул соединений и ждет, пока база данных начнет принимать подключения:
+// при запуске в Docker сервер может стартовать раньше Postgres. Попытки повторяются
+// с растущей задержкой, пока не истечет connectTimeout или не будет отменен ctx.
+// Миграции не применяются - для этого есть команда migrate (см. Migrate).
+func NewPool(ctx context.Context, dbURL string, connectTimeout time.Duration) (*pgxpool.Pool, error) {
+	config, err := pgxpool.ParseConfig(dbURL)
+	if err != nil {
+		return nil, fmt.Errorf("invalid database url: %w", err)
+	}
+	base := config.ConnConfig.RuntimeParams[applicationNameKey]
+	if base == "" {
+		base = ApplicationName
+		config.ConnConfig.RuntimeParams[applicationNameKey] = base
+	}
+	config.AfterConnect = func(_ context.Context, conn *pgx.Conn) error {
+		conn.PgConn().CustomData()[applicationNameKey] = base
+		return nil
+	}
+	config.PrepareConn = tagRequest(base)
+	config.ConnConfig.Tracer = queryTracer{}
+	pool, err := pgxpool.NewWithConfig(ctx, config)
 	if err != nil {
-		panic(err)
+		return nil, fmt.Errorf("error creating pool: %w", err)
 	}
-	if err = pool.Ping(ctx); err != nil {
-		panic(err)
+	if err := waitForDB(ctx, pool, connectTimeout); err != nil {
+		pool.Close()
+		return nil, err
+	}
+	return pool, nil
+}
+
+// waitForDB повторяет проверку соединения, пока база не ответит или не истечет timeout
+func waitForDB(ctx context.Context, db interface{ Ping(context.Context) error }, timeout time.Duration) error {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	delay := connectMinDelay
+	for attempt := 1; ; attempt++ {
+		err := db.Ping(ctx)
+		if err == nil {
+			return nil
+		}
+		slog.WarnContext(ctx, "Database is not ready",
+			slog.Int("attempt", attempt),
+			slog.Duration("retry_in", delay),
+			slog.String("error", err.Error()),
+		)
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("database is not ready after %d attempts: %w", attempt, err)
+		case <-time.After(delay):
+		}
+		delay = min(delay*2, connectMaxDelay)
+	}
+}
+
+// tagRequest возвращает хук выдачи соединения из пула, который записывает идентификатор
+// HTTP-запроса из контекста в application_name. Значение видно в pg_stat_activity и в логах
+// медленных запросов (%a в log_line_prefix), что позволяет сопоставить их с логами сервера.
+//
+// Команда выполняется, только когда идентификатор отличается от уже установленного, и отправляется
+// через PgConn: это один обмен с сервером без подготовки запроса и без span трассировщика.
+// Комментарий с идентификатором в тексте запроса не используется: кеш подготовленных запросов pgx
+// хранит запросы по тексту и заполнялся бы копиями одного запроса для каждого HTTP-запроса.
+func tagRequest(base string) func(context.Context, *pgx.Conn) (bool, error) {
+	return func(ctx context.Context, conn *pgx.Conn) (bool, error) {
+		name := applicationName(base, requestid.FromContext(ctx))
+		data := conn.PgConn().CustomData()
+		if data[applicationNameKey] == name {
+			return true, nil
+		}
+		result := conn.PgConn().ExecParams(ctx, "SELECT set_config('application_name', $1, false)", [][]byte{[]byte(name)}, nil, nil, nil)
+		if _, err := result.Close(); err != nil {
+			// Соединение в неизвестном состоянии - пул закроет его и вернет ошибку запросу
+			return false, err
+		}
+		data[applicationNameKey] = name
+		return true, nil
+	}
+}
+
+// applicationName возвращает "<base> <id>", обрезанный до предела Postgres.
+// Идентификатор проверен requestid.Valid и содержит только ASCII, поэтому обрезка по байтам безопасна.
+func applicationName(base, id string) string {
+	if id == "" {
+		return base
 	}
-	if err = migrateDB(ctx, dbURL); err != nil {
-		panic(err)
+	name := base + " " + id
+	if len(name) > maxApplicationName {
+		name = name[:maxApplicationName]
 	}
-	return pool
+	return name
 }
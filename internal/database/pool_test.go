@@ -0,0 +1,70 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestApplicationName(t *testing.T) {
+	tests := []struct {
+		name     string
+		id       string
+		expected string
+	}{
+		{"без запроса", "", "luxcarpets"},
+		{"с идентификатором", "0af7651916cd43dd8448eb211c80319c", "luxcarpets 0af7651916cd43dd8448eb211c80319c"},
+		{"длинный обрезается", strings.Repeat("a", 100), "luxcarpets " + strings.Repeat("a", maxApplicationName-len("luxcarpets "))},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, applicationName(ApplicationName, tt.id))
+		})
+	}
+}
+
+// flakyDB отвечает на Ping ошибкой заданное число раз
+type flakyDB struct {
+	failures int
+	pings    int
+}
+
+func (f *flakyDB) Ping(context.Context) error {
+	f.pings++
+	if f.pings <= f.failures {
+		return errors.New("connection refused")
+	}
+	return nil
+}
+
+func TestWaitForDB(t *testing.T) {
+	tests := []struct {
+		name          string
+		failures      int
+		timeout       time.Duration
+		expectedPings int
+		wantErr       bool
+	}{
+		{"база готова сразу", 0, time.Second, 1, false},
+		{"база стартует не сразу", 2, 5 * time.Second, 3, false},
+		{"база не стартовала за отведенное время", 100, 100 * time.Millisecond, 1, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db := &flakyDB{failures: tt.failures}
+
+			err := waitForDB(context.Background(), db, tt.timeout)
+
+			if tt.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, tt.expectedPings, db.pings)
+		})
+	}
+}
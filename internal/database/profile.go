@@ -0,0 +1,106 @@
+package database
+
+import (
+	"context"
+	"errors"
+
+	"github.com/LigeronAhill/luxcarpets-go/internal/database/types"
+	"github.com/LigeronAhill/luxcarpets-go/pkg/utils"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// ProfileStorage хранит контактные данные и предпочтения доставки пользователя
+type ProfileStorage struct {
+	pool PgxPoolIface
+}
+
+func NewProfileStorage(pool PgxPoolIface) *ProfileStorage {
+	return &ProfileStorage{
+		pool: pool,
+	}
+}
+
+// SetPhone сохраняет телефон пользователя. nil удаляет телефон.
+// При смене номера отметка о подтверждении снимается.
+func (s *ProfileStorage) SetPhone(ctx context.Context, userID uuid.UUID, phone *string) (*types.User, error) {
+	op := "set phone for user " + userID.String()
+	query := `
+		UPDATE users
+		SET phone = @phone,
+		    phone_verified = phone_verified AND phone IS NOT DISTINCT FROM @phone
+		WHERE id = @id AND deleted_at IS NULL
+		RETURNING *
+	`
+	args := pgx.NamedArgs{
+		"id":    userID,
+		"phone": phone,
+	}
+	rows, err := s.pool.Query(ctx, query, args)
+	if err != nil {
+		return nil, utils.Wrap(op, err)
+	}
+	defer rows.Close()
+	res, err := pgx.CollectExactlyOneRow(rows, pgx.RowToAddrOfStructByName[types.User])
+	if err != nil {
+		return nil, utils.Wrap(op, err)
+	}
+	return res, nil
+}
+
+// GetDeliveryPreferences возвращает предпочтения доставки или nil, если они не задавались
+func (s *ProfileStorage) GetDeliveryPreferences(ctx context.Context, userID uuid.UUID) (*types.DeliveryPreferences, error) {
+	op := "get delivery preferences for user " + userID.String()
+	query := `
+		SELECT * FROM user_delivery_preferences WHERE user_id = @user_id
+	`
+	args := pgx.NamedArgs{
+		"user_id": userID,
+	}
+	rows, err := s.pool.Query(ctx, query, args)
+	if err != nil {
+		return nil, utils.Wrap(op, err)
+	}
+	defer rows.Close()
+	res, err := pgx.CollectExactlyOneRow(rows, pgx.RowToAddrOfStructByName[types.DeliveryPreferences])
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, utils.Wrap(op, err)
+	}
+	return res, nil
+}
+
+// UpsertDeliveryPreferences сохраняет предпочтения доставки
+func (s *ProfileStorage) UpsertDeliveryPreferences(ctx context.Context, prefs types.DeliveryPreferences) (*types.DeliveryPreferences, error) {
+	op := "upsert delivery preferences for user " + prefs.UserID.String()
+	query := `
+		INSERT INTO user_delivery_preferences (user_id, time_slot, call_before_delivery, lifting_required, comment)
+		VALUES (@user_id, @time_slot, @call_before_delivery, @lifting_required, @comment)
+		ON CONFLICT (user_id) DO UPDATE
+		SET
+		    time_slot = EXCLUDED.time_slot,
+		    call_before_delivery = EXCLUDED.call_before_delivery,
+		    lifting_required = EXCLUDED.lifting_required,
+		    comment = EXCLUDED.comment
+		RETURNING *
+	`
+	args := pgx.NamedArgs{
+		"user_id":              prefs.UserID,
+		"time_slot":            prefs.TimeSlot,
+		"call_before_delivery": prefs.CallBeforeDelivery,
+		"lifting_required":     prefs.LiftingRequired,
+		"comment":              prefs.Comment,
+	}
+	rows, err := s.pool.Query(ctx, query, args)
+	if err != nil {
+		return nil, utils.Wrap(op, err)
+	}
+	defer rows.Close()
+	res, err := pgx.CollectExactlyOneRow(rows, pgx.RowToAddrOfStructByName[types.DeliveryPreferences])
+	if err != nil {
+		return nil, utils.Wrap(op, err)
+	}
+	return res, nil
+}
@@ -0,0 +1,91 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/LigeronAhill/luxcarpets-go/internal/database/types"
+	"github.com/google/uuid"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var deliveryPreferencesColumns = []string{
+	"user_id", "time_slot", "call_before_delivery", "lifting_required", "comment", "updated_at",
+}
+
+func TestProfileStorage_SetPhone_Clear(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	storage := NewProfileStorage(mock)
+	userID := uuid.New()
+	now := time.Now()
+
+	mock.ExpectQuery(`UPDATE users
+		SET phone = @phone,
+		    phone_verified = phone_verified AND phone IS NOT DISTINCT FROM @phone
+		WHERE id = @id AND deleted_at IS NULL`).
+		WithArgs((*string)(nil), userID).
+		WillReturnRows(pgxmock.NewRows([]string{
+			"id", "email", "email_verified", "username", "role", "image_url",
+			"password_hash", "created_at", "updated_at", "deleted_at", "phone", "phone_verified",
+		}).AddRow(
+			userID, strPtr("test@example.com"), true, "testuser", types.RoleCustomer, nil,
+			nil, now, now, nil, nil, false,
+		))
+
+	res, err := storage.SetPhone(context.Background(), userID, nil)
+
+	require.NoError(t, err)
+	assert.Nil(t, res.Phone)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestProfileStorage_GetDeliveryPreferences_NotFound(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	storage := NewProfileStorage(mock)
+
+	mock.ExpectQuery(`SELECT \* FROM user_delivery_preferences WHERE user_id = @user_id`).
+		WithArgs(pgxmock.AnyArg()).
+		WillReturnRows(pgxmock.NewRows(deliveryPreferencesColumns))
+
+	res, err := storage.GetDeliveryPreferences(context.Background(), uuid.New())
+
+	assert.NoError(t, err)
+	assert.Nil(t, res)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestProfileStorage_UpsertDeliveryPreferences(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	storage := NewProfileStorage(mock)
+	userID := uuid.New()
+	now := time.Now()
+
+	mock.ExpectQuery(`INSERT INTO user_delivery_preferences`).
+		WithArgs(userID, types.TimeSlotEvening, false, true, (*string)(nil)).
+		WillReturnRows(pgxmock.NewRows(deliveryPreferencesColumns).AddRow(
+			userID, types.TimeSlotEvening, false, true, nil, now,
+		))
+
+	res, err := storage.UpsertDeliveryPreferences(context.Background(), types.DeliveryPreferences{
+		UserID:          userID,
+		TimeSlot:        types.TimeSlotEvening,
+		LiftingRequired: true,
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, types.TimeSlotEvening, res.TimeSlot)
+	assert.True(t, res.LiftingRequired)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,76 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/LigeronAhill/luxcarpets-go/pkg/utils"
+	"github.com/jackc/pgx/v5"
+)
+
+// RateLimitsStorage хранит состояние ограничителя частоты запросов в PostgreSQL.
+// Позволяет применять общие ограничения на нескольких репликах сервера.
+type RateLimitsStorage struct {
+	pool PgxPoolIface
+}
+
+func NewRateLimitsStorage(pool PgxPoolIface) *RateLimitsStorage {
+	return &RateLimitsStorage{
+		pool: pool,
+	}
+}
+
+// Take атомарно продвигает TAT ключа на interval, если он останется не дальше now + period.
+// Проверка и обновление выполняются одним INSERT ... ON CONFLICT: строка ключа блокируется,
+// и одновременные запросы с разных реплик не превышают ограничение.
+func (r *RateLimitsStorage) Take(ctx context.Context, key string, now time.Time, interval, period time.Duration) (time.Time, bool, error) {
+	op := "take rate limit for " + key
+	query := `
+		INSERT INTO rate_limits AS r (key, tat)
+		VALUES (@key, @now::timestamp + @interval::interval)
+		ON CONFLICT (key) DO UPDATE
+		SET tat = GREATEST(r.tat, @now::timestamp) + @interval::interval
+		WHERE GREATEST(r.tat, @now::timestamp) + @interval::interval <= @now::timestamp + @period::interval
+		RETURNING tat
+	`
+	// Столбец без часового пояса: время хранится в UTC
+	args := pgx.NamedArgs{
+		"key":      key,
+		"now":      now.UTC(),
+		"interval": interval,
+		"period":   period,
+	}
+	var tat time.Time
+	err := r.pool.QueryRow(ctx, query, args).Scan(&tat)
+	if err == nil {
+		return tat, true, nil
+	}
+	if !errors.Is(err, pgx.ErrNoRows) {
+		return time.Time{}, false, utils.Wrap(op, err)
+	}
+
+	// Запас исчерпан: строка не изменилась, читаем текущий TAT для Retry-After
+	query = `
+		SELECT tat FROM rate_limits WHERE key = @key
+	`
+	if err := r.pool.QueryRow(ctx, query, pgx.NamedArgs{"key": key}).Scan(&tat); err != nil {
+		return time.Time{}, false, utils.Wrap(op, err)
+	}
+	return tat, false, nil
+}
+
+// DeleteExpired удаляет ключи, запас которых восстановился к моменту before
+func (r *RateLimitsStorage) DeleteExpired(ctx context.Context, before time.Time) error {
+	op := "delete expired rate limits"
+	query := `
+		DELETE FROM rate_limits WHERE tat < @before
+	`
+	args := pgx.NamedArgs{
+		"before": before.UTC(),
+	}
+	if _, err := r.pool.Exec(ctx, query, args); err != nil {
+		return utils.Wrap(op, err)
+	}
+	return nil
+}
@@ -0,0 +1,91 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRateLimitsStorage_Take_Allowed(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	storage := NewRateLimitsStorage(mock)
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	mock.ExpectQuery(`INSERT INTO rate_limits AS r \(key, tat\)`).
+		WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg()).
+		WillReturnRows(pgxmock.NewRows([]string{"tat"}).AddRow(now.Add(6 * time.Second)))
+
+	tat, allowed, err := storage.Take(context.Background(), "auth:ip:10.0.0.1", now, 6*time.Second, time.Minute)
+
+	require.NoError(t, err)
+	assert.True(t, allowed)
+	assert.Equal(t, now.Add(6*time.Second), tat)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRateLimitsStorage_Take_Denied(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	storage := NewRateLimitsStorage(mock)
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	// Условие WHERE не выполнено - INSERT не вернул строку, текущий TAT читается отдельно
+	mock.ExpectQuery(`INSERT INTO rate_limits AS r \(key, tat\)`).
+		WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg()).
+		WillReturnRows(pgxmock.NewRows([]string{"tat"}))
+	mock.ExpectQuery(`SELECT tat FROM rate_limits WHERE key = @key`).
+		WithArgs(pgxmock.AnyArg()).
+		WillReturnRows(pgxmock.NewRows([]string{"tat"}).AddRow(now.Add(time.Minute)))
+
+	tat, allowed, err := storage.Take(context.Background(), "auth:ip:10.0.0.1", now, 6*time.Second, time.Minute)
+
+	require.NoError(t, err)
+	assert.False(t, allowed)
+	assert.Equal(t, now.Add(time.Minute), tat)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRateLimitsStorage_Take_Error(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	storage := NewRateLimitsStorage(mock)
+
+	mock.ExpectQuery(`INSERT INTO rate_limits`).
+		WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg()).
+		WillReturnError(errors.New("connection refused"))
+
+	_, allowed, err := storage.Take(context.Background(), "auth:ip:10.0.0.1", time.Now(), time.Second, time.Minute)
+
+	assert.Error(t, err)
+	assert.False(t, allowed)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRateLimitsStorage_DeleteExpired(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	storage := NewRateLimitsStorage(mock)
+
+	mock.ExpectExec(`DELETE FROM rate_limits WHERE tat < @before`).
+		WithArgs(pgxmock.AnyArg()).
+		WillReturnResult(pgxmock.NewResult("DELETE", 3))
+
+	err = storage.DeleteExpired(context.Background(), time.Now())
+
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
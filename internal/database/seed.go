@@ -0,0 +1,213 @@
+package database
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/LigeronAhill/luxcarpets-go/internal/database/types"
+	"github.com/LigeronAhill/luxcarpets-go/pkg/utils"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// SeedStorage записывает данные каталога для разработки и нагрузочных тестов (команда seed).
+// Вставки выполняются пачками и не трогают существующие записи: повторный запуск
+// пропускает категории с тем же slug, а товары, варианты и изображения - с тем же ключом.
+type SeedStorage struct {
+	pool PgxPoolIface
+}
+
+func NewSeedStorage(pool PgxPoolIface) *SeedStorage {
+	return &SeedStorage{
+		pool: pool,
+	}
+}
+
+// EnsureCategory создает категорию, если категории с таким slug еще нет,
+// и возвращает новую или существующую категорию без изменений
+func (s *SeedStorage) EnsureCategory(ctx context.Context, category types.Category) (*types.Category, error) {
+	op := "ensure category " + category.Slug
+	query := `
+		WITH inserted AS (
+			INSERT INTO categories (parent_id, slug, name, description, position)
+			VALUES (@parent_id, @slug, @name, @description, @position)
+			ON CONFLICT (slug) DO NOTHING
+			RETURNING *
+		)
+		SELECT * FROM inserted
+		UNION ALL
+		SELECT * FROM categories WHERE slug = @slug
+		LIMIT 1
+	`
+	args := pgx.NamedArgs{
+		"parent_id":   category.ParentID,
+		"slug":        category.Slug,
+		"name":        category.Name,
+		"description": category.Description,
+		"position":    category.Position,
+	}
+	rows, err := s.pool.Query(ctx, query, args)
+	if err != nil {
+		return nil, utils.Wrap(op, err)
+	}
+	defer rows.Close()
+	res, err := pgx.CollectExactlyOneRow(rows, pgx.RowToAddrOfStructByName[types.Category])
+	if err != nil {
+		return nil, utils.Wrap(op, err)
+	}
+	return res, nil
+}
+
+// InsertProducts вставляет товары с заданными ID и возвращает количество новых.
+// Товары, чей ID или slug уже занят, пропускаются.
+func (s *SeedStorage) InsertProducts(ctx context.Context, products []types.Product) (int64, error) {
+	op := fmt.Sprintf("insert %d products", len(products))
+	query := `
+		INSERT INTO products (
+		    id, category_id, slug, name, description, brand, material, color_family, dominant_color, is_active
+		)
+		SELECT * FROM unnest(
+		    @ids::uuid[], @category_ids::uuid[], @slugs::text[], @names::text[], @descriptions::text[],
+		    @brands::text[], @materials::text[], @color_families::text[], @dominant_colors::text[], @is_active::boolean[]
+		)
+		ON CONFLICT DO NOTHING
+	`
+	n := len(products)
+	var (
+		ids            = make([]uuid.UUID, n)
+		categoryIDs    = make([]uuid.UUID, n)
+		slugs          = make([]string, n)
+		names          = make([]string, n)
+		descriptions   = make([]*string, n)
+		brands         = make([]*string, n)
+		materials      = make([]*string, n)
+		colorFamilies  = make([]*string, n)
+		dominantColors = make([]*string, n)
+		isActive       = make([]bool, n)
+	)
+	for i, p := range products {
+		ids[i] = p.ID
+		categoryIDs[i] = p.CategoryID
+		slugs[i] = p.Slug
+		names[i] = p.Name
+		descriptions[i] = p.Description
+		brands[i] = p.Brand
+		materials[i] = p.Material
+		if p.ColorFamily != nil {
+			family := string(*p.ColorFamily)
+			colorFamilies[i] = &family
+		}
+		dominantColors[i] = p.DominantColor
+		isActive[i] = p.IsActive
+	}
+	args := pgx.NamedArgs{
+		"ids":             ids,
+		"category_ids":    categoryIDs,
+		"slugs":           slugs,
+		"names":           names,
+		"descriptions":    descriptions,
+		"brands":          brands,
+		"materials":       materials,
+		"color_families":  colorFamilies,
+		"dominant_colors": dominantColors,
+		"is_active":       isActive,
+	}
+	res, err := s.pool.Exec(ctx, query, args)
+	if err != nil {
+		return 0, utils.Wrap(op, err)
+	}
+	return res.RowsAffected(), nil
+}
+
+// InsertVariants вставляет варианты товаров с заданными ID и возвращает количество новых.
+// Варианты с занятым ID или артикулом, а также варианты отсутствующих товаров пропускаются.
+func (s *SeedStorage) InsertVariants(ctx context.Context, variants []types.ProductVariant) (int64, error) {
+	op := fmt.Sprintf("insert %d product variants", len(variants))
+	query := `
+		INSERT INTO product_variants (id, product_id, sku, name, width_cm, length_cm, unit, price_kopecks, stock)
+		SELECT v.* FROM unnest(
+		    @ids::uuid[], @product_ids::uuid[], @skus::text[], @names::text[], @widths::integer[],
+		    @lengths::integer[], @units::text[], @prices::bigint[], @stocks::integer[]
+		) AS v(id, product_id, sku, name, width_cm, length_cm, unit, price_kopecks, stock)
+		WHERE EXISTS (SELECT 1 FROM products p WHERE p.id = v.product_id)
+		ON CONFLICT DO NOTHING
+	`
+	n := len(variants)
+	var (
+		ids        = make([]uuid.UUID, n)
+		productIDs = make([]uuid.UUID, n)
+		skus       = make([]string, n)
+		names      = make([]string, n)
+		widths     = make([]*int, n)
+		lengths    = make([]*int, n)
+		units      = make([]string, n)
+		prices     = make([]int64, n)
+		stocks     = make([]int, n)
+	)
+	for i, v := range variants {
+		ids[i] = v.ID
+		productIDs[i] = v.ProductID
+		skus[i] = v.SKU
+		names[i] = v.Name
+		widths[i] = v.WidthCm
+		lengths[i] = v.LengthCm
+		units[i] = string(v.Unit)
+		prices[i] = v.PriceKopecks
+		stocks[i] = v.Stock
+	}
+	args := pgx.NamedArgs{
+		"ids":         ids,
+		"product_ids": productIDs,
+		"skus":        skus,
+		"names":       names,
+		"widths":      widths,
+		"lengths":     lengths,
+		"units":       units,
+		"prices":      prices,
+		"stocks":      stocks,
+	}
+	res, err := s.pool.Exec(ctx, query, args)
+	if err != nil {
+		return 0, utils.Wrap(op, err)
+	}
+	return res.RowsAffected(), nil
+}
+
+// InsertProductMedia добавляет изображения в галереи товаров на заданные позиции
+// и возвращает количество новых элементов. Изображения, уже добавленные в галерею товара,
+// и элементы галерей отсутствующих товаров пропускаются.
+func (s *SeedStorage) InsertProductMedia(ctx context.Context, items []types.ProductMedia) (int64, error) {
+	op := fmt.Sprintf("insert %d product media", len(items))
+	query := `
+		INSERT INTO product_media (product_id, media_id, position, alt_text)
+		SELECT pm.* FROM unnest(
+		    @product_ids::uuid[], @media_ids::uuid[], @positions::integer[], @alt_texts::text[]
+		) AS pm(product_id, media_id, position, alt_text)
+		WHERE EXISTS (SELECT 1 FROM products p WHERE p.id = pm.product_id)
+		ON CONFLICT DO NOTHING
+	`
+	n := len(items)
+	var (
+		productIDs = make([]uuid.UUID, n)
+		mediaIDs   = make([]uuid.UUID, n)
+		positions  = make([]int, n)
+		altTexts   = make([]string, n)
+	)
+	for i, item := range items {
+		productIDs[i] = item.ProductID
+		mediaIDs[i] = item.MediaID
+		positions[i] = item.Position
+		altTexts[i] = item.AltText
+	}
+	args := pgx.NamedArgs{
+		"product_ids": productIDs,
+		"media_ids":   mediaIDs,
+		"positions":   positions,
+		"alt_texts":   altTexts,
+	}
+	res, err := s.pool.Exec(ctx, query, args)
+	if err != nil {
+		return 0, utils.Wrap(op, err)
+	}
+	return res.RowsAffected(), nil
+}
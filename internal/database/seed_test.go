@@ -0,0 +1,169 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/LigeronAhill/luxcarpets-go/internal/database/types"
+	"github.com/google/uuid"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSeedStorage_EnsureCategory(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	storage := NewSeedStorage(mock)
+	parentID := uuid.New()
+	categoryID := uuid.New()
+	description := "Ковры ручной работы"
+	now := time.Now()
+
+	// Существующая категория возвращается без изменений
+	mock.ExpectQuery(`WITH inserted AS \(
+			INSERT INTO categories .+ ON CONFLICT \(slug\) DO NOTHING
+			RETURNING \*
+		\)
+		SELECT \* FROM inserted
+		UNION ALL
+		SELECT \* FROM categories WHERE slug = @slug`).
+		WithArgs(&parentID, "kovry-ruchnoj-raboty", "Ковры ручной работы", &description, 1).
+		WillReturnRows(pgxmock.NewRows(categoryColumns).AddRow(
+			categoryID, &parentID, "kovry-ruchnoj-raboty", "Ручная работа", nil, 5, now, now, nil,
+		))
+
+	res, err := storage.EnsureCategory(context.Background(), types.Category{
+		ParentID:    &parentID,
+		Slug:        "kovry-ruchnoj-raboty",
+		Name:        "Ковры ручной работы",
+		Description: &description,
+		Position:    1,
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, categoryID, res.ID)
+	assert.Equal(t, "Ручная работа", res.Name)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSeedStorage_InsertProducts(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	storage := NewSeedStorage(mock)
+	categoryID := uuid.New()
+	ids := []uuid.UUID{uuid.New(), uuid.New()}
+	brand := "Merinos"
+	color := types.ColorBeige
+	hex := "#d6bf9c"
+
+	mock.ExpectExec(`INSERT INTO products .+ SELECT \* FROM unnest\(.+\)
+		ON CONFLICT DO NOTHING`).
+		WithArgs(
+			ids,
+			[]uuid.UUID{categoryID, categoryID},
+			[]string{"kover-merinos-valencia-1", "kover-merinos-valencia-2"},
+			[]string{"Ковер 1", "Ковер 2"},
+			[]*string{nil, nil},
+			[]*string{&brand, nil},
+			[]*string{nil, nil},
+			[]*string{strPtr("beige"), nil},
+			[]*string{&hex, nil},
+			[]bool{true, false},
+		).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+
+	n, err := storage.InsertProducts(context.Background(), []types.Product{
+		{
+			ID:            ids[0],
+			CategoryID:    categoryID,
+			Slug:          "kover-merinos-valencia-1",
+			Name:          "Ковер 1",
+			Brand:         &brand,
+			ColorFamily:   &color,
+			DominantColor: &hex,
+			IsActive:      true,
+		},
+		{ID: ids[1], CategoryID: categoryID, Slug: "kover-merinos-valencia-2", Name: "Ковер 2"},
+	})
+
+	require.NoError(t, err)
+	// Второй товар уже был в базе
+	assert.Equal(t, int64(1), n)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSeedStorage_InsertVariants(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	storage := NewSeedStorage(mock)
+	productID := uuid.New()
+	variantID := uuid.New()
+	width := 400
+
+	mock.ExpectExec(`INSERT INTO product_variants .+
+		WHERE EXISTS \(SELECT 1 FROM products p WHERE p.id = v.product_id\)
+		ON CONFLICT DO NOTHING`).
+		WithArgs(
+			[]uuid.UUID{variantID},
+			[]uuid.UUID{productID},
+			[]string{"LC000001-W400"},
+			[]string{"Ширина 4 м"},
+			[]*int{&width},
+			[]*int{nil},
+			[]string{"sqm"},
+			[]int64{150000},
+			[]int{300},
+		).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+
+	n, err := storage.InsertVariants(context.Background(), []types.ProductVariant{{
+		ID:           variantID,
+		ProductID:    productID,
+		SKU:          "LC000001-W400",
+		Name:         "Ширина 4 м",
+		WidthCm:      &width,
+		Unit:         types.UnitSqm,
+		PriceKopecks: 150000,
+		Stock:        300,
+	}})
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSeedStorage_InsertProductMedia(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	storage := NewSeedStorage(mock)
+	productID := uuid.New()
+	mediaIDs := []uuid.UUID{uuid.New(), uuid.New()}
+
+	mock.ExpectExec(`INSERT INTO product_media \(product_id, media_id, position, alt_text\)`).
+		WithArgs(
+			[]uuid.UUID{productID, productID},
+			mediaIDs,
+			[]int{0, 1},
+			[]string{"Ковер", "Ковер в интерьере"},
+		).
+		WillReturnResult(pgxmock.NewResult("INSERT", 2))
+
+	n, err := storage.InsertProductMedia(context.Background(), []types.ProductMedia{
+		{ProductID: productID, MediaID: mediaIDs[0], Position: 0, AltText: "Ковер"},
+		{ProductID: productID, MediaID: mediaIDs[1], Position: 1, AltText: "Ковер в интерьере"},
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), n)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,124 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/LigeronAhill/luxcarpets-go/internal/database/types"
+	"github.com/LigeronAhill/luxcarpets-go/pkg/utils"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// SessionsStorage хранит сессии браузера
+type SessionsStorage struct {
+	pool PgxPoolIface
+}
+
+func NewSessionsStorage(pool PgxPoolIface) *SessionsStorage {
+	return &SessionsStorage{
+		pool: pool,
+	}
+}
+
+// Create сохраняет новую сессию
+func (s *SessionsStorage) Create(ctx context.Context, params types.CreateSessionParams) (*types.Session, error) {
+	op := "create session for user " + params.UserID.String()
+	query := `
+		INSERT INTO sessions (user_id, token_hash, user_agent, ip_address, expires_at)
+		VALUES (@user_id, @token_hash, @user_agent, @ip_address, @expires_at)
+		RETURNING *
+	`
+	args := pgx.NamedArgs{
+		"user_id":    params.UserID,
+		"token_hash": params.TokenHash,
+		"user_agent": params.UserAgent,
+		"ip_address": params.IPAddress,
+		"expires_at": params.ExpiresAt,
+	}
+	rows, err := s.pool.Query(ctx, query, args)
+	if err != nil {
+		return nil, utils.Wrap(op, err)
+	}
+	defer rows.Close()
+	res, err := pgx.CollectExactlyOneRow(rows, pgx.RowToAddrOfStructByName[types.Session])
+	if err != nil {
+		return nil, utils.Wrap(op, err)
+	}
+	return res, nil
+}
+
+// GetByHash возвращает сессию по хешу токена или nil, если такой сессии нет
+func (s *SessionsStorage) GetByHash(ctx context.Context, tokenHash string) (*types.Session, error) {
+	op := "get session by hash"
+	query := `
+		SELECT * FROM sessions WHERE token_hash = @token_hash
+	`
+	args := pgx.NamedArgs{
+		"token_hash": tokenHash,
+	}
+	rows, err := s.pool.Query(ctx, query, args)
+	if err != nil {
+		return nil, utils.Wrap(op, err)
+	}
+	defer rows.Close()
+	res, err := pgx.CollectExactlyOneRow(rows, pgx.RowToAddrOfStructByName[types.Session])
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, utils.Wrap(op, err)
+	}
+	return res, nil
+}
+
+// Touch обновляет время последнего запроса, если предыдущее значение старше staleBefore
+func (s *SessionsStorage) Touch(ctx context.Context, sessionID uuid.UUID, now, staleBefore time.Time) error {
+	op := "touch session " + sessionID.String()
+	query := `
+		UPDATE sessions
+		SET last_seen_at = @now
+		WHERE id = @id AND last_seen_at < @stale_before
+	`
+	args := pgx.NamedArgs{
+		"id":           sessionID,
+		"now":          now,
+		"stale_before": staleBefore,
+	}
+	if _, err := s.pool.Exec(ctx, query, args); err != nil {
+		return utils.Wrap(op, err)
+	}
+	return nil
+}
+
+// Delete удаляет сессию (выход)
+func (s *SessionsStorage) Delete(ctx context.Context, sessionID uuid.UUID) error {
+	op := "delete session " + sessionID.String()
+	query := `
+		DELETE FROM sessions WHERE id = @id
+	`
+	args := pgx.NamedArgs{
+		"id": sessionID,
+	}
+	if _, err := s.pool.Exec(ctx, query, args); err != nil {
+		return utils.Wrap(op, err)
+	}
+	return nil
+}
+
+// DeleteByUser удаляет все сессии пользователя и возвращает их количество
+func (s *SessionsStorage) DeleteByUser(ctx context.Context, userID uuid.UUID) (int64, error) {
+	op := "delete sessions of user " + userID.String()
+	query := `
+		DELETE FROM sessions WHERE user_id = @user_id
+	`
+	args := pgx.NamedArgs{
+		"user_id": userID,
+	}
+	res, err := s.pool.Exec(ctx, query, args)
+	if err != nil {
+		return 0, utils.Wrap(op, err)
+	}
+	return res.RowsAffected(), nil
+}
@@ -0,0 +1,103 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/LigeronAhill/luxcarpets-go/internal/database/types"
+	"github.com/google/uuid"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var sessionColumns = []string{
+	"id", "user_id", "token_hash", "user_agent", "ip_address", "expires_at", "last_seen_at", "created_at",
+}
+
+func TestSessionsStorage_Create(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	storage := NewSessionsStorage(mock)
+	sessionID := uuid.New()
+	userID := uuid.New()
+	now := time.Now()
+	expiresAt := now.Add(time.Hour)
+	userAgent := "Mozilla/5.0"
+
+	mock.ExpectQuery(`INSERT INTO sessions \(user_id, token_hash, user_agent, ip_address, expires_at\)`).
+		WithArgs(userID, "hash", &userAgent, (*string)(nil), expiresAt).
+		WillReturnRows(pgxmock.NewRows(sessionColumns).AddRow(
+			sessionID, userID, "hash", &userAgent, nil, expiresAt, now, now,
+		))
+
+	res, err := storage.Create(context.Background(), types.CreateSessionParams{
+		UserID:    userID,
+		TokenHash: "hash",
+		UserAgent: &userAgent,
+		ExpiresAt: expiresAt,
+	})
+
+	require.NoError(t, err)
+	assert.Equal(t, sessionID, res.ID)
+	assert.True(t, res.Active(now))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSessionsStorage_GetByHash_NotFound(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	storage := NewSessionsStorage(mock)
+
+	mock.ExpectQuery(`SELECT \* FROM sessions WHERE token_hash = @token_hash`).
+		WithArgs("hash").
+		WillReturnRows(pgxmock.NewRows(sessionColumns))
+
+	res, err := storage.GetByHash(context.Background(), "hash")
+
+	assert.NoError(t, err)
+	assert.Nil(t, res)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSessionsStorage_Touch(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	storage := NewSessionsStorage(mock)
+	sessionID := uuid.New()
+	now := time.Now()
+	stale := now.Add(-time.Minute)
+
+	mock.ExpectExec(`UPDATE sessions\s+SET last_seen_at = @now`).
+		WithArgs(now, sessionID, stale).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+
+	assert.NoError(t, storage.Touch(context.Background(), sessionID, now, stale))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSessionsStorage_DeleteByUser(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	storage := NewSessionsStorage(mock)
+	userID := uuid.New()
+
+	mock.ExpectExec(`DELETE FROM sessions WHERE user_id = @user_id`).
+		WithArgs(userID).
+		WillReturnResult(pgxmock.NewResult("DELETE", 3))
+
+	n, err := storage.DeleteByUser(context.Background(), userID)
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(3), n)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,86 @@
+package database
+
+import (
+	"context"
+
+	"github.com/LigeronAhill/luxcarpets-go/internal/database/types"
+	"github.com/LigeronAhill/luxcarpets-go/pkg/utils"
+	"github.com/jackc/pgx/v5"
+)
+
+// SitemapStorage читает страницы каталога для карты сайта
+type SitemapStorage struct {
+	pool PgxPoolIface
+}
+
+func NewSitemapStorage(pool PgxPoolIface) *SitemapStorage {
+	return &SitemapStorage{
+		pool: pool,
+	}
+}
+
+// Version возвращает отпечаток данных карты сайта: количество записей и время последнего изменения
+// категорий, товаров и галерей. Триггеры обновляют updated_at при любом изменении строки,
+// включая мягкое удаление, поэтому отпечаток меняется вместе с содержимым карты.
+func (s *SitemapStorage) Version(ctx context.Context) (string, error) {
+	op := "get sitemap version"
+	query := `
+		SELECT concat_ws('/',
+			(SELECT COUNT(*) || ':' || COALESCE(MAX(updated_at)::TEXT, '') FROM categories),
+			(SELECT COUNT(*) || ':' || COALESCE(MAX(updated_at)::TEXT, '') FROM products),
+			(SELECT COUNT(*) || ':' || COALESCE(MAX(created_at)::TEXT, '') FROM product_media)
+		)
+	`
+	var version string
+	if err := s.pool.QueryRow(ctx, query).Scan(&version); err != nil {
+		return "", utils.Wrap(op, err)
+	}
+	return version, nil
+}
+
+// Categories возвращает неудаленные категории
+func (s *SitemapStorage) Categories(ctx context.Context) ([]*types.SitemapCategory, error) {
+	op := "list sitemap categories"
+	query := `
+		SELECT slug, updated_at FROM categories
+		WHERE deleted_at IS NULL
+		ORDER BY position, slug
+	`
+	rows, err := s.pool.Query(ctx, query)
+	if err != nil {
+		return nil, utils.Wrap(op, err)
+	}
+	defer rows.Close()
+	res, err := pgx.CollectRows(rows, pgx.RowToAddrOfStructByName[types.SitemapCategory])
+	if err != nil {
+		return nil, utils.Wrap(op, err)
+	}
+	return res, nil
+}
+
+// Products возвращает активные неудаленные товары с изображениями галереи товара.
+// Для каждого изображения берется самая большая копия - последняя в renditions.
+func (s *SitemapStorage) Products(ctx context.Context) ([]*types.SitemapProduct, error) {
+	op := "list sitemap products"
+	query := `
+		SELECT p.slug, p.updated_at, COALESCE((
+			SELECT json_agg(m.renditions -> -1 ->> 'key' ORDER BY pm.position, pm.created_at)
+			FROM product_media pm
+			JOIN media m ON m.id = pm.media_id
+			WHERE pm.product_id = p.id AND pm.variant_id IS NULL
+		), '[]') AS image_keys
+		FROM products p
+		WHERE p.deleted_at IS NULL AND p.is_active
+		ORDER BY p.created_at, p.id
+	`
+	rows, err := s.pool.Query(ctx, query)
+	if err != nil {
+		return nil, utils.Wrap(op, err)
+	}
+	defer rows.Close()
+	res, err := pgx.CollectRows(rows, pgx.RowToAddrOfStructByName[types.SitemapProduct])
+	if err != nil {
+		return nil, utils.Wrap(op, err)
+	}
+	return res, nil
+}
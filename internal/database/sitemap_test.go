@@ -0,0 +1,51 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSitemapStorage_Version(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	storage := NewSitemapStorage(mock)
+
+	mock.ExpectQuery(`SELECT concat_ws\('/',`).
+		WillReturnRows(pgxmock.NewRows([]string{"concat_ws"}).AddRow("2:2025-03-01/10:2025-03-02/4:2025-03-02"))
+
+	version, err := storage.Version(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, "2:2025-03-01/10:2025-03-02/4:2025-03-02", version)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSitemapStorage_Products(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	storage := NewSitemapStorage(mock)
+	updatedAt := time.Date(2025, 3, 2, 9, 30, 0, 0, time.UTC)
+
+	mock.ExpectQuery(`SELECT p.slug, p.updated_at, .+ FROM products p WHERE p.deleted_at IS NULL AND p.is_active`).
+		WillReturnRows(pgxmock.NewRows([]string{"slug", "updated_at", "image_keys"}).
+			AddRow("osta-diamond", updatedAt, []string{"media/ab/abcd/1920.jpg"}).
+			AddRow("bez-foto", updatedAt, []string{}))
+
+	res, err := storage.Products(context.Background())
+
+	require.NoError(t, err)
+	require.Len(t, res, 2)
+	assert.Equal(t, "osta-diamond", res[0].Slug)
+	assert.Equal(t, []string{"media/ab/abcd/1920.jpg"}, res[0].ImageKeys)
+	assert.Empty(t, res[1].ImageKeys)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,97 @@
+package database
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/LigeronAhill/luxcarpets-go/internal/tracing"
+	"github.com/jackc/pgx/v5"
+	semconv "go.opentelemetry.io/otel/semconv/v1.34.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// queryTracer создает span на каждый запрос к базе (pgx.QueryTracer).
+// Имя span - краткое описание запроса, например "SELECT users" (см. querySummary).
+// В атрибуты попадает текст запроса без значений параметров: в них бывают хэши паролей и токены.
+type queryTracer struct{}
+
+func (queryTracer) TraceQueryStart(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryStartData) context.Context {
+	operation, collection := querySummary(data.SQL)
+	name := operation
+	if collection != "" {
+		name += " " + collection
+	}
+	attrs := []trace.SpanStartOption{
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.DBSystemNamePostgreSQL,
+			semconv.DBQuerySummary(name),
+			semconv.DBOperationName(operation),
+			semconv.DBQueryText(strings.TrimSpace(data.SQL)),
+		),
+	}
+	if collection != "" {
+		attrs = append(attrs, trace.WithAttributes(semconv.DBCollectionName(collection)))
+	}
+	ctx, _ = tracing.Start(ctx, name, attrs...)
+	return ctx
+}
+
+func (queryTracer) TraceQueryEnd(ctx context.Context, _ *pgx.Conn, data pgx.TraceQueryEndData) {
+	span := trace.SpanFromContext(ctx)
+	if data.Err != nil {
+		tracing.RecordError(span, data.Err)
+	}
+	span.End()
+}
+
+// querySummary возвращает операцию запроса и первую таблицу после FROM, INTO или UPDATE:
+// "SELECT", "users" для "SELECT * FROM users WHERE id = @id". Для остальных запросов
+// (WITH, BEGIN и т. п.) возвращается только первое ключевое слово.
+func querySummary(sql string) (operation, collection string) {
+	fields := strings.Fields(sql)
+	if len(fields) == 0 {
+		return "", ""
+	}
+	operation = strings.ToUpper(fields[0])
+	keyword := ""
+	switch operation {
+	case "SELECT", "DELETE":
+		keyword = "FROM"
+	case "INSERT":
+		keyword = "INTO"
+	case "UPDATE":
+		if len(fields) > 1 {
+			return operation, tableName(fields[1])
+		}
+		return operation, ""
+	default:
+		return operation, ""
+	}
+	for i, field := range fields[:len(fields)-1] {
+		if strings.EqualFold(field, keyword) {
+			return operation, tableName(fields[i+1])
+		}
+	}
+	return operation, ""
+}
+
+// tableName убирает из имени таблицы скобки и запятые: "users," -> "users", "(SELECT" -> ""
+func tableName(s string) string {
+	s = strings.TrimRight(s, ",;)")
+	if strings.HasPrefix(s, "(") {
+		return ""
+	}
+	return s
+}
+
+// endSpan завершает span метода хранилища. pgx.ErrNoRows - обычный результат поиска
+// (пользователь не найден), поэтому span не отмечается ошибочным.
+func endSpan(span trace.Span, err *error) {
+	if err != nil && errors.Is(*err, pgx.ErrNoRows) {
+		span.End()
+		return
+	}
+	tracing.End(span, err)
+}
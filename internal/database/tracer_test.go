@@ -0,0 +1,32 @@
+package database
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestQuerySummary(t *testing.T) {
+	tests := []struct {
+		name               string
+		sql                string
+		expectedOperation  string
+		expectedCollection string
+	}{
+		{"выборка", "\n\t\tSELECT * FROM users WHERE id = @id AND deleted_at IS NULL\n\t", "SELECT", "users"},
+		{"вставка с псевдонимом", "INSERT INTO rate_limits AS r (key, tat) VALUES (@key, @now)", "INSERT", "rate_limits"},
+		{"обновление", "UPDATE users SET deleted_at = NOW() WHERE id = @id;", "UPDATE", "users"},
+		{"удаление", "delete from sessions where expires_at < @now", "DELETE", "sessions"},
+		{"подзапрос", "SELECT count(*) FROM (SELECT 1) AS t", "SELECT", ""},
+		{"функция без таблицы", "SELECT set_config('application_name', $1, false)", "SELECT", ""},
+		{"общее табличное выражение", "WITH deleted AS (DELETE FROM media RETURNING key) SELECT key FROM deleted", "WITH", ""},
+		{"пустой запрос", "  ", "", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			operation, collection := querySummary(tt.sql)
+			assert.Equal(t, tt.expectedOperation, operation)
+			assert.Equal(t, tt.expectedCollection, collection)
+		})
+	}
+}
@@ -0,0 +1,189 @@
+package database
+
+import (
+	"context"
+	"errors"
+
+	"github.com/LigeronAhill/luxcarpets-go/internal/database/types"
+	"github.com/LigeronAhill/luxcarpets-go/pkg/utils"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrTOTPAlreadyConfirmed возвращается при попытке заменить секрет подтвержденного аутентификатора
+var ErrTOTPAlreadyConfirmed = errors.New("totp already confirmed")
+
+// TwoFactorStorage хранит TOTP-секреты и коды восстановления
+type TwoFactorStorage struct {
+	pool PgxPoolIface
+}
+
+func NewTwoFactorStorage(pool PgxPoolIface) *TwoFactorStorage {
+	return &TwoFactorStorage{
+		pool: pool,
+	}
+}
+
+// UpsertTOTP сохраняет новый неподтвержденный секрет.
+// Если аутентификатор уже подтвержден, возвращает ErrTOTPAlreadyConfirmed.
+func (s *TwoFactorStorage) UpsertTOTP(ctx context.Context, userID uuid.UUID, secretEncrypted []byte) (*types.UserTOTP, error) {
+	op := "upsert totp for user " + userID.String()
+	query := `
+		INSERT INTO user_totp (user_id, secret_encrypted)
+		VALUES (@user_id, @secret_encrypted)
+		ON CONFLICT (user_id) DO UPDATE
+		SET
+		    secret_encrypted = EXCLUDED.secret_encrypted,
+		    last_used_step = 0
+		WHERE user_totp.confirmed_at IS NULL
+		RETURNING *
+	`
+	args := pgx.NamedArgs{
+		"user_id":          userID,
+		"secret_encrypted": secretEncrypted,
+	}
+	rows, err := s.pool.Query(ctx, query, args)
+	if err != nil {
+		return nil, utils.Wrap(op, err)
+	}
+	defer rows.Close()
+	res, err := pgx.CollectExactlyOneRow(rows, pgx.RowToAddrOfStructByName[types.UserTOTP])
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrTOTPAlreadyConfirmed
+		}
+		return nil, utils.Wrap(op, err)
+	}
+	return res, nil
+}
+
+// GetTOTP возвращает аутентификатор пользователя или nil, если он не подключался
+func (s *TwoFactorStorage) GetTOTP(ctx context.Context, userID uuid.UUID) (*types.UserTOTP, error) {
+	op := "get totp for user " + userID.String()
+	query := `
+		SELECT * FROM user_totp WHERE user_id = @user_id
+	`
+	args := pgx.NamedArgs{
+		"user_id": userID,
+	}
+	rows, err := s.pool.Query(ctx, query, args)
+	if err != nil {
+		return nil, utils.Wrap(op, err)
+	}
+	defer rows.Close()
+	res, err := pgx.CollectExactlyOneRow(rows, pgx.RowToAddrOfStructByName[types.UserTOTP])
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, nil
+		}
+		return nil, utils.Wrap(op, err)
+	}
+	return res, nil
+}
+
+// ConfirmTOTP подтверждает аутентификатор и запоминает использованный шаг времени.
+// Возвращает false, если подтверждать нечего.
+func (s *TwoFactorStorage) ConfirmTOTP(ctx context.Context, userID uuid.UUID, step int64) (bool, error) {
+	op := "confirm totp for user " + userID.String()
+	query := `
+		UPDATE user_totp
+		SET confirmed_at = NOW(), last_used_step = @step
+		WHERE user_id = @user_id AND confirmed_at IS NULL
+	`
+	args := pgx.NamedArgs{
+		"user_id": userID,
+		"step":    step,
+	}
+	res, err := s.pool.Exec(ctx, query, args)
+	if err != nil {
+		return false, utils.Wrap(op, err)
+	}
+	return res.RowsAffected() == 1, nil
+}
+
+// UseTOTPStep атомарно отмечает шаг времени как использованный.
+// Возвращает false, если этот или более поздний шаг уже был принят (повтор кода).
+func (s *TwoFactorStorage) UseTOTPStep(ctx context.Context, userID uuid.UUID, step int64) (bool, error) {
+	op := "use totp step for user " + userID.String()
+	query := `
+		UPDATE user_totp
+		SET last_used_step = @step
+		WHERE user_id = @user_id AND confirmed_at IS NOT NULL AND last_used_step < @step
+	`
+	args := pgx.NamedArgs{
+		"user_id": userID,
+		"step":    step,
+	}
+	res, err := s.pool.Exec(ctx, query, args)
+	if err != nil {
+		return false, utils.Wrap(op, err)
+	}
+	return res.RowsAffected() == 1, nil
+}
+
+// DeleteTOTP отключает двухфакторную аутентификацию: удаляет секрет и коды восстановления
+func (s *TwoFactorStorage) DeleteTOTP(ctx context.Context, userID uuid.UUID) error {
+	op := "delete totp for user " + userID.String()
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return utils.Wrap(op, err)
+	}
+	defer tx.Rollback(ctx)
+
+	args := pgx.NamedArgs{
+		"user_id": userID,
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM user_recovery_codes WHERE user_id = @user_id`, args); err != nil {
+		return utils.Wrap(op, err)
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM user_totp WHERE user_id = @user_id`, args); err != nil {
+		return utils.Wrap(op, err)
+	}
+	return utils.Wrap(op, tx.Commit(ctx))
+}
+
+// ReplaceRecoveryCodes заменяет все коды восстановления пользователя новыми хешами
+func (s *TwoFactorStorage) ReplaceRecoveryCodes(ctx context.Context, userID uuid.UUID, codeHashes []string) error {
+	op := "replace recovery codes for user " + userID.String()
+	tx, err := s.pool.Begin(ctx)
+	if err != nil {
+		return utils.Wrap(op, err)
+	}
+	defer tx.Rollback(ctx)
+
+	args := pgx.NamedArgs{
+		"user_id":     userID,
+		"code_hashes": codeHashes,
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM user_recovery_codes WHERE user_id = @user_id`, args); err != nil {
+		return utils.Wrap(op, err)
+	}
+	query := `
+		INSERT INTO user_recovery_codes (user_id, code_hash)
+		SELECT @user_id, unnest(@code_hashes::text[])
+	`
+	if _, err := tx.Exec(ctx, query, args); err != nil {
+		return utils.Wrap(op, err)
+	}
+	return utils.Wrap(op, tx.Commit(ctx))
+}
+
+// UseRecoveryCode атомарно отмечает код восстановления как использованный.
+// Возвращает false, если код не найден или уже использован.
+func (s *TwoFactorStorage) UseRecoveryCode(ctx context.Context, userID uuid.UUID, codeHash string) (bool, error) {
+	op := "use recovery code for user " + userID.String()
+	query := `
+		UPDATE user_recovery_codes
+		SET used_at = NOW()
+		WHERE user_id = @user_id AND code_hash = @code_hash AND used_at IS NULL
+	`
+	args := pgx.NamedArgs{
+		"user_id":   userID,
+		"code_hash": codeHash,
+	}
+	res, err := s.pool.Exec(ctx, query, args)
+	if err != nil {
+		return false, utils.Wrap(op, err)
+	}
+	return res.RowsAffected() == 1, nil
+}
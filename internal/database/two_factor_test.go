@@ -0,0 +1,174 @@
+package database
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var userTOTPColumns = []string{"user_id", "secret_encrypted", "confirmed_at", "last_used_step", "created_at", "updated_at"}
+
+func TestTwoFactorStorage_UpsertTOTP_Success(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	storage := NewTwoFactorStorage(mock)
+	userID := uuid.New()
+	secret := []byte{1, 2, 3}
+	now := time.Now()
+
+	mock.ExpectQuery(`INSERT INTO user_totp \(user_id, secret_encrypted\)
+		VALUES \(@user_id, @secret_encrypted\)
+		ON CONFLICT \(user_id\) DO UPDATE`).
+		WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg()).
+		WillReturnRows(pgxmock.NewRows(userTOTPColumns).AddRow(userID, secret, nil, int64(0), now, now))
+
+	res, err := storage.UpsertTOTP(context.Background(), userID, secret)
+
+	require.NoError(t, err)
+	assert.Equal(t, userID, res.UserID)
+	assert.Equal(t, secret, res.SecretEncrypted)
+	assert.False(t, res.Confirmed())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTwoFactorStorage_UpsertTOTP_AlreadyConfirmed(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	storage := NewTwoFactorStorage(mock)
+
+	// Условие WHERE в ON CONFLICT не дает заменить подтвержденный секрет - строк не возвращается
+	mock.ExpectQuery(`INSERT INTO user_totp`).
+		WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg()).
+		WillReturnRows(pgxmock.NewRows(userTOTPColumns))
+
+	res, err := storage.UpsertTOTP(context.Background(), uuid.New(), []byte{1})
+
+	assert.Nil(t, res)
+	assert.ErrorIs(t, err, ErrTOTPAlreadyConfirmed)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTwoFactorStorage_GetTOTP_NotFound(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	storage := NewTwoFactorStorage(mock)
+
+	mock.ExpectQuery(`SELECT \* FROM user_totp WHERE user_id = @user_id`).
+		WithArgs(pgxmock.AnyArg()).
+		WillReturnRows(pgxmock.NewRows(userTOTPColumns))
+
+	res, err := storage.GetTOTP(context.Background(), uuid.New())
+
+	assert.NoError(t, err)
+	assert.Nil(t, res)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTwoFactorStorage_UseTOTPStep(t *testing.T) {
+	tests := []struct {
+		name     string
+		affected int64
+		expected bool
+	}{
+		{"новый шаг принят", 1, true},
+		{"повтор шага отклонен", 0, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock, err := pgxmock.NewPool()
+			require.NoError(t, err)
+			defer mock.Close()
+
+			storage := NewTwoFactorStorage(mock)
+
+			mock.ExpectExec(`UPDATE user_totp
+		SET last_used_step = @step
+		WHERE user_id = @user_id AND confirmed_at IS NOT NULL AND last_used_step < @step`).
+				WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg()).
+				WillReturnResult(pgxmock.NewResult("UPDATE", tt.affected))
+
+			ok, err := storage.UseTOTPStep(context.Background(), uuid.New(), 100)
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, ok)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestTwoFactorStorage_ReplaceRecoveryCodes(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	storage := NewTwoFactorStorage(mock)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE FROM user_recovery_codes WHERE user_id = @user_id`).
+		WithArgs(pgxmock.AnyArg()).
+		WillReturnResult(pgxmock.NewResult("DELETE", 10))
+	mock.ExpectExec(`INSERT INTO user_recovery_codes \(user_id, code_hash\)
+		SELECT @user_id, unnest\(@code_hashes::text\[\]\)`).
+		WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg()).
+		WillReturnResult(pgxmock.NewResult("INSERT", 2))
+	mock.ExpectCommit()
+
+	err = storage.ReplaceRecoveryCodes(context.Background(), uuid.New(), []string{"a", "b"})
+
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTwoFactorStorage_DeleteTOTP(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	storage := NewTwoFactorStorage(mock)
+
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE FROM user_recovery_codes WHERE user_id = @user_id`).
+		WithArgs(pgxmock.AnyArg()).
+		WillReturnResult(pgxmock.NewResult("DELETE", 3))
+	mock.ExpectExec(`DELETE FROM user_totp WHERE user_id = @user_id`).
+		WithArgs(pgxmock.AnyArg()).
+		WillReturnResult(pgxmock.NewResult("DELETE", 1))
+	mock.ExpectCommit()
+
+	err = storage.DeleteTOTP(context.Background(), uuid.New())
+
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTwoFactorStorage_UseRecoveryCode(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	storage := NewTwoFactorStorage(mock)
+
+	mock.ExpectExec(`UPDATE user_recovery_codes
+		SET used_at = NOW\(\)
+		WHERE user_id = @user_id AND code_hash = @code_hash AND used_at IS NULL`).
+		WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg()).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+
+	ok, err := storage.UseRecoveryCode(context.Background(), uuid.New(), "hash")
+
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
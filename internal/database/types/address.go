@@ -0,0 +1,111 @@
+package types
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ElevatorType описывает наличие лифта по адресу доставки.
+// Для тяжелых рулонов важно, поместятся ли они в лифт.
+type ElevatorType string
+
+const (
+	ElevatorNone      ElevatorType = "none"      // Лифта нет
+	ElevatorPassenger ElevatorType = "passenger" // Только пассажирский лифт
+	ElevatorFreight   ElevatorType = "freight"   // Есть грузовой лифт
+)
+
+// Valid проверяет, является ли тип лифта допустимым
+func (e ElevatorType) Valid() bool {
+	switch e {
+	case ElevatorNone, ElevatorPassenger, ElevatorFreight:
+		return true
+	default:
+		return false
+	}
+}
+
+// DeliveryTimeSlot описывает удобное время доставки
+type DeliveryTimeSlot string
+
+const (
+	TimeSlotAny       DeliveryTimeSlot = "any"       // В любое время
+	TimeSlotMorning   DeliveryTimeSlot = "morning"   // 9:00 - 13:00
+	TimeSlotAfternoon DeliveryTimeSlot = "afternoon" // 13:00 - 18:00
+	TimeSlotEvening   DeliveryTimeSlot = "evening"   // 18:00 - 22:00
+)
+
+// Valid проверяет, является ли интервал доставки допустимым
+func (t DeliveryTimeSlot) Valid() bool {
+	switch t {
+	case TimeSlotAny, TimeSlotMorning, TimeSlotAfternoon, TimeSlotEvening:
+		return true
+	default:
+		return false
+	}
+}
+
+// Address представляет сохраненный адрес доставки пользователя.
+// Поля повторяют структуру российского адреса: индекс, регион, город, улица, дом, корпус, квартира.
+type Address struct {
+	ID             uuid.UUID    `json:"id" db:"id"`                             // Уникальный идентификатор адреса
+	UserID         uuid.UUID    `json:"-" db:"user_id"`                         // Владелец адреса
+	Label          *string      `json:"label,omitempty" db:"label"`             // Название ("Дом", "Офис")
+	RecipientName  string       `json:"recipient_name" db:"recipient_name"`     // ФИО получателя
+	RecipientPhone string       `json:"recipient_phone" db:"recipient_phone"`   // Телефон получателя в E.164
+	PostalCode     *string      `json:"postal_code,omitempty" db:"postal_code"` // Почтовый индекс (6 цифр)
+	Region         string       `json:"region" db:"region"`                     // Регион (область, край, республика)
+	City           string       `json:"city" db:"city"`                         // Город или населенный пункт
+	Street         string       `json:"street" db:"street"`                     // Улица
+	House          string       `json:"house" db:"house"`                       // Дом
+	Building       *string      `json:"building,omitempty" db:"building"`       // Корпус или строение
+	Apartment      *string      `json:"apartment,omitempty" db:"apartment"`     // Квартира или офис
+	Entrance       *string      `json:"entrance,omitempty" db:"entrance"`       // Подъезд
+	Intercom       *string      `json:"intercom,omitempty" db:"intercom"`       // Код домофона
+	Floor          *int16       `json:"floor,omitempty" db:"floor"`             // Этаж
+	Elevator       ElevatorType `json:"elevator" db:"elevator"`                 // Наличие лифта
+	Comment        *string      `json:"comment,omitempty" db:"comment"`         // Комментарий для курьера
+	IsDefault      bool         `json:"is_default" db:"is_default"`             // Адрес по умолчанию
+	CreatedAt      time.Time    `json:"created_at" db:"created_at"`             // Дата и время создания
+	UpdatedAt      time.Time    `json:"updated_at" db:"updated_at"`             // Дата и время последнего обновления
+}
+
+// AddressParams содержит поля адреса для создания или полной замены.
+type AddressParams struct {
+	Label          *string      `json:"label"`
+	RecipientName  string       `json:"recipient_name"`
+	RecipientPhone string       `json:"recipient_phone"`
+	PostalCode     *string      `json:"postal_code"`
+	Region         string       `json:"region"`
+	City           string       `json:"city"`
+	Street         string       `json:"street"`
+	House          string       `json:"house"`
+	Building       *string      `json:"building"`
+	Apartment      *string      `json:"apartment"`
+	Entrance       *string      `json:"entrance"`
+	Intercom       *string      `json:"intercom"`
+	Floor          *int16       `json:"floor"`
+	Elevator       ElevatorType `json:"elevator"`
+	Comment        *string      `json:"comment"`
+	IsDefault      bool         `json:"is_default"` // Сделать адресом по умолчанию
+}
+
+// DeliveryPreferences содержит предпочтения пользователя по доставке
+type DeliveryPreferences struct {
+	UserID             uuid.UUID        `json:"-" db:"user_id"`                                 // Владелец
+	TimeSlot           DeliveryTimeSlot `json:"time_slot" db:"time_slot"`                       // Удобное время доставки
+	CallBeforeDelivery bool             `json:"call_before_delivery" db:"call_before_delivery"` // Позвонить перед доставкой
+	LiftingRequired    bool             `json:"lifting_required" db:"lifting_required"`         // Нужен подъем на этаж
+	Comment            *string          `json:"comment,omitempty" db:"comment"`                 // Общий комментарий
+	UpdatedAt          time.Time        `json:"updated_at" db:"updated_at"`                     // Дата и время последнего обновления
+}
+
+// DefaultDeliveryPreferences возвращает предпочтения для пользователя, который их не задавал
+func DefaultDeliveryPreferences(userID uuid.UUID) *DeliveryPreferences {
+	return &DeliveryPreferences{
+		UserID:             userID,
+		TimeSlot:           TimeSlotAny,
+		CallBeforeDelivery: true,
+	}
+}
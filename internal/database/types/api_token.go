@@ -0,0 +1,105 @@
+package types
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TokenScope представляет право доступа персонального токена API
+type TokenScope string
+
+const (
+	ScopeProfileRead  TokenScope = "profile:read"  // Чтение собственного профиля
+	ScopeProfileWrite TokenScope = "profile:write" // Изменение собственного профиля и адресов
+	ScopeCatalogRead  TokenScope = "catalog:read"  // Чтение каталога (фиды маркетплейсов)
+	ScopeCatalogWrite TokenScope = "catalog:write" // Изменение каталога, цен и остатков (синхронизация с 1С)
+	ScopeOrdersRead   TokenScope = "orders:read"   // Чтение заказов
+	ScopeOrdersWrite  TokenScope = "orders:write"  // Изменение статусов заказов
+	ScopeUsersRead    TokenScope = "users:read"    // Чтение пользователей
+	ScopeUsersWrite   TokenScope = "users:write"   // Изменение пользователей
+)
+
+// AllTokenScopes возвращает все допустимые права токенов
+func AllTokenScopes() []TokenScope {
+	return []TokenScope{
+		ScopeProfileRead,
+		ScopeProfileWrite,
+		ScopeCatalogRead,
+		ScopeCatalogWrite,
+		ScopeOrdersRead,
+		ScopeOrdersWrite,
+		ScopeUsersRead,
+		ScopeUsersWrite,
+	}
+}
+
+// Valid проверяет, является ли право допустимым
+func (s TokenScope) Valid() bool {
+	return s.MinRole() != ""
+}
+
+// String возвращает строковое представление права
+func (s TokenScope) String() string {
+	return string(s)
+}
+
+// MinRole возвращает минимальную роль, которой разрешено это право.
+// Для недопустимого права возвращает пустую роль.
+func (s TokenScope) MinRole() UserRole {
+	switch s {
+	case ScopeProfileRead, ScopeProfileWrite, ScopeCatalogRead:
+		return RoleCustomer
+	case ScopeCatalogWrite, ScopeOrdersRead, ScopeOrdersWrite:
+		return RoleEmployee
+	case ScopeUsersRead, ScopeUsersWrite:
+		return RoleAdmin
+	default:
+		return ""
+	}
+}
+
+// AllowedFor проверяет, может ли пользователь с ролью role пользоваться этим правом
+func (s TokenScope) AllowedFor(role UserRole) bool {
+	return s.Valid() && role.HasPermission(s.MinRole())
+}
+
+// TokenScopeFromString создает TokenScope из строки
+func TokenScopeFromString(s string) (TokenScope, error) {
+	scope := TokenScope(s)
+	if !scope.Valid() {
+		return "", fmt.Errorf("incorrect token scope: %s", s)
+	}
+	return scope, nil
+}
+
+// APIToken представляет персональный токен доступа к API.
+// Сам токен не хранится - только его SHA-256 хеш и префикс для отображения.
+type APIToken struct {
+	ID          uuid.UUID    `json:"id" db:"id"`                               // Уникальный идентификатор токена
+	UserID      uuid.UUID    `json:"user_id" db:"user_id"`                     // Владелец токена
+	Name        string       `json:"name" db:"name"`                           // Название (например, "Синхронизация 1С")
+	TokenPrefix string       `json:"token_prefix" db:"token_prefix"`           // Начало токена для отображения
+	TokenHash   string       `json:"-" db:"token_hash"`                        // SHA-256 хеш токена (не возвращается в JSON)
+	Scopes      []TokenScope `json:"scopes" db:"scopes"`                       // Запрошенные права
+	ExpiresAt   time.Time    `json:"expires_at" db:"expires_at"`               // Срок действия
+	LastUsedAt  *time.Time   `json:"last_used_at,omitempty" db:"last_used_at"` // Время последнего использования
+	RevokedAt   *time.Time   `json:"revoked_at,omitempty" db:"revoked_at"`     // Время отзыва (nil = действует)
+	CreatedAt   time.Time    `json:"created_at" db:"created_at"`               // Дата и время создания
+}
+
+// Active возвращает true, если токен не отозван и не истек на момент now
+func (t *APIToken) Active(now time.Time) bool {
+	return t.RevokedAt == nil && now.Before(t.ExpiresAt)
+}
+
+// CreateAPITokenParams содержит параметры для сохранения нового токена
+type CreateAPITokenParams struct {
+	UserID      uuid.UUID    // Владелец токена (обязательно)
+	Name        string       // Название (обязательно)
+	TokenPrefix string       // Начало токена для отображения
+	TokenHash   string       // SHA-256 хеш токена (hex)
+	Scopes      []TokenScope // Права (не пусто)
+	ExpiresAt   time.Time    // Срок действия
+}
@@ -0,0 +1,64 @@
+package types
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTokenScope_AllowedFor(t *testing.T) {
+	tests := []struct {
+		name     string
+		scope    TokenScope
+		role     UserRole
+		expected bool
+	}{
+		{"гость не получает токены", ScopeProfileRead, RoleGuest, false},
+		{"покупатель читает каталог", ScopeCatalogRead, RoleCustomer, true},
+		{"покупатель не меняет каталог", ScopeCatalogWrite, RoleCustomer, false},
+		{"сотрудник меняет каталог", ScopeCatalogWrite, RoleEmployee, true},
+		{"сотрудник не читает пользователей", ScopeUsersRead, RoleEmployee, false},
+		{"администратор читает пользователей", ScopeUsersRead, RoleAdmin, true},
+		{"владелец меняет пользователей", ScopeUsersWrite, RoleOwner, true},
+		{"неизвестное право", TokenScope("everything"), RoleOwner, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.scope.AllowedFor(tt.role))
+		})
+	}
+}
+
+func TestTokenScopeFromString(t *testing.T) {
+	for _, scope := range AllTokenScopes() {
+		parsed, err := TokenScopeFromString(scope.String())
+		assert.NoError(t, err)
+		assert.Equal(t, scope, parsed)
+	}
+
+	_, err := TokenScopeFromString("catalog:delete")
+	assert.Error(t, err)
+}
+
+func TestAPIToken_Active(t *testing.T) {
+	now := time.Now()
+	revokedAt := now.Add(-time.Minute)
+
+	tests := []struct {
+		name     string
+		token    APIToken
+		expected bool
+	}{
+		{"действующий", APIToken{ExpiresAt: now.Add(time.Hour)}, true},
+		{"просроченный", APIToken{ExpiresAt: now.Add(-time.Second)}, false},
+		{"отозванный", APIToken{ExpiresAt: now.Add(time.Hour), RevokedAt: &revokedAt}, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.token.Active(now))
+		})
+	}
+}
@@ -0,0 +1,224 @@
+package types
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ColorFamily - группа цвета для фильтра каталога.
+// Покупатель выбирает "серый" или "бежевый", а не точный оттенок.
+type ColorFamily string
+
+const (
+	ColorWhite  ColorFamily = "white"  // Белый, молочный
+	ColorBeige  ColorFamily = "beige"  // Бежевый, песочный
+	ColorBrown  ColorFamily = "brown"  // Коричневый
+	ColorGray   ColorFamily = "gray"   // Серый
+	ColorBlack  ColorFamily = "black"  // Черный, антрацит
+	ColorRed    ColorFamily = "red"    // Красный, бордовый
+	ColorOrange ColorFamily = "orange" // Оранжевый, терракотовый
+	ColorYellow ColorFamily = "yellow" // Желтый, горчичный
+	ColorGreen  ColorFamily = "green"  // Зеленый, оливковый
+	ColorBlue   ColorFamily = "blue"   // Синий, голубой
+	ColorPurple ColorFamily = "purple" // Фиолетовый, сиреневый
+	ColorPink   ColorFamily = "pink"   // Розовый
+)
+
+// AllColorFamilies возвращает группы цветов в порядке отображения в фильтре
+func AllColorFamilies() []ColorFamily {
+	return []ColorFamily{
+		ColorWhite, ColorBeige, ColorBrown, ColorGray, ColorBlack, ColorRed,
+		ColorOrange, ColorYellow, ColorGreen, ColorBlue, ColorPurple, ColorPink,
+	}
+}
+
+// Valid проверяет, является ли группа цвета допустимой
+func (c ColorFamily) Valid() bool {
+	switch c {
+	case ColorWhite, ColorBeige, ColorBrown, ColorGray, ColorBlack, ColorRed,
+		ColorOrange, ColorYellow, ColorGreen, ColorBlue, ColorPurple, ColorPink:
+		return true
+	default:
+		return false
+	}
+}
+
+// colorFamilyLabels - названия групп цвета для фильтра каталога
+var colorFamilyLabels = map[ColorFamily]string{
+	ColorWhite:  "Белый",
+	ColorBeige:  "Бежевый",
+	ColorBrown:  "Коричневый",
+	ColorGray:   "Серый",
+	ColorBlack:  "Черный",
+	ColorRed:    "Красный",
+	ColorOrange: "Оранжевый",
+	ColorYellow: "Желтый",
+	ColorGreen:  "Зеленый",
+	ColorBlue:   "Синий",
+	ColorPurple: "Фиолетовый",
+	ColorPink:   "Розовый",
+}
+
+// Label возвращает название группы цвета; для неизвестной группы - ее значение
+func (c ColorFamily) Label() string {
+	if label, ok := colorFamilyLabels[c]; ok {
+		return label
+	}
+	return string(c)
+}
+
+// ColorFamilyOf относит цвет к группе по тону, насыщенности и светлоте (HSL).
+// Границы подобраны для текстиля: приглушенные теплые оттенки считаются бежевыми
+// и коричневыми, а не оранжевыми.
+func ColorFamilyOf(c color.Color) ColorFamily {
+	h, s, l := hsl(c)
+	switch {
+	case l < 0.12:
+		return ColorBlack
+	case s < 0.12:
+		switch {
+		case l > 0.88:
+			return ColorWhite
+		case l < 0.22:
+			return ColorBlack
+		default:
+			return ColorGray
+		}
+	case l > 0.93:
+		return ColorWhite
+	case h >= 15 && h < 55 && l >= 0.55 && s < 0.65:
+		return ColorBeige
+	case h >= 5 && h < 45 && l < 0.45:
+		return ColorBrown
+	case h < 15 || h >= 345:
+		if l > 0.7 {
+			return ColorPink
+		}
+		return ColorRed
+	case h < 40:
+		return ColorOrange
+	case h < 62:
+		return ColorYellow
+	case h < 170:
+		return ColorGreen
+	case h < 255:
+		return ColorBlue
+	case h < 300:
+		return ColorPurple
+	default:
+		return ColorPink
+	}
+}
+
+// HexColor форматирует цвет как "#rrggbb"
+func HexColor(c color.Color) string {
+	r, g, b, _ := c.RGBA()
+	return fmt.Sprintf("#%02x%02x%02x", r>>8, g>>8, b>>8)
+}
+
+// hsl переводит цвет в тон (0-360), насыщенность и светлоту (0-1)
+func hsl(c color.Color) (h, s, l float64) {
+	r16, g16, b16, _ := c.RGBA()
+	r, g, b := float64(r16)/0xFFFF, float64(g16)/0xFFFF, float64(b16)/0xFFFF
+	maxC, minC := math.Max(r, math.Max(g, b)), math.Min(r, math.Min(g, b))
+	l = (maxC + minC) / 2
+	delta := maxC - minC
+	if delta == 0 {
+		return 0, 0, l
+	}
+	s = delta / (1 - math.Abs(2*l-1))
+	switch maxC {
+	case r:
+		h = math.Mod((g-b)/delta, 6)
+	case g:
+		h = (b-r)/delta + 2
+	default:
+		h = (r-g)/delta + 4
+	}
+	h *= 60
+	if h < 0 {
+		h += 360
+	}
+	return h, s, l
+}
+
+// VariantUnit - единица продажи варианта товара
+type VariantUnit string
+
+const (
+	UnitPiece   VariantUnit = "piece"    // Штука (ковер заданного размера)
+	UnitSqm     VariantUnit = "sqm"      // Квадратный метр (ковролин, плитка)
+	UnitLinearM VariantUnit = "linear_m" // Погонный метр рулона
+)
+
+// Valid проверяет, является ли единица продажи допустимой
+func (u VariantUnit) Valid() bool {
+	switch u {
+	case UnitPiece, UnitSqm, UnitLinearM:
+		return true
+	default:
+		return false
+	}
+}
+
+// Category представляет категорию каталога
+type Category struct {
+	ID          uuid.UUID  `json:"id" db:"id"`                             // Уникальный идентификатор категории
+	ParentID    *uuid.UUID `json:"parent_id,omitempty" db:"parent_id"`     // Родительская категория
+	Slug        string     `json:"slug" db:"slug"`                         // Часть URL (латиница, цифры, дефис)
+	Name        string     `json:"name" db:"name"`                         // Название
+	Description *string    `json:"description,omitempty" db:"description"` // Описание для страницы категории
+	Position    int        `json:"position" db:"position"`                 // Порядок в меню
+	CreatedAt   time.Time  `json:"created_at" db:"created_at"`             // Дата и время создания
+	UpdatedAt   time.Time  `json:"updated_at" db:"updated_at"`             // Дата и время последнего обновления
+	DeletedAt   *time.Time `json:"-" db:"deleted_at"`                      // Дата и время удаления
+}
+
+// Product представляет товар каталога
+type Product struct {
+	ID            uuid.UUID    `json:"id" db:"id"`                                   // Уникальный идентификатор товара
+	CategoryID    uuid.UUID    `json:"category_id" db:"category_id"`                 // Категория
+	Slug          string       `json:"slug" db:"slug"`                               // Часть URL (латиница, цифры, дефис)
+	Name          string       `json:"name" db:"name"`                               // Название
+	Description   *string      `json:"description,omitempty" db:"description"`       // Описание
+	Brand         *string      `json:"brand,omitempty" db:"brand"`                   // Производитель
+	Material      *string      `json:"material,omitempty" db:"material"`             // Материал ворса
+	ColorFamily   *ColorFamily `json:"color_family,omitempty" db:"color_family"`     // Группа цвета по главному изображению
+	DominantColor *string      `json:"dominant_color,omitempty" db:"dominant_color"` // Преобладающий цвет (#rrggbb)
+	IsActive      bool         `json:"is_active" db:"is_active"`                     // Показывается ли товар в каталоге
+	CreatedAt     time.Time    `json:"created_at" db:"created_at"`                   // Дата и время создания
+	UpdatedAt     time.Time    `json:"updated_at" db:"updated_at"`                   // Дата и время последнего обновления
+	DeletedAt     *time.Time   `json:"-" db:"deleted_at"`                            // Дата и время удаления
+}
+
+// ProductVariant представляет вариант товара (размер или партию) с ценой и остатком
+type ProductVariant struct {
+	ID           uuid.UUID   `json:"id" db:"id"`                         // Уникальный идентификатор варианта
+	ProductID    uuid.UUID   `json:"product_id" db:"product_id"`         // Товар
+	SKU          string      `json:"sku" db:"sku"`                       // Артикул
+	Name         string      `json:"name" db:"name"`                     // Название ("200x300 см")
+	WidthCm      *int        `json:"width_cm,omitempty" db:"width_cm"`   // Ширина в сантиметрах
+	LengthCm     *int        `json:"length_cm,omitempty" db:"length_cm"` // Длина в сантиметрах (нет для рулона)
+	Unit         VariantUnit `json:"unit" db:"unit"`                     // Единица продажи
+	PriceKopecks int64       `json:"price_kopecks" db:"price_kopecks"`   // Цена за единицу в копейках
+	Stock        int         `json:"stock" db:"stock"`                   // Остаток на складе
+	CreatedAt    time.Time   `json:"created_at" db:"created_at"`         // Дата и время создания
+	UpdatedAt    time.Time   `json:"updated_at" db:"updated_at"`         // Дата и время последнего обновления
+	DeletedAt    *time.Time  `json:"-" db:"deleted_at"`                  // Дата и время удаления
+}
+
+// InStock возвращает true, если вариант есть на складе
+func (v *ProductVariant) InStock() bool {
+	return v.Stock > 0
+}
+
+// ProductDetails - данные страницы товара: товар, его категория и варианты в продаже
+type ProductDetails struct {
+	Product  *Product          // Товар
+	Category *Category         // Категория; nil, если категория удалена
+	Variants []*ProductVariant // Варианты по возрастанию цены
+}
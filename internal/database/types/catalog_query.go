@@ -0,0 +1,347 @@
+package types
+
+import (
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// CatalogPageSize - количество товаров на странице каталога
+const CatalogPageSize = 24
+
+// maxCatalogFilterValues ограничивает число значений одного фильтра из адреса страницы
+const maxCatalogFilterValues = 20
+
+// CatalogSort - порядок товаров в каталоге
+type CatalogSort string
+
+const (
+	SortNewest    CatalogSort = "new"        // Сначала новые (по умолчанию)
+	SortPriceAsc  CatalogSort = "price_asc"  // Сначала дешевые
+	SortPriceDesc CatalogSort = "price_desc" // Сначала дорогие
+	SortName      CatalogSort = "name"       // По названию
+)
+
+// Valid проверяет, является ли порядок допустимым
+func (s CatalogSort) Valid() bool {
+	switch s {
+	case SortNewest, SortPriceAsc, SortPriceDesc, SortName:
+		return true
+	default:
+		return false
+	}
+}
+
+// Фасеты каталога - фильтры, для значений которых считается количество товаров
+const (
+	FacetCategory = "category"
+	FacetColor    = "color"
+	FacetBrand    = "brand"
+)
+
+// CatalogQuery содержит фильтры, сортировку и страницу каталога.
+// Совпадает с параметрами адреса страницы: ParseCatalogQuery и Values переводят одно в другое,
+// поэтому состояние фильтра сохраняется в истории браузера и работает без JavaScript.
+type CatalogQuery struct {
+	Search   string        // q: поиск по названию, производителю и описанию
+	Category string        // category: slug категории (включая подкатегории)
+	Colors   []ColorFamily // color: группы цвета (любая из)
+	Brands   []string      // brand: производители (любой из)
+	PriceMin *int64        // price_min: минимальная цена в рублях
+	PriceMax *int64        // price_max: максимальная цена в рублях
+	InStock  bool          // in_stock: только товары в наличии
+	Sort     CatalogSort   // sort: порядок
+	Page     int           // page: номер страницы с 1
+}
+
+// ParseCatalogQuery разбирает параметры адреса страницы каталога.
+// Недопустимые значения отбрасываются: адрес мог быть изменен вручную.
+func ParseCatalogQuery(values url.Values) CatalogQuery {
+	q := CatalogQuery{
+		Search:   strings.TrimSpace(values.Get("q")),
+		Category: strings.TrimSpace(values.Get("category")),
+		InStock:  values.Get("in_stock") == "1" || values.Get("in_stock") == "on" || values.Get("in_stock") == "true",
+		Sort:     CatalogSort(values.Get("sort")),
+		Page:     1,
+	}
+	if len([]rune(q.Search)) > 100 {
+		q.Search = string([]rune(q.Search)[:100])
+	}
+	for _, raw := range values["color"] {
+		color := ColorFamily(raw)
+		if color.Valid() && !slices.Contains(q.Colors, color) && len(q.Colors) < maxCatalogFilterValues {
+			q.Colors = append(q.Colors, color)
+		}
+	}
+	for _, raw := range values["brand"] {
+		brand := strings.TrimSpace(raw)
+		if brand != "" && !slices.Contains(q.Brands, brand) && len(q.Brands) < maxCatalogFilterValues {
+			q.Brands = append(q.Brands, brand)
+		}
+	}
+	q.PriceMin = parsePrice(values.Get("price_min"))
+	q.PriceMax = parsePrice(values.Get("price_max"))
+	if !q.Sort.Valid() {
+		q.Sort = SortNewest
+	}
+	if page, err := strconv.Atoi(values.Get("page")); err == nil && page > 1 {
+		q.Page = page
+	}
+	return q
+}
+
+// parsePrice разбирает неотрицательную цену в рублях; пустое или неверное значение - без ограничения
+func parsePrice(raw string) *int64 {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return nil
+	}
+	price, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil || price < 0 || price > 100_000_000 {
+		return nil
+	}
+	return &price
+}
+
+// Values возвращает параметры адреса страницы. Значения по умолчанию не включаются,
+// чтобы адрес оставался коротким: каталог без фильтров - это "/catalog".
+func (q CatalogQuery) Values() url.Values {
+	values := url.Values{}
+	if q.Search != "" {
+		values.Set("q", q.Search)
+	}
+	if q.Category != "" {
+		values.Set("category", q.Category)
+	}
+	for _, color := range q.Colors {
+		values.Add("color", string(color))
+	}
+	for _, brand := range q.Brands {
+		values.Add("brand", brand)
+	}
+	if q.PriceMin != nil {
+		values.Set("price_min", strconv.FormatInt(*q.PriceMin, 10))
+	}
+	if q.PriceMax != nil {
+		values.Set("price_max", strconv.FormatInt(*q.PriceMax, 10))
+	}
+	if q.InStock {
+		values.Set("in_stock", "1")
+	}
+	if q.Sort != "" && q.Sort != SortNewest {
+		values.Set("sort", string(q.Sort))
+	}
+	if q.Page > 1 {
+		values.Set("page", strconv.Itoa(q.Page))
+	}
+	return values
+}
+
+// URL возвращает адрес страницы каталога с параметрами запроса
+func (q CatalogQuery) URL(path string) string {
+	if encoded := q.Values().Encode(); encoded != "" {
+		return path + "?" + encoded
+	}
+	return path
+}
+
+// WithPage возвращает копию запроса для другой страницы
+func (q CatalogQuery) WithPage(page int) CatalogQuery {
+	q.Page = page
+	return q
+}
+
+// HasColor проверяет, выбрана ли группа цвета
+func (q CatalogQuery) HasColor(color ColorFamily) bool {
+	return slices.Contains(q.Colors, color)
+}
+
+// HasBrand проверяет, выбран ли производитель
+func (q CatalogQuery) HasBrand(brand string) bool {
+	return slices.Contains(q.Brands, brand)
+}
+
+// Offset возвращает смещение первой записи страницы
+func (q CatalogQuery) Offset() int {
+	return (max(q.Page, 1) - 1) * CatalogPageSize
+}
+
+// catalogFrom - источник строк каталога: активные неудаленные товары с минимальной ценой
+// и наличием по вариантам. Цена и наличие нужны и для фильтров, и для карточек.
+const catalogFrom = `
+	FROM products p
+	LEFT JOIN LATERAL (
+		SELECT MIN(v.price_kopecks) AS min_price_kopecks, COALESCE(BOOL_OR(v.stock > 0), FALSE) AS in_stock
+		FROM product_variants v
+		WHERE v.product_id = p.id AND v.deleted_at IS NULL
+	) offer ON TRUE`
+
+// conditions возвращает условия фильтрации. Фильтр фасета exclude не применяется:
+// количество для значений фасета считается так, как будто выбрано только оно,
+// иначе после выбора одного цвета остальные показывали бы 0.
+func (q CatalogQuery) conditions(exclude string, args pgx.NamedArgs) []string {
+	conditions := []string{"p.deleted_at IS NULL", "p.is_active"}
+
+	if q.Search != "" {
+		conditions = append(conditions, "(p.name ILIKE @search OR p.brand ILIKE @search OR p.description ILIKE @search)")
+		args["search"] = "%" + escapeLike(q.Search) + "%"
+	}
+	if q.Category != "" && exclude != FacetCategory {
+		conditions = append(conditions, `p.category_id IN (
+		WITH RECURSIVE tree AS (
+			SELECT id FROM categories WHERE slug = @category AND deleted_at IS NULL
+			UNION ALL
+			SELECT c.id FROM categories c JOIN tree ON c.parent_id = tree.id WHERE c.deleted_at IS NULL
+		)
+		SELECT id FROM tree)`)
+		args["category"] = q.Category
+	}
+	if len(q.Colors) > 0 && exclude != FacetColor {
+		conditions = append(conditions, "p.color_family = ANY(@colors)")
+		colors := make([]string, 0, len(q.Colors))
+		for _, color := range q.Colors {
+			colors = append(colors, string(color))
+		}
+		args["colors"] = colors
+	}
+	if len(q.Brands) > 0 && exclude != FacetBrand {
+		conditions = append(conditions, "p.brand = ANY(@brands)")
+		args["brands"] = q.Brands
+	}
+	if q.PriceMin != nil {
+		conditions = append(conditions, "offer.min_price_kopecks >= @price_min")
+		args["price_min"] = *q.PriceMin * 100
+	}
+	if q.PriceMax != nil {
+		conditions = append(conditions, "offer.min_price_kopecks <= @price_max")
+		args["price_max"] = *q.PriceMax * 100
+	}
+	if q.InStock {
+		conditions = append(conditions, "offer.in_stock")
+	}
+	return conditions
+}
+
+// BuildQuery формирует SQL запрос страницы каталога: карточки товаров с главным изображением
+func (q CatalogQuery) BuildQuery() (query string, args pgx.NamedArgs) {
+	var builder strings.Builder
+	args = make(pgx.NamedArgs)
+
+	builder.WriteString(`SELECT p.id, p.slug, p.name, p.brand, p.color_family, p.dominant_color, p.updated_at,
+		offer.min_price_kopecks, offer.in_stock, cover.alt_text AS cover_alt_text, cover.renditions AS cover_renditions`)
+	builder.WriteString(catalogFrom)
+	builder.WriteString(`
+	LEFT JOIN LATERAL (
+		SELECT pm.alt_text, m.renditions
+		FROM product_media pm
+		JOIN media m ON m.id = pm.media_id
+		WHERE pm.product_id = p.id AND pm.variant_id IS NULL
+		ORDER BY pm.position, pm.created_at
+		LIMIT 1
+	) cover ON TRUE`)
+	builder.WriteString(" WHERE ")
+	builder.WriteString(strings.Join(q.conditions("", args), " AND "))
+
+	// Товары без цены (без вариантов) при сортировке по цене идут в конце
+	switch q.Sort {
+	case SortPriceAsc:
+		builder.WriteString(" ORDER BY offer.min_price_kopecks ASC NULLS LAST, p.id")
+	case SortPriceDesc:
+		builder.WriteString(" ORDER BY offer.min_price_kopecks DESC NULLS LAST, p.id")
+	case SortName:
+		builder.WriteString(" ORDER BY p.name ASC, p.id")
+	default:
+		builder.WriteString(" ORDER BY p.created_at DESC, p.id")
+	}
+
+	builder.WriteString(" LIMIT @limit")
+	args["limit"] = CatalogPageSize
+	if offset := q.Offset(); offset > 0 {
+		builder.WriteString(" OFFSET @offset")
+		args["offset"] = offset
+	}
+	return builder.String(), args
+}
+
+// BuildCountQuery формирует SQL запрос количества товаров, подходящих под фильтры
+func (q CatalogQuery) BuildCountQuery() (query string, args pgx.NamedArgs) {
+	args = make(pgx.NamedArgs)
+	query = "SELECT COUNT(*)" + catalogFrom + " WHERE " + strings.Join(q.conditions("", args), " AND ")
+	return query, args
+}
+
+// BuildFacetQuery формирует SQL запрос количества товаров по значениям фасета.
+// Возвращает строки (value, label, count); для цветов и производителей label совпадает с value.
+func (q CatalogQuery) BuildFacetQuery(facet string) (query string, args pgx.NamedArgs) {
+	args = make(pgx.NamedArgs)
+	where := " WHERE " + strings.Join(q.conditions(facet, args), " AND ")
+	switch facet {
+	case FacetCategory:
+		query = "SELECT c.slug AS value, c.name AS label, COUNT(*) AS count" + catalogFrom +
+			" JOIN categories c ON c.id = p.category_id AND c.deleted_at IS NULL" + where +
+			" GROUP BY c.slug, c.name, c.position ORDER BY c.position, c.name"
+	case FacetColor:
+		query = "SELECT p.color_family AS value, p.color_family AS label, COUNT(*) AS count" + catalogFrom +
+			where + " AND p.color_family IS NOT NULL GROUP BY p.color_family"
+	default:
+		query = "SELECT p.brand AS value, p.brand AS label, COUNT(*) AS count" + catalogFrom +
+			where + " AND p.brand IS NOT NULL GROUP BY p.brand ORDER BY p.brand"
+	}
+	return query, args
+}
+
+// escapeLike экранирует символы шаблона LIKE в поисковой строке
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// ProductCard - товар в списке каталога
+type ProductCard struct {
+	ID              uuid.UUID    `json:"id" db:"id"`                                   // Уникальный идентификатор товара
+	Slug            string       `json:"slug" db:"slug"`                               // Часть URL
+	Name            string       `json:"name" db:"name"`                               // Название
+	Brand           *string      `json:"brand,omitempty" db:"brand"`                   // Производитель
+	ColorFamily     *ColorFamily `json:"color_family,omitempty" db:"color_family"`     // Группа цвета
+	DominantColor   *string      `json:"dominant_color,omitempty" db:"dominant_color"` // Фон карточки до загрузки изображения
+	UpdatedAt       time.Time    `json:"updated_at" db:"updated_at"`                   // Дата и время последнего обновления
+	MinPriceKopecks *int64       `json:"min_price_kopecks,omitempty" db:"min_price_kopecks"`
+	InStock         bool         `json:"in_stock" db:"in_stock"`                       // Есть ли в наличии хотя бы один вариант
+	CoverAltText    *string      `json:"cover_alt_text,omitempty" db:"cover_alt_text"` // Альтернативный текст главного изображения
+	CoverRenditions []Rendition  `json:"-" db:"cover_renditions"`                      // Копии главного изображения
+	Cover           ImageSources `json:"cover,omitempty" db:"-"`                       // Адреса копий главного изображения
+}
+
+// URL возвращает адрес страницы товара
+func (p *ProductCard) URL() string {
+	return "/product/" + p.Slug
+}
+
+// FacetValue - значение фильтра с количеством подходящих товаров
+type FacetValue struct {
+	Value    string `json:"value" db:"value"` // Значение параметра адреса
+	Label    string `json:"label" db:"label"` // Подпись
+	Count    int    `json:"count" db:"count"` // Количество товаров
+	Selected bool   `json:"selected" db:"-"`  // Выбрано ли значение
+}
+
+// CatalogFacets - значения фильтров каталога
+type CatalogFacets struct {
+	Categories []FacetValue `json:"categories"`
+	Colors     []FacetValue `json:"colors"`
+	Brands     []FacetValue `json:"brands"`
+}
+
+// CatalogListing - страница каталога
+type CatalogListing struct {
+	Query    CatalogQuery   `json:"-"`
+	Products []*ProductCard `json:"products"`
+	Total    int            `json:"total"` // Количество товаров по фильтрам
+	Page     int            `json:"page"`  // Текущая страница
+	Pages    int            `json:"pages"` // Количество страниц
+	Facets   CatalogFacets  `json:"facets"`
+}
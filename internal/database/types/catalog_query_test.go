@@ -0,0 +1,170 @@
+package types
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCatalogQuery(t *testing.T) {
+	tests := []struct {
+		name string
+		raw  string
+		want CatalogQuery
+	}{
+		{
+			name: "пустой адрес",
+			raw:  "",
+			want: CatalogQuery{Sort: SortNewest, Page: 1},
+		},
+		{
+			name: "все фильтры",
+			raw:  "q=+шерсть+&category=kovry&color=beige&color=gray&brand=Osta&price_min=1000&price_max=50000&in_stock=1&sort=price_asc&page=3",
+			want: CatalogQuery{
+				Search:   "шерсть",
+				Category: "kovry",
+				Colors:   []ColorFamily{ColorBeige, ColorGray},
+				Brands:   []string{"Osta"},
+				PriceMin: ptr(int64(1000)),
+				PriceMax: ptr(int64(50000)),
+				InStock:  true,
+				Sort:     SortPriceAsc,
+				Page:     3,
+			},
+		},
+		{
+			name: "чекбокс формы без JavaScript",
+			raw:  "in_stock=on",
+			want: CatalogQuery{InStock: true, Sort: SortNewest, Page: 1},
+		},
+		{
+			name: "недопустимые значения отбрасываются",
+			raw:  "color=rainbow&color=beige&color=beige&brand=+&price_min=-5&price_max=abc&sort=random&page=0",
+			want: CatalogQuery{Colors: []ColorFamily{ColorBeige}, Sort: SortNewest, Page: 1},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values, err := url.ParseQuery(tt.raw)
+			assert.NoError(t, err)
+			assert.Equal(t, tt.want, ParseCatalogQuery(values))
+		})
+	}
+}
+
+func TestCatalogQuery_URL(t *testing.T) {
+	assert.Equal(t, "/catalog", CatalogQuery{Sort: SortNewest, Page: 1}.URL("/catalog"))
+
+	q := CatalogQuery{
+		Search:   "ковер",
+		Colors:   []ColorFamily{ColorBeige, ColorGray},
+		PriceMax: ptr(int64(20000)),
+		InStock:  true,
+		Sort:     SortName,
+		Page:     2,
+	}
+	link := q.URL("/catalog")
+	assert.Equal(t, "/catalog?color=beige&color=gray&in_stock=1&page=2&price_max=20000&q=%D0%BA%D0%BE%D0%B2%D0%B5%D1%80&sort=name", link)
+
+	// Адрес разбирается обратно в тот же запрос - на этом держится история браузера
+	parsed, err := url.Parse(link)
+	assert.NoError(t, err)
+	assert.Equal(t, q, ParseCatalogQuery(parsed.Query()))
+	assert.Equal(t, 1, ParseCatalogQuery(q.WithPage(1).Values()).Page)
+}
+
+func TestCatalogQuery_BuildQuery(t *testing.T) {
+	tests := []struct {
+		name         string
+		query        CatalogQuery
+		contains     []string
+		expectedArgs pgx.NamedArgs
+	}{
+		{
+			name:  "без фильтров",
+			query: CatalogQuery{Page: 1},
+			contains: []string{
+				"WHERE p.deleted_at IS NULL AND p.is_active ORDER BY p.created_at DESC, p.id LIMIT @limit",
+			},
+			expectedArgs: pgx.NamedArgs{"limit": CatalogPageSize},
+		},
+		{
+			name: "поиск экранирует шаблон LIKE",
+			query: CatalogQuery{
+				Search: "100%_шерсть",
+				Sort:   SortPriceAsc,
+				Page:   2,
+			},
+			contains: []string{
+				"(p.name ILIKE @search OR p.brand ILIKE @search OR p.description ILIKE @search)",
+				"ORDER BY offer.min_price_kopecks ASC NULLS LAST, p.id LIMIT @limit OFFSET @offset",
+			},
+			expectedArgs: pgx.NamedArgs{
+				"search": `%100\%\_шерсть%`,
+				"limit":  CatalogPageSize,
+				"offset": CatalogPageSize,
+			},
+		},
+		{
+			name: "фильтры по цене в копейках и наличию",
+			query: CatalogQuery{
+				Colors:   []ColorFamily{ColorGray},
+				Brands:   []string{"Osta", "Merinos"},
+				PriceMin: ptr(int64(1000)),
+				PriceMax: ptr(int64(5000)),
+				InStock:  true,
+			},
+			contains: []string{
+				"p.color_family = ANY(@colors) AND p.brand = ANY(@brands) AND offer.min_price_kopecks >= @price_min AND offer.min_price_kopecks <= @price_max AND offer.in_stock",
+			},
+			expectedArgs: pgx.NamedArgs{
+				"colors":    []string{"gray"},
+				"brands":    []string{"Osta", "Merinos"},
+				"price_min": int64(100000),
+				"price_max": int64(500000),
+				"limit":     CatalogPageSize,
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			query, args := tt.query.BuildQuery()
+			for _, part := range tt.contains {
+				assert.Contains(t, query, part)
+			}
+			assert.Equal(t, tt.expectedArgs, args)
+		})
+	}
+}
+
+func TestCatalogQuery_BuildFacetQuery(t *testing.T) {
+	q := CatalogQuery{
+		Category: "kovry",
+		Colors:   []ColorFamily{ColorBeige},
+		Brands:   []string{"Osta"},
+	}
+
+	// Фасет не фильтруется по собственному значению
+	query, args := q.BuildFacetQuery(FacetColor)
+	assert.NotContains(t, query, "@colors")
+	assert.Contains(t, query, "GROUP BY p.color_family")
+	assert.Equal(t, pgx.NamedArgs{"category": "kovry", "brands": []string{"Osta"}}, args)
+
+	query, args = q.BuildFacetQuery(FacetCategory)
+	assert.NotContains(t, query, "@category")
+	assert.Contains(t, query, "JOIN categories c ON c.id = p.category_id")
+	assert.Equal(t, pgx.NamedArgs{"colors": []string{"beige"}, "brands": []string{"Osta"}}, args)
+
+	query, args = q.BuildFacetQuery(FacetBrand)
+	assert.NotContains(t, query, "@brands")
+	assert.Contains(t, query, "GROUP BY p.brand ORDER BY p.brand")
+	assert.Equal(t, pgx.NamedArgs{"category": "kovry", "colors": []string{"beige"}}, args)
+
+	count, _ := q.BuildCountQuery()
+	assert.Contains(t, count, "SELECT COUNT(*)")
+	assert.Contains(t, count, "@category")
+	assert.Contains(t, count, "@colors")
+	assert.Contains(t, count, "@brands")
+}
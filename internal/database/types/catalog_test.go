@@ -0,0 +1,56 @@
+package types
+
+import (
+	"image/color"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestColorFamilyOf(t *testing.T) {
+	tests := []struct {
+		name  string
+		color color.RGBA
+		want  ColorFamily
+	}{
+		{"белый", color.RGBA{250, 250, 248, 255}, ColorWhite},
+		{"молочный", color.RGBA{245, 240, 230, 255}, ColorWhite},
+		{"черный", color.RGBA{15, 15, 18, 255}, ColorBlack},
+		{"антрацит", color.RGBA{45, 46, 48, 255}, ColorBlack},
+		{"серый", color.RGBA{128, 128, 130, 255}, ColorGray},
+		{"бежевый", color.RGBA{200, 180, 150, 255}, ColorBeige},
+		{"песочный", color.RGBA{214, 190, 140, 255}, ColorBeige},
+		{"коричневый", color.RGBA{100, 60, 30, 255}, ColorBrown},
+		{"красный", color.RGBA{200, 20, 30, 255}, ColorRed},
+		{"бордовый", color.RGBA{110, 10, 30, 255}, ColorRed},
+		{"терракотовый", color.RGBA{210, 100, 40, 255}, ColorOrange},
+		{"горчичный", color.RGBA{200, 170, 30, 255}, ColorYellow},
+		{"оливковый", color.RGBA{110, 120, 50, 255}, ColorGreen},
+		{"синий", color.RGBA{30, 60, 160, 255}, ColorBlue},
+		{"голубой", color.RGBA{120, 180, 230, 255}, ColorBlue},
+		{"фиолетовый", color.RGBA{110, 50, 160, 255}, ColorPurple},
+		{"розовый", color.RGBA{240, 150, 190, 255}, ColorPink},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := ColorFamilyOf(tt.color)
+			assert.Equal(t, tt.want, got)
+			assert.True(t, got.Valid())
+		})
+	}
+}
+
+func TestHexColor(t *testing.T) {
+	assert.Equal(t, "#c8b496", HexColor(color.RGBA{200, 180, 150, 255}))
+}
+
+func TestGalleryImage_Srcset(t *testing.T) {
+	img := GalleryImage{Sources: []ImageSource{
+		{URL: "/media/a/320.jpg", Width: 320},
+		{URL: "/media/a/640.jpg", Width: 640},
+	}}
+
+	assert.Equal(t, "/media/a/320.jpg 320w, /media/a/640.jpg 640w", img.Srcset())
+	assert.Equal(t, "/media/a/640.jpg", img.Src())
+	assert.Empty(t, (&GalleryImage{}).Src())
+}
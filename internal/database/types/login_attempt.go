@@ -0,0 +1,13 @@
+package types
+
+import "time"
+
+// LoginAttempt представляет счетчик попыток входа для одного ключа.
+// Ключом может быть учетная запись (account:<email>) или IP-адрес (ip:<адрес>).
+// Попытка засчитывается до проверки учетных данных, успешный вход сбрасывает или отменяет ее.
+type LoginAttempt struct {
+	Key           string    `json:"key" db:"key"`                         // Ключ счетчика
+	Failures      int       `json:"failures" db:"failures"`               // Количество попыток подряд без успешного входа
+	LastFailureAt time.Time `json:"last_failure_at" db:"last_failure_at"` // Время последней засчитанной попытки
+	BlockedUntil  time.Time `json:"blocked_until" db:"blocked_until"`     // До какого времени следующая попытка не засчитывается
+}
@@ -0,0 +1,32 @@
+package types
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MagicLink представляет одноразовую ссылку для входа, отправленную на email.
+// Токен из ссылки и значение cookie браузера не хранятся - только их SHA-256 хеши.
+type MagicLink struct {
+	ID         uuid.UUID  `json:"id" db:"id"`                             // Уникальный идентификатор ссылки
+	Email      string     `json:"email" db:"email"`                       // Email получателя
+	TokenHash  string     `json:"-" db:"token_hash"`                      // SHA-256 хеш токена (не возвращается в JSON)
+	NonceHash  string     `json:"-" db:"nonce_hash"`                      // SHA-256 хеш cookie браузера (не возвращается в JSON)
+	ExpiresAt  time.Time  `json:"expires_at" db:"expires_at"`             // Срок действия
+	ConsumedAt *time.Time `json:"consumed_at,omitempty" db:"consumed_at"` // Время использования
+	CreatedAt  time.Time  `json:"created_at" db:"created_at"`             // Дата и время создания
+}
+
+// Usable возвращает true, если ссылка не использована и не истекла на момент now
+func (l *MagicLink) Usable(now time.Time) bool {
+	return l.ConsumedAt == nil && now.Before(l.ExpiresAt)
+}
+
+// CreateMagicLinkParams содержит параметры для сохранения новой ссылки
+type CreateMagicLinkParams struct {
+	Email     string    // Email получателя в нижнем регистре
+	TokenHash string    // SHA-256 хеш токена (hex)
+	NonceHash string    // SHA-256 хеш cookie браузера (hex)
+	ExpiresAt time.Time // Срок действия
+}
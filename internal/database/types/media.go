@@ -0,0 +1,107 @@
+package types
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Rendition - уменьшенная копия изображения для srcset
+type Rendition struct {
+	Width  int    `json:"width"`  // Ширина в пикселях
+	Height int    `json:"height"` // Высота в пикселях
+	Format string `json:"format"` // Расширение файла ("jpg", "webp")
+	Key    string `json:"key"`    // Ключ в хранилище файлов
+}
+
+// Media представляет загруженное изображение.
+// Одинаковые файлы хранятся один раз: запись ищется по SHA-256 исходного содержимого.
+type Media struct {
+	ID            uuid.UUID   `json:"id" db:"id"`                         // Уникальный идентификатор изображения
+	ContentHash   string      `json:"-" db:"content_hash"`                // SHA-256 исходного файла (hex)
+	Width         int         `json:"width" db:"width"`                   // Ширина исходного изображения
+	Height        int         `json:"height" db:"height"`                 // Высота исходного изображения
+	DominantColor string      `json:"dominant_color" db:"dominant_color"` // Преобладающий цвет (#rrggbb)
+	ColorFamily   ColorFamily `json:"color_family" db:"color_family"`     // Группа цвета для фильтра
+	Renditions    []Rendition `json:"-" db:"renditions"`                  // Уменьшенные копии по возрастанию ширины
+	CreatedAt     time.Time   `json:"created_at" db:"created_at"`         // Дата и время загрузки
+}
+
+// CreateMediaParams содержит параметры для сохранения нового изображения
+type CreateMediaParams struct {
+	ContentHash   string      // SHA-256 исходного файла (hex)
+	Width         int         // Ширина исходного изображения
+	Height        int         // Высота исходного изображения
+	DominantColor string      // Преобладающий цвет (#rrggbb)
+	ColorFamily   ColorFamily // Группа цвета
+	Renditions    []Rendition // Уменьшенные копии
+}
+
+// ProductMedia - изображение в галерее товара или варианта
+type ProductMedia struct {
+	ID        uuid.UUID  `json:"id" db:"id"`                           // Уникальный идентификатор элемента галереи
+	ProductID uuid.UUID  `json:"product_id" db:"product_id"`           // Товар
+	VariantID *uuid.UUID `json:"variant_id,omitempty" db:"variant_id"` // Вариант (если NULL - весь товар)
+	MediaID   uuid.UUID  `json:"media_id" db:"media_id"`               // Изображение
+	Position  int        `json:"position" db:"position"`               // Порядок в галерее (0 - главное)
+	AltText   string     `json:"alt_text" db:"alt_text"`               // Альтернативный текст
+	CreatedAt time.Time  `json:"created_at" db:"created_at"`           // Дата и время добавления
+}
+
+// AttachMediaParams содержит параметры для добавления изображения в галерею
+type AttachMediaParams struct {
+	ProductID uuid.UUID  // Товар (обязательно)
+	VariantID *uuid.UUID // Вариант товара (опционально)
+	MediaID   uuid.UUID  // Изображение (обязательно)
+	AltText   string     // Альтернативный текст
+}
+
+// GalleryImage - изображение галереи с адресами уменьшенных копий для шаблонов и API
+type GalleryImage struct {
+	ProductMedia
+	Width         int          `json:"width" db:"width"`                   // Ширина исходного изображения
+	Height        int          `json:"height" db:"height"`                 // Высота исходного изображения
+	DominantColor string       `json:"dominant_color" db:"dominant_color"` // Преобладающий цвет - фон до загрузки
+	Renditions    []Rendition  `json:"-" db:"renditions"`                  // Уменьшенные копии в хранилище
+	Sources       ImageSources `json:"sources" db:"-"`                     // Адреса копий по возрастанию ширины
+}
+
+// ImageSource - адрес уменьшенной копии
+type ImageSource struct {
+	URL    string `json:"url"`    // Публичный адрес
+	Width  int    `json:"width"`  // Ширина в пикселях
+	Height int    `json:"height"` // Высота в пикселях
+	Format string `json:"format"` // Расширение файла
+}
+
+// ImageSources - адреса уменьшенных копий одного изображения по возрастанию ширины
+type ImageSources []ImageSource
+
+// Srcset возвращает значение атрибута srcset: "url 320w, url 640w"
+func (s ImageSources) Srcset() string {
+	parts := make([]string, 0, len(s))
+	for _, src := range s {
+		parts = append(parts, src.URL+" "+strconv.Itoa(src.Width)+"w")
+	}
+	return strings.Join(parts, ", ")
+}
+
+// Src возвращает адрес самой большой копии - для атрибута src и Open Graph
+func (s ImageSources) Src() string {
+	if len(s) == 0 {
+		return ""
+	}
+	return s[len(s)-1].URL
+}
+
+// Srcset возвращает значение атрибута srcset галереи
+func (g *GalleryImage) Srcset() string {
+	return g.Sources.Srcset()
+}
+
+// Src возвращает адрес самой большой копии изображения галереи
+func (g *GalleryImage) Src() string {
+	return g.Sources.Src()
+}
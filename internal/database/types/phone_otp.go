@@ -0,0 +1,32 @@
+package types
+
+import "time"
+
+// PhoneOTP представляет одноразовый код входа, отправленный на телефон.
+// Сам код не хранится - только его HMAC.
+type PhoneOTP struct {
+	Phone           string    `json:"phone" db:"phone"`                         // Телефон в формате E.164
+	CodeHash        string    `json:"-" db:"code_hash"`                         // HMAC-SHA256 кода (не возвращается в JSON)
+	Attempts        int       `json:"attempts" db:"attempts"`                   // Количество попыток ввода кода
+	ExpiresAt       time.Time `json:"expires_at" db:"expires_at"`               // Срок действия кода
+	SentAt          time.Time `json:"sent_at" db:"sent_at"`                     // Время отправки последнего SMS
+	SendCount       int       `json:"send_count" db:"send_count"`               // Количество SMS с начала окна
+	WindowStartedAt time.Time `json:"window_started_at" db:"window_started_at"` // Начало окна ограничения отправок
+}
+
+// Expired возвращает true, если срок действия кода истек на момент now
+func (o *PhoneOTP) Expired(now time.Time) bool {
+	return !now.Before(o.ExpiresAt)
+}
+
+// SavePhoneOTPParams содержит параметры для сохранения нового кода.
+// Сохранение заменяет предыдущий код и обнуляет счетчик попыток.
+type SavePhoneOTPParams struct {
+	Phone           string     // Телефон в формате E.164
+	CodeHash        string     // HMAC-SHA256 кода (hex)
+	ExpiresAt       time.Time  // Срок действия кода
+	SentAt          time.Time  // Время отправки
+	SendCount       int        // Количество SMS с начала окна, включая это
+	WindowStartedAt time.Time  // Начало окна ограничения отправок
+	PreviousSentAt  *time.Time // Время отправки прочитанного кода (nil - кода не было)
+}
@@ -0,0 +1,34 @@
+package types
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Session представляет сессию браузера.
+// Токен хранится в cookie, в базе данных - только его SHA-256 хеш.
+type Session struct {
+	ID         uuid.UUID `json:"id" db:"id"`                           // Уникальный идентификатор сессии
+	UserID     uuid.UUID `json:"user_id" db:"user_id"`                 // Владелец сессии
+	TokenHash  string    `json:"-" db:"token_hash"`                    // SHA-256 хеш токена (не возвращается в JSON)
+	UserAgent  *string   `json:"user_agent,omitempty" db:"user_agent"` // User-Agent браузера при входе
+	IPAddress  *string   `json:"ip_address,omitempty" db:"ip_address"` // IP-адрес клиента при входе
+	ExpiresAt  time.Time `json:"expires_at" db:"expires_at"`           // Срок действия
+	LastSeenAt time.Time `json:"last_seen_at" db:"last_seen_at"`       // Время последнего запроса
+	CreatedAt  time.Time `json:"created_at" db:"created_at"`           // Дата и время создания
+}
+
+// Active возвращает true, если сессия не истекла на момент now
+func (s *Session) Active(now time.Time) bool {
+	return now.Before(s.ExpiresAt)
+}
+
+// CreateSessionParams содержит параметры для сохранения новой сессии
+type CreateSessionParams struct {
+	UserID    uuid.UUID // Владелец сессии (обязательно)
+	TokenHash string    // SHA-256 хеш токена (hex)
+	UserAgent *string   // User-Agent браузера (опционально)
+	IPAddress *string   // IP-адрес клиента (опционально)
+	ExpiresAt time.Time // Срок действия
+}
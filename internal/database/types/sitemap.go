@@ -0,0 +1,16 @@
+package types
+
+import "time"
+
+// SitemapCategory - категория для карты сайта
+type SitemapCategory struct {
+	Slug      string    `db:"slug"`       // Часть URL
+	UpdatedAt time.Time `db:"updated_at"` // Дата и время последнего обновления
+}
+
+// SitemapProduct - товар для карты сайта
+type SitemapProduct struct {
+	Slug      string    `db:"slug"`       // Часть URL
+	UpdatedAt time.Time `db:"updated_at"` // Дата и время последнего обновления
+	ImageKeys []string  `db:"image_keys"` // Ключи самых больших копий изображений галереи по порядку
+}
@@ -0,0 +1,23 @@
+package types
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// UserTOTP представляет подключенный (или подключаемый) TOTP-аутентификатор пользователя.
+// Секрет хранится только в зашифрованном виде.
+type UserTOTP struct {
+	UserID          uuid.UUID  `json:"user_id" db:"user_id"`                     // ID пользователя
+	SecretEncrypted []byte     `json:"-" db:"secret_encrypted"`                  // Зашифрованный секрет (не возвращается в JSON)
+	ConfirmedAt     *time.Time `json:"confirmed_at,omitempty" db:"confirmed_at"` // Время подтверждения (nil = подключение не завершено)
+	LastUsedStep    int64      `json:"-" db:"last_used_step"`                    // Последний принятый шаг времени
+	CreatedAt       time.Time  `json:"created_at" db:"created_at"`               // Дата и время создания записи
+	UpdatedAt       time.Time  `json:"updated_at" db:"updated_at"`               // Дата и время последнего обновления
+}
+
+// Confirmed возвращает true, если подключение аутентификатора подтверждено кодом
+func (t *UserTOTP) Confirmed() bool {
+	return t.ConfirmedAt != nil
+}
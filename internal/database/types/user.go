@@ -12,28 +12,31 @@ import (
 )
 
 // User представляет полную модель пользователя в системе.
-// Содержит все поля, включая конфиденциальные данные (хэш пароля, токен верификации),
+// Содержит все поля, включая конфиденциальные данные (хэш пароля),
 // которые не должны быть доступны в публичном API.
 type User struct {
-	ID                uuid.UUID  `json:"id" db:"id"`                           // Уникальный идентификатор пользователя
-	Email             string     `json:"email" db:"email"`                     // Электронная почта пользователя
-	EmailVerified     bool       `json:"email_verified" db:"email_verified"`   // Статус подтверждения email
-	VerificationToken *string    `json:"-" db:"verification_token"`            // Токен для подтверждения email (не возвращается в JSON)
-	Username          string     `json:"username" db:"username"`               // Имя пользователя
-	Role              UserRole   `json:"role" db:"role"`                       // Роль пользователя в системе
-	ImageURL          *string    `json:"image_url,omitempty" db:"image_url"`   // URL аватара пользователя (опционально)
-	PasswordHash      *string    `json:"-" db:"password_hash"`                 // Хэш пароля (не возвращается в JSON)
-	CreatedAt         time.Time  `json:"created_at" db:"created_at"`           // Дата и время создания записи
-	UpdatedAt         time.Time  `json:"updated_at" db:"updated_at"`           // Дата и время последнего обновления
-	DeletedAt         *time.Time `json:"deleted_at,omitempty" db:"deleted_at"` // Дата мягкого удаления (nil = активная запись)
+	ID            uuid.UUID  `json:"id" db:"id"`                           // Уникальный идентификатор пользователя
+	Email         *string    `json:"email,omitempty" db:"email"`           // Электронная почта (nil, если вход только по телефону)
+	EmailVerified bool       `json:"email_verified" db:"email_verified"`   // Статус подтверждения email
+	Phone         *string    `json:"phone,omitempty" db:"phone"`           // Телефон в формате E.164 (опционально)
+	PhoneVerified bool       `json:"phone_verified" db:"phone_verified"`   // Телефон подтвержден кодом из SMS
+	Username      string     `json:"username" db:"username"`               // Имя пользователя
+	Role          UserRole   `json:"role" db:"role"`                       // Роль пользователя в системе
+	ImageURL      *string    `json:"image_url,omitempty" db:"image_url"`   // URL аватара пользователя (опционально)
+	PasswordHash  *string    `json:"-" db:"password_hash"`                 // Хэш пароля (не возвращается в JSON)
+	CreatedAt     time.Time  `json:"created_at" db:"created_at"`           // Дата и время создания записи
+	UpdatedAt     time.Time  `json:"updated_at" db:"updated_at"`           // Дата и время последнего обновления
+	DeletedAt     *time.Time `json:"deleted_at,omitempty" db:"deleted_at"` // Дата мягкого удаления (nil = активная запись)
 }
 
 // PublicUser представляет публичную версию пользователя для API.
 // Не содержит конфиденциальные данные, такие как хэш пароля или токены.
 type PublicUser struct {
 	ID            uuid.UUID `json:"id"`                  // Уникальный идентификатор пользователя
-	Email         string    `json:"email"`               // Электронная почта пользователя
+	Email         string    `json:"email,omitempty"`     // Электронная почта пользователя
 	EmailVerified bool      `json:"email_verified"`      // Статус подтверждения email
+	Phone         string    `json:"phone,omitempty"`     // Телефон в формате E.164
+	PhoneVerified bool      `json:"phone_verified"`      // Телефон подтвержден кодом из SMS
 	Username      string    `json:"username"`            // Имя пользователя
 	Role          UserRole  `json:"role"`                // Роль пользователя в системе
 	ImageURL      string    `json:"image_url,omitempty"` // URL аватара пользователя
@@ -45,8 +48,8 @@ type PublicUser struct {
 func (u *User) ToPublic() PublicUser {
 	pu := PublicUser{
 		ID:            u.ID,
-		Email:         u.Email,
 		EmailVerified: u.EmailVerified,
+		PhoneVerified: u.PhoneVerified,
 		Username:      u.Username,
 		Role:          u.Role,
 		CreatedAt:     u.CreatedAt,
@@ -54,30 +57,46 @@ func (u *User) ToPublic() PublicUser {
 	if u.ImageURL != nil {
 		pu.ImageURL = *u.ImageURL
 	}
+	if u.Email != nil {
+		pu.Email = *u.Email
+	}
+	if u.Phone != nil {
+		pu.Phone = *u.Phone
+	}
 	return pu
 }
 
+// Login возвращает идентификатор входа пользователя: email, а если его нет - телефон.
+// Используется как ключ счетчика неудачных попыток входа.
+func (u *User) Login() string {
+	if u.Email != nil {
+		return *u.Email
+	}
+	if u.Phone != nil {
+		return *u.Phone
+	}
+	return u.ID.String()
+}
+
 // CreateUserParams содержит параметры для создания нового пользователя.
 // Используется при регистрации или создании пользователя администратором.
 type CreateUserParams struct {
-	Email             string   // Электронная почта (обязательно)
-	Username          string   // Имя пользователя (обязательно)
-	PasswordHash      *string  // Хэш пароля (nil для OAuth пользователей)
-	Role              UserRole // Роль пользователя (по умолчанию UserRoleUser)
-	ImageURL          *string  // URL аватара (опционально)
-	VerificationToken *string  // Токен для подтверждения email (опционально)
+	Email        string   // Электронная почта (обязательно)
+	Username     string   // Имя пользователя (обязательно)
+	PasswordHash *string  // Хэш пароля (nil для OAuth пользователей)
+	Role         UserRole // Роль пользователя (по умолчанию UserRoleUser)
+	ImageURL     *string  // URL аватара (опционально)
 }
 
 // UpdateUserParams содержит параметры для обновления существующего пользователя.
 // Все поля опциональны - обновляются только переданные значения.
 type UpdateUserParams struct {
-	ID                uuid.UUID // ID пользователя для обновления (обязательно)
-	Username          *string   // Новое имя пользователя
-	Role              *UserRole // Новая роль
-	ImageURL          *string   // Новый URL аватара
-	EmailVerified     *bool     // Новый статус подтверждения email
-	VerificationToken *string   // Новый токен верификации
-	PasswordHash      *string   // Новый хэш пароля
+	ID            uuid.UUID // ID пользователя для обновления (обязательно)
+	Username      *string   // Новое имя пользователя
+	Role          *UserRole // Новая роль
+	ImageURL      *string   // Новый URL аватара
+	EmailVerified *bool     // Новый статус подтверждения email
+	PasswordHash  *string   // Новый хэш пароля
 }
 
 // ListUsersParams содержит параметры фильтрации, пагинации и сортировки
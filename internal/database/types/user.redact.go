@@ -0,0 +1,92 @@
+package types
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/LigeronAhill/luxcarpets-go/pkg/redact"
+)
+
+// Типы пользователя выводятся в ошибки (fmt) и логи (slog) без хэша пароля:
+// вместо значения подставляется redact.Mask, а для nil - nil.
+
+// Format реализует fmt.Formatter
+func (u User) Format(f fmt.State, verb rune) {
+	redact.Format(f, verb, "types.User",
+		redact.Field{Name: "ID", Value: u.ID},
+		redact.Field{Name: "Email", Value: redact.Value(u.Email)},
+		redact.Field{Name: "EmailVerified", Value: u.EmailVerified},
+		redact.Field{Name: "Phone", Value: redact.Value(u.Phone)},
+		redact.Field{Name: "PhoneVerified", Value: u.PhoneVerified},
+		redact.Field{Name: "Username", Value: u.Username},
+		redact.Field{Name: "Role", Value: u.Role},
+		redact.Field{Name: "ImageURL", Value: redact.Value(u.ImageURL)},
+		redact.Field{Name: "PasswordHash", Value: redact.Secret(u.PasswordHash)},
+		redact.Field{Name: "CreatedAt", Value: u.CreatedAt},
+		redact.Field{Name: "UpdatedAt", Value: u.UpdatedAt},
+		redact.Field{Name: "DeletedAt", Value: redact.Value(u.DeletedAt)},
+	)
+}
+
+// LogValue реализует slog.LogValuer: в лог попадают идентификатор, роль и признак пароля
+func (u User) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("id", u.ID.String()),
+		slog.String("role", u.Role.String()),
+		slog.Bool("has_password", u.PasswordHash != nil),
+	)
+}
+
+// Format реализует fmt.Formatter
+func (p CreateUserParams) Format(f fmt.State, verb rune) {
+	redact.Format(f, verb, "types.CreateUserParams",
+		redact.Field{Name: "Email", Value: p.Email},
+		redact.Field{Name: "Username", Value: p.Username},
+		redact.Field{Name: "PasswordHash", Value: redact.Secret(p.PasswordHash)},
+		redact.Field{Name: "Role", Value: p.Role},
+		redact.Field{Name: "ImageURL", Value: redact.Value(p.ImageURL)},
+	)
+}
+
+// LogValue реализует slog.LogValuer
+func (p CreateUserParams) LogValue() slog.Value {
+	return slog.GroupValue(
+		slog.String("email", p.Email),
+		slog.String("username", p.Username),
+		slog.String("role", p.Role.String()),
+		slog.Bool("has_password", p.PasswordHash != nil),
+	)
+}
+
+// Format реализует fmt.Formatter
+func (p UpdateUserParams) Format(f fmt.State, verb rune) {
+	redact.Format(f, verb, "types.UpdateUserParams",
+		redact.Field{Name: "ID", Value: p.ID},
+		redact.Field{Name: "Username", Value: redact.Value(p.Username)},
+		redact.Field{Name: "Role", Value: redact.Value(p.Role)},
+		redact.Field{Name: "ImageURL", Value: redact.Value(p.ImageURL)},
+		redact.Field{Name: "EmailVerified", Value: redact.Value(p.EmailVerified)},
+		redact.Field{Name: "PasswordHash", Value: redact.Secret(p.PasswordHash)},
+	)
+}
+
+// LogValue реализует slog.LogValuer: перечисляются только изменяемые поля
+func (p UpdateUserParams) LogValue() slog.Value {
+	attrs := []slog.Attr{slog.String("id", p.ID.String())}
+	if p.Username != nil {
+		attrs = append(attrs, slog.String("username", *p.Username))
+	}
+	if p.Role != nil {
+		attrs = append(attrs, slog.String("role", p.Role.String()))
+	}
+	if p.ImageURL != nil {
+		attrs = append(attrs, slog.String("image_url", *p.ImageURL))
+	}
+	if p.EmailVerified != nil {
+		attrs = append(attrs, slog.Bool("email_verified", *p.EmailVerified))
+	}
+	if p.PasswordHash != nil {
+		attrs = append(attrs, slog.String("password_hash", redact.Mask))
+	}
+	return slog.GroupValue(attrs...)
+}
@@ -1,6 +1,9 @@
 package types
 
 import (
+	"bytes"
+	"fmt"
+	"log/slog"
 	"testing"
 	"time"
 
@@ -19,7 +22,7 @@ func TestUser_ToPublic(t *testing.T) {
 			name: "полный пользователь с ImageURL",
 			user: &User{
 				ID:            uuid.MustParse("123e4567-e89b-12d3-a456-426614174000"),
-				Email:         "test@example.com",
+				Email:         ptr("test@example.com"),
 				EmailVerified: true,
 				Username:      "testuser",
 				Role:          RoleCustomer,
@@ -41,7 +44,7 @@ func TestUser_ToPublic(t *testing.T) {
 			name: "пользователь без ImageURL",
 			user: &User{
 				ID:            uuid.MustParse("123e4567-e89b-12d3-a456-426614174000"),
-				Email:         "test@example.com",
+				Email:         ptr("test@example.com"),
 				EmailVerified: false,
 				Username:      "testuser",
 				Role:          RoleAdmin,
@@ -59,6 +62,25 @@ func TestUser_ToPublic(t *testing.T) {
 				CreatedAt:     time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC),
 			},
 		},
+		{
+			name: "пользователь только с телефоном",
+			user: &User{
+				ID:            uuid.MustParse("123e4567-e89b-12d3-a456-426614174000"),
+				Phone:         ptr("+79123456789"),
+				PhoneVerified: true,
+				Username:      "Покупатель 6789",
+				Role:          RoleCustomer,
+				CreatedAt:     time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC),
+			},
+			expected: PublicUser{
+				ID:            uuid.MustParse("123e4567-e89b-12d3-a456-426614174000"),
+				Phone:         "+79123456789",
+				PhoneVerified: true,
+				Username:      "Покупатель 6789",
+				Role:          RoleCustomer,
+				CreatedAt:     time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC),
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -69,6 +91,14 @@ func TestUser_ToPublic(t *testing.T) {
 	}
 }
 
+func TestUser_Login(t *testing.T) {
+	id := uuid.MustParse("123e4567-e89b-12d3-a456-426614174000")
+
+	assert.Equal(t, "test@example.com", (&User{ID: id, Email: ptr("test@example.com"), Phone: ptr("+79123456789")}).Login())
+	assert.Equal(t, "+79123456789", (&User{ID: id, Phone: ptr("+79123456789")}).Login())
+	assert.Equal(t, id.String(), (&User{ID: id}).Login())
+}
+
 func TestListUsersParams_BuildQuery(t *testing.T) {
 	tests := []struct {
 		name          string
@@ -258,3 +288,33 @@ func TestListUsersParams_BuildCountQuery(t *testing.T) {
 func ptr[T any](v T) *T {
 	return &v
 }
+
+func TestUserTypes_RedactPasswordHash(t *testing.T) {
+	const hash = "$argon2id$v=19$m=65536,t=3,p=2$c2FsdA$aGFzaA"
+	id := uuid.MustParse("123e4567-e89b-12d3-a456-426614174000")
+	values := map[string]any{
+		"User":             User{ID: id, Email: ptr("test@example.com"), Username: "testuser", Role: RoleCustomer, PasswordHash: ptr(hash)},
+		"CreateUserParams": CreateUserParams{Email: "test@example.com", Username: "testuser", PasswordHash: ptr(hash), Role: RoleCustomer},
+		"UpdateUserParams": UpdateUserParams{ID: id, Username: ptr("testuser"), PasswordHash: ptr(hash)},
+	}
+	for name, value := range values {
+		t.Run(name, func(t *testing.T) {
+			for _, verb := range []string{"%v", "%+v", "%#v", "%s"} {
+				out := fmt.Sprintf(verb, value)
+				assert.NotContains(t, out, hash, verb)
+				assert.Contains(t, out, "[REDACTED]", verb)
+				assert.Contains(t, out, "testuser", verb)
+			}
+
+			var buf bytes.Buffer
+			slog.New(slog.NewJSONHandler(&buf, nil)).Info("user", slog.Any("value", value))
+			assert.NotContains(t, buf.String(), hash)
+		})
+	}
+}
+
+func TestCreateUserParams_Format(t *testing.T) {
+	params := CreateUserParams{Email: "test@example.com", Username: "testuser", Role: RoleCustomer, ImageURL: ptr("/a.jpg")}
+
+	assert.Equal(t, "{Email:test@example.com Username:testuser PasswordHash:<nil> Role:customer ImageURL:/a.jpg}", fmt.Sprintf("%+v", params))
+}
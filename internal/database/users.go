@@ -7,6 +7,7 @@ import (
 	"strings"
 
 	"github.com/LigeronAhill/luxcarpets-go/internal/database/types"
+	"github.com/LigeronAhill/luxcarpets-go/internal/tracing"
 	"github.com/LigeronAhill/luxcarpets-go/pkg/utils"
 	"github.com/google/uuid"
 	"github.com/jackc/pgx/v5"
@@ -34,7 +35,9 @@ func NewUsersStorage(pool PgxPoolIface) *UsersStorage {
 	}
 }
 
-func (u *UsersStorage) Create(ctx context.Context, params types.CreateUserParams) (*types.User, error) {
+func (u *UsersStorage) Create(ctx context.Context, params types.CreateUserParams) (_ *types.User, err error) {
+	ctx, span := tracing.Start(ctx, "UsersStorage.Create")
+	defer endSpan(span, &err)
 	op := fmt.Sprintf("create new user\nparams:%#v", params)
 	query := `
 		INSERT INTO users (
@@ -42,19 +45,17 @@ func (u *UsersStorage) Create(ctx context.Context, params types.CreateUserParams
 		    username,
 		    password_hash,
 		    role,
-		    image_url,
-		    verification_token
+		    image_url
 		)
-		VALUES (@email, @username, @password_hash, @role, @image_url, @verification_token)
+		VALUES (@email, @username, @password_hash, @role, @image_url)
 		RETURNING *
 	`
 	args := pgx.NamedArgs{
-		"email":              strings.ToLower(params.Email),
-		"username":           params.Username,
-		"password_hash":      params.PasswordHash,
-		"role":               params.Role,
-		"image_url":          params.ImageURL,
-		"verification_token": params.VerificationToken,
+		"email":         strings.ToLower(params.Email),
+		"username":      params.Username,
+		"password_hash": params.PasswordHash,
+		"role":          params.Role,
+		"image_url":     params.ImageURL,
 	}
 	rows, err := u.pool.Query(ctx, query, args)
 	if err != nil {
@@ -74,7 +75,36 @@ func (u *UsersStorage) Create(ctx context.Context, params types.CreateUserParams
 	return res, nil
 }
 
-func (u *UsersStorage) GetByID(ctx context.Context, id uuid.UUID) (*types.User, error) {
+// CreateByPhone создает пользователя, вошедшего по подтвержденному телефону
+func (u *UsersStorage) CreateByPhone(ctx context.Context, phone, username string, role types.UserRole) (_ *types.User, err error) {
+	ctx, span := tracing.Start(ctx, "UsersStorage.CreateByPhone")
+	defer endSpan(span, &err)
+	op := "create user by phone " + phone
+	query := `
+		INSERT INTO users (phone, phone_verified, username, role)
+		VALUES (@phone, TRUE, @username, @role)
+		RETURNING *
+	`
+	args := pgx.NamedArgs{
+		"phone":    phone,
+		"username": username,
+		"role":     role,
+	}
+	rows, err := u.pool.Query(ctx, query, args)
+	if err != nil {
+		return nil, utils.Wrap(op, err)
+	}
+	defer rows.Close()
+	res, err := pgx.CollectExactlyOneRow(rows, pgx.RowToAddrOfStructByName[types.User])
+	if err != nil {
+		return nil, utils.Wrap(op, err)
+	}
+	return res, nil
+}
+
+func (u *UsersStorage) GetByID(ctx context.Context, id uuid.UUID) (_ *types.User, err error) {
+	ctx, span := tracing.Start(ctx, "UsersStorage.GetByID")
+	defer endSpan(span, &err)
 	op := "get user by id " + id.String()
 	query := `
 		SELECT * FROM users WHERE id = @id AND deleted_at IS NULL
@@ -94,7 +124,9 @@ func (u *UsersStorage) GetByID(ctx context.Context, id uuid.UUID) (*types.User,
 	return res, nil
 }
 
-func (u *UsersStorage) GetByEmail(ctx context.Context, email string) (*types.User, error) {
+func (u *UsersStorage) GetByEmail(ctx context.Context, email string) (_ *types.User, err error) {
+	ctx, span := tracing.Start(ctx, "UsersStorage.GetByEmail")
+	defer endSpan(span, &err)
 	op := "get user by email " + email
 	query := `
 		SELECT * FROM users WHERE email = @email AND deleted_at IS NULL
@@ -114,7 +146,32 @@ func (u *UsersStorage) GetByEmail(ctx context.Context, email string) (*types.Use
 	return res, nil
 }
 
-func (u *UsersStorage) Update(ctx context.Context, params types.UpdateUserParams) (*types.User, error) {
+// GetByPhone возвращает пользователя с подтвержденным телефоном
+func (u *UsersStorage) GetByPhone(ctx context.Context, phone string) (_ *types.User, err error) {
+	ctx, span := tracing.Start(ctx, "UsersStorage.GetByPhone")
+	defer endSpan(span, &err)
+	op := "get user by phone " + phone
+	query := `
+		SELECT * FROM users WHERE phone = @phone AND phone_verified AND deleted_at IS NULL
+	`
+	args := pgx.NamedArgs{
+		"phone": phone,
+	}
+	rows, err := u.pool.Query(ctx, query, args)
+	if err != nil {
+		return nil, utils.Wrap(op, err)
+	}
+	defer rows.Close()
+	res, err := pgx.CollectExactlyOneRow(rows, pgx.RowToAddrOfStructByName[types.User])
+	if err != nil {
+		return nil, utils.Wrap(op, err)
+	}
+	return res, nil
+}
+
+func (u *UsersStorage) Update(ctx context.Context, params types.UpdateUserParams) (_ *types.User, err error) {
+	ctx, span := tracing.Start(ctx, "UsersStorage.Update")
+	defer endSpan(span, &err)
 	op := fmt.Sprintf("update user\nparams:%#v", params)
 	query := `
 		UPDATE users
@@ -123,19 +180,17 @@ func (u *UsersStorage) Update(ctx context.Context, params types.UpdateUserParams
 		    role = COALESCE(@role, role),
 		    image_url = COALESCE(@image_url, image_url),
 		    email_verified = COALESCE(@email_verified, email_verified),
-		    verification_token = COALESCE(@verification_token, verification_token),
 		    password_hash = COALESCE(@password_hash, password_hash)
 		WHERE id = @id AND deleted_at IS NULL
 		RETURNING *;
 	`
 	args := pgx.NamedArgs{
-		"id":                 params.ID,
-		"username":           params.Username,
-		"email_verified":     params.EmailVerified,
-		"password_hash":      params.PasswordHash,
-		"role":               params.Role,
-		"image_url":          params.ImageURL,
-		"verification_token": params.VerificationToken,
+		"id":             params.ID,
+		"username":       params.Username,
+		"email_verified": params.EmailVerified,
+		"password_hash":  params.PasswordHash,
+		"role":           params.Role,
+		"image_url":      params.ImageURL,
 	}
 	rows, err := u.pool.Query(ctx, query, args)
 	if err != nil {
@@ -149,7 +204,9 @@ func (u *UsersStorage) Update(ctx context.Context, params types.UpdateUserParams
 	return res, nil
 }
 
-func (u *UsersStorage) List(ctx context.Context, params types.ListUsersParams) (*PaginatedResponse[*types.User], error) {
+func (u *UsersStorage) List(ctx context.Context, params types.ListUsersParams) (_ *PaginatedResponse[*types.User], err error) {
+	ctx, span := tracing.Start(ctx, "UsersStorage.List")
+	defer endSpan(span, &err)
 	op := fmt.Sprintf("list users\nparams:%#v", params)
 	countQuery, countArgs := params.BuildCountQuery()
 	var total int
@@ -169,7 +226,9 @@ func (u *UsersStorage) List(ctx context.Context, params types.ListUsersParams) (
 	return NewPaginatedResponse(res, total, params.Limit, params.Offset), nil
 }
 
-func (u *UsersStorage) Delete(ctx context.Context, id uuid.UUID) error {
+func (u *UsersStorage) Delete(ctx context.Context, id uuid.UUID) (err error) {
+	ctx, span := tracing.Start(ctx, "UsersStorage.Delete")
+	defer endSpan(span, &err)
 	op := "delete user by id " + id.String()
 	query := `
 		UPDATE users SET deleted_at = NOW() WHERE id = @id AND deleted_at IS NULL;
@@ -186,3 +245,24 @@ func (u *UsersStorage) Delete(ctx context.Context, id uuid.UUID) error {
 	}
 	return nil
 }
+
+// Restore снимает отметку мягкого удаления с пользователя
+func (u *UsersStorage) Restore(ctx context.Context, id uuid.UUID) (err error) {
+	ctx, span := tracing.Start(ctx, "UsersStorage.Restore")
+	defer endSpan(span, &err)
+	op := "restore user by id " + id.String()
+	query := `
+		UPDATE users SET deleted_at = NULL WHERE id = @id AND deleted_at IS NOT NULL;
+	`
+	args := pgx.NamedArgs{
+		"id": id,
+	}
+	res, err := u.pool.Exec(ctx, query, args)
+	if err != nil {
+		return utils.Wrap(op, err)
+	}
+	if res.RowsAffected() == 0 {
+		return fmt.Errorf("user not found")
+	}
+	return nil
+}
@@ -29,24 +29,22 @@ func TestUsersStorage_Create_Success(t *testing.T) {
 	role := types.RoleCustomer
 	now := time.Now()
 
-	// ВАЖНО: Добавляем verification_token в список колонок
 	mock.ExpectQuery(`INSERT INTO users \(
 		    email,
 		    username,
 		    password_hash,
 		    role,
-		    image_url,
-		    verification_token
+		    image_url
 		\)
-		VALUES \(@email, @username, @password_hash, @role, @image_url, @verification_token\)
+		VALUES \(@email, @username, @password_hash, @role, @image_url\)
 		RETURNING \*`).
-		WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg()).
+		WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg()).
 		WillReturnRows(pgxmock.NewRows([]string{
 			"id", "email", "email_verified", "username", "role", "image_url",
-			"password_hash", "created_at", "updated_at", "deleted_at", "verification_token", // Добавлено поле
+			"password_hash", "created_at", "updated_at", "deleted_at", "phone", "phone_verified",
 		}).AddRow(
-			userID, email, false, username, role, nil,
-			&passwordHash, now, now, nil, nil, // Добавлено nil для verification_token
+			userID, &email, false, username, role, nil,
+			&passwordHash, now, now, nil, nil, false,
 		))
 
 	ctx := context.Background()
@@ -62,7 +60,7 @@ func TestUsersStorage_Create_Success(t *testing.T) {
 	require.NoError(t, err)
 	require.NotNil(t, user)
 	assert.Equal(t, userID, user.ID)
-	assert.Equal(t, email, user.Email)
+	assert.Equal(t, &email, user.Email)
 	assert.Equal(t, username, user.Username)
 	assert.Equal(t, role, user.Role)
 	assert.NoError(t, mock.ExpectationsWereMet())
@@ -92,12 +90,11 @@ func TestUsersStorage_Create_DuplicateEmail(t *testing.T) {
 		    username,
 		    password_hash,
 		    role,
-		    image_url,
-		    verification_token
+		    image_url
 		\)
-		VALUES \(@email, @username, @password_hash, @role, @image_url, @verification_token\)
+		VALUES \(@email, @username, @password_hash, @role, @image_url\)
 		RETURNING \*`).
-		WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg()).
+		WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg()).
 		WillReturnError(pgErr)
 
 	ctx := context.Background()
@@ -159,10 +156,10 @@ func TestUsersStorage_GetByID_Success(t *testing.T) {
 		WithArgs(pgxmock.AnyArg()).
 		WillReturnRows(pgxmock.NewRows([]string{
 			"id", "email", "email_verified", "username", "role", "image_url",
-			"password_hash", "created_at", "updated_at", "deleted_at", "verification_token", // Добавлено поле
+			"password_hash", "created_at", "updated_at", "deleted_at", "phone", "phone_verified",
 		}).AddRow(
-			userID, email, true, username, role, nil,
-			nil, now, now, nil, nil, // Добавлено nil для verification_token
+			userID, &email, true, username, role, nil,
+			nil, now, now, nil, nil, false,
 		))
 
 	ctx := context.Background()
@@ -171,7 +168,7 @@ func TestUsersStorage_GetByID_Success(t *testing.T) {
 	require.NoError(t, err)
 	require.NotNil(t, user)
 	assert.Equal(t, userID, user.ID)
-	assert.Equal(t, email, user.Email)
+	assert.Equal(t, &email, user.Email)
 	assert.Equal(t, username, user.Username)
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
@@ -215,10 +212,10 @@ func TestUsersStorage_GetByEmail_Success(t *testing.T) {
 		WithArgs(pgxmock.AnyArg()).
 		WillReturnRows(pgxmock.NewRows([]string{
 			"id", "email", "email_verified", "username", "role", "image_url",
-			"password_hash", "created_at", "updated_at", "deleted_at", "verification_token",
+			"password_hash", "created_at", "updated_at", "deleted_at", "phone", "phone_verified",
 		}).AddRow(
-			userID, email, true, username, role, nil,
-			nil, now, now, nil, nil,
+			userID, &email, true, username, role, nil,
+			nil, now, now, nil, nil, false,
 		))
 
 	ctx := context.Background()
@@ -227,11 +224,60 @@ func TestUsersStorage_GetByEmail_Success(t *testing.T) {
 	require.NoError(t, err)
 	require.NotNil(t, user)
 	assert.Equal(t, userID, user.ID)
-	assert.Equal(t, email, user.Email)
+	assert.Equal(t, &email, user.Email)
 	assert.Equal(t, username, user.Username)
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
+func TestUsersStorage_CreateByPhone(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	storage := NewUsersStorage(mock)
+
+	userID := uuid.New()
+	phone := "+79123456789"
+	now := time.Now()
+
+	mock.ExpectQuery(`INSERT INTO users \(phone, phone_verified, username, role\)`).
+		WithArgs(phone, "Покупатель 6789", types.RoleCustomer).
+		WillReturnRows(pgxmock.NewRows([]string{
+			"id", "email", "email_verified", "username", "role", "image_url",
+			"password_hash", "created_at", "updated_at", "deleted_at", "phone", "phone_verified",
+		}).AddRow(
+			userID, nil, false, "Покупатель 6789", types.RoleCustomer, nil,
+			nil, now, now, nil, &phone, true,
+		))
+
+	user, err := storage.CreateByPhone(context.Background(), phone, "Покупатель 6789", types.RoleCustomer)
+
+	require.NoError(t, err)
+	assert.Equal(t, userID, user.ID)
+	assert.Nil(t, user.Email)
+	assert.True(t, user.PhoneVerified)
+	assert.Equal(t, phone, user.Login())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUsersStorage_GetByPhone_NotFound(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	storage := NewUsersStorage(mock)
+
+	mock.ExpectQuery(`SELECT \* FROM users WHERE phone = @phone AND phone_verified AND deleted_at IS NULL`).
+		WithArgs("+79123456789").
+		WillReturnError(pgx.ErrNoRows)
+
+	user, err := storage.GetByPhone(context.Background(), "+79123456789")
+
+	assert.Nil(t, user)
+	assert.ErrorIs(t, err, pgx.ErrNoRows)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
 func TestUsersStorage_Update_Success(t *testing.T) {
 	mock, err := pgxmock.NewPool()
 	require.NoError(t, err)
@@ -249,17 +295,16 @@ func TestUsersStorage_Update_Success(t *testing.T) {
 		    role = COALESCE\(@role, role\),
 		    image_url = COALESCE\(@image_url, image_url\),
 		    email_verified = COALESCE\(@email_verified, email_verified\),
-		    verification_token = COALESCE\(@verification_token, verification_token\),
 		    password_hash = COALESCE\(@password_hash, password_hash\)
 		WHERE id = @id AND deleted_at IS NULL
 		RETURNING \*;`).
-		WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg()).
+		WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg()).
 		WillReturnRows(pgxmock.NewRows([]string{
 			"id", "email", "email_verified", "username", "role", "image_url",
-			"password_hash", "created_at", "updated_at", "deleted_at", "verification_token", // Добавлено поле
+			"password_hash", "created_at", "updated_at", "deleted_at", "phone", "phone_verified",
 		}).AddRow(
-			userID, "test@example.com", false, newUsername, types.RoleCustomer, nil,
-			nil, now, now, nil, nil, // Добавлено nil для verification_token
+			userID, strPtr("test@example.com"), false, newUsername, types.RoleCustomer, nil,
+			nil, now, now, nil, nil, false,
 		))
 
 	ctx := context.Background()
@@ -292,13 +337,13 @@ func TestUsersStorage_List_WithPagination(t *testing.T) {
 		WithArgs(pgxmock.AnyArg()).
 		WillReturnRows(pgxmock.NewRows([]string{
 			"id", "email", "email_verified", "username", "role", "image_url",
-			"password_hash", "created_at", "updated_at", "deleted_at", "verification_token", // Все поля
+			"password_hash", "created_at", "updated_at", "deleted_at", "phone", "phone_verified", // Все поля
 		}).AddRow(
-			uuid.New(), "user1@test.com", false, "user1", types.RoleCustomer, nil,
-			nil, time.Now(), time.Now(), nil, nil,
+			uuid.New(), strPtr("user1@test.com"), false, "user1", types.RoleCustomer, nil,
+			nil, time.Now(), time.Now(), nil, nil, false,
 		).AddRow(
-			uuid.New(), "user2@test.com", false, "user2", types.RoleEmployee, nil,
-			nil, time.Now(), time.Now(), nil, nil,
+			uuid.New(), strPtr("user2@test.com"), false, "user2", types.RoleEmployee, nil,
+			nil, time.Now(), time.Now(), nil, nil, false,
 		))
 
 	ctx := context.Background()
@@ -359,6 +404,40 @@ func TestUsersStorage_Delete_NotFound(t *testing.T) {
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
+func TestUsersStorage_Restore(t *testing.T) {
+	tests := []struct {
+		name     string
+		affected int64
+		wantErr  bool
+	}{
+		{name: "удаленный пользователь восстановлен", affected: 1},
+		{name: "пользователь не найден или не удален", affected: 0, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock, err := pgxmock.NewPool()
+			require.NoError(t, err)
+			defer mock.Close()
+
+			storage := NewUsersStorage(mock)
+			userID := uuid.New()
+
+			mock.ExpectExec(`UPDATE users SET deleted_at = NULL WHERE id = @id AND deleted_at IS NOT NULL;`).
+				WithArgs(userID).
+				WillReturnResult(pgxmock.NewResult("UPDATE", tt.affected))
+
+			err = storage.Restore(context.Background(), userID)
+
+			if tt.wantErr {
+				assert.EqualError(t, err, "user not found")
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
 // Добавим тест для метода GetByEmail, который отсутствовал
 func TestUsersStorage_GetByEmail_NotFound(t *testing.T) {
 	mock, err := pgxmock.NewPool()
@@ -381,3 +460,49 @@ func TestUsersStorage_GetByEmail_NotFound(t *testing.T) {
 	assert.ErrorIs(t, err, pgx.ErrNoRows)
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
+
+func strPtr(s string) *string {
+	return &s
+}
+
+func TestUsersStorage_ErrorsDoNotLeakPasswordHash(t *testing.T) {
+	const hash = "$argon2id$v=19$m=65536,t=3,p=2$c2FsdA$aGFzaA"
+	passwordHash := hash
+	dbErr := errors.New("connection reset")
+
+	tests := []struct {
+		name string
+		args int
+		call func(storage *UsersStorage) error
+	}{
+		{"create", 5, func(storage *UsersStorage) error {
+			_, err := storage.Create(context.Background(), types.CreateUserParams{
+				Email: "test@example.com", Username: "testuser", PasswordHash: &passwordHash, Role: types.RoleCustomer,
+			})
+			return err
+		}},
+		{"update", 6, func(storage *UsersStorage) error {
+			_, err := storage.Update(context.Background(), types.UpdateUserParams{ID: uuid.New(), PasswordHash: &passwordHash})
+			return err
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock, err := pgxmock.NewPool()
+			require.NoError(t, err)
+			defer mock.Close()
+			args := make([]any, tt.args)
+			for i := range args {
+				args[i] = pgxmock.AnyArg()
+			}
+			mock.ExpectQuery(`.+`).WithArgs(args...).WillReturnError(dbErr)
+
+			err = tt.call(NewUsersStorage(mock))
+
+			require.ErrorIs(t, err, dbErr)
+			assert.NotContains(t, err.Error(), hash)
+			assert.Contains(t, err.Error(), "PasswordHash:[REDACTED]")
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
@@ -13,3 +13,13 @@ func IsUniqueConstraintViolation(err error, constraintName string) bool {
 	}
 	return false
 }
+
+// IsForeignKeyViolation проверяет, нарушено ли ограничение внешнего ключа constraintName
+// (любого, если constraintName пустой)
+func IsForeignKeyViolation(err error, constraintName string) bool {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return pgErr.Code == "23503" && (pgErr.ConstraintName == constraintName || constraintName == "")
+	}
+	return false
+}
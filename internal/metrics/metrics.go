@@ -0,0 +1,81 @@
+// Пакет metrics содержит метрики приложения в формате Prometheus.
+//
+// Метрики регистрируются в собственном реестре Registry, а не в глобальном реестре
+// клиента Prometheus: так на /metrics попадают только метрики приложения, среды Go
+// и процесса. Сервисы обновляют метрики напрямую через переменные пакета.
+package metrics
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Namespace - префикс имен метрик приложения
+const Namespace = "luxcarpets"
+
+// Registry - реестр метрик, которые отдаются на /metrics
+var Registry = prometheus.NewRegistry()
+
+var factory = promauto.With(Registry)
+
+// HTTPRequestDuration - время обработки HTTP-запросов по маршрутам.
+// Маршрут - шаблон пути ("/api/v1/profile/addresses/:id"), а не сам путь, чтобы число рядов не росло.
+var HTTPRequestDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+	Namespace: Namespace,
+	Subsystem: "http",
+	Name:      "request_duration_seconds",
+	Help:      "HTTP request latency by route.",
+	Buckets:   prometheus.DefBuckets,
+}, []string{"method", "route", "status"})
+
+// PasswordHashDuration - время вычисления Argon2id при создании и проверке паролей.
+// Помогает подобрать ARGON2_MEMORY_KB и ARGON2_ITERATIONS под железо сервера.
+var PasswordHashDuration = factory.NewHistogramVec(prometheus.HistogramOpts{
+	Namespace: Namespace,
+	Subsystem: "password",
+	Name:      "hash_duration_seconds",
+	Help:      "Argon2id hashing duration.",
+	Buckets:   []float64{.01, .025, .05, .1, .25, .5, 1, 2.5},
+}, []string{"operation"})
+
+// Операции для PasswordHashDuration
+const (
+	HashOperationHash   = "hash"
+	HashOperationVerify = "verify"
+)
+
+// SignUps - количество регистраций по способу входа
+var SignUps = factory.NewCounterVec(prometheus.CounterOpts{
+	Namespace: Namespace,
+	Name:      "signups_total",
+	Help:      "Registered users by sign-up method.",
+}, []string{"method"})
+
+// Способы регистрации для SignUps
+const (
+	SignUpPassword = "password" // email и пароль
+	SignUpEmail    = "email"    // email без пароля: вход по ссылке из письма
+	SignUpPhone    = "phone"    // первый вход по коду из SMS
+)
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+}
+
+// ObserveSince записывает в гистограмму время, прошедшее с start
+func ObserveSince(o prometheus.Observer, start time.Time) {
+	o.Observe(time.Since(start).Seconds())
+}
+
+// Handler возвращает обработчик /metrics
+func Handler() http.Handler {
+	return promhttp.HandlerFor(Registry, promhttp.HandlerOpts{Registry: Registry})
+}
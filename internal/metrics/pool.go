@@ -0,0 +1,72 @@
+package metrics
+
+import (
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// PoolStater возвращает статистику пула соединений. Реализация: *pgxpool.Pool.
+type PoolStater interface {
+	Stat() *pgxpool.Stat
+}
+
+// poolCollector снимает статистику пула при каждом запросе /metrics
+type poolCollector struct {
+	pool PoolStater
+
+	acquiredConns     *prometheus.Desc
+	idleConns         *prometheus.Desc
+	constructingConns *prometheus.Desc
+	totalConns        *prometheus.Desc
+	maxConns          *prometheus.Desc
+	acquireCount      *prometheus.Desc
+	acquireDuration   *prometheus.Desc
+	emptyAcquire      *prometheus.Desc
+	emptyAcquireWait  *prometheus.Desc
+	canceledAcquire   *prometheus.Desc
+}
+
+// NewPoolCollector создает сборщик метрик пула соединений PostgreSQL.
+// Время ожидания свободного соединения (wait_duration) растет, когда пулу не хватает соединений.
+func NewPoolCollector(pool PoolStater) prometheus.Collector {
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(Namespace, "db_pool", name), help, nil, nil)
+	}
+	return &poolCollector{
+		pool:              pool,
+		acquiredConns:     desc("acquired_conns", "Connections currently in use."),
+		idleConns:         desc("idle_conns", "Idle connections."),
+		constructingConns: desc("constructing_conns", "Connections being established."),
+		totalConns:        desc("total_conns", "Total connections in the pool."),
+		maxConns:          desc("max_conns", "Maximum pool size."),
+		acquireCount:      desc("acquires_total", "Successful connection acquires."),
+		acquireDuration:   desc("acquire_duration_seconds_total", "Total time spent acquiring connections."),
+		emptyAcquire:      desc("empty_acquires_total", "Acquires that had to wait for a connection."),
+		emptyAcquireWait:  desc("wait_duration_seconds_total", "Total time spent waiting for a free connection."),
+		canceledAcquire:   desc("canceled_acquires_total", "Acquires canceled by context."),
+	}
+}
+
+func (p *poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	prometheus.DescribeByCollect(p, ch)
+}
+
+func (p *poolCollector) Collect(ch chan<- prometheus.Metric) {
+	stat := p.pool.Stat()
+	gauge := func(desc *prometheus.Desc, value int32) {
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.GaugeValue, float64(value))
+	}
+	counter := func(desc *prometheus.Desc, value float64) {
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.CounterValue, value)
+	}
+	gauge(p.acquiredConns, stat.AcquiredConns())
+	gauge(p.idleConns, stat.IdleConns())
+	gauge(p.constructingConns, stat.ConstructingConns())
+	gauge(p.totalConns, stat.TotalConns())
+	gauge(p.maxConns, stat.MaxConns())
+	counter(p.acquireCount, float64(stat.AcquireCount()))
+	counter(p.acquireDuration, stat.AcquireDuration().Seconds())
+	counter(p.emptyAcquire, float64(stat.EmptyAcquireCount()))
+	counter(p.emptyAcquireWait, stat.EmptyAcquireWaitTime().Seconds())
+	counter(p.canceledAcquire, float64(stat.CanceledAcquireCount()))
+}
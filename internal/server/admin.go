@@ -0,0 +1,63 @@
+package server
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/LigeronAhill/luxcarpets-go/internal/service"
+	"github.com/gofiber/fiber/v2"
+)
+
+// unlockSignInBody - тело запроса снятия блокировки входа; нужно указать хотя бы одно поле
+type unlockSignInBody struct {
+	Login string `json:"login"` // Email или телефон заблокированной учетной записи
+	IP    string `json:"ip"`    // Заблокированный IP-адрес
+}
+
+// adminHandler обрабатывает запросы администраторов магазина
+type adminHandler struct {
+	users *service.UsersService
+}
+
+func newAdminHandler(users *service.UsersService) *adminHandler {
+	return &adminHandler{
+		users: users,
+	}
+}
+
+// unlockSignIn снимает временную блокировку входа с учетной записи и IP-адреса.
+// Счетчики неудачных попыток могут храниться в памяти сервера (LOGIN_ATTEMPTS_BACKEND=memory),
+// поэтому снять блокировку можно только через запрос к работающему серверу.
+func (h *adminHandler) unlockSignIn(c *fiber.Ctx) error {
+	var body unlockSignInBody
+	if err := c.BodyParser(&body); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid request body")
+	}
+	body.Login = strings.TrimSpace(body.Login)
+	body.IP = strings.TrimSpace(body.IP)
+	if body.Login == "" && body.IP == "" {
+		return fiber.NewError(fiber.StatusUnprocessableEntity, "login or ip is required")
+	}
+	if body.Login != "" {
+		if err := h.users.UnlockAccount(c.UserContext(), body.Login); err != nil {
+			return adminError(err)
+		}
+	}
+	if body.IP != "" {
+		if err := h.users.UnlockIP(c.UserContext(), body.IP); err != nil {
+			return adminError(err)
+		}
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// adminError сопоставляет ошибки сервисов администрирования с HTTP-статусами
+func adminError(err error) error {
+	switch {
+	case errors.Is(err, service.ErrEmailRequired),
+		errors.Is(err, service.ErrInvalidIP):
+		return fiber.NewError(fiber.StatusUnprocessableEntity, err.Error())
+	default:
+		return err
+	}
+}
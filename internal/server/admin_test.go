@@ -0,0 +1,67 @@
+package server
+
+import (
+	"context"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/LigeronAhill/luxcarpets-go/internal/database"
+	"github.com/LigeronAhill/luxcarpets-go/internal/service"
+	"github.com/gofiber/fiber/v2"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdminHandler_UnlockSignIn(t *testing.T) {
+	const (
+		login = "buyer@example.com"
+		ip    = "203.0.113.7"
+	)
+	tests := []struct {
+		name           string
+		body           string
+		expectedStatus int
+		accountBlocked bool
+		ipBlocked      bool
+	}{
+		{"учетная запись", `{"login": "Buyer@example.com"}`, fiber.StatusNoContent, false, true},
+		{"IP-адрес", `{"ip": "203.0.113.7"}`, fiber.StatusNoContent, true, false},
+		{"учетная запись и IP-адрес", `{"login": "buyer@example.com", "ip": "203.0.113.7"}`, fiber.StatusNoContent, false, false},
+		{"пустой запрос", `{}`, fiber.StatusUnprocessableEntity, true, true},
+		{"неверный IP-адрес", `{"ip": "localhost"}`, fiber.StatusUnprocessableEntity, true, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock, err := pgxmock.NewPool()
+			require.NoError(t, err)
+			defer mock.Close()
+
+			// Учетная запись и IP-адрес блокируются разными попытками, чтобы снятие каждой
+			// блокировки проверялось отдельно; после первой попытки следующая запрещена на минуту
+			policy := service.LockoutPolicy{MaxFailures: 1, LockoutDuration: time.Minute, ResetAfter: time.Hour}
+			guard := service.NewLoginGuard(service.NewMemoryLoginAttemptsStore(), policy, policy)
+			require.NoError(t, guard.Attempt(context.Background(), login, "198.51.100.1"))
+			require.NoError(t, guard.Attempt(context.Background(), "other@example.com", ip))
+
+			users := service.NewUsersService(database.NewUsersStorage(mock), service.WithLoginGuard(guard))
+			app := fiber.New()
+			app.Post("/unlock", newAdminHandler(users).unlockSignIn)
+
+			req := httptest.NewRequest(fiber.MethodPost, "/unlock", strings.NewReader(tt.body))
+			req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+			resp, err := app.Test(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			assert.Equal(t, tt.expectedStatus, resp.StatusCode)
+
+			err = guard.Attempt(context.Background(), login, "")
+			assert.Equal(t, tt.accountBlocked, err != nil, "учетная запись: %v", err)
+			err = guard.Attempt(context.Background(), "third@example.com", ip)
+			assert.Equal(t, tt.ipBlocked, err != nil, "IP-адрес: %v", err)
+		})
+	}
+}
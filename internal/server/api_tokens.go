@@ -0,0 +1,109 @@
+package server
+
+import (
+	"errors"
+	"time"
+
+	"github.com/LigeronAhill/luxcarpets-go/internal/database/types"
+	"github.com/LigeronAhill/luxcarpets-go/internal/server/middleware"
+	"github.com/LigeronAhill/luxcarpets-go/internal/service"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// createAPITokenBody - тело запроса на выпуск токена
+type createAPITokenBody struct {
+	Name          string             `json:"name"`
+	Scopes        []types.TokenScope `json:"scopes"`
+	ExpiresInDays int                `json:"expires_in_days"` // 0 - срок по умолчанию
+}
+
+// apiTokensHandler обрабатывает запросы управления персональными токенами
+type apiTokensHandler struct {
+	tokens *service.APITokensService
+}
+
+func newAPITokensHandler(tokens *service.APITokensService) *apiTokensHandler {
+	return &apiTokensHandler{
+		tokens: tokens,
+	}
+}
+
+// list возвращает неотозванные токены текущего пользователя
+func (h *apiTokensHandler) list(c *fiber.Ctx) error {
+	principal := middleware.PrincipalFrom(c)
+	tokens, err := h.tokens.List(c.UserContext(), principal.User.ID)
+	if err != nil {
+		return err
+	}
+	return c.JSON(tokens)
+}
+
+// create выпускает новый токен. Токен не может получить прав больше, чем дает роль пользователя.
+func (h *apiTokensHandler) create(c *fiber.Ctx) error {
+	principal := middleware.PrincipalFrom(c)
+	var body createAPITokenBody
+	if err := c.BodyParser(&body); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid request body")
+	}
+	// Срок проверяется в днях: перевод большого числа в time.Duration переполняется
+	if body.ExpiresInDays < 0 || body.ExpiresInDays > int(service.MaxAPITokenTTL/(24*time.Hour)) {
+		return fiber.NewError(fiber.StatusBadRequest, service.ErrInvalidAPITokenTTL.Error())
+	}
+	for _, scope := range body.Scopes {
+		if scope.Valid() && !principal.HasScope(scope) {
+			return fiber.NewError(fiber.StatusForbidden, "insufficient scope: "+scope.String())
+		}
+	}
+
+	ttl := time.Duration(body.ExpiresInDays) * 24 * time.Hour
+	if ttl == 0 {
+		ttl = service.DefaultAPITokenTTL
+	}
+
+	issued, err := h.tokens.Create(c.UserContext(), principal.User.ID, service.CreateAPITokenRequest{
+		Name:   body.Name,
+		Scopes: body.Scopes,
+		TTL:    ttl,
+	})
+	if err != nil {
+		return apiTokenError(err)
+	}
+	return c.Status(fiber.StatusCreated).JSON(issued)
+}
+
+// revoke отзывает токен текущего пользователя
+func (h *apiTokensHandler) revoke(c *fiber.Ctx) error {
+	principal := middleware.PrincipalFrom(c)
+	tokenID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusNotFound, service.ErrAPITokenNotFound.Error())
+	}
+	if err := h.tokens.Revoke(c.UserContext(), principal.User.ID, tokenID); err != nil {
+		return apiTokenError(err)
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// getMe возвращает профиль владельца токена
+func getMe(c *fiber.Ctx) error {
+	principal := middleware.PrincipalFrom(c)
+	return c.JSON(principal.User.ToPublic())
+}
+
+// apiTokenError сопоставляет ошибки сервиса токенов с HTTP-статусами
+func apiTokenError(err error) error {
+	switch {
+	case errors.Is(err, service.ErrAPITokenNotFound):
+		return fiber.NewError(fiber.StatusNotFound, err.Error())
+	case errors.Is(err, service.ErrTokenScopeNotAllowed):
+		return fiber.NewError(fiber.StatusForbidden, err.Error())
+	case errors.Is(err, service.ErrAPITokenNameRequired),
+		errors.Is(err, service.ErrAPITokenScopesRequired),
+		errors.Is(err, service.ErrInvalidTokenScope),
+		errors.Is(err, service.ErrInvalidAPITokenTTL):
+		return fiber.NewError(fiber.StatusBadRequest, err.Error())
+	default:
+		return err
+	}
+}
@@ -0,0 +1,52 @@
+package server
+
+import (
+	"io"
+	"testing"
+	"time"
+
+	"github.com/LigeronAhill/luxcarpets-go/internal/database"
+	"github.com/LigeronAhill/luxcarpets-go/internal/database/types"
+	"github.com/LigeronAhill/luxcarpets-go/internal/server/middleware"
+	"github.com/LigeronAhill/luxcarpets-go/internal/service"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Срок действия проверяется до обращения к хранилищу
+func TestAPITokensHandler_Create_InvalidTTL(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	now := time.Now()
+	user := &types.User{ID: uuid.New(), Username: "buyer", Role: types.RoleCustomer, CreatedAt: now, UpdatedAt: now}
+	h := newAPITokensHandler(service.NewAPITokensService(database.NewAPITokensStorage(mock), database.NewUsersStorage(mock)))
+	app := fiber.New()
+	app.Post("/api/v1/tokens", middleware.Authenticate(nil, stubSessions{user: user}), middleware.RequireSession(), h.create)
+
+	tests := []struct {
+		name string
+		days int
+	}{
+		{"отрицательный срок", -1},
+		{"больше максимального", int(service.MaxAPITokenTTL/(24*time.Hour)) + 1},
+		{"переполнение time.Duration", 106752},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := sessionRequest(t, "/api/v1/tokens", createAPITokenBody{Name: "ci", ExpiresInDays: tt.days})
+			resp, err := app.Test(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			assert.Equal(t, fiber.StatusBadRequest, resp.StatusCode)
+			body, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+			assert.Equal(t, service.ErrInvalidAPITokenTTL.Error(), string(body))
+		})
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,291 @@
+package server
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/LigeronAhill/luxcarpets-go/internal/database/types"
+	"github.com/LigeronAhill/luxcarpets-go/internal/server/middleware"
+	"github.com/LigeronAhill/luxcarpets-go/internal/service"
+	"github.com/LigeronAhill/luxcarpets-go/internal/web/components"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// magicNonceCookieName - имя cookie со значением, привязывающим ссылку из письма к браузеру
+const magicNonceCookieName = "lux_magic_nonce"
+
+// phoneCodeBody - тело запроса кода из SMS
+type phoneCodeBody struct {
+	Phone string `json:"phone"`
+}
+
+// magicLinkBody - тело запроса ссылки для входа по email
+type magicLinkBody struct {
+	Email string `json:"email"`
+}
+
+// phoneVerifyBody - тело запроса входа по коду из SMS
+type phoneVerifyBody struct {
+	Phone string `json:"phone"`
+	Code  string `json:"code"`
+}
+
+// secondFactorBody - тело запроса завершения входа кодом 2FA
+type secondFactorBody struct {
+	Challenge string `json:"challenge"`
+	Code      string `json:"code"`
+}
+
+// enrollmentChallengeBody - тело запроса подключения аутентификатора при входе
+type enrollmentChallengeBody struct {
+	Challenge string `json:"challenge"`
+}
+
+// enrollmentSignInResponse - ответ на вход с подключением аутентификатора.
+// Коды восстановления показываются пользователю один раз.
+type enrollmentSignInResponse struct {
+	User          *types.PublicUser `json:"user"`
+	RecoveryCodes []string          `json:"recovery_codes"`
+}
+
+// secondFactorResponse - ответ, когда для входа нужен второй фактор
+type secondFactorResponse struct {
+	Error              string `json:"error"`
+	Challenge          string `json:"challenge"`
+	EnrollmentRequired bool   `json:"enrollment_required"`
+}
+
+// authHandler обрабатывает вход и выход пользователей браузера
+type authHandler struct {
+	users         *service.UsersService
+	phoneOTP      *service.PhoneOTPService
+	magicLinks    *service.MagicLinkService
+	twoFactor     *service.TwoFactorService
+	sessions      *service.SessionsService
+	secureCookies bool
+}
+
+func newAuthHandler(services Services, secureCookies bool) *authHandler {
+	return &authHandler{
+		users:         services.Users,
+		phoneOTP:      services.PhoneOTP,
+		magicLinks:    services.MagicLinks,
+		twoFactor:     services.TwoFactor,
+		sessions:      services.Sessions,
+		secureCookies: secureCookies,
+	}
+}
+
+// requestPhoneCode отправляет одноразовый код на телефон
+func (h *authHandler) requestPhoneCode(c *fiber.Ctx) error {
+	if h.phoneOTP == nil {
+		return fiber.NewError(fiber.StatusNotImplemented, service.ErrPhoneSignInDisabled.Error())
+	}
+	var body phoneCodeBody
+	if err := c.BodyParser(&body); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid request body")
+	}
+	normalized, err := h.phoneOTP.RequestCode(c.UserContext(), body.Phone)
+	if err != nil {
+		return authError(c, err)
+	}
+	return c.Status(fiber.StatusAccepted).JSON(phoneCodeBody{Phone: normalized})
+}
+
+// verifyPhoneCode выполняет вход по коду из SMS и открывает сессию
+func (h *authHandler) verifyPhoneCode(c *fiber.Ctx) error {
+	var body phoneVerifyBody
+	if err := c.BodyParser(&body); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid request body")
+	}
+	user, err := h.users.SignInWithPhone(c.UserContext(), body.Phone, c.IP(), body.Code)
+	if err != nil {
+		return authError(c, err)
+	}
+	if err := h.startSession(c, user.ID); err != nil {
+		return err
+	}
+	return c.JSON(user)
+}
+
+// requestMagicLink отправляет ссылку для входа на email и связывает ее с браузером через cookie.
+// Ответ не зависит от того, зарегистрирован ли email.
+func (h *authHandler) requestMagicLink(c *fiber.Ctx) error {
+	if h.magicLinks == nil {
+		return fiber.NewError(fiber.StatusNotImplemented, service.ErrMagicLinkSignInDisabled.Error())
+	}
+	var body magicLinkBody
+	if err := c.BodyParser(&body); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid request body")
+	}
+	nonce, err := h.magicLinks.Request(c.UserContext(), body.Email)
+	if err != nil {
+		return authError(c, err)
+	}
+	c.Cookie(h.magicNonceCookie(nonce, time.Now().Add(h.magicLinks.TTL())))
+	return c.SendStatus(fiber.StatusAccepted)
+}
+
+// verifyMagicLink выполняет вход по ссылке из письма и открывает сессию
+func (h *authHandler) verifyMagicLink(c *fiber.Ctx) error {
+	user, err := h.users.SignInWithMagicLink(c.UserContext(), c.Query("token"), c.Cookies(magicNonceCookieName))
+	if err != nil {
+		return authError(c, err)
+	}
+	c.Cookie(h.magicNonceCookie("", time.Now().Add(-time.Hour)))
+	if err := h.startSession(c, user.ID); err != nil {
+		return err
+	}
+	// Ссылку из письма открывают в браузере - переводим на сайт вместо JSON
+	if acceptsHTML(c) {
+		addFlash(c, h.secureCookies, components.FlashSuccess, "Вы вошли в аккаунт")
+		return c.Redirect("/", fiber.StatusSeeOther)
+	}
+	return c.JSON(user)
+}
+
+// completeSecondFactor завершает вход кодом из приложения-аутентификатора и открывает сессию
+func (h *authHandler) completeSecondFactor(c *fiber.Ctx) error {
+	var body secondFactorBody
+	if err := c.BodyParser(&body); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid request body")
+	}
+	user, err := h.users.CompleteSignIn(c.UserContext(), body.Challenge, c.IP(), body.Code)
+	if err != nil {
+		return authError(c, err)
+	}
+	if err := h.startSession(c, user.ID); err != nil {
+		return err
+	}
+	return c.JSON(user)
+}
+
+// beginEnrollment начинает обязательное подключение аутентификатора по промежуточному токену,
+// выданному при входе с enrollment_required, и возвращает данные для QR-кода
+func (h *authHandler) beginEnrollment(c *fiber.Ctx) error {
+	if h.twoFactor == nil {
+		return authError(c, service.ErrInvalidChallenge)
+	}
+	var body enrollmentChallengeBody
+	if err := c.BodyParser(&body); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid request body")
+	}
+	enrollment, err := h.twoFactor.BeginChallengeEnrollment(c.UserContext(), body.Challenge)
+	if err != nil {
+		return authError(c, err)
+	}
+	return c.JSON(enrollment)
+}
+
+// completeEnrollment подтверждает аутентификатор первым кодом из приложения,
+// открывает сессию и возвращает коды восстановления
+func (h *authHandler) completeEnrollment(c *fiber.Ctx) error {
+	var body secondFactorBody
+	if err := c.BodyParser(&body); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid request body")
+	}
+	user, recoveryCodes, err := h.users.CompleteEnrollmentSignIn(c.UserContext(), body.Challenge, c.IP(), body.Code)
+	if err != nil {
+		return authError(c, err)
+	}
+	if err := h.startSession(c, user.ID); err != nil {
+		return err
+	}
+	return c.JSON(enrollmentSignInResponse{User: user, RecoveryCodes: recoveryCodes})
+}
+
+// logout завершает текущую сессию браузера
+func (h *authHandler) logout(c *fiber.Ctx) error {
+	principal := middleware.PrincipalFrom(c)
+	if principal.Session != nil {
+		if err := h.sessions.Revoke(c.UserContext(), principal.Session.ID); err != nil {
+			return err
+		}
+	}
+	c.Cookie(h.sessionCookie("", time.Now().Add(-time.Hour)))
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// startSession создает сессию и передает ее токен в cookie
+func (h *authHandler) startSession(c *fiber.Ctx, userID uuid.UUID) error {
+	issued, err := h.sessions.Create(c.UserContext(), userID, c.Get(fiber.HeaderUserAgent), c.IP())
+	if err != nil {
+		return err
+	}
+	c.Cookie(h.sessionCookie(issued.Token, issued.Session.ExpiresAt))
+	return nil
+}
+
+// sessionCookie возвращает cookie сессии. Cookie недоступна из JavaScript
+// и не отправляется при межсайтовых POST-запросах.
+func (h *authHandler) sessionCookie(token string, expires time.Time) *fiber.Cookie {
+	return &fiber.Cookie{
+		Name:     middleware.SessionCookieName,
+		Value:    token,
+		Path:     "/",
+		Expires:  expires,
+		Secure:   h.secureCookies,
+		HTTPOnly: true,
+		SameSite: fiber.CookieSameSiteLaxMode,
+	}
+}
+
+// magicNonceCookie возвращает cookie, привязывающую ссылку из письма к браузеру.
+// Cookie отправляется только на маршруты входа по email.
+func (h *authHandler) magicNonceCookie(nonce string, expires time.Time) *fiber.Cookie {
+	return &fiber.Cookie{
+		Name:     magicNonceCookieName,
+		Value:    nonce,
+		Path:     "/api/v1/auth/email",
+		Expires:  expires,
+		Secure:   h.secureCookies,
+		HTTPOnly: true,
+		SameSite: fiber.CookieSameSiteLaxMode,
+	}
+}
+
+// authError сопоставляет ошибки входа с HTTP-статусами
+func authError(c *fiber.Ctx, err error) error {
+	var blocked *service.LoginBlockedError
+	var throttled *service.OTPThrottledError
+	var secondFactor *service.SecondFactorRequiredError
+	switch {
+	case errors.As(err, &secondFactor):
+		return c.Status(fiber.StatusUnauthorized).JSON(secondFactorResponse{
+			Error:              err.Error(),
+			Challenge:          secondFactor.Challenge,
+			EnrollmentRequired: secondFactor.EnrollmentRequired,
+		})
+	case errors.As(err, &blocked):
+		c.Set(fiber.HeaderRetryAfter, retryAfterSeconds(blocked.RetryAfter))
+		return fiber.NewError(fiber.StatusTooManyRequests, err.Error())
+	case errors.Is(err, service.ErrMagicLinkThrottled):
+		return fiber.NewError(fiber.StatusTooManyRequests, err.Error())
+	case errors.As(err, &throttled):
+		c.Set(fiber.HeaderRetryAfter, retryAfterSeconds(throttled.RetryAfter))
+		return fiber.NewError(fiber.StatusTooManyRequests, err.Error())
+	case errors.Is(err, service.ErrInvalidOTP),
+		errors.Is(err, service.ErrInvalidChallenge),
+		errors.Is(err, service.ErrInvalidTwoFactorCode),
+		errors.Is(err, service.ErrInvalidMagicLink):
+		return fiber.NewError(fiber.StatusUnauthorized, err.Error())
+	case errors.Is(err, service.ErrTwoFactorNotEnrolled),
+		errors.Is(err, service.ErrTwoFactorAlreadyEnabled):
+		return fiber.NewError(fiber.StatusConflict, err.Error())
+	case errors.Is(err, service.ErrInvalidPhone),
+		errors.Is(err, service.ErrEmailRequired):
+		return fiber.NewError(fiber.StatusUnprocessableEntity, err.Error())
+	case errors.Is(err, service.ErrPhoneSignInDisabled),
+		errors.Is(err, service.ErrMagicLinkSignInDisabled):
+		return fiber.NewError(fiber.StatusNotImplemented, err.Error())
+	default:
+		return err
+	}
+}
+
+// retryAfterSeconds форматирует значение заголовка Retry-After (округление вверх)
+func retryAfterSeconds(d time.Duration) string {
+	return strconv.Itoa(int((d + time.Second - 1) / time.Second))
+}
@@ -0,0 +1,205 @@
+package server
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/LigeronAhill/luxcarpets-go/internal/database"
+	"github.com/LigeronAhill/luxcarpets-go/internal/database/types"
+	"github.com/LigeronAhill/luxcarpets-go/internal/server/middleware"
+	"github.com/LigeronAhill/luxcarpets-go/internal/service"
+	"github.com/LigeronAhill/luxcarpets-go/pkg/sms"
+	"github.com/LigeronAhill/luxcarpets-go/pkg/totp"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testKey = []byte("0123456789abcdef0123456789abcdef")
+
+var (
+	userColumns = []string{
+		"id", "email", "email_verified", "username", "role", "image_url",
+		"password_hash", "created_at", "updated_at", "deleted_at", "phone", "phone_verified",
+	}
+	phoneOTPColumns = []string{
+		"phone", "code_hash", "attempts", "expires_at", "sent_at", "send_count", "window_started_at",
+	}
+	totpColumns = []string{
+		"user_id", "secret_encrypted", "confirmed_at", "last_used_step", "created_at", "updated_at",
+	}
+	sessionColumns = []string{
+		"id", "user_id", "token_hash", "user_agent", "ip_address", "expires_at", "last_seen_at", "created_at",
+	}
+)
+
+// captureArg принимает любой аргумент запроса и запоминает его значение
+type captureArg[T any] struct {
+	value *T
+}
+
+func (a captureArg[T]) Match(v any) bool {
+	value, ok := v.(T)
+	if ok {
+		*a.value = value
+	}
+	return ok
+}
+
+// newTestAuthApp регистрирует маршруты входа без middleware сервера
+func newTestAuthApp(services Services) *fiber.App {
+	auth := newAuthHandler(services, false)
+	app := fiber.New()
+	app.Post("/api/v1/auth/phone/code", auth.requestPhoneCode)
+	app.Post("/api/v1/auth/phone/verify", auth.verifyPhoneCode)
+	app.Post("/api/v1/auth/2fa", auth.completeSecondFactor)
+	app.Post("/api/v1/auth/2fa/enroll", auth.beginEnrollment)
+	app.Post("/api/v1/auth/2fa/enroll/confirm", auth.completeEnrollment)
+	return app
+}
+
+// postJSON отправляет запрос и разбирает JSON-ответ в out
+func postJSON(t *testing.T, app *fiber.App, path string, body any, out any) *http.Response {
+	t.Helper()
+	return doJSON(t, app, newJSONRequest(t, path, body), out)
+}
+
+// newJSONRequest создает POST-запрос с телом body в JSON
+func newJSONRequest(t *testing.T, path string, body any) *http.Request {
+	t.Helper()
+	data, err := json.Marshal(body)
+	require.NoError(t, err)
+	req := httptest.NewRequest(fiber.MethodPost, path, strings.NewReader(string(data)))
+	req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+	return req
+}
+
+// doJSON выполняет запрос и разбирает JSON-ответ в out
+func doJSON(t *testing.T, app *fiber.App, req *http.Request, out any) *http.Response {
+	t.Helper()
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	raw, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	if out != nil {
+		require.NoError(t, json.Unmarshal(raw, out), string(raw))
+	}
+	return resp
+}
+
+// Сотрудник без аутентификатора входит по коду из SMS, подключает аутентификатор и получает сессию
+func TestAuthHandler_PhoneSignIn_EnrollmentRequired(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	sender := &sms.Fake{}
+	otpCfg := service.DefaultPhoneOTPConfig
+	otpCfg.Key = testKey
+	phoneOTP, err := service.NewPhoneOTPService(database.NewPhoneOTPStorage(mock), sender, otpCfg)
+	require.NoError(t, err)
+	twoFactor, err := service.NewTwoFactorService(database.NewTwoFactorStorage(mock), database.NewUsersStorage(mock), service.TwoFactorConfig{
+		EncryptionKey: testKey,
+		RequiredRole:  types.RoleEmployee,
+	})
+	require.NoError(t, err)
+	app := newTestAuthApp(Services{
+		Users: service.NewUsersService(database.NewUsersStorage(mock),
+			service.WithPhoneOTP(phoneOTP),
+			service.WithTwoFactor(twoFactor),
+		),
+		PhoneOTP:  phoneOTP,
+		TwoFactor: twoFactor,
+		Sessions:  service.NewSessionsService(database.NewSessionsStorage(mock), database.NewUsersStorage(mock), 0),
+	})
+
+	userPhone := "+79123456789"
+	userID := uuid.New()
+	now := time.Now()
+	employeeRow := func() *pgxmock.Rows {
+		return pgxmock.NewRows(userColumns).AddRow(
+			userID, nil, false, "Сотрудник", types.RoleEmployee, nil,
+			nil, now, now, nil, &userPhone, true,
+		)
+	}
+
+	// Код из SMS
+	var codeHash string
+	mock.ExpectQuery(`SELECT \* FROM phone_otp_codes`).WithArgs(userPhone).WillReturnRows(pgxmock.NewRows(phoneOTPColumns))
+	mock.ExpectExec(`INSERT INTO phone_otp_codes`).
+		WithArgs(userPhone, captureArg[string]{&codeHash}, pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg()).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+
+	resp := postJSON(t, app, "/api/v1/auth/phone/code", phoneCodeBody{Phone: "8 (912) 345-67-89"}, nil)
+	require.Equal(t, fiber.StatusAccepted, resp.StatusCode)
+	msg, ok := sender.Last(userPhone)
+	require.True(t, ok)
+	code := msg.Text[:6]
+
+	// Вход по коду: роль требует 2FA, аутентификатор не подключен
+	mock.ExpectQuery(`UPDATE phone_otp_codes SET attempts = attempts \+ 1`).
+		WithArgs(userPhone, otpCfg.MaxAttempts).
+		WillReturnRows(pgxmock.NewRows(phoneOTPColumns).AddRow(userPhone, codeHash, 1, now.Add(time.Minute), now, 1, now))
+	mock.ExpectExec(`UPDATE phone_otp_codes SET expires_at = sent_at`).
+		WithArgs(userPhone, codeHash, otpCfg.MaxAttempts).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	mock.ExpectQuery(`SELECT \* FROM users WHERE phone = @phone`).WithArgs(userPhone).WillReturnRows(employeeRow())
+	mock.ExpectQuery(`SELECT \* FROM user_totp`).WithArgs(userID).WillReturnRows(pgxmock.NewRows(totpColumns))
+
+	var required secondFactorResponse
+	resp = postJSON(t, app, "/api/v1/auth/phone/verify", phoneVerifyBody{Phone: userPhone, Code: code}, &required)
+	require.Equal(t, fiber.StatusUnauthorized, resp.StatusCode)
+	require.True(t, required.EnrollmentRequired)
+	require.NotEmpty(t, required.Challenge)
+
+	// Токен подключения не завершает обычный вход
+	resp = postJSON(t, app, "/api/v1/auth/2fa", secondFactorBody{Challenge: required.Challenge, Code: "123456"}, nil)
+	require.Equal(t, fiber.StatusUnauthorized, resp.StatusCode)
+
+	// Подключение аутентификатора
+	var encrypted []byte
+	mock.ExpectQuery(`SELECT \* FROM users WHERE id = @id`).WithArgs(userID).WillReturnRows(employeeRow())
+	mock.ExpectQuery(`INSERT INTO user_totp`).
+		WithArgs(userID, captureArg[[]byte]{&encrypted}).
+		WillReturnRows(pgxmock.NewRows(totpColumns).AddRow(userID, []byte("secret"), nil, int64(0), now, now))
+
+	var enrollment service.TOTPEnrollment
+	resp = postJSON(t, app, "/api/v1/auth/2fa/enroll", enrollmentChallengeBody{Challenge: required.Challenge}, &enrollment)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	assert.Contains(t, enrollment.ProvisioningURI, "otpauth://totp/")
+	secret, err := totp.DecodeSecret(enrollment.Secret)
+	require.NoError(t, err)
+
+	// Подтверждение первым кодом из приложения открывает сессию
+	mock.ExpectQuery(`SELECT \* FROM users WHERE id = @id`).WithArgs(userID).WillReturnRows(employeeRow())
+	mock.ExpectQuery(`SELECT \* FROM user_totp`).
+		WithArgs(userID).
+		WillReturnRows(pgxmock.NewRows(totpColumns).AddRow(userID, encrypted, nil, int64(0), now, now))
+	mock.ExpectExec(`UPDATE user_totp\s+SET confirmed_at = NOW\(\)`).
+		WithArgs(pgxmock.AnyArg(), userID).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE FROM user_recovery_codes`).WithArgs(userID).WillReturnResult(pgxmock.NewResult("DELETE", 0))
+	mock.ExpectExec(`INSERT INTO user_recovery_codes`).WithArgs(userID, pgxmock.AnyArg()).WillReturnResult(pgxmock.NewResult("INSERT", 10))
+	mock.ExpectCommit()
+	mock.ExpectQuery(`INSERT INTO sessions`).
+		WithArgs(userID, pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg()).
+		WillReturnRows(pgxmock.NewRows(sessionColumns).AddRow(uuid.New(), userID, "hash", nil, nil, now.Add(service.DefaultSessionTTL), now, now))
+
+	var signedIn enrollmentSignInResponse
+	otp := totp.Code(secret, totp.Step(time.Now()), totp.Digits)
+	resp = postJSON(t, app, "/api/v1/auth/2fa/enroll/confirm", secondFactorBody{Challenge: required.Challenge, Code: otp}, &signedIn)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	assert.Equal(t, userID, signedIn.User.ID)
+	assert.Len(t, signedIn.RecoveryCodes, 10)
+	assert.Contains(t, resp.Header.Get(fiber.HeaderSetCookie), middleware.SessionCookieName+"=")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
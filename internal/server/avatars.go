@@ -0,0 +1,55 @@
+package server
+
+import (
+	"errors"
+
+	"github.com/LigeronAhill/luxcarpets-go/internal/server/middleware"
+	"github.com/LigeronAhill/luxcarpets-go/internal/service"
+	"github.com/gofiber/fiber/v2"
+)
+
+// avatarFormField - имя поля формы multipart с файлом аватара
+const avatarFormField = "avatar"
+
+// avatarHandler обрабатывает загрузку аватара
+type avatarHandler struct {
+	avatars *service.AvatarService
+}
+
+func newAvatarHandler(avatars *service.AvatarService) *avatarHandler {
+	return &avatarHandler{
+		avatars: avatars,
+	}
+}
+
+// upload принимает изображение в поле avatar формы multipart/form-data и устанавливает его аватаром
+func (h *avatarHandler) upload(c *fiber.Ctx) error {
+	principal := middleware.PrincipalFrom(c)
+	header, err := c.FormFile(avatarFormField)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "multipart field \""+avatarFormField+"\" is required")
+	}
+	if header.Size > h.avatars.MaxBytes() {
+		return fiber.NewError(fiber.StatusRequestEntityTooLarge, service.ErrAvatarTooLarge.Error())
+	}
+	file, err := header.Open()
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	user, err := h.avatars.Upload(c.UserContext(), principal.User.ID, file)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrAvatarTooLarge):
+			return fiber.NewError(fiber.StatusRequestEntityTooLarge, err.Error())
+		case errors.Is(err, service.ErrUnsupportedImage):
+			return fiber.NewError(fiber.StatusUnsupportedMediaType, service.ErrUnsupportedImage.Error())
+		case errors.Is(err, service.ErrUserNotFound):
+			return fiber.NewError(fiber.StatusNotFound, service.ErrUserNotFound.Error())
+		default:
+			return err
+		}
+	}
+	return c.JSON(user)
+}
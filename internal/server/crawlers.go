@@ -0,0 +1,37 @@
+package server
+
+import (
+	"github.com/LigeronAhill/luxcarpets-go/internal/service"
+	"github.com/gofiber/fiber/v2"
+)
+
+// crawlersHandler отдает файлы для поисковых роботов и языковых моделей
+type crawlersHandler struct {
+	robots string
+	llms   *service.LLMsService
+}
+
+func newCrawlersHandler(robots string, llms *service.LLMsService) *crawlersHandler {
+	return &crawlersHandler{
+		robots: robots,
+		llms:   llms,
+	}
+}
+
+// robotsTxt отдает robots.txt. Содержимое зависит только от окружения и собирается при запуске.
+func (h *crawlersHandler) robotsTxt(c *fiber.Ctx) error {
+	c.Set(fiber.HeaderContentType, fiber.MIMETextPlainCharsetUTF8)
+	c.Set(fiber.HeaderCacheControl, "public, max-age=86400")
+	return c.SendString(h.robots)
+}
+
+// llmsTxt отдает llms.txt, собранный по текущему каталогу
+func (h *crawlersHandler) llmsTxt(c *fiber.Ctx) error {
+	text, err := h.llms.Text(c.UserContext())
+	if err != nil {
+		return err
+	}
+	c.Set(fiber.HeaderContentType, "text/markdown; charset=utf-8")
+	c.Set(fiber.HeaderCacheControl, "public, max-age=3600")
+	return c.SendString(text)
+}
@@ -0,0 +1,73 @@
+package server
+
+import (
+	"errors"
+	"log/slog"
+	"strings"
+
+	"github.com/LigeronAhill/luxcarpets-go/internal/web/pages"
+	"github.com/gofiber/fiber/v2"
+)
+
+// ErrorResponse - тело ответа с ошибкой
+type ErrorResponse struct {
+	Error string `json:"error"`
+}
+
+// errorHandler преобразует ошибки обработчиков в ответы: для API - JSON,
+// для браузера - страницы 404 и 500 в общем макете сайта.
+// Внутренние ошибки логируются, а клиенту возвращается общее сообщение.
+func (s *Server) errorHandler(c *fiber.Ctx, err error) error {
+	status := fiber.StatusInternalServerError
+	message := "internal server error"
+	var fiberErr *fiber.Error
+	if errors.As(err, &fiberErr) {
+		status = fiberErr.Code
+		message = fiberErr.Message
+	} else {
+		logRequestError(c, "Request failed", err)
+	}
+
+	if !wantsHTML(c) {
+		return c.Status(status).JSON(ErrorResponse{Error: message})
+	}
+	p := s.page(c, "")
+	switch {
+	case status == fiber.StatusNotFound:
+		p.Title = "Страница не найдена"
+		err = render(c, status, pages.NotFound(p))
+	case status >= fiber.StatusInternalServerError:
+		p.Title = "Ошибка сервера"
+		err = render(c, status, pages.ServerError(p))
+	default:
+		p.Title = "Ошибка"
+		err = render(c, status, pages.Error(p, status, message))
+	}
+	if err != nil {
+		// Макет не отрисовался - отвечаем простым текстом, чтобы не зациклиться
+		logRequestError(c, "Failed to render error page", err)
+		return c.Status(status).SendString(message)
+	}
+	return nil
+}
+
+// wantsHTML проверяет, что ошибку нужно показать страницей: запрос не к API и пришел от браузера
+func wantsHTML(c *fiber.Ctx) bool {
+	return !strings.HasPrefix(c.Path(), "/api/") && acceptsHTML(c)
+}
+
+// acceptsHTML проверяет, что клиент явно принимает HTML и предпочитает его JSON.
+// Запрос без заголовка Accept считается запросом клиента API.
+func acceptsHTML(c *fiber.Ctx) bool {
+	return strings.Contains(c.Get(fiber.HeaderAccept), fiber.MIMETextHTML) &&
+		c.Accepts(fiber.MIMETextHTML, fiber.MIMEApplicationJSON) == fiber.MIMETextHTML
+}
+
+// logRequestError логирует ошибку с методом и путем запроса
+func logRequestError(c *fiber.Ctx, msg string, err error) {
+	slog.ErrorContext(c.UserContext(), msg,
+		slog.String("method", c.Method()),
+		slog.String("path", c.Path()),
+		slog.String("error", err.Error()),
+	)
+}
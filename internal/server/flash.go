@@ -0,0 +1,71 @@
+package server
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"time"
+
+	"github.com/LigeronAhill/luxcarpets-go/internal/web/components"
+	"github.com/gofiber/fiber/v2"
+)
+
+// flashCookieName - имя cookie с flash-сообщениями для следующей страницы
+const flashCookieName = "lux_flash"
+
+// Ограничения flash-сообщений: cookie не должна разрастаться при ошибках в цикле перенаправлений
+const (
+	maxFlashes  = 5
+	flashMaxAge = 5 * time.Minute
+)
+
+// addFlash добавляет сообщение, которое будет показано на следующей отрисованной странице
+func addFlash(c *fiber.Ctx, secure bool, kind components.FlashKind, text string) {
+	flashes := append(readFlashes(c), components.Flash{Kind: kind, Text: text})
+	if len(flashes) > maxFlashes {
+		flashes = flashes[len(flashes)-maxFlashes:]
+	}
+	raw, err := json.Marshal(flashes)
+	if err != nil {
+		return
+	}
+	c.Cookie(flashCookie(base64.RawURLEncoding.EncodeToString(raw), time.Now().Add(flashMaxAge), secure))
+}
+
+// takeFlashes возвращает сообщения и удаляет cookie
+func takeFlashes(c *fiber.Ctx, secure bool) []components.Flash {
+	flashes := readFlashes(c)
+	if c.Cookies(flashCookieName) != "" {
+		c.Cookie(flashCookie("", time.Now().Add(-time.Hour), secure))
+	}
+	return flashes
+}
+
+// readFlashes читает сообщения из cookie; поврежденное значение игнорируется.
+// Текст выводится в шаблонах с экранированием, поэтому подделка cookie ничего не дает.
+func readFlashes(c *fiber.Ctx) []components.Flash {
+	value := c.Cookies(flashCookieName)
+	if value == "" {
+		return nil
+	}
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil {
+		return nil
+	}
+	var flashes []components.Flash
+	if err := json.Unmarshal(raw, &flashes); err != nil {
+		return nil
+	}
+	return flashes
+}
+
+func flashCookie(value string, expires time.Time, secure bool) *fiber.Cookie {
+	return &fiber.Cookie{
+		Name:     flashCookieName,
+		Value:    value,
+		Path:     "/",
+		Expires:  expires,
+		Secure:   secure,
+		HTTPOnly: true,
+		SameSite: fiber.CookieSameSiteLaxMode,
+	}
+}
@@ -0,0 +1,75 @@
+package server
+
+import (
+	"context"
+	"crypto/subtle"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/LigeronAhill/luxcarpets-go/internal/metrics"
+	"github.com/LigeronAhill/luxcarpets-go/internal/service"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/adaptor"
+)
+
+// readyTimeout ограничивает проверку готовности: зависшая база не должна задерживать пробы оркестратора
+const readyTimeout = 2 * time.Second
+
+// HealthResponse - тело ответа проверок /healthz и /readyz
+type HealthResponse struct {
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// healthHandler отвечает на пробы Docker и оркестратора и отдает метрики Prometheus
+type healthHandler struct {
+	health       *service.HealthService
+	metricsToken string
+}
+
+func newHealthHandler(health *service.HealthService, metricsToken string) *healthHandler {
+	return &healthHandler{
+		health:       health,
+		metricsToken: metricsToken,
+	}
+}
+
+// healthz сообщает, что процесс жив и обрабатывает запросы. Внешние зависимости не проверяются:
+// недоступная база не должна приводить к перезапуску процесса.
+func (h *healthHandler) healthz(c *fiber.Ctx) error {
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	return c.JSON(HealthResponse{Status: "ok"})
+}
+
+// readyz сообщает, готов ли сервер принимать запросы: база доступна и миграции применены
+func (h *healthHandler) readyz(c *fiber.Ctx) error {
+	c.Set(fiber.HeaderCacheControl, "no-store")
+	ctx, cancel := context.WithTimeout(c.UserContext(), readyTimeout)
+	defer cancel()
+	if err := h.health.Ready(ctx); err != nil {
+		slog.WarnContext(c.UserContext(), "Readiness check failed", slog.String("error", err.Error()))
+		// Подробности (адрес базы, текст ошибки драйвера) остаются в логе
+		reason := service.ErrDatabaseUnavailable
+		if errors.Is(err, service.ErrMigrationsPending) {
+			reason = service.ErrMigrationsPending
+		}
+		return c.Status(fiber.StatusServiceUnavailable).JSON(HealthResponse{Status: "unavailable", Error: reason.Error()})
+	}
+	return c.JSON(HealthResponse{Status: "ok"})
+}
+
+// metrics отдает метрики в формате Prometheus. Если задан токен, запрос должен содержать
+// заголовок Authorization: Bearer <токен>.
+func (h *healthHandler) metrics() fiber.Handler {
+	serve := adaptor.HTTPHandler(metrics.Handler())
+	return func(c *fiber.Ctx) error {
+		if h.metricsToken != "" {
+			expected := "Bearer " + h.metricsToken
+			if subtle.ConstantTimeCompare([]byte(c.Get(fiber.HeaderAuthorization)), []byte(expected)) != 1 {
+				return fiber.ErrUnauthorized
+			}
+		}
+		return serve(c)
+	}
+}
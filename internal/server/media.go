@@ -0,0 +1,163 @@
+package server
+
+import (
+	"errors"
+
+	"github.com/LigeronAhill/luxcarpets-go/internal/service"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// mediaFormField - имя поля формы multipart с файлом изображения товара
+const mediaFormField = "image"
+
+// mediaHandler обрабатывает запросы галереи товара
+type mediaHandler struct {
+	media *service.MediaService
+}
+
+func newMediaHandler(media *service.MediaService) *mediaHandler {
+	return &mediaHandler{
+		media: media,
+	}
+}
+
+// altTextRequest - тело запроса изменения альтернативного текста
+type altTextRequest struct {
+	AltText string `json:"alt_text"`
+}
+
+// reorderRequest - тело запроса изменения порядка галереи
+type reorderRequest struct {
+	IDs []uuid.UUID `json:"ids"`
+}
+
+// list возвращает галерею товара
+func (h *mediaHandler) list(c *fiber.Ctx) error {
+	productID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusNotFound, service.ErrProductNotFound.Error())
+	}
+	images, err := h.media.Gallery(c.UserContext(), productID)
+	if err != nil {
+		return mediaError(err)
+	}
+	return c.JSON(images)
+}
+
+// upload принимает изображение в поле image формы multipart/form-data.
+// Поля alt_text (обязательно) и variant_id (опционально) передаются в той же форме.
+func (h *mediaHandler) upload(c *fiber.Ctx) error {
+	productID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusNotFound, service.ErrProductNotFound.Error())
+	}
+	req := service.UploadMediaRequest{
+		ProductID: productID,
+		AltText:   c.FormValue("alt_text"),
+	}
+	if raw := c.FormValue("variant_id"); raw != "" {
+		variantID, err := uuid.Parse(raw)
+		if err != nil {
+			return fiber.NewError(fiber.StatusUnprocessableEntity, service.ErrVariantNotFound.Error())
+		}
+		req.VariantID = &variantID
+	}
+	header, err := c.FormFile(mediaFormField)
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "multipart field \""+mediaFormField+"\" is required")
+	}
+	if header.Size > h.media.MaxBytes() {
+		return fiber.NewError(fiber.StatusRequestEntityTooLarge, service.ErrMediaTooLarge.Error())
+	}
+	file, err := header.Open()
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	image, err := h.media.Upload(c.UserContext(), req, file)
+	if err != nil {
+		return mediaError(err)
+	}
+	return c.Status(fiber.StatusCreated).JSON(image)
+}
+
+// reorder задает порядок галереи; первое изображение становится главным
+func (h *mediaHandler) reorder(c *fiber.Ctx) error {
+	productID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusNotFound, service.ErrProductNotFound.Error())
+	}
+	var req reorderRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid request body")
+	}
+	images, err := h.media.Reorder(c.UserContext(), productID, req.IDs)
+	if err != nil {
+		return mediaError(err)
+	}
+	return c.JSON(images)
+}
+
+// updateAltText изменяет альтернативный текст изображения
+func (h *mediaHandler) updateAltText(c *fiber.Ctx) error {
+	productID, mediaID, err := mediaParams(c)
+	if err != nil {
+		return err
+	}
+	var req altTextRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid request body")
+	}
+	item, err := h.media.UpdateAltText(c.UserContext(), productID, mediaID, req.AltText)
+	if err != nil {
+		return mediaError(err)
+	}
+	return c.JSON(item)
+}
+
+// remove удаляет изображение из галереи
+func (h *mediaHandler) remove(c *fiber.Ctx) error {
+	productID, mediaID, err := mediaParams(c)
+	if err != nil {
+		return err
+	}
+	if err := h.media.Remove(c.UserContext(), productID, mediaID); err != nil {
+		return mediaError(err)
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// mediaParams извлекает идентификаторы товара и изображения галереи из пути
+func mediaParams(c *fiber.Ctx) (uuid.UUID, uuid.UUID, error) {
+	productID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return uuid.Nil, uuid.Nil, fiber.NewError(fiber.StatusNotFound, service.ErrProductNotFound.Error())
+	}
+	mediaID, err := uuid.Parse(c.Params("mediaId"))
+	if err != nil {
+		return uuid.Nil, uuid.Nil, fiber.NewError(fiber.StatusNotFound, service.ErrMediaNotFound.Error())
+	}
+	return productID, mediaID, nil
+}
+
+// mediaError сопоставляет ошибки сервиса галерей с HTTP-статусами
+func mediaError(err error) error {
+	switch {
+	case errors.Is(err, service.ErrProductNotFound), errors.Is(err, service.ErrMediaNotFound):
+		return fiber.NewError(fiber.StatusNotFound, err.Error())
+	case errors.Is(err, service.ErrMediaAlreadyInGallery):
+		return fiber.NewError(fiber.StatusConflict, err.Error())
+	case errors.Is(err, service.ErrMediaTooLarge):
+		return fiber.NewError(fiber.StatusRequestEntityTooLarge, err.Error())
+	case errors.Is(err, service.ErrUnsupportedImage):
+		return fiber.NewError(fiber.StatusUnsupportedMediaType, service.ErrUnsupportedImage.Error())
+	case errors.Is(err, service.ErrAltTextRequired),
+		errors.Is(err, service.ErrVariantNotFound),
+		errors.Is(err, service.ErrInvalidMediaOrder):
+		return fiber.NewError(fiber.StatusUnprocessableEntity, err.Error())
+	default:
+		return err
+	}
+}
@@ -0,0 +1,78 @@
+// Пакет middleware содержит промежуточные обработчики HTTP-сервера.
+package middleware
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"github.com/LigeronAhill/luxcarpets-go/internal/database/types"
+	"github.com/LigeronAhill/luxcarpets-go/internal/service"
+	"github.com/gofiber/fiber/v2"
+)
+
+// principalKey - ключ клиента в c.Locals
+const principalKey = "principal"
+
+// TokenAuthenticator проверяет токен доступа и возвращает клиента.
+// Реализация: service.APITokensService.
+type TokenAuthenticator interface {
+	Authenticate(ctx context.Context, token string) (*service.Principal, error)
+}
+
+// BearerAuth требует заголовок "Authorization: Bearer <токен>" и сохраняет клиента в контексте запроса.
+// Ответы об отказе следуют RFC 6750: 401 с заголовком WWW-Authenticate.
+func BearerAuth(auth TokenAuthenticator) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		token, ok := bearerToken(c.Get(fiber.HeaderAuthorization))
+		if !ok {
+			c.Set(fiber.HeaderWWWAuthenticate, `Bearer realm="api"`)
+			return fiber.NewError(fiber.StatusUnauthorized, "missing bearer token")
+		}
+		principal, err := auth.Authenticate(c.UserContext(), token)
+		if err != nil {
+			if errors.Is(err, service.ErrInvalidAPIToken) {
+				c.Set(fiber.HeaderWWWAuthenticate, `Bearer realm="api", error="invalid_token"`)
+				return fiber.NewError(fiber.StatusUnauthorized, err.Error())
+			}
+			return err
+		}
+		c.Locals(principalKey, principal)
+		return c.Next()
+	}
+}
+
+// RequireScope пропускает запрос, только если у клиента есть все перечисленные права.
+// Должен подключаться после BearerAuth.
+func RequireScope(scopes ...types.TokenScope) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		principal := PrincipalFrom(c)
+		if principal == nil {
+			c.Set(fiber.HeaderWWWAuthenticate, `Bearer realm="api"`)
+			return fiber.NewError(fiber.StatusUnauthorized, "authentication required")
+		}
+		for _, scope := range scopes {
+			if !principal.HasScope(scope) {
+				c.Set(fiber.HeaderWWWAuthenticate, `Bearer realm="api", error="insufficient_scope", scope="`+scope.String()+`"`)
+				return fiber.NewError(fiber.StatusForbidden, "insufficient scope: "+scope.String())
+			}
+		}
+		return c.Next()
+	}
+}
+
+// PrincipalFrom возвращает аутентифицированного клиента или nil
+func PrincipalFrom(c *fiber.Ctx) *service.Principal {
+	principal, _ := c.Locals(principalKey).(*service.Principal)
+	return principal
+}
+
+// bearerToken извлекает токен из значения заголовка Authorization
+func bearerToken(header string) (string, bool) {
+	scheme, token, ok := strings.Cut(strings.TrimSpace(header), " ")
+	if !ok || !strings.EqualFold(scheme, "Bearer") {
+		return "", false
+	}
+	token = strings.TrimSpace(token)
+	return token, token != ""
+}
@@ -0,0 +1,73 @@
+package middleware
+
+import (
+	"context"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/LigeronAhill/luxcarpets-go/internal/database/types"
+	"github.com/LigeronAhill/luxcarpets-go/internal/service"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubAuthenticator принимает только токен "good" с правом чтения каталога
+type stubAuthenticator struct{}
+
+func (stubAuthenticator) Authenticate(_ context.Context, token string) (*service.Principal, error) {
+	if token != "good" {
+		return nil, service.ErrInvalidAPIToken
+	}
+	return &service.Principal{
+		User:   &types.User{ID: uuid.New(), Role: types.RoleCustomer},
+		Scopes: []types.TokenScope{types.ScopeCatalogRead},
+		Token:  &types.APIToken{ID: uuid.New()},
+	}, nil
+}
+
+func newBearerTestApp() *fiber.App {
+	app := fiber.New()
+	api := app.Group("/api", BearerAuth(stubAuthenticator{}))
+	api.Get("/catalog", RequireScope(types.ScopeCatalogRead), func(c *fiber.Ctx) error {
+		return c.SendString(PrincipalFrom(c).User.Role.String())
+	})
+	api.Get("/orders", RequireScope(types.ScopeOrdersRead), func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	})
+	return app
+}
+
+func TestBearerAuth(t *testing.T) {
+	tests := []struct {
+		name            string
+		path            string
+		authorization   string
+		expectedStatus  int
+		expectedWWWAuth string
+	}{
+		{"без заголовка", "/api/catalog", "", fiber.StatusUnauthorized, `Bearer realm="api"`},
+		{"другая схема", "/api/catalog", "Basic dXNlcjpwYXNz", fiber.StatusUnauthorized, `Bearer realm="api"`},
+		{"неверный токен", "/api/catalog", "Bearer bad", fiber.StatusUnauthorized, `Bearer realm="api", error="invalid_token"`},
+		{"недостаточно прав", "/api/orders", "Bearer good", fiber.StatusForbidden, `Bearer realm="api", error="insufficient_scope", scope="orders:read"`},
+		{"успешный запрос", "/api/catalog", "bearer  good", fiber.StatusOK, ""},
+	}
+
+	app := newBearerTestApp()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(fiber.MethodGet, tt.path, nil)
+			if tt.authorization != "" {
+				req.Header.Set(fiber.HeaderAuthorization, tt.authorization)
+			}
+
+			resp, err := app.Test(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			assert.Equal(t, tt.expectedStatus, resp.StatusCode)
+			assert.Equal(t, tt.expectedWWWAuth, resp.Header.Get(fiber.HeaderWWWAuthenticate))
+		})
+	}
+}
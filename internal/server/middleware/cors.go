@@ -0,0 +1,33 @@
+package middleware
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/LigeronAhill/luxcarpets-go/pkg/datastar"
+	"github.com/gofiber/fiber/v2"
+	"github.com/gofiber/fiber/v2/middleware/cors"
+)
+
+// CORS разрешает межсайтовые запросы только с перечисленных адресов (схема и хост, без пути).
+// Разрешенным сайтам передаются cookie и заголовок токена CSRF; с пустым списком
+// заголовки CORS не отправляются и браузер применяет политику одного источника.
+func CORS(origins []string) fiber.Handler {
+	if len(origins) == 0 {
+		return func(c *fiber.Ctx) error {
+			return c.Next()
+		}
+	}
+	return cors.New(cors.Config{
+		AllowOrigins: strings.Join(origins, ","),
+		AllowMethods: strings.Join([]string{
+			fiber.MethodGet, fiber.MethodHead, fiber.MethodPost, fiber.MethodPut, fiber.MethodPatch, fiber.MethodDelete,
+		}, ","),
+		AllowHeaders: strings.Join([]string{
+			fiber.HeaderAuthorization, fiber.HeaderContentType, CSRFHeader, datastar.HeaderRequest,
+		}, ","),
+		// Fiber запрещает cookie для "*": любой сайт получил бы доступ от имени пользователя
+		AllowCredentials: !slices.Contains(origins, "*"),
+		MaxAge:           600,
+	})
+}
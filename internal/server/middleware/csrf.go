@@ -0,0 +1,150 @@
+package middleware
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Имена, под которыми передается токен CSRF
+const (
+	CSRFCookieName = "lux_csrf"     // Cookie с токеном
+	CSRFHeader     = "X-CSRF-Token" // Заголовок запросов JavaScript и Datastar
+	CSRFFormField  = "csrf_token"   // Поле HTML-формы; совпадает с components.CSRFFieldName
+)
+
+// csrfKey - ключ токена CSRF текущего запроса в c.Locals
+const csrfKey = "csrf"
+
+// ErrCSRF - ответ на изменяющий запрос без действующего токена
+var ErrCSRF = fiber.NewError(fiber.StatusForbidden, "invalid csrf token")
+
+// CSRFConfig - настройки защиты от CSRF
+type CSRFConfig struct {
+	Key    []byte // Секрет, из которого выводится ключ подписи токенов (не короче 32 байт)
+	Secure bool   // Выдавать cookie только для HTTPS
+}
+
+// csrfState - состояние защиты для запроса; токен выдается лениво, при первом обращении CSRFToken
+type csrfState struct {
+	cfg   CSRFConfig
+	token string
+}
+
+// CSRF защищает изменяющие запросы (POST, PUT, PATCH, DELETE) подписанным токеном
+// по схеме double-submit: токен лежит в cookie и должен быть повторен в заголовке X-CSRF-Token
+// или в поле формы csrf_token. Подпись токена включает cookie сессии, поэтому токен
+// из чужой сессии или выданный до входа не принимается.
+//
+// Запросы с заголовком Authorization не проверяются: токен API не отправляется браузером
+// автоматически, и подделать такой запрос со стороннего сайта нельзя.
+//
+// Когда обработчик открывает или закрывает сессию, cookie с токеном удаляется:
+// следующая страница получит токен для новой сессии.
+func CSRF(cfg CSRFConfig) fiber.Handler {
+	cfg.Key = csrfSigningKey(cfg.Key)
+	return func(c *fiber.Ctx) error {
+		state := &csrfState{cfg: cfg}
+		c.Locals(csrfKey, state)
+		session := c.Cookies(SessionCookieName)
+		if cookie := c.Cookies(CSRFCookieName); validCSRFToken(cfg.Key, session, cookie) {
+			state.token = cookie
+		}
+
+		if !safeMethod(c.Method()) && c.Get(fiber.HeaderAuthorization) == "" {
+			submitted := c.Get(CSRFHeader)
+			if submitted == "" {
+				submitted = c.FormValue(CSRFFormField)
+			}
+			if state.token == "" || subtle.ConstantTimeCompare([]byte(submitted), []byte(state.token)) != 1 {
+				return ErrCSRF
+			}
+		}
+
+		err := c.Next()
+		if sessionChanged(c) {
+			c.ClearCookie(CSRFCookieName)
+		}
+		return err
+	}
+}
+
+// CSRFToken возвращает токен для форм и заголовков текущего запроса. Если у браузера нет
+// действующего токена, выдается новый и записывается в cookie. Без middleware CSRF
+// возвращает пустую строку.
+func CSRFToken(c *fiber.Ctx) string {
+	state, ok := c.Locals(csrfKey).(*csrfState)
+	if !ok {
+		return ""
+	}
+	if state.token == "" {
+		state.token = newCSRFToken(state.cfg.Key, c.Cookies(SessionCookieName))
+		c.Cookie(csrfCookie(state.token, state.cfg.Secure))
+	}
+	return state.token
+}
+
+// csrfSigningKey выводит ключ подписи из переданного секрета, чтобы не использовать один ключ для разных целей
+func csrfSigningKey(secret []byte) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte("luxcarpets csrf"))
+	return mac.Sum(nil)
+}
+
+// newCSRFToken возвращает токен "<случайное значение>.<подпись>"
+func newCSRFToken(key []byte, session string) string {
+	random := make([]byte, 32)
+	_, _ = rand.Read(random)
+	value := base64.RawURLEncoding.EncodeToString(random)
+	return value + "." + csrfSignature(key, session, value)
+}
+
+// validCSRFToken проверяет подпись токена для сессии
+func validCSRFToken(key []byte, session, token string) bool {
+	value, signature, ok := strings.Cut(token, ".")
+	if !ok || value == "" {
+		return false
+	}
+	return hmac.Equal([]byte(signature), []byte(csrfSignature(key, session, value)))
+}
+
+func csrfSignature(key []byte, session, value string) string {
+	mac := hmac.New(sha256.New, key)
+	mac.Write([]byte("csrf\x00"))
+	mac.Write([]byte(session))
+	mac.Write([]byte{0})
+	mac.Write([]byte(value))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// sessionChanged проверяет, что ответ устанавливает или удаляет cookie сессии
+func sessionChanged(c *fiber.Ctx) bool {
+	return c.Response().Header.PeekCookie(SessionCookieName) != nil
+}
+
+// safeMethod проверяет, что метод не изменяет состояние (RFC 9110, 9.2.1)
+func safeMethod(method string) bool {
+	switch method {
+	case fiber.MethodGet, fiber.MethodHead, fiber.MethodOptions, fiber.MethodTrace:
+		return true
+	}
+	return false
+}
+
+// csrfCookie возвращает cookie с токеном. Cookie сессионная
+// и недоступна из JavaScript: страница получает токен из meta-тега csrf-token или скрытого поля формы.
+func csrfCookie(token string, secure bool) *fiber.Cookie {
+	return &fiber.Cookie{
+		Name:     CSRFCookieName,
+		Value:    token,
+		Path:     "/",
+		Secure:   secure,
+		HTTPOnly: true,
+		SameSite: fiber.CookieSameSiteLaxMode,
+	}
+}
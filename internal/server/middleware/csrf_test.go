@@ -0,0 +1,147 @@
+package middleware
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testCSRFKey = bytes.Repeat([]byte{7}, 32)
+
+// testSigningKey - ключ, которым middleware подписывает токены при секрете testCSRFKey
+var testSigningKey = csrfSigningKey(testCSRFKey)
+
+func csrfApp() *fiber.App {
+	app := fiber.New()
+	app.Use(CSRF(CSRFConfig{Key: testCSRFKey}))
+	app.Get("/form", func(c *fiber.Ctx) error {
+		return c.SendString(CSRFToken(c))
+	})
+	app.Post("/submit", func(c *fiber.Ctx) error {
+		return c.SendString("ok")
+	})
+	app.Post("/login", func(c *fiber.Ctx) error {
+		c.Cookie(&fiber.Cookie{Name: SessionCookieName, Value: "new-session", Path: "/", Expires: time.Now().Add(time.Hour)})
+		return c.SendString("ok")
+	})
+	return app
+}
+
+func TestCSRF(t *testing.T) {
+	token := newCSRFToken(testSigningKey, "session")
+	guestToken := newCSRFToken(testSigningKey, "")
+	rawKeyToken := newCSRFToken(testCSRFKey, "session")
+	form := url.Values{CSRFFormField: {token}}.Encode()
+
+	tests := []struct {
+		name           string
+		session        string
+		cookie         string
+		header         string
+		authorization  string
+		form           string
+		expectedStatus int
+	}{
+		{"токен в заголовке", "session", token, token, "", "", fiber.StatusOK},
+		{"токен в поле формы", "session", token, "", "", form, fiber.StatusOK},
+		{"без токена", "session", token, "", "", "", fiber.StatusForbidden},
+		{"без cookie", "session", "", token, "", "", fiber.StatusForbidden},
+		{"токен не совпадает с cookie", "session", token, newCSRFToken(testSigningKey, "session"), "", "", fiber.StatusForbidden},
+		{"токен другой сессии", "other", token, token, "", "", fiber.StatusForbidden},
+		{"токен гостя после входа", "session", guestToken, guestToken, "", "", fiber.StatusForbidden},
+		{"поддельная подпись", "session", "abc.def", "abc.def", "", "", fiber.StatusForbidden},
+		{"подпись исходным секретом", "session", rawKeyToken, rawKeyToken, "", "", fiber.StatusForbidden},
+		{"гость", "", guestToken, guestToken, "", "", fiber.StatusOK},
+		{"запрос с токеном API", "", "", "", "Bearer good", "", fiber.StatusOK},
+	}
+
+	app := csrfApp()
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(fiber.MethodPost, "/submit", strings.NewReader(tt.form))
+			if tt.form != "" {
+				req.Header.Set(fiber.HeaderContentType, fiber.MIMEApplicationForm)
+			}
+			if tt.session != "" {
+				req.AddCookie(&http.Cookie{Name: SessionCookieName, Value: tt.session})
+			}
+			if tt.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: CSRFCookieName, Value: tt.cookie})
+			}
+			if tt.header != "" {
+				req.Header.Set(CSRFHeader, tt.header)
+			}
+			if tt.authorization != "" {
+				req.Header.Set(fiber.HeaderAuthorization, tt.authorization)
+			}
+
+			resp, err := app.Test(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			assert.Equal(t, tt.expectedStatus, resp.StatusCode)
+		})
+	}
+}
+
+func TestCSRFToken_IssuedOnce(t *testing.T) {
+	app := csrfApp()
+
+	req := httptest.NewRequest(fiber.MethodGet, "/form", nil)
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	issued := csrfCookieFrom(resp)
+	require.NotNil(t, issued)
+	assert.Equal(t, string(body), issued.Value)
+	assert.True(t, issued.HttpOnly)
+	assert.True(t, validCSRFToken(testSigningKey, "", issued.Value))
+
+	// Действующий токен переиспользуется без новой cookie
+	req = httptest.NewRequest(fiber.MethodGet, "/form", nil)
+	req.AddCookie(&http.Cookie{Name: CSRFCookieName, Value: issued.Value})
+	resp, err = app.Test(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err = io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, issued.Value, string(body))
+	assert.Nil(t, csrfCookieFrom(resp))
+}
+
+func TestCSRF_ClearedOnSessionChange(t *testing.T) {
+	app := csrfApp()
+	token := newCSRFToken(testSigningKey, "")
+
+	req := httptest.NewRequest(fiber.MethodPost, "/login", nil)
+	req.AddCookie(&http.Cookie{Name: CSRFCookieName, Value: token})
+	req.Header.Set(CSRFHeader, token)
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+	cleared := csrfCookieFrom(resp)
+	require.NotNil(t, cleared)
+	assert.Empty(t, cleared.Value)
+}
+
+func csrfCookieFrom(resp *http.Response) *http.Cookie {
+	for _, c := range resp.Cookies() {
+		if c.Name == CSRFCookieName {
+			return c
+		}
+	}
+	return nil
+}
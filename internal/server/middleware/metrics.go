@@ -0,0 +1,52 @@
+package middleware
+
+import (
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/LigeronAhill/luxcarpets-go/internal/metrics"
+	"github.com/gofiber/fiber/v2"
+)
+
+// UnmatchedRoute - метка маршрута для запросов, которые не дошли до обработчика маршрута:
+// страница не найдена, запрос отклонен middleware (CSRF, CORS) и т. п.
+const UnmatchedRoute = "unmatched"
+
+// Metrics записывает время обработки запросов в metrics.HTTPRequestDuration.
+// Ошибку обработчика middleware сразу передает обработчику ошибок приложения,
+// чтобы учесть итоговый код ответа и время отрисовки страницы ошибки.
+// Подключается после RequestID и до Recover.
+func Metrics() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		start := time.Now()
+		if err := c.Next(); err != nil {
+			if handlerErr := c.App().ErrorHandler(c, err); handlerErr != nil {
+				_ = c.SendStatus(errorStatus(handlerErr))
+			}
+		}
+		status := strconv.Itoa(c.Response().StatusCode())
+		metrics.ObserveSince(metrics.HTTPRequestDuration.WithLabelValues(c.Method(), routePattern(c), status), start)
+		return nil
+	}
+}
+
+// routePattern возвращает шаблон маршрута, обработавшего запрос, или UnmatchedRoute.
+// Общие middleware зарегистрированы с путем "/" и совпадают с любым адресом. Если последним
+// совпавшим маршрутом остался такой middleware, запрос не дошел до обработчика маршрута.
+func routePattern(c *fiber.Ctx) string {
+	route := c.Route().Path
+	if route == "/" && c.Path() != "/" {
+		return UnmatchedRoute
+	}
+	return route
+}
+
+// errorStatus возвращает код ответа для ошибки, которую не обработал обработчик ошибок
+func errorStatus(err error) int {
+	var fiberErr *fiber.Error
+	if errors.As(err, &fiberErr) {
+		return fiberErr.Code
+	}
+	return fiber.StatusInternalServerError
+}
@@ -0,0 +1,65 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"strconv"
+	"testing"
+
+	"github.com/LigeronAhill/luxcarpets-go/internal/metrics"
+	"github.com/gofiber/fiber/v2"
+	"github.com/prometheus/client_golang/prometheus"
+	dto "github.com/prometheus/client_model/go"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// requestCount возвращает число запросов, учтенных в metrics.HTTPRequestDuration с заданными метками
+func requestCount(t *testing.T, method, route string, status int) uint64 {
+	t.Helper()
+	var m dto.Metric
+	histogram := metrics.HTTPRequestDuration.WithLabelValues(method, route, strconv.Itoa(status)).(prometheus.Histogram)
+	require.NoError(t, histogram.Write(&m))
+	return m.GetHistogram().GetSampleCount()
+}
+
+func TestMetrics(t *testing.T) {
+	var handled int
+	app := fiber.New(fiber.Config{
+		ErrorHandler: func(c *fiber.Ctx, err error) error {
+			handled++
+			return fiber.DefaultErrorHandler(c, err)
+		},
+	})
+	app.Use(Metrics())
+	app.Get("/metrics-test/products/:id", func(c *fiber.Ctx) error {
+		if c.Params("id") == "missing" {
+			return fiber.ErrNotFound
+		}
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	tests := []struct {
+		name           string
+		path           string
+		expectedRoute  string
+		expectedStatus int
+	}{
+		{"маршрут с параметром", "/metrics-test/products/42", "/metrics-test/products/:id", fiber.StatusOK},
+		{"ошибка обработчика", "/metrics-test/products/missing", "/metrics-test/products/:id", fiber.StatusNotFound},
+		{"неизвестный адрес", "/metrics-test/unknown", UnmatchedRoute, fiber.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := requestCount(t, fiber.MethodGet, tt.expectedRoute, tt.expectedStatus)
+
+			resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, tt.path, nil))
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			assert.Equal(t, tt.expectedStatus, resp.StatusCode)
+			assert.Equal(t, before+1, requestCount(t, fiber.MethodGet, tt.expectedRoute, tt.expectedStatus))
+		})
+	}
+	// Ошибки обрабатываются один раз - в Metrics, а не повторно приложением
+	assert.Equal(t, 2, handled)
+}
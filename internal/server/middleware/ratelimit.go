@@ -0,0 +1,87 @@
+package middleware
+
+import (
+	"context"
+	"log/slog"
+	"strconv"
+	"time"
+
+	"github.com/LigeronAhill/luxcarpets-go/internal/service"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Заголовки ограничения частоты (draft-ietf-httpapi-ratelimit-headers)
+const (
+	HeaderRateLimitLimit     = "RateLimit-Limit"
+	HeaderRateLimitRemaining = "RateLimit-Remaining"
+	HeaderRateLimitReset     = "RateLimit-Reset"
+	HeaderRateLimitPolicy    = "RateLimit-Policy"
+)
+
+// RateLimiter учитывает запросы и решает, пропускать ли их.
+// Реализация: service.RateLimiter.
+type RateLimiter interface {
+	Allow(ctx context.Context, limit service.RateLimit, key string) (service.RateLimitResult, error)
+}
+
+// KeyFunc возвращает ключ, по которому считаются запросы
+type KeyFunc func(c *fiber.Ctx) string
+
+// KeyByIP считает запросы по IP-адресу клиента. Подходит для маршрутов без входа.
+func KeyByIP(c *fiber.Ctx) string {
+	return "ip:" + c.IP()
+}
+
+// KeyByClient считает запросы по токену API или пользователю, а для гостей - по IP-адресу.
+// Должен подключаться после Authenticate или OptionalSession.
+func KeyByClient(c *fiber.Ctx) string {
+	principal := PrincipalFrom(c)
+	switch {
+	case principal == nil:
+		return KeyByIP(c)
+	case principal.Token != nil:
+		return "token:" + principal.Token.ID.String()
+	default:
+		return "user:" + principal.User.ID.String()
+	}
+}
+
+// RateLimit ограничивает частоту запросов по политике limit. Ответы содержат заголовки
+// RateLimit-Limit, RateLimit-Remaining, RateLimit-Reset и RateLimit-Policy; на превышение
+// возвращается 429 с Retry-After. Выключенная политика или limiter = nil пропускают все запросы.
+//
+// Если хранилище ограничителя недоступно, запрос пропускается, а ошибка логируется:
+// сбой учета не должен закрывать сайт.
+func RateLimit(limiter RateLimiter, limit service.RateLimit, key KeyFunc) fiber.Handler {
+	if limiter == nil || !limit.Enabled() {
+		return func(c *fiber.Ctx) error {
+			return c.Next()
+		}
+	}
+	policy := strconv.Itoa(limit.Limit) + ";w=" + strconv.Itoa(int(limit.Period.Seconds()))
+	return func(c *fiber.Ctx) error {
+		result, err := limiter.Allow(c.UserContext(), limit, key(c))
+		if err != nil {
+			slog.ErrorContext(c.UserContext(), "Rate limit check failed",
+				slog.String("policy", limit.Name),
+				slog.String("path", c.Path()),
+				slog.String("error", err.Error()),
+			)
+			return c.Next()
+		}
+		c.Set(HeaderRateLimitLimit, strconv.Itoa(result.Limit))
+		c.Set(HeaderRateLimitRemaining, strconv.Itoa(result.Remaining))
+		c.Set(HeaderRateLimitReset, seconds(result.Reset))
+		c.Set(HeaderRateLimitPolicy, policy)
+		if !result.Allowed {
+			c.Set(fiber.HeaderRetryAfter, seconds(result.RetryAfter))
+			return fiber.NewError(fiber.StatusTooManyRequests, "too many requests")
+		}
+		return c.Next()
+	}
+}
+
+// seconds форматирует длительность в целых секундах с округлением вверх
+func seconds(d time.Duration) string {
+	return strconv.Itoa(int((d + time.Second - 1) / time.Second))
+}
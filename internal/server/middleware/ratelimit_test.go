@@ -0,0 +1,146 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/LigeronAhill/luxcarpets-go/internal/database/types"
+	"github.com/LigeronAhill/luxcarpets-go/internal/service"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubRateLimiter возвращает заданный результат и запоминает ключ последнего запроса
+type stubRateLimiter struct {
+	result service.RateLimitResult
+	err    error
+	key    string
+}
+
+func (s *stubRateLimiter) Allow(_ context.Context, _ service.RateLimit, key string) (service.RateLimitResult, error) {
+	s.key = key
+	return s.result, s.err
+}
+
+func TestRateLimit(t *testing.T) {
+	limit := service.RateLimit{Name: "auth", Limit: 10, Period: time.Minute}
+
+	tests := []struct {
+		name           string
+		limiter        *stubRateLimiter
+		expectedStatus int
+		expectedHeader map[string]string
+	}{
+		{
+			name: "запрос разрешен",
+			limiter: &stubRateLimiter{result: service.RateLimitResult{
+				Allowed: true, Limit: 10, Remaining: 7, Reset: 17500 * time.Millisecond,
+			}},
+			expectedStatus: fiber.StatusNoContent,
+			expectedHeader: map[string]string{
+				HeaderRateLimitLimit:     "10",
+				HeaderRateLimitRemaining: "7",
+				HeaderRateLimitReset:     "18",
+				HeaderRateLimitPolicy:    "10;w=60",
+				fiber.HeaderRetryAfter:   "",
+			},
+		},
+		{
+			name: "запас исчерпан",
+			limiter: &stubRateLimiter{result: service.RateLimitResult{
+				Limit: 10, Reset: time.Minute, RetryAfter: 5 * time.Second,
+			}},
+			expectedStatus: fiber.StatusTooManyRequests,
+			expectedHeader: map[string]string{
+				HeaderRateLimitLimit:     "10",
+				HeaderRateLimitRemaining: "0",
+				HeaderRateLimitReset:     "60",
+				fiber.HeaderRetryAfter:   "5",
+			},
+		},
+		{
+			name:           "хранилище недоступно",
+			limiter:        &stubRateLimiter{err: errors.New("connection refused")},
+			expectedStatus: fiber.StatusNoContent,
+			expectedHeader: map[string]string{
+				HeaderRateLimitLimit:   "",
+				fiber.HeaderRetryAfter: "",
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := fiber.New()
+			app.Post("/login", RateLimit(tt.limiter, limit, KeyByIP), func(c *fiber.Ctx) error {
+				return c.SendStatus(fiber.StatusNoContent)
+			})
+
+			resp, err := app.Test(httptest.NewRequest(fiber.MethodPost, "/login", nil))
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			assert.Equal(t, tt.expectedStatus, resp.StatusCode)
+			assert.Equal(t, "ip:0.0.0.0", tt.limiter.key)
+			for name, value := range tt.expectedHeader {
+				assert.Equal(t, value, resp.Header.Get(name), name)
+			}
+		})
+	}
+}
+
+func TestRateLimit_Disabled(t *testing.T) {
+	limiter := &stubRateLimiter{}
+	app := fiber.New()
+	app.Get("/", RateLimit(limiter, service.RateLimit{Name: "api"}, KeyByIP), func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusNoContent)
+	})
+
+	resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/", nil))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Equal(t, fiber.StatusNoContent, resp.StatusCode)
+	assert.Empty(t, limiter.key)
+	assert.Empty(t, resp.Header.Get(HeaderRateLimitLimit))
+}
+
+func TestKeyByClient(t *testing.T) {
+	user := &types.User{ID: uuid.New()}
+	token := &types.APIToken{ID: uuid.New()}
+
+	tests := []struct {
+		name      string
+		principal *service.Principal
+		expected  string
+	}{
+		{"гость", nil, "ip:0.0.0.0"},
+		{"сессия", &service.Principal{User: user}, "user:" + user.ID.String()},
+		{"токен API", &service.Principal{User: user, Token: token}, "token:" + token.ID.String()},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var key string
+			app := fiber.New()
+			app.Get("/", func(c *fiber.Ctx) error {
+				if tt.principal != nil {
+					c.Locals(principalKey, tt.principal)
+				}
+				key = KeyByClient(c)
+				return c.SendStatus(fiber.StatusNoContent)
+			})
+
+			resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/", nil))
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			assert.Equal(t, tt.expected, key)
+		})
+	}
+}
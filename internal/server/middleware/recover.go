@@ -0,0 +1,30 @@
+package middleware
+
+import (
+	"fmt"
+	"log/slog"
+	"runtime/debug"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// Recover перехватывает панику в обработчиках, логирует ее со стеком вызовов и возвращает
+// fiber.ErrInternalServerError: ErrorHandler приложения покажет страницу 500 или ответит JSON.
+// Подключается после RequestID, Tracing и Metrics и до остальных middleware: паника попадает в лог
+// с идентификатором запроса, а трассировка и метрики учитывают ответ 500 вместо оборванного запроса.
+func Recover() fiber.Handler {
+	return func(c *fiber.Ctx) (err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				slog.ErrorContext(c.UserContext(), "Panic recovered",
+					slog.String("method", c.Method()),
+					slog.String("path", c.Path()),
+					slog.String("panic", fmt.Sprint(r)),
+					slog.String("stack", string(debug.Stack())),
+				)
+				err = fiber.ErrInternalServerError
+			}
+		}()
+		return c.Next()
+	}
+}
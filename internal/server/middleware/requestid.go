@@ -0,0 +1,22 @@
+package middleware
+
+import (
+	"github.com/LigeronAhill/luxcarpets-go/pkg/requestid"
+	"github.com/gofiber/fiber/v2"
+)
+
+// RequestID присваивает запросу идентификатор: принимает X-Request-ID от прокси, если он корректен,
+// или создает новый. Идентификатор возвращается в ответе и передается в контексте запроса
+// (c.UserContext()) - его получают логи и соединения с Postgres.
+// Должен подключаться первым, чтобы идентификатор был и в логах остальных middleware.
+func RequestID() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		id := c.Get(requestid.Header)
+		if !requestid.Valid(id) {
+			id = requestid.New()
+		}
+		c.Set(requestid.Header, id)
+		c.SetUserContext(requestid.WithID(c.UserContext(), id))
+		return c.Next()
+	}
+}
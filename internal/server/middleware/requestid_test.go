@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"io"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/LigeronAhill/luxcarpets-go/pkg/requestid"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestID(t *testing.T) {
+	tests := []struct {
+		name     string
+		header   string
+		accepted bool
+	}{
+		{"новый идентификатор", "", false},
+		{"идентификатор от прокси", "lb-1:0af7651916cd43dd", true},
+		{"некорректный заменяется", "abc\r\nSet-Cookie: x", false},
+	}
+
+	app := fiber.New()
+	app.Use(RequestID())
+	app.Get("/", func(c *fiber.Ctx) error {
+		return c.SendString(requestid.FromContext(c.UserContext()))
+	})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(fiber.MethodGet, "/", nil)
+			if tt.header != "" {
+				req.Header.Set(requestid.Header, tt.header)
+			}
+
+			resp, err := app.Test(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+			body, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+
+			id := resp.Header.Get(requestid.Header)
+			assert.True(t, requestid.Valid(id))
+			assert.Equal(t, id, string(body))
+			if tt.accepted {
+				assert.Equal(t, tt.header, id)
+			} else {
+				assert.NotEqual(t, tt.header, id)
+			}
+		})
+	}
+}
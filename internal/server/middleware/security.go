@@ -0,0 +1,109 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/a-h/templ"
+	"github.com/gofiber/fiber/v2"
+)
+
+// cspKey - ключ политики CSP текущего запроса в c.Locals
+const cspKey = "csp"
+
+// PermissionsPolicy запрещает странице API устройств и платежей: магазину они не нужны
+const PermissionsPolicy = "accelerometer=(), camera=(), geolocation=(), gyroscope=(), magnetometer=(), microphone=(), payment=(), usb=()"
+
+// SecurityHeadersConfig - настройки заголовков безопасности
+type SecurityHeadersConfig struct {
+	HSTSMaxAge   time.Duration // Срок Strict-Transport-Security; 0 - заголовок не отправляется (без HTTPS)
+	ImageSources []string      // Дополнительные источники img-src, например адрес хранилища файлов
+}
+
+// cspState - политика CSP текущего запроса
+type cspState struct {
+	cfg   SecurityHeadersConfig
+	nonce string
+}
+
+// SecurityHeaders добавляет к ответам заголовки безопасности: Content-Security-Policy,
+// Strict-Transport-Security, Referrer-Policy, Permissions-Policy и защиту от встраивания во фреймы.
+//
+// Для каждого запроса создается nonce: встроенные скрипты выполняются, только если у них
+// есть атрибут nonce с этим значением. Nonce передается в контекст templ (templ.WithNonce),
+// поэтому скрипты, которые выводит templ, получают его автоматически; в остальных шаблонах
+// значение доступно через templ.GetNonce.
+//
+// Скрипты загружаются только с собственного адреса сайта. Вычисление строк как кода ('unsafe-eval')
+// по умолчанию запрещено; странице, которой оно нужно, его разрешает AllowScriptEval.
+func SecurityHeaders(cfg SecurityHeadersConfig) fiber.Handler {
+	hsts := ""
+	if cfg.HSTSMaxAge > 0 {
+		hsts = "max-age=" + strconv.Itoa(int(cfg.HSTSMaxAge.Seconds())) + "; includeSubDomains"
+	}
+	return func(c *fiber.Ctx) error {
+		nonce := newNonce()
+		c.Locals(cspKey, &cspState{cfg: cfg, nonce: nonce})
+		c.SetUserContext(templ.WithNonce(c.UserContext(), nonce))
+
+		c.Set(fiber.HeaderContentSecurityPolicy, contentSecurityPolicy(cfg, nonce, false))
+		c.Set(fiber.HeaderXContentTypeOptions, "nosniff")
+		c.Set(fiber.HeaderXFrameOptions, "DENY")
+		c.Set(fiber.HeaderReferrerPolicy, "strict-origin-when-cross-origin")
+		c.Set(fiber.HeaderPermissionsPolicy, PermissionsPolicy)
+		c.Set("Cross-Origin-Opener-Policy", "same-origin")
+		if hsts != "" {
+			c.Set(fiber.HeaderStrictTransportSecurity, hsts)
+		}
+		return c.Next()
+	}
+}
+
+// CSPNonce возвращает nonce политики CSP текущего запроса
+func CSPNonce(c *fiber.Ctx) string {
+	if state, ok := c.Locals(cspKey).(*cspState); ok {
+		return state.nonce
+	}
+	return ""
+}
+
+// AllowScriptEval добавляет в политику CSP ответа 'unsafe-eval'. Нужно только страницам
+// с Datastar: выражения его атрибутов вычисляются через new Function. Без middleware
+// SecurityHeaders ничего не делает.
+func AllowScriptEval(c *fiber.Ctx) {
+	if state, ok := c.Locals(cspKey).(*cspState); ok {
+		c.Set(fiber.HeaderContentSecurityPolicy, contentSecurityPolicy(state.cfg, state.nonce, true))
+	}
+}
+
+// contentSecurityPolicy собирает политику: все ресурсы - только с собственного адреса,
+// скрипты - дополнительно с nonce (и с 'unsafe-eval', если eval), изображения - из ImageSources и data:
+func contentSecurityPolicy(cfg SecurityHeadersConfig, nonce string, eval bool) string {
+	scriptSources := []string{"'self'", "'nonce-" + nonce + "'"}
+	if eval {
+		scriptSources = append(scriptSources, "'unsafe-eval'")
+	}
+	directives := []string{
+		"default-src 'self'",
+		"script-src " + strings.Join(scriptSources, " "),
+		"style-src 'self'",
+		"img-src " + strings.Join(append([]string{"'self'", "data:"}, cfg.ImageSources...), " "),
+		"connect-src 'self'",
+		"font-src 'self'",
+		"object-src 'none'",
+		"base-uri 'self'",
+		"form-action 'self'",
+		"frame-ancestors 'none'",
+	}
+	return strings.Join(directives, "; ")
+}
+
+// newNonce возвращает 128 случайных бит в base64
+func newNonce() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return base64.StdEncoding.EncodeToString(b)
+}
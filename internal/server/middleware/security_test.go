@@ -0,0 +1,157 @@
+package middleware
+
+import (
+	"errors"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/LigeronAhill/luxcarpets-go/pkg/datastar"
+	"github.com/a-h/templ"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSecurityHeaders(t *testing.T) {
+	app := fiber.New()
+	app.Use(SecurityHeaders(SecurityHeadersConfig{
+		HSTSMaxAge:   365 * 24 * time.Hour,
+		ImageSources: []string{"https://s3.example.com"},
+	}))
+	app.Get("/", func(c *fiber.Ctx) error {
+		// Nonce доступен и обработчику, и шаблонам templ
+		return c.SendString(CSPNonce(c) + "|" + templ.GetNonce(c.UserContext()))
+	})
+
+	resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/", nil))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	nonce, templNonce, _ := strings.Cut(string(body), "|")
+	require.NotEmpty(t, nonce)
+	assert.Equal(t, nonce, templNonce)
+	assert.Equal(t, "default-src 'self'; "+
+		"script-src 'self' 'nonce-"+nonce+"'; "+
+		"style-src 'self'; "+
+		"img-src 'self' data: https://s3.example.com; "+
+		"connect-src 'self'; font-src 'self'; object-src 'none'; base-uri 'self'; form-action 'self'; frame-ancestors 'none'",
+		resp.Header.Get(fiber.HeaderContentSecurityPolicy))
+	assert.Equal(t, "max-age=31536000; includeSubDomains", resp.Header.Get(fiber.HeaderStrictTransportSecurity))
+	assert.Equal(t, "strict-origin-when-cross-origin", resp.Header.Get(fiber.HeaderReferrerPolicy))
+	assert.Equal(t, PermissionsPolicy, resp.Header.Get(fiber.HeaderPermissionsPolicy))
+	assert.Equal(t, "nosniff", resp.Header.Get(fiber.HeaderXContentTypeOptions))
+	assert.Equal(t, "DENY", resp.Header.Get(fiber.HeaderXFrameOptions))
+
+	// Каждый запрос получает новый nonce
+	resp, err = app.Test(httptest.NewRequest(fiber.MethodGet, "/", nil))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.NotContains(t, resp.Header.Get(fiber.HeaderContentSecurityPolicy), nonce)
+}
+
+// Каталог обновляет адрес страницы ответом Datastar; политика разрешает только скрипты с nonce,
+// поэтому адрес должен передаваться выражением атрибута, а не элементом script
+func TestSecurityHeaders_DatastarHistory(t *testing.T) {
+	app := fiber.New()
+	app.Use(SecurityHeaders(SecurityHeadersConfig{}))
+	app.Get("/catalog", func(c *fiber.Ctx) error {
+		AllowScriptEval(c)
+		c.Set(fiber.HeaderContentType, datastar.ContentType)
+		return datastar.NewWriter(c.Response().BodyWriter()).PushURL("/catalog?page=2")
+	})
+
+	resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/catalog", nil))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	var scriptSrc []string
+	for directive := range strings.SplitSeq(resp.Header.Get(fiber.HeaderContentSecurityPolicy), ";") {
+		if fields := strings.Fields(directive); len(fields) > 0 && fields[0] == "script-src" {
+			scriptSrc = fields[1:]
+		}
+	}
+	assert.NotContains(t, scriptSrc, "'unsafe-inline'")
+	assert.NotContains(t, string(body), "<script")
+	// Выражения атрибутов Datastar вычисляются через new Function
+	assert.Contains(t, scriptSrc, "'unsafe-eval'")
+	assert.Contains(t, string(body), `data-effect="window.history.pushState({}, &#34;&#34;, &#34;/catalog?page=2&#34;); el.remove()"`)
+}
+
+func TestSecurityHeaders_WithoutHSTS(t *testing.T) {
+	app := fiber.New()
+	app.Use(SecurityHeaders(SecurityHeadersConfig{}))
+	app.Get("/", func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusNoContent) })
+
+	resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/", nil))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	assert.Empty(t, resp.Header.Get(fiber.HeaderStrictTransportSecurity))
+}
+
+func TestCORS(t *testing.T) {
+	tests := []struct {
+		name           string
+		origins        []string
+		origin         string
+		expectedOrigin string
+		credentials    bool
+	}{
+		{"разрешенный сайт", []string{"https://admin.luxcarpets.ru"}, "https://admin.luxcarpets.ru", "https://admin.luxcarpets.ru", true},
+		{"чужой сайт", []string{"https://admin.luxcarpets.ru"}, "https://evil.example", "", false},
+		{"CORS отключен", nil, "https://admin.luxcarpets.ru", "", false},
+		{"любой сайт без cookie", []string{"*"}, "https://evil.example", "*", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			app := fiber.New()
+			app.Use(CORS(tt.origins))
+			app.Post("/api/v1/cart", func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusNoContent) })
+
+			req := httptest.NewRequest(fiber.MethodOptions, "/api/v1/cart", nil)
+			req.Header.Set(fiber.HeaderOrigin, tt.origin)
+			req.Header.Set(fiber.HeaderAccessControlRequestMethod, fiber.MethodPost)
+			req.Header.Set(fiber.HeaderAccessControlRequestHeaders, CSRFHeader)
+			resp, err := app.Test(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			assert.Equal(t, tt.expectedOrigin, resp.Header.Get(fiber.HeaderAccessControlAllowOrigin))
+			assert.Equal(t, tt.credentials, resp.Header.Get(fiber.HeaderAccessControlAllowCredentials) == "true")
+			if tt.expectedOrigin != "" {
+				assert.Contains(t, resp.Header.Get(fiber.HeaderAccessControlAllowHeaders), CSRFHeader)
+			}
+		})
+	}
+}
+
+func TestRecover(t *testing.T) {
+	var handled error
+	app := fiber.New(fiber.Config{
+		ErrorHandler: func(c *fiber.Ctx, err error) error {
+			handled = err
+			return c.Status(fiber.StatusInternalServerError).SendString("страница 500")
+		},
+	})
+	app.Use(Recover())
+	app.Get("/", func(c *fiber.Ctx) error {
+		panic("nil map")
+	})
+
+	resp, err := app.Test(httptest.NewRequest(fiber.MethodGet, "/", nil))
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+
+	assert.Equal(t, fiber.StatusInternalServerError, resp.StatusCode)
+	assert.Equal(t, "страница 500", string(body))
+	assert.True(t, errors.Is(handled, fiber.ErrInternalServerError))
+}
@@ -0,0 +1,78 @@
+package middleware
+
+import (
+	"context"
+	"errors"
+
+	"github.com/LigeronAhill/luxcarpets-go/internal/service"
+	"github.com/gofiber/fiber/v2"
+)
+
+// SessionCookieName - имя cookie с токеном сессии браузера
+const SessionCookieName = "lux_session"
+
+// SessionAuthenticator проверяет токен сессии из cookie и возвращает клиента.
+// Реализация: service.SessionsService.
+type SessionAuthenticator interface {
+	Authenticate(ctx context.Context, token string) (*service.Principal, error)
+}
+
+// Authenticate принимает токен API из заголовка Authorization или сессию браузера из cookie.
+// Заголовок Authorization имеет приоритет: если он передан, cookie не проверяется.
+func Authenticate(tokens TokenAuthenticator, sessions SessionAuthenticator) fiber.Handler {
+	bearer := BearerAuth(tokens)
+	return func(c *fiber.Ctx) error {
+		cookie := c.Cookies(SessionCookieName)
+		if c.Get(fiber.HeaderAuthorization) != "" || cookie == "" {
+			return bearer(c)
+		}
+		principal, err := sessions.Authenticate(c.UserContext(), cookie)
+		if err != nil {
+			if errors.Is(err, service.ErrInvalidSession) {
+				c.ClearCookie(SessionCookieName)
+				return fiber.NewError(fiber.StatusUnauthorized, err.Error())
+			}
+			return err
+		}
+		c.Locals(principalKey, principal)
+		return c.Next()
+	}
+}
+
+// OptionalSession сохраняет клиента в контексте запроса, если у браузера есть действующая сессия,
+// и пропускает запрос без клиента в остальных случаях. Используется для HTML-страниц,
+// которые доступны гостям, но показывают текущего пользователя в шапке.
+func OptionalSession(sessions SessionAuthenticator) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		cookie := c.Cookies(SessionCookieName)
+		if cookie == "" {
+			return c.Next()
+		}
+		principal, err := sessions.Authenticate(c.UserContext(), cookie)
+		if err != nil {
+			if !errors.Is(err, service.ErrInvalidSession) {
+				return err
+			}
+			c.ClearCookie(SessionCookieName)
+			return c.Next()
+		}
+		c.Locals(principalKey, principal)
+		return c.Next()
+	}
+}
+
+// RequireSession пропускает только запросы из сессии браузера и отклоняет токены API.
+// Так токен интеграции, даже с одним правом catalog:read, не может просматривать, отзывать
+// и выпускать токены пользователя. Должен подключаться после Authenticate.
+func RequireSession() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		principal := PrincipalFrom(c)
+		if principal == nil {
+			return fiber.NewError(fiber.StatusUnauthorized, "authentication required")
+		}
+		if principal.Token != nil || principal.Session == nil {
+			return fiber.NewError(fiber.StatusForbidden, "browser session required")
+		}
+		return c.Next()
+	}
+}
@@ -0,0 +1,173 @@
+package middleware
+
+import (
+	"context"
+	"io"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/LigeronAhill/luxcarpets-go/internal/database/types"
+	"github.com/LigeronAhill/luxcarpets-go/internal/service"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubSessions принимает только сессию "session" с правами покупателя
+type stubSessions struct{}
+
+func (stubSessions) Authenticate(_ context.Context, token string) (*service.Principal, error) {
+	if token != "session" {
+		return nil, service.ErrInvalidSession
+	}
+	return &service.Principal{
+		User:    &types.User{ID: uuid.New(), Role: types.RoleCustomer},
+		Scopes:  []types.TokenScope{types.ScopeProfileRead},
+		Session: &types.Session{ID: uuid.New()},
+	}, nil
+}
+
+func TestAuthenticate(t *testing.T) {
+	tests := []struct {
+		name           string
+		authorization  string
+		cookie         string
+		expectedStatus int
+		expectedBody   string
+		clearsCookie   bool
+	}{
+		{"без учетных данных", "", "", fiber.StatusUnauthorized, "", false},
+		{"сессия", "", "session", fiber.StatusOK, "session", false},
+		{"неверная сессия", "", "expired", fiber.StatusUnauthorized, "", true},
+		{"токен API", "Bearer good", "", fiber.StatusOK, "token", false},
+		{"заголовок важнее cookie", "Bearer bad", "session", fiber.StatusUnauthorized, "", false},
+	}
+
+	app := fiber.New()
+	app.Get("/api/me", Authenticate(stubAuthenticator{}, stubSessions{}), func(c *fiber.Ctx) error {
+		if PrincipalFrom(c).Session != nil {
+			return c.SendString("session")
+		}
+		return c.SendString("token")
+	})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(fiber.MethodGet, "/api/me", nil)
+			if tt.authorization != "" {
+				req.Header.Set(fiber.HeaderAuthorization, tt.authorization)
+			}
+			if tt.cookie != "" {
+				req.Header.Set(fiber.HeaderCookie, SessionCookieName+"="+tt.cookie)
+			}
+
+			resp, err := app.Test(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			assert.Equal(t, tt.expectedStatus, resp.StatusCode)
+			if tt.expectedBody != "" {
+				body := make([]byte, len(tt.expectedBody))
+				_, _ = resp.Body.Read(body)
+				assert.Equal(t, tt.expectedBody, string(body))
+			}
+			cleared := false
+			for _, c := range resp.Cookies() {
+				if c.Name == SessionCookieName && c.Value == "" {
+					cleared = true
+				}
+			}
+			assert.Equal(t, tt.clearsCookie, cleared)
+		})
+	}
+}
+
+func TestOptionalSession(t *testing.T) {
+	tests := []struct {
+		name         string
+		cookie       string
+		expectedBody string
+		clearsCookie bool
+	}{
+		{"гость", "", "guest", false},
+		{"сессия", "session", "user", false},
+		{"неверная сессия - как гость", "expired", "guest", true},
+	}
+
+	app := fiber.New()
+	app.Get("/", OptionalSession(stubSessions{}), func(c *fiber.Ctx) error {
+		if PrincipalFrom(c) != nil {
+			return c.SendString("user")
+		}
+		return c.SendString("guest")
+	})
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(fiber.MethodGet, "/", nil)
+			if tt.cookie != "" {
+				req.Header.Set(fiber.HeaderCookie, SessionCookieName+"="+tt.cookie)
+			}
+
+			resp, err := app.Test(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			assert.Equal(t, fiber.StatusOK, resp.StatusCode)
+			body, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expectedBody, string(body))
+			cleared := false
+			for _, c := range resp.Cookies() {
+				if c.Name == SessionCookieName && c.Value == "" {
+					cleared = true
+				}
+			}
+			assert.Equal(t, tt.clearsCookie, cleared)
+		})
+	}
+}
+
+func TestRequireSession(t *testing.T) {
+	tests := []struct {
+		name           string
+		method         string
+		path           string
+		authorization  string
+		cookie         string
+		expectedStatus int
+	}{
+		{"список токенов из сессии", fiber.MethodGet, "/api/tokens", "", "session", fiber.StatusOK},
+		{"выпуск токена из сессии", fiber.MethodPost, "/api/tokens", "", "session", fiber.StatusOK},
+		{"отзыв токена из сессии", fiber.MethodDelete, "/api/tokens/1", "", "session", fiber.StatusOK},
+		{"список токенов токеном каталога", fiber.MethodGet, "/api/tokens", "Bearer good", "", fiber.StatusForbidden},
+		{"выпуск токена токеном каталога", fiber.MethodPost, "/api/tokens", "Bearer good", "", fiber.StatusForbidden},
+		{"отзыв токена токеном каталога", fiber.MethodDelete, "/api/tokens/1", "Bearer good", "", fiber.StatusForbidden},
+		{"без учетных данных", fiber.MethodGet, "/api/tokens", "", "", fiber.StatusUnauthorized},
+	}
+
+	app := fiber.New()
+	api := app.Group("/api", Authenticate(stubAuthenticator{}, stubSessions{}))
+	ok := func(c *fiber.Ctx) error {
+		return c.SendStatus(fiber.StatusOK)
+	}
+	api.Get("/tokens", RequireSession(), ok)
+	api.Post("/tokens", RequireSession(), ok)
+	api.Delete("/tokens/:id", RequireSession(), ok)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			if tt.authorization != "" {
+				req.Header.Set(fiber.HeaderAuthorization, tt.authorization)
+			}
+			if tt.cookie != "" {
+				req.Header.Set(fiber.HeaderCookie, SessionCookieName+"="+tt.cookie)
+			}
+
+			resp, err := app.Test(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			assert.Equal(t, tt.expectedStatus, resp.StatusCode)
+		})
+	}
+}
@@ -0,0 +1,87 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/LigeronAhill/luxcarpets-go/internal/tracing"
+	"github.com/LigeronAhill/luxcarpets-go/pkg/requestid"
+	"github.com/gofiber/fiber/v2"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.34.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// RequestIDAttribute - атрибут span с идентификатором запроса (X-Request-ID)
+const RequestIDAttribute = attribute.Key("http.request.id")
+
+// Tracing создает серверный span на каждый запрос и передает его обработчикам через UserContext.
+// Контекст трассировки вызывающей стороны берется из заголовков traceparent и tracestate.
+// Имя span - метод и шаблон маршрута ("GET /api/v1/profile/addresses/:id").
+//
+// Подключается после RequestID и до Metrics: к моменту завершения span ошибка обработчика
+// уже превращена в ответ и код ответа известен.
+func Tracing() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		ctx := otel.GetTextMapPropagator().Extract(c.UserContext(), fiberCarrier{c})
+		ctx, span := tracing.Start(ctx, c.Method(),
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(c.Method()),
+				semconv.URLPath(c.Path()),
+				semconv.URLScheme(c.Protocol()),
+				semconv.ServerAddress(c.Hostname()),
+				semconv.ClientAddress(c.IP()),
+				semconv.UserAgentOriginal(c.Get(fiber.HeaderUserAgent)),
+				RequestIDAttribute.String(requestid.FromContext(ctx)),
+			),
+		)
+		defer span.End()
+		c.SetUserContext(ctx)
+
+		err := c.Next()
+
+		route := routePattern(c)
+		if route != UnmatchedRoute {
+			span.SetAttributes(semconv.HTTPRoute(route))
+		}
+		span.SetName(c.Method() + " " + route)
+		status := c.Response().StatusCode()
+		if err != nil {
+			status = errorStatus(err)
+		}
+		span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+		// Ответы 4xx - ошибки клиента, а не сервера
+		if status >= fiber.StatusInternalServerError {
+			if err != nil {
+				span.RecordError(err)
+			}
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+		return err
+	}
+}
+
+// fiberCarrier читает заголовки запроса Fiber для propagation.TextMapPropagator
+type fiberCarrier struct {
+	c *fiber.Ctx
+}
+
+var _ propagation.TextMapCarrier = fiberCarrier{}
+
+func (f fiberCarrier) Get(key string) string {
+	return f.c.Get(key)
+}
+
+// Set не используется: контекст только извлекается из входящих запросов
+func (f fiberCarrier) Set(string, string) {}
+
+func (f fiberCarrier) Keys() []string {
+	keys := make([]string, 0)
+	f.c.Request().Header.VisitAll(func(key, _ []byte) {
+		keys = append(keys, string(key))
+	})
+	return keys
+}
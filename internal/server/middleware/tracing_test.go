@@ -0,0 +1,112 @@
+package middleware
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/LigeronAhill/luxcarpets-go/pkg/requestid"
+	"github.com/gofiber/fiber/v2"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.34.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// recordSpans направляет span глобального TracerProvider в память до конца теста
+func recordSpans(t *testing.T) *tracetest.SpanRecorder {
+	t.Helper()
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	previous, previousPropagator := otel.GetTracerProvider(), otel.GetTextMapPropagator()
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	t.Cleanup(func() {
+		otel.SetTracerProvider(previous)
+		otel.SetTextMapPropagator(previousPropagator)
+	})
+	return recorder
+}
+
+// spanAttributes возвращает атрибуты span в виде карты для сравнения
+func spanAttributes(span sdktrace.ReadOnlySpan) map[attribute.Key]attribute.Value {
+	attrs := make(map[attribute.Key]attribute.Value)
+	for _, kv := range span.Attributes() {
+		attrs[kv.Key] = kv.Value
+	}
+	return attrs
+}
+
+func TestTracing(t *testing.T) {
+	recorder := recordSpans(t)
+	app := fiber.New()
+	app.Use(RequestID(), Tracing(), Metrics())
+	app.Get("/tracing-test/products/:id", func(c *fiber.Ctx) error {
+		// Обработчик получает контекст с серверным span
+		assert.True(t, trace.SpanContextFromContext(c.UserContext()).IsValid())
+		switch c.Params("id") {
+		case "missing":
+			return fiber.ErrNotFound
+		case "broken":
+			return fiber.ErrInternalServerError
+		}
+		return c.SendStatus(fiber.StatusOK)
+	})
+
+	tests := []struct {
+		name           string
+		path           string
+		expectedName   string
+		expectedStatus int
+		expectedCode   codes.Code
+	}{
+		{"успешный запрос", "/tracing-test/products/42", "GET /tracing-test/products/:id", fiber.StatusOK, codes.Unset},
+		{"ошибка клиента", "/tracing-test/products/missing", "GET /tracing-test/products/:id", fiber.StatusNotFound, codes.Unset},
+		{"ошибка сервера", "/tracing-test/products/broken", "GET /tracing-test/products/:id", fiber.StatusInternalServerError, codes.Error},
+		{"неизвестный адрес", "/tracing-test/unknown", "GET " + UnmatchedRoute, fiber.StatusNotFound, codes.Unset},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder.Reset()
+			req := httptest.NewRequest(fiber.MethodGet, tt.path, nil)
+			req.Header.Set(requestid.Header, "req-1")
+			resp, err := app.Test(req)
+			require.NoError(t, err)
+			defer resp.Body.Close()
+
+			spans := recorder.Ended()
+			require.Len(t, spans, 1)
+			span := spans[0]
+			attrs := spanAttributes(span)
+			assert.Equal(t, tt.expectedName, span.Name())
+			assert.Equal(t, trace.SpanKindServer, span.SpanKind())
+			assert.Equal(t, tt.expectedCode, span.Status().Code)
+			assert.Equal(t, int64(tt.expectedStatus), attrs[semconv.HTTPResponseStatusCodeKey].AsInt64())
+			assert.Equal(t, "req-1", attrs[RequestIDAttribute].AsString())
+		})
+	}
+}
+
+func TestTracing_ContinuesCallerTrace(t *testing.T) {
+	recorder := recordSpans(t)
+	app := fiber.New()
+	app.Use(Tracing())
+	app.Get("/", func(c *fiber.Ctx) error { return c.SendStatus(fiber.StatusNoContent) })
+
+	req := httptest.NewRequest(fiber.MethodGet, "/", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	resp, err := app.Test(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", spans[0].SpanContext().TraceID().String())
+	assert.Equal(t, "00f067aa0ba902b7", spans[0].Parent().SpanID().String())
+	assert.Equal(t, "GET /", spans[0].Name())
+}
@@ -0,0 +1,125 @@
+package server
+
+import (
+	"errors"
+	"net/url"
+
+	"github.com/LigeronAhill/luxcarpets-go/internal/database/types"
+	"github.com/LigeronAhill/luxcarpets-go/internal/server/middleware"
+	"github.com/LigeronAhill/luxcarpets-go/internal/service"
+	"github.com/LigeronAhill/luxcarpets-go/internal/web/pages"
+	"github.com/LigeronAhill/luxcarpets-go/internal/web/seo"
+	"github.com/LigeronAhill/luxcarpets-go/pkg/datastar"
+	"github.com/gofiber/fiber/v2"
+)
+
+// home отображает главную страницу
+func (s *Server) home(c *fiber.Ctx) error {
+	p := s.page(c, "")
+	p.Description = "Ковролин, ковры и ковровая плитка с доставкой и подъемом на этаж."
+	p.Schema = []any{
+		seo.NewOrganization(s.cfg.Site),
+		seo.NewWebSite(s.cfg.Site),
+		seo.NewLocalBusiness(s.cfg.Site),
+	}
+	return render(c, fiber.StatusOK, pages.Home(p))
+}
+
+// catalog отображает каталог с фильтрами. Фильтры передаются параметрами адреса;
+// на обычный запрос возвращается полная страница, на запрос Datastar - фрагменты
+// со списком товаров и значениями фильтров. Оба ответа строятся из одного вызова CatalogService.List.
+func (s *Server) catalog(c *fiber.Ctx) error {
+	values, err := url.ParseQuery(string(c.Request().URI().QueryString()))
+	if err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid query string")
+	}
+	q := types.ParseCatalogQuery(values)
+	listing, err := s.catalogService.List(c.UserContext(), q)
+	if err != nil {
+		return err
+	}
+
+	// Ответы с одного адреса различаются по заголовку Datastar-Request
+	c.Vary(datastar.HeaderRequest)
+	if datastar.IsRequest(c.Get(datastar.HeaderRequest)) {
+		return patch(c, catalogHistoryURL(c, q), pages.CatalogResults(listing), pages.CatalogFacets(listing))
+	}
+
+	p := s.page(c, "Каталог")
+	p.Description = "Ковролин, ковры и ковровая плитка: фильтры по цвету, производителю, цене и наличию."
+	p.Datastar = true
+	middleware.AllowScriptEval(c)
+	p.Schema = []any{seo.NewBreadcrumbList(s.cfg.Site, catalogCrumbs(listing)...)}
+	return render(c, fiber.StatusOK, pages.Catalog(p, listing))
+}
+
+// product отображает страницу товара. Удаленные и скрытые товары отвечают 404.
+func (s *Server) product(c *fiber.Ctx) error {
+	details, err := s.catalogService.Product(c.UserContext(), c.Params("slug"))
+	if errors.Is(err, service.ErrProductNotFound) {
+		return fiber.NewError(fiber.StatusNotFound, err.Error())
+	}
+	if err != nil {
+		return err
+	}
+	images, err := s.mediaService.Gallery(c.UserContext(), details.Product.ID)
+	if err != nil {
+		return err
+	}
+
+	p := s.page(c, details.Product.Name)
+	p.Meta = seo.ProductMeta(s.cfg.Site, details.Product, details.Variants, images)
+	p.Description = p.Meta.Description
+	crumbs := []seo.Crumb{{Name: "Главная", Path: "/"}, {Name: "Каталог", Path: pages.CatalogPath}}
+	if details.Category != nil {
+		crumbs = append(crumbs, seo.Crumb{
+			Name: details.Category.Name,
+			Path: types.CatalogQuery{Category: details.Category.Slug}.URL(pages.CatalogPath),
+		})
+	}
+	p.Schema = []any{
+		seo.NewProduct(s.cfg.Site, details.Product, details.Category, details.Variants, images),
+		seo.NewBreadcrumbList(s.cfg.Site, crumbs...),
+	}
+	return render(c, fiber.StatusOK, pages.Product(p, details, images))
+}
+
+// about отображает страницу о магазине
+func (s *Server) about(c *fiber.Ctx) error {
+	p := s.page(c, "О нас")
+	p.Description = s.cfg.Site.Description
+	p.Schema = []any{seo.NewOrganization(s.cfg.Site)}
+	return render(c, fiber.StatusOK, pages.About(p))
+}
+
+// contacts отображает контакты магазина
+func (s *Server) contacts(c *fiber.Ctx) error {
+	p := s.page(c, "Контакты")
+	p.Description = "Телефон, email, адрес шоурума и часы работы " + s.cfg.Site.Name + "."
+	p.Schema = []any{seo.NewLocalBusiness(s.cfg.Site)}
+	return render(c, fiber.StatusOK, pages.Contacts(p))
+}
+
+// catalogHistoryURL возвращает адрес для истории браузера или пустую строку,
+// если страница уже открыта по этому адресу (повторная отправка той же формы)
+func catalogHistoryURL(c *fiber.Ctx, q types.CatalogQuery) string {
+	target := q.URL(pages.CatalogPath)
+	if referer, err := url.Parse(c.Get(fiber.HeaderReferer)); err == nil && referer.RequestURI() == target {
+		return ""
+	}
+	return target
+}
+
+// catalogCrumbs возвращает навигационную цепочку каталога; выбранная категория - последний пункт
+func catalogCrumbs(listing *types.CatalogListing) []seo.Crumb {
+	crumbs := []seo.Crumb{{Name: "Главная", Path: "/"}, {Name: "Каталог", Path: pages.CatalogPath}}
+	for _, category := range listing.Facets.Categories {
+		if category.Selected {
+			crumbs = append(crumbs, seo.Crumb{
+				Name: category.Label,
+				Path: types.CatalogQuery{Category: category.Value}.URL(pages.CatalogPath),
+			})
+		}
+	}
+	return crumbs
+}
@@ -0,0 +1,145 @@
+package server
+
+import (
+	"errors"
+
+	"github.com/LigeronAhill/luxcarpets-go/internal/database/types"
+	"github.com/LigeronAhill/luxcarpets-go/internal/server/middleware"
+	"github.com/LigeronAhill/luxcarpets-go/internal/service"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// profileHandler обрабатывает запросы страницы "Профиль"
+type profileHandler struct {
+	profile *service.ProfileService
+}
+
+func newProfileHandler(profile *service.ProfileService) *profileHandler {
+	return &profileHandler{
+		profile: profile,
+	}
+}
+
+// get возвращает профиль: контакты, предпочтения доставки и адреса
+func (h *profileHandler) get(c *fiber.Ctx) error {
+	principal := middleware.PrincipalFrom(c)
+	profile, err := h.profile.Get(c.UserContext(), principal.User.ID)
+	if err != nil {
+		return profileError(err)
+	}
+	return c.JSON(profile)
+}
+
+// updateContacts изменяет имя пользователя и телефон
+func (h *profileHandler) updateContacts(c *fiber.Ctx) error {
+	principal := middleware.PrincipalFrom(c)
+	var req service.UpdateProfileRequest
+	if err := c.BodyParser(&req); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid request body")
+	}
+	user, err := h.profile.UpdateContacts(c.UserContext(), principal.User.ID, req)
+	if err != nil {
+		return profileError(err)
+	}
+	return c.JSON(user)
+}
+
+// updateDeliveryPreferences сохраняет предпочтения доставки
+func (h *profileHandler) updateDeliveryPreferences(c *fiber.Ctx) error {
+	principal := middleware.PrincipalFrom(c)
+	var prefs types.DeliveryPreferences
+	if err := c.BodyParser(&prefs); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid request body")
+	}
+	saved, err := h.profile.UpdateDeliveryPreferences(c.UserContext(), principal.User.ID, prefs)
+	if err != nil {
+		return profileError(err)
+	}
+	return c.JSON(saved)
+}
+
+// listAddresses возвращает адреса доставки
+func (h *profileHandler) listAddresses(c *fiber.Ctx) error {
+	principal := middleware.PrincipalFrom(c)
+	addresses, err := h.profile.ListAddresses(c.UserContext(), principal.User.ID)
+	if err != nil {
+		return profileError(err)
+	}
+	return c.JSON(addresses)
+}
+
+// addAddress сохраняет новый адрес доставки
+func (h *profileHandler) addAddress(c *fiber.Ctx) error {
+	principal := middleware.PrincipalFrom(c)
+	var params types.AddressParams
+	if err := c.BodyParser(&params); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid request body")
+	}
+	address, err := h.profile.AddAddress(c.UserContext(), principal.User.ID, params)
+	if err != nil {
+		return profileError(err)
+	}
+	return c.Status(fiber.StatusCreated).JSON(address)
+}
+
+// updateAddress полностью заменяет адрес доставки
+func (h *profileHandler) updateAddress(c *fiber.Ctx) error {
+	principal := middleware.PrincipalFrom(c)
+	addressID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusNotFound, service.ErrAddressNotFound.Error())
+	}
+	var params types.AddressParams
+	if err := c.BodyParser(&params); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid request body")
+	}
+	address, err := h.profile.UpdateAddress(c.UserContext(), principal.User.ID, addressID, params)
+	if err != nil {
+		return profileError(err)
+	}
+	return c.JSON(address)
+}
+
+// deleteAddress удаляет адрес доставки
+func (h *profileHandler) deleteAddress(c *fiber.Ctx) error {
+	principal := middleware.PrincipalFrom(c)
+	addressID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusNotFound, service.ErrAddressNotFound.Error())
+	}
+	if err := h.profile.DeleteAddress(c.UserContext(), principal.User.ID, addressID); err != nil {
+		return profileError(err)
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// setDefaultAddress делает адрес адресом по умолчанию
+func (h *profileHandler) setDefaultAddress(c *fiber.Ctx) error {
+	principal := middleware.PrincipalFrom(c)
+	addressID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return fiber.NewError(fiber.StatusNotFound, service.ErrAddressNotFound.Error())
+	}
+	if err := h.profile.SetDefaultAddress(c.UserContext(), principal.User.ID, addressID); err != nil {
+		return profileError(err)
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// profileError сопоставляет ошибки сервиса профиля с HTTP-статусами
+func profileError(err error) error {
+	switch {
+	case errors.Is(err, service.ErrAddressNotFound), errors.Is(err, service.ErrUserNotFound):
+		return fiber.NewError(fiber.StatusNotFound, err.Error())
+	case errors.Is(err, service.ErrTooManyAddresses), errors.Is(err, service.ErrSignInPhoneChange):
+		return fiber.NewError(fiber.StatusConflict, err.Error())
+	case errors.Is(err, service.ErrInvalidAddress),
+		errors.Is(err, service.ErrInvalidPhone),
+		errors.Is(err, service.ErrInvalidUsername),
+		errors.Is(err, service.ErrInvalidDeliveryPreferences):
+		return fiber.NewError(fiber.StatusUnprocessableEntity, err.Error())
+	default:
+		return err
+	}
+}
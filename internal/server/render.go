@@ -0,0 +1,89 @@
+package server
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/LigeronAhill/luxcarpets-go/internal/server/middleware"
+	"github.com/LigeronAhill/luxcarpets-go/internal/web/components"
+	"github.com/LigeronAhill/luxcarpets-go/pkg/datastar"
+	"github.com/a-h/templ"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// CartCounter возвращает количество товаров в корзине пользователя для значка в шапке
+type CartCounter interface {
+	CountItems(ctx context.Context, userID uuid.UUID) (int, error)
+}
+
+// render отправляет HTML-страницу с кодом status. Компонент пишется прямо в тело ответа;
+// если отрисовка завершилась ошибкой, частично записанный HTML отбрасывается,
+// а ошибка передается в errorHandler, который покажет страницу 500.
+func render(c *fiber.Ctx, status int, component templ.Component) error {
+	c.Status(status)
+	c.Set(fiber.HeaderContentType, fiber.MIMETextHTMLCharsetUTF8)
+	if err := component.Render(c.UserContext(), c.Response().BodyWriter()); err != nil {
+		c.Response().ResetBody()
+		return err
+	}
+	return nil
+}
+
+// patch отвечает на запрос Datastar: каждый фрагмент отправляется событием SSE и заменяет
+// на странице элемент с тем же id. Если pushURL не пустой, он добавляется в историю браузера,
+// чтобы адрес страницы соответствовал показанному состоянию.
+func patch(c *fiber.Ctx, pushURL string, fragments ...templ.Component) error {
+	c.Set(fiber.HeaderContentType, datastar.ContentType)
+	c.Set(fiber.HeaderCacheControl, "no-cache")
+	sse := datastar.NewWriter(c.Response().BodyWriter())
+	var html strings.Builder
+	for _, fragment := range fragments {
+		html.Reset()
+		if err := fragment.Render(c.UserContext(), &html); err != nil {
+			c.Response().ResetBody()
+			return err
+		}
+		if err := sse.PatchElements(html.String()); err != nil {
+			return err
+		}
+	}
+	if pushURL != "" {
+		return sse.PushURL(pushURL)
+	}
+	return nil
+}
+
+// page собирает общие данные макета: текущего пользователя, корзину и flash-сообщения.
+// Flash-сообщения удаляются из cookie - они показываются один раз.
+func (s *Server) page(c *fiber.Ctx, title string) components.Page {
+	p := components.Page{
+		Title:   title,
+		Path:    c.Path(),
+		Flashes: takeFlashes(c, s.cfg.SecureCookies),
+		Year:    time.Now().Year(),
+		Assets:  s.cfg.Assets,
+		Site:    s.cfg.Site,
+		// Токен выдается на каждой странице: формы и запросы Datastar берут его из макета
+		CSRFToken: middleware.CSRFToken(c),
+	}
+	if s.cfg.Site.URL != "" {
+		// Канонический адрес - без параметров: страницы с фильтрами не должны попадать в поиск отдельно
+		p.Meta.URL = s.cfg.Site.Absolute(c.Path())
+	}
+	if principal := middleware.PrincipalFrom(c); principal != nil {
+		user := principal.User.ToPublic()
+		p.User = &user
+		if s.cart != nil {
+			count, err := s.cart.CountItems(c.UserContext(), user.ID)
+			if err != nil {
+				// Значок корзины не должен ломать страницу
+				logRequestError(c, "Failed to count cart items", err)
+			} else {
+				p.CartCount = count
+			}
+		}
+	}
+	return p
+}
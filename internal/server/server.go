@@ -0,0 +1,271 @@
+// Пакет server содержит HTTP-сервер на Fiber: маршруты, обработчики и middleware.
+package server
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+
+	"github.com/LigeronAhill/luxcarpets-go/internal/database/types"
+	"github.com/LigeronAhill/luxcarpets-go/internal/server/middleware"
+	"github.com/LigeronAhill/luxcarpets-go/internal/service"
+	"github.com/LigeronAhill/luxcarpets-go/internal/web/pages"
+	"github.com/LigeronAhill/luxcarpets-go/internal/web/seo"
+	"github.com/LigeronAhill/luxcarpets-go/pkg/assets"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Config содержит настройки HTTP-сервера
+type Config struct {
+	Address         string         // Адрес для прослушивания, например ":8080"
+	ShutdownTimeout time.Duration  // Сколько ждать завершения активных запросов при остановке
+	SecureCookies   bool           // Выдавать cookie только для HTTPS (включается в production)
+	Production      bool           // Окружение production: сайт открыт для поисковых роботов
+	BodyLimit       int            // Максимальный размер тела запроса в байтах; 0 - 10 МиБ
+	MediaDir        string         // Каталог, раздаваемый по адресу MediaURL (локальное хранилище файлов)
+	MediaURL        string         // Префикс адресов файлов из MediaDir, например "/media"
+	Assets          *assets.Assets // Статические файлы сайта; nil - не раздаются
+	Site            seo.Site       // Сведения о магазине для метаданных страниц
+	CSRFKey         []byte         // Секрет подписи токенов CSRF (не короче 32 байт)
+	CORSOrigins     []string       // Сайты, которым разрешены запросы к API из браузера
+	HSTSMaxAge      time.Duration  // Срок Strict-Transport-Security; 0 - заголовок не отправляется
+	ImageSources    []string       // Внешние адреса изображений для политики CSP (хранилище файлов, CDN)
+	RateLimits      RateLimits     // Ограничения частоты запросов; нулевые политики отключены
+	MetricsToken    string         // Токен доступа к /metrics; пустой - метрики доступны без токена
+	ProxyHeader     string         // Заголовок с IP-адресом клиента от обратного прокси, например "X-Real-IP"; пустой - адрес соединения
+	TrustedProxies  []string       // IP-адреса и подсети прокси, которым доверяется ProxyHeader
+}
+
+// RateLimits - политики ограничения частоты запросов для групп маршрутов
+type RateLimits struct {
+	OTP    service.RateLimit // Запрос кода из SMS и ссылки для входа: по IP-адресу
+	Auth   service.RateLimit // Проверка кода из SMS, ссылки и второго фактора: по IP-адресу
+	Search service.RateLimit // Каталог с фильтрами: по IP-адресу
+	API    service.RateLimit // API: по токену, пользователю или IP-адресу
+}
+
+// Services содержит сервисы, используемые обработчиками
+type Services struct {
+	Users      *service.UsersService
+	APITokens  *service.APITokensService
+	Profile    *service.ProfileService
+	PhoneOTP   *service.PhoneOTPService  // nil - вход по телефону отключен
+	MagicLinks *service.MagicLinkService // nil - вход по ссылке из письма отключен
+	TwoFactor  *service.TwoFactorService // nil - двухфакторная аутентификация не настроена
+	Sessions   *service.SessionsService
+	Avatars    *service.AvatarService
+	Media      *service.MediaService
+	Catalog    *service.CatalogService
+	Sitemap    *service.SitemapService
+	LLMs       *service.LLMsService
+	Cart       CartCounter          // nil - значок корзины не показывается
+	RateLimits *service.RateLimiter // nil - частота запросов не ограничивается
+	Health     *service.HealthService
+}
+
+// Server - HTTP-сервер приложения
+type Server struct {
+	app            *fiber.App
+	cfg            Config
+	cart           CartCounter
+	catalogService *service.CatalogService
+	mediaService   *service.MediaService
+}
+
+// New создает сервер и регистрирует маршруты
+func New(cfg Config, services Services) *Server {
+	if cfg.ShutdownTimeout <= 0 {
+		cfg.ShutdownTimeout = 10 * time.Second
+	}
+	if cfg.BodyLimit <= 0 {
+		cfg.BodyLimit = 10 << 20
+	}
+	s := &Server{
+		cfg:            cfg,
+		cart:           services.Cart,
+		catalogService: services.Catalog,
+		mediaService:   services.Media,
+	}
+	s.app = fiber.New(fiber.Config{
+		AppName:               "luxcarpets",
+		BodyLimit:             cfg.BodyLimit,
+		ErrorHandler:          s.errorHandler,
+		DisableStartupMessage: true,
+		// За прокси адрес соединения у всех клиентов один: без заголовка прокси ограничения частоты
+		// и блокировки входа по IP-адресу действовали бы на весь сайт. Заголовок принимается только
+		// от TrustedProxies, иначе клиент подставил бы в него любой адрес.
+		ProxyHeader:             cfg.ProxyHeader,
+		EnableTrustedProxyCheck: true,
+		TrustedProxies:          cfg.TrustedProxies,
+		EnableIPValidation:      true,
+	})
+	s.registerMiddleware()
+	s.registerRoutes(services)
+	return s
+}
+
+// App возвращает приложение Fiber (используется в тестах)
+func (s *Server) App() *fiber.App {
+	return s.app
+}
+
+// Run запускает сервер и корректно останавливает его при отмене ctx
+func (s *Server) Run(ctx context.Context) error {
+	errCh := make(chan error, 1)
+	go func() {
+		slog.Info("HTTP server listening", slog.String("address", s.cfg.Address))
+		errCh <- s.app.Listen(s.cfg.Address)
+	}()
+
+	select {
+	case err := <-errCh:
+		return err
+	case <-ctx.Done():
+		slog.Info("Shutting down HTTP server")
+		if err := s.app.ShutdownWithTimeout(s.cfg.ShutdownTimeout); err != nil {
+			return err
+		}
+		if err := <-errCh; err != nil && !errors.Is(err, context.Canceled) {
+			return err
+		}
+		return nil
+	}
+}
+
+// registerMiddleware подключает middleware, общие для всех маршрутов. RequestID - первым,
+// чтобы идентификатор запроса попал во все логи, затем Tracing и Metrics, чтобы учесть время всех
+// остальных middleware, и Recover: паника в обработчике или в middleware после него заканчивается страницей 500,
+// которую видят и трассировка, и метрики. RequestID, Tracing и Metrics не вызывают чужой код и не паникуют.
+func (s *Server) registerMiddleware() {
+	s.app.Use(middleware.RequestID())
+	s.app.Use(middleware.Tracing())
+	s.app.Use(middleware.Metrics())
+	s.app.Use(middleware.Recover())
+	s.app.Use(middleware.SecurityHeaders(middleware.SecurityHeadersConfig{
+		HSTSMaxAge:   s.cfg.HSTSMaxAge,
+		ImageSources: s.cfg.ImageSources,
+	}))
+	s.app.Use(middleware.CORS(s.cfg.CORSOrigins))
+	s.app.Use(middleware.CSRF(middleware.CSRFConfig{Key: s.cfg.CSRFKey, Secure: s.cfg.SecureCookies}))
+}
+
+// registerRoutes регистрирует все маршруты приложения
+func (s *Server) registerRoutes(services Services) {
+	health := newHealthHandler(services.Health, s.cfg.MetricsToken)
+	s.app.Get("/healthz", health.healthz)
+	s.app.Get("/readyz", health.readyz)
+	s.app.Get("/metrics", health.metrics())
+
+	if s.cfg.Assets != nil {
+		static := newStaticHandler(s.cfg.Assets)
+		s.app.Get(s.cfg.Assets.Prefix()+"*", static.serve)
+	}
+	if s.cfg.MediaDir != "" {
+		// Адреса файлов содержат случайную версию или хеш содержимого, поэтому их можно кешировать надолго
+		s.app.Static(s.cfg.MediaURL, s.cfg.MediaDir, fiber.Static{
+			MaxAge: 365 * 24 * 60 * 60,
+			ModifyResponse: func(c *fiber.Ctx) error {
+				c.Set(fiber.HeaderXContentTypeOptions, "nosniff")
+				return nil
+			},
+		})
+	}
+
+	// Страницы сайта доступны гостям; сессия нужна только для шапки
+	session := middleware.OptionalSession(services.Sessions)
+	limit := s.rateLimit(services.RateLimits)
+	s.app.Get("/", session, s.home)
+	s.app.Get(pages.CatalogPath, limit(s.cfg.RateLimits.Search, middleware.KeyByIP), session, s.catalog)
+	s.app.Get(pages.ProductPath, session, s.product)
+	s.app.Get(pages.AboutPath, session, s.about)
+	s.app.Get(pages.ContactsPath, session, s.contacts)
+
+	sitemap := newSitemapHandler(services.Sitemap)
+	s.app.Get("/sitemap.xml", sitemap.serve)
+	s.app.Get("/sitemap-:part.xml", sitemap.serve)
+
+	crawlers := newCrawlersHandler(service.RobotsTxt(s.cfg.Production, services.Sitemap.URL()), services.LLMs)
+	s.app.Get("/robots.txt", crawlers.robotsTxt)
+	s.app.Get("/llms.txt", crawlers.llmsTxt)
+
+	// Маршруты входа регистрируются до группы с аутентификацией:
+	// Fiber выполняет обработчики в порядке регистрации, и до middleware группы очередь не доходит
+	auth := newAuthHandler(services, s.cfg.SecureCookies)
+	otpLimit := limit(s.cfg.RateLimits.OTP, middleware.KeyByIP)
+	authLimit := limit(s.cfg.RateLimits.Auth, middleware.KeyByIP)
+	s.app.Post("/api/v1/auth/phone/code", otpLimit, auth.requestPhoneCode)
+	s.app.Post("/api/v1/auth/phone/verify", authLimit, auth.verifyPhoneCode)
+	s.app.Post("/api/v1/auth/email/link", otpLimit, auth.requestMagicLink)
+	s.app.Get(service.MagicLinkVerifyPath, authLimit, auth.verifyMagicLink)
+	s.app.Post("/api/v1/auth/2fa", authLimit, auth.completeSecondFactor)
+	s.app.Post("/api/v1/auth/2fa/enroll", authLimit, auth.beginEnrollment)
+	s.app.Post("/api/v1/auth/2fa/enroll/confirm", authLimit, auth.completeEnrollment)
+
+	// Ограничение API подключается после аутентификации, чтобы считать запросы по токену или пользователю
+	api := s.app.Group("/api/v1",
+		middleware.Authenticate(services.APITokens, services.Sessions),
+		limit(s.cfg.RateLimits.API, middleware.KeyByClient),
+	)
+	api.Post("/auth/logout", auth.logout)
+
+	api.Get("/me", middleware.RequireScope(types.ScopeProfileRead), getMe)
+
+	// Токенами управляют только из браузера: токен интеграции не должен выпускать и отзывать токены
+	tokens := newAPITokensHandler(services.APITokens)
+	sessionOnly := middleware.RequireSession()
+	api.Get("/tokens", sessionOnly, tokens.list)
+	api.Post("/tokens", sessionOnly, tokens.create)
+	api.Delete("/tokens/:id", sessionOnly, tokens.revoke)
+
+	// Снять блокировку входа может администратор из браузера или токеном с правом users:write
+	admin := newAdminHandler(services.Users)
+	api.Post("/admin/sign-in/unlock", middleware.RequireScope(types.ScopeUsersWrite), admin.unlockSignIn)
+
+	// Двухфакторной аутентификацией управляют только из браузера; коды проверяются с ограничением
+	// частоты, чтобы украденная сессия не позволяла перебирать их для отключения 2FA
+	if services.TwoFactor != nil {
+		twoFactor := newTwoFactorHandler(services.TwoFactor)
+		codeLimit := limit(s.cfg.RateLimits.Auth, middleware.KeyByClient)
+		api.Post("/profile/2fa", sessionOnly, twoFactor.enroll)
+		api.Post("/profile/2fa/confirm", sessionOnly, codeLimit, twoFactor.confirm)
+		api.Post("/profile/2fa/disable", sessionOnly, codeLimit, twoFactor.disable)
+		api.Post("/profile/2fa/recovery-codes", sessionOnly, codeLimit, twoFactor.regenerateRecoveryCodes)
+	}
+
+	profile := newProfileHandler(services.Profile)
+	read := middleware.RequireScope(types.ScopeProfileRead)
+	write := middleware.RequireScope(types.ScopeProfileWrite)
+	api.Get("/profile", read, profile.get)
+	api.Patch("/profile", write, profile.updateContacts)
+	api.Put("/profile/delivery", write, profile.updateDeliveryPreferences)
+	api.Get("/profile/addresses", read, profile.listAddresses)
+	api.Post("/profile/addresses", write, profile.addAddress)
+	api.Put("/profile/addresses/:id", write, profile.updateAddress)
+	api.Delete("/profile/addresses/:id", write, profile.deleteAddress)
+	api.Post("/profile/addresses/:id/default", write, profile.setDefaultAddress)
+
+	avatars := newAvatarHandler(services.Avatars)
+	api.Put("/profile/avatar", write, avatars.upload)
+
+	media := newMediaHandler(services.Media)
+	catalogRead := middleware.RequireScope(types.ScopeCatalogRead)
+	catalogWrite := middleware.RequireScope(types.ScopeCatalogWrite)
+	api.Get("/products/:id/media", catalogRead, media.list)
+	api.Post("/products/:id/media", catalogWrite, media.upload)
+	api.Put("/products/:id/media/order", catalogWrite, media.reorder)
+	api.Patch("/products/:id/media/:mediaId", catalogWrite, media.updateAltText)
+	api.Delete("/products/:id/media/:mediaId", catalogWrite, media.remove)
+}
+
+// rateLimit возвращает конструктор middleware ограничения частоты с общим ограничителем.
+// Без ограничителя middleware пропускают все запросы: nil-указатель не передается
+// в интерфейс напрямую, иначе middleware.RateLimit не распознает его как nil.
+func (s *Server) rateLimit(limiter *service.RateLimiter) func(service.RateLimit, middleware.KeyFunc) fiber.Handler {
+	return func(policy service.RateLimit, key middleware.KeyFunc) fiber.Handler {
+		if limiter == nil {
+			return middleware.RateLimit(nil, policy, key)
+		}
+		return middleware.RateLimit(limiter, policy, key)
+	}
+}
@@ -0,0 +1,35 @@
+package server
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/LigeronAhill/luxcarpets-go/internal/service"
+	"github.com/gofiber/fiber/v2"
+)
+
+// sitemapHandler отдает карту сайта
+type sitemapHandler struct {
+	sitemap *service.SitemapService
+}
+
+func newSitemapHandler(sitemap *service.SitemapService) *sitemapHandler {
+	return &sitemapHandler{
+		sitemap: sitemap,
+	}
+}
+
+// serve отдает sitemap.xml или его часть sitemap-N.xml
+func (h *sitemapHandler) serve(c *fiber.Ctx) error {
+	data, err := h.sitemap.File(c.UserContext(), strings.TrimPrefix(c.Path(), "/"))
+	if err != nil {
+		if errors.Is(err, service.ErrSitemapNotFound) {
+			return fiber.NewError(fiber.StatusNotFound, err.Error())
+		}
+		return err
+	}
+	c.Set(fiber.HeaderContentType, fiber.MIMEApplicationXMLCharsetUTF8)
+	// Поисковые роботы читают карту редко; час задержки после изменения каталога допустим
+	c.Set(fiber.HeaderCacheControl, "public, max-age=3600")
+	return c.Send(data)
+}
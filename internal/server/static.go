@@ -0,0 +1,56 @@
+package server
+
+import (
+	"github.com/LigeronAhill/luxcarpets-go/pkg/assets"
+	"github.com/gofiber/fiber/v2"
+)
+
+// Cache-Control для статических файлов: адреса с хешем не меняются никогда,
+// адреса без хеша перепроверяются по ETag при каждом запросе
+const (
+	immutableCacheControl  = "public, max-age=31536000, immutable"
+	revalidateCacheControl = "public, no-cache"
+)
+
+// staticHandler раздает встроенные статические файлы
+type staticHandler struct {
+	assets *assets.Assets
+}
+
+func newStaticHandler(assets *assets.Assets) *staticHandler {
+	return &staticHandler{
+		assets: assets,
+	}
+}
+
+// serve отдает файл, выбирая заранее сжатую копию по Accept-Encoding, и отвечает 304 по If-None-Match
+func (h *staticHandler) serve(c *fiber.Ctx) error {
+	asset, ok := h.assets.Resolve(c.Params("*"), c.Get(fiber.HeaderAcceptEncoding))
+	if !ok {
+		return fiber.ErrNotFound
+	}
+
+	if len(asset.File.Encodings) > 0 {
+		c.Vary(fiber.HeaderAcceptEncoding)
+	}
+	c.Set(fiber.HeaderETag, asset.ETag)
+	c.Set(fiber.HeaderXContentTypeOptions, "nosniff")
+	if asset.Immutable {
+		c.Set(fiber.HeaderCacheControl, immutableCacheControl)
+	} else {
+		c.Set(fiber.HeaderCacheControl, revalidateCacheControl)
+	}
+	if assets.ETagMatches(c.Get(fiber.HeaderIfNoneMatch), asset.ETag) {
+		return c.SendStatus(fiber.StatusNotModified)
+	}
+
+	data, err := h.assets.ReadFile(asset)
+	if err != nil {
+		return err
+	}
+	c.Set(fiber.HeaderContentType, asset.File.ContentType)
+	if asset.Encoding != "" {
+		c.Set(fiber.HeaderContentEncoding, asset.Encoding)
+	}
+	return c.Send(data)
+}
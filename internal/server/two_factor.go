@@ -0,0 +1,98 @@
+package server
+
+import (
+	"errors"
+
+	"github.com/LigeronAhill/luxcarpets-go/internal/server/middleware"
+	"github.com/LigeronAhill/luxcarpets-go/internal/service"
+	"github.com/gofiber/fiber/v2"
+)
+
+// twoFactorCodeBody - тело запроса с кодом из приложения-аутентификатора или кодом восстановления
+type twoFactorCodeBody struct {
+	Code string `json:"code"`
+}
+
+// recoveryCodesResponse - новые коды восстановления; показываются пользователю один раз
+type recoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recovery_codes"`
+}
+
+// twoFactorHandler обрабатывает управление двухфакторной аутентификацией в личном кабинете
+type twoFactorHandler struct {
+	twoFactor *service.TwoFactorService
+}
+
+func newTwoFactorHandler(twoFactor *service.TwoFactorService) *twoFactorHandler {
+	return &twoFactorHandler{
+		twoFactor: twoFactor,
+	}
+}
+
+// enroll создает новый секрет и возвращает данные для QR-кода.
+// Аутентификатор не действует, пока не подтвержден кодом через confirm.
+func (h *twoFactorHandler) enroll(c *fiber.Ctx) error {
+	principal := middleware.PrincipalFrom(c)
+	enrollment, err := h.twoFactor.BeginEnrollment(c.UserContext(), principal.User.ID)
+	if err != nil {
+		return twoFactorError(err)
+	}
+	return c.JSON(enrollment)
+}
+
+// confirm подтверждает аутентификатор первым кодом из приложения и возвращает коды восстановления
+func (h *twoFactorHandler) confirm(c *fiber.Ctx) error {
+	principal := middleware.PrincipalFrom(c)
+	var body twoFactorCodeBody
+	if err := c.BodyParser(&body); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid request body")
+	}
+	codes, err := h.twoFactor.ConfirmEnrollment(c.UserContext(), principal.User.ID, body.Code)
+	if err != nil {
+		return twoFactorError(err)
+	}
+	return c.JSON(recoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// disable отключает двухфакторную аутентификацию после проверки кода
+func (h *twoFactorHandler) disable(c *fiber.Ctx) error {
+	principal := middleware.PrincipalFrom(c)
+	var body twoFactorCodeBody
+	if err := c.BodyParser(&body); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid request body")
+	}
+	if err := h.twoFactor.Disable(c.UserContext(), principal.User.ID, body.Code); err != nil {
+		return twoFactorError(err)
+	}
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// regenerateRecoveryCodes заменяет коды восстановления после проверки кода
+func (h *twoFactorHandler) regenerateRecoveryCodes(c *fiber.Ctx) error {
+	principal := middleware.PrincipalFrom(c)
+	var body twoFactorCodeBody
+	if err := c.BodyParser(&body); err != nil {
+		return fiber.NewError(fiber.StatusBadRequest, "invalid request body")
+	}
+	codes, err := h.twoFactor.RegenerateRecoveryCodes(c.UserContext(), principal.User.ID, body.Code)
+	if err != nil {
+		return twoFactorError(err)
+	}
+	return c.JSON(recoveryCodesResponse{RecoveryCodes: codes})
+}
+
+// twoFactorError сопоставляет ошибки сервиса 2FA с HTTP-статусами
+func twoFactorError(err error) error {
+	switch {
+	case errors.Is(err, service.ErrUserNotFound):
+		return fiber.NewError(fiber.StatusNotFound, err.Error())
+	case errors.Is(err, service.ErrTwoFactorNotEnrolled),
+		errors.Is(err, service.ErrTwoFactorAlreadyEnabled):
+		return fiber.NewError(fiber.StatusConflict, err.Error())
+	case errors.Is(err, service.ErrInvalidTwoFactorCode),
+		errors.Is(err, service.ErrTwoFactorRequired):
+		return fiber.NewError(fiber.StatusForbidden, err.Error())
+	default:
+		return err
+	}
+}
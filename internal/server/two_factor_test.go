@@ -0,0 +1,162 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/LigeronAhill/luxcarpets-go/internal/database"
+	"github.com/LigeronAhill/luxcarpets-go/internal/database/types"
+	"github.com/LigeronAhill/luxcarpets-go/internal/server/middleware"
+	"github.com/LigeronAhill/luxcarpets-go/internal/service"
+	"github.com/LigeronAhill/luxcarpets-go/pkg/totp"
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// stubSessions принимает любую cookie сессии и возвращает заданного пользователя
+type stubSessions struct {
+	user *types.User
+}
+
+func (s stubSessions) Authenticate(_ context.Context, _ string) (*service.Principal, error) {
+	return &service.Principal{User: s.user, Session: &types.Session{ID: uuid.New()}}, nil
+}
+
+// newTestTwoFactorApp регистрирует маршруты управления 2FA для сессии пользователя user
+func newTestTwoFactorApp(t *testing.T, mock pgxmock.PgxPoolIface, user *types.User) *fiber.App {
+	t.Helper()
+	twoFactor, err := service.NewTwoFactorService(database.NewTwoFactorStorage(mock), database.NewUsersStorage(mock), service.TwoFactorConfig{
+		EncryptionKey: testKey,
+		RequiredRole:  types.RoleEmployee,
+	})
+	require.NoError(t, err)
+	h := newTwoFactorHandler(twoFactor)
+	app := fiber.New()
+	api := app.Group("/api/v1", middleware.Authenticate(nil, stubSessions{user: user}), middleware.RequireSession())
+	api.Post("/profile/2fa", h.enroll)
+	api.Post("/profile/2fa/confirm", h.confirm)
+	api.Post("/profile/2fa/disable", h.disable)
+	api.Post("/profile/2fa/recovery-codes", h.regenerateRecoveryCodes)
+	return app
+}
+
+// sessionRequest создает запрос с cookie сессии браузера
+func sessionRequest(t *testing.T, path string, body any) *http.Request {
+	t.Helper()
+	req := newJSONRequest(t, path, body)
+	req.Header.Set(fiber.HeaderCookie, middleware.SessionCookieName+"=session")
+	return req
+}
+
+func expectUserRow(mock pgxmock.PgxPoolIface, user *types.User) {
+	mock.ExpectQuery(`SELECT \* FROM users WHERE id = @id`).
+		WithArgs(user.ID).
+		WillReturnRows(pgxmock.NewRows(userColumns).AddRow(
+			user.ID, user.Email, true, user.Username, user.Role, nil,
+			nil, user.CreatedAt, user.UpdatedAt, nil, nil, false,
+		))
+}
+
+func expectRecoveryCodesReplaced(mock pgxmock.PgxPoolIface, userID uuid.UUID) {
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE FROM user_recovery_codes`).WithArgs(userID).WillReturnResult(pgxmock.NewResult("DELETE", 0))
+	mock.ExpectExec(`INSERT INTO user_recovery_codes`).WithArgs(userID, pgxmock.AnyArg()).WillReturnResult(pgxmock.NewResult("INSERT", 10))
+	mock.ExpectCommit()
+}
+
+// Покупатель подключает аутентификатор, заменяет коды восстановления и отключает 2FA
+func TestTwoFactorHandler_Lifecycle(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	now := time.Now()
+	email := "buyer@example.com"
+	user := &types.User{ID: uuid.New(), Email: &email, Username: "buyer", Role: types.RoleCustomer, CreatedAt: now, UpdatedAt: now}
+	app := newTestTwoFactorApp(t, mock, user)
+
+	// Подключение
+	var encrypted []byte
+	expectUserRow(mock, user)
+	mock.ExpectQuery(`INSERT INTO user_totp`).
+		WithArgs(user.ID, captureArg[[]byte]{&encrypted}).
+		WillReturnRows(pgxmock.NewRows(totpColumns).AddRow(user.ID, []byte("secret"), nil, int64(0), now, now))
+
+	var enrollment service.TOTPEnrollment
+	resp := doJSON(t, app, sessionRequest(t, "/api/v1/profile/2fa", nil), &enrollment)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	secret, err := totp.DecodeSecret(enrollment.Secret)
+	require.NoError(t, err)
+
+	// Подтверждение первым кодом
+	mock.ExpectQuery(`SELECT \* FROM user_totp`).
+		WithArgs(user.ID).
+		WillReturnRows(pgxmock.NewRows(totpColumns).AddRow(user.ID, encrypted, nil, int64(0), now, now))
+	mock.ExpectExec(`UPDATE user_totp\s+SET confirmed_at = NOW\(\)`).
+		WithArgs(pgxmock.AnyArg(), user.ID).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	expectRecoveryCodesReplaced(mock, user.ID)
+
+	var confirmed recoveryCodesResponse
+	code := totp.Code(secret, totp.Step(time.Now()), totp.Digits)
+	resp = doJSON(t, app, sessionRequest(t, "/api/v1/profile/2fa/confirm", twoFactorCodeBody{Code: code}), &confirmed)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	require.Len(t, confirmed.RecoveryCodes, 10)
+
+	// Замена кодов восстановления по одному из выданных кодов
+	mock.ExpectExec(`UPDATE user_recovery_codes`).
+		WithArgs(user.ID, pgxmock.AnyArg()).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	expectRecoveryCodesReplaced(mock, user.ID)
+
+	var regenerated recoveryCodesResponse
+	resp = doJSON(t, app, sessionRequest(t, "/api/v1/profile/2fa/recovery-codes", twoFactorCodeBody{Code: confirmed.RecoveryCodes[0]}), &regenerated)
+	require.Equal(t, fiber.StatusOK, resp.StatusCode)
+	assert.Len(t, regenerated.RecoveryCodes, 10)
+	assert.NotEqual(t, confirmed.RecoveryCodes, regenerated.RecoveryCodes)
+
+	// Неверный код не отключает 2FA
+	expectUserRow(mock, user)
+	mock.ExpectExec(`UPDATE user_recovery_codes`).
+		WithArgs(user.ID, pgxmock.AnyArg()).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+
+	resp = doJSON(t, app, sessionRequest(t, "/api/v1/profile/2fa/disable", twoFactorCodeBody{Code: "wrong-code"}), nil)
+	assert.Equal(t, fiber.StatusForbidden, resp.StatusCode)
+
+	// Отключение
+	expectUserRow(mock, user)
+	mock.ExpectExec(`UPDATE user_recovery_codes`).
+		WithArgs(user.ID, pgxmock.AnyArg()).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE FROM user_recovery_codes`).WithArgs(user.ID).WillReturnResult(pgxmock.NewResult("DELETE", 9))
+	mock.ExpectExec(`DELETE FROM user_totp`).WithArgs(user.ID).WillReturnResult(pgxmock.NewResult("DELETE", 1))
+	mock.ExpectCommit()
+
+	resp = doJSON(t, app, sessionRequest(t, "/api/v1/profile/2fa/disable", twoFactorCodeBody{Code: regenerated.RecoveryCodes[0]}), nil)
+	assert.Equal(t, fiber.StatusNoContent, resp.StatusCode)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTwoFactorHandler_Disable_RequiredRole(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	now := time.Now()
+	email := "staff@example.com"
+	user := &types.User{ID: uuid.New(), Email: &email, Username: "staff", Role: types.RoleEmployee, CreatedAt: now, UpdatedAt: now}
+	app := newTestTwoFactorApp(t, mock, user)
+
+	expectUserRow(mock, user)
+
+	resp := doJSON(t, app, sessionRequest(t, "/api/v1/profile/2fa/disable", twoFactorCodeBody{Code: "123456"}), nil)
+	assert.Equal(t, fiber.StatusForbidden, resp.StatusCode)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
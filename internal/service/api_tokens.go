@@ -0,0 +1,281 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"log/slog"
+	"slices"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/LigeronAhill/luxcarpets-go/internal/database"
+	"github.com/LigeronAhill/luxcarpets-go/internal/database/types"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// Ошибки персональных токенов API
+var (
+	// ErrInvalidAPIToken возвращается при неверном, отозванном или просроченном токене
+	ErrInvalidAPIToken = errors.New("invalid api token")
+	// ErrAPITokenNotFound возвращается, если токен не найден среди токенов пользователя
+	ErrAPITokenNotFound = errors.New("api token not found")
+	// ErrAPITokenNameRequired возвращается, если не указано название токена
+	ErrAPITokenNameRequired = errors.New("api token name must be between 1 and 100 characters")
+	// ErrAPITokenScopesRequired возвращается, если не указано ни одного права
+	ErrAPITokenScopesRequired = errors.New("at least one api token scope is required")
+	// ErrInvalidTokenScope возвращается при неизвестном праве
+	ErrInvalidTokenScope = errors.New("invalid token scope")
+	// ErrTokenScopeNotAllowed возвращается, если роль пользователя не допускает запрошенное право
+	ErrTokenScopeNotAllowed = errors.New("token scope is not allowed for user role")
+	// ErrInvalidAPITokenTTL возвращается при сроке действия больше MaxAPITokenTTL
+	ErrInvalidAPITokenTTL = errors.New("api token lifetime is too long")
+)
+
+const (
+	// APITokenPrefix - префикс токенов, по которому их находят сканеры секретов
+	APITokenPrefix = "lxc_pat_"
+	// DefaultAPITokenTTL - срок действия токена, если он не указан
+	DefaultAPITokenTTL = 90 * 24 * time.Hour
+	// MaxAPITokenTTL - максимальный срок действия токена
+	MaxAPITokenTTL = 366 * 24 * time.Hour
+
+	// apiTokenRandomLength - длина случайной части токена в символах base62 (~178 бит)
+	apiTokenRandomLength = 30
+	// apiTokenChecksumLength - длина контрольной суммы CRC32 в символах base62
+	apiTokenChecksumLength = 6
+	// apiTokenDisplayLength - сколько символов токена хранится для отображения
+	apiTokenDisplayLength = len(APITokenPrefix) + 4
+	// apiTokenTouchInterval - как часто обновляется время последнего использования
+	apiTokenTouchInterval = time.Minute
+	// maxAPITokenNameLength - максимальная длина названия токена
+	maxAPITokenNameLength = 100
+)
+
+const base62Alphabet = "0123456789ABCDEFGHIJKLMNOPQRSTUVWXYZabcdefghijklmnopqrstuvwxyz"
+
+// Principal описывает аутентифицированного клиента API и его фактические права
+type Principal struct {
+	User    *types.User        // Пользователь, от имени которого выполняется запрос
+	Scopes  []types.TokenScope // Права, ограниченные текущей ролью пользователя
+	Token   *types.APIToken    // Токен, которым выполнен вход (nil для сессии браузера)
+	Session *types.Session     // Сессия браузера (nil для токена API)
+}
+
+// HasScope проверяет, есть ли у клиента право scope
+func (p *Principal) HasScope(scope types.TokenScope) bool {
+	return slices.Contains(p.Scopes, scope)
+}
+
+// CreateAPITokenRequest содержит параметры выпуска нового токена
+type CreateAPITokenRequest struct {
+	Name   string             `json:"name"`   // Название токена
+	Scopes []types.TokenScope `json:"scopes"` // Запрошенные права
+	TTL    time.Duration      `json:"-"`      // Срок действия (0 - DefaultAPITokenTTL)
+}
+
+// IssuedAPIToken содержит выпущенный токен.
+// Token показывается пользователю один раз и больше нигде не хранится.
+type IssuedAPIToken struct {
+	Token    string          `json:"token"`     // Сам токен
+	APIToken *types.APIToken `json:"api_token"` // Сохраненные данные токена
+}
+
+// APITokensService управляет персональными токенами доступа к API
+type APITokensService struct {
+	storage *database.APITokensStorage
+	users   *database.UsersStorage
+	now     func() time.Time
+}
+
+// NewAPITokensService создает сервис персональных токенов API
+func NewAPITokensService(storage *database.APITokensStorage, users *database.UsersStorage) *APITokensService {
+	return &APITokensService{
+		storage: storage,
+		users:   users,
+		now:     time.Now,
+	}
+}
+
+// Create выпускает новый токен для пользователя
+//
+// Возможные ошибки:
+//   - ErrUserNotFound: если пользователь не найден
+//   - ErrAPITokenNameRequired: если название пустое или длиннее 100 символов
+//   - ErrAPITokenScopesRequired, ErrInvalidTokenScope: если права не указаны или неизвестны
+//   - ErrTokenScopeNotAllowed: если право недоступно для роли пользователя
+//   - ErrInvalidAPITokenTTL: если срок действия больше MaxAPITokenTTL
+func (s *APITokensService) Create(ctx context.Context, userID uuid.UUID, req CreateAPITokenRequest) (*IssuedAPIToken, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" || utf8.RuneCountInString(name) > maxAPITokenNameLength {
+		return nil, ErrAPITokenNameRequired
+	}
+	if len(req.Scopes) == 0 {
+		return nil, ErrAPITokenScopesRequired
+	}
+	ttl := req.TTL
+	if ttl <= 0 {
+		ttl = DefaultAPITokenTTL
+	}
+	if ttl > MaxAPITokenTTL {
+		return nil, ErrInvalidAPITokenTTL
+	}
+
+	user, err := s.users.GetByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrUserNotFound, err)
+	}
+	scopes := make([]types.TokenScope, 0, len(req.Scopes))
+	for _, scope := range req.Scopes {
+		if !scope.Valid() {
+			return nil, fmt.Errorf("%w: %s", ErrInvalidTokenScope, scope)
+		}
+		if !scope.AllowedFor(user.Role) {
+			return nil, fmt.Errorf("%w: %s", ErrTokenScopeNotAllowed, scope)
+		}
+		if !slices.Contains(scopes, scope) {
+			scopes = append(scopes, scope)
+		}
+	}
+
+	token, err := generateAPIToken()
+	if err != nil {
+		return nil, err
+	}
+	saved, err := s.storage.Create(ctx, types.CreateAPITokenParams{
+		UserID:      userID,
+		Name:        name,
+		TokenPrefix: token[:apiTokenDisplayLength],
+		TokenHash:   hashToken(token),
+		Scopes:      scopes,
+		ExpiresAt:   s.now().Add(ttl),
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to save api token: %w", err)
+	}
+	return &IssuedAPIToken{Token: token, APIToken: saved}, nil
+}
+
+// Authenticate проверяет токен из заголовка Authorization и возвращает клиента.
+// Права токена пересекаются с текущей ролью пользователя: если роль понизили,
+// ранее выданные права перестают действовать без перевыпуска токена.
+//
+// Возможные ошибки:
+//   - ErrInvalidAPIToken: если токен неверный, отозван, просрочен или пользователь удален
+func (s *APITokensService) Authenticate(ctx context.Context, token string) (*Principal, error) {
+	if !validAPITokenFormat(token) {
+		return nil, ErrInvalidAPIToken
+	}
+	saved, err := s.storage.GetByHash(ctx, hashToken(token))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get api token: %w", err)
+	}
+	now := s.now()
+	if saved == nil || !saved.Active(now) {
+		return nil, ErrInvalidAPIToken
+	}
+
+	user, err := s.users.GetByID(ctx, saved.UserID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrInvalidAPIToken
+		}
+		return nil, fmt.Errorf("failed to get api token owner: %w", err)
+	}
+	scopes := make([]types.TokenScope, 0, len(saved.Scopes))
+	for _, scope := range saved.Scopes {
+		if scope.AllowedFor(user.Role) {
+			scopes = append(scopes, scope)
+		}
+	}
+
+	if err := s.storage.TouchLastUsed(ctx, saved.ID, now, now.Add(-apiTokenTouchInterval)); err != nil {
+		slog.WarnContext(ctx, "Failed to update api token last use",
+			slog.String("token_id", saved.ID.String()),
+			slog.String("error", err.Error()),
+		)
+	}
+	return &Principal{User: user, Scopes: scopes, Token: saved}, nil
+}
+
+// List возвращает действующие и просроченные, но не отозванные токены пользователя
+func (s *APITokensService) List(ctx context.Context, userID uuid.UUID) ([]*types.APIToken, error) {
+	tokens, err := s.storage.ListByUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list api tokens: %w", err)
+	}
+	return tokens, nil
+}
+
+// Revoke отзывает токен пользователя
+//
+// Возможные ошибки:
+//   - ErrAPITokenNotFound: если токен не найден, принадлежит другому пользователю или уже отозван
+func (s *APITokensService) Revoke(ctx context.Context, userID, tokenID uuid.UUID) error {
+	revoked, err := s.storage.Revoke(ctx, userID, tokenID)
+	if err != nil {
+		return fmt.Errorf("failed to revoke api token: %w", err)
+	}
+	if !revoked {
+		return ErrAPITokenNotFound
+	}
+	return nil
+}
+
+// generateAPIToken создает токен вида lxc_pat_<30 символов base62><6 символов CRC32>.
+// Контрольная сумма позволяет сканерам секретов и серверу отсеивать опечатки без обращения к базе данных.
+func generateAPIToken() (string, error) {
+	random := make([]byte, 0, apiTokenRandomLength)
+	buf := make([]byte, apiTokenRandomLength)
+	for len(random) < apiTokenRandomLength {
+		if _, err := rand.Read(buf); err != nil {
+			return "", fmt.Errorf("failed to generate random bytes: %w", err)
+		}
+		for _, b := range buf {
+			// Отбрасываем значения >= 248, чтобы распределение по алфавиту было равномерным
+			if b < 248 && len(random) < apiTokenRandomLength {
+				random = append(random, base62Alphabet[b%62])
+			}
+		}
+	}
+	return APITokenPrefix + string(random) + apiTokenChecksum(string(random)), nil
+}
+
+// apiTokenChecksum кодирует CRC32 случайной части токена в base62 фиксированной длины
+func apiTokenChecksum(random string) string {
+	sum := crc32.ChecksumIEEE([]byte(random))
+	out := make([]byte, apiTokenChecksumLength)
+	for i := apiTokenChecksumLength - 1; i >= 0; i-- {
+		out[i] = base62Alphabet[sum%62]
+		sum /= 62
+	}
+	return string(out)
+}
+
+// validAPITokenFormat проверяет префикс, алфавит и контрольную сумму токена
+func validAPITokenFormat(token string) bool {
+	body, ok := strings.CutPrefix(token, APITokenPrefix)
+	if !ok || len(body) != apiTokenRandomLength+apiTokenChecksumLength {
+		return false
+	}
+	for i := 0; i < len(body); i++ {
+		if strings.IndexByte(base62Alphabet, body[i]) < 0 {
+			return false
+		}
+	}
+	random, checksum := body[:apiTokenRandomLength], body[apiTokenRandomLength:]
+	return apiTokenChecksum(random) == checksum
+}
+
+// hashToken возвращает SHA-256 токена в hex.
+// Быстрый хеш допустим: токены случайные и имеют высокую энтропию.
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,239 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/LigeronAhill/luxcarpets-go/internal/database"
+	"github.com/LigeronAhill/luxcarpets-go/internal/database/types"
+	"github.com/google/uuid"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var apiTokenColumns = []string{
+	"id", "user_id", "name", "token_prefix", "token_hash", "scopes",
+	"expires_at", "last_used_at", "revoked_at", "created_at",
+}
+
+func newTestAPITokensService(mock pgxmock.PgxPoolIface, now time.Time) *APITokensService {
+	svc := NewAPITokensService(database.NewAPITokensStorage(mock), database.NewUsersStorage(mock))
+	svc.now = func() time.Time { return now }
+	return svc
+}
+
+func TestGenerateAPIToken_Format(t *testing.T) {
+	token, err := generateAPIToken()
+	require.NoError(t, err)
+
+	assert.True(t, strings.HasPrefix(token, APITokenPrefix))
+	assert.Len(t, token, len(APITokenPrefix)+apiTokenRandomLength+apiTokenChecksumLength)
+	assert.True(t, validAPITokenFormat(token))
+
+	other, err := generateAPIToken()
+	require.NoError(t, err)
+	assert.NotEqual(t, token, other)
+}
+
+func TestValidAPITokenFormat_Rejects(t *testing.T) {
+	token, err := generateAPIToken()
+	require.NoError(t, err)
+
+	// Опечатка в случайной части ломает контрольную сумму
+	typo := []byte(token)
+	i := len(APITokenPrefix)
+	if typo[i] == 'a' {
+		typo[i] = 'b'
+	} else {
+		typo[i] = 'a'
+	}
+
+	assert.False(t, validAPITokenFormat(string(typo)))
+	assert.False(t, validAPITokenFormat(strings.TrimPrefix(token, APITokenPrefix)))
+	assert.False(t, validAPITokenFormat(token[:len(token)-1]))
+	assert.False(t, validAPITokenFormat(token[:len(token)-1]+"!"))
+	assert.False(t, validAPITokenFormat(""))
+}
+
+func TestAPITokensService_Create_Success(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	now := time.Now()
+	svc := newTestAPITokensService(mock, now)
+	userID := uuid.New()
+	tokenID := uuid.New()
+	scopes := []types.TokenScope{types.ScopeCatalogRead, types.ScopeCatalogWrite}
+
+	expectUserByID(mock, userID, "staff@example.com", types.RoleEmployee)
+	mock.ExpectQuery(`INSERT INTO api_tokens`).
+		WithArgs(userID, "Синхронизация 1С", pgxmock.AnyArg(), pgxmock.AnyArg(), []string{"catalog:read", "catalog:write"}, now.Add(DefaultAPITokenTTL)).
+		WillReturnRows(pgxmock.NewRows(apiTokenColumns).AddRow(
+			tokenID, userID, "Синхронизация 1С", "lxc_pat_abcd", "hash", scopes,
+			now.Add(DefaultAPITokenTTL), nil, nil, now,
+		))
+
+	issued, err := svc.Create(context.Background(), userID, CreateAPITokenRequest{
+		Name:   "  Синхронизация 1С ",
+		Scopes: []types.TokenScope{types.ScopeCatalogRead, types.ScopeCatalogWrite, types.ScopeCatalogRead},
+	})
+
+	require.NoError(t, err)
+	assert.True(t, validAPITokenFormat(issued.Token))
+	assert.Equal(t, tokenID, issued.APIToken.ID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAPITokensService_Create_ScopeCappedByRole(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	svc := newTestAPITokensService(mock, time.Now())
+	userID := uuid.New()
+
+	expectUserByID(mock, userID, "customer@example.com", types.RoleCustomer)
+
+	_, err = svc.Create(context.Background(), userID, CreateAPITokenRequest{
+		Name:   "feed",
+		Scopes: []types.TokenScope{types.ScopeCatalogRead, types.ScopeOrdersRead},
+	})
+
+	assert.ErrorIs(t, err, ErrTokenScopeNotAllowed)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAPITokensService_Create_Validation(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	svc := newTestAPITokensService(mock, time.Now())
+	ctx := context.Background()
+	userID := uuid.New()
+
+	_, err = svc.Create(ctx, userID, CreateAPITokenRequest{Name: " ", Scopes: []types.TokenScope{types.ScopeCatalogRead}})
+	assert.ErrorIs(t, err, ErrAPITokenNameRequired)
+
+	_, err = svc.Create(ctx, userID, CreateAPITokenRequest{Name: "feed"})
+	assert.ErrorIs(t, err, ErrAPITokenScopesRequired)
+
+	_, err = svc.Create(ctx, userID, CreateAPITokenRequest{
+		Name:   "feed",
+		Scopes: []types.TokenScope{types.ScopeCatalogRead},
+		TTL:    MaxAPITokenTTL + time.Hour,
+	})
+	assert.ErrorIs(t, err, ErrInvalidAPITokenTTL)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAPITokensService_Authenticate_Success(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	now := time.Now()
+	svc := newTestAPITokensService(mock, now)
+	token, err := generateAPIToken()
+	require.NoError(t, err)
+	tokenID := uuid.New()
+	userID := uuid.New()
+
+	// Роль понижена до покупателя после выпуска токена - право на запись каталога отбрасывается
+	mock.ExpectQuery(`SELECT \* FROM api_tokens WHERE token_hash = @token_hash`).
+		WithArgs(hashToken(token)).
+		WillReturnRows(pgxmock.NewRows(apiTokenColumns).AddRow(
+			tokenID, userID, "1C", token[:apiTokenDisplayLength], hashToken(token),
+			[]types.TokenScope{types.ScopeCatalogRead, types.ScopeCatalogWrite},
+			now.Add(time.Hour), nil, nil, now.Add(-time.Hour),
+		))
+	expectUserByID(mock, userID, "former-staff@example.com", types.RoleCustomer)
+	mock.ExpectExec(`UPDATE api_tokens
+		SET last_used_at = @now`).
+		WithArgs(now, tokenID, now.Add(-apiTokenTouchInterval)).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+
+	principal, err := svc.Authenticate(context.Background(), token)
+
+	require.NoError(t, err)
+	assert.Equal(t, userID, principal.User.ID)
+	assert.Equal(t, []types.TokenScope{types.ScopeCatalogRead}, principal.Scopes)
+	assert.True(t, principal.HasScope(types.ScopeCatalogRead))
+	assert.False(t, principal.HasScope(types.ScopeCatalogWrite))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAPITokensService_Authenticate_Rejected(t *testing.T) {
+	now := time.Now()
+	revokedAt := now.Add(-time.Minute)
+
+	tests := []struct {
+		name      string
+		expiresAt time.Time
+		revokedAt *time.Time
+	}{
+		{"отозванный токен", now.Add(time.Hour), &revokedAt},
+		{"просроченный токен", now.Add(-time.Second), nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock, err := pgxmock.NewPool()
+			require.NoError(t, err)
+			defer mock.Close()
+
+			svc := newTestAPITokensService(mock, now)
+			token, err := generateAPIToken()
+			require.NoError(t, err)
+
+			mock.ExpectQuery(`SELECT \* FROM api_tokens WHERE token_hash = @token_hash`).
+				WithArgs(hashToken(token)).
+				WillReturnRows(pgxmock.NewRows(apiTokenColumns).AddRow(
+					uuid.New(), uuid.New(), "1C", token[:apiTokenDisplayLength], hashToken(token),
+					[]types.TokenScope{types.ScopeCatalogRead},
+					tt.expiresAt, nil, tt.revokedAt, now.Add(-time.Hour),
+				))
+
+			principal, err := svc.Authenticate(context.Background(), token)
+
+			assert.Nil(t, principal)
+			assert.ErrorIs(t, err, ErrInvalidAPIToken)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestAPITokensService_Authenticate_MalformedSkipsDatabase(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	svc := newTestAPITokensService(mock, time.Now())
+
+	_, err = svc.Authenticate(context.Background(), "lxc_pat_not-a-real-token")
+
+	assert.ErrorIs(t, err, ErrInvalidAPIToken)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAPITokensService_Revoke_NotFound(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	svc := newTestAPITokensService(mock, time.Now())
+
+	mock.ExpectExec(`UPDATE api_tokens
+		SET revoked_at = NOW\(\)`).
+		WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg()).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+
+	err = svc.Revoke(context.Background(), uuid.New(), uuid.New())
+
+	assert.ErrorIs(t, err, ErrAPITokenNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,177 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/LigeronAhill/luxcarpets-go/internal/database/types"
+	"github.com/LigeronAhill/luxcarpets-go/pkg/blob"
+	"github.com/LigeronAhill/luxcarpets-go/pkg/imaging"
+	"github.com/google/uuid"
+)
+
+// Ошибки загрузки аватара
+var (
+	// ErrAvatarTooLarge возвращается, если файл или размеры изображения превышают ограничения
+	ErrAvatarTooLarge = errors.New("avatar image is too large")
+	// ErrUnsupportedImage возвращается, если загруженный файл не является изображением JPEG, PNG или WebP
+	ErrUnsupportedImage = errors.New("image must be a JPEG, PNG or WebP file")
+)
+
+// AvatarConfig содержит ограничения и размеры аватаров
+type AvatarConfig struct {
+	MaxBytes  int64 // Максимальный размер файла
+	MaxPixels int   // Максимальное произведение ширины на высоту
+	Sizes     []int // Стороны квадратных миниатюр в пикселях
+}
+
+// DefaultAvatarConfig - настройки по умолчанию
+var DefaultAvatarConfig = AvatarConfig{
+	MaxBytes:  5 << 20,
+	MaxPixels: 40_000_000,
+	Sizes:     []int{64, 128, 256, 512},
+}
+
+// AvatarService принимает изображения профиля, строит миниатюры и сохраняет их в хранилище.
+// Миниатюры сохраняются по ключам "avatars/<user_id>/<версия>/<размер>.<расширение>";
+// в image_url пользователя записывается адрес самой большой миниатюры,
+// адреса остальных получаются заменой последнего сегмента.
+type AvatarService struct {
+	users   *UsersService
+	blobs   blob.Storage
+	encoder imaging.Encoder
+	cfg     AvatarConfig
+}
+
+// NewAvatarService создает сервис аватаров. Незаданные параметры берутся из DefaultAvatarConfig.
+func NewAvatarService(users *UsersService, blobs blob.Storage, encoder imaging.Encoder, cfg AvatarConfig) *AvatarService {
+	if cfg.MaxBytes <= 0 {
+		cfg.MaxBytes = DefaultAvatarConfig.MaxBytes
+	}
+	if cfg.MaxPixels <= 0 {
+		cfg.MaxPixels = DefaultAvatarConfig.MaxPixels
+	}
+	if len(cfg.Sizes) == 0 {
+		cfg.Sizes = DefaultAvatarConfig.Sizes
+	}
+	return &AvatarService{
+		users:   users,
+		blobs:   blobs,
+		encoder: encoder,
+		cfg:     cfg,
+	}
+}
+
+// MaxBytes возвращает максимальный размер загружаемого файла
+func (s *AvatarService) MaxBytes() int64 {
+	return s.cfg.MaxBytes
+}
+
+// Upload обрабатывает изображение и устанавливает его аватаром пользователя.
+// Формат определяется по содержимому, метаданные (EXIF, GPS) в миниатюры не попадают.
+// Миниатюры предыдущего аватара удаляются после сохранения нового.
+//
+// Возможные ошибки:
+//   - ErrAvatarTooLarge: если файл больше MaxBytes или изображение больше MaxPixels
+//   - ErrUnsupportedImage: если файл не является изображением JPEG, PNG или WebP
+//   - ErrUserNotFound: если пользователь не найден
+//   - ошибки хранилища
+func (s *AvatarService) Upload(ctx context.Context, userID uuid.UUID, r io.Reader) (*types.PublicUser, error) {
+	data, err := io.ReadAll(io.LimitReader(r, s.cfg.MaxBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read avatar: %w", err)
+	}
+	if int64(len(data)) > s.cfg.MaxBytes {
+		return nil, ErrAvatarTooLarge
+	}
+	img, err := imaging.Decode(data, s.cfg.MaxPixels)
+	if err != nil {
+		if errors.Is(err, imaging.ErrTooManyPixels) {
+			return nil, ErrAvatarTooLarge
+		}
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedImage, err)
+	}
+
+	current, err := s.users.GetByID(ctx, userID.String())
+	if err != nil {
+		return nil, err
+	}
+
+	version, err := avatarVersion()
+	if err != nil {
+		return nil, err
+	}
+	var largestURL string
+	for _, size := range s.cfg.Sizes {
+		var buf bytes.Buffer
+		if err := s.encoder.Encode(&buf, imaging.SquareThumbnail(img, size)); err != nil {
+			return nil, fmt.Errorf("failed to encode avatar: %w", err)
+		}
+		key := s.key(userID, version, size)
+		if err := s.blobs.Put(ctx, key, &buf, int64(buf.Len()), s.encoder.ContentType()); err != nil {
+			return nil, fmt.Errorf("failed to store avatar: %w", err)
+		}
+		if size == s.largestSize() {
+			largestURL = s.blobs.URL(key)
+		}
+	}
+
+	updated, err := s.users.Update(ctx, types.UpdateUserParams{ID: userID, ImageURL: &largestURL})
+	if err != nil {
+		return nil, err
+	}
+	if current.ImageURL != "" && current.ImageURL != largestURL {
+		s.deletePrevious(ctx, userID, current.ImageURL)
+	}
+	return updated, nil
+}
+
+// deletePrevious удаляет миниатюры прежнего аватара, если он был загружен через этот сервис.
+// Ошибки только логируются: новый аватар уже сохранен.
+func (s *AvatarService) deletePrevious(ctx context.Context, userID uuid.UUID, imageURL string) {
+	prefix := s.blobs.URL("avatars/" + userID.String() + "/")
+	rest, ok := strings.CutPrefix(imageURL, prefix)
+	if !ok {
+		return
+	}
+	version, _, ok := strings.Cut(rest, "/")
+	if !ok || version == "" {
+		return
+	}
+	for _, size := range s.cfg.Sizes {
+		if err := s.blobs.Delete(ctx, s.key(userID, version, size)); err != nil {
+			slog.WarnContext(ctx, "Failed to delete previous avatar",
+				slog.String("user_id", userID.String()),
+				slog.String("error", err.Error()),
+			)
+		}
+	}
+}
+
+// key возвращает ключ миниатюры в хранилище
+func (s *AvatarService) key(userID uuid.UUID, version string, size int) string {
+	return "avatars/" + userID.String() + "/" + version + "/" + strconv.Itoa(size) + "." + s.encoder.Extension()
+}
+
+// largestSize возвращает размер самой большой миниатюры
+func (s *AvatarService) largestSize() int {
+	return slices.Max(s.cfg.Sizes)
+}
+
+// avatarVersion возвращает случайный сегмент пути, чтобы новый аватар не отдавался из кеша браузера или CDN
+func avatarVersion() (string, error) {
+	raw := make([]byte, 8)
+	if _, err := rand.Read(raw); err != nil {
+		return "", fmt.Errorf("failed to generate avatar version: %w", err)
+	}
+	return hex.EncodeToString(raw), nil
+}
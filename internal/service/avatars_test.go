@@ -0,0 +1,156 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"image/png"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/LigeronAhill/luxcarpets-go/internal/database"
+	"github.com/LigeronAhill/luxcarpets-go/internal/database/types"
+	"github.com/LigeronAhill/luxcarpets-go/pkg/blob"
+	"github.com/LigeronAhill/luxcarpets-go/pkg/imaging"
+	"github.com/google/uuid"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var avatarUserColumns = []string{
+	"id", "email", "email_verified", "username", "role", "image_url",
+	"password_hash", "created_at", "updated_at", "deleted_at", "phone", "phone_verified",
+}
+
+func testPNG(t *testing.T, w, h int) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := range h {
+		for x := range w {
+			img.Set(x, y, color.RGBA{R: uint8(x), G: uint8(y), B: 128, A: 255})
+		}
+	}
+	var buf bytes.Buffer
+	require.NoError(t, png.Encode(&buf, img))
+	return buf.Bytes()
+}
+
+func newTestAvatarService(t *testing.T, mock pgxmock.PgxPoolIface, cfg AvatarConfig) (*AvatarService, *blob.Local) {
+	t.Helper()
+	storage, err := blob.NewLocal(t.TempDir(), "/media")
+	require.NoError(t, err)
+	users := NewUsersService(database.NewUsersStorage(mock))
+	return NewAvatarService(users, storage, imaging.JPEGEncoder{}, cfg), storage
+}
+
+func TestAvatarService_Upload_Success(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	svc, storage := newTestAvatarService(t, mock, AvatarConfig{Sizes: []int{32, 64}})
+	userID := uuid.New()
+	now := time.Now()
+	email := "user@test.com"
+
+	// Предыдущий аватар, загруженный через сервис
+	oldKeys := []string{"avatars/" + userID.String() + "/old/32.jpg", "avatars/" + userID.String() + "/old/64.jpg"}
+	for _, key := range oldKeys {
+		require.NoError(t, storage.Put(context.Background(), key, strings.NewReader("old"), 3, "image/jpeg"))
+	}
+	oldURL := storage.URL(oldKeys[1])
+
+	mock.ExpectQuery(`SELECT \* FROM users WHERE id = @id`).
+		WithArgs(userID).
+		WillReturnRows(pgxmock.NewRows(avatarUserColumns).AddRow(
+			userID, &email, true, "testuser", types.RoleCustomer, &oldURL,
+			nil, now, now, nil, nil, false,
+		))
+	newURL := &capturedArg{}
+	mock.ExpectQuery(`UPDATE users`).
+		WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg(), newURL, pgxmock.AnyArg(), pgxmock.AnyArg(), userID).
+		WillReturnRows(pgxmock.NewRows(avatarUserColumns).AddRow(
+			userID, &email, true, "testuser", types.RoleCustomer, strPtr("/media/avatars/new/64.jpg"),
+			nil, now, now, nil, nil, false,
+		))
+
+	user, err := svc.Upload(context.Background(), userID, bytes.NewReader(testPNG(t, 120, 80)))
+
+	require.NoError(t, err)
+	assert.Equal(t, userID, user.ID)
+
+	imageURL, ok := newURL.value.(*string)
+	require.True(t, ok)
+	assert.Regexp(t, `^/media/avatars/`+userID.String()+`/[0-9a-f]{16}/64\.jpg$`, *imageURL)
+
+	// Все размеры сохранены в JPEG нужного размера
+	for _, size := range []int{32, 64} {
+		key := strings.TrimPrefix(strings.Replace(*imageURL, "/64.jpg", "/"+strconv.Itoa(size)+".jpg", 1), "/media/")
+		data, err := os.ReadFile(filepath.Join(storage.Root(), filepath.FromSlash(key)))
+		require.NoError(t, err)
+		cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+		require.NoError(t, err)
+		assert.Equal(t, "jpeg", format)
+		assert.Equal(t, size, cfg.Width)
+		assert.Equal(t, size, cfg.Height)
+	}
+
+	// Предыдущие миниатюры удалены
+	for _, key := range oldKeys {
+		_, err := os.Stat(filepath.Join(storage.Root(), filepath.FromSlash(key)))
+		assert.True(t, os.IsNotExist(err), key)
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAvatarService_Upload_Rejected(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     AvatarConfig
+		data    []byte
+		wantErr error
+	}{
+		{
+			name:    "файл больше лимита",
+			cfg:     AvatarConfig{MaxBytes: 100},
+			data:    bytes.Repeat([]byte{0}, 101),
+			wantErr: ErrAvatarTooLarge,
+		},
+		{
+			name:    "слишком много пикселей",
+			cfg:     AvatarConfig{MaxPixels: 100},
+			data:    testPNG(t, 20, 20),
+			wantErr: ErrAvatarTooLarge,
+		},
+		{
+			name:    "не изображение",
+			data:    []byte("<svg xmlns=\"http://www.w3.org/2000/svg\"><script>alert(1)</script></svg>"),
+			wantErr: ErrUnsupportedImage,
+		},
+		{
+			name:    "поврежденный файл",
+			data:    testPNG(t, 20, 20)[:40],
+			wantErr: ErrUnsupportedImage,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock, err := pgxmock.NewPool()
+			require.NoError(t, err)
+			defer mock.Close()
+
+			svc, _ := newTestAvatarService(t, mock, tt.cfg)
+
+			_, err = svc.Upload(context.Background(), uuid.New(), bytes.NewReader(tt.data))
+
+			assert.ErrorIs(t, err, tt.wantErr)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
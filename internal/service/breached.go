@@ -0,0 +1,126 @@
+package service
+
+import (
+	"bufio"
+	"crypto/sha1"
+	_ "embed"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// bundledBreachedPasswords - встроенный список SHA-1 хешей распространенных паролей
+//
+//go:embed data/breached_passwords.txt
+var bundledBreachedPasswords string
+
+// breachedPrefixLength - длина префикса SHA-1, по которому запрашивается диапазон хешей
+const breachedPrefixLength = 5
+
+// BreachedPasswordChecker возвращает диапазоны хешей утекших паролей по модели k-anonymity:
+// по первым 5 символам SHA-1 отдаются все известные суффиксы, а сравнение выполняется на стороне вызывающего.
+// Так реализация может обращаться к внешнему сервису, не передавая ему ни пароль, ни полный хеш.
+type BreachedPasswordChecker interface {
+	// Range возвращает суффиксы SHA-1 (35 символов в верхнем регистре) для префикса из 5 символов
+	Range(prefix string) ([]string, error)
+}
+
+// BreachedPasswordList - локальный список утекших паролей, сгруппированный по префиксам SHA-1.
+// Не обращается к сети.
+type BreachedPasswordList struct {
+	ranges map[string][]string
+}
+
+// NewBreachedPasswordList создает пустой список утекших паролей
+func NewBreachedPasswordList() *BreachedPasswordList {
+	return &BreachedPasswordList{
+		ranges: make(map[string][]string),
+	}
+}
+
+// BundledBreachedPasswords возвращает встроенный в бинарный файл список утекших паролей
+var BundledBreachedPasswords = sync.OnceValue(func() *BreachedPasswordList {
+	list := NewBreachedPasswordList()
+	if err := list.Load(strings.NewReader(bundledBreachedPasswords)); err != nil {
+		panic(fmt.Sprintf("invalid bundled breached passwords list: %v", err))
+	}
+	return list
+})
+
+// LoadBreachedPasswordsFile создает список из встроенных хешей и хешей из файла.
+// Файл в формате выгрузки Pwned Passwords: по одному "HASH" или "HASH:COUNT" на строку.
+func LoadBreachedPasswordsFile(path string) (*BreachedPasswordList, error) {
+	list := NewBreachedPasswordList()
+	if err := list.Load(strings.NewReader(bundledBreachedPasswords)); err != nil {
+		return nil, fmt.Errorf("failed to load bundled breached passwords: %w", err)
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open breached passwords file: %w", err)
+	}
+	defer f.Close()
+
+	if err := list.Load(f); err != nil {
+		return nil, fmt.Errorf("failed to load breached passwords file %s: %w", path, err)
+	}
+	return list, nil
+}
+
+// Load добавляет в список хеши из r.
+// Пустые строки и строки, начинающиеся с #, пропускаются.
+func (l *BreachedPasswordList) Load(r io.Reader) error {
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+		hash, _, _ := strings.Cut(text, ":")
+		hash = strings.ToUpper(hash)
+		if len(hash) != sha1.Size*2 {
+			return fmt.Errorf("line %d: invalid SHA-1 hash length", line)
+		}
+		if _, err := hex.DecodeString(hash); err != nil {
+			return fmt.Errorf("line %d: invalid SHA-1 hash: %w", line, err)
+		}
+		prefix, suffix := hash[:breachedPrefixLength], hash[breachedPrefixLength:]
+		l.ranges[prefix] = append(l.ranges[prefix], suffix)
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+	for prefix := range l.ranges {
+		slices.Sort(l.ranges[prefix])
+		l.ranges[prefix] = slices.Compact(l.ranges[prefix])
+	}
+	return nil
+}
+
+// Range возвращает суффиксы хешей для префикса
+func (l *BreachedPasswordList) Range(prefix string) ([]string, error) {
+	return l.ranges[strings.ToUpper(prefix)], nil
+}
+
+// isBreachedPassword проверяет, встречается ли пароль в списке утекших
+func isBreachedPassword(checker BreachedPasswordChecker, password string) (bool, error) {
+	sum := sha1.Sum([]byte(password))
+	hash := strings.ToUpper(hex.EncodeToString(sum[:]))
+
+	suffixes, err := checker.Range(hash[:breachedPrefixLength])
+	if err != nil {
+		return false, err
+	}
+	for _, suffix := range suffixes {
+		if strings.EqualFold(suffix, hash[breachedPrefixLength:]) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,100 @@
+package service
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBundledBreachedPasswords(t *testing.T) {
+	list := BundledBreachedPasswords()
+
+	tests := []struct {
+		password string
+		breached bool
+	}{
+		{"123456", true},
+		{"password", true},
+		{"P@ssw0rd", true},
+		{"Qwerty123!", true},
+		{"TestP@ssw0rd", false},
+		{"Kj8#mQ2$vLp9", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.password, func(t *testing.T) {
+			breached, err := isBreachedPassword(list, tt.password)
+			require.NoError(t, err)
+			assert.Equal(t, tt.breached, breached)
+		})
+	}
+}
+
+func TestBreachedPasswordList_Load(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr bool
+	}{
+		{
+			name:  "хеши с количеством и без",
+			input: "# комментарий\n\n5BAA61E4C9B93F3F0682250B6CF8331B7EE68FD8:3861493\n7c4a8d09ca3762af61e59520943dc26494f8941b\n",
+		},
+		{
+			name:    "неверная длина",
+			input:   "5BAA61E4C9B93F3F:1\n",
+			wantErr: true,
+		},
+		{
+			name:    "не шестнадцатеричные символы",
+			input:   "ZZZA61E4C9B93F3F0682250B6CF8331B7EE68FD8\n",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			list := NewBreachedPasswordList()
+			err := list.Load(strings.NewReader(tt.input))
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+
+			// Диапазон возвращает только суффиксы, без полного хеша
+			suffixes, err := list.Range("5baa6")
+			require.NoError(t, err)
+			assert.Equal(t, []string{"1E4C9B93F3F0682250B6CF8331B7EE68FD8"}, suffixes)
+
+			breached, err := isBreachedPassword(list, "123456")
+			require.NoError(t, err)
+			assert.True(t, breached)
+		})
+	}
+}
+
+func TestLoadBreachedPasswordsFile(t *testing.T) {
+	// SHA-1 от "Kj8#mQ2$vLp9"
+	path := filepath.Join(t.TempDir(), "breached.txt")
+	require.NoError(t, os.WriteFile(path, []byte("E18229D304499E67B7962C96DEEF1D8AA0C4282F:2\n"), 0o600))
+
+	list, err := LoadBreachedPasswordsFile(path)
+	require.NoError(t, err)
+
+	breached, err := isBreachedPassword(list, "Kj8#mQ2$vLp9")
+	require.NoError(t, err)
+	assert.True(t, breached)
+
+	// Встроенный список сохраняется
+	breached, err = isBreachedPassword(list, "P@ssw0rd")
+	require.NoError(t, err)
+	assert.True(t, breached)
+
+	_, err = LoadBreachedPasswordsFile(filepath.Join(t.TempDir(), "missing.txt"))
+	assert.Error(t, err)
+}
@@ -0,0 +1,112 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/LigeronAhill/luxcarpets-go/internal/database"
+	"github.com/LigeronAhill/luxcarpets-go/internal/database/types"
+	"github.com/LigeronAhill/luxcarpets-go/pkg/blob"
+)
+
+// CatalogService формирует страницы каталога: товары по фильтрам и количество товаров
+// для каждого значения фильтра. Один вызов List дает все данные и для полной страницы,
+// и для фрагментов, которыми страница обновляется без перезагрузки. Product возвращает данные страницы товара.
+type CatalogService struct {
+	storage *database.CatalogStorage
+	blobs   blob.Storage
+}
+
+func NewCatalogService(storage *database.CatalogStorage, blobs blob.Storage) *CatalogService {
+	return &CatalogService{
+		storage: storage,
+		blobs:   blobs,
+	}
+}
+
+// List возвращает страницу каталога с карточками товаров и значениями фильтров
+func (s *CatalogService) List(ctx context.Context, q types.CatalogQuery) (*types.CatalogListing, error) {
+	products, total, err := s.storage.List(ctx, q)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list catalog: %w", err)
+	}
+	for _, product := range products {
+		product.Cover = imageSources(s.blobs, product.CoverRenditions)
+	}
+
+	listing := &types.CatalogListing{
+		Query:    q,
+		Products: products,
+		Total:    total,
+		Page:     max(q.Page, 1),
+		Pages:    max((total+types.CatalogPageSize-1)/types.CatalogPageSize, 1),
+	}
+
+	if listing.Facets.Categories, err = s.facet(ctx, q, types.FacetCategory); err != nil {
+		return nil, err
+	}
+	if listing.Facets.Colors, err = s.facet(ctx, q, types.FacetColor); err != nil {
+		return nil, err
+	}
+	if listing.Facets.Brands, err = s.facet(ctx, q, types.FacetBrand); err != nil {
+		return nil, err
+	}
+	return listing, nil
+}
+
+// Product возвращает активный товар по части URL с категорией и вариантами в продаже
+//
+// Возможные ошибки:
+//   - ErrProductNotFound: если товара нет, он удален или скрыт
+//   - ошибки хранилища
+func (s *CatalogService) Product(ctx context.Context, slug string) (*types.ProductDetails, error) {
+	product, err := s.storage.Product(ctx, slug)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product: %w", err)
+	}
+	if product == nil {
+		return nil, ErrProductNotFound
+	}
+	category, err := s.storage.Category(ctx, product.CategoryID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get product category: %w", err)
+	}
+	variants, err := s.storage.Variants(ctx, product.ID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list product variants: %w", err)
+	}
+	return &types.ProductDetails{
+		Product:  product,
+		Category: category,
+		Variants: variants,
+	}, nil
+}
+
+// facet возвращает значения фильтра и отмечает выбранные
+func (s *CatalogService) facet(ctx context.Context, q types.CatalogQuery, facet string) ([]types.FacetValue, error) {
+	values, err := s.storage.Facet(ctx, q, facet)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count catalog facet %s: %w", facet, err)
+	}
+	for i := range values {
+		value := &values[i]
+		switch facet {
+		case types.FacetCategory:
+			value.Selected = value.Value == q.Category
+		case types.FacetColor:
+			value.Label = types.ColorFamily(value.Value).Label()
+			value.Selected = q.HasColor(types.ColorFamily(value.Value))
+		case types.FacetBrand:
+			value.Selected = q.HasBrand(value.Value)
+		}
+	}
+	if facet == types.FacetColor {
+		// Цвета показываются в постоянном порядке, а не по количеству товаров
+		order := types.AllColorFamilies()
+		slices.SortFunc(values, func(a, b types.FacetValue) int {
+			return slices.Index(order, types.ColorFamily(a.Value)) - slices.Index(order, types.ColorFamily(b.Value))
+		})
+	}
+	return values, nil
+}
@@ -0,0 +1,180 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/LigeronAhill/luxcarpets-go/internal/database"
+	"github.com/LigeronAhill/luxcarpets-go/internal/database/types"
+	"github.com/LigeronAhill/luxcarpets-go/pkg/blob"
+	"github.com/google/uuid"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var productCardColumns = []string{
+	"id", "slug", "name", "brand", "color_family", "dominant_color", "updated_at",
+	"min_price_kopecks", "in_stock", "cover_alt_text", "cover_renditions",
+}
+
+func newTestCatalogService(t *testing.T, mock pgxmock.PgxPoolIface) *CatalogService {
+	t.Helper()
+	storage, err := blob.NewLocal(t.TempDir(), "/media")
+	require.NoError(t, err)
+	return NewCatalogService(database.NewCatalogStorage(mock), storage)
+}
+
+func facetRows(values ...string) *pgxmock.Rows {
+	rows := pgxmock.NewRows([]string{"value", "label", "count"})
+	for _, value := range values {
+		rows.AddRow(value, value, int64(2))
+	}
+	return rows
+}
+
+func TestCatalogService_List(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	svc := newTestCatalogService(t, mock)
+	price := int64(1299000)
+	renditions := []types.Rendition{
+		{Width: 320, Height: 240, Format: "jpg", Key: "media/ab/abcd/320.jpg"},
+		{Width: 640, Height: 480, Format: "jpg", Key: "media/ab/abcd/640.jpg"},
+	}
+	q := types.CatalogQuery{Colors: []types.ColorFamily{types.ColorGray}, Brands: []string{"Osta"}, Page: 1}
+
+	mock.ExpectQuery(`SELECT COUNT\(\*\)`).
+		WithArgs([]string{"gray"}, []string{"Osta"}).
+		WillReturnRows(pgxmock.NewRows([]string{"count"}).AddRow(25))
+	mock.ExpectQuery(`SELECT p.id, p.slug, `).
+		WithArgs([]string{"gray"}, []string{"Osta"}, types.CatalogPageSize).
+		WillReturnRows(pgxmock.NewRows(productCardColumns).AddRow(
+			uuid.New(), "kover-osta", "Ковер Osta", strPtr("Osta"), nil, nil, time.Now(),
+			&price, true, strPtr("Ковер"), renditions,
+		))
+	mock.ExpectQuery(`SELECT c.slug AS value`).
+		WithArgs([]string{"gray"}, []string{"Osta"}).
+		WillReturnRows(pgxmock.NewRows([]string{"value", "label", "count"}).AddRow("kovry", "Ковры", int64(25)))
+	mock.ExpectQuery(`SELECT p.color_family AS value`).
+		WithArgs([]string{"Osta"}).
+		WillReturnRows(facetRows("gray", "beige", "white"))
+	mock.ExpectQuery(`SELECT p.brand AS value`).
+		WithArgs([]string{"gray"}).
+		WillReturnRows(facetRows("Merinos", "Osta"))
+
+	listing, err := svc.List(context.Background(), q)
+
+	require.NoError(t, err)
+	assert.Equal(t, 25, listing.Total)
+	assert.Equal(t, 1, listing.Page)
+	assert.Equal(t, 2, listing.Pages)
+	require.Len(t, listing.Products, 1)
+	assert.Equal(t, "/media/media/ab/abcd/320.jpg 320w, /media/media/ab/abcd/640.jpg 640w", listing.Products[0].Cover.Srcset())
+
+	assert.Equal(t, []types.FacetValue{{Value: "kovry", Label: "Ковры", Count: 25}}, listing.Facets.Categories)
+	// Цвета - в порядке фильтра, с русскими названиями
+	assert.Equal(t, []types.FacetValue{
+		{Value: "white", Label: "Белый", Count: 2},
+		{Value: "beige", Label: "Бежевый", Count: 2},
+		{Value: "gray", Label: "Серый", Count: 2, Selected: true},
+	}, listing.Facets.Colors)
+	assert.Equal(t, []types.FacetValue{
+		{Value: "Merinos", Label: "Merinos", Count: 2},
+		{Value: "Osta", Label: "Osta", Count: 2, Selected: true},
+	}, listing.Facets.Brands)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCatalogService_List_StorageError(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	svc := newTestCatalogService(t, mock)
+	mock.ExpectQuery(`SELECT COUNT\(\*\)`).WillReturnError(errors.New("connection refused"))
+
+	listing, err := svc.List(context.Background(), types.CatalogQuery{Page: 1})
+
+	assert.Nil(t, listing)
+	assert.ErrorContains(t, err, "failed to list catalog")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCatalogService_Product(t *testing.T) {
+	productColumns := []string{
+		"id", "category_id", "slug", "name", "description", "brand", "material", "color_family", "dominant_color",
+		"is_active", "created_at", "updated_at", "deleted_at",
+	}
+	categoryColumns := []string{"id", "parent_id", "slug", "name", "description", "position", "created_at", "updated_at", "deleted_at"}
+	variantColumns := []string{
+		"id", "product_id", "sku", "name", "width_cm", "length_cm", "unit", "price_kopecks", "stock",
+		"created_at", "updated_at", "deleted_at",
+	}
+	productID := uuid.New()
+	categoryID := uuid.New()
+	now := time.Now()
+
+	tests := []struct {
+		name          string
+		setupMock     func(mock pgxmock.PgxPoolIface)
+		expectedError error
+	}{
+		{
+			name: "товар с категорией и вариантами",
+			setupMock: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery(`SELECT \* FROM products`).
+					WithArgs("kover-osta").
+					WillReturnRows(pgxmock.NewRows(productColumns).AddRow(
+						productID, categoryID, "kover-osta", "Ковер Osta", nil, nil, nil, nil, nil, true, now, now, nil,
+					))
+				mock.ExpectQuery(`SELECT \* FROM categories`).
+					WithArgs(categoryID).
+					WillReturnRows(pgxmock.NewRows(categoryColumns).AddRow(
+						categoryID, nil, "kovry", "Ковры", nil, 1, now, now, nil,
+					))
+				mock.ExpectQuery(`SELECT \* FROM product_variants`).
+					WithArgs(productID).
+					WillReturnRows(pgxmock.NewRows(variantColumns).AddRow(
+						uuid.New(), productID, "OD-200", "200x300 см", nil, nil, types.UnitPiece, int64(4599000), 2, now, now, nil,
+					))
+			},
+		},
+		{
+			name: "товар не найден",
+			setupMock: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectQuery(`SELECT \* FROM products`).
+					WithArgs("kover-osta").
+					WillReturnRows(pgxmock.NewRows(productColumns))
+			},
+			expectedError: ErrProductNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock, err := pgxmock.NewPool()
+			require.NoError(t, err)
+			defer mock.Close()
+
+			svc := newTestCatalogService(t, mock)
+			tt.setupMock(mock)
+
+			res, err := svc.Product(context.Background(), "kover-osta")
+
+			if tt.expectedError != nil {
+				assert.ErrorIs(t, err, tt.expectedError)
+				assert.Nil(t, res)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, productID, res.Product.ID)
+				assert.Equal(t, "Ковры", res.Category.Name)
+				require.Len(t, res.Variants, 1)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
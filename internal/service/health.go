@@ -0,0 +1,55 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Ошибки проверки готовности
+var (
+	// ErrDatabaseUnavailable возвращается, когда база данных не отвечает
+	ErrDatabaseUnavailable = errors.New("database unavailable")
+	// ErrMigrationsPending возвращается, когда схема базы отстает от миграций приложения
+	ErrMigrationsPending = errors.New("database migrations pending")
+)
+
+// HealthStore проверяет состояние базы данных. Реализация: database.HealthStorage.
+type HealthStore interface {
+	Ping(ctx context.Context) error
+	SchemaVersion(ctx context.Context) (int32, error)
+}
+
+// HealthService проверяет готовность сервера принимать запросы
+type HealthService struct {
+	store  HealthStore
+	latest int32
+}
+
+// NewHealthService создает сервис проверки готовности.
+// latest - номер последней миграции, встроенной в приложение (database.LatestMigration).
+func NewHealthService(store HealthStore, latest int32) *HealthService {
+	return &HealthService{
+		store:  store,
+		latest: latest,
+	}
+}
+
+// Ready проверяет, что база данных доступна и все миграции применены.
+//
+// Возможные ошибки:
+//   - ErrDatabaseUnavailable: если база не отвечает или версию схемы не удалось прочитать
+//   - ErrMigrationsPending: если применены не все миграции
+func (s *HealthService) Ready(ctx context.Context) error {
+	if err := s.store.Ping(ctx); err != nil {
+		return fmt.Errorf("%w: %w", ErrDatabaseUnavailable, err)
+	}
+	version, err := s.store.SchemaVersion(ctx)
+	if err != nil {
+		return fmt.Errorf("%w: %w", ErrDatabaseUnavailable, err)
+	}
+	if version < s.latest {
+		return fmt.Errorf("%w: schema version %d, expected %d", ErrMigrationsPending, version, s.latest)
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/LigeronAhill/luxcarpets-go/internal/database"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHealthService_Ready(t *testing.T) {
+	tests := []struct {
+		name        string
+		pingErr     error
+		version     int32
+		versionErr  error
+		expectedErr error
+	}{
+		{"все миграции применены", nil, 9, nil, nil},
+		{"база новее приложения", nil, 10, nil, nil},
+		{"база не отвечает", errors.New("connection refused"), 0, nil, ErrDatabaseUnavailable},
+		{"нет таблицы версий", nil, 0, errors.New(`relation "schema_version" does not exist`), ErrDatabaseUnavailable},
+		{"миграции не применены", nil, 8, nil, ErrMigrationsPending},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock, err := pgxmock.NewPool()
+			require.NoError(t, err)
+			defer mock.Close()
+
+			svc := NewHealthService(database.NewHealthStorage(mock), 9)
+			mock.ExpectPing().WillReturnError(tt.pingErr)
+			if tt.pingErr == nil {
+				query := mock.ExpectQuery(`SELECT version FROM schema_version`)
+				if tt.versionErr != nil {
+					query.WillReturnError(tt.versionErr)
+				} else {
+					query.WillReturnRows(pgxmock.NewRows([]string{"version"}).AddRow(tt.version))
+				}
+			}
+
+			err = svc.Ready(context.Background())
+
+			if tt.expectedErr != nil {
+				assert.ErrorIs(t, err, tt.expectedErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
@@ -0,0 +1,105 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/LigeronAhill/luxcarpets-go/internal/database"
+	"github.com/LigeronAhill/luxcarpets-go/internal/database/types"
+)
+
+// LLMsService формирует llms.txt (https://llmstxt.org) - краткое описание магазина в Markdown
+// для языковых моделей: разделы каталога и производители с количеством товаров, сведения о магазине
+// и ссылки на основные страницы. Количество товаров считается так же, как фильтры каталога.
+type LLMsService struct {
+	catalog *database.CatalogStorage
+	shop    ShopInfo
+	baseURL string
+}
+
+// NewLLMsService создает сервис llms.txt. baseURL - публичный адрес сайта.
+func NewLLMsService(catalog *database.CatalogStorage, shop ShopInfo, baseURL string) *LLMsService {
+	return &LLMsService{
+		catalog: catalog,
+		shop:    shop,
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+	}
+}
+
+// Text возвращает содержимое llms.txt
+func (s *LLMsService) Text(ctx context.Context) (string, error) {
+	all := types.CatalogQuery{Page: 1}
+	categories, err := s.catalog.Facet(ctx, all, types.FacetCategory)
+	if err != nil {
+		return "", fmt.Errorf("failed to list catalog categories: %w", err)
+	}
+	brands, err := s.catalog.Facet(ctx, all, types.FacetBrand)
+	if err != nil {
+		return "", fmt.Errorf("failed to list catalog brands: %w", err)
+	}
+
+	var b strings.Builder
+	b.WriteString("# " + s.shop.Name + "\n\n")
+	if s.shop.About != "" {
+		b.WriteString("> " + s.shop.About + "\n\n")
+	}
+	if contacts := s.contacts(); contacts != "" {
+		b.WriteString("Контакты: " + contacts + ".\n\n")
+	}
+
+	if len(categories) > 0 {
+		b.WriteString("## Каталог\n\n")
+		for _, category := range categories {
+			s.writeLink(&b, category.Label, "/catalog?"+url.Values{"category": {category.Value}}.Encode(),
+				"товаров: "+strconv.Itoa(category.Count))
+		}
+		b.WriteString("\n")
+	}
+	if len(brands) > 0 {
+		b.WriteString("## Производители\n\n")
+		for _, brand := range brands {
+			s.writeLink(&b, brand.Label, "/catalog?"+url.Values{"brand": {brand.Value}}.Encode(),
+				"товаров: "+strconv.Itoa(brand.Count))
+		}
+		b.WriteString("\n")
+	}
+
+	b.WriteString("## О магазине\n\n")
+	s.writeLink(&b, "О нас", "/about", s.shop.About)
+	s.writeLink(&b, "Контакты", "/contacts", s.contacts())
+	s.writeLink(&b, "Каталог", "/catalog", "поиск и фильтры по цвету, производителю, цене и наличию")
+	b.WriteString("\n## Optional\n\n")
+	s.writeLink(&b, "Карта сайта", "/sitemap.xml", "все категории и товары")
+	return b.String(), nil
+}
+
+// contacts возвращает контакты магазина одной строкой
+func (s *LLMsService) contacts() string {
+	var parts []string
+	for _, part := range []struct{ title, value string }{
+		{"телефон", s.shop.Phone},
+		{"email", s.shop.Email},
+		{"адрес", s.shop.Address},
+		{"часы работы", s.shop.OpeningHours},
+	} {
+		if part.value != "" {
+			parts = append(parts, part.title+": "+part.value)
+		}
+	}
+	return strings.Join(parts, "; ")
+}
+
+// writeLink записывает пункт списка ссылок: "- [title](url): description"
+func (s *LLMsService) writeLink(b *strings.Builder, title, path, description string) {
+	b.WriteString("- [" + markdownEscaper.Replace(title) + "](" + s.baseURL + path + ")")
+	if description != "" {
+		b.WriteString(": " + description)
+	}
+	b.WriteString("\n")
+}
+
+// markdownEscaper экранирует символы, которые ломают текст ссылки Markdown
+var markdownEscaper = strings.NewReplacer(`\`, `\\`, `[`, `\[`, `]`, `\]`)
@@ -0,0 +1,77 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/LigeronAhill/luxcarpets-go/internal/database"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLLMsService_Text(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	svc := NewLLMsService(database.NewCatalogStorage(mock), ShopInfo{
+		Name:         "LuxCarpets",
+		About:        "Магазин ковровых покрытий.",
+		Phone:        "+74950000000",
+		Address:      "Москва, ул. Примерная, 1",
+		OpeningHours: "Mo-Su 10:00-20:00",
+	}, "https://luxcarpets.ru/")
+
+	mock.ExpectQuery(`SELECT c.slug AS value`).
+		WillReturnRows(pgxmock.NewRows([]string{"value", "label", "count"}).
+			AddRow("kovry", "Ковры", int64(90)).
+			AddRow("plitka", "Плитка [50x50]", int64(4)))
+	mock.ExpectQuery(`SELECT p.brand AS value`).
+		WillReturnRows(pgxmock.NewRows([]string{"value", "label", "count"}).AddRow("Osta", "Osta", int64(12)))
+
+	text, err := svc.Text(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, `# LuxCarpets
+
+> Магазин ковровых покрытий.
+
+Контакты: телефон: +74950000000; адрес: Москва, ул. Примерная, 1; часы работы: Mo-Su 10:00-20:00.
+
+## Каталог
+
+- [Ковры](https://luxcarpets.ru/catalog?category=kovry): товаров: 90
+- [Плитка \[50x50\]](https://luxcarpets.ru/catalog?category=plitka): товаров: 4
+
+## Производители
+
+- [Osta](https://luxcarpets.ru/catalog?brand=Osta): товаров: 12
+
+## О магазине
+
+- [О нас](https://luxcarpets.ru/about): Магазин ковровых покрытий.
+- [Контакты](https://luxcarpets.ru/contacts): телефон: +74950000000; адрес: Москва, ул. Примерная, 1; часы работы: Mo-Su 10:00-20:00
+- [Каталог](https://luxcarpets.ru/catalog): поиск и фильтры по цвету, производителю, цене и наличию
+
+## Optional
+
+- [Карта сайта](https://luxcarpets.ru/sitemap.xml): все категории и товары
+`, text)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestLLMsService_Text_StorageError(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	svc := NewLLMsService(database.NewCatalogStorage(mock), ShopInfo{Name: "LuxCarpets"}, "https://luxcarpets.ru")
+	mock.ExpectQuery(`SELECT c.slug AS value`).WillReturnError(errors.New("connection refused"))
+
+	_, err = svc.Text(context.Background())
+
+	assert.ErrorContains(t, err, "failed to list catalog categories")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,304 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/LigeronAhill/luxcarpets-go/internal/database/types"
+)
+
+// ErrTooManyAttempts возвращается, когда вход временно заблокирован из-за неудачных попыток
+var ErrTooManyAttempts = errors.New("too many failed sign-in attempts")
+
+// LoginBlockedError сообщает, через сколько можно повторить попытку входа.
+// Проверяется через errors.Is(err, ErrTooManyAttempts).
+type LoginBlockedError struct {
+	RetryAfter time.Duration // Время до снятия блокировки
+}
+
+func (e *LoginBlockedError) Error() string {
+	return fmt.Sprintf("%s: retry after %s", ErrTooManyAttempts, e.RetryAfter.Round(time.Second))
+}
+
+func (e *LoginBlockedError) Unwrap() error {
+	return ErrTooManyAttempts
+}
+
+// LoginAttemptsStore хранит счетчики попыток входа.
+// Реализации: MemoryLoginAttemptsStore (один процесс) и database.LoginAttemptsStorage (несколько реплик).
+type LoginAttemptsStore interface {
+	// Get возвращает счетчик для ключа или nil, если попыток не было
+	Get(ctx context.Context, key string) (*types.LoginAttempt, error)
+	// RegisterAttempt атомарно засчитывает попытку, если ключ не заблокирован, и возвращает счетчик после увеличения.
+	// Попытки старше resetBefore не учитываются. После n-й попытки ключ блокируется на waits[n-1]
+	// (на последний элемент, если попыток больше). Для заблокированного ключа возвращает nil.
+	RegisterAttempt(ctx context.Context, key string, now, resetBefore time.Time, waits []time.Duration) (*types.LoginAttempt, error)
+	// Release отменяет засчитанную попытку и блокировку, которую она выставила
+	Release(ctx context.Context, key string) error
+	// Reset сбрасывает счетчик для ключа
+	Reset(ctx context.Context, key string) error
+}
+
+// LockoutPolicy описывает правила задержек и блокировок после неудачных попыток входа
+type LockoutPolicy struct {
+	MaxFailures     int           // После стольких неудач подряд ключ блокируется на LockoutDuration
+	BaseDelay       time.Duration // Задержка после первой неудачи, удваивается с каждой следующей
+	MaxDelay        time.Duration // Максимальная задержка между попытками до блокировки
+	LockoutDuration time.Duration // Длительность временной блокировки
+	ResetAfter      time.Duration // Через сколько времени без неудач счетчик обнуляется
+}
+
+// DefaultAccountLockoutPolicy - политика по умолчанию для учетной записи
+var DefaultAccountLockoutPolicy = LockoutPolicy{
+	MaxFailures:     10,
+	BaseDelay:       time.Second,
+	MaxDelay:        5 * time.Minute,
+	LockoutDuration: 15 * time.Minute,
+	ResetAfter:      time.Hour,
+}
+
+// DefaultIPLockoutPolicy - политика по умолчанию для IP-адреса.
+// Порог выше, так как с одного адреса могут входить разные пользователи (NAT, офис).
+var DefaultIPLockoutPolicy = LockoutPolicy{
+	MaxFailures:     50,
+	BaseDelay:       0,
+	MaxDelay:        0,
+	LockoutDuration: 15 * time.Minute,
+	ResetAfter:      time.Hour,
+}
+
+// maxWaits ограничивает число задержек, если порог блокировки не задан, а задержка растет без предела
+const maxWaits = 32
+
+// waits возвращает задержки после каждой попытки подряд: waits[n-1] действует после n-й попытки,
+// последний элемент - после всех следующих. Начиная с MaxFailures попыток ключ блокируется на LockoutDuration.
+func (p LockoutPolicy) waits() []time.Duration {
+	var res []time.Duration
+	for failures := 1; failures <= maxWaits; failures++ {
+		if p.MaxFailures > 0 && failures >= p.MaxFailures {
+			return append(res, p.LockoutDuration)
+		}
+		d := p.delay(failures)
+		res = append(res, d)
+		if p.MaxFailures <= 0 && (d == 0 || d == p.MaxDelay) {
+			break
+		}
+	}
+	return res
+}
+
+// delay вычисляет экспоненциальную задержку после заданного числа неудач
+func (p LockoutPolicy) delay(failures int) time.Duration {
+	if p.BaseDelay <= 0 || failures <= 0 {
+		return 0
+	}
+	d := p.BaseDelay
+	for i := 1; i < failures; i++ {
+		d *= 2
+		if p.MaxDelay > 0 && d >= p.MaxDelay {
+			return p.MaxDelay
+		}
+	}
+	if p.MaxDelay > 0 && d > p.MaxDelay {
+		return p.MaxDelay
+	}
+	return d
+}
+
+// LoginGuard отслеживает неудачные попытки входа по учетной записи и по IP-адресу
+type LoginGuard struct {
+	store   LoginAttemptsStore
+	account LockoutPolicy
+	ip      LockoutPolicy
+	now     func() time.Time
+}
+
+// NewLoginGuard создает защиту от перебора паролей с указанным хранилищем и политиками
+func NewLoginGuard(store LoginAttemptsStore, account, ip LockoutPolicy) *LoginGuard {
+	return &LoginGuard{
+		store:   store,
+		account: account,
+		ip:      ip,
+		now:     time.Now,
+	}
+}
+
+func accountKey(email string) string {
+	return "account:" + strings.ToLower(strings.TrimSpace(email))
+}
+
+func ipKey(ip string) string {
+	return "ip:" + ip
+}
+
+// Attempt засчитывает попытку входа для учетной записи и IP-адреса до проверки учетных данных.
+// Возвращает *LoginBlockedError, если учетная запись или IP-адрес заблокированы.
+// Ключ учетной записи строится из email независимо от того, существует ли пользователь.
+//
+// Попытка засчитывается одним атомарным обновлением счетчика, поэтому параллельные запросы
+// не проверят больше учетных данных, чем разрешает политика. Неудачная проверка уже учтена;
+// после успешной нужно вызвать RecordSuccess или Release.
+func (g *LoginGuard) Attempt(ctx context.Context, email, ip string) error {
+	now := g.now()
+	if err := g.register(ctx, accountKey(email), g.account, now); err != nil {
+		return err
+	}
+	if ip == "" {
+		return nil
+	}
+	if err := g.register(ctx, ipKey(ip), g.ip, now); err != nil {
+		// Учетные данные не проверялись - попытка учетной записи отменяется
+		if releaseErr := g.store.Release(ctx, accountKey(email)); releaseErr != nil {
+			return fmt.Errorf("failed to release login attempt: %w", releaseErr)
+		}
+		return err
+	}
+	return nil
+}
+
+// register засчитывает попытку для ключа или возвращает *LoginBlockedError
+func (g *LoginGuard) register(ctx context.Context, key string, policy LockoutPolicy, now time.Time) error {
+	attempt, err := g.store.RegisterAttempt(ctx, key, now, now.Add(-policy.ResetAfter), policy.waits())
+	if err != nil {
+		return fmt.Errorf("failed to register login attempt: %w", err)
+	}
+	if attempt != nil {
+		return nil
+	}
+
+	// Ключ заблокирован: счетчик не изменился, читаем время окончания блокировки для RetryAfter
+	attempt, err = g.store.Get(ctx, key)
+	if err != nil {
+		return fmt.Errorf("failed to check login attempts: %w", err)
+	}
+	var wait time.Duration
+	if attempt != nil {
+		wait = attempt.BlockedUntil.Sub(now)
+	}
+	return &LoginBlockedError{RetryAfter: max(wait, time.Second)}
+}
+
+// RecordSuccess сбрасывает счетчик учетной записи после успешного входа и отменяет попытку IP-адреса.
+// Остальные попытки IP-адреса сохраняются, чтобы вход в свою учетную запись
+// не обнулял ограничения для перебора чужих.
+func (g *LoginGuard) RecordSuccess(ctx context.Context, email, ip string) error {
+	if err := g.store.Reset(ctx, accountKey(email)); err != nil {
+		return fmt.Errorf("failed to reset login attempts: %w", err)
+	}
+	if ip != "" {
+		if err := g.store.Release(ctx, ipKey(ip)); err != nil {
+			return fmt.Errorf("failed to release login attempt: %w", err)
+		}
+	}
+	return nil
+}
+
+// Release отменяет попытку, засчитанную Attempt, не сбрасывая счетчики.
+// Используется, когда первый фактор проверен, а вход завершится только после второго:
+// неудачные коды, введенные до этого, остаются учтенными.
+func (g *LoginGuard) Release(ctx context.Context, email, ip string) error {
+	if err := g.store.Release(ctx, accountKey(email)); err != nil {
+		return fmt.Errorf("failed to release login attempt: %w", err)
+	}
+	if ip != "" {
+		if err := g.store.Release(ctx, ipKey(ip)); err != nil {
+			return fmt.Errorf("failed to release login attempt: %w", err)
+		}
+	}
+	return nil
+}
+
+// UnlockAccount снимает блокировку с учетной записи
+func (g *LoginGuard) UnlockAccount(ctx context.Context, email string) error {
+	if err := g.store.Reset(ctx, accountKey(email)); err != nil {
+		return fmt.Errorf("failed to unlock account: %w", err)
+	}
+	return nil
+}
+
+// UnlockIP снимает блокировку с IP-адреса
+func (g *LoginGuard) UnlockIP(ctx context.Context, ip string) error {
+	if err := g.store.Reset(ctx, ipKey(ip)); err != nil {
+		return fmt.Errorf("failed to unlock ip: %w", err)
+	}
+	return nil
+}
+
+// memoryStoreMaxKeys - размер, после которого из памяти удаляются устаревшие счетчики
+const memoryStoreMaxKeys = 10000
+
+// MemoryLoginAttemptsStore хранит счетчики в памяти процесса.
+// Подходит для одного экземпляра сервера и для тестов.
+type MemoryLoginAttemptsStore struct {
+	mu       sync.Mutex
+	attempts map[string]types.LoginAttempt
+}
+
+// NewMemoryLoginAttemptsStore создает пустое хранилище счетчиков в памяти
+func NewMemoryLoginAttemptsStore() *MemoryLoginAttemptsStore {
+	return &MemoryLoginAttemptsStore{
+		attempts: make(map[string]types.LoginAttempt),
+	}
+}
+
+func (m *MemoryLoginAttemptsStore) Get(_ context.Context, key string) (*types.LoginAttempt, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	attempt, ok := m.attempts[key]
+	if !ok {
+		return nil, nil
+	}
+	return &attempt, nil
+}
+
+func (m *MemoryLoginAttemptsStore) RegisterAttempt(_ context.Context, key string, now, resetBefore time.Time, waits []time.Duration) (*types.LoginAttempt, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if len(m.attempts) >= memoryStoreMaxKeys {
+		for k, a := range m.attempts {
+			if a.LastFailureAt.Before(resetBefore) {
+				delete(m.attempts, k)
+			}
+		}
+	}
+
+	attempt, ok := m.attempts[key]
+	if !ok || attempt.LastFailureAt.Before(resetBefore) {
+		attempt = types.LoginAttempt{Key: key}
+	} else if now.Before(attempt.BlockedUntil) {
+		return nil, nil
+	}
+	attempt.Failures++
+	attempt.LastFailureAt = now
+	attempt.BlockedUntil = now
+	if len(waits) > 0 {
+		attempt.BlockedUntil = now.Add(waits[min(attempt.Failures, len(waits))-1])
+	}
+	m.attempts[key] = attempt
+	return &attempt, nil
+}
+
+func (m *MemoryLoginAttemptsStore) Release(_ context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	attempt, ok := m.attempts[key]
+	if !ok {
+		return nil
+	}
+	attempt.Failures = max(attempt.Failures-1, 0)
+	attempt.BlockedUntil = attempt.LastFailureAt
+	m.attempts[key] = attempt
+	return nil
+}
+
+func (m *MemoryLoginAttemptsStore) Reset(_ context.Context, key string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	delete(m.attempts, key)
+	return nil
+}
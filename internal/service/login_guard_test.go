@@ -0,0 +1,191 @@
+package service
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLockoutPolicy_Delay(t *testing.T) {
+	policy := LockoutPolicy{BaseDelay: time.Second, MaxDelay: 10 * time.Second}
+
+	tests := []struct {
+		name     string
+		failures int
+		expected time.Duration
+	}{
+		{"без неудач", 0, 0},
+		{"одна неудача", 1, time.Second},
+		{"две неудачи", 2, 2 * time.Second},
+		{"три неудачи", 3, 4 * time.Second},
+		{"ограничение сверху", 5, 10 * time.Second},
+		{"много неудач", 100, 10 * time.Second},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, policy.delay(tt.failures))
+		})
+	}
+}
+
+func TestLockoutPolicy_Waits(t *testing.T) {
+	tests := []struct {
+		name     string
+		policy   LockoutPolicy
+		expected []time.Duration
+	}{
+		{
+			name: "задержки до блокировки",
+			policy: LockoutPolicy{
+				MaxFailures:     4,
+				BaseDelay:       time.Second,
+				MaxDelay:        time.Minute,
+				LockoutDuration: 15 * time.Minute,
+			},
+			expected: []time.Duration{time.Second, 2 * time.Second, 4 * time.Second, 15 * time.Minute},
+		},
+		{
+			name:     "только блокировка",
+			policy:   LockoutPolicy{MaxFailures: 3, LockoutDuration: time.Minute},
+			expected: []time.Duration{0, 0, time.Minute},
+		},
+		{
+			name:     "без порога задержка растет до максимума",
+			policy:   LockoutPolicy{BaseDelay: time.Second, MaxDelay: 3 * time.Second},
+			expected: []time.Duration{time.Second, 2 * time.Second, 3 * time.Second},
+		},
+		{
+			name:     "без ограничений",
+			policy:   LockoutPolicy{},
+			expected: []time.Duration{0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.expected, tt.policy.waits())
+		})
+	}
+}
+
+func TestLoginGuard_AccountAndIP(t *testing.T) {
+	account := LockoutPolicy{MaxFailures: 3, LockoutDuration: time.Minute, ResetAfter: time.Hour}
+	ip := LockoutPolicy{MaxFailures: 5, LockoutDuration: 2 * time.Minute, ResetAfter: time.Hour}
+	guard := NewLoginGuard(NewMemoryLoginAttemptsStore(), account, ip)
+
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	guard.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	// Три попытки для одной учетной записи блокируют ее
+	for range 3 {
+		require.NoError(t, guard.Attempt(ctx, "User@Example.com", "10.0.0.1"))
+	}
+	err := guard.Attempt(ctx, "user@example.com", "10.0.0.2")
+	require.ErrorIs(t, err, ErrTooManyAttempts)
+
+	// Еще две попытки с того же IP для другой учетной записи блокируют адрес для всех учетных записей
+	for range 2 {
+		require.NoError(t, guard.Attempt(ctx, "other@example.com", "10.0.0.1"))
+	}
+	err = guard.Attempt(ctx, "third@example.com", "10.0.0.1")
+	var blocked *LoginBlockedError
+	require.ErrorAs(t, err, &blocked)
+	assert.Equal(t, 2*time.Minute, blocked.RetryAfter)
+
+	// Попытка, отклоненная из-за IP, не засчитывается учетной записи
+	require.NoError(t, guard.Attempt(ctx, "third@example.com", ""))
+	require.NoError(t, guard.Attempt(ctx, "third@example.com", ""))
+
+	// Блокировка снимается по истечении времени
+	now = now.Add(2 * time.Minute)
+	assert.NoError(t, guard.Attempt(ctx, "third@example.com", "10.0.0.1"))
+	assert.NoError(t, guard.Attempt(ctx, "user@example.com", "10.0.0.2"))
+}
+
+func TestLoginGuard_Attempt_ParallelBurst(t *testing.T) {
+	account := LockoutPolicy{MaxFailures: 5, LockoutDuration: time.Minute, ResetAfter: time.Hour}
+	guard := NewLoginGuard(NewMemoryLoginAttemptsStore(), account, DefaultIPLockoutPolicy)
+	ctx := context.Background()
+
+	// Все запросы начинаются до того, как хоть один узнает результат проверки кода
+	var allowed atomic.Int32
+	var wg sync.WaitGroup
+	for range 100 {
+		wg.Go(func() {
+			if guard.Attempt(ctx, "user@example.com", "") == nil {
+				allowed.Add(1)
+			}
+		})
+	}
+	wg.Wait()
+
+	assert.Equal(t, int32(5), allowed.Load())
+}
+
+func TestLoginGuard_SuccessReleaseAndUnlock(t *testing.T) {
+	account := LockoutPolicy{MaxFailures: 2, LockoutDuration: time.Minute, ResetAfter: time.Hour}
+	ip := LockoutPolicy{MaxFailures: 2, LockoutDuration: time.Minute, ResetAfter: time.Hour}
+	guard := NewLoginGuard(NewMemoryLoginAttemptsStore(), account, ip)
+	ctx := context.Background()
+
+	// Успешный вход сбрасывает счетчик учетной записи и отменяет только свою попытку IP
+	require.NoError(t, guard.Attempt(ctx, "user@example.com", "10.0.0.1"))
+	require.NoError(t, guard.Attempt(ctx, "user@example.com", "10.0.0.1"))
+	require.NoError(t, guard.RecordSuccess(ctx, "user@example.com", "10.0.0.1"))
+	require.NoError(t, guard.Attempt(ctx, "user@example.com", ""))
+	require.NoError(t, guard.Attempt(ctx, "other@example.com", "10.0.0.1"))
+	assert.ErrorIs(t, guard.Attempt(ctx, "third@example.com", "10.0.0.1"), ErrTooManyAttempts)
+
+	require.NoError(t, guard.UnlockIP(ctx, "10.0.0.1"))
+	require.NoError(t, guard.UnlockAccount(ctx, "user@example.com"))
+
+	// Пройденный первый шаг отменяет свою попытку, но не сбрасывает неудачные
+	require.NoError(t, guard.Attempt(ctx, "user@example.com", "10.0.0.1"))
+	require.NoError(t, guard.Attempt(ctx, "user@example.com", "10.0.0.1"))
+	require.NoError(t, guard.Release(ctx, "user@example.com", "10.0.0.1"))
+	require.NoError(t, guard.Attempt(ctx, "user@example.com", "10.0.0.1"))
+	assert.ErrorIs(t, guard.Attempt(ctx, "user@example.com", ""), ErrTooManyAttempts)
+
+	require.NoError(t, guard.UnlockAccount(ctx, "user@example.com"))
+	assert.NoError(t, guard.Attempt(ctx, "user@example.com", ""))
+}
+
+func TestMemoryLoginAttemptsStore_ResetWindow(t *testing.T) {
+	store := NewMemoryLoginAttemptsStore()
+	ctx := context.Background()
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	waits := []time.Duration{time.Second, time.Minute}
+
+	attempt, err := store.Get(ctx, "account:user@example.com")
+	require.NoError(t, err)
+	assert.Nil(t, attempt)
+
+	attempt, err = store.RegisterAttempt(ctx, "account:user@example.com", now, now.Add(-time.Hour), waits)
+	require.NoError(t, err)
+	assert.Equal(t, 1, attempt.Failures)
+	assert.Equal(t, now.Add(time.Second), attempt.BlockedUntil)
+
+	// Пока действует задержка, попытка не засчитывается
+	attempt, err = store.RegisterAttempt(ctx, "account:user@example.com", now, now.Add(-time.Hour), waits)
+	require.NoError(t, err)
+	assert.Nil(t, attempt)
+
+	attempt, err = store.RegisterAttempt(ctx, "account:user@example.com", now.Add(time.Minute), now.Add(-time.Hour), waits)
+	require.NoError(t, err)
+	assert.Equal(t, 2, attempt.Failures)
+	assert.Equal(t, now.Add(2*time.Minute), attempt.BlockedUntil)
+
+	// Предыдущие попытки старше окна сброса не учитываются
+	later := now.Add(2 * time.Hour)
+	attempt, err = store.RegisterAttempt(ctx, "account:user@example.com", later, later.Add(-time.Hour), waits)
+	require.NoError(t, err)
+	assert.Equal(t, 1, attempt.Failures)
+	assert.Equal(t, later, attempt.LastFailureAt)
+}
@@ -0,0 +1,213 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/LigeronAhill/luxcarpets-go/internal/database"
+	"github.com/LigeronAhill/luxcarpets-go/internal/database/types"
+	"github.com/LigeronAhill/luxcarpets-go/pkg/mailer"
+	"github.com/jackc/pgx/v5"
+)
+
+// Ошибки входа по ссылке из письма
+var (
+	// ErrInvalidMagicLink возвращается при неверной, просроченной или уже использованной ссылке,
+	// а также если ссылка открыта не в том браузере, в котором была запрошена
+	ErrInvalidMagicLink = errors.New("invalid or expired sign-in link")
+	// ErrMagicLinkThrottled возвращается, если ссылки на email запрашиваются слишком часто
+	ErrMagicLinkThrottled = errors.New("sign-in link requested too often")
+	// ErrMagicLinkSignInDisabled возвращается, если вход по ссылке из письма не настроен
+	ErrMagicLinkSignInDisabled = errors.New("email sign-in is not configured")
+)
+
+const (
+	// magicLinkTokenBytes - энтропия токена ссылки и значения cookie (256 бит)
+	magicLinkTokenBytes = 32
+	// magicLinkSendWindow - окно, в котором действует ограничение MaxPerHour
+	magicLinkSendWindow = time.Hour
+	// MagicLinkVerifyPath - путь, на который ведет ссылка из письма
+	MagicLinkVerifyPath = "/api/v1/auth/email/verify"
+)
+
+// MagicLinkConfig содержит настройки ссылок для входа по email
+type MagicLinkConfig struct {
+	BaseURL    string        // Публичный адрес сайта, от которого строится ссылка
+	TTL        time.Duration // Время жизни ссылки
+	MaxPerHour int           // Максимальное количество писем на один email в час
+}
+
+// DefaultMagicLinkConfig - настройки по умолчанию (без адреса сайта)
+var DefaultMagicLinkConfig = MagicLinkConfig{
+	TTL:        15 * time.Minute,
+	MaxPerHour: 5,
+}
+
+// MagicLinkService выдает и проверяет одноразовые ссылки для входа, отправленные на email.
+// Ссылка действует только в браузере, который ее запросил: браузер получает случайное
+// значение (nonce) в cookie, а в базе хранится его хеш рядом с хешем токена из ссылки.
+type MagicLinkService struct {
+	storage *database.MagicLinksStorage
+	users   *database.UsersStorage
+	sender  mailer.Sender
+	cfg     MagicLinkConfig
+	now     func() time.Time
+	sending sync.WaitGroup // Письма, которые еще отправляются в фоне
+}
+
+// NewMagicLinkService создает сервис ссылок для входа.
+// Незаданные параметры берутся из DefaultMagicLinkConfig.
+//
+// Возможные ошибки:
+//   - ошибка, если BaseURL не является абсолютным адресом http(s)
+func NewMagicLinkService(storage *database.MagicLinksStorage, users *database.UsersStorage, sender mailer.Sender, cfg MagicLinkConfig) (*MagicLinkService, error) {
+	base, err := url.Parse(cfg.BaseURL)
+	if err != nil || (base.Scheme != "http" && base.Scheme != "https") || base.Host == "" {
+		return nil, fmt.Errorf("invalid magic link base url: %q", cfg.BaseURL)
+	}
+	cfg.BaseURL = strings.TrimRight(cfg.BaseURL, "/")
+	if cfg.TTL <= 0 {
+		cfg.TTL = DefaultMagicLinkConfig.TTL
+	}
+	if cfg.MaxPerHour <= 0 {
+		cfg.MaxPerHour = DefaultMagicLinkConfig.MaxPerHour
+	}
+	return &MagicLinkService{
+		storage: storage,
+		users:   users,
+		sender:  sender,
+		cfg:     cfg,
+		now:     time.Now,
+	}, nil
+}
+
+// TTL возвращает время жизни новых ссылок
+func (s *MagicLinkService) TTL() time.Duration {
+	return s.cfg.TTL
+}
+
+// Request отправляет ссылку для входа на email и возвращает nonce для cookie браузера.
+// Для незарегистрированного email письмо не отправляется, но ответ такой же,
+// чтобы по нему нельзя было проверить наличие учетной записи: ограничение частоты
+// действует для любого email, ссылка сохраняется всегда, а письмо отправляется в фоне
+// и не влияет на время ответа. Ошибки отправки только логируются.
+//
+// Возможные ошибки:
+//   - ErrEmailRequired: если email не указан
+//   - ErrMagicLinkThrottled: если ссылки на этот email запрашиваются слишком часто
+func (s *MagicLinkService) Request(ctx context.Context, email string) (string, error) {
+	email = strings.ToLower(strings.TrimSpace(email))
+	if email == "" {
+		return "", ErrEmailRequired
+	}
+	nonce, err := randomMagicLinkToken()
+	if err != nil {
+		return "", err
+	}
+
+	now := s.now()
+	sent, err := s.storage.CountSince(ctx, email, now.Add(-magicLinkSendWindow))
+	if err != nil {
+		return "", fmt.Errorf("failed to count sign-in links: %w", err)
+	}
+	if sent >= s.cfg.MaxPerHour {
+		return "", ErrMagicLinkThrottled
+	}
+
+	// Ссылка на незарегистрированный email никуда не отправляется и нужна только для подсчета
+	// запросов; если ее все же открыть, SignInWithMagicLink не найдет пользователя
+	token, err := randomMagicLinkToken()
+	if err != nil {
+		return "", err
+	}
+	if _, err := s.storage.Create(ctx, types.CreateMagicLinkParams{
+		Email:     email,
+		TokenHash: hashToken(token),
+		NonceHash: hashToken(nonce),
+		ExpiresAt: now.Add(s.cfg.TTL),
+	}); err != nil {
+		return "", fmt.Errorf("failed to save sign-in link: %w", err)
+	}
+
+	user, err := s.users.GetByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nonce, nil
+		}
+		return "", fmt.Errorf("failed to find user: %w", err)
+	}
+
+	link := s.cfg.BaseURL + MagicLinkVerifyPath + "?token=" + url.QueryEscape(token)
+	minutes := int(s.cfg.TTL / time.Minute)
+	msg := mailer.Message{
+		To:      email,
+		Subject: "Вход в LuxCarpets",
+		Text: fmt.Sprintf("Здравствуйте, %s!\n\n"+
+			"Чтобы войти в LuxCarpets, откройте ссылку в том же браузере, в котором запросили вход:\n%s\n\n"+
+			"Ссылка действует %d мин. и сработает только один раз. "+
+			"Если вы не запрашивали вход, просто удалите это письмо.\n", user.Username, link, minutes),
+	}
+	s.sending.Add(1)
+	go func() {
+		defer s.sending.Done()
+		// Запрос к этому моменту завершен, и его контекст отменен
+		if err := s.sender.Send(context.WithoutCancel(ctx), msg); err != nil {
+			slog.ErrorContext(ctx, "Failed to send sign-in link", slog.String("error", err.Error()))
+		}
+	}()
+	return nonce, nil
+}
+
+// Wait ждет отправки писем, запущенных Request. Вызывается при остановке сервера.
+func (s *MagicLinkService) Wait() {
+	s.sending.Wait()
+}
+
+// Consume проверяет ссылку и значение cookie браузера, отмечает ссылку использованной
+// и возвращает email, на который она была отправлена.
+// Ссылка, открытая в чужом браузере, не расходуется.
+//
+// Возможные ошибки:
+//   - ErrInvalidMagicLink: если ссылка неверная, просрочена, уже использована или nonce не совпадает
+func (s *MagicLinkService) Consume(ctx context.Context, token, nonce string) (string, error) {
+	if token == "" || nonce == "" {
+		return "", ErrInvalidMagicLink
+	}
+	link, err := s.storage.GetByHash(ctx, hashToken(token))
+	if err != nil {
+		return "", fmt.Errorf("failed to get sign-in link: %w", err)
+	}
+	if link == nil || !hmac.Equal([]byte(hashToken(nonce)), []byte(link.NonceHash)) {
+		return "", ErrInvalidMagicLink
+	}
+	now := s.now()
+	if !link.Usable(now) {
+		return "", ErrInvalidMagicLink
+	}
+	consumed, err := s.storage.Consume(ctx, link.ID, now)
+	if err != nil {
+		return "", fmt.Errorf("failed to consume sign-in link: %w", err)
+	}
+	if !consumed {
+		return "", ErrInvalidMagicLink
+	}
+	return link.Email, nil
+}
+
+// randomMagicLinkToken создает случайный токен в кодировке base64url
+func randomMagicLinkToken() (string, error) {
+	raw := make([]byte, magicLinkTokenBytes)
+	if _, err := rand.Read(raw); err != nil {
+		return "", fmt.Errorf("failed to generate sign-in link token: %w", err)
+	}
+	return base64.RawURLEncoding.EncodeToString(raw), nil
+}
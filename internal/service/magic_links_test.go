@@ -0,0 +1,309 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/LigeronAhill/luxcarpets-go/internal/database"
+	"github.com/LigeronAhill/luxcarpets-go/internal/database/types"
+	"github.com/LigeronAhill/luxcarpets-go/pkg/mailer"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var magicLinkColumns = []string{
+	"id", "email", "token_hash", "nonce_hash", "expires_at", "consumed_at", "created_at",
+}
+
+var magicLinkUserColumns = []string{
+	"id", "email", "email_verified", "username", "role", "image_url",
+	"password_hash", "created_at", "updated_at", "deleted_at", "phone", "phone_verified",
+}
+
+func newTestMagicLinkService(t *testing.T, mock pgxmock.PgxPoolIface, now time.Time) (*MagicLinkService, *mailer.Fake) {
+	t.Helper()
+	sender := &mailer.Fake{}
+	cfg := DefaultMagicLinkConfig
+	cfg.BaseURL = "https://luxcarpets.test/"
+	svc, err := NewMagicLinkService(database.NewMagicLinksStorage(mock), database.NewUsersStorage(mock), sender, cfg)
+	require.NoError(t, err)
+	svc.now = func() time.Time { return now }
+	return svc, sender
+}
+
+// expectMagicLink ожидает чтение ссылки с токеном token, запрошенной в браузере с nonce
+func expectMagicLink(mock pgxmock.PgxPoolIface, linkID uuid.UUID, token, nonce string, expiresAt time.Time, consumedAt *time.Time) {
+	mock.ExpectQuery(`SELECT \* FROM magic_links WHERE token_hash = @token_hash`).
+		WithArgs(hashToken(token)).
+		WillReturnRows(pgxmock.NewRows(magicLinkColumns).AddRow(
+			linkID, "user@test.com", hashToken(token), hashToken(nonce), expiresAt, consumedAt, expiresAt.Add(-15*time.Minute),
+		))
+}
+
+// expectMagicLinkCreate ожидает сохранение ссылки на email
+func expectMagicLinkCreate(mock pgxmock.PgxPoolIface, email string, now time.Time, tokenHash, nonceHash any) {
+	mock.ExpectQuery(`INSERT INTO magic_links`).
+		WithArgs(email, tokenHash, nonceHash, now.Add(15*time.Minute)).
+		WillReturnRows(pgxmock.NewRows(magicLinkColumns).AddRow(
+			uuid.New(), email, "token-hash", "nonce-hash", now.Add(15*time.Minute), nil, now,
+		))
+}
+
+func TestNewMagicLinkService_InvalidBaseURL(t *testing.T) {
+	for _, baseURL := range []string{"", "luxcarpets.test", "ftp://luxcarpets.test"} {
+		_, err := NewMagicLinkService(nil, nil, &mailer.Fake{}, MagicLinkConfig{BaseURL: baseURL})
+		assert.Error(t, err, baseURL)
+	}
+}
+
+func TestMagicLinkService_Request_Success(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	now := time.Now()
+	svc, sender := newTestMagicLinkService(t, mock, now)
+	email := "user@test.com"
+
+	mock.ExpectQuery(`SELECT COUNT\(\*\) FROM magic_links`).
+		WithArgs(email, now.Add(-time.Hour)).
+		WillReturnRows(pgxmock.NewRows([]string{"count"}).AddRow(1))
+	tokenHash, nonceHash := &capturedArg{}, &capturedArg{}
+	expectMagicLinkCreate(mock, email, now, tokenHash, nonceHash)
+	mock.ExpectQuery(`SELECT \* FROM users WHERE email = @email`).
+		WithArgs(email).
+		WillReturnRows(pgxmock.NewRows(magicLinkUserColumns).AddRow(
+			uuid.New(), &email, false, "testuser", types.RoleCustomer, nil,
+			nil, now, now, nil, nil, false,
+		))
+
+	nonce, err := svc.Request(context.Background(), " User@Test.com ")
+	svc.Wait()
+
+	require.NoError(t, err)
+	assert.NotEmpty(t, nonce)
+	msg, ok := sender.Last(email)
+	require.True(t, ok)
+	prefix := "https://luxcarpets.test" + MagicLinkVerifyPath + "?token="
+	require.Contains(t, msg.Text, prefix)
+	token := strings.Fields(msg.Text[strings.Index(msg.Text, prefix)+len(prefix):])[0]
+	assert.Equal(t, hashToken(token), tokenHash.value, "в базе хранится хеш токена из письма")
+	assert.Equal(t, hashToken(nonce), nonceHash.value, "в базе хранится хеш nonce из cookie")
+	assert.NotContains(t, msg.Text, nonce, "nonce не должен попадать в письмо")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMagicLinkService_Request_UnknownEmail(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	now := time.Now()
+	svc, sender := newTestMagicLinkService(t, mock, now)
+	email := "nobody@test.com"
+
+	// Запрос для несуществующего email проходит те же шаги, кроме отправки письма
+	mock.ExpectQuery(`SELECT COUNT\(\*\) FROM magic_links`).
+		WithArgs(email, now.Add(-time.Hour)).
+		WillReturnRows(pgxmock.NewRows([]string{"count"}).AddRow(0))
+	expectMagicLinkCreate(mock, email, now, pgxmock.AnyArg(), pgxmock.AnyArg())
+	mock.ExpectQuery(`SELECT \* FROM users WHERE email = @email`).
+		WithArgs(email).
+		WillReturnError(pgx.ErrNoRows)
+
+	nonce, err := svc.Request(context.Background(), email)
+	svc.Wait()
+
+	require.NoError(t, err)
+	assert.NotEmpty(t, nonce)
+	assert.Empty(t, sender.Messages())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMagicLinkService_Request_Throttled(t *testing.T) {
+	// Ограничение не зависит от наличия учетной записи: пользователь даже не ищется
+	for _, email := range []string{"user@test.com", "nobody@test.com"} {
+		t.Run(email, func(t *testing.T) {
+			mock, err := pgxmock.NewPool()
+			require.NoError(t, err)
+			defer mock.Close()
+
+			now := time.Now()
+			svc, sender := newTestMagicLinkService(t, mock, now)
+
+			mock.ExpectQuery(`SELECT COUNT\(\*\) FROM magic_links`).
+				WithArgs(email, now.Add(-time.Hour)).
+				WillReturnRows(pgxmock.NewRows([]string{"count"}).AddRow(5))
+
+			_, err = svc.Request(context.Background(), email)
+			svc.Wait()
+
+			assert.ErrorIs(t, err, ErrMagicLinkThrottled)
+			assert.Empty(t, sender.Messages())
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestMagicLinkService_Request_SendErrorNotReturned(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	now := time.Now()
+	svc, sender := newTestMagicLinkService(t, mock, now)
+	sender.Err = errors.New("smtp unavailable")
+	email := "user@test.com"
+
+	mock.ExpectQuery(`SELECT COUNT\(\*\) FROM magic_links`).
+		WithArgs(email, now.Add(-time.Hour)).
+		WillReturnRows(pgxmock.NewRows([]string{"count"}).AddRow(0))
+	expectMagicLinkCreate(mock, email, now, pgxmock.AnyArg(), pgxmock.AnyArg())
+	mock.ExpectQuery(`SELECT \* FROM users WHERE email = @email`).
+		WithArgs(email).
+		WillReturnRows(pgxmock.NewRows(magicLinkUserColumns).AddRow(
+			uuid.New(), &email, true, "testuser", types.RoleCustomer, nil,
+			nil, now, now, nil, nil, false,
+		))
+
+	// Ответ не должен отличаться от ответа для несуществующего email
+	nonce, err := svc.Request(context.Background(), email)
+	svc.Wait()
+
+	require.NoError(t, err)
+	assert.NotEmpty(t, nonce)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMagicLinkService_Consume(t *testing.T) {
+	consumedAt := time.Now().Add(-time.Minute)
+
+	tests := []struct {
+		name        string
+		nonce       string
+		expiresIn   time.Duration
+		consumedAt  *time.Time
+		wantConsume bool
+		affected    int64
+		wantErr     error
+	}{
+		{name: "действующая ссылка", nonce: "nonce", expiresIn: 10 * time.Minute, wantConsume: true, affected: 1},
+		{name: "другой браузер", nonce: "other", expiresIn: 10 * time.Minute, wantErr: ErrInvalidMagicLink},
+		{name: "ссылка истекла", nonce: "nonce", expiresIn: -time.Minute, wantErr: ErrInvalidMagicLink},
+		{name: "ссылка уже использована", nonce: "nonce", expiresIn: 10 * time.Minute, consumedAt: &consumedAt, wantErr: ErrInvalidMagicLink},
+		{name: "одновременное использование", nonce: "nonce", expiresIn: 10 * time.Minute, wantConsume: true, affected: 0, wantErr: ErrInvalidMagicLink},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock, err := pgxmock.NewPool()
+			require.NoError(t, err)
+			defer mock.Close()
+
+			now := time.Now()
+			svc, _ := newTestMagicLinkService(t, mock, now)
+			linkID := uuid.New()
+
+			expectMagicLink(mock, linkID, "token", "nonce", now.Add(tt.expiresIn), tt.consumedAt)
+			if tt.wantConsume {
+				mock.ExpectExec(`UPDATE magic_links\s+SET consumed_at = @now`).
+					WithArgs(now, linkID).
+					WillReturnResult(pgxmock.NewResult("UPDATE", tt.affected))
+			}
+
+			email, err := svc.Consume(context.Background(), "token", tt.nonce)
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, "user@test.com", email)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestMagicLinkService_Consume_Empty(t *testing.T) {
+	svc, _ := newTestMagicLinkService(t, nil, time.Now())
+
+	_, err := svc.Consume(context.Background(), "token", "")
+	assert.ErrorIs(t, err, ErrInvalidMagicLink)
+	_, err = svc.Consume(context.Background(), "", "nonce")
+	assert.ErrorIs(t, err, ErrInvalidMagicLink)
+}
+
+func TestUsersService_SignInWithMagicLink(t *testing.T) {
+	tests := []struct {
+		name          string
+		emailVerified bool
+	}{
+		{"email подтверждается при входе", false},
+		{"email уже подтвержден", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock, err := pgxmock.NewPool()
+			require.NoError(t, err)
+			defer mock.Close()
+
+			now := time.Now()
+			links, _ := newTestMagicLinkService(t, mock, now)
+			svc := NewUsersService(database.NewUsersStorage(mock), WithMagicLinks(links))
+			linkID := uuid.New()
+			userID := uuid.New()
+			email := "user@test.com"
+
+			expectMagicLink(mock, linkID, "token", "nonce", now.Add(10*time.Minute), nil)
+			mock.ExpectExec(`UPDATE magic_links`).
+				WithArgs(now, linkID).
+				WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+			mock.ExpectQuery(`SELECT \* FROM users WHERE email = @email`).
+				WithArgs(email).
+				WillReturnRows(pgxmock.NewRows(magicLinkUserColumns).AddRow(
+					userID, &email, tt.emailVerified, "testuser", types.RoleCustomer, nil,
+					nil, now, now, nil, nil, false,
+				))
+			if !tt.emailVerified {
+				mock.ExpectQuery(`UPDATE users`).
+					WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg()).
+					WillReturnRows(pgxmock.NewRows(magicLinkUserColumns).AddRow(
+						userID, &email, true, "testuser", types.RoleCustomer, nil,
+						nil, now, now, nil, nil, false,
+					))
+			}
+
+			user, err := svc.SignInWithMagicLink(context.Background(), "token", "nonce")
+
+			require.NoError(t, err)
+			assert.Equal(t, userID, user.ID)
+			assert.True(t, user.EmailVerified)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestUsersService_SignInWithMagicLink_Disabled(t *testing.T) {
+	svc := NewUsersService(database.NewUsersStorage(nil))
+
+	_, err := svc.SignInWithMagicLink(context.Background(), "token", "nonce")
+
+	assert.ErrorIs(t, err, ErrMagicLinkSignInDisabled)
+}
+
+// capturedArg принимает любой аргумент запроса и запоминает его
+type capturedArg struct {
+	value any
+}
+
+func (a *capturedArg) Match(v any) bool {
+	a.value = v
+	return true
+}
@@ -0,0 +1,387 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/LigeronAhill/luxcarpets-go/internal/database"
+	"github.com/LigeronAhill/luxcarpets-go/internal/database/types"
+	"github.com/LigeronAhill/luxcarpets-go/pkg/blob"
+	"github.com/LigeronAhill/luxcarpets-go/pkg/imaging"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// Ошибки галереи товара
+var (
+	// ErrMediaTooLarge возвращается, если файл или размеры изображения превышают ограничения
+	ErrMediaTooLarge = errors.New("product image is too large")
+	// ErrAltTextRequired возвращается, если не указан альтернативный текст или он длиннее 255 символов
+	ErrAltTextRequired = errors.New("alt text is required and must be at most 255 characters")
+	// ErrMediaNotFound возвращается, если изображения нет в галерее товара
+	ErrMediaNotFound = errors.New("product image not found")
+	// ErrMediaAlreadyInGallery возвращается при повторной загрузке того же файла в галерею
+	ErrMediaAlreadyInGallery = errors.New("image is already in the gallery")
+	// ErrInvalidMediaOrder возвращается, если новый порядок не перечисляет все изображения галереи ровно по одному разу
+	ErrInvalidMediaOrder = errors.New("order must list every gallery image exactly once")
+	// ErrProductNotFound возвращается, если товар не найден
+	ErrProductNotFound = errors.New("product not found")
+	// ErrVariantNotFound возвращается, если вариант не найден или относится к другому товару
+	ErrVariantNotFound = errors.New("product variant not found")
+)
+
+// maxAltTextLength - максимальная длина альтернативного текста в символах
+const maxAltTextLength = 255
+
+// MediaConfig содержит ограничения и ширины уменьшенных копий изображений товаров
+type MediaConfig struct {
+	MaxBytes  int64 // Максимальный размер файла
+	MaxPixels int   // Максимальное произведение ширины на высоту
+	Widths    []int // Ширины копий для srcset в пикселях
+}
+
+// DefaultMediaConfig - настройки по умолчанию
+var DefaultMediaConfig = MediaConfig{
+	MaxBytes:  20 << 20,
+	MaxPixels: 60_000_000,
+	Widths:    []int{320, 640, 960, 1280, 1920},
+}
+
+// MediaService управляет галереями товаров: принимает фотографии, строит уменьшенные копии
+// для srcset, определяет преобладающий цвет для фильтра каталога и хранит одинаковые файлы один раз.
+//
+// Копии сохраняются по ключам "media/<hash[:2]>/<hash>/<ширина>.<расширение>", где hash - SHA-256
+// исходного файла: содержимое по ключу никогда не меняется, поэтому его можно кешировать бессрочно.
+// Формат копий задается Encoder - сейчас это JPEG, кодировщики WebP/AVIF подключаются через тот же интерфейс.
+type MediaService struct {
+	storage *database.MediaStorage
+	blobs   blob.Storage
+	encoder imaging.Encoder
+	cfg     MediaConfig
+}
+
+// NewMediaService создает сервис галерей. Незаданные параметры берутся из DefaultMediaConfig.
+func NewMediaService(storage *database.MediaStorage, blobs blob.Storage, encoder imaging.Encoder, cfg MediaConfig) *MediaService {
+	if cfg.MaxBytes <= 0 {
+		cfg.MaxBytes = DefaultMediaConfig.MaxBytes
+	}
+	if cfg.MaxPixels <= 0 {
+		cfg.MaxPixels = DefaultMediaConfig.MaxPixels
+	}
+	if len(cfg.Widths) == 0 {
+		cfg.Widths = DefaultMediaConfig.Widths
+	}
+	widths := slices.Clone(cfg.Widths)
+	slices.Sort(widths)
+	cfg.Widths = slices.Compact(widths)
+	return &MediaService{
+		storage: storage,
+		blobs:   blobs,
+		encoder: encoder,
+		cfg:     cfg,
+	}
+}
+
+// MaxBytes возвращает максимальный размер загружаемого файла
+func (s *MediaService) MaxBytes() int64 {
+	return s.cfg.MaxBytes
+}
+
+// UploadMediaRequest содержит параметры загрузки изображения в галерею
+type UploadMediaRequest struct {
+	ProductID uuid.UUID  // Товар
+	VariantID *uuid.UUID // Вариант товара (nil - изображение всего товара)
+	AltText   string     // Альтернативный текст
+}
+
+// Upload добавляет изображение в конец галереи товара или варианта.
+// Если такой файл уже загружался (для любого товара), повторная обработка не выполняется.
+// Цвет товара для фильтра обновляется по главному изображению галереи.
+//
+// Возможные ошибки:
+//   - ErrAltTextRequired: если альтернативный текст пустой или слишком длинный
+//   - ErrMediaTooLarge: если файл больше MaxBytes или изображение больше MaxPixels
+//   - ErrUnsupportedImage: если файл не является изображением JPEG, PNG или WebP
+//   - ErrProductNotFound, ErrVariantNotFound: если товар или вариант не найден
+//   - ErrMediaAlreadyInGallery: если это изображение уже есть в галерее
+//   - ошибки хранилища
+func (s *MediaService) Upload(ctx context.Context, req UploadMediaRequest, r io.Reader) (*types.GalleryImage, error) {
+	altText, err := normalizeAltText(req.AltText)
+	if err != nil {
+		return nil, err
+	}
+	media, err := s.Store(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+
+	item, err := s.storage.Attach(ctx, types.AttachMediaParams{
+		ProductID: req.ProductID,
+		VariantID: req.VariantID,
+		MediaID:   media.ID,
+		AltText:   altText,
+	})
+	if err != nil {
+		return nil, galleryError(err)
+	}
+	if err := s.storage.RefreshProductColor(ctx, req.ProductID); err != nil {
+		return nil, fmt.Errorf("failed to refresh product color: %w", err)
+	}
+
+	image := &types.GalleryImage{
+		ProductMedia:  *item,
+		Width:         media.Width,
+		Height:        media.Height,
+		DominantColor: media.DominantColor,
+		Renditions:    media.Renditions,
+	}
+	s.fillSources(image)
+	return image, nil
+}
+
+// Store сохраняет изображение и его уменьшенные копии, не добавляя его в галерею.
+// Если такой файл уже загружался, возвращается существующая запись.
+// Upload использует его для каждого файла; команда seed - чтобы загрузить изображения
+// один раз и затем прикрепить их к товарам пачкой.
+//
+// Возможные ошибки:
+//   - ErrMediaTooLarge: если файл больше MaxBytes или изображение больше MaxPixels
+//   - ErrUnsupportedImage: если файл не является изображением JPEG, PNG или WebP
+//   - ошибки хранилища
+func (s *MediaService) Store(ctx context.Context, r io.Reader) (*types.Media, error) {
+	data, err := io.ReadAll(io.LimitReader(r, s.cfg.MaxBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read image: %w", err)
+	}
+	if int64(len(data)) > s.cfg.MaxBytes {
+		return nil, ErrMediaTooLarge
+	}
+
+	sum := sha256.Sum256(data)
+	contentHash := hex.EncodeToString(sum[:])
+	media, err := s.storage.GetByHash(ctx, contentHash)
+	if err != nil {
+		return nil, fmt.Errorf("failed to find image: %w", err)
+	}
+	if media != nil {
+		return media, nil
+	}
+	return s.process(ctx, contentHash, data)
+}
+
+// process декодирует новое изображение, сохраняет уменьшенные копии и создает запись о нем
+func (s *MediaService) process(ctx context.Context, contentHash string, data []byte) (*types.Media, error) {
+	img, err := imaging.Decode(data, s.cfg.MaxPixels)
+	if err != nil {
+		if errors.Is(err, imaging.ErrTooManyPixels) {
+			return nil, ErrMediaTooLarge
+		}
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedImage, err)
+	}
+	bounds := img.Bounds()
+
+	renditions := make([]types.Rendition, 0, len(s.cfg.Widths))
+	for _, width := range s.renditionWidths(bounds.Dx()) {
+		resized := imaging.Resize(img, width)
+		var buf bytes.Buffer
+		if err := s.encoder.Encode(&buf, resized); err != nil {
+			return nil, fmt.Errorf("failed to encode image: %w", err)
+		}
+		key := s.key(contentHash, width)
+		if err := s.blobs.Put(ctx, key, &buf, int64(buf.Len()), s.encoder.ContentType()); err != nil {
+			return nil, fmt.Errorf("failed to store image: %w", err)
+		}
+		renditions = append(renditions, types.Rendition{
+			Width:  width,
+			Height: resized.Bounds().Dy(),
+			Format: s.encoder.Extension(),
+			Key:    key,
+		})
+	}
+
+	dominant := imaging.DominantColor(img)
+	media, err := s.storage.Create(ctx, types.CreateMediaParams{
+		ContentHash:   contentHash,
+		Width:         bounds.Dx(),
+		Height:        bounds.Dy(),
+		DominantColor: types.HexColor(dominant),
+		ColorFamily:   types.ColorFamilyOf(dominant),
+		Renditions:    renditions,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to save image: %w", err)
+	}
+	return media, nil
+}
+
+// renditionWidths возвращает ширины копий, не превышающие ширину исходного изображения.
+// Изображение уже самой маленькой ширины сохраняется одной копией в исходном размере.
+func (s *MediaService) renditionWidths(originalWidth int) []int {
+	widths := make([]int, 0, len(s.cfg.Widths))
+	for _, width := range s.cfg.Widths {
+		if width <= originalWidth {
+			widths = append(widths, width)
+		}
+	}
+	if len(widths) == 0 {
+		widths = append(widths, originalWidth)
+	}
+	return widths
+}
+
+// Gallery возвращает изображения товара и его вариантов в порядке галереи
+func (s *MediaService) Gallery(ctx context.Context, productID uuid.UUID) ([]*types.GalleryImage, error) {
+	images, err := s.storage.Gallery(ctx, productID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get gallery: %w", err)
+	}
+	for _, image := range images {
+		s.fillSources(image)
+	}
+	return images, nil
+}
+
+// Reorder задает порядок галереи. ids должен содержать все изображения галереи ровно по одному разу;
+// первое изображение становится главным, и по нему обновляется цвет товара.
+//
+// Возможные ошибки:
+//   - ErrInvalidMediaOrder: если ids не совпадает с набором изображений галереи
+//   - ошибки хранилища
+func (s *MediaService) Reorder(ctx context.Context, productID uuid.UUID, ids []uuid.UUID) ([]*types.GalleryImage, error) {
+	current, err := s.storage.Gallery(ctx, productID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get gallery: %w", err)
+	}
+	if !sameMediaSet(current, ids) {
+		return nil, ErrInvalidMediaOrder
+	}
+	affected, err := s.storage.Reorder(ctx, productID, ids)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reorder gallery: %w", err)
+	}
+	if affected != int64(len(ids)) {
+		// Галерею изменили параллельно - порядок мог примениться частично
+		return nil, ErrInvalidMediaOrder
+	}
+	if err := s.storage.RefreshProductColor(ctx, productID); err != nil {
+		return nil, fmt.Errorf("failed to refresh product color: %w", err)
+	}
+	return s.Gallery(ctx, productID)
+}
+
+// UpdateAltText изменяет альтернативный текст изображения галереи
+//
+// Возможные ошибки:
+//   - ErrAltTextRequired: если текст пустой или слишком длинный
+//   - ErrMediaNotFound: если изображения нет в галерее товара
+//   - ошибки хранилища
+func (s *MediaService) UpdateAltText(ctx context.Context, productID, id uuid.UUID, altText string) (*types.ProductMedia, error) {
+	altText, err := normalizeAltText(altText)
+	if err != nil {
+		return nil, err
+	}
+	item, err := s.storage.UpdateAltText(ctx, productID, id, altText)
+	if err != nil {
+		return nil, galleryError(err)
+	}
+	return item, nil
+}
+
+// Remove удаляет изображение из галереи товара. Файлы остаются в хранилище:
+// то же изображение может использоваться в галереях других товаров.
+//
+// Возможные ошибки:
+//   - ErrMediaNotFound: если изображения нет в галерее товара
+//   - ошибки хранилища
+func (s *MediaService) Remove(ctx context.Context, productID, id uuid.UUID) error {
+	deleted, err := s.storage.Detach(ctx, productID, id)
+	if err != nil {
+		return fmt.Errorf("failed to remove image: %w", err)
+	}
+	if !deleted {
+		return ErrMediaNotFound
+	}
+	if err := s.storage.RefreshProductColor(ctx, productID); err != nil {
+		return fmt.Errorf("failed to refresh product color: %w", err)
+	}
+	return nil
+}
+
+// fillSources заполняет публичные адреса уменьшенных копий
+func (s *MediaService) fillSources(image *types.GalleryImage) {
+	image.Sources = imageSources(s.blobs, image.Renditions)
+}
+
+// imageSources возвращает публичные адреса уменьшенных копий изображения
+func imageSources(blobs blob.Storage, renditions []types.Rendition) types.ImageSources {
+	sources := make(types.ImageSources, 0, len(renditions))
+	for _, rendition := range renditions {
+		sources = append(sources, types.ImageSource{
+			URL:    blobs.URL(rendition.Key),
+			Width:  rendition.Width,
+			Height: rendition.Height,
+			Format: rendition.Format,
+		})
+	}
+	return sources
+}
+
+// key возвращает ключ копии в хранилище
+func (s *MediaService) key(contentHash string, width int) string {
+	return "media/" + contentHash[:2] + "/" + contentHash + "/" + strconv.Itoa(width) + "." + s.encoder.Extension()
+}
+
+// normalizeAltText проверяет альтернативный текст и убирает пробелы по краям
+func normalizeAltText(altText string) (string, error) {
+	altText = strings.TrimSpace(altText)
+	if altText == "" || utf8.RuneCountInString(altText) > maxAltTextLength {
+		return "", ErrAltTextRequired
+	}
+	return altText, nil
+}
+
+// sameMediaSet проверяет, что ids перечисляет все изображения галереи ровно по одному разу
+func sameMediaSet(gallery []*types.GalleryImage, ids []uuid.UUID) bool {
+	if len(gallery) != len(ids) {
+		return false
+	}
+	seen := make(map[uuid.UUID]bool, len(ids))
+	for _, id := range ids {
+		if seen[id] {
+			return false
+		}
+		seen[id] = true
+	}
+	for _, image := range gallery {
+		if !seen[image.ID] {
+			return false
+		}
+	}
+	return true
+}
+
+// galleryError преобразует ошибки хранилища галереи в ошибки сервиса
+func galleryError(err error) error {
+	switch {
+	case errors.Is(err, pgx.ErrNoRows):
+		return ErrMediaNotFound
+	case database.IsUniqueConstraintViolation(err, "uq_product_media_product_media"),
+		database.IsUniqueConstraintViolation(err, "uq_product_media_variant_media"):
+		return ErrMediaAlreadyInGallery
+	case database.IsForeignKeyViolation(err, "fk_product_media_variant"):
+		return ErrVariantNotFound
+	case database.IsForeignKeyViolation(err, "product_media_product_id_fkey"):
+		return ErrProductNotFound
+	default:
+		return fmt.Errorf("failed to update gallery: %w", err)
+	}
+}
@@ -0,0 +1,312 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"image"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/LigeronAhill/luxcarpets-go/internal/database"
+	"github.com/LigeronAhill/luxcarpets-go/internal/database/types"
+	"github.com/LigeronAhill/luxcarpets-go/pkg/blob"
+	"github.com/LigeronAhill/luxcarpets-go/pkg/imaging"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var (
+	mediaColumns = []string{
+		"id", "content_hash", "width", "height", "dominant_color", "color_family", "renditions", "created_at",
+	}
+	productMediaColumns = []string{
+		"id", "product_id", "variant_id", "media_id", "position", "alt_text", "created_at",
+	}
+	galleryColumns = append(append([]string{}, productMediaColumns...), "width", "height", "dominant_color", "renditions")
+)
+
+func newTestMediaService(t *testing.T, mock pgxmock.PgxPoolIface, cfg MediaConfig) (*MediaService, *blob.Local) {
+	t.Helper()
+	storage, err := blob.NewLocal(t.TempDir(), "/media")
+	require.NoError(t, err)
+	return NewMediaService(database.NewMediaStorage(mock), storage, imaging.JPEGEncoder{}, cfg), storage
+}
+
+func contentHash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+func TestMediaService_Upload_NewImage(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	svc, storage := newTestMediaService(t, mock, MediaConfig{Widths: []int{640, 32, 64}})
+	data := testPNG(t, 100, 50)
+	hash := contentHash(data)
+	productID := uuid.New()
+	mediaID := uuid.New()
+	now := time.Now()
+
+	mock.ExpectQuery(`SELECT \* FROM media WHERE content_hash = @content_hash`).
+		WithArgs(hash).
+		WillReturnRows(pgxmock.NewRows(mediaColumns))
+	renditions := &capturedArg{}
+	mock.ExpectQuery(`INSERT INTO media`).
+		WithArgs(hash, 100, 50, pgxmock.AnyArg(), pgxmock.AnyArg(), renditions).
+		WillReturnRows(pgxmock.NewRows(mediaColumns).AddRow(
+			mediaID, hash, 100, 50, "#326480", types.ColorBlue, []types.Rendition{
+				{Width: 32, Height: 16, Format: "jpg", Key: "media/" + hash[:2] + "/" + hash + "/32.jpg"},
+				{Width: 64, Height: 32, Format: "jpg", Key: "media/" + hash[:2] + "/" + hash + "/64.jpg"},
+			}, now,
+		))
+	mock.ExpectQuery(`INSERT INTO product_media`).
+		WithArgs(productID, (*uuid.UUID)(nil), mediaID, "Ковер в интерьере").
+		WillReturnRows(pgxmock.NewRows(productMediaColumns).AddRow(
+			uuid.New(), productID, nil, mediaID, 0, "Ковер в интерьере", now,
+		))
+	mock.ExpectExec(`UPDATE products`).
+		WithArgs(productID).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+
+	res, err := svc.Upload(context.Background(), UploadMediaRequest{
+		ProductID: productID,
+		AltText:   "  Ковер в интерьере ",
+	}, bytes.NewReader(data))
+
+	require.NoError(t, err)
+	assert.Equal(t, "Ковер в интерьере", res.AltText)
+	assert.Equal(t, "/media/media/"+hash[:2]+"/"+hash+"/32.jpg 32w, /media/media/"+hash[:2]+"/"+hash+"/64.jpg 64w", res.Srcset())
+
+	// Копии шире оригинала не создаются, пропорции сохраняются
+	saved, ok := renditions.value.([]types.Rendition)
+	require.True(t, ok)
+	require.Len(t, saved, 2)
+	for _, rendition := range saved {
+		data, err := os.ReadFile(filepath.Join(storage.Root(), filepath.FromSlash(rendition.Key)))
+		require.NoError(t, err)
+		cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+		require.NoError(t, err)
+		assert.Equal(t, "jpeg", format)
+		assert.Equal(t, rendition.Width, cfg.Width)
+		assert.Equal(t, rendition.Width/2, cfg.Height)
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMediaService_Upload_Deduplicated(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	svc, _ := newTestMediaService(t, mock, MediaConfig{})
+	data := testPNG(t, 20, 20)
+	hash := contentHash(data)
+	productID := uuid.New()
+	variantID := uuid.New()
+	mediaID := uuid.New()
+	now := time.Now()
+
+	// Файл уже загружен для другого товара - повторная обработка не выполняется
+	mock.ExpectQuery(`SELECT \* FROM media WHERE content_hash = @content_hash`).
+		WithArgs(hash).
+		WillReturnRows(pgxmock.NewRows(mediaColumns).AddRow(
+			mediaID, hash, 20, 20, "#808080", types.ColorGray, []types.Rendition{{Width: 20, Height: 20, Format: "jpg", Key: "k.jpg"}}, now,
+		))
+	mock.ExpectQuery(`INSERT INTO product_media`).
+		WithArgs(productID, &variantID, mediaID, "Образец цвета").
+		WillReturnRows(pgxmock.NewRows(productMediaColumns).AddRow(
+			uuid.New(), productID, &variantID, mediaID, 3, "Образец цвета", now,
+		))
+	mock.ExpectExec(`UPDATE products`).
+		WithArgs(productID).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+
+	res, err := svc.Upload(context.Background(), UploadMediaRequest{
+		ProductID: productID,
+		VariantID: &variantID,
+		AltText:   "Образец цвета",
+	}, bytes.NewReader(data))
+
+	require.NoError(t, err)
+	assert.Equal(t, 3, res.Position)
+	assert.Equal(t, "/media/k.jpg", res.Src())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestMediaService_Upload_Rejected(t *testing.T) {
+	tests := []struct {
+		name    string
+		cfg     MediaConfig
+		altText string
+		data    []byte
+		mock    func(mock pgxmock.PgxPoolIface, hash string)
+		wantErr error
+	}{
+		{
+			name:    "нет альтернативного текста",
+			altText: "   ",
+			data:    testPNG(t, 20, 20),
+			wantErr: ErrAltTextRequired,
+		},
+		{
+			name:    "файл больше лимита",
+			cfg:     MediaConfig{MaxBytes: 100},
+			altText: "Ковер",
+			data:    bytes.Repeat([]byte{0}, 101),
+			wantErr: ErrMediaTooLarge,
+		},
+		{
+			name:    "не изображение",
+			altText: "Ковер",
+			data:    []byte("<svg xmlns=\"http://www.w3.org/2000/svg\"></svg>"),
+			mock: func(mock pgxmock.PgxPoolIface, hash string) {
+				mock.ExpectQuery(`SELECT \* FROM media`).WithArgs(hash).WillReturnRows(pgxmock.NewRows(mediaColumns))
+			},
+			wantErr: ErrUnsupportedImage,
+		},
+		{
+			name:    "изображение уже в галерее",
+			altText: "Ковер",
+			data:    testPNG(t, 20, 20),
+			mock: func(mock pgxmock.PgxPoolIface, hash string) {
+				mock.ExpectQuery(`SELECT \* FROM media`).WithArgs(hash).
+					WillReturnRows(pgxmock.NewRows(mediaColumns).AddRow(
+						uuid.New(), hash, 20, 20, "#808080", types.ColorGray, []types.Rendition{}, time.Now(),
+					))
+				mock.ExpectQuery(`INSERT INTO product_media`).
+					WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg()).
+					WillReturnError(&pgconn.PgError{Code: "23505", ConstraintName: "uq_product_media_product_media"})
+			},
+			wantErr: ErrMediaAlreadyInGallery,
+		},
+		{
+			name:    "товар не найден",
+			altText: "Ковер",
+			data:    testPNG(t, 20, 20),
+			mock: func(mock pgxmock.PgxPoolIface, hash string) {
+				mock.ExpectQuery(`SELECT \* FROM media`).WithArgs(hash).
+					WillReturnRows(pgxmock.NewRows(mediaColumns).AddRow(
+						uuid.New(), hash, 20, 20, "#808080", types.ColorGray, []types.Rendition{}, time.Now(),
+					))
+				mock.ExpectQuery(`INSERT INTO product_media`).
+					WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg()).
+					WillReturnError(&pgconn.PgError{Code: "23503", ConstraintName: "product_media_product_id_fkey"})
+			},
+			wantErr: ErrProductNotFound,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock, err := pgxmock.NewPool()
+			require.NoError(t, err)
+			defer mock.Close()
+
+			svc, _ := newTestMediaService(t, mock, tt.cfg)
+			if tt.mock != nil {
+				tt.mock(mock, contentHash(tt.data))
+			}
+
+			_, err = svc.Upload(context.Background(), UploadMediaRequest{ProductID: uuid.New(), AltText: tt.altText}, bytes.NewReader(tt.data))
+
+			assert.ErrorIs(t, err, tt.wantErr)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestMediaService_Reorder(t *testing.T) {
+	productID := uuid.New()
+	first, second := uuid.New(), uuid.New()
+	galleryRows := func() *pgxmock.Rows {
+		now := time.Now()
+		return pgxmock.NewRows(galleryColumns).
+			AddRow(first, productID, nil, uuid.New(), 0, "Первое", now, 10, 10, "#ffffff", []types.Rendition{}).
+			AddRow(second, productID, nil, uuid.New(), 1, "Второе", now, 10, 10, "#000000", []types.Rendition{})
+	}
+
+	tests := []struct {
+		name    string
+		ids     []uuid.UUID
+		mock    func(mock pgxmock.PgxPoolIface)
+		wantErr error
+	}{
+		{
+			name: "новый порядок применен",
+			ids:  []uuid.UUID{second, first},
+			mock: func(mock pgxmock.PgxPoolIface) {
+				mock.ExpectExec(`UPDATE product_media`).
+					WithArgs([]uuid.UUID{second, first}, productID).
+					WillReturnResult(pgxmock.NewResult("UPDATE", 2))
+				mock.ExpectExec(`UPDATE products`).
+					WithArgs(productID).
+					WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+				mock.ExpectQuery(`SELECT pm\.\*`).WithArgs(productID).WillReturnRows(galleryRows())
+			},
+		},
+		{
+			name:    "не все изображения",
+			ids:     []uuid.UUID{second},
+			wantErr: ErrInvalidMediaOrder,
+		},
+		{
+			name:    "повтор изображения",
+			ids:     []uuid.UUID{second, second},
+			wantErr: ErrInvalidMediaOrder,
+		},
+		{
+			name:    "чужое изображение",
+			ids:     []uuid.UUID{first, uuid.New()},
+			wantErr: ErrInvalidMediaOrder,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock, err := pgxmock.NewPool()
+			require.NoError(t, err)
+			defer mock.Close()
+
+			svc, _ := newTestMediaService(t, mock, MediaConfig{})
+			mock.ExpectQuery(`SELECT pm\.\*`).WithArgs(productID).WillReturnRows(galleryRows())
+			if tt.mock != nil {
+				tt.mock(mock)
+			}
+
+			res, err := svc.Reorder(context.Background(), productID, tt.ids)
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				require.NoError(t, err)
+				assert.Len(t, res, 2)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestMediaService_Remove_NotFound(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	svc, _ := newTestMediaService(t, mock, MediaConfig{})
+	productID, id := uuid.New(), uuid.New()
+
+	mock.ExpectExec(`DELETE FROM product_media`).
+		WithArgs(id, productID).
+		WillReturnResult(pgxmock.NewResult("DELETE", 0))
+
+	err = svc.Remove(context.Background(), productID, id)
+
+	assert.ErrorIs(t, err, ErrMediaNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
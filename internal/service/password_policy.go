@@ -0,0 +1,123 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"unicode"
+)
+
+var (
+	// ErrPasswordBreached возвращается, когда пароль найден в списке утекших паролей
+	ErrPasswordBreached = errors.New("password is too common or was found in a data breach")
+	// ErrInvalidPasswordPolicy возвращается при некорректной настройке политики паролей
+	ErrInvalidPasswordPolicy = errors.New("invalid password policy")
+)
+
+// maxPasswordInputLength - абсолютный предел длины пароля, который принимается на проверку.
+// Защищает от DoS при хешировании независимо от настроенной политики.
+const maxPasswordInputLength = 1024
+
+// PasswordPolicy описывает требования к новым паролям.
+// Применяется при регистрации и смене пароля; при входе проверяется только хеш,
+// чтобы ужесточение политики не блокировало пользователей со старыми паролями.
+type PasswordPolicy struct {
+	MinLength      int                     // Минимальная длина в байтах
+	MaxLength      int                     // Максимальная длина в байтах
+	RequireUpper   bool                    // Требовать заглавную букву
+	RequireLower   bool                    // Требовать строчную букву
+	RequireDigit   bool                    // Требовать цифру
+	RequireSpecial bool                    // Требовать спецсимвол
+	Breached       BreachedPasswordChecker // Список утекших паролей (nil - не проверять)
+}
+
+// DefaultPasswordPolicy - политика по умолчанию: 8-72 байта, все классы символов,
+// проверка по встроенному списку утекших паролей
+var DefaultPasswordPolicy = PasswordPolicy{
+	MinLength:      minPasswordLength,
+	MaxLength:      maxPasswordLength,
+	RequireUpper:   true,
+	RequireLower:   true,
+	RequireDigit:   true,
+	RequireSpecial: true,
+	Breached:       BundledBreachedPasswords(),
+}
+
+// Check проверяет корректность самой политики
+func (p PasswordPolicy) Check() error {
+	if p.MinLength < 1 {
+		return fmt.Errorf("%w: minimum length must be positive", ErrInvalidPasswordPolicy)
+	}
+	if p.MaxLength < p.MinLength {
+		return fmt.Errorf("%w: maximum length is less than minimum", ErrInvalidPasswordPolicy)
+	}
+	if p.MaxLength > maxPasswordInputLength {
+		return fmt.Errorf("%w: maximum length must not exceed %d", ErrInvalidPasswordPolicy, maxPasswordInputLength)
+	}
+	return nil
+}
+
+// Validate проверяет пароль на соответствие политике
+//
+// Параметры:
+//   - password: пароль для проверки
+//
+// Возвращает:
+//   - error: nil если пароль валидный, иначе одна из ошибок валидации
+//
+// Возможные ошибки:
+//   - ErrPasswordTooLong, ErrPasswordTooShort: длина вне допустимых пределов
+//   - ErrPasswordNoUpper, ErrPasswordNoLower, ErrPasswordNoDigit, ErrPasswordNoSpecial: нет нужного класса символов
+//   - ErrPasswordBreached: пароль найден в списке утекших
+func (p PasswordPolicy) Validate(password string) error {
+	if len(password) > p.MaxLength {
+		return ErrPasswordTooLong
+	}
+	if len(password) < p.MinLength {
+		return ErrPasswordTooShort
+	}
+
+	var (
+		hasUpper   bool
+		hasLower   bool
+		hasDigit   bool
+		hasSpecial bool
+	)
+
+	for _, char := range password {
+		switch {
+		case unicode.IsUpper(char):
+			hasUpper = true
+		case unicode.IsLower(char):
+			hasLower = true
+		case unicode.IsDigit(char):
+			hasDigit = true
+		case unicode.IsPunct(char) || unicode.IsSymbol(char):
+			hasSpecial = true
+		}
+	}
+
+	if p.RequireUpper && !hasUpper {
+		return ErrPasswordNoUpper
+	}
+	if p.RequireLower && !hasLower {
+		return ErrPasswordNoLower
+	}
+	if p.RequireDigit && !hasDigit {
+		return ErrPasswordNoDigit
+	}
+	if p.RequireSpecial && !hasSpecial {
+		return ErrPasswordNoSpecial
+	}
+
+	if p.Breached != nil {
+		breached, err := isBreachedPassword(p.Breached, password)
+		if err != nil {
+			return fmt.Errorf("failed to check breached passwords: %w", err)
+		}
+		if breached {
+			return ErrPasswordBreached
+		}
+	}
+
+	return nil
+}
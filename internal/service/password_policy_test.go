@@ -0,0 +1,131 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type failingBreachedChecker struct{}
+
+func (failingBreachedChecker) Range(string) ([]string, error) {
+	return nil, errors.New("list unavailable")
+}
+
+func TestPasswordPolicy_Validate(t *testing.T) {
+	relaxed := PasswordPolicy{MinLength: 12, MaxLength: 128}
+
+	tests := []struct {
+		name     string
+		policy   PasswordPolicy
+		password string
+		wantErr  error
+	}{
+		{
+			name:     "политика по умолчанию: валидный пароль",
+			policy:   DefaultPasswordPolicy,
+			password: "TestP@ssw0rd",
+			wantErr:  nil,
+		},
+		{
+			name:     "политика по умолчанию: утекший пароль",
+			policy:   DefaultPasswordPolicy,
+			password: "P@ssw0rd",
+			wantErr:  ErrPasswordBreached,
+		},
+		{
+			name:     "политика по умолчанию: утекший пароль со спецсимволом",
+			policy:   DefaultPasswordPolicy,
+			password: "Qwerty123!",
+			wantErr:  ErrPasswordBreached,
+		},
+		{
+			name:     "без требований к символам: длинная фраза",
+			policy:   relaxed,
+			password: "correct horse battery staple",
+			wantErr:  nil,
+		},
+		{
+			name:     "без требований к символам: короткая фраза",
+			policy:   relaxed,
+			password: "short phrase",
+			wantErr:  nil,
+		},
+		{
+			name:     "без требований к символам: меньше минимума",
+			policy:   relaxed,
+			password: "too short",
+			wantErr:  ErrPasswordTooShort,
+		},
+		{
+			name:     "увеличенный максимум",
+			policy:   relaxed,
+			password: strings.Repeat("a", 100),
+			wantErr:  nil,
+		},
+		{
+			name:     "больше максимума",
+			policy:   relaxed,
+			password: strings.Repeat("a", 129),
+			wantErr:  ErrPasswordTooLong,
+		},
+		{
+			name:     "утекший пароль без проверки по списку",
+			policy:   PasswordPolicy{MinLength: 8, MaxLength: 72, RequireDigit: true},
+			password: "P@ssw0rd",
+			wantErr:  nil,
+		},
+		{
+			name:     "только требование цифры",
+			policy:   PasswordPolicy{MinLength: 8, MaxLength: 72, RequireDigit: true},
+			password: "no digits here",
+			wantErr:  ErrPasswordNoDigit,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.policy.Validate(tt.password)
+			if tt.wantErr == nil {
+				assert.NoError(t, err)
+			} else {
+				assert.ErrorIs(t, err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestPasswordPolicy_Validate_CheckerError(t *testing.T) {
+	policy := PasswordPolicy{MinLength: 8, MaxLength: 72, Breached: failingBreachedChecker{}}
+
+	err := policy.Validate("TestP@ssw0rd")
+
+	assert.ErrorContains(t, err, "list unavailable")
+	assert.False(t, isPasswordValidationError(err))
+}
+
+func TestPasswordPolicy_Check(t *testing.T) {
+	tests := []struct {
+		name    string
+		policy  PasswordPolicy
+		wantErr bool
+	}{
+		{"политика по умолчанию", DefaultPasswordPolicy, false},
+		{"нулевой минимум", PasswordPolicy{MinLength: 0, MaxLength: 72}, true},
+		{"максимум меньше минимума", PasswordPolicy{MinLength: 10, MaxLength: 8}, true},
+		{"максимум выше предела", PasswordPolicy{MinLength: 8, MaxLength: maxPasswordInputLength + 1}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := tt.policy.Check()
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidPasswordPolicy)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,219 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"time"
+
+	"github.com/LigeronAhill/luxcarpets-go/internal/database"
+	"github.com/LigeronAhill/luxcarpets-go/internal/database/types"
+	"github.com/LigeronAhill/luxcarpets-go/pkg/phone"
+	"github.com/LigeronAhill/luxcarpets-go/pkg/sms"
+)
+
+// Ошибки входа по телефону
+var (
+	// ErrInvalidOTP возвращается при неверном, просроченном или уже использованном коде из SMS
+	ErrInvalidOTP = errors.New("invalid or expired one-time code")
+	// ErrOTPThrottled возвращается, если код запрашивается слишком часто (*OTPThrottledError)
+	ErrOTPThrottled = errors.New("one-time code requested too often")
+	// ErrPhoneSignInDisabled возвращается, если вход по телефону не настроен
+	ErrPhoneSignInDisabled = errors.New("phone sign-in is not configured")
+)
+
+// OTPThrottledError сообщает, через сколько можно запросить новый код.
+// Проверяется через errors.Is(err, ErrOTPThrottled).
+type OTPThrottledError struct {
+	RetryAfter time.Duration // Время до следующей возможной отправки
+}
+
+func (e *OTPThrottledError) Error() string {
+	return fmt.Sprintf("%s: retry after %s", ErrOTPThrottled, e.RetryAfter.Round(time.Second))
+}
+
+func (e *OTPThrottledError) Unwrap() error {
+	return ErrOTPThrottled
+}
+
+const (
+	// otpDigits - количество цифр в коде
+	otpDigits = 6
+	// otpSendWindow - окно, в котором действует ограничение MaxSendsPerHour
+	otpSendWindow = time.Hour
+)
+
+// PhoneOTPConfig содержит настройки одноразовых кодов входа по телефону
+type PhoneOTPConfig struct {
+	Key             []byte        // Секрет для HMAC кодов (не короче 32 байт)
+	TTL             time.Duration // Время жизни кода
+	MaxAttempts     int           // Количество попыток ввода одного кода
+	ResendInterval  time.Duration // Минимальный интервал между SMS на один номер
+	MaxSendsPerHour int           // Максимальное количество SMS на один номер в час
+}
+
+// DefaultPhoneOTPConfig - настройки по умолчанию (без ключа)
+var DefaultPhoneOTPConfig = PhoneOTPConfig{
+	TTL:             5 * time.Minute,
+	MaxAttempts:     5,
+	ResendInterval:  time.Minute,
+	MaxSendsPerHour: 5,
+}
+
+// PhoneOTPService выдает и проверяет одноразовые коды, отправленные в SMS
+type PhoneOTPService struct {
+	storage *database.PhoneOTPStorage
+	sender  sms.Sender
+	key     []byte
+	cfg     PhoneOTPConfig
+	now     func() time.Time
+}
+
+// NewPhoneOTPService создает сервис одноразовых кодов.
+// Незаданные параметры берутся из DefaultPhoneOTPConfig.
+//
+// Возможные ошибки:
+//   - ошибка, если ключ короче 32 байт
+func NewPhoneOTPService(storage *database.PhoneOTPStorage, sender sms.Sender, cfg PhoneOTPConfig) (*PhoneOTPService, error) {
+	if len(cfg.Key) < 32 {
+		return nil, errors.New("phone otp key must be at least 32 bytes")
+	}
+	if cfg.TTL <= 0 {
+		cfg.TTL = DefaultPhoneOTPConfig.TTL
+	}
+	if cfg.MaxAttempts <= 0 {
+		cfg.MaxAttempts = DefaultPhoneOTPConfig.MaxAttempts
+	}
+	if cfg.ResendInterval <= 0 {
+		cfg.ResendInterval = DefaultPhoneOTPConfig.ResendInterval
+	}
+	if cfg.MaxSendsPerHour <= 0 {
+		cfg.MaxSendsPerHour = DefaultPhoneOTPConfig.MaxSendsPerHour
+	}
+
+	// Ключ HMAC выводится из переданного секрета, чтобы не использовать один ключ для разных целей
+	mac := hmac.New(sha256.New, cfg.Key)
+	mac.Write([]byte("luxcarpets phone otp"))
+
+	return &PhoneOTPService{
+		storage: storage,
+		sender:  sender,
+		key:     mac.Sum(nil),
+		cfg:     cfg,
+		now:     time.Now,
+	}, nil
+}
+
+// RequestCode отправляет новый код на телефон и возвращает номер в формате E.164.
+// Предыдущий код перестает действовать.
+//
+// Возможные ошибки:
+//   - ErrInvalidPhone: если номер нельзя привести к формату E.164
+//   - ErrOTPThrottled: если код запрашивается слишком часто (*OTPThrottledError)
+//   - ошибки шлюза SMS
+func (s *PhoneOTPService) RequestCode(ctx context.Context, rawPhone string) (string, error) {
+	normalized, err := phone.Normalize(rawPhone)
+	if err != nil {
+		return "", ErrInvalidPhone
+	}
+	existing, err := s.storage.Get(ctx, normalized)
+	if err != nil {
+		return "", fmt.Errorf("failed to get one-time code: %w", err)
+	}
+
+	now := s.now()
+	sendCount, windowStartedAt := 1, now
+	var previousSentAt *time.Time
+	if existing != nil {
+		previousSentAt = &existing.SentAt
+		if wait := existing.SentAt.Add(s.cfg.ResendInterval).Sub(now); wait > 0 {
+			return "", &OTPThrottledError{RetryAfter: wait}
+		}
+		if windowEnd := existing.WindowStartedAt.Add(otpSendWindow); now.Before(windowEnd) {
+			if existing.SendCount >= s.cfg.MaxSendsPerHour {
+				return "", &OTPThrottledError{RetryAfter: windowEnd.Sub(now)}
+			}
+			sendCount, windowStartedAt = existing.SendCount+1, existing.WindowStartedAt
+		}
+	}
+
+	code, err := generateOTP()
+	if err != nil {
+		return "", err
+	}
+	saved, err := s.storage.Save(ctx, types.SavePhoneOTPParams{
+		Phone:           normalized,
+		CodeHash:        s.hashCode(normalized, code),
+		ExpiresAt:       now.Add(s.cfg.TTL),
+		SentAt:          now,
+		SendCount:       sendCount,
+		WindowStartedAt: windowStartedAt,
+		PreviousSentAt:  previousSentAt,
+	})
+	if err != nil {
+		return "", fmt.Errorf("failed to save one-time code: %w", err)
+	}
+	if !saved {
+		// Параллельный запрос уже отправил код после проверки ограничений
+		return "", &OTPThrottledError{RetryAfter: s.cfg.ResendInterval}
+	}
+
+	text := fmt.Sprintf("%s - код для входа в LuxCarpets. Никому его не сообщайте.", code)
+	if err := s.sender.Send(ctx, normalized, text); err != nil {
+		return "", fmt.Errorf("failed to send sms: %w", err)
+	}
+	return normalized, nil
+}
+
+// VerifyCode проверяет код для номера в формате E.164. Верный код можно использовать только один раз.
+//
+// Возможные ошибки:
+//   - ErrInvalidOTP: если код неверный, просрочен, уже использован или исчерпаны попытки
+func (s *PhoneOTPService) VerifyCode(ctx context.Context, normalizedPhone, code string) error {
+	// Попытка засчитывается до сравнения кода: параллельные запросы не проверят больше MaxAttempts кодов
+	otp, err := s.storage.RegisterAttempt(ctx, normalizedPhone, s.cfg.MaxAttempts)
+	if err != nil {
+		return fmt.Errorf("failed to register one-time code attempt: %w", err)
+	}
+	if otp == nil || otp.CodeHash == "" || otp.Expired(s.now()) {
+		return ErrInvalidOTP
+	}
+
+	codeHash := s.hashCode(normalizedPhone, code)
+	if !hmac.Equal([]byte(codeHash), []byte(otp.CodeHash)) {
+		return ErrInvalidOTP
+	}
+
+	consumed, err := s.storage.Consume(ctx, normalizedPhone, codeHash, s.cfg.MaxAttempts)
+	if err != nil {
+		return fmt.Errorf("failed to consume one-time code: %w", err)
+	}
+	if !consumed {
+		return ErrInvalidOTP
+	}
+	return nil
+}
+
+// hashCode возвращает HMAC-SHA256 кода, привязанный к номеру телефона.
+// Без ключа шестизначный код восстанавливался бы из хеша перебором за доли секунды.
+func (s *PhoneOTPService) hashCode(normalizedPhone, code string) string {
+	mac := hmac.New(sha256.New, s.key)
+	mac.Write([]byte(normalizedPhone))
+	mac.Write([]byte{0})
+	mac.Write([]byte(code))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// generateOTP создает случайный код из otpDigits цифр с равномерным распределением
+func generateOTP() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1_000_000))
+	if err != nil {
+		return "", fmt.Errorf("failed to generate one-time code: %w", err)
+	}
+	return fmt.Sprintf("%0*d", otpDigits, n.Int64()), nil
+}
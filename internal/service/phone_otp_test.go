@@ -0,0 +1,374 @@
+package service
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/LigeronAhill/luxcarpets-go/internal/database"
+	"github.com/LigeronAhill/luxcarpets-go/internal/database/types"
+	"github.com/LigeronAhill/luxcarpets-go/pkg/sms"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var phoneOTPColumns = []string{
+	"phone", "code_hash", "attempts", "expires_at", "sent_at", "send_count", "window_started_at",
+}
+
+const testPhone = "+79123456789"
+
+func newTestPhoneOTPService(t *testing.T, mock pgxmock.PgxPoolIface, now time.Time) (*PhoneOTPService, *sms.Fake) {
+	t.Helper()
+	sender := &sms.Fake{}
+	cfg := DefaultPhoneOTPConfig
+	cfg.Key = testTwoFactorKey
+	svc, err := NewPhoneOTPService(database.NewPhoneOTPStorage(mock), sender, cfg)
+	require.NoError(t, err)
+	svc.now = func() time.Time { return now }
+	return svc, sender
+}
+
+// expectPhoneOTP ожидает попытку ввода кода code, отправленного sentAgo назад, после attempts попыток.
+// Если попытки исчерпаны, условие UPDATE не выполняется и строка не возвращается.
+func expectPhoneOTP(mock pgxmock.PgxPoolIface, svc *PhoneOTPService, code string, attempts int, sentAgo time.Duration) {
+	now := svc.now()
+	sentAt := now.Add(-sentAgo)
+	rows := pgxmock.NewRows(phoneOTPColumns)
+	if attempts < svc.cfg.MaxAttempts {
+		rows.AddRow(testPhone, svc.hashCode(testPhone, code), attempts+1, sentAt.Add(svc.cfg.TTL), sentAt, 1, sentAt)
+	}
+	mock.ExpectQuery(`UPDATE phone_otp_codes SET attempts = attempts \+ 1`).
+		WithArgs(testPhone, svc.cfg.MaxAttempts).
+		WillReturnRows(rows)
+}
+
+func TestNewPhoneOTPService_ShortKey(t *testing.T) {
+	_, err := NewPhoneOTPService(nil, &sms.Fake{}, PhoneOTPConfig{Key: []byte("short")})
+	assert.Error(t, err)
+}
+
+func TestGenerateOTP_Format(t *testing.T) {
+	for range 100 {
+		code, err := generateOTP()
+		require.NoError(t, err)
+		assert.Regexp(t, `^[0-9]{6}$`, code)
+	}
+}
+
+func TestPhoneOTPService_RequestCode_Success(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	now := time.Now()
+	svc, sender := newTestPhoneOTPService(t, mock, now)
+
+	mock.ExpectQuery(`SELECT \* FROM phone_otp_codes WHERE phone = @phone`).
+		WithArgs(testPhone).
+		WillReturnRows(pgxmock.NewRows(phoneOTPColumns))
+	mock.ExpectExec(`INSERT INTO phone_otp_codes`).
+		WithArgs(testPhone, pgxmock.AnyArg(), now.Add(5*time.Minute), now, 1, now, (*time.Time)(nil)).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+
+	normalized, err := svc.RequestCode(context.Background(), "8 (912) 345-67-89")
+
+	require.NoError(t, err)
+	assert.Equal(t, testPhone, normalized)
+	msg, ok := sender.Last(testPhone)
+	require.True(t, ok)
+	assert.Regexp(t, regexp.MustCompile(`^[0-9]{6} - код для входа`), msg.Text)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPhoneOTPService_RequestCode_Throttled(t *testing.T) {
+	tests := []struct {
+		name            string
+		sentAgo         time.Duration
+		sendCount       int
+		windowAgo       time.Duration
+		wantRetryAfter  time.Duration
+		wantSaveCount   int
+		wantSaveWindow  time.Duration
+		wantThrottleErr bool
+	}{
+		{"повторная отправка раньше интервала", 20 * time.Second, 1, 20 * time.Second, 40 * time.Second, 0, 0, true},
+		{"превышен лимит в час", 10 * time.Minute, 5, 20 * time.Minute, 40 * time.Minute, 0, 0, true},
+		{"отправка в текущем окне", 2 * time.Minute, 2, 20 * time.Minute, 0, 3, 20 * time.Minute, false},
+		{"новое окно после часа", 2 * time.Hour, 5, 2 * time.Hour, 0, 1, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock, err := pgxmock.NewPool()
+			require.NoError(t, err)
+			defer mock.Close()
+
+			now := time.Now()
+			svc, sender := newTestPhoneOTPService(t, mock, now)
+
+			sentAt := now.Add(-tt.sentAgo)
+			mock.ExpectQuery(`SELECT \* FROM phone_otp_codes WHERE phone = @phone`).
+				WithArgs(testPhone).
+				WillReturnRows(pgxmock.NewRows(phoneOTPColumns).AddRow(
+					testPhone, "hash", 0, sentAt.Add(5*time.Minute), sentAt, tt.sendCount, now.Add(-tt.windowAgo),
+				))
+			if !tt.wantThrottleErr {
+				mock.ExpectExec(`INSERT INTO phone_otp_codes`).
+					WithArgs(testPhone, pgxmock.AnyArg(), now.Add(5*time.Minute), now, tt.wantSaveCount, now.Add(-tt.wantSaveWindow), &sentAt).
+					WillReturnResult(pgxmock.NewResult("INSERT", 1))
+			}
+
+			_, err = svc.RequestCode(context.Background(), testPhone)
+
+			if tt.wantThrottleErr {
+				var throttled *OTPThrottledError
+				require.ErrorAs(t, err, &throttled)
+				assert.ErrorIs(t, err, ErrOTPThrottled)
+				assert.Equal(t, tt.wantRetryAfter, throttled.RetryAfter)
+				assert.Empty(t, sender.Messages())
+			} else {
+				require.NoError(t, err)
+				assert.Len(t, sender.Messages(), 1)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestPhoneOTPService_RequestCode_ConcurrentRequest(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	now := time.Now()
+	svc, sender := newTestPhoneOTPService(t, mock, now)
+
+	// Оба запроса прочитали отсутствие кода, но параллельный успел сохранить свой
+	mock.ExpectQuery(`SELECT \* FROM phone_otp_codes WHERE phone = @phone`).
+		WithArgs(testPhone).
+		WillReturnRows(pgxmock.NewRows(phoneOTPColumns))
+	mock.ExpectExec(`INSERT INTO phone_otp_codes`).
+		WithArgs(testPhone, pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), 1, pgxmock.AnyArg(), (*time.Time)(nil)).
+		WillReturnResult(pgxmock.NewResult("INSERT", 0))
+
+	_, err = svc.RequestCode(context.Background(), testPhone)
+
+	var throttled *OTPThrottledError
+	require.ErrorAs(t, err, &throttled)
+	assert.Equal(t, svc.cfg.ResendInterval, throttled.RetryAfter)
+	assert.Empty(t, sender.Messages())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPhoneOTPService_RequestCode_InvalidPhone(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	svc, _ := newTestPhoneOTPService(t, mock, time.Now())
+
+	_, err = svc.RequestCode(context.Background(), "12345")
+
+	assert.ErrorIs(t, err, ErrInvalidPhone)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPhoneOTPService_RequestCode_SenderError(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	svc, sender := newTestPhoneOTPService(t, mock, time.Now())
+	sender.Err = sms.ErrDeliveryFailed
+
+	mock.ExpectQuery(`SELECT \* FROM phone_otp_codes WHERE phone = @phone`).
+		WithArgs(testPhone).
+		WillReturnRows(pgxmock.NewRows(phoneOTPColumns))
+	mock.ExpectExec(`INSERT INTO phone_otp_codes`).
+		WithArgs(testPhone, pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), 1, pgxmock.AnyArg(), pgxmock.AnyArg()).
+		WillReturnResult(pgxmock.NewResult("INSERT", 1))
+
+	_, err = svc.RequestCode(context.Background(), testPhone)
+
+	assert.ErrorIs(t, err, sms.ErrDeliveryFailed)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestPhoneOTPService_VerifyCode(t *testing.T) {
+	tests := []struct {
+		name     string
+		code     string
+		attempts int
+		sentAgo  time.Duration
+		expect   func(mock pgxmock.PgxPoolIface, svc *PhoneOTPService)
+		wantErr  error
+	}{
+		{
+			name:    "верный код",
+			code:    "123456",
+			sentAgo: time.Minute,
+			expect: func(mock pgxmock.PgxPoolIface, svc *PhoneOTPService) {
+				mock.ExpectExec(`UPDATE phone_otp_codes SET expires_at = sent_at`).
+					WithArgs(testPhone, svc.hashCode(testPhone, "123456"), svc.cfg.MaxAttempts).
+					WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+			},
+		},
+		{
+			name:    "код использован параллельно",
+			code:    "123456",
+			sentAgo: time.Minute,
+			expect: func(mock pgxmock.PgxPoolIface, svc *PhoneOTPService) {
+				mock.ExpectExec(`UPDATE phone_otp_codes SET expires_at = sent_at`).
+					WithArgs(testPhone, svc.hashCode(testPhone, "123456"), svc.cfg.MaxAttempts).
+					WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+			},
+			wantErr: ErrInvalidOTP,
+		},
+		{
+			name:    "неверный код",
+			code:    "654321",
+			sentAgo: time.Minute,
+			wantErr: ErrInvalidOTP,
+		},
+		{
+			name:    "код просрочен",
+			code:    "123456",
+			sentAgo: 10 * time.Minute,
+			wantErr: ErrInvalidOTP,
+		},
+		{
+			name:     "последняя попытка",
+			code:     "123456",
+			attempts: 4,
+			sentAgo:  time.Minute,
+			expect: func(mock pgxmock.PgxPoolIface, svc *PhoneOTPService) {
+				mock.ExpectExec(`UPDATE phone_otp_codes SET expires_at = sent_at`).
+					WithArgs(testPhone, svc.hashCode(testPhone, "123456"), svc.cfg.MaxAttempts).
+					WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+			},
+		},
+		{
+			name:     "попытки исчерпаны",
+			code:     "123456",
+			attempts: 5,
+			sentAgo:  time.Minute,
+			wantErr:  ErrInvalidOTP,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock, err := pgxmock.NewPool()
+			require.NoError(t, err)
+			defer mock.Close()
+
+			svc, _ := newTestPhoneOTPService(t, mock, time.Now())
+			expectPhoneOTP(mock, svc, "123456", tt.attempts, tt.sentAgo)
+			if tt.expect != nil {
+				tt.expect(mock, svc)
+			}
+
+			err = svc.VerifyCode(context.Background(), testPhone, tt.code)
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestUsersService_SignInWithPhone(t *testing.T) {
+	tests := []struct {
+		name         string
+		existing     bool
+		wantUsername string
+	}{
+		{"существующий пользователь", true, "testuser"},
+		{"автоматическая регистрация", false, "Покупатель 6789"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock, err := pgxmock.NewPool()
+			require.NoError(t, err)
+			defer mock.Close()
+
+			otp, _ := newTestPhoneOTPService(t, mock, time.Now())
+			svc := NewUsersService(database.NewUsersStorage(mock), WithPhoneOTP(otp))
+			userID := uuid.New()
+			phone := testPhone
+			now := time.Now()
+			userColumns := []string{
+				"id", "email", "email_verified", "username", "role", "image_url",
+				"password_hash", "created_at", "updated_at", "deleted_at", "phone", "phone_verified",
+			}
+
+			expectPhoneOTP(mock, otp, "123456", 0, time.Minute)
+			mock.ExpectExec(`UPDATE phone_otp_codes SET expires_at = sent_at`).
+				WithArgs(testPhone, otp.hashCode(testPhone, "123456"), otp.cfg.MaxAttempts).
+				WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+			getByPhone := mock.ExpectQuery(`SELECT \* FROM users WHERE phone = @phone AND phone_verified`).
+				WithArgs(testPhone)
+			if tt.existing {
+				getByPhone.WillReturnRows(pgxmock.NewRows(userColumns).AddRow(
+					userID, strPtr("test@example.com"), true, "testuser", types.RoleEmployee, nil,
+					nil, now, now, nil, &phone, true,
+				))
+			} else {
+				getByPhone.WillReturnError(pgx.ErrNoRows)
+				mock.ExpectQuery(`INSERT INTO users \(phone, phone_verified, username, role\)`).
+					WithArgs(testPhone, "Покупатель 6789", types.RoleCustomer).
+					WillReturnRows(pgxmock.NewRows(userColumns).AddRow(
+						userID, nil, false, "Покупатель 6789", types.RoleCustomer, nil,
+						nil, now, now, nil, &phone, true,
+					))
+			}
+
+			user, err := svc.SignInWithPhone(context.Background(), "+7 912 345 67 89", "10.0.0.1", " 123456 ")
+
+			require.NoError(t, err)
+			assert.Equal(t, userID, user.ID)
+			assert.Equal(t, tt.wantUsername, user.Username)
+			assert.Equal(t, testPhone, user.Phone)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestUsersService_SignInWithPhone_WrongCode_Lockout(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	otp, _ := newTestPhoneOTPService(t, mock, time.Now())
+	guard := NewLoginGuard(NewMemoryLoginAttemptsStore(), LockoutPolicy{
+		MaxFailures:     1,
+		LockoutDuration: time.Minute,
+		ResetAfter:      time.Hour,
+	}, DefaultIPLockoutPolicy)
+	svc := NewUsersService(database.NewUsersStorage(mock), WithPhoneOTP(otp), WithLoginGuard(guard))
+	ctx := context.Background()
+
+	expectPhoneOTP(mock, otp, "123456", 0, time.Minute)
+
+	_, err = svc.SignInWithPhone(ctx, testPhone, "", "000000")
+	assert.ErrorIs(t, err, ErrInvalidOTP)
+
+	// Учетная запись заблокирована - код даже не проверяется
+	_, err = svc.SignInWithPhone(ctx, testPhone, "", "123456")
+	assert.ErrorIs(t, err, ErrTooManyAttempts)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUsersService_SignInWithPhone_Disabled(t *testing.T) {
+	svc := NewUsersService(database.NewUsersStorage(nil))
+
+	_, err := svc.SignInWithPhone(context.Background(), testPhone, "", "123456")
+
+	assert.ErrorIs(t, err, ErrPhoneSignInDisabled)
+}
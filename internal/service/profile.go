@@ -0,0 +1,373 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/LigeronAhill/luxcarpets-go/internal/database"
+	"github.com/LigeronAhill/luxcarpets-go/internal/database/types"
+	"github.com/LigeronAhill/luxcarpets-go/pkg/phone"
+	"github.com/google/uuid"
+)
+
+// Ошибки профиля покупателя
+var (
+	// ErrInvalidPhone возвращается, если телефон нельзя привести к формату E.164
+	ErrInvalidPhone = errors.New("invalid phone number")
+	// ErrInvalidUsername возвращается, если имя пользователя короче 3 или длиннее 50 символов
+	ErrInvalidUsername = errors.New("username must be between 3 and 50 characters")
+	// ErrInvalidAddress возвращается при незаполненных или некорректных полях адреса
+	ErrInvalidAddress = errors.New("invalid address")
+	// ErrAddressNotFound возвращается, если адрес не найден среди адресов пользователя
+	ErrAddressNotFound = errors.New("address not found")
+	// ErrTooManyAddresses возвращается при превышении MaxAddressesPerUser
+	ErrTooManyAddresses = errors.New("too many saved addresses")
+	// ErrInvalidDeliveryPreferences возвращается при некорректных предпочтениях доставки
+	ErrInvalidDeliveryPreferences = errors.New("invalid delivery preferences")
+	// ErrSignInPhoneChange возвращается при попытке сменить или удалить телефон,
+	// если это единственный способ входа пользователя
+	ErrSignInPhoneChange = errors.New("phone is the only sign-in method and cannot be changed")
+)
+
+const (
+	// MaxAddressesPerUser - максимальное количество сохраненных адресов
+	MaxAddressesPerUser = 20
+
+	maxAddressFieldLength = 255 // регион, город, улица, получатель
+	maxAddressPartLength  = 20  // дом, корпус, квартира, домофон
+	maxLabelLength        = 50
+	maxEntranceLength     = 10
+	maxCommentLength      = 500
+	minFloor              = -5
+	maxFloor              = 200
+)
+
+// Profile содержит данные страницы "Профиль"
+type Profile struct {
+	User                types.PublicUser           `json:"user"`
+	DeliveryPreferences *types.DeliveryPreferences `json:"delivery_preferences"`
+	Addresses           []*types.Address           `json:"addresses"`
+}
+
+// UpdateProfileRequest содержит изменяемые контактные данные.
+// nil - поле не меняется; пустой Phone удаляет телефон.
+type UpdateProfileRequest struct {
+	Username *string `json:"username"`
+	Phone    *string `json:"phone"`
+}
+
+// ProfileService управляет профилем покупателя: контактами, адресами и предпочтениями доставки
+type ProfileService struct {
+	users     *database.UsersStorage
+	profile   *database.ProfileStorage
+	addresses *database.AddressesStorage
+}
+
+// NewProfileService создает сервис профиля
+func NewProfileService(users *database.UsersStorage, profile *database.ProfileStorage, addresses *database.AddressesStorage) *ProfileService {
+	return &ProfileService{
+		users:     users,
+		profile:   profile,
+		addresses: addresses,
+	}
+}
+
+// Get возвращает профиль пользователя.
+// Если предпочтения доставки не задавались, возвращаются значения по умолчанию.
+//
+// Возможные ошибки:
+//   - ErrUserNotFound: если пользователь не найден
+func (s *ProfileService) Get(ctx context.Context, userID uuid.UUID) (*Profile, error) {
+	user, err := s.users.GetByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrUserNotFound, err)
+	}
+	prefs, err := s.profile.GetDeliveryPreferences(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get delivery preferences: %w", err)
+	}
+	if prefs == nil {
+		prefs = types.DefaultDeliveryPreferences(userID)
+	}
+	addresses, err := s.addresses.ListByUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list addresses: %w", err)
+	}
+	return &Profile{
+		User:                user.ToPublic(),
+		DeliveryPreferences: prefs,
+		Addresses:           addresses,
+	}, nil
+}
+
+// UpdateContacts изменяет имя пользователя и телефон
+//
+// Возможные ошибки:
+//   - ErrInvalidUsername: если имя короче 3 или длиннее 50 символов
+//   - ErrInvalidPhone: если телефон нельзя привести к формату E.164
+//   - ErrSignInPhoneChange: если пользователь без email пытается сменить подтвержденный телефон
+//   - ErrUserNotFound: если пользователь не найден
+func (s *ProfileService) UpdateContacts(ctx context.Context, userID uuid.UUID, req UpdateProfileRequest) (*types.PublicUser, error) {
+	var username *string
+	if req.Username != nil {
+		trimmed := strings.TrimSpace(*req.Username)
+		if n := utf8.RuneCountInString(trimmed); n < 3 || n > 50 {
+			return nil, ErrInvalidUsername
+		}
+		username = &trimmed
+	}
+	var normalizedPhone *string
+	if req.Phone != nil && strings.TrimSpace(*req.Phone) != "" {
+		p, err := phone.Normalize(*req.Phone)
+		if err != nil {
+			return nil, ErrInvalidPhone
+		}
+		normalizedPhone = &p
+	}
+
+	// Смена номера снимает подтверждение, и пользователь без email потерял бы доступ к учетной записи
+	if req.Phone != nil {
+		current, err := s.users.GetByID(ctx, userID)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrUserNotFound, err)
+		}
+		if current.Email == nil && current.PhoneVerified && !equalPhones(current.Phone, normalizedPhone) {
+			return nil, ErrSignInPhoneChange
+		}
+	}
+
+	var user *types.User
+	var err error
+	if username != nil {
+		user, err = s.users.Update(ctx, types.UpdateUserParams{ID: userID, Username: username})
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrUserNotFound, err)
+		}
+	}
+	if req.Phone != nil {
+		user, err = s.profile.SetPhone(ctx, userID, normalizedPhone)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrUserNotFound, err)
+		}
+	}
+	if user == nil {
+		user, err = s.users.GetByID(ctx, userID)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrUserNotFound, err)
+		}
+	}
+	res := user.ToPublic()
+	return &res, nil
+}
+
+// equalPhones сравнивает телефоны, учитывая отсутствие номера
+func equalPhones(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
+
+// UpdateDeliveryPreferences сохраняет предпочтения доставки
+//
+// Возможные ошибки:
+//   - ErrInvalidDeliveryPreferences: если интервал доставки неизвестен или комментарий слишком длинный
+func (s *ProfileService) UpdateDeliveryPreferences(ctx context.Context, userID uuid.UUID, prefs types.DeliveryPreferences) (*types.DeliveryPreferences, error) {
+	if prefs.TimeSlot == "" {
+		prefs.TimeSlot = types.TimeSlotAny
+	}
+	if !prefs.TimeSlot.Valid() {
+		return nil, fmt.Errorf("%w: unknown time slot %q", ErrInvalidDeliveryPreferences, prefs.TimeSlot)
+	}
+	comment, err := optionalField(prefs.Comment, "comment", maxCommentLength)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrInvalidDeliveryPreferences, err)
+	}
+	prefs.Comment = comment
+	prefs.UserID = userID
+
+	saved, err := s.profile.UpsertDeliveryPreferences(ctx, prefs)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save delivery preferences: %w", err)
+	}
+	return saved, nil
+}
+
+// ListAddresses возвращает адреса доставки: сначала адрес по умолчанию, затем новые
+func (s *ProfileService) ListAddresses(ctx context.Context, userID uuid.UUID) ([]*types.Address, error) {
+	addresses, err := s.addresses.ListByUser(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list addresses: %w", err)
+	}
+	return addresses, nil
+}
+
+// AddAddress сохраняет новый адрес доставки. Первый адрес становится адресом по умолчанию.
+//
+// Возможные ошибки:
+//   - ErrInvalidAddress: если поля адреса не заполнены или некорректны
+//   - ErrTooManyAddresses: если у пользователя уже MaxAddressesPerUser адресов
+func (s *ProfileService) AddAddress(ctx context.Context, userID uuid.UUID, params types.AddressParams) (*types.Address, error) {
+	params, err := normalizeAddress(params)
+	if err != nil {
+		return nil, err
+	}
+	total, err := s.addresses.Count(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to count addresses: %w", err)
+	}
+	if total >= MaxAddressesPerUser {
+		return nil, ErrTooManyAddresses
+	}
+	created, err := s.addresses.Create(ctx, userID, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create address: %w", err)
+	}
+	return created, nil
+}
+
+// UpdateAddress полностью заменяет поля адреса
+//
+// Возможные ошибки:
+//   - ErrInvalidAddress: если поля адреса не заполнены или некорректны
+//   - ErrAddressNotFound: если адрес не найден среди адресов пользователя
+func (s *ProfileService) UpdateAddress(ctx context.Context, userID, addressID uuid.UUID, params types.AddressParams) (*types.Address, error) {
+	params, err := normalizeAddress(params)
+	if err != nil {
+		return nil, err
+	}
+	updated, err := s.addresses.Update(ctx, userID, addressID, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update address: %w", err)
+	}
+	if updated == nil {
+		return nil, ErrAddressNotFound
+	}
+	return updated, nil
+}
+
+// SetDefaultAddress делает адрес адресом по умолчанию
+//
+// Возможные ошибки:
+//   - ErrAddressNotFound: если адрес не найден среди адресов пользователя
+func (s *ProfileService) SetDefaultAddress(ctx context.Context, userID, addressID uuid.UUID) error {
+	ok, err := s.addresses.SetDefault(ctx, userID, addressID)
+	if err != nil {
+		return fmt.Errorf("failed to set default address: %w", err)
+	}
+	if !ok {
+		return ErrAddressNotFound
+	}
+	return nil
+}
+
+// DeleteAddress удаляет адрес
+//
+// Возможные ошибки:
+//   - ErrAddressNotFound: если адрес не найден среди адресов пользователя
+func (s *ProfileService) DeleteAddress(ctx context.Context, userID, addressID uuid.UUID) error {
+	ok, err := s.addresses.Delete(ctx, userID, addressID)
+	if err != nil {
+		return fmt.Errorf("failed to delete address: %w", err)
+	}
+	if !ok {
+		return ErrAddressNotFound
+	}
+	return nil
+}
+
+// normalizeAddress обрезает пробелы, проверяет обязательные поля и приводит телефон к E.164
+func normalizeAddress(p types.AddressParams) (types.AddressParams, error) {
+	required := []struct {
+		name   string
+		value  *string
+		maxLen int
+	}{
+		{"recipient_name", &p.RecipientName, maxAddressFieldLength},
+		{"region", &p.Region, maxAddressFieldLength},
+		{"city", &p.City, maxAddressFieldLength},
+		{"street", &p.Street, maxAddressFieldLength},
+		{"house", &p.House, maxAddressPartLength},
+	}
+	for _, f := range required {
+		*f.value = strings.TrimSpace(*f.value)
+		if *f.value == "" {
+			return p, fmt.Errorf("%w: %s is required", ErrInvalidAddress, f.name)
+		}
+		if utf8.RuneCountInString(*f.value) > f.maxLen {
+			return p, fmt.Errorf("%w: %s is too long", ErrInvalidAddress, f.name)
+		}
+	}
+
+	optional := []struct {
+		name   string
+		value  **string
+		maxLen int
+	}{
+		{"label", &p.Label, maxLabelLength},
+		{"postal_code", &p.PostalCode, 6},
+		{"building", &p.Building, maxAddressPartLength},
+		{"apartment", &p.Apartment, maxAddressPartLength},
+		{"entrance", &p.Entrance, maxEntranceLength},
+		{"intercom", &p.Intercom, maxAddressPartLength},
+		{"comment", &p.Comment, maxCommentLength},
+	}
+	for _, f := range optional {
+		v, err := optionalField(*f.value, f.name, f.maxLen)
+		if err != nil {
+			return p, fmt.Errorf("%w: %s", ErrInvalidAddress, err)
+		}
+		*f.value = v
+	}
+
+	recipientPhone, err := phone.Normalize(p.RecipientPhone)
+	if err != nil {
+		return p, fmt.Errorf("%w: recipient_phone must be a valid phone number", ErrInvalidAddress)
+	}
+	p.RecipientPhone = recipientPhone
+
+	if p.PostalCode != nil && !isDigits(*p.PostalCode, 6) {
+		return p, fmt.Errorf("%w: postal_code must be 6 digits", ErrInvalidAddress)
+	}
+	if p.Floor != nil && (*p.Floor < minFloor || *p.Floor > maxFloor) {
+		return p, fmt.Errorf("%w: floor must be between %d and %d", ErrInvalidAddress, minFloor, maxFloor)
+	}
+	if p.Elevator == "" {
+		p.Elevator = types.ElevatorNone
+	}
+	if !p.Elevator.Valid() {
+		return p, fmt.Errorf("%w: unknown elevator type %q", ErrInvalidAddress, p.Elevator)
+	}
+	return p, nil
+}
+
+// optionalField обрезает пробелы в необязательном поле; пустая строка превращается в nil
+func optionalField(value *string, name string, maxLen int) (*string, error) {
+	if value == nil {
+		return nil, nil
+	}
+	trimmed := strings.TrimSpace(*value)
+	if trimmed == "" {
+		return nil, nil
+	}
+	if utf8.RuneCountInString(trimmed) > maxLen {
+		return nil, fmt.Errorf("%s is too long", name)
+	}
+	return &trimmed, nil
+}
+
+// isDigits проверяет, что строка состоит ровно из n цифр
+func isDigits(s string, n int) bool {
+	if len(s) != n {
+		return false
+	}
+	for _, c := range s {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
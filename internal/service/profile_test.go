@@ -0,0 +1,222 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/LigeronAhill/luxcarpets-go/internal/database"
+	"github.com/LigeronAhill/luxcarpets-go/internal/database/types"
+	"github.com/google/uuid"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestProfileService(mock pgxmock.PgxPoolIface) *ProfileService {
+	return NewProfileService(
+		database.NewUsersStorage(mock),
+		database.NewProfileStorage(mock),
+		database.NewAddressesStorage(mock),
+	)
+}
+
+func validAddressParams() types.AddressParams {
+	floor := int16(9)
+	return types.AddressParams{
+		RecipientName:  " Иванов Иван ",
+		RecipientPhone: "8 (912) 345-67-89",
+		Region:         "Москва",
+		City:           "Москва",
+		Street:         "ул. Тверская",
+		House:          "1",
+		Floor:          &floor,
+	}
+}
+
+func strPtr(s string) *string {
+	return &s
+}
+
+func TestNormalizeAddress(t *testing.T) {
+	t.Run("нормализация", func(t *testing.T) {
+		params := validAddressParams()
+		params.Apartment = strPtr("  ")
+		params.PostalCode = strPtr(" 125009 ")
+
+		res, err := normalizeAddress(params)
+
+		require.NoError(t, err)
+		assert.Equal(t, "Иванов Иван", res.RecipientName)
+		assert.Equal(t, "+79123456789", res.RecipientPhone)
+		assert.Equal(t, "125009", *res.PostalCode)
+		assert.Nil(t, res.Apartment)
+		assert.Equal(t, types.ElevatorNone, res.Elevator)
+	})
+
+	tests := []struct {
+		name   string
+		modify func(p *types.AddressParams)
+	}{
+		{"нет города", func(p *types.AddressParams) { p.City = " " }},
+		{"нет дома", func(p *types.AddressParams) { p.House = "" }},
+		{"неверный телефон", func(p *types.AddressParams) { p.RecipientPhone = "12345" }},
+		{"неверный индекс", func(p *types.AddressParams) { p.PostalCode = strPtr("1250") }},
+		{"нереальный этаж", func(p *types.AddressParams) { f := int16(500); p.Floor = &f }},
+		{"неизвестный лифт", func(p *types.AddressParams) { p.Elevator = "escalator" }},
+		{"длинный комментарий", func(p *types.AddressParams) { p.Comment = strPtr(string(make([]rune, 501))) }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			params := validAddressParams()
+			tt.modify(&params)
+			_, err := normalizeAddress(params)
+			assert.ErrorIs(t, err, ErrInvalidAddress)
+		})
+	}
+}
+
+func TestProfileService_UpdateContacts_Validation(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	svc := newTestProfileService(mock)
+	ctx := context.Background()
+
+	_, err = svc.UpdateContacts(ctx, uuid.New(), UpdateProfileRequest{Phone: strPtr("not a phone")})
+	assert.ErrorIs(t, err, ErrInvalidPhone)
+
+	_, err = svc.UpdateContacts(ctx, uuid.New(), UpdateProfileRequest{Username: strPtr(" a ")})
+	assert.ErrorIs(t, err, ErrInvalidUsername)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestProfileService_UpdateContacts_Phone(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	svc := newTestProfileService(mock)
+	userID := uuid.New()
+	normalized := "+79123456789"
+	now := time.Now()
+
+	expectUserByID(mock, userID, "test@example.com", types.RoleCustomer)
+	mock.ExpectQuery(`UPDATE users\s+SET phone = @phone`).
+		WithArgs(&normalized, userID).
+		WillReturnRows(pgxmock.NewRows([]string{
+			"id", "email", "email_verified", "username", "role", "image_url",
+			"password_hash", "created_at", "updated_at", "deleted_at", "phone", "phone_verified",
+		}).AddRow(
+			userID, strPtr("test@example.com"), true, "testuser", types.RoleCustomer, nil,
+			nil, now, now, nil, &normalized, false,
+		))
+
+	res, err := svc.UpdateContacts(context.Background(), userID, UpdateProfileRequest{Phone: strPtr("+7 912 345-67-89")})
+
+	require.NoError(t, err)
+	assert.Equal(t, normalized, res.Phone)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestProfileService_UpdateContacts_SignInPhone(t *testing.T) {
+	current := "+79123456789"
+	tests := []struct {
+		name    string
+		phone   string
+		wantErr error
+	}{
+		{"смена номера", "+79990000000", ErrSignInPhoneChange},
+		{"удаление номера", "", ErrSignInPhoneChange},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock, err := pgxmock.NewPool()
+			require.NoError(t, err)
+			defer mock.Close()
+
+			svc := newTestProfileService(mock)
+			userID := uuid.New()
+			now := time.Now()
+
+			mock.ExpectQuery(`SELECT \* FROM users WHERE id = @id AND deleted_at IS NULL`).
+				WithArgs(userID).
+				WillReturnRows(pgxmock.NewRows([]string{
+					"id", "email", "email_verified", "username", "role", "image_url",
+					"password_hash", "created_at", "updated_at", "deleted_at", "phone", "phone_verified",
+				}).AddRow(
+					userID, nil, false, "Покупатель 6789", types.RoleCustomer, nil,
+					nil, now, now, nil, &current, true,
+				))
+
+			_, err = svc.UpdateContacts(context.Background(), userID, UpdateProfileRequest{Phone: strPtr(tt.phone)})
+
+			assert.ErrorIs(t, err, tt.wantErr)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestProfileService_AddAddress_TooMany(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	svc := newTestProfileService(mock)
+
+	mock.ExpectQuery(`SELECT COUNT\(\*\) FROM user_addresses WHERE user_id = @user_id`).
+		WithArgs(pgxmock.AnyArg()).
+		WillReturnRows(pgxmock.NewRows([]string{"count"}).AddRow(MaxAddressesPerUser))
+
+	_, err = svc.AddAddress(context.Background(), uuid.New(), validAddressParams())
+
+	assert.ErrorIs(t, err, ErrTooManyAddresses)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestProfileService_Get_DefaultPreferences(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	svc := newTestProfileService(mock)
+	userID := uuid.New()
+
+	expectUserByID(mock, userID, "test@example.com", types.RoleCustomer)
+	mock.ExpectQuery(`SELECT \* FROM user_delivery_preferences`).
+		WithArgs(pgxmock.AnyArg()).
+		WillReturnRows(pgxmock.NewRows([]string{
+			"user_id", "time_slot", "call_before_delivery", "lifting_required", "comment", "updated_at",
+		}))
+	mock.ExpectQuery(`SELECT \* FROM user_addresses`).
+		WithArgs(pgxmock.AnyArg()).
+		WillReturnRows(pgxmock.NewRows([]string{
+			"id", "user_id", "label", "recipient_name", "recipient_phone", "postal_code",
+			"region", "city", "street", "house", "building", "apartment",
+			"entrance", "intercom", "floor", "elevator", "comment", "is_default",
+			"created_at", "updated_at",
+		}))
+
+	profile, err := svc.Get(context.Background(), userID)
+
+	require.NoError(t, err)
+	assert.Equal(t, userID, profile.User.ID)
+	assert.Equal(t, types.TimeSlotAny, profile.DeliveryPreferences.TimeSlot)
+	assert.True(t, profile.DeliveryPreferences.CallBeforeDelivery)
+	assert.Empty(t, profile.Addresses)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestProfileService_UpdateDeliveryPreferences_InvalidSlot(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	svc := newTestProfileService(mock)
+
+	_, err = svc.UpdateDeliveryPreferences(context.Background(), uuid.New(), types.DeliveryPreferences{TimeSlot: "night"})
+
+	assert.ErrorIs(t, err, ErrInvalidDeliveryPreferences)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,181 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// RateLimit - ограничение частоты запросов: не больше Limit запросов за Period.
+// Запросы могут идти подряд, пока не исчерпан запас; затем запас восстанавливается
+// равномерно, по одному запросу каждые Period / Limit.
+type RateLimit struct {
+	Name   string        // Имя политики; входит в ключ счетчика и в заголовок RateLimit-Policy
+	Limit  int           // Количество запросов; 0 - ограничение отключено
+	Period time.Duration // Окно, за которое восстанавливается весь запас
+}
+
+// Enabled проверяет, что ограничение задано
+func (l RateLimit) Enabled() bool {
+	return l.Limit > 0 && l.Period > 0
+}
+
+// interval возвращает время восстановления одного запроса
+func (l RateLimit) interval() time.Duration {
+	return l.Period / time.Duration(l.Limit)
+}
+
+// ParseRateLimit разбирает ограничение в формате "<количество>/<период>", например "10/1m".
+// Пустая строка или "0" отключают ограничение.
+func ParseRateLimit(name, s string) (RateLimit, error) {
+	limit := RateLimit{Name: name}
+	if s == "" || s == "0" {
+		return limit, nil
+	}
+	count, period, ok := strings.Cut(s, "/")
+	if !ok {
+		return limit, fmt.Errorf("invalid rate limit %q: expected <count>/<period>", s)
+	}
+	n, err := strconv.Atoi(count)
+	if err != nil || n < 0 {
+		return limit, fmt.Errorf("invalid rate limit %q: bad count", s)
+	}
+	d, err := time.ParseDuration(period)
+	if err != nil || d <= 0 {
+		return limit, fmt.Errorf("invalid rate limit %q: bad period", s)
+	}
+	if n > 0 && d/time.Duration(n) <= 0 {
+		return limit, fmt.Errorf("invalid rate limit %q: period too short for count", s)
+	}
+	limit.Limit, limit.Period = n, d
+	return limit, nil
+}
+
+// RateLimitResult - решение по запросу и данные для заголовков RateLimit-*
+type RateLimitResult struct {
+	Allowed    bool          // Запрос разрешен
+	Limit      int           // Размер запаса
+	Remaining  int           // Сколько запросов можно выполнить сразу после этого
+	Reset      time.Duration // Через сколько запас восстановится полностью
+	RetryAfter time.Duration // Для отклоненного запроса: через сколько можно повторить
+}
+
+// RateLimitStore хранит для каждого ключа теоретическое время прибытия (TAT) алгоритма GCRA.
+// Реализации: MemoryRateLimitStore (один процесс) и database.RateLimitsStorage (несколько реплик).
+type RateLimitStore interface {
+	// Take атомарно вычисляет новый TAT = max(TAT, now) + interval. Если новый TAT не дальше
+	// now + period, сохраняет его и возвращает с allowed = true; иначе возвращает текущий TAT
+	// и allowed = false, ничего не меняя.
+	Take(ctx context.Context, key string, now time.Time, interval, period time.Duration) (tat time.Time, allowed bool, err error)
+	// DeleteExpired удаляет ключи с TAT раньше before: их запас восстановлен полностью
+	DeleteExpired(ctx context.Context, before time.Time) error
+}
+
+// rateLimitPurgeInterval - как часто удаляются восстановившиеся ключи
+const rateLimitPurgeInterval = 10 * time.Minute
+
+// RateLimiter ограничивает частоту запросов по алгоритму GCRA (generic cell rate algorithm):
+// для ключа хранится одно время, а не список запросов, поэтому состояние компактно
+// и обновляется одной атомарной операцией в хранилище.
+type RateLimiter struct {
+	store RateLimitStore
+	now   func() time.Time
+
+	mu        sync.Mutex
+	nextPurge time.Time
+}
+
+// NewRateLimiter создает ограничитель с указанным хранилищем
+func NewRateLimiter(store RateLimitStore) *RateLimiter {
+	return &RateLimiter{
+		store: store,
+		now:   time.Now,
+	}
+}
+
+// Allow учитывает запрос с ключом key (IP-адрес, пользователь, токен API) по политике limit
+func (r *RateLimiter) Allow(ctx context.Context, limit RateLimit, key string) (RateLimitResult, error) {
+	if !limit.Enabled() {
+		return RateLimitResult{Allowed: true}, nil
+	}
+	now := r.now()
+	r.purge(ctx, now)
+
+	interval := limit.interval()
+	tat, allowed, err := r.store.Take(ctx, limit.Name+":"+key, now, interval, limit.Period)
+	if err != nil {
+		return RateLimitResult{}, fmt.Errorf("failed to check rate limit: %w", err)
+	}
+
+	result := RateLimitResult{Allowed: allowed, Limit: limit.Limit}
+	if tat.After(now) {
+		result.Reset = tat.Sub(now)
+	}
+	if allowed {
+		result.Remaining = int((limit.Period - result.Reset) / interval)
+	} else {
+		// Следующий запрос поместится, когда TAT + interval отойдет от now не дальше чем на period
+		result.RetryAfter = max(tat.Add(interval).Sub(now)-limit.Period, 0)
+	}
+	return result, nil
+}
+
+// purge раз в rateLimitPurgeInterval удаляет восстановившиеся ключи в фоне,
+// не задерживая текущий запрос
+func (r *RateLimiter) purge(ctx context.Context, now time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if now.Before(r.nextPurge) {
+		return
+	}
+	r.nextPurge = now.Add(rateLimitPurgeInterval)
+	go func() {
+		if err := r.store.DeleteExpired(context.WithoutCancel(ctx), now); err != nil {
+			slog.ErrorContext(ctx, "Failed to purge rate limits", slog.String("error", err.Error()))
+		}
+	}()
+}
+
+// MemoryRateLimitStore хранит состояние ограничителя в памяти процесса.
+// Подходит для одного экземпляра сервера и для тестов.
+type MemoryRateLimitStore struct {
+	mu   sync.Mutex
+	tats map[string]time.Time
+}
+
+// NewMemoryRateLimitStore создает пустое хранилище в памяти
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{
+		tats: make(map[string]time.Time),
+	}
+}
+
+func (m *MemoryRateLimitStore) Take(_ context.Context, key string, now time.Time, interval, period time.Duration) (time.Time, bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	tat, ok := m.tats[key]
+	if !ok || tat.Before(now) {
+		tat = now
+	}
+	next := tat.Add(interval)
+	if next.Sub(now) > period {
+		return tat, false, nil
+	}
+	m.tats[key] = next
+	return next, true, nil
+}
+
+func (m *MemoryRateLimitStore) DeleteExpired(_ context.Context, before time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for key, tat := range m.tats {
+		if tat.Before(before) {
+			delete(m.tats, key)
+		}
+	}
+	return nil
+}
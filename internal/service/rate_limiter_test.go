@@ -0,0 +1,146 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newTestRateLimiter создает ограничитель с хранилищем в памяти и управляемыми часами
+func newTestRateLimiter(now *time.Time) *RateLimiter {
+	limiter := NewRateLimiter(NewMemoryRateLimitStore())
+	limiter.now = func() time.Time { return *now }
+	return limiter
+}
+
+func TestParseRateLimit(t *testing.T) {
+	tests := []struct {
+		name     string
+		value    string
+		expected RateLimit
+		wantErr  bool
+	}{
+		{"запросы в минуту", "10/1m", RateLimit{Name: "auth", Limit: 10, Period: time.Minute}, false},
+		{"запросы в час", "100/1h", RateLimit{Name: "auth", Limit: 100, Period: time.Hour}, false},
+		{"пустая строка отключает", "", RateLimit{Name: "auth"}, false},
+		{"ноль отключает", "0", RateLimit{Name: "auth"}, false},
+		{"без периода", "10", RateLimit{}, true},
+		{"не число", "ten/1m", RateLimit{}, true},
+		{"отрицательное количество", "-1/1m", RateLimit{}, true},
+		{"неверный период", "10/minute", RateLimit{}, true},
+		{"нулевой период", "10/0s", RateLimit{}, true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limit, err := ParseRateLimit("auth", tt.value)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, limit)
+		})
+	}
+}
+
+func TestRateLimiter_Allow_Burst(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	limiter := newTestRateLimiter(&now)
+	limit := RateLimit{Name: "auth", Limit: 3, Period: 30 * time.Second}
+	ctx := context.Background()
+
+	// Весь запас доступен сразу
+	for i := range 3 {
+		result, err := limiter.Allow(ctx, limit, "ip:10.0.0.1")
+		require.NoError(t, err)
+		assert.True(t, result.Allowed)
+		assert.Equal(t, 3, result.Limit)
+		assert.Equal(t, 2-i, result.Remaining)
+		assert.Equal(t, time.Duration(i+1)*10*time.Second, result.Reset)
+	}
+
+	result, err := limiter.Allow(ctx, limit, "ip:10.0.0.1")
+	require.NoError(t, err)
+	assert.False(t, result.Allowed)
+	assert.Equal(t, 0, result.Remaining)
+	assert.Equal(t, 30*time.Second, result.Reset)
+	assert.Equal(t, 10*time.Second, result.RetryAfter)
+
+	// Другой ключ и другая политика считаются отдельно
+	result, err = limiter.Allow(ctx, limit, "ip:10.0.0.2")
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+	result, err = limiter.Allow(ctx, RateLimit{Name: "otp", Limit: 3, Period: 30 * time.Second}, "ip:10.0.0.1")
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+}
+
+func TestRateLimiter_Allow_Refill(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	limiter := newTestRateLimiter(&now)
+	limit := RateLimit{Name: "auth", Limit: 2, Period: time.Minute}
+	ctx := context.Background()
+
+	for range 2 {
+		result, err := limiter.Allow(ctx, limit, "user:1")
+		require.NoError(t, err)
+		require.True(t, result.Allowed)
+	}
+	result, err := limiter.Allow(ctx, limit, "user:1")
+	require.NoError(t, err)
+	require.False(t, result.Allowed)
+	assert.Equal(t, 30*time.Second, result.RetryAfter)
+
+	// Чуть раньше Retry-After запрос по-прежнему отклоняется
+	now = now.Add(29 * time.Second)
+	result, err = limiter.Allow(ctx, limit, "user:1")
+	require.NoError(t, err)
+	assert.False(t, result.Allowed)
+	assert.Equal(t, time.Second, result.RetryAfter)
+
+	// Через Retry-After восстанавливается один запрос
+	now = now.Add(time.Second)
+	result, err = limiter.Allow(ctx, limit, "user:1")
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 0, result.Remaining)
+
+	// Через полный период восстанавливается весь запас
+	now = now.Add(time.Minute)
+	result, err = limiter.Allow(ctx, limit, "user:1")
+	require.NoError(t, err)
+	assert.True(t, result.Allowed)
+	assert.Equal(t, 1, result.Remaining)
+	assert.Equal(t, 30*time.Second, result.Reset)
+}
+
+func TestRateLimiter_Allow_Disabled(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	limiter := newTestRateLimiter(&now)
+
+	for range 100 {
+		result, err := limiter.Allow(context.Background(), RateLimit{Name: "api"}, "ip:10.0.0.1")
+		require.NoError(t, err)
+		assert.True(t, result.Allowed)
+	}
+}
+
+func TestMemoryRateLimitStore_DeleteExpired(t *testing.T) {
+	store := NewMemoryRateLimitStore()
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	ctx := context.Background()
+
+	_, _, err := store.Take(ctx, "old", now.Add(-time.Hour), time.Second, time.Minute)
+	require.NoError(t, err)
+	_, _, err = store.Take(ctx, "fresh", now, time.Second, time.Minute)
+	require.NoError(t, err)
+
+	require.NoError(t, store.DeleteExpired(ctx, now))
+
+	assert.NotContains(t, store.tats, "old")
+	assert.Contains(t, store.tats, "fresh")
+}
@@ -0,0 +1,26 @@
+package service
+
+import "strings"
+
+// robotsDisallowed - разделы, которые не нужны в поиске: личные данные, корзина и API
+var robotsDisallowed = []string{"/api/", "/cart", "/checkout", "/profile"}
+
+// RobotsTxt формирует robots.txt. Вне production индексация запрещена полностью,
+// чтобы тестовые стенды не попадали в поиск. В production закрываются личные разделы и API,
+// а роботам сообщается адрес карты сайта.
+func RobotsTxt(production bool, sitemapURL string) string {
+	var b strings.Builder
+	b.WriteString("User-agent: *\n")
+	if !production {
+		b.WriteString("Disallow: /\n")
+		return b.String()
+	}
+	for _, path := range robotsDisallowed {
+		b.WriteString("Disallow: " + path + "\n")
+	}
+	b.WriteString("Allow: /\n")
+	if sitemapURL != "" {
+		b.WriteString("\nSitemap: " + sitemapURL + "\n")
+	}
+	return b.String()
+}
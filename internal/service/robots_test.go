@@ -0,0 +1,38 @@
+package service
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRobotsTxt(t *testing.T) {
+	tests := []struct {
+		name       string
+		production bool
+		want       string
+	}{
+		{
+			name:       "production",
+			production: true,
+			want: "User-agent: *\n" +
+				"Disallow: /api/\n" +
+				"Disallow: /cart\n" +
+				"Disallow: /checkout\n" +
+				"Disallow: /profile\n" +
+				"Allow: /\n" +
+				"\n" +
+				"Sitemap: https://luxcarpets.ru/sitemap.xml\n",
+		},
+		{
+			name:       "тестовый стенд закрыт полностью",
+			production: false,
+			want:       "User-agent: *\nDisallow: /\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, RobotsTxt(tt.production, "https://luxcarpets.ru/sitemap.xml"))
+		})
+	}
+}
@@ -0,0 +1,587 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/png"
+	"log/slog"
+	"math/rand/v2"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/LigeronAhill/luxcarpets-go/internal/database"
+	"github.com/LigeronAhill/luxcarpets-go/internal/database/types"
+	"github.com/google/uuid"
+)
+
+// DefaultSeedProducts - количество товаров по умолчанию: несколько страниц каталога в каждой категории
+const DefaultSeedProducts = 500
+
+// seedBatchSize - количество товаров в одной вставке
+const seedBatchSize = 1000
+
+// seedNamespace - пространство имен для ID товаров и вариантов (UUID v5 от slug и артикула).
+// Одинаковые ID при каждом запуске делают повторное заполнение безопасным.
+var seedNamespace = uuid.MustParse("0c2f5d3e-6a8b-4c71-9e24-5b1f7d9a3c60")
+
+// seedUser - пользователь для разработки
+type seedUser struct {
+	Email    string
+	Username string
+	Role     types.UserRole
+}
+
+// seedUsers - по одному пользователю на каждую роль персонала и покупатель
+var seedUsers = []seedUser{
+	{Email: "owner@luxcarpets.local", Username: "Владелец", Role: types.RoleOwner},
+	{Email: "admin@luxcarpets.local", Username: "Администратор", Role: types.RoleAdmin},
+	{Email: "employee@luxcarpets.local", Username: "Сотрудник", Role: types.RoleEmployee},
+	{Email: "customer@luxcarpets.local", Username: "Покупатель", Role: types.RoleCustomer},
+}
+
+// seedCategory - категория каталога; Parent - slug родительской категории
+type seedCategory struct {
+	Slug        string
+	Parent      string
+	Name        string
+	Description string
+}
+
+// seedCategories перечислены так, что родитель идет раньше дочерних категорий
+var seedCategories = []seedCategory{
+	{Slug: "kovry", Name: "Ковры", Description: "Ковры ручной и машинной работы для гостиной, спальни и детской"},
+	{Slug: "kovry-ruchnoj-raboty", Parent: "kovry", Name: "Ковры ручной работы", Description: "Ковры из шерсти и шелка, сотканные и тафтингованные вручную"},
+	{Slug: "kovry-mashinnye", Parent: "kovry", Name: "Машинные ковры", Description: "Практичные ковры из синтетических и смесовых волокон"},
+	{Slug: "kovrolin", Name: "Ковролин", Description: "Ковровые покрытия в рулонах шириной от 3 до 5 метров"},
+	{Slug: "kovrovaya-plitka", Name: "Ковровая плитка", Description: "Модульные покрытия для офисов и общественных помещений"},
+}
+
+// seedBrand - производитель и его коллекции
+type seedBrand struct {
+	Name        string
+	Collections []string
+}
+
+// seedKind описывает товары одной категории: производителей, материалы, размеры и цены
+type seedKind struct {
+	Category   string            // slug категории
+	Noun       string            // начало названия товара
+	SlugPrefix string            // начало slug товара
+	Unit       types.VariantUnit // единица продажи вариантов
+	Brands     []seedBrand       // производители
+	Materials  []string          // материалы ворса
+	Share      int               // доля товаров категории в процентах
+	PriceMin   int               // цена за квадратный метр в рублях: от PriceMin до PriceMax
+	PriceMax   int
+	PileMin    int // высота ворса в миллиметрах: от PileMin до PileMax
+	PileMax    int
+	Usage      string // последнее предложение описания
+}
+
+var seedKinds = []seedKind{
+	{
+		Category:   "kovry-ruchnoj-raboty",
+		Noun:       "Ковер",
+		SlugPrefix: "kover",
+		Unit:       types.UnitPiece,
+		Brands: []seedBrand{
+			{Name: "Jaipur Rugs", Collections: []string{"Kilim", "Manchaha", "Aurora", "Jaipur Heritage"}},
+			{Name: "Lalee", Collections: []string{"Marrakesh", "Nomad", "Sahara"}},
+			{Name: "Carpet Decor", Collections: []string{"Tabriz", "Kashan", "Isfahan", "Heriz"}},
+		},
+		Materials: []string{"шерсть", "шерсть и шелк", "шелк", "шерсть и вискоза"},
+		Share:     20,
+		PriceMin:  15000,
+		PriceMax:  60000,
+		PileMin:   6,
+		PileMax:   18,
+		Usage:     "Узор и цвет каждого ковра немного отличаются: ковер сделан вручную.",
+	},
+	{
+		Category:   "kovry-mashinnye",
+		Noun:       "Ковер",
+		SlugPrefix: "kover",
+		Unit:       types.UnitPiece,
+		Brands: []seedBrand{
+			{Name: "Merinos", Collections: []string{"Valencia", "Silver", "Crystal", "Kamea"}},
+			{Name: "Sintelon", Collections: []string{"Vegas", "Tiffany", "Boho"}},
+			{Name: "Osta", Collections: []string{"Piazzo", "Patina", "Carmen"}},
+			{Name: "Oriental Weavers", Collections: []string{"Cairo", "Luxor", "Shiraz"}},
+			{Name: "Balta", Collections: []string{"Nordic", "Pietro", "Lounge"}},
+		},
+		Materials: []string{"полипропилен", "хит-сет полипропилен", "полиэстер", "акрил", "вискоза"},
+		Share:     45,
+		PriceMin:  1200,
+		PriceMax:  7000,
+		PileMin:   5,
+		PileMax:   25,
+		Usage:     "Подходит для гостиной, спальни и детской, не линяет и легко чистится.",
+	},
+	{
+		Category:   "kovrolin",
+		Noun:       "Ковролин",
+		SlugPrefix: "kovrolin",
+		Unit:       types.UnitSqm,
+		Brands: []seedBrand{
+			{Name: "Balta ITC", Collections: []string{"Merida", "Sparkle", "Cosy", "Optima"}},
+			{Name: "Associated Weavers", Collections: []string{"Lancaster", "Savannah", "Rhapsody"}},
+			{Name: "Condor", Collections: []string{"Solid", "Lyon", "Summit"}},
+			{Name: "Ideal", Collections: []string{"Dynasty", "Harmony", "Sirius"}},
+		},
+		Materials: []string{"полиамид", "полипропилен", "шерсть", "полиэстер"},
+		Share:     25,
+		PriceMin:  600,
+		PriceMax:  3500,
+		PileMin:   4,
+		PileMax:   14,
+		Usage:     "Отрезается от рулона по размеру помещения, укладка на клей или двусторонний скотч.",
+	},
+	{
+		Category:   "kovrovaya-plitka",
+		Noun:       "Ковровая плитка",
+		SlugPrefix: "plitka",
+		Unit:       types.UnitSqm,
+		Brands: []seedBrand{
+			{Name: "Interface", Collections: []string{"Employ", "Human Nature", "Open Air"}},
+			{Name: "Desso", Collections: []string{"AirMaster", "Essence", "Fuse"}},
+			{Name: "Tarkett", Collections: []string{"Sky", "Starlight", "Tweed"}},
+		},
+		Materials: []string{"полиамид"},
+		Share:     10,
+		PriceMin:  1800,
+		PriceMax:  4500,
+		PileMin:   3,
+		PileMax:   7,
+		Usage:     "Плитки укладываются на фиксатор и меняются по одной, подходит для офисов.",
+	},
+}
+
+// seedRugSizes - размеры ковров в сантиметрах (ширина x длина)
+var seedRugSizes = [][2]int{
+	{60, 110}, {80, 150}, {120, 180}, {133, 190}, {160, 230}, {200, 300}, {240, 340}, {300, 400},
+}
+
+// seedRollWidths - ширины рулонов ковролина в сантиметрах
+var seedRollWidths = []int{300, 400, 500}
+
+// seedPalette - основные цвета изображений: по одному на группу цвета фильтра каталога
+var seedPalette = []color.RGBA{
+	{0xf5, 0xf3, 0xee, 0xff}, // молочный
+	{0xd6, 0xbf, 0x9c, 0xff}, // бежевый
+	{0x6b, 0x45, 0x2a, 0xff}, // коричневый
+	{0x8c, 0x8e, 0x91, 0xff}, // серый
+	{0x26, 0x27, 0x2b, 0xff}, // антрацит
+	{0x8e, 0x1f, 0x2b, 0xff}, // бордовый
+	{0xc4, 0x62, 0x2d, 0xff}, // терракотовый
+	{0xd9, 0xa4, 0x1e, 0xff}, // горчичный
+	{0x5f, 0x7a, 0x35, 0xff}, // оливковый
+	{0x2c, 0x4f, 0x8c, 0xff}, // синий
+	{0x6a, 0x44, 0x8c, 0xff}, // фиолетовый
+	{0xe8, 0x9f, 0xb8, 0xff}, // розовый
+}
+
+// Узоры изображений
+const (
+	seedPatternStripes  = iota // поперечные полосы
+	seedPatternDiamonds        // ромбы
+	seedPatterns
+)
+
+// Размер изображений: хватает для копий 320, 640 и 960 пикселей
+const (
+	seedImageWidth  = 960
+	seedImageHeight = 1280
+)
+
+// SeedConfig - параметры заполнения базы
+type SeedConfig struct {
+	Products int    // Количество товаров; при повторном запуске с большим значением добавляются недостающие
+	Password string // Пароль пользователей
+}
+
+// SeedReport - количество созданных записей. Записи, которые уже были в базе, не учитываются.
+type SeedReport struct {
+	Users    int   // Пользователи
+	Images   int   // Изображения, доступные для галерей (новые и существующие)
+	Products int64 // Товары
+	Variants int64 // Варианты товаров
+	Gallery  int64 // Изображения в галереях товаров
+}
+
+// SeedService заполняет базу данными для разработки: пользователями всех ролей и каталогом
+// из категорий, производителей, коллекций, ковров, рулонов и плитки с ценами и изображениями.
+//
+// Данные детерминированы: товар с номером i одинаков при каждом запуске и не зависит
+// от общего количества товаров. Поэтому заполнение идемпотентно, а запуск с большим
+// количеством товаров добавляет только новые (например, 100 000 для нагрузочных тестов
+// списка и поиска каталога).
+type SeedService struct {
+	users   *UsersService
+	media   *MediaService
+	storage *database.SeedStorage
+}
+
+func NewSeedService(users *UsersService, media *MediaService, storage *database.SeedStorage) *SeedService {
+	return &SeedService{
+		users:   users,
+		media:   media,
+		storage: storage,
+	}
+}
+
+// Run создает пользователей, категории, изображения и товары
+//
+// Возможные ошибки:
+//   - ошибки проверки пароля по политике сервиса пользователей
+//   - ошибки хранилища
+func (s *SeedService) Run(ctx context.Context, cfg SeedConfig) (*SeedReport, error) {
+	if cfg.Products < 0 {
+		return nil, fmt.Errorf("products must not be negative: %d", cfg.Products)
+	}
+	report := &SeedReport{}
+	users, err := s.seedUsers(ctx, cfg.Password)
+	if err != nil {
+		return nil, err
+	}
+	report.Users = users
+
+	categories, err := s.seedCategories(ctx)
+	if err != nil {
+		return nil, err
+	}
+	palette, err := s.seedImages(ctx)
+	if err != nil {
+		return nil, err
+	}
+	report.Images = len(palette)
+
+	catalog := &seedCatalog{categories: categories, palette: palette}
+	for start := 0; start < cfg.Products; start += seedBatchSize {
+		end := min(start+seedBatchSize, cfg.Products)
+		batch := catalog.batch(start, end)
+		if err := s.insertBatch(ctx, batch, report); err != nil {
+			return nil, err
+		}
+		slog.DebugContext(ctx, "Seeded products", slog.Int("done", end), slog.Int("total", cfg.Products))
+	}
+	return report, nil
+}
+
+// seedUsers регистрирует отсутствующих пользователей через SignUp с подтвержденным email.
+// Мягко удаленный пользователь пропускается: его email занят, а восстанавливать учетную запись
+// должен администратор (server admin restore), а не заполнение базы.
+func (s *SeedService) seedUsers(ctx context.Context, password string) (int, error) {
+	created := 0
+	for _, u := range seedUsers {
+		_, err := s.users.GetByEmail(ctx, u.Email)
+		if err == nil {
+			continue
+		}
+		if !errors.Is(err, ErrUserNotFound) {
+			return created, fmt.Errorf("failed to find user %s: %w", u.Email, err)
+		}
+		deleted, err := s.deletedUser(ctx, u.Email)
+		if err != nil {
+			return created, err
+		}
+		if deleted {
+			slog.WarnContext(ctx, "Seed user is deleted, skipping", slog.String("email", u.Email))
+			continue
+		}
+		role := string(u.Role)
+		user, err := s.users.SignUp(ctx, u.Email, u.Username, &password, &role, nil)
+		if err != nil {
+			return created, fmt.Errorf("failed to sign up %s: %w", u.Email, err)
+		}
+		verified := true
+		if _, err := s.users.Update(ctx, types.UpdateUserParams{ID: user.ID, EmailVerified: &verified}); err != nil {
+			return created, fmt.Errorf("failed to verify email of %s: %w", u.Email, err)
+		}
+		created++
+	}
+	return created, nil
+}
+
+// deletedUser проверяет, есть ли мягко удаленный пользователь с email.
+// Фильтр List ищет по части email, поэтому совпадение проверяется точно.
+func (s *SeedService) deletedUser(ctx context.Context, email string) (bool, error) {
+	users, err := s.users.List(ctx, types.ListUsersParams{Limit: 100, Email: &email, IncludeDeleted: true})
+	if err != nil {
+		return false, fmt.Errorf("failed to find deleted user %s: %w", email, err)
+	}
+	for _, user := range users.Data {
+		if strings.EqualFold(user.Email, email) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// seedCategories создает категории и возвращает их ID по slug
+func (s *SeedService) seedCategories(ctx context.Context) (map[string]uuid.UUID, error) {
+	ids := make(map[string]uuid.UUID, len(seedCategories))
+	for i, c := range seedCategories {
+		category := types.Category{
+			Slug:        c.Slug,
+			Name:        c.Name,
+			Description: &c.Description,
+			Position:    i,
+		}
+		if c.Parent != "" {
+			parentID := ids[c.Parent]
+			category.ParentID = &parentID
+		}
+		created, err := s.storage.EnsureCategory(ctx, category)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create category %s: %w", c.Slug, err)
+		}
+		ids[c.Slug] = created.ID
+	}
+	return ids, nil
+}
+
+// seedImages сохраняет изображения всех цветов и узоров через MediaService: копии для srcset
+// и цвет для фильтра получаются так же, как при загрузке фотографии администратором.
+// Уже сохраненные изображения находятся по содержимому и повторно не обрабатываются.
+func (s *SeedService) seedImages(ctx context.Context) ([]*types.Media, error) {
+	palette := make([]*types.Media, 0, len(seedPalette)*seedPatterns)
+	for _, base := range seedPalette {
+		for pattern := range seedPatterns {
+			var buf bytes.Buffer
+			if err := png.Encode(&buf, seedImage(base, pattern)); err != nil {
+				return nil, fmt.Errorf("failed to encode seed image: %w", err)
+			}
+			media, err := s.media.Store(ctx, &buf)
+			if err != nil {
+				return nil, fmt.Errorf("failed to store seed image: %w", err)
+			}
+			palette = append(palette, media)
+		}
+	}
+	return palette, nil
+}
+
+// insertBatch вставляет товары, их варианты и галереи
+func (s *SeedService) insertBatch(ctx context.Context, batch *seedBatch, report *SeedReport) error {
+	products, err := s.storage.InsertProducts(ctx, batch.products)
+	if err != nil {
+		return fmt.Errorf("failed to insert products: %w", err)
+	}
+	variants, err := s.storage.InsertVariants(ctx, batch.variants)
+	if err != nil {
+		return fmt.Errorf("failed to insert product variants: %w", err)
+	}
+	gallery, err := s.storage.InsertProductMedia(ctx, batch.gallery)
+	if err != nil {
+		return fmt.Errorf("failed to insert product images: %w", err)
+	}
+	report.Products += products
+	report.Variants += variants
+	report.Gallery += gallery
+	return nil
+}
+
+// seedBatch - товары с вариантами и галереями для одной вставки
+type seedBatch struct {
+	products []types.Product
+	variants []types.ProductVariant
+	gallery  []types.ProductMedia
+}
+
+// seedCatalog генерирует товары по номеру
+type seedCatalog struct {
+	categories map[string]uuid.UUID // ID категорий по slug
+	palette    []*types.Media       // Изображения для галерей
+}
+
+// batch генерирует товары с номерами от start до end (не включая end)
+func (c *seedCatalog) batch(start, end int) *seedBatch {
+	batch := &seedBatch{}
+	for i := start; i < end; i++ {
+		product, variants, gallery := c.product(i)
+		batch.products = append(batch.products, product)
+		batch.variants = append(batch.variants, variants...)
+		batch.gallery = append(batch.gallery, gallery...)
+	}
+	return batch
+}
+
+// product генерирует товар с номером i. Случайные значения берутся из генератора,
+// инициализированного номером, поэтому результат зависит только от i.
+func (c *seedCatalog) product(i int) (types.Product, []types.ProductVariant, []types.ProductMedia) {
+	r := rand.New(rand.NewPCG(uint64(i), 0x5eed))
+	kind := pickKind(r)
+	brand := kind.Brands[r.IntN(len(kind.Brands))]
+	collection := brand.Collections[r.IntN(len(brand.Collections))]
+	material := kind.Materials[r.IntN(len(kind.Materials))]
+	cover := c.palette[r.IntN(len(c.palette))]
+	colorName := strings.ToLower(cover.ColorFamily.Label())
+	design := 100 + r.IntN(900)
+
+	name := fmt.Sprintf("%s %s %s %d, %s", kind.Noun, brand.Name, collection, design, colorName)
+	slug := fmt.Sprintf("%s-%s-%s-%d", kind.SlugPrefix, slugify(brand.Name), slugify(collection), i+1)
+	description := fmt.Sprintf("%s из коллекции %s от %s. Материал: %s, высота ворса %d мм. %s",
+		kind.Noun, collection, brand.Name, material, kind.PileMin+r.IntN(kind.PileMax-kind.PileMin+1), kind.Usage)
+	colorFamily := cover.ColorFamily
+	dominantColor := cover.DominantColor
+	product := types.Product{
+		ID:            uuid.NewSHA1(seedNamespace, []byte(slug)),
+		CategoryID:    c.categories[kind.Category],
+		Slug:          slug,
+		Name:          name,
+		Description:   &description,
+		Brand:         &brand.Name,
+		Material:      &material,
+		ColorFamily:   &colorFamily,
+		DominantColor: &dominantColor,
+		IsActive:      true,
+	}
+
+	pricePerSqm := kind.PriceMin + r.IntN(kind.PriceMax-kind.PriceMin+1)
+	variants := seedVariants(r, kind, product.ID, i, pricePerSqm)
+
+	// Главное изображение определяет цвет товара, остальные - виды в интерьере
+	gallery := []types.ProductMedia{{ProductID: product.ID, MediaID: cover.ID, Position: 0, AltText: name}}
+	extra := r.IntN(3)
+	for len(gallery) <= extra {
+		media := c.palette[r.IntN(len(c.palette))]
+		if slices.ContainsFunc(gallery, func(item types.ProductMedia) bool { return item.MediaID == media.ID }) {
+			continue
+		}
+		gallery = append(gallery, types.ProductMedia{
+			ProductID: product.ID,
+			MediaID:   media.ID,
+			Position:  len(gallery),
+			AltText:   name + " в интерьере",
+		})
+	}
+	return product, variants, gallery
+}
+
+// pickKind выбирает категорию товара с учетом долей категорий
+func pickKind(r *rand.Rand) *seedKind {
+	n := r.IntN(100)
+	for i := range seedKinds {
+		if n < seedKinds[i].Share {
+			return &seedKinds[i]
+		}
+		n -= seedKinds[i].Share
+	}
+	return &seedKinds[len(seedKinds)-1]
+}
+
+// seedVariants генерирует варианты товара: размеры ковра, ширины рулона или плитку 50x50 см.
+// Цена ковра считается по площади, ковролина и плитки - за квадратный метр.
+func seedVariants(r *rand.Rand, kind *seedKind, productID uuid.UUID, i, pricePerSqm int) []types.ProductVariant {
+	prefix := fmt.Sprintf("LC%06d", i+1)
+	variant := func(sku, name string, width int, length *int, priceRub int) types.ProductVariant {
+		stock := 0
+		// Примерно каждого седьмого варианта нет в наличии
+		if r.IntN(7) != 0 {
+			stock = 1 + r.IntN(120)
+			if kind.Unit == types.UnitSqm {
+				stock *= 10
+			}
+		}
+		return types.ProductVariant{
+			ID:           uuid.NewSHA1(seedNamespace, []byte(sku)),
+			ProductID:    productID,
+			SKU:          sku,
+			Name:         name,
+			WidthCm:      &width,
+			LengthCm:     length,
+			Unit:         kind.Unit,
+			PriceKopecks: int64(priceRub) * 100,
+			Stock:        stock,
+		}
+	}
+
+	switch kind.Category {
+	case "kovrolin":
+		first := r.IntN(len(seedRollWidths) - 1)
+		variants := make([]types.ProductVariant, 0, len(seedRollWidths)-first)
+		for _, width := range seedRollWidths[first:] {
+			sku := prefix + "-W" + strconv.Itoa(width)
+			variants = append(variants, variant(sku, fmt.Sprintf("Ширина %d м", width/100), width, nil, pricePerSqm))
+		}
+		return variants
+	case "kovrovaya-plitka":
+		length := 50
+		return []types.ProductVariant{variant(prefix+"-50x50", "Плитка 50x50 см", 50, &length, pricePerSqm)}
+	default:
+		first := r.IntN(len(seedRugSizes) - 3)
+		count := 3 + r.IntN(2)
+		sizes := seedRugSizes[first:min(first+count, len(seedRugSizes))]
+		variants := make([]types.ProductVariant, 0, len(sizes))
+		for _, size := range sizes {
+			width, length := size[0], size[1]
+			// Цена округляется до 10 рублей
+			price := (pricePerSqm*width*length/10000 + 5) / 10 * 10
+			sku := fmt.Sprintf("%s-%dx%d", prefix, width, length)
+			variants = append(variants, variant(sku, fmt.Sprintf("%dx%d см", width, length), width, &length, price))
+		}
+		return variants
+	}
+}
+
+// slugify переводит латинское название в часть slug: "Oriental Weavers" -> "oriental-weavers"
+func slugify(s string) string {
+	var b strings.Builder
+	dash := false
+	for _, r := range strings.ToLower(s) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			if dash && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			dash = false
+			continue
+		}
+		dash = true
+	}
+	return b.String()
+}
+
+// seedImage рисует изображение ковра: основной цвет, темная кайма и светлый узор.
+// Основной цвет занимает большую часть площади и становится преобладающим.
+func seedImage(base color.RGBA, pattern int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, seedImageWidth, seedImageHeight))
+	dark := shade(base, 0.75)
+	light := shade(base, 1.2)
+	border := seedImageWidth / 16
+	for y := range seedImageHeight {
+		for x := range seedImageWidth {
+			c := base
+			switch {
+			case x < border || y < border || x >= seedImageWidth-border || y >= seedImageHeight-border:
+				c = dark
+			case pattern == seedPatternStripes && y%96 < 12:
+				c = light
+			case pattern == seedPatternDiamonds && (abs(x-seedImageWidth/2)+abs(y-seedImageHeight/2))%160 < 16:
+				c = light
+			}
+			img.SetRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+// shade умножает яркость цвета на factor
+func shade(c color.RGBA, factor float64) color.RGBA {
+	scale := func(v uint8) uint8 {
+		return uint8(min(float64(v)*factor, 255))
+	}
+	return color.RGBA{R: scale(c.R), G: scale(c.G), B: scale(c.B), A: c.A}
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
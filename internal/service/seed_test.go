@@ -0,0 +1,154 @@
+package service
+
+import (
+	"context"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/LigeronAhill/luxcarpets-go/internal/database"
+	"github.com/LigeronAhill/luxcarpets-go/internal/database/types"
+	"github.com/LigeronAhill/luxcarpets-go/pkg/imaging"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Изображения должны попадать в разные группы цвета, иначе фильтр по цвету будет неполным
+func TestSeedImage_ColorFamilies(t *testing.T) {
+	want := types.AllColorFamilies()
+	require.Len(t, seedPalette, len(want))
+	for i, base := range seedPalette {
+		for pattern := range seedPatterns {
+			dominant := imaging.DominantColor(seedImage(base, pattern))
+			assert.Equal(t, want[i], types.ColorFamilyOf(dominant), "цвет %s, узор %d", types.HexColor(base), pattern)
+		}
+	}
+}
+
+func testSeedCatalog() *seedCatalog {
+	categories := make(map[string]uuid.UUID)
+	for _, c := range seedCategories {
+		categories[c.Slug] = uuid.New()
+	}
+	var palette []*types.Media
+	for _, family := range types.AllColorFamilies() {
+		palette = append(palette, &types.Media{ID: uuid.New(), ColorFamily: family, DominantColor: "#808080"})
+	}
+	return &seedCatalog{categories: categories, palette: palette}
+}
+
+func TestSeedCatalog_Product(t *testing.T) {
+	catalog := testSeedCatalog()
+	slugPattern := regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+	batch := catalog.batch(0, 300)
+	require.Len(t, batch.products, 300)
+
+	slugs := make(map[string]bool)
+	skus := make(map[string]bool)
+	categories := make(map[uuid.UUID]int)
+	for _, product := range batch.products {
+		assert.Regexp(t, slugPattern, product.Slug)
+		assert.False(t, slugs[product.Slug], "slug %s повторяется", product.Slug)
+		slugs[product.Slug] = true
+		assert.Equal(t, uuid.NewSHA1(seedNamespace, []byte(product.Slug)), product.ID)
+		assert.True(t, product.ColorFamily.Valid())
+		categories[product.CategoryID]++
+	}
+	// Товары есть во всех конечных категориях, родительская категория "kovry" пустая
+	assert.Len(t, categories, len(seedKinds))
+
+	for _, variant := range batch.variants {
+		assert.False(t, skus[variant.SKU], "артикул %s повторяется", variant.SKU)
+		skus[variant.SKU] = true
+		assert.True(t, variant.Unit.Valid())
+		assert.Positive(t, variant.PriceKopecks)
+		require.NotNil(t, variant.WidthCm)
+		assert.Positive(t, *variant.WidthCm)
+		assert.GreaterOrEqual(t, variant.Stock, 0)
+	}
+
+	covers := 0
+	for _, item := range batch.gallery {
+		if item.Position == 0 {
+			covers++
+		}
+		assert.NotEmpty(t, item.AltText)
+	}
+	assert.Equal(t, len(batch.products), covers)
+}
+
+// Товар зависит только от номера: повторный запуск и запуск с другим количеством товаров
+// генерируют те же товары с теми же ID
+func TestSeedCatalog_Product_Deterministic(t *testing.T) {
+	catalog := testSeedCatalog()
+
+	product, variants, gallery := catalog.product(41)
+	batch := catalog.batch(40, 45)
+
+	assert.Equal(t, product, batch.products[1])
+	assert.Subset(t, batch.variants, variants)
+	assert.Subset(t, batch.gallery, gallery)
+	other, _, _ := catalog.product(42)
+	assert.NotEqual(t, product.Slug, other.Slug)
+}
+
+func TestSlugify(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{name: "одно слово", in: "Merinos", want: "merinos"},
+		{name: "несколько слов", in: "Oriental Weavers", want: "oriental-weavers"},
+		{name: "заглавные внутри слова", in: "AirMaster", want: "airmaster"},
+		{name: "лишние символы", in: " Balta  ITC! ", want: "balta-itc"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, slugify(tt.in))
+		})
+	}
+}
+
+// Мягко удаленный пользователь занимает email: заполнение пропускает его, а не падает на SignUp
+func TestSeedService_SeedUsers_SkipsDeleted(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	svc := NewSeedService(NewUsersService(database.NewUsersStorage(mock)), nil, nil)
+	userColumns := []string{
+		"id", "email", "email_verified", "username", "role", "image_url",
+		"password_hash", "created_at", "updated_at", "deleted_at", "phone", "phone_verified",
+	}
+	now := time.Now()
+	deleted := seedUsers[2]
+
+	for _, u := range seedUsers {
+		query := mock.ExpectQuery(`SELECT \* FROM users WHERE email = @email AND deleted_at IS NULL`).WithArgs(u.Email)
+		if u.Email != deleted.Email {
+			query.WillReturnRows(pgxmock.NewRows(userColumns).AddRow(
+				uuid.New(), strPtr(u.Email), true, u.Username, u.Role, nil, nil, now, now, nil, nil, false,
+			))
+			continue
+		}
+		query.WillReturnError(pgx.ErrNoRows)
+		mock.ExpectQuery(`SELECT COUNT\(\*\) FROM users WHERE email ILIKE @email`).
+			WithArgs("%" + u.Email + "%").
+			WillReturnRows(pgxmock.NewRows([]string{"count"}).AddRow(1))
+		mock.ExpectQuery(`SELECT \* FROM users WHERE email ILIKE @email ORDER BY created_at DESC LIMIT @limit`).
+			WithArgs("%"+u.Email+"%", 100).
+			WillReturnRows(pgxmock.NewRows(userColumns).AddRow(
+				uuid.New(), strPtr(u.Email), true, u.Username, u.Role, nil, nil, now, now, &now, nil, false,
+			))
+	}
+
+	created, err := svc.seedUsers(context.Background(), "LuxCarpets-Dev-2026!")
+
+	require.NoError(t, err)
+	assert.Zero(t, created)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,151 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/LigeronAhill/luxcarpets-go/internal/database"
+	"github.com/LigeronAhill/luxcarpets-go/internal/database/types"
+	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
+)
+
+// ErrInvalidSession возвращается при неверной или просроченной сессии
+var ErrInvalidSession = errors.New("invalid or expired session")
+
+const (
+	// DefaultSessionTTL - срок действия сессии браузера
+	DefaultSessionTTL = 30 * 24 * time.Hour
+
+	// sessionTokenBytes - энтропия токена сессии (256 бит)
+	sessionTokenBytes = 32
+	// sessionTouchInterval - как часто обновляется время последнего запроса
+	sessionTouchInterval = time.Minute
+	// maxUserAgentLength - сколько символов User-Agent сохраняется
+	maxUserAgentLength = 512
+)
+
+// IssuedSession содержит созданную сессию.
+// Token передается браузеру в cookie и больше нигде не хранится.
+type IssuedSession struct {
+	Token   string         // Токен для cookie
+	Session *types.Session // Сохраненные данные сессии
+}
+
+// SessionsService управляет сессиями браузера
+type SessionsService struct {
+	storage *database.SessionsStorage
+	users   *database.UsersStorage
+	ttl     time.Duration
+	now     func() time.Time
+}
+
+// NewSessionsService создает сервис сессий. ttl <= 0 - DefaultSessionTTL.
+func NewSessionsService(storage *database.SessionsStorage, users *database.UsersStorage, ttl time.Duration) *SessionsService {
+	if ttl <= 0 {
+		ttl = DefaultSessionTTL
+	}
+	return &SessionsService{
+		storage: storage,
+		users:   users,
+		ttl:     ttl,
+		now:     time.Now,
+	}
+}
+
+// TTL возвращает срок действия новых сессий
+func (s *SessionsService) TTL() time.Duration {
+	return s.ttl
+}
+
+// Create создает сессию для пользователя после успешного входа
+func (s *SessionsService) Create(ctx context.Context, userID uuid.UUID, userAgent, clientIP string) (*IssuedSession, error) {
+	raw := make([]byte, sessionTokenBytes)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, fmt.Errorf("failed to generate session token: %w", err)
+	}
+	token := base64.RawURLEncoding.EncodeToString(raw)
+
+	params := types.CreateSessionParams{
+		UserID:    userID,
+		TokenHash: hashToken(token),
+		ExpiresAt: s.now().Add(s.ttl),
+	}
+	if userAgent != "" {
+		if len(userAgent) > maxUserAgentLength {
+			userAgent = userAgent[:maxUserAgentLength]
+		}
+		params.UserAgent = &userAgent
+	}
+	if clientIP != "" {
+		params.IPAddress = &clientIP
+	}
+	saved, err := s.storage.Create(ctx, params)
+	if err != nil {
+		return nil, fmt.Errorf("failed to save session: %w", err)
+	}
+	return &IssuedSession{Token: token, Session: saved}, nil
+}
+
+// Authenticate проверяет токен из cookie и возвращает клиента.
+// Сессия браузера получает все права, доступные роли пользователя.
+//
+// Возможные ошибки:
+//   - ErrInvalidSession: если сессия не найдена, истекла или пользователь удален
+func (s *SessionsService) Authenticate(ctx context.Context, token string) (*Principal, error) {
+	if token == "" {
+		return nil, ErrInvalidSession
+	}
+	session, err := s.storage.GetByHash(ctx, hashToken(token))
+	if err != nil {
+		return nil, fmt.Errorf("failed to get session: %w", err)
+	}
+	now := s.now()
+	if session == nil || !session.Active(now) {
+		return nil, ErrInvalidSession
+	}
+
+	user, err := s.users.GetByID(ctx, session.UserID)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrInvalidSession
+		}
+		return nil, fmt.Errorf("failed to get session owner: %w", err)
+	}
+	var scopes []types.TokenScope
+	for _, scope := range types.AllTokenScopes() {
+		if scope.AllowedFor(user.Role) {
+			scopes = append(scopes, scope)
+		}
+	}
+
+	if err := s.storage.Touch(ctx, session.ID, now, now.Add(-sessionTouchInterval)); err != nil {
+		slog.WarnContext(ctx, "Failed to update session last use",
+			slog.String("session_id", session.ID.String()),
+			slog.String("error", err.Error()),
+		)
+	}
+	return &Principal{User: user, Scopes: scopes, Session: session}, nil
+}
+
+// Revoke завершает сессию (выход)
+func (s *SessionsService) Revoke(ctx context.Context, sessionID uuid.UUID) error {
+	if err := s.storage.Delete(ctx, sessionID); err != nil {
+		return fmt.Errorf("failed to delete session: %w", err)
+	}
+	return nil
+}
+
+// RevokeAll завершает все сессии пользователя и возвращает их количество
+func (s *SessionsService) RevokeAll(ctx context.Context, userID uuid.UUID) (int64, error) {
+	n, err := s.storage.DeleteByUser(ctx, userID)
+	if err != nil {
+		return 0, fmt.Errorf("failed to delete user sessions: %w", err)
+	}
+	return n, nil
+}
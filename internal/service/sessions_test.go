@@ -0,0 +1,137 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/LigeronAhill/luxcarpets-go/internal/database"
+	"github.com/LigeronAhill/luxcarpets-go/internal/database/types"
+	"github.com/google/uuid"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var sessionColumns = []string{
+	"id", "user_id", "token_hash", "user_agent", "ip_address", "expires_at", "last_seen_at", "created_at",
+}
+
+func newTestSessionsService(mock pgxmock.PgxPoolIface, now time.Time) *SessionsService {
+	svc := NewSessionsService(database.NewSessionsStorage(mock), database.NewUsersStorage(mock), 0)
+	svc.now = func() time.Time { return now }
+	return svc
+}
+
+func TestSessionsService_Create(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	now := time.Now()
+	svc := newTestSessionsService(mock, now)
+	userID := uuid.New()
+	clientIP := "10.0.0.1"
+
+	mock.ExpectQuery(`INSERT INTO sessions`).
+		WithArgs(userID, pgxmock.AnyArg(), (*string)(nil), &clientIP, now.Add(DefaultSessionTTL)).
+		WillReturnRows(pgxmock.NewRows(sessionColumns).AddRow(
+			uuid.New(), userID, "hash", nil, &clientIP, now.Add(DefaultSessionTTL), now, now,
+		))
+
+	issued, err := svc.Create(context.Background(), userID, "", clientIP)
+
+	require.NoError(t, err)
+	assert.Len(t, issued.Token, 43)
+	assert.Equal(t, userID, issued.Session.UserID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSessionsService_Authenticate(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	now := time.Now()
+	svc := newTestSessionsService(mock, now)
+	sessionID := uuid.New()
+	userID := uuid.New()
+	token := "session-token"
+
+	mock.ExpectQuery(`SELECT \* FROM sessions WHERE token_hash = @token_hash`).
+		WithArgs(hashToken(token)).
+		WillReturnRows(pgxmock.NewRows(sessionColumns).AddRow(
+			sessionID, userID, hashToken(token), nil, nil, now.Add(time.Hour), now.Add(-time.Hour), now.Add(-time.Hour),
+		))
+	expectUserByID(mock, userID, "test@example.com", types.RoleCustomer)
+	mock.ExpectExec(`UPDATE sessions`).
+		WithArgs(now, sessionID, now.Add(-sessionTouchInterval)).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+
+	principal, err := svc.Authenticate(context.Background(), token)
+
+	require.NoError(t, err)
+	assert.Equal(t, userID, principal.User.ID)
+	assert.Equal(t, sessionID, principal.Session.ID)
+	assert.Nil(t, principal.Token)
+	assert.True(t, principal.HasScope(types.ScopeProfileWrite))
+	assert.False(t, principal.HasScope(types.ScopeOrdersRead))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSessionsService_Authenticate_Invalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		token string
+		rows  func(now time.Time) *pgxmock.Rows
+	}{
+		{"пустой токен", "", nil},
+		{"сессия не найдена", "unknown", func(time.Time) *pgxmock.Rows {
+			return pgxmock.NewRows(sessionColumns)
+		}},
+		{"сессия истекла", "expired", func(now time.Time) *pgxmock.Rows {
+			return pgxmock.NewRows(sessionColumns).AddRow(
+				uuid.New(), uuid.New(), hashToken("expired"), nil, nil, now.Add(-time.Second), now, now,
+			)
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock, err := pgxmock.NewPool()
+			require.NoError(t, err)
+			defer mock.Close()
+
+			now := time.Now()
+			svc := newTestSessionsService(mock, now)
+			if tt.rows != nil {
+				mock.ExpectQuery(`SELECT \* FROM sessions WHERE token_hash = @token_hash`).
+					WithArgs(hashToken(tt.token)).
+					WillReturnRows(tt.rows(now))
+			}
+
+			_, err = svc.Authenticate(context.Background(), tt.token)
+
+			assert.ErrorIs(t, err, ErrInvalidSession)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestSessionsService_RevokeAll(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	svc := newTestSessionsService(mock, time.Now())
+	userID := uuid.New()
+
+	mock.ExpectExec(`DELETE FROM sessions WHERE user_id = @user_id`).
+		WithArgs(userID).
+		WillReturnResult(pgxmock.NewResult("DELETE", 2))
+
+	n, err := svc.RevokeAll(context.Background(), userID)
+
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), n)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
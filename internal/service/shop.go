@@ -0,0 +1,12 @@
+package service
+
+// ShopInfo - сведения о магазине из настроек: название, описание и контакты.
+// Используются на страницах "О нас" и "Контакты", в llms.txt и разметке schema.org.
+type ShopInfo struct {
+	Name         string // Название
+	About        string // Краткое описание
+	Phone        string // Телефон в международном формате
+	Email        string // Адрес электронной почты
+	Address      string // Адрес шоурума
+	OpeningHours string // Часы работы в формате schema.org ("Mo-Su 10:00-20:00")
+}
@@ -0,0 +1,126 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/LigeronAhill/luxcarpets-go/internal/database"
+	"github.com/LigeronAhill/luxcarpets-go/pkg/blob"
+	"github.com/LigeronAhill/luxcarpets-go/pkg/sitemap"
+)
+
+// ErrSitemapNotFound возвращается для несуществующего файла карты сайта
+var ErrSitemapNotFound = errors.New("sitemap not found")
+
+// sitemapStaticPages - страницы сайта, которые не хранятся в базе данных
+var sitemapStaticPages = []string{"/", "/catalog", "/about", "/contacts"}
+
+// SitemapService формирует карту сайта из каталога: статические страницы, категории
+// и активные товары с изображениями. Удаленные (deleted_at IS NOT NULL) и скрытые товары не включаются.
+//
+// Готовые файлы хранятся в памяти. Перед ответом сервис сверяет отпечаток данных
+// (количество записей и последнее изменение категорий, товаров и галерей) и собирает карту заново,
+// только если каталог изменился.
+type SitemapService struct {
+	storage *database.SitemapStorage
+	blobs   blob.Storage
+	baseURL string
+	limit   int // Адресов в одном файле; 0 - sitemap.MaxURLs
+
+	mu      sync.Mutex
+	version string
+	files   map[string][]byte
+}
+
+// NewSitemapService создает сервис карты сайта. baseURL - публичный адрес сайта ("https://luxcarpets.ru").
+func NewSitemapService(storage *database.SitemapStorage, blobs blob.Storage, baseURL string) *SitemapService {
+	return &SitemapService{
+		storage: storage,
+		blobs:   blobs,
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+	}
+}
+
+// URL возвращает адрес главного файла карты сайта
+func (s *SitemapService) URL() string {
+	return s.baseURL + "/" + sitemap.IndexName
+}
+
+// File возвращает файл карты сайта по имени: "sitemap.xml" или часть индекса "sitemap-1.xml".
+//
+// Возможные ошибки:
+//   - ErrSitemapNotFound: если такого файла нет
+//   - ошибки хранилища
+func (s *SitemapService) File(ctx context.Context, name string) ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	version, err := s.storage.Version(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get sitemap version: %w", err)
+	}
+	if s.files == nil || version != s.version {
+		files, err := s.build(ctx)
+		if err != nil {
+			return nil, err
+		}
+		s.files, s.version = files, version
+	}
+
+	data, ok := s.files[name]
+	if !ok {
+		return nil, ErrSitemapNotFound
+	}
+	return data, nil
+}
+
+// build собирает все файлы карты сайта
+func (s *SitemapService) build(ctx context.Context) (map[string][]byte, error) {
+	categories, err := s.storage.Categories(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sitemap categories: %w", err)
+	}
+	products, err := s.storage.Products(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list sitemap products: %w", err)
+	}
+
+	urls := make([]sitemap.URL, 0, len(sitemapStaticPages)+len(categories)+len(products))
+	for _, path := range sitemapStaticPages {
+		urls = append(urls, sitemap.URL{Loc: s.baseURL + path})
+	}
+	for _, category := range categories {
+		urls = append(urls, sitemap.URL{
+			Loc:     s.baseURL + "/catalog?" + url.Values{"category": {category.Slug}}.Encode(),
+			LastMod: category.UpdatedAt,
+		})
+	}
+	for _, product := range products {
+		u := sitemap.URL{
+			Loc:     s.baseURL + "/product/" + product.Slug,
+			LastMod: product.UpdatedAt,
+		}
+		for _, key := range product.ImageKeys {
+			u.Images = append(u.Images, s.absolute(s.blobs.URL(key)))
+		}
+		urls = append(urls, u)
+	}
+
+	files, err := sitemap.Build(s.baseURL, urls, s.limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build sitemap: %w", err)
+	}
+	return files, nil
+}
+
+// absolute дополняет адресом сайта относительный адрес файла (локальное хранилище раздает "/media/...")
+func (s *SitemapService) absolute(link string) string {
+	if strings.HasPrefix(link, "/") {
+		return s.baseURL + link
+	}
+	return link
+}
@@ -0,0 +1,93 @@
+package service
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/LigeronAhill/luxcarpets-go/internal/database"
+	"github.com/LigeronAhill/luxcarpets-go/pkg/blob"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestSitemapService(t *testing.T, mock pgxmock.PgxPoolIface) *SitemapService {
+	t.Helper()
+	storage, err := blob.NewLocal(t.TempDir(), "/media")
+	require.NoError(t, err)
+	return NewSitemapService(database.NewSitemapStorage(mock), storage, "https://luxcarpets.ru/")
+}
+
+func expectSitemapVersion(mock pgxmock.PgxPoolIface, version string) {
+	mock.ExpectQuery(`SELECT concat_ws`).WillReturnRows(pgxmock.NewRows([]string{"concat_ws"}).AddRow(version))
+}
+
+func expectSitemapData(mock pgxmock.PgxPoolIface, products ...string) {
+	updatedAt := time.Date(2025, 3, 2, 9, 30, 0, 0, time.UTC)
+	mock.ExpectQuery(`SELECT slug, updated_at FROM categories`).
+		WillReturnRows(pgxmock.NewRows([]string{"slug", "updated_at"}).AddRow("kovry", updatedAt))
+	rows := pgxmock.NewRows([]string{"slug", "updated_at", "image_keys"})
+	for _, slug := range products {
+		rows.AddRow(slug, updatedAt, []string{"media/ab/" + slug + "/1920.jpg"})
+	}
+	mock.ExpectQuery(`SELECT p.slug, p.updated_at`).WillReturnRows(rows)
+}
+
+func TestSitemapService_File(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	svc := newTestSitemapService(t, mock)
+	expectSitemapVersion(mock, "v1")
+	expectSitemapData(mock, "osta-diamond")
+	expectSitemapVersion(mock, "v1")
+
+	data, err := svc.File(context.Background(), "sitemap.xml")
+
+	require.NoError(t, err)
+	body := string(data)
+	assert.Contains(t, body, "<loc>https://luxcarpets.ru/</loc>")
+	assert.Contains(t, body, "<loc>https://luxcarpets.ru/catalog?category=kovry</loc>")
+	assert.Contains(t, body, "<loc>https://luxcarpets.ru/product/osta-diamond</loc>\n    <lastmod>2025-03-02T09:30:00Z</lastmod>")
+	assert.Contains(t, body, "<image:loc>https://luxcarpets.ru/media/media/ab/osta-diamond/1920.jpg</image:loc>")
+	assert.Equal(t, "https://luxcarpets.ru/sitemap.xml", svc.URL())
+
+	_, err = svc.File(context.Background(), "sitemap-1.xml")
+	assert.ErrorIs(t, err, ErrSitemapNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestSitemapService_File_RebuildsWhenCatalogChanges(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	svc := newTestSitemapService(t, mock)
+	svc.limit = 6
+
+	expectSitemapVersion(mock, "v1")
+	expectSitemapData(mock, "a")
+	// Каталог не изменился - карта берется из кеша
+	expectSitemapVersion(mock, "v1")
+	// Добавлен товар - карта собирается заново и разбивается на части
+	expectSitemapVersion(mock, "v2")
+	expectSitemapData(mock, "a", "b")
+	expectSitemapVersion(mock, "v2")
+
+	first, err := svc.File(context.Background(), "sitemap.xml")
+	require.NoError(t, err)
+	cached, err := svc.File(context.Background(), "sitemap.xml")
+	require.NoError(t, err)
+	assert.Equal(t, first, cached)
+	assert.Contains(t, string(first), "<urlset")
+
+	index, err := svc.File(context.Background(), "sitemap.xml")
+	require.NoError(t, err)
+	assert.Contains(t, string(index), "<loc>https://luxcarpets.ru/sitemap-2.xml</loc>")
+	part, err := svc.File(context.Background(), "sitemap-2.xml")
+	require.NoError(t, err)
+	assert.Contains(t, string(part), "<loc>https://luxcarpets.ru/product/b</loc>")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,402 @@
+package service
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/LigeronAhill/luxcarpets-go/internal/database"
+	"github.com/LigeronAhill/luxcarpets-go/internal/database/types"
+	"github.com/LigeronAhill/luxcarpets-go/pkg/encryption"
+	"github.com/LigeronAhill/luxcarpets-go/pkg/totp"
+	"github.com/google/uuid"
+)
+
+// Ошибки двухфакторной аутентификации
+var (
+	// ErrSecondFactorRequired возвращается из SignIn, когда для входа нужен второй фактор (*SecondFactorRequiredError)
+	ErrSecondFactorRequired = errors.New("second factor required")
+	// ErrInvalidTwoFactorCode возвращается при неверном, просроченном или повторно использованном коде
+	ErrInvalidTwoFactorCode = errors.New("invalid two-factor code")
+	// ErrInvalidChallenge возвращается при поддельном или просроченном промежуточном токене входа
+	ErrInvalidChallenge = errors.New("invalid or expired sign-in challenge")
+	// ErrTwoFactorNotEnrolled возвращается, если аутентификатор не подключался
+	ErrTwoFactorNotEnrolled = errors.New("two-factor authentication is not enrolled")
+	// ErrTwoFactorAlreadyEnabled возвращается при попытке повторно подключить аутентификатор
+	ErrTwoFactorAlreadyEnabled = errors.New("two-factor authentication is already enabled")
+	// ErrTwoFactorRequired возвращается при попытке отключить 2FA для роли, где она обязательна
+	ErrTwoFactorRequired = errors.New("two-factor authentication is required for this role")
+)
+
+// SecondFactorRequiredError возвращается из SignIn, когда первый фактор проверен,
+// но для завершения входа нужен код из приложения-аутентификатора.
+// Проверяется через errors.Is(err, ErrSecondFactorRequired).
+type SecondFactorRequiredError struct {
+	Challenge          string // Промежуточный токен для CompleteSignIn или CompleteEnrollmentSignIn
+	EnrollmentRequired bool   // true, если роль требует 2FA, а аутентификатор еще не подключен
+}
+
+func (e *SecondFactorRequiredError) Error() string {
+	if e.EnrollmentRequired {
+		return ErrSecondFactorRequired.Error() + ": enrollment required"
+	}
+	return ErrSecondFactorRequired.Error()
+}
+
+func (e *SecondFactorRequiredError) Unwrap() error {
+	return ErrSecondFactorRequired
+}
+
+const (
+	// recoveryCodesCount - количество кодов восстановления, выдаваемых за раз
+	recoveryCodesCount = 10
+	// recoveryCodeBytes - энтропия одного кода восстановления (50 бит после кодирования в base32)
+	recoveryCodeBytes = 7
+	// totpSkew - допустимое расхождение часов в шагах (±30 секунд)
+	totpSkew = 1
+)
+
+// recoveryEncoding - алфавит кодов восстановления без выравнивания
+var recoveryEncoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// challengePurpose определяет, для чего выдан промежуточный токен
+type challengePurpose byte
+
+const (
+	challengeSignIn challengePurpose = 1 // ввод кода для завершения входа
+	challengeEnroll challengePurpose = 2 // обязательное подключение аутентификатора
+)
+
+// challengeHeaderSize - размер полей промежуточного токена перед ключом учетной записи
+const challengeHeaderSize = 25
+
+// TwoFactorConfig содержит настройки двухфакторной аутентификации
+type TwoFactorConfig struct {
+	EncryptionKey []byte         // Ключ AES-256 для шифрования секретов и подписи промежуточных токенов
+	Issuer        string         // Название сервиса в приложении-аутентификаторе
+	RequiredRole  types.UserRole // Минимальная роль, для которой 2FA обязательна (пусто - не обязательна)
+	ChallengeTTL  time.Duration  // Время жизни промежуточного токена входа
+}
+
+// TOTPEnrollment содержит данные для подключения приложения-аутентификатора
+type TOTPEnrollment struct {
+	Secret          string `json:"secret"`           // Секрет в base32 для ручного ввода
+	ProvisioningURI string `json:"provisioning_uri"` // otpauth:// URI для QR-кода
+}
+
+// TwoFactorService управляет TOTP-аутентификаторами и кодами восстановления
+type TwoFactorService struct {
+	storage      *database.TwoFactorStorage
+	users        *database.UsersStorage
+	cipher       *encryption.Cipher
+	challengeKey []byte
+	issuer       string
+	requiredRole types.UserRole
+	challengeTTL time.Duration
+	now          func() time.Time
+}
+
+// NewTwoFactorService создает сервис двухфакторной аутентификации
+//
+// Возможные ошибки:
+//   - encryption.ErrInvalidKey: если ключ шифрования не 32 байта
+//   - ошибка, если RequiredRole не пустая и не является допустимой ролью
+func NewTwoFactorService(storage *database.TwoFactorStorage, users *database.UsersStorage, cfg TwoFactorConfig) (*TwoFactorService, error) {
+	cipher, err := encryption.NewCipher(cfg.EncryptionKey)
+	if err != nil {
+		return nil, err
+	}
+	if cfg.RequiredRole != "" && !cfg.RequiredRole.Valid() {
+		return nil, fmt.Errorf("invalid two-factor required role: %s", cfg.RequiredRole)
+	}
+	if cfg.Issuer == "" {
+		cfg.Issuer = "LuxCarpets"
+	}
+	if cfg.ChallengeTTL <= 0 {
+		cfg.ChallengeTTL = 5 * time.Minute
+	}
+
+	// Ключ подписи токенов выводится из ключа шифрования, чтобы не использовать один ключ для двух целей
+	mac := hmac.New(sha256.New, cfg.EncryptionKey)
+	mac.Write([]byte("luxcarpets two-factor challenge"))
+
+	return &TwoFactorService{
+		storage:      storage,
+		users:        users,
+		cipher:       cipher,
+		challengeKey: mac.Sum(nil),
+		issuer:       cfg.Issuer,
+		requiredRole: cfg.RequiredRole,
+		challengeTTL: cfg.ChallengeTTL,
+		now:          time.Now,
+	}, nil
+}
+
+// Required возвращает true, если политика требует 2FA для роли
+func (s *TwoFactorService) Required(role types.UserRole) bool {
+	return s.requiredRole != "" && role.HasPermission(s.requiredRole)
+}
+
+// Enabled возвращает true, если у пользователя подтвержден аутентификатор
+func (s *TwoFactorService) Enabled(ctx context.Context, userID uuid.UUID) (bool, error) {
+	t, err := s.storage.GetTOTP(ctx, userID)
+	if err != nil {
+		return false, fmt.Errorf("failed to get totp: %w", err)
+	}
+	return t != nil && t.Confirmed(), nil
+}
+
+// BeginEnrollment создает новый секрет и возвращает данные для QR-кода.
+// Подключение не действует, пока не подтверждено через ConfirmEnrollment.
+//
+// Возможные ошибки:
+//   - ErrUserNotFound: если пользователь не найден
+//   - ErrTwoFactorAlreadyEnabled: если аутентификатор уже подтвержден
+func (s *TwoFactorService) BeginEnrollment(ctx context.Context, userID uuid.UUID) (*TOTPEnrollment, error) {
+	user, err := s.users.GetByID(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrUserNotFound, err)
+	}
+
+	secret, err := totp.GenerateSecret()
+	if err != nil {
+		return nil, err
+	}
+	encrypted, err := s.cipher.Encrypt(secret, userID[:])
+	if err != nil {
+		return nil, fmt.Errorf("failed to encrypt totp secret: %w", err)
+	}
+	if _, err := s.storage.UpsertTOTP(ctx, userID, encrypted); err != nil {
+		if errors.Is(err, database.ErrTOTPAlreadyConfirmed) {
+			return nil, ErrTwoFactorAlreadyEnabled
+		}
+		return nil, fmt.Errorf("failed to save totp secret: %w", err)
+	}
+
+	return &TOTPEnrollment{
+		Secret:          totp.EncodeSecret(secret),
+		ProvisioningURI: totp.ProvisioningURI(s.issuer, user.Login(), secret),
+	}, nil
+}
+
+// BeginChallengeEnrollment начинает обязательное подключение аутентификатора
+// по промежуточному токену из SecondFactorRequiredError с EnrollmentRequired = true
+func (s *TwoFactorService) BeginChallengeEnrollment(ctx context.Context, challenge string) (*TOTPEnrollment, error) {
+	userID, _, err := s.parseChallenge(challenge, challengeEnroll)
+	if err != nil {
+		return nil, err
+	}
+	return s.BeginEnrollment(ctx, userID)
+}
+
+// ConfirmEnrollment подтверждает аутентификатор первым кодом из приложения
+// и выдает новые коды восстановления. Коды показываются пользователю один раз,
+// в базе данных хранятся только их хеши.
+//
+// Возможные ошибки:
+//   - ErrTwoFactorNotEnrolled: если BeginEnrollment не вызывался
+//   - ErrTwoFactorAlreadyEnabled: если аутентификатор уже подтвержден
+//   - ErrInvalidTwoFactorCode: если код неверный
+func (s *TwoFactorService) ConfirmEnrollment(ctx context.Context, userID uuid.UUID, code string) ([]string, error) {
+	t, err := s.storage.GetTOTP(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get totp: %w", err)
+	}
+	if t == nil {
+		return nil, ErrTwoFactorNotEnrolled
+	}
+	if t.Confirmed() {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+
+	secret, err := s.cipher.Decrypt(t.SecretEncrypted, userID[:])
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt totp secret: %w", err)
+	}
+	step, ok := totp.Validate(secret, code, s.now(), totpSkew)
+	if !ok {
+		return nil, ErrInvalidTwoFactorCode
+	}
+	confirmed, err := s.storage.ConfirmTOTP(ctx, userID, step)
+	if err != nil {
+		return nil, fmt.Errorf("failed to confirm totp: %w", err)
+	}
+	if !confirmed {
+		return nil, ErrTwoFactorAlreadyEnabled
+	}
+
+	return s.issueRecoveryCodes(ctx, userID)
+}
+
+// Verify проверяет код из приложения (6 цифр) или код восстановления.
+// Каждый код принимается только один раз.
+//
+// Возможные ошибки:
+//   - ErrInvalidTwoFactorCode: если код неверный или уже использован
+func (s *TwoFactorService) Verify(ctx context.Context, userID uuid.UUID, code string) error {
+	code = strings.TrimSpace(code)
+	if isTOTPCode(code) {
+		return s.verifyTOTP(ctx, userID, code)
+	}
+
+	used, err := s.storage.UseRecoveryCode(ctx, userID, hashRecoveryCode(code))
+	if err != nil {
+		return fmt.Errorf("failed to use recovery code: %w", err)
+	}
+	if !used {
+		return ErrInvalidTwoFactorCode
+	}
+	return nil
+}
+
+// RegenerateRecoveryCodes заменяет коды восстановления после проверки текущего кода
+func (s *TwoFactorService) RegenerateRecoveryCodes(ctx context.Context, userID uuid.UUID, code string) ([]string, error) {
+	if err := s.Verify(ctx, userID, code); err != nil {
+		return nil, err
+	}
+	return s.issueRecoveryCodes(ctx, userID)
+}
+
+// Disable отключает 2FA после проверки кода.
+// Для ролей, где 2FA обязательна, возвращает ErrTwoFactorRequired.
+func (s *TwoFactorService) Disable(ctx context.Context, userID uuid.UUID, code string) error {
+	user, err := s.users.GetByID(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("%w: %s", ErrUserNotFound, err)
+	}
+	if s.Required(user.Role) {
+		return ErrTwoFactorRequired
+	}
+	if err := s.Verify(ctx, userID, code); err != nil {
+		return err
+	}
+	return s.storage.DeleteTOTP(ctx, userID)
+}
+
+// Reset сбрасывает 2FA без проверки кода - для администраторов,
+// когда пользователь потерял устройство и коды восстановления.
+// Проверка прав выполняется вызывающей стороной.
+func (s *TwoFactorService) Reset(ctx context.Context, userID uuid.UUID) error {
+	return s.storage.DeleteTOTP(ctx, userID)
+}
+
+// verifyTOTP проверяет код приложения и запрещает его повторное использование
+func (s *TwoFactorService) verifyTOTP(ctx context.Context, userID uuid.UUID, code string) error {
+	t, err := s.storage.GetTOTP(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("failed to get totp: %w", err)
+	}
+	if t == nil || !t.Confirmed() {
+		return ErrInvalidTwoFactorCode
+	}
+	secret, err := s.cipher.Decrypt(t.SecretEncrypted, userID[:])
+	if err != nil {
+		return fmt.Errorf("failed to decrypt totp secret: %w", err)
+	}
+	step, ok := totp.Validate(secret, code, s.now(), totpSkew)
+	if !ok || step <= t.LastUsedStep {
+		return ErrInvalidTwoFactorCode
+	}
+	accepted, err := s.storage.UseTOTPStep(ctx, userID, step)
+	if err != nil {
+		return fmt.Errorf("failed to use totp step: %w", err)
+	}
+	if !accepted {
+		return ErrInvalidTwoFactorCode
+	}
+	return nil
+}
+
+// issueRecoveryCodes создает новые коды восстановления и сохраняет их хеши
+func (s *TwoFactorService) issueRecoveryCodes(ctx context.Context, userID uuid.UUID) ([]string, error) {
+	codes := make([]string, recoveryCodesCount)
+	hashes := make([]string, recoveryCodesCount)
+	for i := range codes {
+		raw, err := generateRandomBytes(recoveryCodeBytes)
+		if err != nil {
+			return nil, err
+		}
+		encoded := strings.ToLower(recoveryEncoding.EncodeToString(raw))[:10]
+		codes[i] = encoded[:5] + "-" + encoded[5:]
+		hashes[i] = hashRecoveryCode(codes[i])
+	}
+	if err := s.storage.ReplaceRecoveryCodes(ctx, userID, hashes); err != nil {
+		return nil, fmt.Errorf("failed to save recovery codes: %w", err)
+	}
+	return codes, nil
+}
+
+// issueChallenge создает подписанный промежуточный токен входа.
+// Токен не хранится на сервере, поэтому работает на любой реплике.
+// login - ключ учетной записи в LoginGuard, под которым засчитан первый шаг входа (email или телефон):
+// попытки второго шага засчитываются под тем же ключом.
+// Формат: base64url(user_id[16] || expires_unix[8] || purpose[1] || login[n] || hmac_sha256[32]).
+func (s *TwoFactorService) issueChallenge(userID uuid.UUID, login string, purpose challengePurpose) string {
+	payload := make([]byte, 0, challengeHeaderSize+len(login)+sha256.Size)
+	payload = append(payload, userID[:]...)
+	payload = binary.BigEndian.AppendUint64(payload, uint64(s.now().Add(s.challengeTTL).Unix()))
+	payload = append(payload, byte(purpose))
+	payload = append(payload, login...)
+
+	mac := hmac.New(sha256.New, s.challengeKey)
+	mac.Write(payload)
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(payload))
+}
+
+// parseChallenge проверяет подпись, срок действия и назначение промежуточного токена.
+// Возвращает пользователя и ключ учетной записи, под которым засчитан первый шаг входа.
+func (s *TwoFactorService) parseChallenge(challenge string, purpose challengePurpose) (uuid.UUID, string, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(challenge)
+	if err != nil || len(raw) < challengeHeaderSize+sha256.Size {
+		return uuid.Nil, "", ErrInvalidChallenge
+	}
+	payload, signature := raw[:len(raw)-sha256.Size], raw[len(raw)-sha256.Size:]
+
+	mac := hmac.New(sha256.New, s.challengeKey)
+	mac.Write(payload)
+	if !hmac.Equal(signature, mac.Sum(nil)) {
+		return uuid.Nil, "", ErrInvalidChallenge
+	}
+	if challengePurpose(payload[24]) != purpose {
+		return uuid.Nil, "", ErrInvalidChallenge
+	}
+	expires := time.Unix(int64(binary.BigEndian.Uint64(payload[16:24])), 0)
+	if !s.now().Before(expires) {
+		return uuid.Nil, "", ErrInvalidChallenge
+	}
+
+	userID, err := uuid.FromBytes(payload[:16])
+	if err != nil {
+		return uuid.Nil, "", ErrInvalidChallenge
+	}
+	return userID, string(payload[challengeHeaderSize:]), nil
+}
+
+// isTOTPCode проверяет, похож ли код на код приложения (ровно 6 цифр)
+func isTOTPCode(code string) bool {
+	if len(code) != totp.Digits {
+		return false
+	}
+	for _, c := range code {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// hashRecoveryCode нормализует код восстановления (регистр, дефисы, пробелы) и возвращает SHA-256 в hex.
+// Быстрый хеш допустим: коды случайные и имеют 50 бит энтропии.
+func hashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
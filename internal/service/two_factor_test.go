@@ -0,0 +1,447 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/LigeronAhill/luxcarpets-go/internal/database"
+	"github.com/LigeronAhill/luxcarpets-go/internal/database/types"
+	"github.com/LigeronAhill/luxcarpets-go/pkg/totp"
+	"github.com/google/uuid"
+	"github.com/pashagolub/pgxmock/v4"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testTwoFactorKey = []byte("0123456789abcdef0123456789abcdef")
+
+func newTestTwoFactorService(t *testing.T, mock pgxmock.PgxPoolIface, requiredRole types.UserRole, now time.Time) *TwoFactorService {
+	t.Helper()
+	svc, err := NewTwoFactorService(
+		database.NewTwoFactorStorage(mock),
+		database.NewUsersStorage(mock),
+		TwoFactorConfig{EncryptionKey: testTwoFactorKey, RequiredRole: requiredRole},
+	)
+	require.NoError(t, err)
+	svc.now = func() time.Time { return now }
+	return svc
+}
+
+func expectUserByID(mock pgxmock.PgxPoolIface, userID uuid.UUID, email string, role types.UserRole) {
+	now := time.Now()
+	mock.ExpectQuery(`SELECT \* FROM users WHERE id = @id AND deleted_at IS NULL`).
+		WithArgs(pgxmock.AnyArg()).
+		WillReturnRows(pgxmock.NewRows([]string{
+			"id", "email", "email_verified", "username", "role", "image_url",
+			"password_hash", "created_at", "updated_at", "deleted_at", "phone", "phone_verified",
+		}).AddRow(
+			userID, &email, true, "testuser", role, nil,
+			nil, now, now, nil, nil, false,
+		))
+}
+
+func expectConfirmedTOTP(mock pgxmock.PgxPoolIface, svc *TwoFactorService, userID uuid.UUID, secret []byte, lastUsedStep int64) {
+	encrypted, _ := svc.cipher.Encrypt(secret, userID[:])
+	now := time.Now()
+	mock.ExpectQuery(`SELECT \* FROM user_totp WHERE user_id = @user_id`).
+		WithArgs(pgxmock.AnyArg()).
+		WillReturnRows(pgxmock.NewRows([]string{
+			"user_id", "secret_encrypted", "confirmed_at", "last_used_step", "created_at", "updated_at",
+		}).AddRow(userID, encrypted, &now, lastUsedStep, now, now))
+}
+
+func TestNewTwoFactorService_InvalidConfig(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	_, err = NewTwoFactorService(database.NewTwoFactorStorage(mock), database.NewUsersStorage(mock), TwoFactorConfig{EncryptionKey: []byte("short")})
+	assert.Error(t, err)
+
+	_, err = NewTwoFactorService(database.NewTwoFactorStorage(mock), database.NewUsersStorage(mock), TwoFactorConfig{
+		EncryptionKey: testTwoFactorKey,
+		RequiredRole:  types.UserRole("superhero"),
+	})
+	assert.Error(t, err)
+}
+
+func TestTwoFactorService_Required(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	svc := newTestTwoFactorService(t, mock, types.RoleEmployee, time.Now())
+	assert.False(t, svc.Required(types.RoleGuest))
+	assert.False(t, svc.Required(types.RoleCustomer))
+	assert.True(t, svc.Required(types.RoleEmployee))
+	assert.True(t, svc.Required(types.RoleAdmin))
+
+	optional := newTestTwoFactorService(t, mock, "", time.Now())
+	assert.False(t, optional.Required(types.RoleAdmin))
+}
+
+func TestTwoFactorService_Challenge(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	now := time.Now()
+	svc := newTestTwoFactorService(t, mock, "", now)
+	userID := uuid.New()
+	challenge := svc.issueChallenge(userID, testPhone, challengeSignIn)
+
+	t.Run("валидный токен", func(t *testing.T) {
+		got, login, err := svc.parseChallenge(challenge, challengeSignIn)
+		require.NoError(t, err)
+		assert.Equal(t, userID, got)
+		assert.Equal(t, testPhone, login)
+	})
+
+	t.Run("другое назначение", func(t *testing.T) {
+		_, _, err := svc.parseChallenge(challenge, challengeEnroll)
+		assert.ErrorIs(t, err, ErrInvalidChallenge)
+	})
+
+	t.Run("подделанный токен", func(t *testing.T) {
+		tampered := []byte(challenge)
+		if tampered[0] == 'A' {
+			tampered[0] = 'B'
+		} else {
+			tampered[0] = 'A'
+		}
+		_, _, err := svc.parseChallenge(string(tampered), challengeSignIn)
+		assert.ErrorIs(t, err, ErrInvalidChallenge)
+	})
+
+	t.Run("мусор", func(t *testing.T) {
+		_, _, err := svc.parseChallenge("not-a-challenge", challengeSignIn)
+		assert.ErrorIs(t, err, ErrInvalidChallenge)
+	})
+
+	t.Run("просроченный токен", func(t *testing.T) {
+		svc.now = func() time.Time { return now.Add(svc.challengeTTL + time.Second) }
+		defer func() { svc.now = func() time.Time { return now } }()
+		_, _, err := svc.parseChallenge(challenge, challengeSignIn)
+		assert.ErrorIs(t, err, ErrInvalidChallenge)
+	})
+}
+
+func TestHashRecoveryCode_Normalization(t *testing.T) {
+	expected := hashRecoveryCode("abcde-fghij")
+	assert.Equal(t, expected, hashRecoveryCode("ABCDE-FGHIJ"))
+	assert.Equal(t, expected, hashRecoveryCode("abcdefghij"))
+	assert.Equal(t, expected, hashRecoveryCode("abcde fghij"))
+	assert.NotEqual(t, expected, hashRecoveryCode("abcde-fghik"))
+}
+
+func TestIsTOTPCode(t *testing.T) {
+	assert.True(t, isTOTPCode("123456"))
+	assert.False(t, isTOTPCode("12345"))
+	assert.False(t, isTOTPCode("1234567"))
+	assert.False(t, isTOTPCode("12a456"))
+	assert.False(t, isTOTPCode("abcde-fghij"))
+}
+
+func TestTwoFactorService_ConfirmEnrollment_Success(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	now := time.Now()
+	svc := newTestTwoFactorService(t, mock, "", now)
+	userID := uuid.New()
+	secret, err := totp.GenerateSecret()
+	require.NoError(t, err)
+	encrypted, err := svc.cipher.Encrypt(secret, userID[:])
+	require.NoError(t, err)
+
+	mock.ExpectQuery(`SELECT \* FROM user_totp WHERE user_id = @user_id`).
+		WithArgs(pgxmock.AnyArg()).
+		WillReturnRows(pgxmock.NewRows([]string{
+			"user_id", "secret_encrypted", "confirmed_at", "last_used_step", "created_at", "updated_at",
+		}).AddRow(userID, encrypted, nil, int64(0), now, now))
+	mock.ExpectExec(`UPDATE user_totp
+		SET confirmed_at = NOW\(\), last_used_step = @step`).
+		WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg()).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	mock.ExpectBegin()
+	mock.ExpectExec(`DELETE FROM user_recovery_codes`).
+		WithArgs(pgxmock.AnyArg()).
+		WillReturnResult(pgxmock.NewResult("DELETE", 0))
+	mock.ExpectExec(`INSERT INTO user_recovery_codes`).
+		WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg()).
+		WillReturnResult(pgxmock.NewResult("INSERT", recoveryCodesCount))
+	mock.ExpectCommit()
+
+	code := totp.Code(secret, totp.Step(now), totp.Digits)
+	codes, err := svc.ConfirmEnrollment(context.Background(), userID, code)
+
+	require.NoError(t, err)
+	assert.Len(t, codes, recoveryCodesCount)
+	for _, c := range codes {
+		assert.Regexp(t, `^[a-z2-7]{5}-[a-z2-7]{5}$`, c)
+	}
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTwoFactorService_ConfirmEnrollment_NotEnrolled(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	svc := newTestTwoFactorService(t, mock, "", time.Now())
+
+	mock.ExpectQuery(`SELECT \* FROM user_totp WHERE user_id = @user_id`).
+		WithArgs(pgxmock.AnyArg()).
+		WillReturnRows(pgxmock.NewRows([]string{
+			"user_id", "secret_encrypted", "confirmed_at", "last_used_step", "created_at", "updated_at",
+		}))
+
+	_, err = svc.ConfirmEnrollment(context.Background(), uuid.New(), "123456")
+
+	assert.ErrorIs(t, err, ErrTwoFactorNotEnrolled)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTwoFactorService_Verify_ReplayRejected(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	now := time.Now()
+	svc := newTestTwoFactorService(t, mock, "", now)
+	userID := uuid.New()
+	secret, err := totp.GenerateSecret()
+	require.NoError(t, err)
+
+	// Шаг уже использован - код не принимается без обращения к UseTOTPStep
+	expectConfirmedTOTP(mock, svc, userID, secret, totp.Step(now)+totpSkew)
+
+	err = svc.Verify(context.Background(), userID, totp.Code(secret, totp.Step(now), totp.Digits))
+
+	assert.ErrorIs(t, err, ErrInvalidTwoFactorCode)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTwoFactorService_Verify_RecoveryCode(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	svc := newTestTwoFactorService(t, mock, "", time.Now())
+
+	mock.ExpectExec(`UPDATE user_recovery_codes`).
+		WithArgs(pgxmock.AnyArg(), hashRecoveryCode("abcde-fghij")).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	mock.ExpectExec(`UPDATE user_recovery_codes`).
+		WithArgs(pgxmock.AnyArg(), hashRecoveryCode("abcde-fghij")).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 0))
+
+	ctx := context.Background()
+	userID := uuid.New()
+	assert.NoError(t, svc.Verify(ctx, userID, " ABCDE-FGHIJ "))
+	assert.ErrorIs(t, svc.Verify(ctx, userID, "abcde-fghij"), ErrInvalidTwoFactorCode)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestTwoFactorService_Disable_RequiredRole(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	svc := newTestTwoFactorService(t, mock, types.RoleEmployee, time.Now())
+	userID := uuid.New()
+	expectUserByID(mock, userID, "staff@example.com", types.RoleEmployee)
+
+	err = svc.Disable(context.Background(), userID, "123456")
+
+	assert.ErrorIs(t, err, ErrTwoFactorRequired)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUsersService_SignIn_SecondFactor(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	now := time.Now()
+	twoFactor := newTestTwoFactorService(t, mock, "", now)
+	service := NewUsersService(database.NewUsersStorage(mock), WithTwoFactor(twoFactor))
+
+	userID := uuid.New()
+	email := "test@example.com"
+	password := "TestP@ssw0rd"
+	hashedPassword, _ := service.hashPassword(password)
+	secret, err := totp.GenerateSecret()
+	require.NoError(t, err)
+
+	mock.ExpectQuery(`SELECT \* FROM users WHERE email = @email AND deleted_at IS NULL`).
+		WithArgs(pgxmock.AnyArg()).
+		WillReturnRows(pgxmock.NewRows([]string{
+			"id", "email", "email_verified", "username", "role", "image_url",
+			"password_hash", "created_at", "updated_at", "deleted_at", "phone", "phone_verified",
+		}).AddRow(
+			userID, &email, true, "testuser", types.RoleCustomer, nil,
+			&hashedPassword, now, now, nil, nil, false,
+		))
+	expectConfirmedTOTP(mock, twoFactor, userID, secret, 0)
+
+	ctx := context.Background()
+	result, err := service.SignIn(ctx, email, "", password)
+
+	assert.Nil(t, result)
+	require.ErrorIs(t, err, ErrSecondFactorRequired)
+	var sfErr *SecondFactorRequiredError
+	require.True(t, errors.As(err, &sfErr))
+	assert.False(t, sfErr.EnrollmentRequired)
+
+	// Второй шаг: код из приложения
+	expectUserByID(mock, userID, email, types.RoleCustomer)
+	expectConfirmedTOTP(mock, twoFactor, userID, secret, 0)
+	mock.ExpectExec(`UPDATE user_totp
+		SET last_used_step = @step`).
+		WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg()).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+
+	result, err = service.CompleteSignIn(ctx, sfErr.Challenge, "", totp.Code(secret, totp.Step(now), totp.Digits))
+
+	require.NoError(t, err)
+	assert.Equal(t, email, result.Email)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// Вход по телефону пользователя с email: коды второго шага засчитываются под ключом телефона,
+// под которым засчитан первый шаг, и успешный вход сбрасывает именно его
+func TestUsersService_SignInWithPhone_SecondFactor(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	now := time.Now()
+	otp, _ := newTestPhoneOTPService(t, mock, now)
+	twoFactor := newTestTwoFactorService(t, mock, "", now)
+	store := NewMemoryLoginAttemptsStore()
+	guard := NewLoginGuard(store, LockoutPolicy{
+		MaxFailures:     10,
+		LockoutDuration: time.Minute,
+		ResetAfter:      time.Hour,
+	}, DefaultIPLockoutPolicy)
+	service := NewUsersService(database.NewUsersStorage(mock), WithPhoneOTP(otp), WithTwoFactor(twoFactor), WithLoginGuard(guard))
+	ctx := context.Background()
+
+	userID := uuid.New()
+	email := "test@example.com"
+	phone := testPhone
+	secret, err := totp.GenerateSecret()
+	require.NoError(t, err)
+
+	expectPhoneOTP(mock, otp, "123456", 0, time.Minute)
+	mock.ExpectExec(`UPDATE phone_otp_codes SET expires_at = sent_at`).
+		WithArgs(testPhone, otp.hashCode(testPhone, "123456"), otp.cfg.MaxAttempts).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+	mock.ExpectQuery(`SELECT \* FROM users WHERE phone = @phone AND phone_verified`).
+		WithArgs(testPhone).
+		WillReturnRows(pgxmock.NewRows([]string{
+			"id", "email", "email_verified", "username", "role", "image_url",
+			"password_hash", "created_at", "updated_at", "deleted_at", "phone", "phone_verified",
+		}).AddRow(
+			userID, &email, true, "testuser", types.RoleCustomer, nil,
+			nil, now, now, nil, &phone, true,
+		))
+	expectConfirmedTOTP(mock, twoFactor, userID, secret, 0)
+
+	_, err = service.SignInWithPhone(ctx, testPhone, "", "123456")
+	var sfErr *SecondFactorRequiredError
+	require.True(t, errors.As(err, &sfErr))
+
+	// Неверный код засчитывается под ключом телефона, а не email
+	step := totp.Step(now)
+	valid := []string{
+		totp.Code(secret, step-1, totp.Digits),
+		totp.Code(secret, step, totp.Digits),
+		totp.Code(secret, step+1, totp.Digits),
+	}
+	wrong := "000000"
+	if slices.Contains(valid, wrong) {
+		wrong = "111111"
+	}
+	if slices.Contains(valid, wrong) {
+		wrong = "222222"
+	}
+	if slices.Contains(valid, wrong) {
+		wrong = "333333"
+	}
+	expectUserByID(mock, userID, email, types.RoleCustomer)
+	expectConfirmedTOTP(mock, twoFactor, userID, secret, 0)
+
+	_, err = service.CompleteSignIn(ctx, sfErr.Challenge, "", wrong)
+	require.ErrorIs(t, err, ErrInvalidTwoFactorCode)
+
+	attempt, err := store.Get(ctx, accountKey(testPhone))
+	require.NoError(t, err)
+	require.NotNil(t, attempt)
+	assert.Equal(t, 1, attempt.Failures)
+	attempt, err = store.Get(ctx, accountKey(email))
+	require.NoError(t, err)
+	assert.Nil(t, attempt)
+
+	// Успешный вход сбрасывает счетчик телефона
+	expectUserByID(mock, userID, email, types.RoleCustomer)
+	expectConfirmedTOTP(mock, twoFactor, userID, secret, 0)
+	mock.ExpectExec(`UPDATE user_totp
+		SET last_used_step = @step`).
+		WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg()).
+		WillReturnResult(pgxmock.NewResult("UPDATE", 1))
+
+	_, err = service.CompleteSignIn(ctx, sfErr.Challenge, "", valid[1])
+	require.NoError(t, err)
+
+	attempt, err = store.Get(ctx, accountKey(testPhone))
+	require.NoError(t, err)
+	assert.Nil(t, attempt)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUsersService_SignIn_EnrollmentRequired(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	now := time.Now()
+	twoFactor := newTestTwoFactorService(t, mock, types.RoleEmployee, now)
+	service := NewUsersService(database.NewUsersStorage(mock), WithTwoFactor(twoFactor))
+
+	userID := uuid.New()
+	email := "staff@example.com"
+	password := "TestP@ssw0rd"
+	hashedPassword, _ := service.hashPassword(password)
+
+	mock.ExpectQuery(`SELECT \* FROM users WHERE email = @email AND deleted_at IS NULL`).
+		WithArgs(pgxmock.AnyArg()).
+		WillReturnRows(pgxmock.NewRows([]string{
+			"id", "email", "email_verified", "username", "role", "image_url",
+			"password_hash", "created_at", "updated_at", "deleted_at", "phone", "phone_verified",
+		}).AddRow(
+			userID, &email, true, "staff", types.RoleEmployee, nil,
+			&hashedPassword, now, now, nil, nil, false,
+		))
+	mock.ExpectQuery(`SELECT \* FROM user_totp WHERE user_id = @user_id`).
+		WithArgs(pgxmock.AnyArg()).
+		WillReturnRows(pgxmock.NewRows([]string{
+			"user_id", "secret_encrypted", "confirmed_at", "last_used_step", "created_at", "updated_at",
+		}))
+
+	_, err = service.SignIn(context.Background(), email, "", password)
+
+	var sfErr *SecondFactorRequiredError
+	require.True(t, errors.As(err, &sfErr))
+	assert.True(t, sfErr.EnrollmentRequired)
+
+	// Токен подключения нельзя использовать для завершения обычного входа
+	_, err = service.CompleteSignIn(context.Background(), sfErr.Challenge, "", "123456")
+	assert.ErrorIs(t, err, ErrInvalidChallenge)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
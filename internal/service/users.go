@@ -4,11 +4,17 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"log/slog"
+	"net/netip"
 	"strings"
 
 	"github.com/LigeronAhill/luxcarpets-go/internal/database"
 	"github.com/LigeronAhill/luxcarpets-go/internal/database/types"
+	"github.com/LigeronAhill/luxcarpets-go/internal/metrics"
+	"github.com/LigeronAhill/luxcarpets-go/internal/tracing"
+	"github.com/LigeronAhill/luxcarpets-go/pkg/phone"
 	"github.com/google/uuid"
+	"github.com/jackc/pgx/v5"
 )
 
 // Определяем переменные для ошибок
@@ -16,8 +22,10 @@ var (
 	// Ошибки валидации
 	// ErrEmailRequired возвращается, когда email не предоставлен
 	ErrEmailRequired = errors.New("email cannot be empty")
-	// ErrPasswordOrTokenReq возвращается, когда не предоставлен ни пароль, ни токен верификации
-	ErrPasswordOrTokenReq = errors.New("either password or verification token must be provided")
+	// ErrInvalidIP возвращается, когда IP-адрес не указан или указан с ошибкой
+	ErrInvalidIP = errors.New("invalid ip address")
+	// ErrPasswordRequired возвращается, когда пароль не предоставлен
+	ErrPasswordRequired = errors.New("password cannot be empty")
 
 	// Ошибки аутентификации
 	// ErrWrongCredentials возвращается при неверных учетных данных
@@ -27,12 +35,6 @@ var (
 	// ErrUserNotFound возвращается если пользователь не найден
 	ErrUserNotFound = errors.New("user not found")
 
-	// Ошибки доступа
-	// ErrPasswordLoginNotAvailable возвращается, когда у пользователя нет пароля
-	ErrPasswordLoginNotAvailable = errors.New("password login not available for this user")
-	// ErrTokenLoginNotAvailable возвращается, когда у пользователя нет токена верификации
-	ErrTokenLoginNotAvailable = errors.New("token login not available for this user")
-
 	// Ошибки пагинации
 	ErrInvalidOffset = errors.New("offset must be greater than or equal to 0")
 	ErrInvalidLimit  = errors.New("limit must be between 1 and 100")
@@ -43,12 +45,79 @@ var (
 
 // UsersService предоставляет методы для работы с пользователями
 type UsersService struct {
-	storage *database.UsersStorage
+	storage    *database.UsersStorage
+	guard      *LoginGuard
+	policy     PasswordPolicy
+	hashParams *params
+	dummyHash  func() string
+	twoFactor  *TwoFactorService
+	phoneOTP   *PhoneOTPService
+	magicLinks *MagicLinkService
+}
+
+// UsersServiceOption настраивает UsersService при создании
+type UsersServiceOption func(*UsersService)
+
+// WithLoginGuard задает защиту от перебора паролей.
+// По умолчанию используется LoginGuard с хранилищем в памяти процесса.
+func WithLoginGuard(guard *LoginGuard) UsersServiceOption {
+	return func(s *UsersService) {
+		s.guard = guard
+	}
+}
+
+// WithPasswordPolicy задает политику для новых паролей.
+// По умолчанию используется DefaultPasswordPolicy.
+func WithPasswordPolicy(policy PasswordPolicy) UsersServiceOption {
+	return func(s *UsersService) {
+		s.policy = policy
+	}
+}
+
+// WithArgon2Params задает параметры Argon2id для новых хешей.
+// Хеши с другими параметрами пересчитываются при следующем успешном входе.
+// Параметры должны быть предварительно проверены через Argon2Params.Validate.
+func WithArgon2Params(p Argon2Params) UsersServiceOption {
+	return func(s *UsersService) {
+		s.hashParams = p.toParams()
+	}
+}
+
+// WithTwoFactor включает второй шаг входа для пользователей с подключенным аутентификатором
+// и обязательное подключение для ролей, указанных в политике TwoFactorService.
+func WithTwoFactor(twoFactor *TwoFactorService) UsersServiceOption {
+	return func(s *UsersService) {
+		s.twoFactor = twoFactor
+	}
+}
+
+// WithPhoneOTP включает вход по телефону с одноразовым кодом из SMS
+func WithPhoneOTP(phoneOTP *PhoneOTPService) UsersServiceOption {
+	return func(s *UsersService) {
+		s.phoneOTP = phoneOTP
+	}
+}
+
+// WithMagicLinks включает вход по одноразовой ссылке из письма
+func WithMagicLinks(magicLinks *MagicLinkService) UsersServiceOption {
+	return func(s *UsersService) {
+		s.magicLinks = magicLinks
+	}
 }
 
 // NewUsersService создает новый экземпляр сервиса пользователей
-func NewUsersService(storage *database.UsersStorage) *UsersService {
-	return &UsersService{storage}
+func NewUsersService(storage *database.UsersStorage, opts ...UsersServiceOption) *UsersService {
+	s := &UsersService{
+		storage:    storage,
+		guard:      NewLoginGuard(NewMemoryLoginAttemptsStore(), DefaultAccountLockoutPolicy, DefaultIPLockoutPolicy),
+		policy:     DefaultPasswordPolicy,
+		hashParams: defaultParams,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	s.dummyHash = newDummyPasswordHash(s.hashParams)
+	return s
 }
 
 // SignUp регистрирует нового пользователя в системе
@@ -60,20 +129,21 @@ func NewUsersService(storage *database.UsersStorage) *UsersService {
 //   - password: указатель на строку с паролем (может быть nil для OAuth регистрации)
 //   - role: указатель на строку с ролью (может быть nil, тогда устанавливается RoleGuest)
 //   - imageURL: указатель на строку с URL аватара (может быть nil)
-//   - verificationToken: указатель на строку с токеном верификации (может быть nil)
 //
 // Возвращает:
 //   - *types.PublicUser: публичные данные созданного пользователя
 //   - error: ошибка, если регистрация не удалась
 //
 // Возможные ошибки:
-//   - ошибки валидации пароля из функции hashPassword
+//   - ошибки валидации пароля по политике сервиса (PasswordPolicy)
 //   - ErrInvalidRole если роль не существует
 //   - ошибки базы данных при создании пользователя
-func (s *UsersService) SignUp(ctx context.Context, email, username string, password, role, imageURL, verificationToken *string) (*types.PublicUser, error) {
+func (s *UsersService) SignUp(ctx context.Context, email, username string, password, role, imageURL *string) (_ *types.PublicUser, err error) {
+	ctx, span := tracing.Start(ctx, "UsersService.SignUp")
+	defer tracing.End(span, &err)
 	var passwordHash *string
 	if password != nil {
-		hash, err := hashPassword(*password)
+		hash, err := s.hashPassword(*password)
 		if err != nil {
 			return nil, fmt.Errorf("invalid password: %w", err)
 		}
@@ -90,30 +160,35 @@ func (s *UsersService) SignUp(ctx context.Context, email, username string, passw
 	}
 
 	params := types.CreateUserParams{
-		Email:             email,
-		Username:          username,
-		PasswordHash:      passwordHash,
-		Role:              parsedRole,
-		ImageURL:          imageURL,
-		VerificationToken: verificationToken,
+		Email:        email,
+		Username:     username,
+		PasswordHash: passwordHash,
+		Role:         parsedRole,
+		ImageURL:     imageURL,
 	}
 
 	created, err := s.storage.Create(ctx, params)
 	if err != nil {
 		return nil, fmt.Errorf("failed to create user: %w", err)
 	}
+	method := metrics.SignUpEmail
+	if password != nil {
+		method = metrics.SignUpPassword
+	}
+	metrics.SignUps.WithLabelValues(method).Inc()
 
 	res := created.ToPublic()
 	return &res, nil
 }
 
-// SignIn аутентифицирует пользователя по email и паролю или токену верификации
+// SignIn аутентифицирует пользователя по email и паролю.
+// Вход без пароля выполняется по ссылке из письма (см. SignInWithMagicLink).
 //
 // Параметры:
 //   - ctx: контекст выполнения
 //   - email: email пользователя (обязательный)
-//   - password: указатель на строку с паролем (может быть nil при входе по токену)
-//   - verificationToken: указатель на строку с токеном верификации (может быть nil при входе по паролю)
+//   - clientIP: IP-адрес клиента для учета неудачных попыток (может быть пустым)
+//   - password: пароль (обязательный)
 //
 // Возвращает:
 //   - *types.PublicUser: публичные данные аутентифицированного пользователя
@@ -121,56 +196,366 @@ func (s *UsersService) SignUp(ctx context.Context, email, username string, passw
 //
 // Возможные ошибки:
 //   - ErrEmailRequired: если email не указан
-//   - ErrPasswordOrTokenReq: если не указан ни пароль, ни токен
-//   - ErrPasswordLoginNotAvailable: если у пользователя нет пароля (попытка входа по паролю)
-//   - ErrTokenLoginNotAvailable: если у пользователя нет токена (попытка входа по токену)
-//   - ErrWrongCredentials: если пароль или токен неверны
+//   - ErrPasswordRequired: если пароль не указан
+//   - ErrTooManyAttempts: если вход временно заблокирован (*LoginBlockedError)
+//   - ErrWrongCredentials: если пользователь не найден, у него нет пароля или пароль неверный
+//   - ErrSecondFactorRequired: если нужен код 2FA (*SecondFactorRequiredError с токеном для CompleteSignIn)
 //   - ошибки базы данных при поиске пользователя
-func (s *UsersService) SignIn(ctx context.Context, email string, password, verificationToken *string) (*types.PublicUser, error) {
+//
+// Безопасность:
+//   - для несуществующего пользователя выполняется такое же хеширование пароля,
+//     как для существующего, и возвращается та же ошибка ErrWrongCredentials
+//   - если хеш пароля создан с устаревшими параметрами Argon2id, после успешного
+//     входа он пересчитывается с текущими параметрами
+func (s *UsersService) SignIn(ctx context.Context, email, clientIP, password string) (_ *types.PublicUser, err error) {
+	ctx, span := tracing.Start(ctx, "UsersService.SignIn")
+	defer tracing.End(span, &err)
 	if email == "" {
 		return nil, ErrEmailRequired
 	}
+	if password == "" {
+		return nil, ErrPasswordRequired
+	}
+
+	// Попытка засчитывается до проверки пароля; успешный вход ее отменяет
+	if err := s.guard.Attempt(ctx, email, clientIP); err != nil {
+		return nil, err
+	}
 
 	existing, err := s.storage.GetByEmail(ctx, email)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return nil, fmt.Errorf("failed to find user: %w", err)
+	}
+
+	ok, needsRehash, err := s.verifyPassword(existing, password)
 	if err != nil {
-		return nil, fmt.Errorf("user not found: %w", err)
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrWrongCredentials
+	}
+
+	if needsRehash {
+		s.rehashPassword(ctx, existing.ID, password)
 	}
 
+	if err := s.requireSecondFactor(ctx, existing, email); err != nil {
+		return nil, s.secondFactorPending(ctx, email, clientIP, err)
+	}
+
+	if err := s.guard.RecordSuccess(ctx, email, clientIP); err != nil {
+		return nil, err
+	}
 	res := existing.ToPublic()
+	return &res, nil
+}
 
-	// Вход по паролю
-	if password != nil {
-		// Проверяем, что у пользователя есть пароль
-		if existing.PasswordHash == nil {
-			return nil, ErrPasswordLoginNotAvailable
+// SignInWithPhone аутентифицирует пользователя по телефону и коду из SMS (см. PhoneOTPService.RequestCode).
+// Если пользователя с таким подтвержденным телефоном нет, он регистрируется с ролью RoleCustomer.
+//
+// Параметры:
+//   - ctx: контекст выполнения
+//   - rawPhone: телефон в любом формате, который понимает phone.Normalize
+//   - clientIP: IP-адрес клиента для учета неудачных попыток (может быть пустым)
+//   - code: 6-значный код из SMS
+//
+// Возможные ошибки:
+//   - ErrPhoneSignInDisabled: если вход по телефону не настроен (нет WithPhoneOTP)
+//   - ErrInvalidPhone: если номер нельзя привести к формату E.164
+//   - ErrTooManyAttempts: если вход временно заблокирован (*LoginBlockedError)
+//   - ErrInvalidOTP: если код неверный, просрочен или уже использован
+//   - ErrSecondFactorRequired: если нужен код 2FA (*SecondFactorRequiredError с токеном для CompleteSignIn)
+func (s *UsersService) SignInWithPhone(ctx context.Context, rawPhone, clientIP, code string) (_ *types.PublicUser, err error) {
+	ctx, span := tracing.Start(ctx, "UsersService.SignInWithPhone")
+	defer tracing.End(span, &err)
+	if s.phoneOTP == nil {
+		return nil, ErrPhoneSignInDisabled
+	}
+	normalized, err := phone.Normalize(rawPhone)
+	if err != nil {
+		return nil, ErrInvalidPhone
+	}
+
+	if err := s.guard.Attempt(ctx, normalized, clientIP); err != nil {
+		return nil, err
+	}
+	if err := s.phoneOTP.VerifyCode(ctx, normalized, strings.TrimSpace(code)); err != nil {
+		return nil, err
+	}
+
+	user, err := s.storage.GetByPhone(ctx, normalized)
+	if err != nil {
+		if !errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("failed to find user: %w", err)
+		}
+		user, err = s.storage.CreateByPhone(ctx, normalized, phoneUsername(normalized), types.RoleCustomer)
+		if err != nil {
+			return nil, fmt.Errorf("failed to create user: %w", err)
 		}
+		metrics.SignUps.WithLabelValues(metrics.SignUpPhone).Inc()
+	}
+
+	if err := s.requireSecondFactor(ctx, user, normalized); err != nil {
+		return nil, s.secondFactorPending(ctx, normalized, clientIP, err)
+	}
+	if err := s.guard.RecordSuccess(ctx, normalized, clientIP); err != nil {
+		return nil, err
+	}
+	res := user.ToPublic()
+	return &res, nil
+}
 
-		check, err := comparePasswordAndHash(*password, *existing.PasswordHash)
+// SignInWithMagicLink аутентифицирует пользователя по ссылке из письма (см. MagicLinkService.Request).
+// Переход по ссылке подтверждает владение адресом, поэтому email отмечается подтвержденным.
+//
+// Параметры:
+//   - ctx: контекст выполнения
+//   - token: токен из ссылки
+//   - nonce: значение cookie браузера, в котором была запрошена ссылка
+//
+// Возможные ошибки:
+//   - ErrMagicLinkSignInDisabled: если вход по ссылке не настроен (нет WithMagicLinks)
+//   - ErrInvalidMagicLink: если ссылка неверная, просрочена, уже использована или открыта в другом браузере
+//   - ErrSecondFactorRequired: если нужен код 2FA (*SecondFactorRequiredError с токеном для CompleteSignIn)
+//
+// Примечание:
+//   - ограничения LoginGuard не проверяются: токен ссылки (256 бит) нельзя подобрать,
+//     а успешный вход сбрасывает счетчик неудачных попыток учетной записи
+func (s *UsersService) SignInWithMagicLink(ctx context.Context, token, nonce string) (_ *types.PublicUser, err error) {
+	ctx, span := tracing.Start(ctx, "UsersService.SignInWithMagicLink")
+	defer tracing.End(span, &err)
+	if s.magicLinks == nil {
+		return nil, ErrMagicLinkSignInDisabled
+	}
+	email, err := s.magicLinks.Consume(ctx, token, nonce)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.storage.GetByEmail(ctx, email)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, ErrInvalidMagicLink
+		}
+		return nil, fmt.Errorf("failed to find user: %w", err)
+	}
+	if !user.EmailVerified {
+		verified := true
+		user, err = s.storage.Update(ctx, types.UpdateUserParams{ID: user.ID, EmailVerified: &verified})
 		if err != nil {
-			return nil, fmt.Errorf("password verification failed: %w", err)
+			return nil, fmt.Errorf("failed to verify email: %w", err)
 		}
-		if !check {
-			return nil, ErrWrongCredentials
+	}
+
+	if err := s.requireSecondFactor(ctx, user, email); err != nil {
+		return nil, err
+	}
+	if err := s.guard.RecordSuccess(ctx, email, ""); err != nil {
+		return nil, err
+	}
+	res := user.ToPublic()
+	return &res, nil
+}
+
+// phoneUsername возвращает имя нового пользователя, зарегистрированного по телефону
+func phoneUsername(normalizedPhone string) string {
+	return "Покупатель " + normalizedPhone[len(normalizedPhone)-4:]
+}
+
+// CompleteSignIn завершает вход кодом из приложения-аутентификатора или кодом восстановления
+//
+// Параметры:
+//   - ctx: контекст выполнения
+//   - challenge: промежуточный токен из SecondFactorRequiredError
+//   - clientIP: IP-адрес клиента для учета неудачных попыток (может быть пустым)
+//   - code: 6-значный код из приложения или код восстановления
+//
+// Возвращает:
+//   - *types.PublicUser: публичные данные аутентифицированного пользователя
+//   - error: ошибка, если вход не удался
+//
+// Возможные ошибки:
+//   - ErrInvalidChallenge: если токен поддельный, просрочен или двухфакторная аутентификация не настроена
+//   - ErrTooManyAttempts: если вход временно заблокирован (*LoginBlockedError)
+//   - ErrInvalidTwoFactorCode: если код неверный или уже использован
+func (s *UsersService) CompleteSignIn(ctx context.Context, challenge, clientIP, code string) (_ *types.PublicUser, err error) {
+	ctx, span := tracing.Start(ctx, "UsersService.CompleteSignIn")
+	defer tracing.End(span, &err)
+	user, login, err := s.challengeUser(ctx, challenge, challengeSignIn, clientIP)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.twoFactor.Verify(ctx, user.ID, code); err != nil {
+		return nil, err
+	}
+
+	if err := s.guard.RecordSuccess(ctx, login, clientIP); err != nil {
+		return nil, err
+	}
+	res := user.ToPublic()
+	return &res, nil
+}
+
+// CompleteEnrollmentSignIn подтверждает обязательное подключение аутентификатора
+// (начатое через TwoFactorService.BeginChallengeEnrollment) и завершает вход
+//
+// Возвращает:
+//   - *types.PublicUser: публичные данные аутентифицированного пользователя
+//   - []string: коды восстановления, которые нужно показать пользователю один раз
+//   - error: ошибка, если вход не удался
+//
+// Возможные ошибки:
+//   - ErrInvalidChallenge: если токен поддельный, просрочен или выдан не для подключения
+//   - ErrTooManyAttempts: если вход временно заблокирован (*LoginBlockedError)
+//   - ErrTwoFactorNotEnrolled: если подключение не было начато
+//   - ErrInvalidTwoFactorCode: если код неверный
+func (s *UsersService) CompleteEnrollmentSignIn(ctx context.Context, challenge, clientIP, code string) (_ *types.PublicUser, _ []string, err error) {
+	ctx, span := tracing.Start(ctx, "UsersService.CompleteEnrollmentSignIn")
+	defer tracing.End(span, &err)
+	user, login, err := s.challengeUser(ctx, challenge, challengeEnroll, clientIP)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	recoveryCodes, err := s.twoFactor.ConfirmEnrollment(ctx, user.ID, code)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	if err := s.guard.RecordSuccess(ctx, login, clientIP); err != nil {
+		return nil, nil, err
+	}
+	res := user.ToPublic()
+	return &res, recoveryCodes, nil
+}
+
+// requireSecondFactor возвращает *SecondFactorRequiredError, если пользователю нужен второй шаг входа.
+// login - ключ учетной записи, под которым засчитан первый шаг; он подписывается в промежуточный токен.
+func (s *UsersService) requireSecondFactor(ctx context.Context, user *types.User, login string) error {
+	if s.twoFactor == nil {
+		return nil
+	}
+	enabled, err := s.twoFactor.Enabled(ctx, user.ID)
+	if err != nil {
+		return err
+	}
+	if enabled {
+		return &SecondFactorRequiredError{Challenge: s.twoFactor.issueChallenge(user.ID, login, challengeSignIn)}
+	}
+	if s.twoFactor.Required(user.Role) {
+		return &SecondFactorRequiredError{
+			Challenge:          s.twoFactor.issueChallenge(user.ID, login, challengeEnroll),
+			EnrollmentRequired: true,
 		}
-		return &res, nil
 	}
+	return nil
+}
 
-	// Вход по токену верификации
-	if verificationToken != nil {
-		// Проверяем, что у пользователя есть токен
-		if existing.VerificationToken == nil {
-			return nil, ErrTokenLoginNotAvailable
+// challengeUser проверяет промежуточный токен, засчитывает попытку ввода кода под ключом
+// учетной записи первого шага и возвращает пользователя и этот ключ
+func (s *UsersService) challengeUser(ctx context.Context, challenge string, purpose challengePurpose, clientIP string) (*types.User, string, error) {
+	if s.twoFactor == nil {
+		return nil, "", ErrInvalidChallenge
+	}
+	userID, login, err := s.twoFactor.parseChallenge(challenge, purpose)
+	if err != nil {
+		return nil, "", err
+	}
+	user, err := s.storage.GetByID(ctx, userID)
+	if err != nil {
+		return nil, "", fmt.Errorf("%w: %s", ErrUserNotFound, err)
+	}
+	if err := s.guard.Attempt(ctx, login, clientIP); err != nil {
+		return nil, "", err
+	}
+	return user, login, nil
+}
+
+// secondFactorPending отменяет попытку первого шага, если он пройден и нужен второй фактор.
+// Счетчик учетной записи не сбрасывается до проверки кода: неудачные коды остаются учтенными,
+// и повторный ввод пароля не позволяет перебирать коды без ограничений.
+func (s *UsersService) secondFactorPending(ctx context.Context, email, clientIP string, err error) error {
+	if errors.Is(err, ErrSecondFactorRequired) {
+		if releaseErr := s.guard.Release(ctx, email, clientIP); releaseErr != nil {
+			return releaseErr
 		}
+	}
+	return err
+}
 
-		// Сравниваем значения, а не указатели
-		if *verificationToken != *existing.VerificationToken {
-			return nil, ErrWrongCredentials
+// UnlockAccount снимает временную блокировку входа с учетной записи.
+// Предназначен для администраторов; проверка прав выполняется вызывающей стороной.
+//
+// Возможные ошибки:
+//   - ErrEmailRequired: если email не указан
+//   - ошибки хранилища счетчиков попыток
+func (s *UsersService) UnlockAccount(ctx context.Context, email string) (err error) {
+	ctx, span := tracing.Start(ctx, "UsersService.UnlockAccount")
+	defer tracing.End(span, &err)
+	if email == "" {
+		return ErrEmailRequired
+	}
+	return s.guard.UnlockAccount(ctx, email)
+}
+
+// UnlockIP снимает временную блокировку входа с IP-адреса.
+// Предназначен для администраторов; проверка прав выполняется вызывающей стороной.
+//
+// Возможные ошибки:
+//   - ErrInvalidIP: если адрес не указан или указан с ошибкой
+//   - ошибки хранилища счетчиков попыток
+func (s *UsersService) UnlockIP(ctx context.Context, ip string) (err error) {
+	ctx, span := tracing.Start(ctx, "UsersService.UnlockIP")
+	defer tracing.End(span, &err)
+	addr, err := netip.ParseAddr(ip)
+	if err != nil {
+		return ErrInvalidIP
+	}
+	return s.guard.UnlockIP(ctx, addr.String())
+}
+
+// verifyPassword проверяет пароль пользователя.
+// Если пользователь не найден или у него нет пароля, сравнение выполняется
+// с фиктивным хешем, чтобы время ответа не выдавало существование учетной записи.
+func (s *UsersService) verifyPassword(user *types.User, password string) (match, needsRehash bool, err error) {
+	encodedHash := s.dummyHash()
+	if user != nil && user.PasswordHash != nil {
+		encodedHash = *user.PasswordHash
+	}
+
+	match, needsRehash, err = comparePasswordAndHash(password, encodedHash, s.hashParams)
+	if err != nil {
+		if isPasswordValidationError(err) {
+			return false, false, nil
 		}
-		return &res, nil
+		return false, false, fmt.Errorf("password verification failed: %w", err)
+	}
+	if user == nil || user.PasswordHash == nil {
+		return false, false, nil
 	}
+	return match, needsRehash, nil
+}
 
-	// Если ни пароль, ни токен не предоставлены
-	return nil, ErrPasswordOrTokenReq
+// hashPassword проверяет пароль по политике сервиса и создает хеш с текущими параметрами
+func (s *UsersService) hashPassword(password string) (string, error) {
+	if err := s.policy.Validate(password); err != nil {
+		return "", err
+	}
+	return generateFromPassword(password, s.hashParams)
+}
+
+// rehashPassword сохраняет хеш пароля с текущими параметрами Argon2id.
+// Ошибки только логируются: вход уже выполнен, а пересчет повторится при следующем входе.
+func (s *UsersService) rehashPassword(ctx context.Context, id uuid.UUID, password string) {
+	hash, err := generateFromPassword(password, s.hashParams)
+	if err != nil {
+		slog.WarnContext(ctx, "Failed to rehash password", slog.String("user_id", id.String()), slog.String("error", err.Error()))
+		return
+	}
+	if _, err := s.storage.Update(ctx, types.UpdateUserParams{ID: id, PasswordHash: &hash}); err != nil {
+		slog.WarnContext(ctx, "Failed to store rehashed password", slog.String("user_id", id.String()), slog.String("error", err.Error()))
+	}
 }
 
 // GetByID возвращает публичные данные пользователя по ID
@@ -187,7 +572,9 @@ func (s *UsersService) SignIn(ctx context.Context, email string, password, verif
 //   - ErrUserIDRequired: если ID не указан
 //   - ErrUserNotFound: если пользователь не найден
 //   - ошибки базы данных
-func (s *UsersService) GetByID(ctx context.Context, id string) (*types.PublicUser, error) {
+func (s *UsersService) GetByID(ctx context.Context, id string) (_ *types.PublicUser, err error) {
+	ctx, span := tracing.Start(ctx, "UsersService.GetByID")
+	defer tracing.End(span, &err)
 	if id == "" {
 		return nil, ErrUserIDRequired
 	}
@@ -240,7 +627,9 @@ func (s *UsersService) GetByID(ctx context.Context, id string) (*types.PublicUse
 //	for _, user := range response.Items {
 //	    fmt.Printf("- %s (%s) - %s\n", user.Username, user.Email, user.Role)
 //	}
-func (s *UsersService) List(ctx context.Context, params types.ListUsersParams) (*database.PaginatedResponse[*types.PublicUser], error) {
+func (s *UsersService) List(ctx context.Context, params types.ListUsersParams) (_ *database.PaginatedResponse[*types.PublicUser], err error) {
+	ctx, span := tracing.Start(ctx, "UsersService.List")
+	defer tracing.End(span, &err)
 	// Валидация параметров пагинации
 	if params.Offset < 0 {
 		return nil, ErrInvalidOffset
@@ -291,7 +680,9 @@ func (s *UsersService) List(ctx context.Context, params types.ListUsersParams) (
 //   - Это "мягкое" удаление - запись помечается как удаленная, но остается в БД
 //   - Удаленный пользователь не будет виден в списках и при поиске по ID,
 //     если только в List не указан параметр IncludeDeleted = true
-func (s *UsersService) Delete(ctx context.Context, id string) error {
+func (s *UsersService) Delete(ctx context.Context, id string) (err error) {
+	ctx, span := tracing.Start(ctx, "UsersService.Delete")
+	defer tracing.End(span, &err)
 	if id == "" {
 		return ErrUserIDRequired
 	}
@@ -311,6 +702,38 @@ func (s *UsersService) Delete(ctx context.Context, id string) error {
 	return nil
 }
 
+// Restore восстанавливает мягко удаленного пользователя
+//
+// Параметры:
+//   - ctx: контекст выполнения
+//   - id: UUID удаленного пользователя
+//
+// Возможные ошибки:
+//   - ErrUserIDRequired: если ID не указан
+//   - ErrUserNotFound: если пользователь не найден или не удален
+//   - ошибки базы данных
+func (s *UsersService) Restore(ctx context.Context, id string) (err error) {
+	ctx, span := tracing.Start(ctx, "UsersService.Restore")
+	defer tracing.End(span, &err)
+	if id == "" {
+		return ErrUserIDRequired
+	}
+	parsedID, err := uuid.Parse(id)
+	if err != nil {
+		return fmt.Errorf("%w: invalid UUID format", ErrUserIDRequired)
+	}
+
+	err = s.storage.Restore(ctx, parsedID)
+	if err != nil {
+		if err.Error() == "user not found" {
+			return ErrUserNotFound
+		}
+		return fmt.Errorf("failed to restore user: %w", err)
+	}
+
+	return nil
+}
+
 // Update обновляет данные пользователя
 //
 // Параметры:
@@ -337,21 +760,18 @@ func (s *UsersService) Delete(ctx context.Context, id string) error {
 //	    ImageURL: &newImageURL,
 //	}
 //	updated, err := service.Update(ctx, params)
-func (s *UsersService) Update(ctx context.Context, params types.UpdateUserParams) (*types.PublicUser, error) {
+func (s *UsersService) Update(ctx context.Context, params types.UpdateUserParams) (_ *types.PublicUser, err error) {
+	ctx, span := tracing.Start(ctx, "UsersService.Update")
+	defer tracing.End(span, &err)
 	if params.ID == uuid.Nil {
 		return nil, ErrUserIDRequired
 	}
 
-	// Если обновляется пароль, проверяем его валидность
+	// Если обновляется пароль, проверяем его по политике и хешируем
 	if params.PasswordHash != nil {
-		if err := validatePassword(*params.PasswordHash); err != nil {
-			return nil, fmt.Errorf("invalid new password: %w", err)
-		}
-
-		// Хешируем новый пароль
-		hash, err := hashPassword(*params.PasswordHash)
+		hash, err := s.hashPassword(*params.PasswordHash)
 		if err != nil {
-			return nil, fmt.Errorf("failed to hash new password: %w", err)
+			return nil, fmt.Errorf("invalid new password: %w", err)
 		}
 		params.PasswordHash = &hash
 	}
@@ -379,7 +799,9 @@ func (s *UsersService) Update(ctx context.Context, params types.UpdateUserParams
 //   - ErrEmailRequired: если email не указан
 //   - ErrUserNotFound: если пользователь не найден
 //   - ошибки базы данных
-func (s *UsersService) GetByEmail(ctx context.Context, email string) (*types.PublicUser, error) {
+func (s *UsersService) GetByEmail(ctx context.Context, email string) (_ *types.PublicUser, err error) {
+	ctx, span := tracing.Start(ctx, "UsersService.GetByEmail")
+	defer tracing.End(span, &err)
 	if email == "" {
 		return nil, ErrEmailRequired
 	}
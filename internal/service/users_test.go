@@ -28,7 +28,6 @@ func TestUsersService_SignUp_Success(t *testing.T) {
 	password := "TestP@ssw0rd"
 	role := string(types.RoleGuest)
 	imageURL := "https://example.com/avatar.jpg"
-	verificationToken := "token123"
 	now := time.Now()
 
 	// Используем AnyArg() для всех параметров
@@ -37,22 +36,21 @@ func TestUsersService_SignUp_Success(t *testing.T) {
 		    username,
 		    password_hash,
 		    role,
-		    image_url,
-		    verification_token
+		    image_url
 		\)
-		VALUES \(@email, @username, @password_hash, @role, @image_url, @verification_token\)
+		VALUES \(@email, @username, @password_hash, @role, @image_url\)
 		RETURNING \*`).
-		WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg()).
+		WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg()).
 		WillReturnRows(pgxmock.NewRows([]string{
 			"id", "email", "email_verified", "username", "role", "image_url",
-			"password_hash", "created_at", "updated_at", "deleted_at", "verification_token",
+			"password_hash", "created_at", "updated_at", "deleted_at", "phone", "phone_verified",
 		}).AddRow(
-			userID, email, false, username, types.RoleGuest, &imageURL,
-			nil, now, now, nil, &verificationToken,
+			userID, &email, false, username, types.RoleGuest, &imageURL,
+			nil, now, now, nil, nil, false,
 		))
 
 	ctx := context.Background()
-	result, err := service.SignUp(ctx, email, username, &password, &role, &imageURL, &verificationToken)
+	result, err := service.SignUp(ctx, email, username, &password, &role, &imageURL)
 
 	require.NoError(t, err)
 	require.NotNil(t, result)
@@ -76,7 +74,7 @@ func TestUsersService_SignUp_InvalidPassword(t *testing.T) {
 	password := "weak"
 
 	ctx := context.Background()
-	result, err := service.SignUp(ctx, email, username, &password, nil, nil, nil)
+	result, err := service.SignUp(ctx, email, username, &password, nil, nil)
 
 	assert.Error(t, err)
 	assert.Nil(t, result)
@@ -98,7 +96,7 @@ func TestUsersService_SignUp_InvalidRole(t *testing.T) {
 	invalidRole := "invalid_role"
 
 	ctx := context.Background()
-	result, err := service.SignUp(ctx, email, username, &password, &invalidRole, nil, nil)
+	result, err := service.SignUp(ctx, email, username, &password, &invalidRole, nil)
 
 	assert.Error(t, err)
 	assert.Nil(t, result)
@@ -118,21 +116,21 @@ func TestUsersService_SignIn_Password_Success(t *testing.T) {
 	email := "test@example.com"
 	username := "testuser"
 	password := "TestP@ssw0rd"
-	hashedPassword, _ := hashPassword(password)
+	hashedPassword, _ := service.hashPassword(password)
 	now := time.Now()
 
 	mock.ExpectQuery(`SELECT \* FROM users WHERE email = @email AND deleted_at IS NULL`).
 		WithArgs(pgxmock.AnyArg()).
 		WillReturnRows(pgxmock.NewRows([]string{
 			"id", "email", "email_verified", "username", "role", "image_url",
-			"password_hash", "created_at", "updated_at", "deleted_at", "verification_token",
+			"password_hash", "created_at", "updated_at", "deleted_at", "phone", "phone_verified",
 		}).AddRow(
-			userID, email, false, username, types.RoleGuest, nil,
-			&hashedPassword, now, now, nil, nil,
+			userID, &email, false, username, types.RoleGuest, nil,
+			&hashedPassword, now, now, nil, nil, false,
 		))
 
 	ctx := context.Background()
-	result, err := service.SignIn(ctx, email, &password, nil)
+	result, err := service.SignIn(ctx, email, "", password)
 
 	require.NoError(t, err)
 	require.NotNil(t, result)
@@ -141,37 +139,59 @@ func TestUsersService_SignIn_Password_Success(t *testing.T) {
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
-func TestUsersService_SignIn_Token_Success(t *testing.T) {
+func TestUsersService_SignIn_RehashOutdatedPassword(t *testing.T) {
 	mock, err := pgxmock.NewPool()
 	require.NoError(t, err)
 	defer mock.Close()
 
 	storage := database.NewUsersStorage(mock)
-	service := NewUsersService(storage)
+	service := NewUsersService(storage, WithArgon2Params(Argon2Params{MemoryKB: 32 * 1024, Iterations: 2, Parallelism: 1}))
 
 	userID := uuid.New()
 	email := "test@example.com"
-	username := "testuser"
-	token := "valid-token"
+	password := "TestP@ssw0rd"
+	// Хеш создан со старыми параметрами по умолчанию
+	hashedPassword, _ := NewUsersService(nil).hashPassword(password)
 	now := time.Now()
 
+	columns := []string{
+		"id", "email", "email_verified", "username", "role", "image_url",
+		"password_hash", "created_at", "updated_at", "deleted_at", "phone", "phone_verified",
+	}
 	mock.ExpectQuery(`SELECT \* FROM users WHERE email = @email AND deleted_at IS NULL`).
 		WithArgs(pgxmock.AnyArg()).
-		WillReturnRows(pgxmock.NewRows([]string{
-			"id", "email", "email_verified", "username", "role", "image_url",
-			"password_hash", "created_at", "updated_at", "deleted_at", "verification_token",
-		}).AddRow(
-			userID, email, false, username, types.RoleGuest, nil,
-			nil, now, now, nil, &token,
+		WillReturnRows(pgxmock.NewRows(columns).AddRow(
+			userID, &email, false, "testuser", types.RoleCustomer, nil,
+			&hashedPassword, now, now, nil, nil, false,
+		))
+	mock.ExpectQuery(`UPDATE users`).
+		WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg()).
+		WillReturnRows(pgxmock.NewRows(columns).AddRow(
+			userID, &email, false, "testuser", types.RoleCustomer, nil,
+			&hashedPassword, now, now, nil, nil, false,
 		))
 
 	ctx := context.Background()
-	result, err := service.SignIn(ctx, email, nil, &token)
+	result, err := service.SignIn(ctx, email, "", password)
 
 	require.NoError(t, err)
-	require.NotNil(t, result)
-	assert.Equal(t, email, result.Email)
-	assert.Equal(t, username, result.Username)
+	assert.Equal(t, userID, result.ID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUsersService_SignUp_BreachedPassword(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	storage := database.NewUsersStorage(mock)
+	service := NewUsersService(storage)
+
+	password := "P@ssw0rd"
+	result, err := service.SignUp(context.Background(), "test@example.com", "testuser", &password, nil, nil)
+
+	assert.Nil(t, result)
+	assert.ErrorIs(t, err, ErrPasswordBreached)
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
@@ -184,7 +204,7 @@ func TestUsersService_SignIn_EmailRequired(t *testing.T) {
 	service := NewUsersService(storage)
 
 	ctx := context.Background()
-	result, err := service.SignIn(ctx, "", nil, nil)
+	result, err := service.SignIn(ctx, "", "", "")
 
 	assert.Error(t, err)
 	assert.Nil(t, result)
@@ -192,7 +212,7 @@ func TestUsersService_SignIn_EmailRequired(t *testing.T) {
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
-func TestUsersService_SignIn_NoCredentials(t *testing.T) {
+func TestUsersService_SignIn_PasswordRequired(t *testing.T) {
 	mock, err := pgxmock.NewPool()
 	require.NoError(t, err)
 	defer mock.Close()
@@ -200,23 +220,12 @@ func TestUsersService_SignIn_NoCredentials(t *testing.T) {
 	storage := database.NewUsersStorage(mock)
 	service := NewUsersService(storage)
 
-	// Добавляем мок для запроса, так как метод сначала ищет пользователя
-	mock.ExpectQuery(`SELECT \* FROM users WHERE email = @email AND deleted_at IS NULL`).
-		WithArgs(pgxmock.AnyArg()).
-		WillReturnRows(pgxmock.NewRows([]string{
-			"id", "email", "email_verified", "username", "role", "image_url",
-			"password_hash", "created_at", "updated_at", "deleted_at", "verification_token",
-		}).AddRow(
-			uuid.New(), "test@example.com", false, "testuser", types.RoleGuest, nil,
-			nil, time.Now(), time.Now(), nil, nil,
-		))
-
 	ctx := context.Background()
-	result, err := service.SignIn(ctx, "test@example.com", nil, nil)
+	result, err := service.SignIn(ctx, "test@example.com", "", "")
 
 	assert.Error(t, err)
 	assert.Nil(t, result)
-	assert.ErrorIs(t, err, ErrPasswordOrTokenReq)
+	assert.ErrorIs(t, err, ErrPasswordRequired)
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
@@ -236,11 +245,60 @@ func TestUsersService_SignIn_UserNotFound(t *testing.T) {
 		WillReturnError(pgx.ErrNoRows)
 
 	ctx := context.Background()
-	result, err := service.SignIn(ctx, email, &password, nil)
+	result, err := service.SignIn(ctx, email, "", password)
 
 	assert.Error(t, err)
 	assert.Nil(t, result)
-	assert.Contains(t, err.Error(), "user not found")
+	assert.ErrorIs(t, err, ErrWrongCredentials)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestUsersService_SignIn_WrongPassword_Lockout(t *testing.T) {
+	mock, err := pgxmock.NewPool()
+	require.NoError(t, err)
+	defer mock.Close()
+
+	policy := LockoutPolicy{MaxFailures: 2, LockoutDuration: time.Minute, ResetAfter: time.Hour}
+	guard := NewLoginGuard(NewMemoryLoginAttemptsStore(), policy, DefaultIPLockoutPolicy)
+	storage := database.NewUsersStorage(mock)
+	service := NewUsersService(storage, WithLoginGuard(guard))
+
+	email := "test@example.com"
+	hashedPassword, _ := service.hashPassword("TestP@ssw0rd")
+	wrongPassword := "WrongP@ssw0rd"
+
+	for range 2 {
+		mock.ExpectQuery(`SELECT \* FROM users WHERE email = @email AND deleted_at IS NULL`).
+			WithArgs(pgxmock.AnyArg()).
+			WillReturnRows(pgxmock.NewRows([]string{
+				"id", "email", "email_verified", "username", "role", "image_url",
+				"password_hash", "created_at", "updated_at", "deleted_at", "phone", "phone_verified",
+			}).AddRow(
+				uuid.New(), &email, false, "testuser", types.RoleGuest, nil,
+				&hashedPassword, time.Now(), time.Now(), nil, nil, false,
+			))
+	}
+
+	ctx := context.Background()
+	for range 2 {
+		_, err = service.SignIn(ctx, email, "10.0.0.1", wrongPassword)
+		assert.ErrorIs(t, err, ErrWrongCredentials)
+	}
+
+	// Третья попытка блокируется до обращения к базе данных
+	_, err = service.SignIn(ctx, email, "10.0.0.1", wrongPassword)
+	assert.ErrorIs(t, err, ErrTooManyAttempts)
+	var blocked *LoginBlockedError
+	require.ErrorAs(t, err, &blocked)
+	assert.Greater(t, blocked.RetryAfter, time.Duration(0))
+
+	// После разблокировки администратором попытка снова проверяется
+	require.NoError(t, service.UnlockAccount(ctx, email))
+	mock.ExpectQuery(`SELECT \* FROM users WHERE email = @email AND deleted_at IS NULL`).
+		WithArgs(pgxmock.AnyArg()).
+		WillReturnError(pgx.ErrNoRows)
+	_, err = service.SignIn(ctx, email, "10.0.0.1", wrongPassword)
+	assert.ErrorIs(t, err, ErrWrongCredentials)
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
@@ -261,10 +319,10 @@ func TestUsersService_GetByID_Success(t *testing.T) {
 		WithArgs(pgxmock.AnyArg()).
 		WillReturnRows(pgxmock.NewRows([]string{
 			"id", "email", "email_verified", "username", "role", "image_url",
-			"password_hash", "created_at", "updated_at", "deleted_at", "verification_token",
+			"password_hash", "created_at", "updated_at", "deleted_at", "phone", "phone_verified",
 		}).AddRow(
-			userID, email, false, username, types.RoleGuest, nil,
-			nil, now, now, nil, nil,
+			userID, &email, false, username, types.RoleGuest, nil,
+			nil, now, now, nil, nil, false,
 		))
 
 	ctx := context.Background()
@@ -311,10 +369,10 @@ func TestUsersService_GetByEmail_Success(t *testing.T) {
 		WithArgs(pgxmock.AnyArg()).
 		WillReturnRows(pgxmock.NewRows([]string{
 			"id", "email", "email_verified", "username", "role", "image_url",
-			"password_hash", "created_at", "updated_at", "deleted_at", "verification_token",
+			"password_hash", "created_at", "updated_at", "deleted_at", "phone", "phone_verified",
 		}).AddRow(
-			userID, email, false, username, types.RoleGuest, nil,
-			nil, now, now, nil, nil,
+			userID, &email, false, username, types.RoleGuest, nil,
+			nil, now, now, nil, nil, false,
 		))
 
 	ctx := context.Background()
@@ -345,17 +403,16 @@ func TestUsersService_Update_Success(t *testing.T) {
 		    role = COALESCE\(@role, role\),
 		    image_url = COALESCE\(@image_url, image_url\),
 		    email_verified = COALESCE\(@email_verified, email_verified\),
-		    verification_token = COALESCE\(@verification_token, verification_token\),
 		    password_hash = COALESCE\(@password_hash, password_hash\)
 		WHERE id = @id AND deleted_at IS NULL
 		RETURNING \*;`).
-		WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg()).
+		WithArgs(pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg(), pgxmock.AnyArg()).
 		WillReturnRows(pgxmock.NewRows([]string{
 			"id", "email", "email_verified", "username", "role", "image_url",
-			"password_hash", "created_at", "updated_at", "deleted_at", "verification_token",
+			"password_hash", "created_at", "updated_at", "deleted_at", "phone", "phone_verified",
 		}).AddRow(
-			userID, "test@example.com", false, newUsername, types.RoleGuest, nil,
-			nil, now, now, nil, nil,
+			userID, strPtr("test@example.com"), false, newUsername, types.RoleGuest, nil,
+			nil, now, now, nil, nil, false,
 		))
 
 	ctx := context.Background()
@@ -414,13 +471,13 @@ func TestUsersService_List_Success(t *testing.T) {
 		WithArgs(pgxmock.AnyArg()).
 		WillReturnRows(pgxmock.NewRows([]string{
 			"id", "email", "email_verified", "username", "role", "image_url",
-			"password_hash", "created_at", "updated_at", "deleted_at", "verification_token",
+			"password_hash", "created_at", "updated_at", "deleted_at", "phone", "phone_verified",
 		}).AddRow(
-			uuid.New(), "user1@test.com", false, "user1", types.RoleGuest, nil,
-			nil, time.Now(), time.Now(), nil, nil,
+			uuid.New(), strPtr("user1@test.com"), false, "user1", types.RoleGuest, nil,
+			nil, time.Now(), time.Now(), nil, nil, false,
 		).AddRow(
-			uuid.New(), "user2@test.com", false, "user2", types.RoleAdmin, nil,
-			nil, time.Now(), time.Now(), nil, nil,
+			uuid.New(), strPtr("user2@test.com"), false, "user2", types.RoleAdmin, nil,
+			nil, time.Now(), time.Now(), nil, nil, false,
 		))
 
 	ctx := context.Background()
@@ -502,3 +559,43 @@ func TestUsersService_Delete_NotFound(t *testing.T) {
 	assert.ErrorIs(t, err, ErrUserNotFound)
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
+
+func TestUsersService_Restore(t *testing.T) {
+	tests := []struct {
+		name     string
+		id       func(uuid.UUID) string
+		affected int64
+		noQuery  bool
+		wantErr  error
+	}{
+		{name: "удаленный пользователь восстановлен", id: uuid.UUID.String, affected: 1},
+		{name: "пользователь не найден", id: uuid.UUID.String, affected: 0, wantErr: ErrUserNotFound},
+		{name: "пустой ID", id: func(uuid.UUID) string { return "" }, noQuery: true, wantErr: ErrUserIDRequired},
+		{name: "неверный UUID", id: func(uuid.UUID) string { return "not-a-uuid" }, noQuery: true, wantErr: ErrUserIDRequired},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mock, err := pgxmock.NewPool()
+			require.NoError(t, err)
+			defer mock.Close()
+
+			service := NewUsersService(database.NewUsersStorage(mock))
+			userID := uuid.New()
+
+			if !tt.noQuery {
+				mock.ExpectExec(`UPDATE users SET deleted_at = NULL WHERE id = @id AND deleted_at IS NOT NULL;`).
+					WithArgs(userID).
+					WillReturnResult(pgxmock.NewResult("UPDATE", tt.affected))
+			}
+
+			err = service.Restore(context.Background(), tt.id(userID))
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
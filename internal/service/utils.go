@@ -7,8 +7,10 @@ import (
 	"errors"
 	"fmt"
 	"strings"
-	"unicode"
+	"sync"
+	"time"
 
+	"github.com/LigeronAhill/luxcarpets-go/internal/metrics"
 	"golang.org/x/crypto/argon2"
 )
 
@@ -58,6 +60,42 @@ var defaultParams = &params{
 	keyLength:   32,
 }
 
+// Argon2Params задает настраиваемые параметры Argon2id для новых хешей паролей.
+// Длина соли и ключа фиксированы (16 и 32 байта).
+type Argon2Params struct {
+	MemoryKB    uint32 // Объем памяти в килобайтах
+	Iterations  uint32 // Количество итераций
+	Parallelism uint8  // Степень параллелизма
+}
+
+// Validate проверяет, что все параметры положительные
+func (a Argon2Params) Validate() error {
+	if a.MemoryKB == 0 || a.Iterations == 0 || a.Parallelism == 0 {
+		return errors.New("argon2 memory, iterations and parallelism must be positive")
+	}
+	return nil
+}
+
+// toParams преобразует настройки в параметры хеширования
+func (a Argon2Params) toParams() *params {
+	return &params{
+		memory:      a.MemoryKB,
+		iterations:  a.Iterations,
+		parallelism: a.Parallelism,
+		saltLength:  defaultParams.saltLength,
+		keyLength:   defaultParams.keyLength,
+	}
+}
+
+// outdated сообщает, отличаются ли параметры сохраненного хеша от текущих
+func (p *params) outdated(current *params) bool {
+	return p.memory != current.memory ||
+		p.iterations != current.iterations ||
+		p.parallelism != current.parallelism ||
+		p.saltLength != current.saltLength ||
+		p.keyLength != current.keyLength
+}
+
 // generateRandomBytes генерирует криптостойкие случайные байты
 //
 // Параметры:
@@ -75,65 +113,19 @@ func generateRandomBytes(n uint32) ([]byte, error) {
 	return b, nil
 }
 
-// validatePassword проверяет пароль на соответствие требованиям безопасности
-//
-// Требования:
-//   - длина от 8 до 72 символов
-//   - минимум одна заглавная буква
-//   - минимум одна строчная буква
-//   - минимум одна цифра
-//   - минимум один спецсимвол
-//
-// Параметры:
-//   - password: пароль для проверки
-//
-// Возвращает:
-//   - error: nil если пароль валидный, иначе одна из ошибок валидации
-func validatePassword(password string) error {
-	if len(password) > maxPasswordLength {
-		return ErrPasswordTooLong
-	}
-	if len(password) < minPasswordLength {
-		return ErrPasswordTooShort
-	}
-
-	var (
-		hasUpper   bool
-		hasLower   bool
-		hasDigit   bool
-		hasSpecial bool
-	)
-
-	for _, char := range password {
-		switch {
-		case unicode.IsUpper(char):
-			hasUpper = true
-		case unicode.IsLower(char):
-			hasLower = true
-		case unicode.IsDigit(char):
-			hasDigit = true
-		case unicode.IsPunct(char) || unicode.IsSymbol(char):
-			hasSpecial = true
-		}
-	}
-
-	if !hasUpper {
-		return ErrPasswordNoUpper
-	}
-	if !hasLower {
-		return ErrPasswordNoLower
-	}
-	if !hasDigit {
-		return ErrPasswordNoDigit
-	}
-	if !hasSpecial {
-		return ErrPasswordNoSpecial
-	}
-
-	return nil
+// isPasswordValidationError проверяет, является ли ошибка ошибкой валидации пароля
+func isPasswordValidationError(err error) bool {
+	return errors.Is(err, ErrPasswordTooLong) ||
+		errors.Is(err, ErrPasswordTooShort) ||
+		errors.Is(err, ErrPasswordNoUpper) ||
+		errors.Is(err, ErrPasswordNoLower) ||
+		errors.Is(err, ErrPasswordNoDigit) ||
+		errors.Is(err, ErrPasswordNoSpecial) ||
+		errors.Is(err, ErrPasswordBreached)
 }
 
-// generateFromPassword создает хеш пароля с использованием Argon2id
+// generateFromPassword создает хеш пароля с использованием Argon2id.
+// Не проверяет пароль на соответствие политике - это задача вызывающего.
 //
 // Параметры:
 //   - password: пароль для хеширования
@@ -150,16 +142,14 @@ func validatePassword(password string) error {
 //   - соль: base64
 //   - хеш: base64
 func generateFromPassword(password string, p *params) (encodedHash string, err error) {
-	if err := validatePassword(password); err != nil {
-		return "", err
-	}
-
 	salt, err := generateRandomBytes(p.saltLength)
 	if err != nil {
 		return "", fmt.Errorf("failed to generate salt: %w", err)
 	}
 
+	start := time.Now()
 	hash := argon2.IDKey([]byte(password), salt, p.iterations, p.memory, p.parallelism, p.keyLength)
+	metrics.ObserveSince(metrics.PasswordHashDuration.WithLabelValues(metrics.HashOperationHash), start)
 
 	// Base64 encode the salt and hashed password.
 	b64Salt := base64.RawStdEncoding.EncodeToString(salt)
@@ -172,24 +162,21 @@ func generateFromPassword(password string, p *params) (encodedHash string, err e
 	return encodedHash, nil
 }
 
-// hashPassword создает хеш пароля с параметрами по умолчанию
-//
-// Параметры:
-//   - password: пароль для хеширования
-//
-// Возвращает:
-//   - string: закодированный хеш
-//   - error: ошибка валидации или хеширования
-//
-// Пример использования:
-//
-//	hash, err := hashPassword("MyP@ssw0rd")
-//	if err != nil {
-//	    log.Fatal(err)
-//	}
-//	fmt.Println(hash) // $argon2id$v=19$m=65536,t=3,p=2$...
-func hashPassword(password string) (string, error) {
-	return generateFromPassword(password, defaultParams)
+// newDummyPasswordHash возвращает функцию, лениво создающую хеш случайного пароля с параметрами p.
+// Хеш используется для сравнения, когда пользователь не найден, чтобы время ответа
+// не отличалось от проверки существующей учетной записи.
+func newDummyPasswordHash(p *params) func() string {
+	return sync.OnceValue(func() string {
+		password, err := generateRandomBytes(p.keyLength)
+		if err != nil {
+			panic(err)
+		}
+		hash, err := generateFromPassword(base64.RawStdEncoding.EncodeToString(password), p)
+		if err != nil {
+			panic(err)
+		}
+		return hash
+	})
 }
 
 // comparePasswordAndHash сравнивает пароль с хешем
@@ -197,45 +184,50 @@ func hashPassword(password string) (string, error) {
 // Параметры:
 //   - password: пароль для проверки
 //   - encodedHash: хеш в формате, созданном hashPassword
+//   - current: текущие параметры Argon2id, с которыми создаются новые хеши
 //
 // Возвращает:
-//   - bool: true если пароль соответствует хешу
-//   - error: ошибка валидации или декодирования хеша
+//   - match: true если пароль соответствует хешу
+//   - needsRehash: true если хеш создан с параметрами, отличными от current
+//   - err: ошибка декодирования хеша или слишком длинный пароль
 //
 // Безопасность:
 //   - использует ConstantTimeCompare для предотвращения timing-атак
-//   - валидирует пароль перед сравнением
+//   - не применяет политику паролей: она могла измениться после создания хеша,
+//     ограничивается только максимальная длина для защиты от DoS
 //
 // Пример использования:
 //
-//	match, err := comparePasswordAndHash("MyP@ssw0rd", hash)
+//	match, needsRehash, err := comparePasswordAndHash("MyP@ssw0rd", hash, defaultParams)
 //	if err != nil {
 //	    log.Fatal(err)
 //	}
-//	if match {
-//	    fmt.Println("Password is correct")
+//	if match && needsRehash {
+//	    // сохранить новый хеш с текущими параметрами
 //	}
-func comparePasswordAndHash(password, encodedHash string) (match bool, err error) {
-	if err := validatePassword(password); err != nil {
-		return false, err
+func comparePasswordAndHash(password, encodedHash string, current *params) (match, needsRehash bool, err error) {
+	if len(password) > maxPasswordInputLength {
+		return false, false, ErrPasswordTooLong
 	}
 
 	// Extract the parameters, salt and derived key from the encoded password hash.
 	p, salt, hash, err := decodeHash(encodedHash)
 	if err != nil {
-		return false, fmt.Errorf("failed to decode hash: %w", err)
+		return false, false, fmt.Errorf("failed to decode hash: %w", err)
 	}
 
 	// Derive the key from the other password using the same parameters.
+	start := time.Now()
 	otherHash := argon2.IDKey([]byte(password), salt, p.iterations, p.memory, p.parallelism, p.keyLength)
+	metrics.ObserveSince(metrics.PasswordHashDuration.WithLabelValues(metrics.HashOperationVerify), start)
 
 	// Check that the contents of the hashed passwords are identical. Note
 	// that we are using the subtle.ConstantTimeCompare() function for this
 	// to help prevent timing attacks.
 	if subtle.ConstantTimeCompare(hash, otherHash) == 1 {
-		return true, nil
+		return true, p.outdated(current), nil
 	}
-	return false, nil
+	return false, false, nil
 }
 
 // decodeHash декодирует хеш в параметры, соль и хеш
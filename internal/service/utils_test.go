@@ -8,6 +8,9 @@ import (
 	"github.com/stretchr/testify/require"
 )
 
+// defaultUsersService проверяет и хеширует пароли с политикой и параметрами Argon2id по умолчанию
+var defaultUsersService = NewUsersService(nil)
+
 func TestValidatePassword(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -83,7 +86,7 @@ func TestValidatePassword(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			err := validatePassword(tt.password)
+			err := defaultUsersService.policy.Validate(tt.password)
 			if tt.wantErr == nil {
 				assert.NoError(t, err)
 			} else {
@@ -124,7 +127,7 @@ func TestHashPassword(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			hash, err := hashPassword(tt.password)
+			hash, err := defaultUsersService.hashPassword(tt.password)
 
 			if tt.wantErr != nil {
 				assert.ErrorIs(t, err, tt.wantErr)
@@ -154,7 +157,7 @@ func TestHashPassword(t *testing.T) {
 
 func TestComparePasswordAndHash(t *testing.T) {
 	validPassword := "TestP@ssw0rd"
-	hash, err := hashPassword(validPassword)
+	hash, err := defaultUsersService.hashPassword(validPassword)
 	require.NoError(t, err)
 	require.NotEmpty(t, hash)
 
@@ -180,11 +183,18 @@ func TestComparePasswordAndHash(t *testing.T) {
 			wantErr:   nil,
 		},
 		{
-			name:      "невалидный пароль",
+			name:      "пароль не по политике сравнивается без ошибки",
 			password:  "weak",
 			hash:      hash,
 			wantMatch: false,
-			wantErr:   ErrPasswordTooShort,
+			wantErr:   nil,
+		},
+		{
+			name:      "слишком длинный пароль",
+			password:  strings.Repeat("a", maxPasswordInputLength+1),
+			hash:      hash,
+			wantMatch: false,
+			wantErr:   ErrPasswordTooLong,
 		},
 		{
 			name:      "некорректный хеш",
@@ -204,7 +214,7 @@ func TestComparePasswordAndHash(t *testing.T) {
 
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			match, err := comparePasswordAndHash(tt.password, tt.hash)
+			match, _, err := comparePasswordAndHash(tt.password, tt.hash, defaultParams)
 
 			if tt.wantErr != nil {
 				assert.ErrorIs(t, err, tt.wantErr)
@@ -263,7 +273,7 @@ func TestGenerateRandomBytes(t *testing.T) {
 func TestDecodeHash(t *testing.T) {
 	// Сначала создадим валидный хеш
 	validPassword := "TestP@ssw0rd"
-	validHash, err := hashPassword(validPassword)
+	validHash, err := defaultUsersService.hashPassword(validPassword)
 	require.NoError(t, err)
 
 	tests := []struct {
@@ -348,21 +358,21 @@ func TestHashPassword_Consistency(t *testing.T) {
 	// Один и тот же пароль должен давать разные хеши каждый раз
 	password := "TestP@ssw0rd"
 
-	hash1, err := hashPassword(password)
+	hash1, err := defaultUsersService.hashPassword(password)
 	require.NoError(t, err)
 
-	hash2, err := hashPassword(password)
+	hash2, err := defaultUsersService.hashPassword(password)
 	require.NoError(t, err)
 
 	// Хеши должны быть разными из-за разной соли
 	assert.NotEqual(t, hash1, hash2)
 
 	// Но оба должны валидироваться
-	match1, err := comparePasswordAndHash(password, hash1)
+	match1, _, err := comparePasswordAndHash(password, hash1, defaultParams)
 	require.NoError(t, err)
 	assert.True(t, match1)
 
-	match2, err := comparePasswordAndHash(password, hash2)
+	match2, _, err := comparePasswordAndHash(password, hash2, defaultParams)
 	require.NoError(t, err)
 	assert.True(t, match2)
 }
@@ -398,10 +408,10 @@ func TestHashPassword_EdgeCases(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			if tt.wantErr != nil {
-				err := validatePassword(tt.password)
+				err := defaultUsersService.policy.Validate(tt.password)
 				assert.ErrorIs(t, err, tt.wantErr)
 			} else {
-				hash, err := hashPassword(tt.password)
+				hash, err := defaultUsersService.hashPassword(tt.password)
 				assert.NoError(t, err)
 				assert.NotEmpty(t, hash)
 
@@ -419,10 +429,10 @@ func TestHashPassword_EdgeCases(t *testing.T) {
 func TestComparePasswordAndHash_TimingAttack(t *testing.T) {
 	// Этот тест проверяет, что функция сравнения не падает при неверном пароле
 	validPassword := "TestP@ssw0rd"
-	hash, err := hashPassword(validPassword)
+	hash, err := defaultUsersService.hashPassword(validPassword)
 	require.NoError(t, err)
 
-	// Используем только валидные пароли (проходящие validatePassword)
+	// Используем только валидные пароли (проходящие политику по умолчанию)
 	validButWrongPasswords := []string{
 		"TestP@ssw0rD",   // отличается только последняя буква (D вместо d)
 		"TestP@ssw0rd!",  // добавлен спецсимвол в конце
@@ -467,12 +477,42 @@ func TestComparePasswordAndHash_TimingAttack(t *testing.T) {
 	for _, wrongPass := range validButWrongPasswords {
 		t.Run(wrongPass, func(t *testing.T) {
 			// Убедимся, что пароль валидный
-			err := validatePassword(wrongPass)
+			err := defaultUsersService.policy.Validate(wrongPass)
 			require.NoError(t, err, "Тестовый пароль должен быть валидным: %s", wrongPass)
 
-			match, err := comparePasswordAndHash(wrongPass, hash)
+			match, _, err := comparePasswordAndHash(wrongPass, hash, defaultParams)
 			assert.NoError(t, err, "Для валидного пароля не должно быть ошибки")
 			assert.False(t, match, "Пароль не должен совпадать")
 		})
 	}
 }
+
+func TestComparePasswordAndHash_NeedsRehash(t *testing.T) {
+	password := "TestP@ssw0rd"
+	oldParams := &params{memory: 16 * 1024, iterations: 2, parallelism: 1, saltLength: 16, keyLength: 32}
+	oldHash, err := generateFromPassword(password, oldParams)
+	require.NoError(t, err)
+	currentHash, err := generateFromPassword(password, defaultParams)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name            string
+		password        string
+		hash            string
+		wantMatch       bool
+		wantNeedsRehash bool
+	}{
+		{"устаревшие параметры", password, oldHash, true, true},
+		{"текущие параметры", password, currentHash, true, false},
+		{"неверный пароль со старым хешем", "WrongP@ssw0rd", oldHash, false, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			match, needsRehash, err := comparePasswordAndHash(tt.password, tt.hash, defaultParams)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantMatch, match)
+			assert.Equal(t, tt.wantNeedsRehash, needsRehash)
+		})
+	}
+}
@@ -0,0 +1,118 @@
+// Пакет tracing настраивает распределенную трассировку OpenTelemetry.
+//
+// Init устанавливает глобальный TracerProvider и распространение контекста W3C Trace Context.
+// Сервер создает span на каждый HTTP-запрос (middleware.Tracing), сервисы и хранилища -
+// дочерние span на методы (Start), а pgx - на запросы к базе (database.NewPool).
+// Идентификаторы трассировки попадают в записи slog (logger.ContextHandler).
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.34.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// InstrumentationName - имя библиотеки инструментирования в span приложения
+const InstrumentationName = "github.com/LigeronAhill/luxcarpets-go"
+
+// Способы экспорта span
+const (
+	ExporterNone   = "none"   // Трассировка отключена
+	ExporterStdout = "stdout" // Span выводятся в stdout в JSON (для разработки)
+	ExporterOTLP   = "otlp"   // Span отправляются по OTLP/HTTP в коллектор
+)
+
+// Config - настройки трассировки
+type Config struct {
+	Exporter    string  // ExporterNone, ExporterStdout или ExporterOTLP
+	Endpoint    string  // Адрес коллектора OTLP/HTTP, например "http://otel-collector:4318"; пустой - из переменных OTEL_EXPORTER_OTLP_*
+	ServiceName string  // Имя сервиса в span
+	Environment string  // Окружение (production, development)
+	SampleRatio float64 // Доля записываемых трассировок от 0 до 1
+}
+
+// Init настраивает трассировку и возвращает функцию, которая отправляет оставшиеся span
+// и останавливает экспорт. Для ExporterNone глобальный TracerProvider не меняется:
+// span не записываются, а Start почти ничего не стоит.
+func Init(ctx context.Context, cfg Config) (shutdown func(context.Context) error, err error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	var exporter sdktrace.SpanExporter
+	switch cfg.Exporter {
+	case ExporterNone, "":
+		return func(context.Context) error { return nil }, nil
+	case ExporterStdout:
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	case ExporterOTLP:
+		var opts []otlptracehttp.Option
+		if cfg.Endpoint != "" {
+			opts = append(opts, otlptracehttp.WithEndpointURL(cfg.Endpoint))
+		}
+		exporter, err = otlptracehttp.New(ctx, opts...)
+	default:
+		return nil, fmt.Errorf("unknown tracing exporter: %s", cfg.Exporter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s exporter: %w", cfg.Exporter, err)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(semconv.SchemaURL,
+		semconv.ServiceName(cfg.ServiceName),
+		semconv.DeploymentEnvironmentName(cfg.Environment),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create tracing resource: %w", err)
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		// Решение о записи принимает корневой span; дочерние следуют решению вызывающего сервиса
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
+
+// Tracer возвращает трассировщик приложения из глобального TracerProvider
+func Tracer() trace.Tracer {
+	return otel.Tracer(InstrumentationName)
+}
+
+// Start создает дочерний span с именем name, например "UsersService.SignUp".
+// Завершается вызовом End.
+func Start(ctx context.Context, name string, opts ...trace.SpanStartOption) (context.Context, trace.Span) {
+	return Tracer().Start(ctx, name, opts...)
+}
+
+// End записывает ошибку *err в span и завершает его. Предназначен для defer в методах
+// с именованным результатом err:
+//
+//	ctx, span := tracing.Start(ctx, "UsersStorage.GetByID")
+//	defer tracing.End(span, &err)
+func End(span trace.Span, err *error) {
+	if err != nil && *err != nil {
+		RecordError(span, *err)
+	}
+	span.End()
+}
+
+// RecordError отмечает span как ошибочный. Отмена контекста не считается ошибкой сервиса.
+func RecordError(span trace.Span, err error) {
+	if errors.Is(err, context.Canceled) {
+		span.SetAttributes(semconv.ErrorTypeKey.String("canceled"))
+		return
+	}
+	span.RecordError(err)
+	span.SetStatus(codes.Error, err.Error())
+}
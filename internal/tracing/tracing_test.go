@@ -0,0 +1,53 @@
+package tracing
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestEnd(t *testing.T) {
+	tests := []struct {
+		name         string
+		err          error
+		expectedCode codes.Code
+		expectEvent  bool
+	}{
+		{"без ошибки", nil, codes.Unset, false},
+		{"ошибка", errors.New("connection refused"), codes.Error, true},
+		{"отмена запроса", fmt.Errorf("get user: %w", context.Canceled), codes.Unset, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recorder := tracetest.NewSpanRecorder()
+			tracer := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)).Tracer(InstrumentationName)
+
+			func() (err error) {
+				_, span := tracer.Start(context.Background(), "UsersService.GetByID")
+				defer End(span, &err)
+				return tt.err
+			}()
+
+			spans := recorder.Ended()
+			require.Len(t, spans, 1)
+			assert.Equal(t, tt.expectedCode, spans[0].Status().Code)
+			assert.Equal(t, tt.expectEvent, len(spans[0].Events()) > 0)
+		})
+	}
+}
+
+func TestInit(t *testing.T) {
+	shutdown, err := Init(context.Background(), Config{Exporter: ExporterNone})
+	require.NoError(t, err)
+	assert.NoError(t, shutdown(context.Background()))
+
+	_, err = Init(context.Background(), Config{Exporter: "jaeger"})
+	assert.Error(t, err)
+}
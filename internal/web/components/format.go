@@ -0,0 +1,43 @@
+package components
+
+import (
+	"strconv"
+	"strings"
+)
+
+// Price форматирует цену в копейках для витрины: 1299000 -> "12 990 ₽".
+// Копейки показываются, только если они есть. Разряды разделяются неразрывным пробелом.
+func Price(kopecks int64) string {
+	rubles, rest := kopecks/100, kopecks%100
+	digits := strconv.FormatInt(rubles, 10)
+	var b strings.Builder
+	for i, digit := range digits {
+		if i > 0 && (len(digits)-i)%3 == 0 {
+			b.WriteString("\u00a0")
+		}
+		b.WriteRune(digit)
+	}
+	if rest != 0 {
+		b.WriteString("," + strconv.FormatInt(rest/10, 10) + strconv.FormatInt(rest%10, 10))
+	}
+	b.WriteString("\u00a0₽")
+	return b.String()
+}
+
+// Plural выбирает форму слова для числа n: Plural(5, "товар", "товара", "товаров") -> "товаров"
+func Plural(n int, one, few, many string) string {
+	n %= 100
+	if n < 0 {
+		n = -n
+	}
+	switch {
+	case n >= 11 && n <= 14:
+		return many
+	case n%10 == 1:
+		return one
+	case n%10 >= 2 && n%10 <= 4:
+		return few
+	default:
+		return many
+	}
+}
@@ -0,0 +1,35 @@
+package components
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPrice(t *testing.T) {
+	tests := []struct {
+		name    string
+		kopecks int64
+		want    string
+	}{
+		{"ноль", 0, "0\u00a0₽"},
+		{"меньше тысячи", 99900, "999\u00a0₽"},
+		{"тысячи", 1299000, "12\u00a0990\u00a0₽"},
+		{"миллионы", 123456700, "1\u00a0234\u00a0567\u00a0₽"},
+		{"с копейками", 1299005, "12\u00a0990,05\u00a0₽"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Price(tt.kopecks))
+		})
+	}
+}
+
+func TestPlural(t *testing.T) {
+	for n, want := range map[int]string{
+		0: "товаров", 1: "товар", 2: "товара", 5: "товаров", 11: "товаров",
+		14: "товаров", 21: "товар", 22: "товара", 111: "товаров", 101: "товар",
+	} {
+		assert.Equal(t, want, Plural(n, "товар", "товара", "товаров"), n)
+	}
+}
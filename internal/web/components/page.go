@@ -0,0 +1,104 @@
+// Пакет components содержит общие templ-компоненты сайта: макет страницы, шапку, подвал
+// и flash-сообщения. Файлы *_templ.go создаются командой go tool templ generate (just templ).
+package components
+
+import (
+	"strconv"
+
+	"github.com/LigeronAhill/luxcarpets-go/internal/database/types"
+	"github.com/LigeronAhill/luxcarpets-go/internal/web/seo"
+	"github.com/LigeronAhill/luxcarpets-go/pkg/assets"
+)
+
+// SiteName - название магазина в заголовках и шапке
+const SiteName = "LuxCarpets"
+
+// DatastarAsset - клиентская библиотека Datastar среди статических файлов сайта.
+// Файл лежит в assets/js и обновляется командой just vendor-datastar. Версия зафиксирована (1.0.0-RC.6):
+// формат событий и атрибутов до выхода 1.0 может меняться, а pkg/datastar написан под эту версию.
+const DatastarAsset = "js/datastar.js"
+
+// FlashKind - вид flash-сообщения
+type FlashKind string
+
+const (
+	FlashSuccess FlashKind = "success" // Действие выполнено
+	FlashInfo    FlashKind = "info"    // Информация
+	FlashError   FlashKind = "error"   // Ошибка
+)
+
+// Flash - сообщение, которое показывается один раз на следующей странице
+type Flash struct {
+	Kind FlashKind `json:"kind"`
+	Text string    `json:"text"`
+}
+
+// Page содержит общие данные макета страницы
+type Page struct {
+	Title       string            // Заголовок страницы без названия магазина
+	Description string            // Описание для meta description
+	Path        string            // Путь текущей страницы для подсветки пункта меню
+	User        *types.PublicUser // Текущий пользователь; nil - гость
+	CartCount   int               // Количество товаров в корзине
+	Flashes     []Flash           // Сообщения для показа
+	Year        int               // Текущий год для подвала
+	Assets      *assets.Assets    // Статические файлы; nil - адреса без хеша
+	Datastar    bool              // Подключить Datastar: страница обновляет фрагменты без перезагрузки
+	Site        seo.Site          // Сведения о магазине для метаданных
+	Meta        seo.Meta          // Open Graph и Twitter Cards; заголовок и описание по умолчанию из Title и Description
+	Schema      []any             // Разметка schema.org, выводится блоками JSON-LD
+	CSRFToken   string            // Токен CSRF для форм и запросов из JavaScript (meta csrf-token)
+}
+
+// FullTitle возвращает содержимое тега title
+func (p Page) FullTitle() string {
+	if p.Title == "" {
+		return SiteName
+	}
+	return p.Title + " — " + SiteName
+}
+
+// MetaTags возвращает теги Open Graph и Twitter Cards. Без адреса сайта теги не выводятся:
+// Open Graph требует абсолютных адресов.
+func (p Page) MetaTags() []seo.Tag {
+	if p.Site.URL == "" {
+		return nil
+	}
+	m := p.Meta
+	if m.Title == "" {
+		m.Title = p.Title
+	}
+	if m.Description == "" {
+		m.Description = p.Description
+	}
+	return m.Tags(p.Site)
+}
+
+// Asset возвращает адрес статического файла с хешем в имени
+func (p Page) Asset(name string) string {
+	if p.Assets == nil {
+		return "/static/" + name
+	}
+	return p.Assets.URL(name)
+}
+
+// CartBadge возвращает текст значка корзины; больше 99 товаров показываются как "99+"
+func (p Page) CartBadge() string {
+	if p.CartCount > 99 {
+		return "99+"
+	}
+	return strconv.Itoa(p.CartCount)
+}
+
+// navItem - пункт главного меню
+type navItem struct {
+	Title string
+	Href  string
+}
+
+// mainNav - главное меню сайта
+var mainNav = []navItem{
+	{Title: "Каталог", Href: "/catalog"},
+	{Title: "О нас", Href: "/about"},
+	{Title: "Контакты", Href: "/contacts"},
+}
@@ -0,0 +1,73 @@
+package pages
+
+import (
+	"strconv"
+
+	"github.com/LigeronAhill/luxcarpets-go/internal/database/types"
+	"github.com/LigeronAhill/luxcarpets-go/internal/web/components"
+)
+
+// CatalogPath - адрес страницы каталога
+const CatalogPath = "/catalog"
+
+// Идентификаторы фрагментов каталога: по ним Datastar заменяет элементы страницы
+const (
+	CatalogResultsID = "catalog-results"
+	CatalogFacetsID  = "catalog-facets"
+)
+
+// catalogAction - действие Datastar: отправить форму фильтров GET-запросом.
+// Параметры формы становятся параметрами адреса - теми же, что без JavaScript.
+const catalogAction = "@get('" + CatalogPath + "', {contentType: 'form'})"
+
+// catalogSorts - варианты сортировки в порядке списка
+var catalogSorts = []struct {
+	Value types.CatalogSort
+	Title string
+}{
+	{types.SortNewest, "Сначала новые"},
+	{types.SortPriceAsc, "Сначала дешевле"},
+	{types.SortPriceDesc, "Сначала дороже"},
+	{types.SortName, "По названию"},
+}
+
+// pageLinkAction - действие Datastar для перехода на страницу списка.
+// Адрес содержит только экранированные параметры, поэтому кавычек в нем нет.
+func pageLinkAction(href string) string {
+	return "@get('" + href + "')"
+}
+
+// priceValue возвращает значение поля цены
+func priceValue(price *int64) string {
+	if price == nil {
+		return ""
+	}
+	return strconv.FormatInt(*price, 10)
+}
+
+// pageNumbers возвращает номера страниц для навигации: первую, последнюю и соседние с текущей.
+// Пропуски обозначаются нулем.
+func pageNumbers(current, total int) []int {
+	var pages []int
+	for page := 1; page <= total; page++ {
+		if page == 1 || page == total || (page >= current-2 && page <= current+2) {
+			pages = append(pages, page)
+		} else if len(pages) > 0 && pages[len(pages)-1] != 0 {
+			pages = append(pages, 0)
+		}
+	}
+	return pages
+}
+
+// foundText возвращает текст о количестве найденных товаров
+func foundText(total int) string {
+	return "Найдено " + strconv.Itoa(total) + " " + components.Plural(total, "товар", "товара", "товаров")
+}
+
+// coverAlt возвращает альтернативный текст главного изображения или название товара
+func coverAlt(product *types.ProductCard) string {
+	if product.CoverAltText != nil && *product.CoverAltText != "" {
+		return *product.CoverAltText
+	}
+	return product.Name
+}
@@ -0,0 +1,284 @@
+package pages
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/LigeronAhill/luxcarpets-go/internal/database/types"
+	"github.com/LigeronAhill/luxcarpets-go/internal/web/components"
+	"github.com/LigeronAhill/luxcarpets-go/internal/web/seo"
+	"github.com/LigeronAhill/luxcarpets-go/pkg/assets"
+	"github.com/a-h/templ"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Эталонные файлы обновляются командой: go test ./internal/web/pages -update
+var update = flag.Bool("update", false, "update golden files")
+
+// fixtureAssets возвращает статические файлы с фиксированными хешами
+func fixtureAssets(t *testing.T) *assets.Assets {
+	t.Helper()
+	manifest := `{"files": {
+		"css/app.css": {"name": "css/app.css", "path": "css/app.0123abcd.css"},
+		"js/app.js": {"name": "js/app.js", "path": "js/app.4567ef01.js"},
+		"js/datastar.js": {"name": "js/datastar.js", "path": "js/datastar.89ab0123.js"},
+		"img/favicon.svg": {"name": "img/favicon.svg", "path": "img/favicon.89abcdef.svg"}
+	}}`
+	a, err := assets.Load(fstest.MapFS{assets.ManifestName: {Data: []byte(manifest)}}, "/static/")
+	require.NoError(t, err)
+	return a
+}
+
+var fixtureSite = seo.Site{
+	Name:         "LuxCarpets",
+	URL:          "https://luxcarpets.ru",
+	Description:  "Магазин ковровых покрытий",
+	Phone:        "+74950000000",
+	Address:      "Москва, ул. Примерная, 1",
+	OpeningHours: "Mo-Su 10:00-20:00",
+}
+
+func fixtureUser() *types.PublicUser {
+	return &types.PublicUser{
+		ID:        uuid.MustParse("0b7e4a4e-8d8f-4b8e-9f0a-3c1d2e3f4a5b"),
+		Email:     "anna@example.com",
+		Username:  "Анна",
+		Role:      types.RoleCustomer,
+		ImageURL:  "/media/avatars/0b7e/64.jpg",
+		CreatedAt: time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+}
+
+// fixtureListing возвращает вторую страницу каталога с выбранными фильтрами
+func fixtureListing() *types.CatalogListing {
+	price := int64(1299000)
+	brand := "Osta"
+	alt := "Ковер в гостиной"
+	q := types.ParseCatalogQuery(map[string][]string{
+		"q": {"шерсть"}, "color": {"beige"}, "brand": {"Osta"}, "price_max": {"50000"}, "sort": {"price_asc"}, "page": {"2"},
+	})
+	return &types.CatalogListing{
+		Query: q,
+		Products: []*types.ProductCard{
+			{
+				ID:              uuid.MustParse("6f1c2d3e-4a5b-4c6d-8e7f-9a0b1c2d3e4f"),
+				Slug:            "osta-diamond",
+				Name:            "Osta Diamond",
+				Brand:           &brand,
+				MinPriceKopecks: &price,
+				InStock:         true,
+				CoverAltText:    &alt,
+				Cover: types.ImageSources{
+					{URL: "/media/media/ab/abcd/320.jpg", Width: 320, Height: 240, Format: "jpg"},
+					{URL: "/media/media/ab/abcd/640.jpg", Width: 640, Height: 480, Format: "jpg"},
+				},
+			},
+			{
+				ID:   uuid.MustParse("7a2b3c4d-5e6f-4a7b-8c9d-0e1f2a3b4c5d"),
+				Slug: "bez-foto",
+				Name: "Ковролин <без фото>",
+			},
+		},
+		Total: 130,
+		Page:  2,
+		Pages: 6,
+		Facets: types.CatalogFacets{
+			Categories: []types.FacetValue{{Value: "kovry", Label: "Ковры", Count: 90}, {Value: "kovrolin", Label: "Ковролин", Count: 40}},
+			Colors:     []types.FacetValue{{Value: "beige", Label: "Бежевый", Count: 70, Selected: true}, {Value: "gray", Label: "Серый", Count: 12}},
+			Brands:     []types.FacetValue{{Value: "Merinos", Label: "Merinos", Count: 5}, {Value: "Osta", Label: "Osta", Count: 130, Selected: true}},
+		},
+	}
+}
+
+// fixtureProduct возвращает товар с категорией, вариантами и галереей
+func fixtureProduct() (*types.ProductDetails, []*types.GalleryImage) {
+	brand := "Osta"
+	material := "Шерсть"
+	color := types.ColorBeige
+	description := "Плотный шерстяной ковер <ручной работы>."
+	width, length := 200, 300
+	product := &types.Product{
+		ID:          uuid.MustParse("6f1c2d3e-4a5b-4c6d-8e7f-9a0b1c2d3e4f"),
+		Slug:        "osta-diamond",
+		Name:        "Osta Diamond",
+		Description: &description,
+		Brand:       &brand,
+		Material:    &material,
+		ColorFamily: &color,
+		IsActive:    true,
+	}
+	details := &types.ProductDetails{
+		Product:  product,
+		Category: &types.Category{Slug: "kovry", Name: "Ковры"},
+		Variants: []*types.ProductVariant{
+			{SKU: "OD-200", Name: "200x300 см", WidthCm: &width, LengthCm: &length, Unit: types.UnitPiece, PriceKopecks: 4599000, Stock: 2},
+			{SKU: "OD-R4", Name: "Рулон 4 м", Unit: types.UnitSqm, PriceKopecks: 189050},
+		},
+	}
+	images := []*types.GalleryImage{
+		{
+			ProductMedia:  types.ProductMedia{AltText: "Ковер в гостиной"},
+			DominantColor: "#c8b496",
+			Sources: types.ImageSources{
+				{URL: "/media/media/ab/abcd/320.jpg", Width: 320, Height: 240, Format: "jpg"},
+				{URL: "/media/media/ab/abcd/640.jpg", Width: 640, Height: 480, Format: "jpg"},
+			},
+		},
+		{
+			DominantColor: "#d6bf9c",
+			Sources:       types.ImageSources{{URL: "/media/media/cd/cdef/320.jpg", Width: 320, Height: 320, Format: "jpg"}},
+		},
+	}
+	return details, images
+}
+
+func TestPages_Golden(t *testing.T) {
+	a := fixtureAssets(t)
+	guest := components.Page{Path: "/", Year: 2025, Assets: a}
+
+	tests := []struct {
+		name      string
+		component func(p components.Page) templ.Component
+		page      components.Page
+	}{
+		{
+			name:      "home_guest",
+			component: Home,
+			page:      guest,
+		},
+		{
+			name:      "home_user",
+			component: Home,
+			page: components.Page{
+				Description: "Ковролин & ковры <с доставкой>",
+				Path:        "/",
+				User:        fixtureUser(),
+				CartCount:   120,
+				Flashes: []components.Flash{
+					{Kind: components.FlashSuccess, Text: "Вы вошли в аккаунт"},
+					{Kind: components.FlashError, Text: "<script>alert(1)</script>"},
+				},
+				Year:      2025,
+				Assets:    a,
+				Site:      fixtureSite,
+				Meta:      seo.Meta{URL: "https://luxcarpets.ru/"},
+				Schema:    []any{seo.NewOrganization(fixtureSite), seo.NewWebSite(fixtureSite), seo.NewLocalBusiness(fixtureSite)},
+				CSRFToken: "csrf-token",
+			},
+		},
+		{
+			name:      "not_found",
+			component: NotFound,
+			page:      components.Page{Title: "Страница не найдена", Path: "/catalog", Year: 2025, Assets: a},
+		},
+		{
+			name:      "server_error",
+			component: ServerError,
+			page:      components.Page{Title: "Ошибка сервера", Path: "/", User: fixtureUser(), CartCount: 3, Year: 2025, Assets: a},
+		},
+		{
+			name: "forbidden",
+			component: func(p components.Page) templ.Component {
+				return Error(p, 403, "Недостаточно прав")
+			},
+			page: components.Page{Title: "Ошибка", Path: "/", Year: 2025},
+		},
+		{
+			name: "catalog",
+			component: func(p components.Page) templ.Component {
+				return Catalog(p, fixtureListing())
+			},
+			page: components.Page{
+				Title:    "Каталог",
+				Path:     "/catalog",
+				Year:     2025,
+				Assets:   a,
+				Datastar: true,
+				Site:     fixtureSite,
+				Meta:     seo.Meta{URL: "https://luxcarpets.ru/catalog"},
+				Schema: []any{seo.NewBreadcrumbList(fixtureSite,
+					seo.Crumb{Name: "Главная", Path: "/"},
+					seo.Crumb{Name: "Каталог", Path: "/catalog"},
+				)},
+			},
+		},
+		{
+			name: "catalog_empty",
+			component: func(p components.Page) templ.Component {
+				return Catalog(p, &types.CatalogListing{Query: types.CatalogQuery{Search: "ничего", Sort: types.SortNewest, Page: 1}, Page: 1, Pages: 1})
+			},
+			page: components.Page{Title: "Каталог", Path: "/catalog", Year: 2025, Assets: a, Datastar: true},
+		},
+		{
+			name: "product",
+			component: func(p components.Page) templ.Component {
+				details, images := fixtureProduct()
+				return Product(p, details, images)
+			},
+			page: func() components.Page {
+				details, images := fixtureProduct()
+				return components.Page{
+					Title:  "Osta Diamond",
+					Path:   "/product/osta-diamond",
+					Year:   2025,
+					Assets: a,
+					Site:   fixtureSite,
+					Meta:   seo.ProductMeta(fixtureSite, details.Product, details.Variants, images),
+					Schema: []any{seo.NewProduct(fixtureSite, details.Product, details.Category, details.Variants, images)},
+				}
+			}(),
+		},
+		{
+			name: "product_without_variants",
+			component: func(p components.Page) templ.Component {
+				return Product(p, &types.ProductDetails{Product: &types.Product{Slug: "bez-foto", Name: "Ковролин <без фото>"}}, nil)
+			},
+			page: components.Page{Title: "Ковролин <без фото>", Path: "/product/bez-foto", Year: 2025, Assets: a},
+		},
+		{
+			name:      "about",
+			component: About,
+			page:      components.Page{Title: "О нас", Path: "/about", Year: 2025, Assets: a, Site: fixtureSite},
+		},
+		{
+			name:      "contacts",
+			component: Contacts,
+			page: components.Page{
+				Title:  "Контакты",
+				Path:   "/contacts",
+				Year:   2025,
+				Assets: a,
+				Site:   seo.Site{Phone: "+74950000000", Email: "info@luxcarpets.ru", Address: "Москва, ул. Примерная, 1"},
+			},
+		},
+		{
+			// Фрагмент, который отправляется в ответ на запрос Datastar
+			name: "catalog_results",
+			component: func(components.Page) templ.Component {
+				return CatalogResults(fixtureListing())
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			require.NoError(t, tt.component(tt.page).Render(context.Background(), &buf))
+
+			golden := filepath.Join("testdata", tt.name+".golden.html")
+			if *update {
+				require.NoError(t, os.WriteFile(golden, buf.Bytes(), 0o644))
+			}
+			want, err := os.ReadFile(golden)
+			require.NoError(t, err)
+			assert.Equal(t, string(want), buf.String())
+		})
+	}
+}
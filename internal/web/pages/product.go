@@ -0,0 +1,44 @@
+package pages
+
+import (
+	"github.com/LigeronAhill/luxcarpets-go/internal/database/types"
+	"github.com/LigeronAhill/luxcarpets-go/internal/web/components"
+)
+
+// ProductPath - маршрут страницы товара; адрес конкретного товара - "/product/" + slug
+const ProductPath = "/product/:slug"
+
+// Адреса информационных страниц
+const (
+	AboutPath    = "/about"
+	ContactsPath = "/contacts"
+)
+
+// unitTitles - подписи единиц продажи к цене
+var unitTitles = map[types.VariantUnit]string{
+	types.UnitPiece:   "шт.",
+	types.UnitSqm:     "м²",
+	types.UnitLinearM: "пог. м",
+}
+
+// variantPrice возвращает цену варианта с единицей продажи: "1 890,50 ₽ / м²"
+func variantPrice(v *types.ProductVariant) string {
+	price := components.Price(v.PriceKopecks)
+	if unit, ok := unitTitles[v.Unit]; ok {
+		return price + " / " + unit
+	}
+	return price
+}
+
+// imageAlt возвращает альтернативный текст изображения галереи или название товара
+func imageAlt(image *types.GalleryImage, product *types.Product) string {
+	if image.AltText != "" {
+		return image.AltText
+	}
+	return product.Name
+}
+
+// categoryURL возвращает адрес каталога, отфильтрованного по категории
+func categoryURL(category *types.Category) string {
+	return types.CatalogQuery{Category: category.Slug}.URL(CatalogPath)
+}
@@ -0,0 +1,334 @@
+package seo
+
+import (
+	"strconv"
+
+	"github.com/LigeronAhill/luxcarpets-go/internal/database/types"
+)
+
+// Context - значение @context разметки JSON-LD
+const Context = "https://schema.org"
+
+// Currency - валюта цен (ISO 4217)
+const Currency = "RUB"
+
+// Наличие товара
+const (
+	InStock    = "https://schema.org/InStock"
+	OutOfStock = "https://schema.org/OutOfStock"
+)
+
+// NewCondition - состояние товара: новый
+const NewCondition = "https://schema.org/NewCondition"
+
+// unitCodes - коды единиц UN/CEFACT для цены не за штуку
+var unitCodes = map[types.VariantUnit]string{
+	types.UnitSqm:     "MTK", // Квадратный метр
+	types.UnitLinearM: "MTR", // Метр
+}
+
+// Price форматирует цену в копейках для schema.org и Open Graph: точка как разделитель, два знака
+func Price(kopecks int64) string {
+	rest := kopecks % 100
+	return strconv.FormatInt(kopecks/100, 10) + "." + strconv.FormatInt(rest/10, 10) + strconv.FormatInt(rest%10, 10)
+}
+
+// Product - товар (https://schema.org/Product)
+type Product struct {
+	Context     string   `json:"@context"`
+	Type        string   `json:"@type"`
+	Name        string   `json:"name"`
+	Description string   `json:"description,omitempty"`
+	URL         string   `json:"url"`
+	Image       []string `json:"image,omitempty"`
+	Brand       *Brand   `json:"brand,omitempty"`
+	Material    string   `json:"material,omitempty"`
+	Color       string   `json:"color,omitempty"`
+	Category    string   `json:"category,omitempty"`
+	Offers      any      `json:"offers,omitempty"` // *Offer для одного варианта, *AggregateOffer для нескольких
+}
+
+// Brand - производитель (https://schema.org/Brand)
+type Brand struct {
+	Type string `json:"@type"`
+	Name string `json:"name"`
+}
+
+// Offer - предложение варианта товара (https://schema.org/Offer)
+type Offer struct {
+	Type               string                  `json:"@type"`
+	SKU                string                  `json:"sku,omitempty"`
+	Name               string                  `json:"name,omitempty"`
+	URL                string                  `json:"url"`
+	Price              string                  `json:"price"`
+	PriceCurrency      string                  `json:"priceCurrency"`
+	PriceSpecification *UnitPriceSpecification `json:"priceSpecification,omitempty"`
+	Availability       string                  `json:"availability"`
+	ItemCondition      string                  `json:"itemCondition"`
+}
+
+// UnitPriceSpecification - цена за единицу измерения (https://schema.org/UnitPriceSpecification)
+type UnitPriceSpecification struct {
+	Type          string `json:"@type"`
+	Price         string `json:"price"`
+	PriceCurrency string `json:"priceCurrency"`
+	UnitCode      string `json:"unitCode"`
+}
+
+// AggregateOffer - диапазон цен нескольких вариантов (https://schema.org/AggregateOffer)
+type AggregateOffer struct {
+	Type          string   `json:"@type"`
+	LowPrice      string   `json:"lowPrice"`
+	HighPrice     string   `json:"highPrice"`
+	PriceCurrency string   `json:"priceCurrency"`
+	OfferCount    int      `json:"offerCount"`
+	Availability  string   `json:"availability"`
+	Offers        []*Offer `json:"offers"`
+}
+
+// NewProduct возвращает разметку товара. Предложения строятся по неудаленным вариантам:
+// цена в рублях, наличие - по остатку. Товар без вариантов размечается без offers.
+func NewProduct(site Site, p *types.Product, category *types.Category, variants []*types.ProductVariant, images []*types.GalleryImage) *Product {
+	product := &Product{
+		Context: Context,
+		Type:    "Product",
+		Name:    p.Name,
+		URL:     site.ProductURL(p),
+	}
+	if p.Description != nil {
+		product.Description = *p.Description
+	}
+	if p.Brand != nil && *p.Brand != "" {
+		product.Brand = &Brand{Type: "Brand", Name: *p.Brand}
+	}
+	if p.Material != nil {
+		product.Material = *p.Material
+	}
+	if p.ColorFamily != nil {
+		product.Color = p.ColorFamily.Label()
+	}
+	if category != nil {
+		product.Category = category.Name
+	}
+	for _, image := range images {
+		if src := image.Src(); src != "" {
+			product.Image = append(product.Image, site.Absolute(src))
+		}
+	}
+
+	var offers []*Offer
+	var low, high int64
+	inStock := false
+	for _, v := range variants {
+		if v.DeletedAt != nil {
+			continue
+		}
+		offers = append(offers, newOffer(product.URL, v))
+		if len(offers) == 1 || v.PriceKopecks < low {
+			low = v.PriceKopecks
+		}
+		if len(offers) == 1 || v.PriceKopecks > high {
+			high = v.PriceKopecks
+		}
+		inStock = inStock || v.InStock()
+	}
+	switch len(offers) {
+	case 0:
+	case 1:
+		product.Offers = offers[0]
+	default:
+		product.Offers = &AggregateOffer{
+			Type:          "AggregateOffer",
+			LowPrice:      Price(low),
+			HighPrice:     Price(high),
+			PriceCurrency: Currency,
+			OfferCount:    len(offers),
+			Availability:  availability(inStock),
+			Offers:        offers,
+		}
+	}
+	return product
+}
+
+// newOffer возвращает предложение варианта. Для ковролина и плитки цена указывается за квадратный метр.
+func newOffer(url string, v *types.ProductVariant) *Offer {
+	offer := &Offer{
+		Type:          "Offer",
+		SKU:           v.SKU,
+		Name:          v.Name,
+		URL:           url,
+		Price:         Price(v.PriceKopecks),
+		PriceCurrency: Currency,
+		Availability:  availability(v.InStock()),
+		ItemCondition: NewCondition,
+	}
+	if code, ok := unitCodes[v.Unit]; ok {
+		offer.PriceSpecification = &UnitPriceSpecification{
+			Type:          "UnitPriceSpecification",
+			Price:         offer.Price,
+			PriceCurrency: Currency,
+			UnitCode:      code,
+		}
+	}
+	return offer
+}
+
+func availability(inStock bool) string {
+	if inStock {
+		return InStock
+	}
+	return OutOfStock
+}
+
+// Crumb - пункт навигационной цепочки
+type Crumb struct {
+	Name string // Название
+	Path string // Адрес от корня сайта; у текущей страницы может быть пустым
+}
+
+// BreadcrumbList - навигационная цепочка (https://schema.org/BreadcrumbList)
+type BreadcrumbList struct {
+	Context         string      `json:"@context"`
+	Type            string      `json:"@type"`
+	ItemListElement []*ListItem `json:"itemListElement"`
+}
+
+// ListItem - пункт цепочки (https://schema.org/ListItem)
+type ListItem struct {
+	Type     string `json:"@type"`
+	Position int    `json:"position"`
+	Name     string `json:"name"`
+	Item     string `json:"item,omitempty"`
+}
+
+// NewBreadcrumbList возвращает навигационную цепочку. Позиции нумеруются с 1.
+func NewBreadcrumbList(site Site, crumbs ...Crumb) *BreadcrumbList {
+	list := &BreadcrumbList{
+		Context:         Context,
+		Type:            "BreadcrumbList",
+		ItemListElement: make([]*ListItem, 0, len(crumbs)),
+	}
+	for i, crumb := range crumbs {
+		item := &ListItem{Type: "ListItem", Position: i + 1, Name: crumb.Name}
+		if crumb.Path != "" {
+			item.Item = site.Absolute(crumb.Path)
+		}
+		list.ItemListElement = append(list.ItemListElement, item)
+	}
+	return list
+}
+
+// Organization - магазин как организация (https://schema.org/Organization)
+type Organization struct {
+	Context     string `json:"@context"`
+	Type        string `json:"@type"`
+	Name        string `json:"name"`
+	URL         string `json:"url"`
+	Logo        string `json:"logo,omitempty"`
+	Description string `json:"description,omitempty"`
+	Email       string `json:"email,omitempty"`
+	Telephone   string `json:"telephone,omitempty"`
+}
+
+// NewOrganization возвращает разметку организации
+func NewOrganization(site Site) *Organization {
+	org := &Organization{
+		Context:     Context,
+		Type:        "Organization",
+		Name:        site.Name,
+		URL:         site.Absolute("/"),
+		Description: site.Description,
+		Email:       site.Email,
+		Telephone:   site.Phone,
+	}
+	if site.Logo != "" {
+		org.Logo = site.Absolute(site.Logo)
+	}
+	return org
+}
+
+// LocalBusiness - шоурум магазина (https://schema.org/LocalBusiness)
+type LocalBusiness struct {
+	Context      string         `json:"@context"`
+	Type         string         `json:"@type"`
+	Name         string         `json:"name"`
+	URL          string         `json:"url"`
+	Image        string         `json:"image,omitempty"`
+	Description  string         `json:"description,omitempty"`
+	Telephone    string         `json:"telephone,omitempty"`
+	Email        string         `json:"email,omitempty"`
+	Address      *PostalAddress `json:"address,omitempty"`
+	OpeningHours string         `json:"openingHours,omitempty"`
+	Currencies   string         `json:"currenciesAccepted"`
+}
+
+// PostalAddress - адрес (https://schema.org/PostalAddress)
+type PostalAddress struct {
+	Type           string `json:"@type"`
+	StreetAddress  string `json:"streetAddress"`
+	AddressCountry string `json:"addressCountry"`
+}
+
+// NewLocalBusiness возвращает разметку шоурума. Адрес задается одной строкой в настройках.
+func NewLocalBusiness(site Site) *LocalBusiness {
+	business := &LocalBusiness{
+		Context:      Context,
+		Type:         "LocalBusiness",
+		Name:         site.Name,
+		URL:          site.Absolute("/"),
+		Description:  site.Description,
+		Telephone:    site.Phone,
+		Email:        site.Email,
+		OpeningHours: site.OpeningHours,
+		Currencies:   Currency,
+	}
+	if site.Logo != "" {
+		business.Image = site.Absolute(site.Logo)
+	}
+	if site.Address != "" {
+		business.Address = &PostalAddress{Type: "PostalAddress", StreetAddress: site.Address, AddressCountry: "RU"}
+	}
+	return business
+}
+
+// WebSite - сайт с поиском по каталогу (https://schema.org/WebSite)
+type WebSite struct {
+	Context         string        `json:"@context"`
+	Type            string        `json:"@type"`
+	Name            string        `json:"name"`
+	URL             string        `json:"url"`
+	InLanguage      string        `json:"inLanguage"`
+	PotentialAction *SearchAction `json:"potentialAction"`
+}
+
+// SearchAction - поиск по сайту (https://schema.org/SearchAction)
+type SearchAction struct {
+	Type       string      `json:"@type"`
+	Target     *EntryPoint `json:"target"`
+	QueryInput string      `json:"query-input"`
+}
+
+// EntryPoint - шаблон адреса поиска (https://schema.org/EntryPoint)
+type EntryPoint struct {
+	Type        string `json:"@type"`
+	URLTemplate string `json:"urlTemplate"`
+}
+
+// NewWebSite возвращает разметку сайта. Поиск ведет в каталог с параметром q.
+func NewWebSite(site Site) *WebSite {
+	return &WebSite{
+		Context:    Context,
+		Type:       "WebSite",
+		Name:       site.Name,
+		URL:        site.Absolute("/"),
+		InLanguage: "ru-RU",
+		PotentialAction: &SearchAction{
+			Type: "SearchAction",
+			Target: &EntryPoint{
+				Type:        "EntryPoint",
+				URLTemplate: site.Absolute("/catalog?q={search_term_string}"),
+			},
+			QueryInput: "required name=search_term_string",
+		},
+	}
+}
@@ -0,0 +1,166 @@
+// Пакет seo формирует метаданные страниц для поисковых систем и соцсетей:
+// теги Open Graph и Twitter Cards и структурированные данные schema.org в формате JSON-LD.
+//
+// Данные строятся из типов каталога (types.Product, types.ProductVariant, types.GalleryImage)
+// и сведений о магазине Site. Шаблоны выводят результат компонентом components.Layout.
+package seo
+
+import (
+	"strconv"
+	"strings"
+
+	"github.com/LigeronAhill/luxcarpets-go/internal/database/types"
+)
+
+// Site - сведения о магазине для метаданных
+type Site struct {
+	Name         string // Название магазина
+	URL          string // Публичный адрес сайта без завершающего слеша ("https://luxcarpets.ru")
+	Description  string // Краткое описание
+	Logo         string // Адрес логотипа (абсолютный или от корня сайта)
+	Phone        string // Телефон в международном формате
+	Email        string // Адрес электронной почты
+	Address      string // Адрес шоурума
+	OpeningHours string // Часы работы в формате schema.org ("Mo-Su 10:00-20:00")
+}
+
+// Absolute возвращает абсолютный адрес: пути от корня сайта дополняются адресом сайта
+func (s Site) Absolute(link string) string {
+	if strings.HasPrefix(link, "/") && !strings.HasPrefix(link, "//") {
+		return strings.TrimSuffix(s.URL, "/") + link
+	}
+	return link
+}
+
+// ProductURL возвращает адрес страницы товара
+func (s Site) ProductURL(p *types.Product) string {
+	return s.Absolute("/product/" + p.Slug)
+}
+
+// Тип объекта Open Graph
+const (
+	OGWebsite = "website"
+	OGProduct = "product"
+)
+
+// Meta - данные для тегов Open Graph и Twitter Cards
+type Meta struct {
+	Type        string // og:type: OGWebsite или OGProduct; пустой - OGWebsite
+	Title       string // Заголовок без названия магазина
+	Description string // Описание
+	URL         string // Канонический абсолютный адрес страницы
+	Image       string // Абсолютный адрес изображения
+	ImageAlt    string // Альтернативный текст изображения
+	ImageWidth  int    // Ширина изображения в пикселях
+	ImageHeight int    // Высота изображения в пикселях
+
+	// Для товара (OGProduct)
+	PriceKopecks *int64 // Минимальная цена
+	InStock      bool   // Есть ли в наличии
+}
+
+// Tag - тег meta. Open Graph использует атрибут property, Twitter - name.
+type Tag struct {
+	Property string
+	Name     string
+	Content  string
+}
+
+// Tags возвращает теги meta в порядке вывода. Пустые значения пропускаются.
+func (m Meta) Tags(site Site) []Tag {
+	ogType := m.Type
+	if ogType == "" {
+		ogType = OGWebsite
+	}
+	title := m.Title
+	if title == "" {
+		title = site.Name
+	}
+	card := "summary"
+	if m.Image != "" {
+		card = "summary_large_image"
+	}
+
+	var tags []Tag
+	property := func(name, content string) {
+		if content != "" {
+			tags = append(tags, Tag{Property: name, Content: content})
+		}
+	}
+	name := func(name, content string) {
+		if content != "" {
+			tags = append(tags, Tag{Name: name, Content: content})
+		}
+	}
+
+	property("og:type", ogType)
+	property("og:site_name", site.Name)
+	property("og:locale", "ru_RU")
+	property("og:title", title)
+	property("og:description", m.Description)
+	property("og:url", m.URL)
+	property("og:image", m.Image)
+	property("og:image:alt", m.ImageAlt)
+	if m.Image != "" && m.ImageWidth > 0 && m.ImageHeight > 0 {
+		property("og:image:width", strconv.Itoa(m.ImageWidth))
+		property("og:image:height", strconv.Itoa(m.ImageHeight))
+	}
+	if ogType == OGProduct && m.PriceKopecks != nil {
+		property("product:price:amount", Price(*m.PriceKopecks))
+		property("product:price:currency", Currency)
+		property("product:availability", map[bool]string{true: "in stock", false: "out of stock"}[m.InStock])
+	}
+	name("twitter:card", card)
+	name("twitter:title", title)
+	name("twitter:description", m.Description)
+	name("twitter:image", m.Image)
+	name("twitter:image:alt", m.ImageAlt)
+	return tags
+}
+
+// ProductMeta возвращает метаданные страницы товара. Изображение - главное в галерее,
+// цена - минимальная среди вариантов.
+func ProductMeta(site Site, p *types.Product, variants []*types.ProductVariant, images []*types.GalleryImage) Meta {
+	m := Meta{
+		Type:  OGProduct,
+		Title: p.Name,
+		URL:   site.ProductURL(p),
+	}
+	if p.Description != nil {
+		m.Description = truncate(*p.Description, 200)
+	}
+	if len(images) > 0 {
+		cover := images[0]
+		m.Image = site.Absolute(cover.Src())
+		m.ImageAlt = cover.AltText
+		if len(cover.Sources) > 0 {
+			largest := cover.Sources[len(cover.Sources)-1]
+			m.ImageWidth, m.ImageHeight = largest.Width, largest.Height
+		}
+	}
+	for _, v := range variants {
+		if v.DeletedAt != nil {
+			continue
+		}
+		if m.PriceKopecks == nil || v.PriceKopecks < *m.PriceKopecks {
+			price := v.PriceKopecks
+			m.PriceKopecks = &price
+		}
+		m.InStock = m.InStock || v.InStock()
+	}
+	return m
+}
+
+// truncate обрезает текст до limit символов по границе слова
+func truncate(s string, limit int) string {
+	s = strings.Join(strings.Fields(s), " ")
+	runes := []rune(s)
+	if len(runes) <= limit {
+		return s
+	}
+	cut := string(runes[:limit])
+	if i := strings.LastIndex(cut, " "); i > 0 {
+		cut = cut[:i]
+	}
+	return strings.TrimRight(cut, ",.;:- ") + "…"
+}
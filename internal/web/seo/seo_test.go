@@ -0,0 +1,265 @@
+package seo
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/LigeronAhill/luxcarpets-go/internal/database/types"
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var testSite = Site{
+	Name:         "LuxCarpets",
+	URL:          "https://luxcarpets.ru",
+	Description:  "Магазин ковровых покрытий",
+	Logo:         "/static/img/favicon.svg",
+	Phone:        "+74950000000",
+	Email:        "shop@luxcarpets.ru",
+	Address:      "Москва, ул. Примерная, 1",
+	OpeningHours: "Mo-Su 10:00-20:00",
+}
+
+// requiredProperties - обязательные свойства типов schema.org по требованиям
+// расширенных результатов поиска (Google, Яндекс)
+var requiredProperties = map[string][]string{
+	"Product":                {"name", "url", "offers"},
+	"Offer":                  {"price", "priceCurrency", "availability", "url"},
+	"AggregateOffer":         {"lowPrice", "highPrice", "priceCurrency", "offerCount", "offers"},
+	"UnitPriceSpecification": {"price", "priceCurrency", "unitCode"},
+	"Brand":                  {"name"},
+	"BreadcrumbList":         {"itemListElement"},
+	"ListItem":               {"position", "name"},
+	"Organization":           {"name", "url"},
+	"LocalBusiness":          {"name", "url", "address", "telephone"},
+	"PostalAddress":          {"streetAddress", "addressCountry"},
+	"WebSite":                {"name", "url", "potentialAction"},
+	"SearchAction":           {"target", "query-input"},
+	"EntryPoint":             {"urlTemplate"},
+}
+
+// validateSchema проверяет разметку: корень содержит @context, у каждого вложенного объекта
+// есть @type из requiredProperties и все обязательные свойства этого типа
+func validateSchema(t *testing.T, v any) map[string]any {
+	t.Helper()
+	raw, err := json.Marshal(v)
+	require.NoError(t, err)
+	var root map[string]any
+	require.NoError(t, json.Unmarshal(raw, &root))
+	assert.Equal(t, Context, root["@context"])
+
+	var walk func(path string, node any)
+	walk = func(path string, node any) {
+		switch node := node.(type) {
+		case map[string]any:
+			typ, ok := node["@type"].(string)
+			if !assert.True(t, ok, "%s: no @type", path) {
+				return
+			}
+			required, known := requiredProperties[typ]
+			assert.True(t, known, "%s: unexpected type %s", path, typ)
+			for _, prop := range required {
+				assert.Contains(t, node, prop, "%s (%s): missing %s", path, typ, prop)
+			}
+			for key, child := range node {
+				walk(path+"."+key, child)
+			}
+		case []any:
+			for i, child := range node {
+				walk(fmt.Sprintf("%s[%d]", path, i), child)
+			}
+		}
+	}
+	walk("$", root)
+	return root
+}
+
+func fixtureProduct() (*types.Product, *types.Category, []*types.GalleryImage) {
+	brand := "Osta"
+	material := "Шерсть"
+	color := types.ColorBeige
+	description := "Шерстяной ковер  ручной работы."
+	product := &types.Product{
+		ID:          uuid.MustParse("6f1c2d3e-4a5b-4c6d-8e7f-9a0b1c2d3e4f"),
+		Slug:        "osta-diamond",
+		Name:        "Osta Diamond",
+		Description: &description,
+		Brand:       &brand,
+		Material:    &material,
+		ColorFamily: &color,
+		IsActive:    true,
+	}
+	category := &types.Category{Slug: "kovry", Name: "Ковры"}
+	images := []*types.GalleryImage{{
+		ProductMedia: types.ProductMedia{AltText: "Ковер в гостиной"},
+		Sources: types.ImageSources{
+			{URL: "/media/media/ab/abcd/640.jpg", Width: 640, Height: 480},
+			{URL: "/media/media/ab/abcd/1920.jpg", Width: 1920, Height: 1440},
+		},
+	}}
+	return product, category, images
+}
+
+func TestNewProduct_SingleOffer(t *testing.T) {
+	product, category, images := fixtureProduct()
+	variants := []*types.ProductVariant{
+		{SKU: "OD-200", Name: "200x300 см", Unit: types.UnitPiece, PriceKopecks: 4599000, Stock: 2},
+		{SKU: "OD-OLD", Name: "Снят с продажи", Unit: types.UnitPiece, PriceKopecks: 100, DeletedAt: &time.Time{}},
+	}
+
+	ld := NewProduct(testSite, product, category, variants, images)
+
+	validateSchema(t, ld)
+	raw, err := json.Marshal(ld)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"@context": "https://schema.org",
+		"@type": "Product",
+		"name": "Osta Diamond",
+		"description": "Шерстяной ковер  ручной работы.",
+		"url": "https://luxcarpets.ru/product/osta-diamond",
+		"image": ["https://luxcarpets.ru/media/media/ab/abcd/1920.jpg"],
+		"brand": {"@type": "Brand", "name": "Osta"},
+		"material": "Шерсть",
+		"color": "Бежевый",
+		"category": "Ковры",
+		"offers": {
+			"@type": "Offer",
+			"sku": "OD-200",
+			"name": "200x300 см",
+			"url": "https://luxcarpets.ru/product/osta-diamond",
+			"price": "45990.00",
+			"priceCurrency": "RUB",
+			"availability": "https://schema.org/InStock",
+			"itemCondition": "https://schema.org/NewCondition"
+		}
+	}`, string(raw))
+}
+
+func TestNewProduct_AggregateOffer(t *testing.T) {
+	product, _, _ := fixtureProduct()
+	variants := []*types.ProductVariant{
+		{SKU: "OD-400", Name: "Рулон 4 м", Unit: types.UnitSqm, PriceKopecks: 189050, Stock: 0},
+		{SKU: "OD-500", Name: "Рулон 5 м", Unit: types.UnitSqm, PriceKopecks: 159000, Stock: 0},
+	}
+
+	ld := NewProduct(testSite, product, nil, variants, nil)
+
+	root := validateSchema(t, ld)
+	offers := root["offers"].(map[string]any)
+	assert.Equal(t, "AggregateOffer", offers["@type"])
+	assert.Equal(t, "1590.00", offers["lowPrice"])
+	assert.Equal(t, "1890.50", offers["highPrice"])
+	assert.Equal(t, float64(2), offers["offerCount"])
+	assert.Equal(t, OutOfStock, offers["availability"])
+	first := offers["offers"].([]any)[0].(map[string]any)
+	assert.Equal(t, map[string]any{
+		"@type": "UnitPriceSpecification", "price": "1890.50", "priceCurrency": "RUB", "unitCode": "MTK",
+	}, first["priceSpecification"])
+	assert.NotContains(t, root, "image")
+	assert.NotContains(t, root, "category")
+}
+
+func TestNewBreadcrumbList(t *testing.T) {
+	ld := NewBreadcrumbList(testSite,
+		Crumb{Name: "Главная", Path: "/"},
+		Crumb{Name: "Каталог", Path: "/catalog"},
+		Crumb{Name: "Osta Diamond"},
+	)
+
+	validateSchema(t, ld)
+	raw, err := json.Marshal(ld)
+	require.NoError(t, err)
+	assert.JSONEq(t, `{
+		"@context": "https://schema.org",
+		"@type": "BreadcrumbList",
+		"itemListElement": [
+			{"@type": "ListItem", "position": 1, "name": "Главная", "item": "https://luxcarpets.ru/"},
+			{"@type": "ListItem", "position": 2, "name": "Каталог", "item": "https://luxcarpets.ru/catalog"},
+			{"@type": "ListItem", "position": 3, "name": "Osta Diamond"}
+		]
+	}`, string(raw))
+}
+
+func TestNewOrganization(t *testing.T) {
+	root := validateSchema(t, NewOrganization(testSite))
+
+	assert.Equal(t, "https://luxcarpets.ru/", root["url"])
+	assert.Equal(t, "https://luxcarpets.ru/static/img/favicon.svg", root["logo"])
+	assert.Equal(t, "+74950000000", root["telephone"])
+}
+
+func TestNewLocalBusiness(t *testing.T) {
+	raw, err := json.Marshal(NewLocalBusiness(testSite))
+	require.NoError(t, err)
+	validateSchema(t, NewLocalBusiness(testSite))
+	assert.JSONEq(t, `{
+		"@context": "https://schema.org",
+		"@type": "LocalBusiness",
+		"name": "LuxCarpets",
+		"url": "https://luxcarpets.ru/",
+		"image": "https://luxcarpets.ru/static/img/favicon.svg",
+		"description": "Магазин ковровых покрытий",
+		"telephone": "+74950000000",
+		"email": "shop@luxcarpets.ru",
+		"address": {"@type": "PostalAddress", "streetAddress": "Москва, ул. Примерная, 1", "addressCountry": "RU"},
+		"openingHours": "Mo-Su 10:00-20:00",
+		"currenciesAccepted": "RUB"
+	}`, string(raw))
+}
+
+func TestNewWebSite(t *testing.T) {
+	root := validateSchema(t, NewWebSite(testSite))
+
+	action := root["potentialAction"].(map[string]any)
+	assert.Equal(t, "required name=search_term_string", action["query-input"])
+	assert.Equal(t, "https://luxcarpets.ru/catalog?q={search_term_string}", action["target"].(map[string]any)["urlTemplate"])
+}
+
+func TestProductMeta_Tags(t *testing.T) {
+	product, _, images := fixtureProduct()
+	variants := []*types.ProductVariant{
+		{PriceKopecks: 4599000, Stock: 0},
+		{PriceKopecks: 2599000, Stock: 1},
+	}
+
+	tags := ProductMeta(testSite, product, variants, images).Tags(testSite)
+
+	assert.Equal(t, []Tag{
+		{Property: "og:type", Content: "product"},
+		{Property: "og:site_name", Content: "LuxCarpets"},
+		{Property: "og:locale", Content: "ru_RU"},
+		{Property: "og:title", Content: "Osta Diamond"},
+		{Property: "og:description", Content: "Шерстяной ковер ручной работы."},
+		{Property: "og:url", Content: "https://luxcarpets.ru/product/osta-diamond"},
+		{Property: "og:image", Content: "https://luxcarpets.ru/media/media/ab/abcd/1920.jpg"},
+		{Property: "og:image:alt", Content: "Ковер в гостиной"},
+		{Property: "og:image:width", Content: "1920"},
+		{Property: "og:image:height", Content: "1440"},
+		{Property: "product:price:amount", Content: "25990.00"},
+		{Property: "product:price:currency", Content: "RUB"},
+		{Property: "product:availability", Content: "in stock"},
+		{Name: "twitter:card", Content: "summary_large_image"},
+		{Name: "twitter:title", Content: "Osta Diamond"},
+		{Name: "twitter:description", Content: "Шерстяной ковер ручной работы."},
+		{Name: "twitter:image", Content: "https://luxcarpets.ru/media/media/ab/abcd/1920.jpg"},
+		{Name: "twitter:image:alt", Content: "Ковер в гостиной"},
+	}, tags)
+}
+
+func TestMeta_Tags_Website(t *testing.T) {
+	tags := Meta{Description: "Ковры"}.Tags(testSite)
+
+	assert.Contains(t, tags, Tag{Property: "og:type", Content: "website"})
+	assert.Contains(t, tags, Tag{Property: "og:title", Content: "LuxCarpets"})
+	assert.Contains(t, tags, Tag{Name: "twitter:card", Content: "summary"})
+	assert.NotContains(t, tags, Tag{Property: "og:image", Content: ""})
+}
+
+func TestTruncate(t *testing.T) {
+	assert.Equal(t, "короткий текст", truncate("короткий\n текст", 20))
+	assert.Equal(t, "Шерстяной ковер…", truncate("Шерстяной ковер, ручная работа", 20))
+}
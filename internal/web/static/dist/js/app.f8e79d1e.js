@@ -0,0 +1,15 @@
+// Скрипты сайта, не зависящие от Datastar
+document.addEventListener("click", (event) => {
+  const button = event.target.closest("[data-dismiss]");
+  if (button) {
+    button.closest("[role=alert], [role=status]")?.remove();
+  }
+});
+
+// Фильтры каталога записываются в историю браузера без перезагрузки страницы (pushState),
+// поэтому при переходе "Назад" страница загружается заново по восстановленному адресу
+window.addEventListener("popstate", () => {
+  if (document.getElementById("catalog-filters")) {
+    window.location.reload();
+  }
+});
@@ -0,0 +1,28 @@
+// Пакет static встраивает статические файлы сайта в бинарный файл.
+// Исходные файлы лежат в каталоге assets в корне репозитория; после их изменения
+// нужно пересобрать каталог dist командой go generate ./internal/web/static (just assets).
+package static
+
+import (
+	"embed"
+	"io/fs"
+
+	"github.com/LigeronAhill/luxcarpets-go/pkg/assets"
+)
+
+//go:generate go run ../../../cmd/assets -src ../../../assets -dst dist
+
+//go:embed dist
+var dist embed.FS
+
+// Prefix - адрес, по которому раздаются статические файлы
+const Prefix = "/static/"
+
+// Load загружает манифест встроенных файлов
+func Load() (*assets.Assets, error) {
+	sub, err := fs.Sub(dist, "dist")
+	if err != nil {
+		return nil, err
+	}
+	return assets.Load(sub, Prefix)
+}
@@ -0,0 +1,22 @@
+package static
+
+import (
+	"testing"
+
+	"github.com/LigeronAhill/luxcarpets-go/internal/web/components"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// Файлы, на которые ссылается макет, должны быть во встроенном манифесте:
+// для неизвестного файла URL возвращает адрес без хеша, и запрос по нему отвечает 404
+func TestLoad_LayoutAssets(t *testing.T) {
+	a, err := Load()
+	require.NoError(t, err)
+
+	for _, name := range []string{"css/app.css", "js/app.js", "img/favicon.svg", components.DatastarAsset} {
+		t.Run(name, func(t *testing.T) {
+			assert.NotEqual(t, Prefix+name, a.URL(name), "run just assets (just vendor-datastar for Datastar)")
+		})
+	}
+}
@@ -0,0 +1,169 @@
+package assets
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"strconv"
+	"strings"
+)
+
+// Assets раздает файлы, подготовленные Build
+type Assets struct {
+	fsys   fs.FS
+	prefix string
+	files  map[string]*File // по логическому имени
+	paths  map[string]*File // по имени с хешем
+}
+
+// Load читает манифест из fsys. prefix - адрес, по которому раздаются файлы, например "/static/".
+func Load(fsys fs.FS, prefix string) (*Assets, error) {
+	raw, err := fs.ReadFile(fsys, ManifestName)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read asset manifest: %w", err)
+	}
+	var manifest Manifest
+	if err := json.Unmarshal(raw, &manifest); err != nil {
+		return nil, fmt.Errorf("failed to parse asset manifest: %w", err)
+	}
+	a := &Assets{
+		fsys:   fsys,
+		prefix: "/" + strings.Trim(prefix, "/") + "/",
+		files:  manifest.Files,
+		paths:  make(map[string]*File, len(manifest.Files)),
+	}
+	if a.files == nil {
+		a.files = make(map[string]*File)
+	}
+	for _, file := range a.files {
+		a.paths[file.Path] = file
+	}
+	return a, nil
+}
+
+// Prefix возвращает адрес, по которому раздаются файлы ("/static/")
+func (a *Assets) Prefix() string {
+	return a.prefix
+}
+
+// URL возвращает адрес файла с хешем в имени: "css/app.css" -> "/static/css/app.3f9a1c2b.css".
+// Для неизвестного файла возвращается адрес без хеша - запрос по нему вернет 404,
+// что проще заметить, чем пустой атрибут.
+func (a *Assets) URL(name string) string {
+	name = strings.TrimPrefix(name, "/")
+	if file, ok := a.files[name]; ok {
+		return a.prefix + file.Path
+	}
+	return a.prefix + name
+}
+
+// FuncMap возвращает функции для html/template: {{ asset "css/app.css" }}
+func (a *Assets) FuncMap() template.FuncMap {
+	return template.FuncMap{
+		"asset": a.URL,
+	}
+}
+
+// Asset - представление файла, выбранное для ответа
+type Asset struct {
+	File      *File
+	Path      string // Имя в файловой системе
+	Encoding  string // Значение Content-Encoding; пустое - без сжатия
+	ETag      string // Строгий ETag представления
+	Size      int64  // Размер в байтах
+	Immutable bool   // Запрошен адрес с хешем: содержимое по нему никогда не меняется
+}
+
+// Resolve находит файл по пути относительно префикса ("css/app.3f9a1c2b.css" или "css/app.css")
+// и выбирает представление по заголовку Accept-Encoding: brotli, затем gzip, затем без сжатия.
+// Возвращает false, если файла нет.
+func (a *Assets) Resolve(name, acceptEncoding string) (*Asset, bool) {
+	name = strings.TrimPrefix(name, "/")
+	file, immutable := a.paths[name]
+	if !immutable {
+		var ok bool
+		if file, ok = a.files[name]; !ok {
+			return nil, false
+		}
+	}
+	asset := &Asset{
+		File:      file,
+		Path:      file.Path,
+		ETag:      file.ETag,
+		Size:      file.Size,
+		Immutable: immutable,
+	}
+	if encoding := Negotiate(acceptEncoding, file.Encodings); encoding != "" {
+		variant := file.Encodings[encoding]
+		asset.Path = variant.Path
+		asset.Encoding = encoding
+		asset.ETag = variant.ETag
+		asset.Size = variant.Size
+	}
+	return asset, true
+}
+
+// ReadFile возвращает содержимое представления
+func (a *Assets) ReadFile(asset *Asset) ([]byte, error) {
+	return fs.ReadFile(a.fsys, asset.Path)
+}
+
+// Negotiate выбирает кодировку из available по заголовку Accept-Encoding.
+// Предпочтение отдается brotli; кодировки с q=0 не выбираются.
+// Пустая строка означает ответ без сжатия.
+func Negotiate(acceptEncoding string, available map[string]*Variant) string {
+	if len(available) == 0 || acceptEncoding == "" {
+		return ""
+	}
+	accepted := make(map[string]float64)
+	for part := range strings.SplitSeq(acceptEncoding, ",") {
+		coding, params, _ := strings.Cut(part, ";")
+		coding = strings.ToLower(strings.TrimSpace(coding))
+		q := 1.0
+		for param := range strings.SplitSeq(params, ";") {
+			key, value, ok := strings.Cut(strings.TrimSpace(param), "=")
+			if ok && strings.EqualFold(key, "q") {
+				if parsed, err := strconv.ParseFloat(value, 64); err == nil {
+					q = parsed
+				}
+			}
+		}
+		accepted[coding] = q
+	}
+
+	var best string
+	var bestQ float64
+	for _, encoding := range []string{EncodingBrotli, EncodingGzip} {
+		if _, ok := available[encoding]; !ok {
+			continue
+		}
+		q, ok := accepted[encoding]
+		if !ok {
+			q, ok = accepted["*"]
+		}
+		if ok && q > bestQ {
+			best, bestQ = encoding, q
+		}
+	}
+	return best
+}
+
+// ETagMatches проверяет заголовок If-None-Match. Для него используется слабое сравнение:
+// префикс W/ не учитывается.
+func ETagMatches(ifNoneMatch, etag string) bool {
+	ifNoneMatch = strings.TrimSpace(ifNoneMatch)
+	if ifNoneMatch == "" {
+		return false
+	}
+	if ifNoneMatch == "*" {
+		return true
+	}
+	etag = strings.TrimPrefix(etag, "W/")
+	for candidate := range strings.SplitSeq(ifNoneMatch, ",") {
+		if strings.TrimPrefix(strings.TrimSpace(candidate), "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,216 @@
+package assets
+
+import (
+	"bytes"
+	"compress/gzip"
+	"html/template"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"testing/fstest"
+
+	"github.com/andybalholm/brotli"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var appCSS = bytes.Repeat([]byte("body { color: #2b2622; }\n"), 20)
+
+func testSource() fstest.MapFS {
+	return fstest.MapFS{
+		"css/app.css":     {Data: appCSS},
+		"img/logo.png":    {Data: []byte("\x89PNG\r\n\x1a\n not really compressible")},
+		"js/app.min.js":   {Data: []byte("console.log(1)")},
+		".hidden/secret":  {Data: []byte("secret")},
+		"css/.DS_Store":   {Data: []byte("junk")},
+		"fonts/sans.woff": {Data: []byte("wOFF")},
+	}
+}
+
+func buildTest(t *testing.T) (*Manifest, string) {
+	t.Helper()
+	dst := filepath.Join(t.TempDir(), "dist")
+	manifest, err := Build(testSource(), dst)
+	require.NoError(t, err)
+	return manifest, dst
+}
+
+func TestBuild(t *testing.T) {
+	manifest, dst := buildTest(t)
+
+	assert.Len(t, manifest.Files, 4, "скрытые файлы пропускаются")
+
+	css := manifest.Files["css/app.css"]
+	require.NotNil(t, css)
+	assert.Regexp(t, `^css/app\.[0-9a-f]{8}\.css$`, css.Path)
+	assert.Equal(t, "text/css; charset=utf-8", css.ContentType)
+	assert.Regexp(t, `^"[0-9a-f]{32}"$`, css.ETag)
+
+	data, err := os.ReadFile(filepath.Join(dst, filepath.FromSlash(css.Path)))
+	require.NoError(t, err)
+	assert.Equal(t, appCSS, data)
+
+	// Сжатые копии распаковываются в исходное содержимое и имеют собственные ETag
+	require.Contains(t, css.Encodings, EncodingBrotli)
+	require.Contains(t, css.Encodings, EncodingGzip)
+	br, err := os.ReadFile(filepath.Join(dst, filepath.FromSlash(css.Encodings[EncodingBrotli].Path)))
+	require.NoError(t, err)
+	unpacked, err := io.ReadAll(brotli.NewReader(bytes.NewReader(br)))
+	require.NoError(t, err)
+	assert.Equal(t, appCSS, unpacked)
+
+	gz, err := os.ReadFile(filepath.Join(dst, filepath.FromSlash(css.Encodings[EncodingGzip].Path)))
+	require.NoError(t, err)
+	reader, err := gzip.NewReader(bytes.NewReader(gz))
+	require.NoError(t, err)
+	unpacked, err = io.ReadAll(reader)
+	require.NoError(t, err)
+	assert.Equal(t, appCSS, unpacked)
+	assert.NotEqual(t, css.ETag, css.Encodings[EncodingGzip].ETag)
+
+	// Имя с несколькими точками: хеш перед последним расширением
+	assert.Regexp(t, `^js/app\.min\.[0-9a-f]{8}\.js$`, manifest.Files["js/app.min.js"].Path)
+	// Маленький файл не сжимается: копия не меньше оригинала
+	assert.Empty(t, manifest.Files["js/app.min.js"].Encodings)
+	// Изображения PNG уже сжаты
+	assert.Empty(t, manifest.Files["img/logo.png"].Encodings)
+	assert.Equal(t, "image/png", manifest.Files["img/logo.png"].ContentType)
+}
+
+func TestBuild_Deterministic(t *testing.T) {
+	first, _ := buildTest(t)
+	second, _ := buildTest(t)
+
+	assert.Equal(t, first, second)
+}
+
+func TestBuild_Output(t *testing.T) {
+	t.Run("повторная сборка удаляет устаревшие файлы", func(t *testing.T) {
+		manifest, dst := buildTest(t)
+		old := manifest.Files["css/app.css"].Path
+
+		src := testSource()
+		src["css/app.css"] = &fstest.MapFile{Data: []byte("body { color: red; }")}
+		rebuilt, err := Build(src, dst)
+		require.NoError(t, err)
+
+		assert.NotEqual(t, old, rebuilt.Files["css/app.css"].Path)
+		_, err = os.Stat(filepath.Join(dst, filepath.FromSlash(old)))
+		assert.True(t, os.IsNotExist(err))
+	})
+
+	t.Run("каталог с посторонними файлами не очищается", func(t *testing.T) {
+		dst := t.TempDir()
+		require.NoError(t, os.WriteFile(filepath.Join(dst, "important.txt"), []byte("data"), 0o644))
+
+		_, err := Build(testSource(), dst)
+
+		assert.ErrorIs(t, err, ErrNotGenerated)
+		_, err = os.Stat(filepath.Join(dst, "important.txt"))
+		assert.NoError(t, err)
+	})
+}
+
+func TestAssets_URL(t *testing.T) {
+	manifest, dst := buildTest(t)
+	a, err := Load(os.DirFS(dst), "static")
+	require.NoError(t, err)
+
+	assert.Equal(t, "/static/", a.Prefix())
+	assert.Equal(t, "/static/"+manifest.Files["css/app.css"].Path, a.URL("css/app.css"))
+	assert.Equal(t, "/static/"+manifest.Files["css/app.css"].Path, a.URL("/css/app.css"))
+	assert.Equal(t, "/static/missing.css", a.URL("missing.css"))
+
+	var buf bytes.Buffer
+	tmpl := template.Must(template.New("head").Funcs(a.FuncMap()).Parse(`<link rel="stylesheet" href="{{ asset "css/app.css" }}">`))
+	require.NoError(t, tmpl.Execute(&buf, nil))
+	assert.Equal(t, `<link rel="stylesheet" href="/static/`+manifest.Files["css/app.css"].Path+`">`, buf.String())
+}
+
+func TestAssets_Resolve(t *testing.T) {
+	manifest, dst := buildTest(t)
+	a, err := Load(os.DirFS(dst), "/static/")
+	require.NoError(t, err)
+	css := manifest.Files["css/app.css"]
+
+	tests := []struct {
+		name           string
+		path           string
+		acceptEncoding string
+		wantFound      bool
+		wantEncoding   string
+		wantImmutable  bool
+	}{
+		{name: "адрес с хешем, brotli", path: css.Path, acceptEncoding: "gzip, deflate, br", wantFound: true, wantEncoding: EncodingBrotli, wantImmutable: true},
+		{name: "только gzip", path: css.Path, acceptEncoding: "gzip", wantFound: true, wantEncoding: EncodingGzip, wantImmutable: true},
+		{name: "без сжатия", path: css.Path, wantFound: true, wantImmutable: true},
+		{name: "логическое имя", path: "css/app.css", acceptEncoding: "br", wantFound: true, wantEncoding: EncodingBrotli},
+		{name: "несжимаемый файл", path: "img/logo.png", acceptEncoding: "br", wantFound: true},
+		{name: "нет файла", path: "css/other.css"},
+		{name: "манифест не раздается", path: ManifestName},
+		{name: "сжатая копия не раздается напрямую", path: css.Encodings[EncodingBrotli].Path},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			asset, found := a.Resolve(tt.path, tt.acceptEncoding)
+
+			require.Equal(t, tt.wantFound, found)
+			if !found {
+				return
+			}
+			assert.Equal(t, tt.wantEncoding, asset.Encoding)
+			assert.Equal(t, tt.wantImmutable, asset.Immutable)
+			data, err := a.ReadFile(asset)
+			require.NoError(t, err)
+			assert.Equal(t, asset.Size, int64(len(data)))
+		})
+	}
+}
+
+func TestNegotiate(t *testing.T) {
+	both := map[string]*Variant{EncodingBrotli: {}, EncodingGzip: {}}
+	gzipOnly := map[string]*Variant{EncodingGzip: {}}
+
+	tests := []struct {
+		name           string
+		acceptEncoding string
+		available      map[string]*Variant
+		want           string
+	}{
+		{name: "предпочтение brotli", acceptEncoding: "gzip, br", available: both, want: EncodingBrotli},
+		{name: "brotli запрещен", acceptEncoding: "gzip, br;q=0", available: both, want: EncodingGzip},
+		{name: "вес gzip выше", acceptEncoding: "br;q=0.5, gzip;q=0.8", available: both, want: EncodingGzip},
+		{name: "любая кодировка", acceptEncoding: "*", available: both, want: EncodingBrotli},
+		{name: "только gzip доступен", acceptEncoding: "br, gzip", available: gzipOnly, want: EncodingGzip},
+		{name: "регистр не важен", acceptEncoding: "GZIP", available: gzipOnly, want: EncodingGzip},
+		{name: "нет заголовка", acceptEncoding: "", available: both, want: ""},
+		{name: "только identity", acceptEncoding: "identity", available: both, want: ""},
+		{name: "нет сжатых копий", acceptEncoding: "br", available: nil, want: ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, Negotiate(tt.acceptEncoding, tt.available))
+		})
+	}
+}
+
+func TestETagMatches(t *testing.T) {
+	tests := []struct {
+		name        string
+		ifNoneMatch string
+		want        bool
+	}{
+		{name: "совпадает", ifNoneMatch: `"abc"`, want: true},
+		{name: "слабый ETag клиента", ifNoneMatch: `W/"abc"`, want: true},
+		{name: "список", ifNoneMatch: `"x", "abc"`, want: true},
+		{name: "звездочка", ifNoneMatch: `*`, want: true},
+		{name: "другой", ifNoneMatch: `"abd"`, want: false},
+		{name: "пустой", ifNoneMatch: ``, want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, ETagMatches(tt.ifNoneMatch, `"abc"`))
+		})
+	}
+}
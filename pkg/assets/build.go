@@ -0,0 +1,299 @@
+// Пакет assets готовит статические файлы сайта к раздаче и раздает их.
+//
+// Build выполняется при сборке (go generate): копирует файлы под именами с хешем содержимого
+// (app.css -> app.3f9a1c2b.css), сохраняет рядом сжатые копии .br и .gz и записывает manifest.json.
+// Во время работы Load читает манифест из встроенной файловой системы, URL превращает логическое
+// имя файла в адрес с хешем, а Resolve выбирает представление файла для ответа.
+package assets
+
+import (
+	"bytes"
+	"compress/gzip"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"mime"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+)
+
+// ManifestName - имя файла манифеста в выходном каталоге
+const ManifestName = "manifest.json"
+
+// Кодировки сжатых копий в терминах заголовка Content-Encoding
+const (
+	EncodingBrotli = "br"
+	EncodingGzip   = "gzip"
+)
+
+// hashLength - количество символов хеша в имени файла
+const hashLength = 8
+
+// compressibleTypes - типы содержимого, для которых создаются сжатые копии.
+// Изображения JPEG/PNG/WebP и шрифты WOFF2 уже сжаты.
+var compressibleTypes = []string{
+	"text/",
+	"application/javascript",
+	"application/json",
+	"application/manifest+json",
+	"application/xml",
+	"application/wasm",
+	"image/svg+xml",
+	"image/x-icon",
+	"font/ttf",
+	"font/otf",
+}
+
+// ErrNotGenerated возвращается, если выходной каталог содержит файлы, созданные не Build
+var ErrNotGenerated = errors.New("output directory is not empty and has no manifest")
+
+// Manifest описывает подготовленные файлы
+type Manifest struct {
+	Files map[string]*File `json:"files"` // Файлы по логическому имени ("css/app.css")
+}
+
+// File - подготовленный статический файл
+type File struct {
+	Name        string              `json:"name"`                // Логическое имя ("css/app.css")
+	Path        string              `json:"path"`                // Имя с хешем ("css/app.3f9a1c2b.css")
+	ContentType string              `json:"content_type"`        // MIME-тип
+	ETag        string              `json:"etag"`                // Строгий ETag исходного содержимого
+	Size        int64               `json:"size"`                // Размер в байтах
+	Encodings   map[string]*Variant `json:"encodings,omitempty"` // Сжатые копии по кодировке
+}
+
+// Variant - сжатая копия файла
+type Variant struct {
+	Path string `json:"path"` // Имя копии ("css/app.3f9a1c2b.css.br")
+	ETag string `json:"etag"` // Строгий ETag сжатого содержимого
+	Size int64  `json:"size"` // Размер в байтах
+}
+
+// Build подготавливает все файлы из src в каталоге dst и возвращает манифест.
+// Файлы и каталоги, имена которых начинаются с точки, пропускаются.
+// Каталог dst очищается перед сборкой, если он создан предыдущим запуском Build.
+//
+// Возможные ошибки:
+//   - ErrNotGenerated: если dst содержит посторонние файлы
+//   - ошибки чтения, сжатия и записи
+func Build(src fs.FS, dst string) (*Manifest, error) {
+	if err := resetOutput(dst); err != nil {
+		return nil, err
+	}
+
+	manifest := &Manifest{Files: make(map[string]*File)}
+	err := fs.WalkDir(src, ".", func(name string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if name != "." && strings.HasPrefix(d.Name(), ".") {
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		data, err := fs.ReadFile(src, name)
+		if err != nil {
+			return err
+		}
+		file, err := buildFile(dst, name, data)
+		if err != nil {
+			return fmt.Errorf("failed to build %s: %w", name, err)
+		}
+		manifest.Files[name] = file
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	raw, err := json.MarshalIndent(manifest, "", "  ")
+	if err != nil {
+		return nil, err
+	}
+	if err := os.WriteFile(filepath.Join(dst, ManifestName), append(raw, '\n'), 0o644); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+// buildFile записывает файл под именем с хешем и его сжатые копии
+func buildFile(dst, name string, data []byte) (*File, error) {
+	sum := sha256.Sum256(data)
+	hash := hex.EncodeToString(sum[:])
+	file := &File{
+		Name:        name,
+		Path:        hashedName(name, hash[:hashLength]),
+		ContentType: contentType(name, data),
+		ETag:        strongETag(sum[:]),
+		Size:        int64(len(data)),
+	}
+	if err := writeFile(dst, file.Path, data); err != nil {
+		return nil, err
+	}
+	if !compressible(file.ContentType) {
+		return file, nil
+	}
+
+	for _, encoding := range []string{EncodingBrotli, EncodingGzip} {
+		compressed, err := compress(encoding, data)
+		if err != nil {
+			return nil, err
+		}
+		// Копия, которая не меньше оригинала, только увеличит ответ
+		if len(compressed) >= len(data) {
+			continue
+		}
+		variantSum := sha256.Sum256(compressed)
+		variant := &Variant{
+			Path: file.Path + extension(encoding),
+			ETag: strongETag(variantSum[:]),
+			Size: int64(len(compressed)),
+		}
+		if err := writeFile(dst, variant.Path, compressed); err != nil {
+			return nil, err
+		}
+		if file.Encodings == nil {
+			file.Encodings = make(map[string]*Variant)
+		}
+		file.Encodings[encoding] = variant
+	}
+	return file, nil
+}
+
+// resetOutput очищает каталог, созданный предыдущей сборкой, или создает новый
+func resetOutput(dst string) error {
+	entries, err := os.ReadDir(dst)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return os.MkdirAll(dst, 0o755)
+		}
+		return err
+	}
+	if len(entries) > 0 {
+		if _, err := os.Stat(filepath.Join(dst, ManifestName)); err != nil {
+			return fmt.Errorf("%w: %s", ErrNotGenerated, dst)
+		}
+	}
+	for _, entry := range entries {
+		if err := os.RemoveAll(filepath.Join(dst, entry.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// hashedName вставляет хеш перед расширением: "css/app.css" -> "css/app.3f9a1c2b.css"
+func hashedName(name, hash string) string {
+	ext := path.Ext(name)
+	return strings.TrimSuffix(name, ext) + "." + hash + ext
+}
+
+// strongETag формирует строгий ETag из хеша содержимого
+func strongETag(sum []byte) string {
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// contentTypes - MIME-типы основных расширений. Собственная таблица нужна, чтобы манифест
+// не зависел от базы типов системы, на которой выполняется сборка.
+var contentTypes = map[string]string{
+	".css":         "text/css; charset=utf-8",
+	".js":          "text/javascript; charset=utf-8",
+	".mjs":         "text/javascript; charset=utf-8",
+	".json":        "application/json",
+	".map":         "application/json",
+	".webmanifest": "application/manifest+json",
+	".html":        "text/html; charset=utf-8",
+	".txt":         "text/plain; charset=utf-8",
+	".xml":         "application/xml",
+	".svg":         "image/svg+xml",
+	".ico":         "image/x-icon",
+	".png":         "image/png",
+	".jpg":         "image/jpeg",
+	".jpeg":        "image/jpeg",
+	".webp":        "image/webp",
+	".avif":        "image/avif",
+	".woff2":       "font/woff2",
+	".woff":        "font/woff",
+	".ttf":         "font/ttf",
+	".otf":         "font/otf",
+	".wasm":        "application/wasm",
+}
+
+// contentType определяет MIME-тип по расширению, а при неизвестном расширении - по содержимому
+func contentType(name string, data []byte) string {
+	ext := strings.ToLower(path.Ext(name))
+	if ct, ok := contentTypes[ext]; ok {
+		return ct
+	}
+	if ct := mime.TypeByExtension(ext); ct != "" {
+		return ct
+	}
+	return http.DetectContentType(data)
+}
+
+func compressible(contentType string) bool {
+	for _, prefix := range compressibleTypes {
+		if strings.HasPrefix(contentType, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// compress сжимает данные с максимальной степенью: сборка выполняется один раз
+func compress(encoding string, data []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	switch encoding {
+	case EncodingBrotli:
+		w := brotli.NewWriterLevel(&buf, brotli.BestCompression)
+		if _, err := w.Write(data); err != nil {
+			return nil, err
+		}
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+	case EncodingGzip:
+		// Заголовок gzip без имени и времени - результат зависит только от содержимого
+		w, err := gzip.NewWriterLevel(&buf, gzip.BestCompression)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := w.Write(data); err != nil {
+			return nil, err
+		}
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("unknown encoding %q", encoding)
+	}
+	return buf.Bytes(), nil
+}
+
+// extension возвращает расширение файла сжатой копии
+func extension(encoding string) string {
+	if encoding == EncodingBrotli {
+		return ".br"
+	}
+	return ".gz"
+}
+
+func writeFile(dst, name string, data []byte) error {
+	target := filepath.Join(dst, filepath.FromSlash(name))
+	if err := os.MkdirAll(filepath.Dir(target), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(target, data, 0o644)
+}
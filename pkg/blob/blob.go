@@ -0,0 +1,37 @@
+// Пакет blob сохраняет файлы (изображения, медиа) в подключаемых хранилищах.
+// Сервисы зависят только от интерфейса Storage: при локальной разработке используется Local,
+// в production - S3 или совместимое с ним хранилище.
+package blob
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+)
+
+// ErrInvalidKey возвращается для ключа, который нельзя безопасно использовать как путь
+var ErrInvalidKey = errors.New("invalid blob key")
+
+// Storage сохраняет и удаляет объекты по ключу вида "avatars/<id>/256.jpg"
+type Storage interface {
+	// Put сохраняет объект размером size байт, перезаписывая существующий
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Delete удаляет объект. Отсутствие объекта ошибкой не считается.
+	Delete(ctx context.Context, key string) error
+	// URL возвращает публичный адрес объекта
+	URL(key string) string
+}
+
+// ValidateKey проверяет, что ключ состоит из непустых сегментов без "." и ".."
+func ValidateKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, `\`) {
+		return ErrInvalidKey
+	}
+	for segment := range strings.SplitSeq(key, "/") {
+		if segment == "" || segment == "." || segment == ".." {
+			return ErrInvalidKey
+		}
+	}
+	return nil
+}
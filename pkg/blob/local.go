@@ -0,0 +1,88 @@
+package blob
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// Local хранит объекты в каталоге файловой системы.
+// Раздачу файлов по адресу baseURL обеспечивает HTTP-сервер.
+type Local struct {
+	root    string
+	baseURL string
+}
+
+// NewLocal создает хранилище в каталоге root (создается при необходимости).
+// baseURL - префикс публичных адресов, например "/media".
+func NewLocal(root, baseURL string) (*Local, error) {
+	if err := os.MkdirAll(root, 0o755); err != nil {
+		return nil, fmt.Errorf("failed to create blob directory: %w", err)
+	}
+	return &Local{
+		root:    root,
+		baseURL: strings.TrimRight(baseURL, "/"),
+	}, nil
+}
+
+// Root возвращает каталог хранилища
+func (l *Local) Root() string {
+	return l.root
+}
+
+// Put записывает объект во временный файл и переименовывает его,
+// чтобы читатели не увидели частично записанный файл
+func (l *Local) Put(_ context.Context, key string, r io.Reader, size int64, _ string) error {
+	if err := ValidateKey(key); err != nil {
+		return err
+	}
+	path := filepath.Join(l.root, filepath.FromSlash(key))
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		return fmt.Errorf("failed to create blob directory: %w", err)
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return fmt.Errorf("failed to create blob file: %w", err)
+	}
+	defer os.Remove(tmp.Name())
+
+	written, err := io.Copy(tmp, r)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return fmt.Errorf("failed to write blob %s: %w", key, err)
+	}
+	if size >= 0 && written != size {
+		return fmt.Errorf("failed to write blob %s: got %d bytes, expected %d", key, written, size)
+	}
+	if err := os.Chmod(tmp.Name(), 0o644); err != nil {
+		return fmt.Errorf("failed to write blob %s: %w", key, err)
+	}
+	if err := os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("failed to write blob %s: %w", key, err)
+	}
+	return nil
+}
+
+// Delete удаляет файл объекта
+func (l *Local) Delete(_ context.Context, key string) error {
+	if err := ValidateKey(key); err != nil {
+		return err
+	}
+	err := os.Remove(filepath.Join(l.root, filepath.FromSlash(key)))
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return fmt.Errorf("failed to delete blob %s: %w", key, err)
+	}
+	return nil
+}
+
+// URL возвращает адрес объекта относительно baseURL
+func (l *Local) URL(key string) string {
+	return l.baseURL + "/" + key
+}
@@ -1,16 +1,137 @@
 package config
 
 import (
+	"time"
+
 	"github.com/ilyakaznacheev/cleanenv"
 )
 
 type DatabaseSettings struct {
-	URL string `toml:"url" env:"DATABASE_URL" env-required:"true" env-description:"Postgres database URL"`
+	URL            string        `toml:"url" env:"DATABASE_URL" env-required:"true" env-description:"Postgres database URL"`
+	ConnectTimeout time.Duration `toml:"connect_timeout" env:"DATABASE_CONNECT_TIMEOUT" env-default:"30s" env-description:"How long to wait for the database to accept connections on startup"`
+}
+
+type ServerSettings struct {
+	Address         string        `toml:"address" env:"SERVER_ADDRESS" env-default:":8080" env-description:"HTTP server listen address"`
+	ShutdownTimeout time.Duration `toml:"shutdown_timeout" env:"SERVER_SHUTDOWN_TIMEOUT" env-default:"10s" env-description:"Graceful shutdown timeout"`
+	PublicBaseURL   string        `toml:"public_base_url" env:"PUBLIC_BASE_URL" env-default:"http://localhost:8080" env-description:"Public site address used in links sent to users"`
+	CORSOrigins     []string      `toml:"cors_origins" env:"CORS_ALLOWED_ORIGINS" env-description:"Comma-separated origins allowed to call the API from browsers - empty disables CORS"`
+	HSTSMaxAge      time.Duration `toml:"hsts_max_age" env:"HSTS_MAX_AGE" env-default:"8760h" env-description:"Strict-Transport-Security max-age in production - 0 disables the header"`
+	MetricsToken    string        `toml:"metrics_token" env:"METRICS_TOKEN" env-description:"Bearer token required to scrape /metrics - empty leaves the endpoint open"`
+	ProxyHeader     string        `toml:"proxy_header" env:"PROXY_HEADER" env-description:"Header with the client IP set by the reverse proxy, e.g. X-Real-IP - empty uses the connection address"`
+	TrustedProxies  []string      `toml:"trusted_proxies" env:"TRUSTED_PROXIES" env-description:"Comma-separated proxy IPs or CIDR ranges whose PROXY_HEADER is trusted"`
+}
+
+type TracingSettings struct {
+	Exporter    string  `toml:"exporter" env:"TRACING_EXPORTER" env-default:"none" env-description:"Trace exporter - otlp, stdout or none"`
+	Endpoint    string  `toml:"endpoint" env:"TRACING_ENDPOINT" env-description:"OTLP/HTTP collector URL, e.g. http://otel-collector:4318 - empty uses OTEL_EXPORTER_OTLP_* variables"`
+	ServiceName string  `toml:"service_name" env:"TRACING_SERVICE_NAME" env-default:"luxcarpets" env-description:"Service name reported in spans"`
+	SampleRatio float64 `toml:"sample_ratio" env:"TRACING_SAMPLE_RATIO" env-default:"1" env-description:"Fraction of new traces to record, from 0 to 1"`
+}
+
+type AuthSettings struct {
+	LoginAttemptsBackend string        `toml:"login_attempts_backend" env:"LOGIN_ATTEMPTS_BACKEND" env-default:"memory" env-description:"Failed sign-in counters storage - memory or postgres"`
+	LoginMaxFailures     int           `toml:"login_max_failures" env:"LOGIN_MAX_FAILURES" env-default:"10" env-description:"Failed sign-ins per account before temporary lockout"`
+	LoginIPMaxFailures   int           `toml:"login_ip_max_failures" env:"LOGIN_IP_MAX_FAILURES" env-default:"50" env-description:"Failed sign-ins per IP address before temporary lockout"`
+	LoginLockout         time.Duration `toml:"login_lockout" env:"LOGIN_LOCKOUT" env-default:"15m" env-description:"Temporary lockout duration"`
+	SessionTTL           time.Duration `toml:"session_ttl" env:"SESSION_TTL" env-default:"720h" env-description:"Browser session lifetime"`
+	CSRFKey              string        `toml:"csrf_key" env:"CSRF_KEY" env-required:"true" env-description:"Base64-encoded 32-byte key for signing CSRF tokens"`
+}
+
+type RateLimitSettings struct {
+	Backend string `toml:"backend" env:"RATE_LIMIT_BACKEND" env-default:"memory" env-description:"Rate limit counters storage - memory or postgres"`
+	OTP     string `toml:"otp" env:"RATE_LIMIT_OTP" env-default:"5/10m" env-description:"Sign-in code and link requests per IP address as count/period - 0 disables the limit"`
+	Auth    string `toml:"auth" env:"RATE_LIMIT_AUTH" env-default:"10/1m" env-description:"Sign-in code and 2FA checks per IP address as count/period - 0 disables the limit"`
+	Search  string `toml:"search" env:"RATE_LIMIT_SEARCH" env-default:"60/1m" env-description:"Catalog search requests per IP address as count/period - 0 disables the limit"`
+	API     string `toml:"api" env:"RATE_LIMIT_API" env-default:"600/1m" env-description:"API requests per token, user or IP address as count/period - 0 disables the limit"`
+}
+
+type PasswordSettings struct {
+	MinLength         int    `toml:"min_length" env:"PASSWORD_MIN_LENGTH" env-default:"8" env-description:"Minimum password length in bytes"`
+	MaxLength         int    `toml:"max_length" env:"PASSWORD_MAX_LENGTH" env-default:"72" env-description:"Maximum password length in bytes"`
+	RequireUpper      bool   `toml:"require_upper" env:"PASSWORD_REQUIRE_UPPER" env-default:"true" env-description:"Require an uppercase letter"`
+	RequireLower      bool   `toml:"require_lower" env:"PASSWORD_REQUIRE_LOWER" env-default:"true" env-description:"Require a lowercase letter"`
+	RequireDigit      bool   `toml:"require_digit" env:"PASSWORD_REQUIRE_DIGIT" env-default:"true" env-description:"Require a digit"`
+	RequireSpecial    bool   `toml:"require_special" env:"PASSWORD_REQUIRE_SPECIAL" env-default:"true" env-description:"Require a special character"`
+	CheckBreached     bool   `toml:"check_breached" env:"PASSWORD_CHECK_BREACHED" env-default:"true" env-description:"Reject passwords from the breached passwords list"`
+	BreachedFile      string `toml:"breached_file" env:"PASSWORD_BREACHED_FILE" env-description:"Additional breached SHA-1 hashes file in Pwned Passwords format"`
+	Argon2MemoryKB    uint32 `toml:"argon2_memory_kb" env:"ARGON2_MEMORY_KB" env-default:"65536" env-description:"Argon2id memory in KiB"`
+	Argon2Iterations  uint32 `toml:"argon2_iterations" env:"ARGON2_ITERATIONS" env-default:"3" env-description:"Argon2id iterations"`
+	Argon2Parallelism uint8  `toml:"argon2_parallelism" env:"ARGON2_PARALLELISM" env-default:"2" env-description:"Argon2id parallelism"`
+}
+
+type TwoFactorSettings struct {
+	EncryptionKey string `toml:"encryption_key" env:"TWO_FACTOR_ENCRYPTION_KEY" env-required:"true" env-description:"Base64-encoded 32-byte key for encrypting TOTP secrets"`
+	Issuer        string `toml:"issuer" env:"TWO_FACTOR_ISSUER" env-default:"LuxCarpets" env-description:"Issuer name shown in authenticator apps"`
+	RequiredRole  string `toml:"required_role" env:"TWO_FACTOR_REQUIRED_ROLE" env-default:"employee" env-description:"Minimum role that must use two-factor authentication - none disables enforcement"`
+}
+
+type SMSSettings struct {
+	Provider   string `toml:"provider" env:"SMS_PROVIDER" env-default:"log" env-description:"SMS gateway - log, smsru or none (disables phone sign-in)"`
+	SMSRuAPIID string `toml:"smsru_api_id" env:"SMSRU_API_ID" env-description:"SMS.ru API key"`
+	Sender     string `toml:"sender" env:"SMS_SENDER" env-description:"Registered SMS sender name"`
+	OTPKey     string `toml:"otp_key" env:"PHONE_OTP_KEY" env-required:"true" env-description:"Base64-encoded 32-byte key for hashing phone sign-in codes"`
+}
+
+type MailSettings struct {
+	Provider     string        `toml:"provider" env:"MAIL_PROVIDER" env-default:"log" env-description:"Mail transport - log, smtp or none (disables email sign-in links)"`
+	SMTPHost     string        `toml:"smtp_host" env:"SMTP_HOST" env-description:"SMTP server host"`
+	SMTPPort     int           `toml:"smtp_port" env:"SMTP_PORT" env-default:"587" env-description:"SMTP server port"`
+	SMTPUsername string        `toml:"smtp_username" env:"SMTP_USERNAME" env-description:"SMTP username - empty disables authentication"`
+	SMTPPassword string        `toml:"smtp_password" env:"SMTP_PASSWORD" env-description:"SMTP password"`
+	From         string        `toml:"from" env:"MAIL_FROM" env-default:"LuxCarpets <noreply@localhost>" env-description:"Sender address"`
+	MagicLinkTTL time.Duration `toml:"magic_link_ttl" env:"MAGIC_LINK_TTL" env-default:"15m" env-description:"Email sign-in link lifetime"`
+}
+
+type BlobSettings struct {
+	Driver      string `toml:"driver" env:"BLOB_DRIVER" env-default:"local" env-description:"File storage - local or s3"`
+	LocalDir    string `toml:"local_dir" env:"BLOB_LOCAL_DIR" env-default:"./data/media" env-description:"Directory for the local file storage"`
+	LocalURL    string `toml:"local_url" env:"BLOB_LOCAL_URL" env-default:"/media" env-description:"URL prefix the server uses to serve local files"`
+	S3Endpoint  string `toml:"s3_endpoint" env:"S3_ENDPOINT" env-description:"S3-compatible API address"`
+	S3Region    string `toml:"s3_region" env:"S3_REGION" env-default:"us-east-1" env-description:"S3 region used for request signing"`
+	S3Bucket    string `toml:"s3_bucket" env:"S3_BUCKET" env-description:"S3 bucket name"`
+	S3AccessKey string `toml:"s3_access_key" env:"S3_ACCESS_KEY" env-description:"S3 access key ID"`
+	S3SecretKey string `toml:"s3_secret_key" env:"S3_SECRET_KEY" env-description:"S3 secret access key"`
+	S3PublicURL string `toml:"s3_public_url" env:"S3_PUBLIC_URL" env-description:"Public URL prefix for stored files (CDN) - defaults to endpoint/bucket"`
+}
+
+type AvatarSettings struct {
+	MaxBytes  int64 `toml:"max_bytes" env:"AVATAR_MAX_BYTES" env-default:"5242880" env-description:"Maximum avatar upload size in bytes"`
+	MaxPixels int   `toml:"max_pixels" env:"AVATAR_MAX_PIXELS" env-default:"40000000" env-description:"Maximum avatar width * height"`
+	Quality   int   `toml:"quality" env:"AVATAR_JPEG_QUALITY" env-default:"85" env-description:"JPEG quality of avatar thumbnails"`
+}
+
+type MediaSettings struct {
+	MaxBytes  int64 `toml:"max_bytes" env:"MEDIA_MAX_BYTES" env-default:"20971520" env-description:"Maximum product image upload size in bytes"`
+	MaxPixels int   `toml:"max_pixels" env:"MEDIA_MAX_PIXELS" env-default:"60000000" env-description:"Maximum product image width * height"`
+	Widths    []int `toml:"widths" env:"MEDIA_WIDTHS" env-default:"320,640,960,1280,1920" env-description:"Widths of product image variants for srcset"`
+	Quality   int   `toml:"quality" env:"MEDIA_JPEG_QUALITY" env-default:"82" env-description:"JPEG quality of product image variants"`
+}
+
+type ShopSettings struct {
+	Name         string `toml:"name" env:"SHOP_NAME" env-default:"LuxCarpets" env-description:"Shop name shown to customers and crawlers"`
+	About        string `toml:"about" env:"SHOP_ABOUT" env-default:"Магазин ковровых покрытий: ковролин, ковры и ковровая плитка с доставкой и подъемом на этаж." env-description:"Short shop description for the about page, llms.txt and structured data"`
+	Phone        string `toml:"phone" env:"SHOP_PHONE" env-description:"Contact phone in international format"`
+	Email        string `toml:"email" env:"SHOP_EMAIL" env-description:"Contact email"`
+	Address      string `toml:"address" env:"SHOP_ADDRESS" env-description:"Showroom street address"`
+	OpeningHours string `toml:"opening_hours" env:"SHOP_OPENING_HOURS" env-default:"Mo-Su 10:00-20:00" env-description:"Opening hours in schema.org format"`
 }
 
 type AppSettings struct {
-	Environment      string           `toml:"environment" env:"ENVIRONMENT" env-default:"development" env-description:"Application environment - production or development"`
-	DatabaseSettings DatabaseSettings `toml:"database"`
+	Environment       string            `toml:"environment" env:"ENVIRONMENT" env-default:"development" env-description:"Application environment - production or development"`
+	DatabaseSettings  DatabaseSettings  `toml:"database"`
+	ServerSettings    ServerSettings    `toml:"server"`
+	TracingSettings   TracingSettings   `toml:"tracing"`
+	AuthSettings      AuthSettings      `toml:"auth"`
+	RateLimitSettings RateLimitSettings `toml:"rate_limit"`
+	PasswordSettings  PasswordSettings  `toml:"password"`
+	TwoFactorSettings TwoFactorSettings `toml:"two_factor"`
+	SMSSettings       SMSSettings       `toml:"sms"`
+	MailSettings      MailSettings      `toml:"mail"`
+	BlobSettings      BlobSettings      `toml:"blob"`
+	AvatarSettings    AvatarSettings    `toml:"avatar"`
+	MediaSettings     MediaSettings     `toml:"media"`
+	ShopSettings      ShopSettings      `toml:"shop"`
 }
 
 func Init(path string) (*AppSettings, error) {
@@ -29,3 +150,22 @@ func Init(path string) (*AppSettings, error) {
 
 	}
 }
+
+// InitDatabase читает из переменных окружения только настройки базы данных.
+// Используется командами, которым не нужны остальные обязательные настройки сервера (migrate).
+func InitDatabase() (*DatabaseSettings, error) {
+	var cfg DatabaseSettings
+	if err := cleanenv.ReadEnv(&cfg); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
+
+// InitServer читает из переменных окружения только настройки HTTP-сервера (healthcheck)
+func InitServer() (*ServerSettings, error) {
+	var cfg ServerSettings
+	if err := cleanenv.ReadEnv(&cfg); err != nil {
+		return nil, err
+	}
+	return &cfg, nil
+}
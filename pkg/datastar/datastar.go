@@ -0,0 +1,132 @@
+// Пакет datastar отправляет ответы для клиентской библиотеки Datastar (https://data-star.dev)
+// в формате Server-Sent Events.
+//
+// Страница с атрибутами Datastar выполняет запрос (@get) с заголовком Datastar-Request,
+// а сервер отвечает потоком событий datastar-patch-elements: каждое событие содержит HTML-фрагменты,
+// которые заменяют на странице элементы с теми же id. Пакет реализует только нужную сайту часть
+// протокола - замену элементов и выполнение скрипта.
+package datastar
+
+import (
+	"encoding/json"
+	"fmt"
+	"html"
+	"io"
+	"strings"
+)
+
+// Заголовки протокола
+const (
+	// HeaderRequest - заголовок, которым Datastar помечает свои запросы
+	HeaderRequest = "Datastar-Request"
+	// ContentType - тип содержимого ответа
+	ContentType = "text/event-stream"
+)
+
+// EventPatchElements - событие замены элементов страницы
+const EventPatchElements = "datastar-patch-elements"
+
+// Mode - способ вставки фрагмента
+type Mode string
+
+const (
+	ModeOuter   Mode = "outer"   // Заменить элемент с тем же id (по умолчанию)
+	ModeInner   Mode = "inner"   // Заменить содержимое элемента
+	ModeReplace Mode = "replace" // Заменить элемент без сравнения DOM
+	ModeAppend  Mode = "append"  // Добавить в конец элемента selector
+	ModePrepend Mode = "prepend" // Добавить в начало элемента selector
+	ModeRemove  Mode = "remove"  // Удалить элементы selector
+)
+
+// IsRequest проверяет, выполнен ли запрос библиотекой Datastar, по значению заголовка HeaderRequest
+func IsRequest(header string) bool {
+	return strings.EqualFold(strings.TrimSpace(header), "true")
+}
+
+// Writer записывает события Datastar. Первая ошибка записи сохраняется,
+// последующие вызовы ничего не делают и возвращают ее же.
+type Writer struct {
+	w   io.Writer
+	err error
+}
+
+// NewWriter создает Writer поверх тела ответа
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: w}
+}
+
+// PatchOptions - параметры замены элементов
+type PatchOptions struct {
+	Selector string // CSS-селектор целевого элемента; пустой - элементы ищутся по id фрагментов
+	Mode     Mode   // Способ вставки; пустой - ModeOuter
+}
+
+// PatchElements заменяет на странице элементы с теми же id, что у корневых элементов фрагмента
+func (w *Writer) PatchElements(elements string) error {
+	return w.PatchElementsWith(elements, PatchOptions{})
+}
+
+// PatchElementsWith вставляет фрагмент способом из opts
+func (w *Writer) PatchElementsWith(elements string, opts PatchOptions) error {
+	lines := make([]string, 0, 4)
+	if opts.Selector != "" {
+		lines = append(lines, "selector "+opts.Selector)
+	}
+	if opts.Mode != "" && opts.Mode != ModeOuter {
+		lines = append(lines, "mode "+string(opts.Mode))
+	}
+	for line := range strings.Lines(elements) {
+		line = strings.TrimRight(line, "\r\n")
+		if strings.TrimSpace(line) != "" {
+			lines = append(lines, "elements "+line)
+		}
+	}
+	return w.event(EventPatchElements, lines)
+}
+
+// ExecuteScript выполняет скрипт на странице. Скрипт передается не элементом script,
+// а выражением атрибута data-effect скрытого элемента, который удаляет себя после выполнения:
+// политика CSP сайта блокирует script без nonce, а выражения атрибутов Datastar вычисляет сама
+// библиотека (политике для этого нужен 'unsafe-eval').
+func (w *Writer) ExecuteScript(script string) error {
+	expression := strings.TrimSuffix(strings.TrimSpace(script), ";") + "; el.remove()"
+	return w.PatchElementsWith(
+		`<div hidden data-effect="`+html.EscapeString(expression)+`"></div>`,
+		PatchOptions{Selector: "body", Mode: ModeAppend},
+	)
+}
+
+// PushURL добавляет адрес в историю браузера, не загружая страницу.
+// Кнопка "Назад" после этого вернет предыдущее состояние фильтров.
+func (w *Writer) PushURL(url string) error {
+	return w.ExecuteScript("window.history.pushState({}, \"\", " + jsString(url) + ")")
+}
+
+// ReplaceURL заменяет адрес текущей записи истории браузера
+func (w *Writer) ReplaceURL(url string) error {
+	return w.ExecuteScript("window.history.replaceState({}, \"\", " + jsString(url) + ")")
+}
+
+// event записывает событие SSE: строки данных и пустую строку в конце
+func (w *Writer) event(name string, data []string) error {
+	if w.err != nil {
+		return w.err
+	}
+	var b strings.Builder
+	b.WriteString("event: " + name + "\n")
+	for _, line := range data {
+		b.WriteString("data: " + line + "\n")
+	}
+	b.WriteString("\n")
+	if _, err := io.WriteString(w.w, b.String()); err != nil {
+		w.err = fmt.Errorf("failed to write datastar event: %w", err)
+	}
+	return w.err
+}
+
+// jsString возвращает строковый литерал JavaScript. json.Marshal экранирует < и >,
+// поэтому значение безопасно и внутри элемента script, и после экранирования в атрибуте.
+func jsString(s string) string {
+	raw, _ := json.Marshal(s)
+	return string(raw)
+}
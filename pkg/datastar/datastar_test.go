@@ -0,0 +1,57 @@
+package datastar
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsRequest(t *testing.T) {
+	assert.True(t, IsRequest("true"))
+	assert.True(t, IsRequest(" True "))
+	assert.False(t, IsRequest(""))
+	assert.False(t, IsRequest("false"))
+}
+
+func TestWriter_PatchElements(t *testing.T) {
+	var b strings.Builder
+	w := NewWriter(&b)
+
+	err := w.PatchElements("<div id=\"results\">\n  <p>Товар</p>\n\n</div>\n")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "event: datastar-patch-elements\n"+
+		"data: elements <div id=\"results\">\n"+
+		"data: elements   <p>Товар</p>\n"+
+		"data: elements </div>\n\n", b.String())
+}
+
+func TestWriter_PushURL(t *testing.T) {
+	var b strings.Builder
+	w := NewWriter(&b)
+
+	err := w.PushURL("/catalog?q=</script>")
+
+	assert.NoError(t, err)
+	// Без элемента script: адрес добавляется выражением Datastar, которое не блокирует политика CSP
+	assert.Equal(t, "event: datastar-patch-elements\n"+
+		"data: selector body\n"+
+		"data: mode append\n"+
+		`data: elements <div hidden data-effect="window.history.pushState({}, &#34;&#34;, &#34;/catalog?q=\u003c/script\u003e&#34;); el.remove()"></div>`+"\n\n", b.String())
+}
+
+type failingWriter struct{}
+
+func (failingWriter) Write([]byte) (int, error) {
+	return 0, errors.New("connection closed")
+}
+
+func TestWriter_KeepsFirstError(t *testing.T) {
+	w := NewWriter(failingWriter{})
+
+	err := w.PatchElements("<div id=\"a\"></div>")
+	assert.ErrorContains(t, err, "connection closed")
+	assert.Equal(t, err, w.ReplaceURL("/catalog"))
+}
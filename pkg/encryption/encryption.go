@@ -0,0 +1,82 @@
+// Пакет encryption предоставляет симметричное шифрование небольших секретов
+// для хранения в базе данных (AES-256-GCM).
+package encryption
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+)
+
+// KeySize - длина ключа в байтах (AES-256)
+const KeySize = 32
+
+var (
+	// ErrInvalidKey возвращается при ключе неверной длины
+	ErrInvalidKey = errors.New("encryption key must be 32 bytes")
+	// ErrDecrypt возвращается, если шифртекст поврежден или зашифрован другим ключом
+	ErrDecrypt = errors.New("failed to decrypt data")
+)
+
+// Cipher шифрует и расшифровывает данные с аутентификацией (AES-256-GCM).
+// Случайный nonce добавляется в начало шифртекста.
+type Cipher struct {
+	aead cipher.AEAD
+}
+
+// ParseKey декодирует ключ из base64 (стандартный или URL-алфавит)
+func ParseKey(s string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		key, err = base64.RawURLEncoding.DecodeString(s)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode encryption key: %w", err)
+		}
+	}
+	if len(key) != KeySize {
+		return nil, ErrInvalidKey
+	}
+	return key, nil
+}
+
+// NewCipher создает шифр с 32-байтовым ключом
+func NewCipher(key []byte) (*Cipher, error) {
+	if len(key) != KeySize {
+		return nil, ErrInvalidKey
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create cipher: %w", err)
+	}
+	aead, err := cipher.NewGCM(block)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create gcm: %w", err)
+	}
+	return &Cipher{aead: aead}, nil
+}
+
+// Encrypt шифрует plaintext. associatedData не шифруется, но должна совпасть при расшифровке:
+// так шифртекст привязывается к записи (например, к ID пользователя).
+func (c *Cipher) Encrypt(plaintext, associatedData []byte) ([]byte, error) {
+	nonce := make([]byte, c.aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return c.aead.Seal(nonce, nonce, plaintext, associatedData), nil
+}
+
+// Decrypt расшифровывает данные, созданные Encrypt
+func (c *Cipher) Decrypt(ciphertext, associatedData []byte) ([]byte, error) {
+	size := c.aead.NonceSize()
+	if len(ciphertext) < size+c.aead.Overhead() {
+		return nil, ErrDecrypt
+	}
+	plaintext, err := c.aead.Open(nil, ciphertext[:size], ciphertext[size:], associatedData)
+	if err != nil {
+		return nil, ErrDecrypt
+	}
+	return plaintext, nil
+}
@@ -0,0 +1,85 @@
+package encryption
+
+import (
+	"bytes"
+	"encoding/base64"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCipher_EncryptDecrypt(t *testing.T) {
+	key := bytes.Repeat([]byte{7}, KeySize)
+	c, err := NewCipher(key)
+	require.NoError(t, err)
+
+	plaintext := []byte("totp secret")
+	ad := []byte("user-1")
+
+	ciphertext, err := c.Encrypt(plaintext, ad)
+	require.NoError(t, err)
+	assert.NotContains(t, string(ciphertext), string(plaintext))
+
+	// Повторное шифрование дает другой шифртекст из-за случайного nonce
+	other, err := c.Encrypt(plaintext, ad)
+	require.NoError(t, err)
+	assert.NotEqual(t, ciphertext, other)
+
+	decrypted, err := c.Decrypt(ciphertext, ad)
+	require.NoError(t, err)
+	assert.Equal(t, plaintext, decrypted)
+
+	tests := []struct {
+		name       string
+		ciphertext []byte
+		ad         []byte
+	}{
+		{"другие связанные данные", ciphertext, []byte("user-2")},
+		{"поврежденный шифртекст", append(bytes.Clone(ciphertext[:len(ciphertext)-1]), ciphertext[len(ciphertext)-1]^1), ad},
+		{"слишком короткий шифртекст", ciphertext[:5], ad},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := c.Decrypt(tt.ciphertext, tt.ad)
+			assert.ErrorIs(t, err, ErrDecrypt)
+		})
+	}
+
+	otherCipher, err := NewCipher(bytes.Repeat([]byte{8}, KeySize))
+	require.NoError(t, err)
+	_, err = otherCipher.Decrypt(ciphertext, ad)
+	assert.ErrorIs(t, err, ErrDecrypt)
+}
+
+func TestParseKey(t *testing.T) {
+	key := bytes.Repeat([]byte{0xfb}, KeySize)
+
+	tests := []struct {
+		name    string
+		input   string
+		wantErr error
+	}{
+		{"стандартный base64", base64.StdEncoding.EncodeToString(key), nil},
+		{"url base64 без выравнивания", base64.RawURLEncoding.EncodeToString(key), nil},
+		{"неверная длина", base64.StdEncoding.EncodeToString(key[:16]), ErrInvalidKey},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			parsed, err := ParseKey(tt.input)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, key, parsed)
+		})
+	}
+
+	_, err := ParseKey("not base64!")
+	assert.Error(t, err)
+
+	_, err = NewCipher(key[:10])
+	assert.ErrorIs(t, err, ErrInvalidKey)
+}
@@ -0,0 +1,197 @@
+// Пакет imaging проверяет и обрабатывает загружаемые изображения: определяет формат
+// по содержимому, поворачивает по EXIF, строит квадратные миниатюры и кодирует их заново.
+// Повторное кодирование отбрасывает все метаданные исходного файла (EXIF, GPS, XMP).
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"net/http"
+
+	"golang.org/x/image/draw"
+	"golang.org/x/image/webp"
+)
+
+// Ошибки обработки изображений
+var (
+	// ErrUnsupportedFormat возвращается, если содержимое не является JPEG, PNG или WebP
+	ErrUnsupportedFormat = errors.New("unsupported image format")
+	// ErrTooManyPixels возвращается, если размеры изображения превышают допустимые
+	ErrTooManyPixels = errors.New("image dimensions are too large")
+)
+
+// Format - формат исходного изображения
+type Format string
+
+const (
+	FormatJPEG Format = "jpeg"
+	FormatPNG  Format = "png"
+	FormatWebP Format = "webp"
+)
+
+// DetectFormat определяет формат по первым байтам содержимого (без учета имени файла и заголовков клиента)
+func DetectFormat(data []byte) (Format, error) {
+	switch http.DetectContentType(data) {
+	case "image/jpeg":
+		return FormatJPEG, nil
+	case "image/png":
+		return FormatPNG, nil
+	case "image/webp":
+		return FormatWebP, nil
+	default:
+		return "", ErrUnsupportedFormat
+	}
+}
+
+// Decode декодирует изображение и поворачивает его согласно EXIF Orientation.
+// Размеры проверяются до декодирования, чтобы небольшой файл не развернулся в гигабайты памяти.
+//
+// Возможные ошибки:
+//   - ErrUnsupportedFormat: если формат не поддерживается или файл поврежден
+//   - ErrTooManyPixels: если ширина * высота больше maxPixels
+func Decode(data []byte, maxPixels int) (image.Image, error) {
+	format, err := DetectFormat(data)
+	if err != nil {
+		return nil, err
+	}
+	decodeConfig, decode := decoders(format)
+
+	cfg, err := decodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedFormat, err)
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width > maxPixels/cfg.Height {
+		return nil, ErrTooManyPixels
+	}
+	img, err := decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrUnsupportedFormat, err)
+	}
+	if format == FormatJPEG {
+		img = applyOrientation(img, jpegOrientation(data))
+	}
+	return img, nil
+}
+
+// decoders возвращает функции декодирования для формата.
+// Декодеры вызываются напрямую, без регистрации в image.RegisterFormat.
+func decoders(format Format) (func(io.Reader) (image.Config, error), func(io.Reader) (image.Image, error)) {
+	switch format {
+	case FormatPNG:
+		return png.DecodeConfig, png.Decode
+	case FormatWebP:
+		return webp.DecodeConfig, webp.Decode
+	default:
+		return jpeg.DecodeConfig, jpeg.Decode
+	}
+}
+
+// SquareThumbnail вырезает из центра изображения квадрат и масштабирует его до size x size.
+// Прозрачные области заливаются белым, так как миниатюры кодируются в форматы без альфа-канала.
+func SquareThumbnail(img image.Image, size int) *image.RGBA {
+	bounds := img.Bounds()
+	side := min(bounds.Dx(), bounds.Dy())
+	crop := image.Rect(0, 0, side, side).Add(image.Point{
+		X: bounds.Min.X + (bounds.Dx()-side)/2,
+		Y: bounds.Min.Y + (bounds.Dy()-side)/2,
+	})
+
+	dst := image.NewRGBA(image.Rect(0, 0, size, size))
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, crop, draw.Over, nil)
+	return dst
+}
+
+// Resize масштабирует изображение до ширины width с сохранением пропорций.
+// Прозрачные области заливаются белым.
+func Resize(img image.Image, width int) *image.RGBA {
+	bounds := img.Bounds()
+	height := max(1, int(float64(bounds.Dy())*float64(width)/float64(bounds.Dx())+0.5))
+
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	draw.Draw(dst, dst.Bounds(), image.NewUniform(color.White), image.Point{}, draw.Src)
+	draw.CatmullRom.Scale(dst, dst.Bounds(), img, bounds, draw.Over, nil)
+	return dst
+}
+
+// DominantColor возвращает преобладающий цвет изображения.
+// Изображение уменьшается до 64x64, цвета группируются по 4 старшим битам каждого канала,
+// и возвращается средний цвет самой большой группы. Прозрачные пиксели не учитываются.
+func DominantColor(img image.Image) color.RGBA {
+	const sampleSide = 64
+	bounds := img.Bounds()
+	sample := image.NewNRGBA(image.Rect(0, 0, min(sampleSide, bounds.Dx()), min(sampleSide, bounds.Dy())))
+	draw.ApproxBiLinear.Scale(sample, sample.Bounds(), img, bounds, draw.Src, nil)
+
+	type bucket struct {
+		count   int
+		r, g, b int
+	}
+	buckets := make(map[uint16]*bucket)
+	var best *bucket
+	for i := 0; i < len(sample.Pix); i += 4 {
+		r, g, b, a := sample.Pix[i], sample.Pix[i+1], sample.Pix[i+2], sample.Pix[i+3]
+		if a < 128 {
+			continue
+		}
+		key := uint16(r>>4)<<8 | uint16(g>>4)<<4 | uint16(b>>4)
+		bk, ok := buckets[key]
+		if !ok {
+			bk = &bucket{}
+			buckets[key] = bk
+		}
+		bk.count++
+		bk.r += int(r)
+		bk.g += int(g)
+		bk.b += int(b)
+		if best == nil || bk.count > best.count {
+			best = bk
+		}
+	}
+	if best == nil {
+		return color.RGBA{R: 255, G: 255, B: 255, A: 255}
+	}
+	return color.RGBA{
+		R: uint8(best.r / best.count),
+		G: uint8(best.g / best.count),
+		B: uint8(best.b / best.count),
+		A: 255,
+	}
+}
+
+// Encoder кодирует обработанное изображение
+type Encoder interface {
+	Encode(w io.Writer, img image.Image) error
+	ContentType() string // MIME-тип результата
+	Extension() string   // Расширение файла без точки
+}
+
+// JPEGEncoder кодирует изображения в JPEG
+type JPEGEncoder struct {
+	Quality int // Качество 1-100; 0 - 85
+}
+
+// Encode кодирует изображение в JPEG
+func (e JPEGEncoder) Encode(w io.Writer, img image.Image) error {
+	quality := e.Quality
+	if quality <= 0 {
+		quality = 85
+	}
+	return jpeg.Encode(w, img, &jpeg.Options{Quality: quality})
+}
+
+// ContentType возвращает "image/jpeg"
+func (JPEGEncoder) ContentType() string {
+	return "image/jpeg"
+}
+
+// Extension возвращает "jpg"
+func (JPEGEncoder) Extension() string {
+	return "jpg"
+}
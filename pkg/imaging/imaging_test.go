@@ -0,0 +1,212 @@
+package imaging
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/binary"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"image/png"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// webp1x1 - изображение WebP (lossless) размером 1x1
+const webp1x1 = "UklGRhoAAABXRUJQVlA4TA0AAAAvAAAAEAcQERGIiP4HAA=="
+
+// halves возвращает изображение w x h: левая половина красная, правая синяя
+func halves(w, h int) image.Image {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := range h {
+		for x := range w {
+			c := color.RGBA{R: 255, A: 255}
+			if x >= w/2 {
+				c = color.RGBA{B: 255, A: 255}
+			}
+			img.Set(x, y, c)
+		}
+	}
+	return img
+}
+
+func encodeJPEG(t *testing.T, img image.Image) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	require.NoError(t, jpeg.Encode(&buf, img, &jpeg.Options{Quality: 95}))
+	return buf.Bytes()
+}
+
+// withOrientation вставляет после SOI сегмент APP1 с EXIF Orientation
+func withOrientation(jpegData []byte, orientation uint16) []byte {
+	tiff := []byte("MM\x00\x2a\x00\x00\x00\x08" + // заголовок TIFF, IFD0 со смещением 8
+		"\x00\x01" + // одна запись
+		"\x01\x12\x00\x03\x00\x00\x00\x01\x00\x00\x00\x00" + // Orientation, SHORT, 1 значение
+		"\x00\x00\x00\x00") // следующего IFD нет
+	binary.BigEndian.PutUint16(tiff[8+2+8:], orientation)
+	payload := append([]byte("Exif\x00\x00"), tiff...)
+	segment := []byte{0xFF, 0xE1, 0, 0}
+	binary.BigEndian.PutUint16(segment[2:], uint16(len(payload)+2))
+	segment = append(segment, payload...)
+
+	out := append([]byte{}, jpegData[:2]...)
+	out = append(out, segment...)
+	return append(out, jpegData[2:]...)
+}
+
+func isRed(c color.Color) bool {
+	r, g, b, _ := c.RGBA()
+	return r > 0xC000 && g < 0x4000 && b < 0x4000
+}
+
+func isBlue(c color.Color) bool {
+	r, g, b, _ := c.RGBA()
+	return b > 0xC000 && r < 0x4000 && g < 0x4000
+}
+
+func TestDetectFormat(t *testing.T) {
+	var pngData bytes.Buffer
+	require.NoError(t, png.Encode(&pngData, halves(2, 2)))
+	webpData, err := base64.StdEncoding.DecodeString(webp1x1)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name    string
+		data    []byte
+		want    Format
+		wantErr bool
+	}{
+		{name: "JPEG", data: encodeJPEG(t, halves(2, 2)), want: FormatJPEG},
+		{name: "PNG", data: pngData.Bytes(), want: FormatPNG},
+		{name: "WebP", data: webpData, want: FormatWebP},
+		{name: "GIF не поддерживается", data: []byte("GIF89a..."), wantErr: true},
+		{name: "HTML с расширением картинки", data: []byte("<html><script>alert(1)</script>"), wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DetectFormat(tt.data)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrUnsupportedFormat)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestDecode_WebP(t *testing.T) {
+	data, err := base64.StdEncoding.DecodeString(webp1x1)
+	require.NoError(t, err)
+
+	img, err := Decode(data, 100)
+
+	require.NoError(t, err)
+	assert.Equal(t, image.Rect(0, 0, 1, 1), img.Bounds())
+}
+
+func TestDecode_TooManyPixels(t *testing.T) {
+	_, err := Decode(encodeJPEG(t, halves(40, 20)), 40*20-1)
+	assert.ErrorIs(t, err, ErrTooManyPixels)
+}
+
+func TestDecode_Corrupted(t *testing.T) {
+	data := encodeJPEG(t, halves(40, 20))
+	_, err := Decode(data[:len(data)/3], 10_000)
+	assert.ErrorIs(t, err, ErrUnsupportedFormat)
+}
+
+func TestDecode_Orientation(t *testing.T) {
+	tests := []struct {
+		name        string
+		orientation uint16
+		wantBounds  image.Rectangle
+		// точки, где ожидается красный и синий цвет
+		red, blue image.Point
+	}{
+		{"без поворота", 1, image.Rect(0, 0, 40, 20), image.Pt(5, 10), image.Pt(35, 10)},
+		{"поворот на 180", 3, image.Rect(0, 0, 40, 20), image.Pt(35, 10), image.Pt(5, 10)},
+		{"поворот по часовой", 6, image.Rect(0, 0, 20, 40), image.Pt(10, 5), image.Pt(10, 35)},
+		{"поворот против часовой", 8, image.Rect(0, 0, 20, 40), image.Pt(10, 35), image.Pt(10, 5)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			data := withOrientation(encodeJPEG(t, halves(40, 20)), tt.orientation)
+
+			img, err := Decode(data, 10_000)
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantBounds, img.Bounds())
+			assert.True(t, isRed(img.At(tt.red.X, tt.red.Y)), "ожидался красный в %v", tt.red)
+			assert.True(t, isBlue(img.At(tt.blue.X, tt.blue.Y)), "ожидался синий в %v", tt.blue)
+		})
+	}
+}
+
+func TestJPEGOrientation_Malformed(t *testing.T) {
+	assert.Equal(t, 1, jpegOrientation(nil))
+	assert.Equal(t, 1, jpegOrientation([]byte{0xFF, 0xD8, 0xFF, 0xE1, 0xFF, 0xFF}))
+	assert.Equal(t, 1, jpegOrientation(withOrientation(encodeJPEG(t, halves(2, 2)), 42)))
+}
+
+func TestSquareThumbnail(t *testing.T) {
+	// Широкое изображение: красный слева, синий справа, по центру граница
+	thumb := SquareThumbnail(halves(300, 100), 64)
+
+	assert.Equal(t, image.Rect(0, 0, 64, 64), thumb.Bounds())
+	assert.True(t, isRed(thumb.At(5, 32)))
+	assert.True(t, isBlue(thumb.At(58, 32)))
+
+	// Прозрачный фон заливается белым
+	transparent := SquareThumbnail(image.NewNRGBA(image.Rect(0, 0, 10, 10)), 8)
+	r, g, b, a := transparent.At(4, 4).RGBA()
+	assert.Equal(t, [4]uint32{0xFFFF, 0xFFFF, 0xFFFF, 0xFFFF}, [4]uint32{r, g, b, a})
+}
+
+func TestJPEGEncoder_StripsMetadata(t *testing.T) {
+	source := withOrientation(encodeJPEG(t, halves(40, 20)), 6)
+	img, err := Decode(source, 10_000)
+	require.NoError(t, err)
+
+	var out bytes.Buffer
+	encoder := JPEGEncoder{}
+	require.NoError(t, encoder.Encode(&out, SquareThumbnail(img, 16)))
+
+	assert.Equal(t, "image/jpeg", encoder.ContentType())
+	assert.Equal(t, "jpg", encoder.Extension())
+	assert.NotContains(t, out.String(), "Exif")
+	assert.Equal(t, 1, jpegOrientation(out.Bytes()))
+}
+
+func TestResize(t *testing.T) {
+	resized := Resize(halves(400, 300), 200)
+
+	assert.Equal(t, image.Rect(0, 0, 200, 150), resized.Bounds())
+	assert.True(t, isRed(resized.At(20, 75)))
+	assert.True(t, isBlue(resized.At(180, 75)))
+}
+
+func TestDominantColor(t *testing.T) {
+	// 3/4 изображения - серо-бежевый фон, 1/4 - синий узор
+	img := image.NewRGBA(image.Rect(0, 0, 200, 200))
+	for y := range 200 {
+		for x := range 200 {
+			c := color.RGBA{R: 200, G: 185, B: 160, A: 255}
+			if x < 100 && y < 100 {
+				c = color.RGBA{B: 200, A: 255}
+			}
+			img.Set(x, y, c)
+		}
+	}
+
+	got := DominantColor(img)
+
+	assert.InDelta(t, 200, int(got.R), 2)
+	assert.InDelta(t, 185, int(got.G), 2)
+	assert.InDelta(t, 160, int(got.B), 2)
+
+	// Полностью прозрачное изображение - белый
+	assert.Equal(t, color.RGBA{R: 255, G: 255, B: 255, A: 255}, DominantColor(image.NewNRGBA(image.Rect(0, 0, 4, 4))))
+}
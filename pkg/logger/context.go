@@ -0,0 +1,58 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+
+	"github.com/LigeronAhill/luxcarpets-go/pkg/requestid"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Имена атрибутов из контекста
+const (
+	RequestIDKey = "request_id" // Идентификатор HTTP-запроса
+	TraceIDKey   = "trace_id"   // Идентификатор трассировки OpenTelemetry
+	SpanIDKey    = "span_id"    // Идентификатор текущего span
+)
+
+// ContextHandler дополняет записи атрибутами из контекста: идентификатором HTTP-запроса
+// и идентификаторами трассировки, по которым запись находится в системе трассировки.
+// Атрибуты добавляются только к записям с контекстом (slog.InfoContext, slog.ErrorContext и т.д.).
+type ContextHandler struct {
+	slog.Handler
+}
+
+// NewContextHandler оборачивает обработчик next
+func NewContextHandler(next slog.Handler) *ContextHandler {
+	return &ContextHandler{Handler: next}
+}
+
+// Handle добавляет атрибуты из контекста и передает запись дальше
+func (h *ContextHandler) Handle(ctx context.Context, r slog.Record) error {
+	id := requestid.FromContext(ctx)
+	span := trace.SpanContextFromContext(ctx)
+	if id == "" && !span.IsValid() {
+		return h.Handler.Handle(ctx, r)
+	}
+	r = r.Clone()
+	if id != "" {
+		r.AddAttrs(slog.String(RequestIDKey, id))
+	}
+	if span.IsValid() {
+		r.AddAttrs(
+			slog.String(TraceIDKey, span.TraceID().String()),
+			slog.String(SpanIDKey, span.SpanID().String()),
+		)
+	}
+	return h.Handler.Handle(ctx, r)
+}
+
+// WithAttrs возвращает обработчик с дополнительными атрибутами
+func (h *ContextHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return &ContextHandler{Handler: h.Handler.WithAttrs(attrs)}
+}
+
+// WithGroup возвращает обработчик с группой атрибутов
+func (h *ContextHandler) WithGroup(name string) slog.Handler {
+	return &ContextHandler{Handler: h.Handler.WithGroup(name)}
+}
@@ -0,0 +1,47 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"testing"
+
+	"github.com/LigeronAhill/luxcarpets-go/pkg/requestid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestContextHandler(t *testing.T) {
+	var buf bytes.Buffer
+	log := slog.New(NewContextHandler(slog.NewTextHandler(&buf, nil)))
+	ctx := requestid.WithID(context.Background(), "req-1")
+
+	log.InfoContext(ctx, "Request failed", slog.String("path", "/catalog"))
+	log.With(slog.String("component", "sitemap")).WithGroup("db").ErrorContext(ctx, "Query failed", slog.Int("rows", 0))
+	log.Info("Without context")
+
+	lines := bytes.Split(bytes.TrimSpace(buf.Bytes()), []byte("\n"))
+	assert.Len(t, lines, 3)
+	assert.Contains(t, string(lines[0]), "path=/catalog request_id=req-1")
+	assert.Contains(t, string(lines[1]), "component=sitemap db.rows=0 db.request_id=req-1")
+	assert.NotContains(t, string(lines[2]), RequestIDKey)
+}
+
+func TestContextHandler_Trace(t *testing.T) {
+	var buf bytes.Buffer
+	log := slog.New(NewContextHandler(slog.NewTextHandler(&buf, nil)))
+	traceID, err := trace.TraceIDFromHex("4bf92f3577b34da6a3ce929d0e0e4736")
+	require.NoError(t, err)
+	spanID, err := trace.SpanIDFromHex("00f067aa0ba902b7")
+	require.NoError(t, err)
+	ctx := trace.ContextWithSpanContext(context.Background(), trace.NewSpanContext(trace.SpanContextConfig{
+		TraceID: traceID,
+		SpanID:  spanID,
+	}))
+
+	log.InfoContext(ctx, "Query slow")
+
+	assert.Contains(t, buf.String(), "trace_id=4bf92f3577b34da6a3ce929d0e0e4736 span_id=00f067aa0ba902b7")
+	assert.NotContains(t, buf.String(), RequestIDKey)
+}
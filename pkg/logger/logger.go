@@ -18,7 +18,7 @@ func Init(level slog.Level) *slog.Logger {
 	opts := &slog.HandlerOptions{
 		Level: level,
 	}
-	logger := slog.New(prettylogger.NewColoredHandler(os.Stdout, opts))
+	logger := slog.New(NewRedactHandler(NewContextHandler(prettylogger.NewColoredHandler(os.Stdout, opts))))
 	slog.SetDefault(logger)
 	slog.Info("Logger initialized", "level", level.String())
 	return logger
@@ -0,0 +1,92 @@
+package logger
+
+import (
+	"context"
+	"log/slog"
+	"strings"
+
+	"github.com/LigeronAhill/luxcarpets-go/pkg/redact"
+)
+
+// sensitiveKeys - имена атрибутов, значения которых всегда скрываются (без учета регистра)
+var sensitiveKeys = map[string]bool{
+	"password":      true,
+	"token":         true,
+	"secret":        true,
+	"code":          true,
+	"otp":           true,
+	"authorization": true,
+	"cookie":        true,
+	"set-cookie":    true,
+	"api_key":       true,
+	"private_key":   true,
+}
+
+// sensitiveSuffixes - окончания имен атрибутов с секретами: password_hash, csrf_token, totp_secret
+var sensitiveSuffixes = []string{"_password", "_hash", "_token", "_secret", "_key"}
+
+// sensitive проверяет, что атрибут с таким именем содержит секрет
+func sensitive(key string) bool {
+	key = strings.ToLower(key)
+	if sensitiveKeys[key] {
+		return true
+	}
+	for _, suffix := range sensitiveSuffixes {
+		if strings.HasSuffix(key, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// RedactHandler заменяет значения атрибутов с именами секретов (см. sensitive) на redact.Mask,
+// в том числе внутри групп и значений slog.LogValuer. Это вторая линия защиты:
+// типы с секретами сами скрывают их в LogValue и Format.
+type RedactHandler struct {
+	slog.Handler
+}
+
+// NewRedactHandler оборачивает обработчик next
+func NewRedactHandler(next slog.Handler) *RedactHandler {
+	return &RedactHandler{Handler: next}
+}
+
+// Handle скрывает секреты и передает запись дальше
+func (h *RedactHandler) Handle(ctx context.Context, r slog.Record) error {
+	clean := slog.NewRecord(r.Time, r.Level, r.Message, r.PC)
+	r.Attrs(func(a slog.Attr) bool {
+		clean.AddAttrs(redactAttr(a))
+		return true
+	})
+	return h.Handler.Handle(ctx, clean)
+}
+
+// WithAttrs возвращает обработчик с дополнительными атрибутами, секреты в которых скрыты
+func (h *RedactHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	clean := make([]slog.Attr, len(attrs))
+	for i, a := range attrs {
+		clean[i] = redactAttr(a)
+	}
+	return &RedactHandler{Handler: h.Handler.WithAttrs(clean)}
+}
+
+// WithGroup возвращает обработчик с группой атрибутов
+func (h *RedactHandler) WithGroup(name string) slog.Handler {
+	return &RedactHandler{Handler: h.Handler.WithGroup(name)}
+}
+
+func redactAttr(a slog.Attr) slog.Attr {
+	if sensitive(a.Key) {
+		return slog.String(a.Key, redact.Mask)
+	}
+	value := a.Value.Resolve()
+	if value.Kind() != slog.KindGroup {
+		return slog.Attr{Key: a.Key, Value: value}
+	}
+	group := value.Group()
+	clean := make([]slog.Attr, len(group))
+	for i, child := range group {
+		clean[i] = redactAttr(child)
+	}
+	return slog.Attr{Key: a.Key, Value: slog.GroupValue(clean...)}
+}
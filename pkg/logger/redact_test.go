@@ -0,0 +1,53 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// leakyUser раскрывает секрет в LogValue - обработчик должен скрыть его по имени атрибута
+type leakyUser struct{ hash string }
+
+func (u leakyUser) LogValue() slog.Value {
+	return slog.GroupValue(slog.String("id", "42"), slog.String("password_hash", u.hash))
+}
+
+func TestRedactHandler(t *testing.T) {
+	const secret = "s3cr3t-value"
+	var buf bytes.Buffer
+	log := slog.New(NewRedactHandler(slog.NewJSONHandler(&buf, nil)))
+
+	log.With(slog.String("api_key", secret)).Info("Signed in",
+		slog.String("user_id", "42"),
+		slog.String("password", secret),
+		slog.String("Authorization", "Bearer "+secret),
+		slog.Group("session", slog.String("csrf_token", secret), slog.String("token_id", "t1")),
+		slog.Any("user", leakyUser{hash: secret}),
+		slog.String("code", secret),
+		slog.Int("status", 200),
+	)
+
+	assert.NotContains(t, buf.String(), secret)
+	var record map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+	assert.Equal(t, "42", record["user_id"])
+	assert.Equal(t, "[REDACTED]", record["password"])
+	assert.Equal(t, "[REDACTED]", record["api_key"])
+	assert.Equal(t, map[string]any{"csrf_token": "[REDACTED]", "token_id": "t1"}, record["session"])
+	assert.Equal(t, map[string]any{"id": "42", "password_hash": "[REDACTED]"}, record["user"])
+	assert.Equal(t, float64(200), record["status"])
+}
+
+func TestSensitive(t *testing.T) {
+	for _, key := range []string{"password", "PASSWORD", "password_hash", "token", "verification_token", "totp_secret", "encryption_key", "cookie", "otp"} {
+		assert.True(t, sensitive(key), key)
+	}
+	for _, key := range []string{"user_id", "token_id", "session_id", "path", "error", "phone", "status"} {
+		assert.False(t, sensitive(key), key)
+	}
+}
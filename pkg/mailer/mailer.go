@@ -0,0 +1,74 @@
+// Пакет mailer отправляет письма через подключаемые транспорты.
+// Сервисы зависят только от интерфейса Sender: в разработке используется LogSender,
+// в тестах - Fake, в production - SMTP.
+package mailer
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+)
+
+// Sender отправляет письмо
+type Sender interface {
+	Send(ctx context.Context, msg Message) error
+}
+
+// Message - письмо
+type Message struct {
+	To      string // Адрес получателя
+	Subject string // Тема
+	Text    string // Текст письма
+	HTML    string // HTML-версия письма (опционально)
+}
+
+// LogSender не отправляет письма, а пишет их в лог. Используется при локальной разработке.
+type LogSender struct{}
+
+// Send записывает письмо в лог
+func (LogSender) Send(ctx context.Context, msg Message) error {
+	slog.InfoContext(ctx, "Email",
+		slog.String("to", msg.To),
+		slog.String("subject", msg.Subject),
+		slog.String("text", msg.Text),
+	)
+	return nil
+}
+
+// Fake запоминает отправленные письма. Используется в тестах.
+type Fake struct {
+	mu       sync.Mutex
+	messages []Message
+	// Err, если задана, возвращается из Send вместо отправки
+	Err error
+}
+
+// Send сохраняет письмо или возвращает Err
+func (f *Fake) Send(_ context.Context, msg Message) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.Err != nil {
+		return f.Err
+	}
+	f.messages = append(f.messages, msg)
+	return nil
+}
+
+// Messages возвращает копию отправленных писем
+func (f *Fake) Messages() []Message {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]Message(nil), f.messages...)
+}
+
+// Last возвращает последнее письмо на адрес to
+func (f *Fake) Last(to string) (Message, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i := len(f.messages) - 1; i >= 0; i-- {
+		if f.messages[i].To == to {
+			return f.messages[i], true
+		}
+	}
+	return Message{}, false
+}
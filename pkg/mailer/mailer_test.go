@@ -0,0 +1,124 @@
+package mailer
+
+import (
+	"context"
+	"errors"
+	"io"
+	"mime"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/smtp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFake(t *testing.T) {
+	fake := &Fake{}
+	ctx := context.Background()
+
+	require.NoError(t, fake.Send(ctx, Message{To: "user@test.com", Subject: "первое"}))
+	require.NoError(t, fake.Send(ctx, Message{To: "other@test.com", Subject: "другой адрес"}))
+	require.NoError(t, fake.Send(ctx, Message{To: "user@test.com", Subject: "второе"}))
+
+	assert.Len(t, fake.Messages(), 3)
+	last, ok := fake.Last("user@test.com")
+	require.True(t, ok)
+	assert.Equal(t, "второе", last.Subject)
+	_, ok = fake.Last("nobody@test.com")
+	assert.False(t, ok)
+
+	fake.Err = errors.New("smtp down")
+	assert.Error(t, fake.Send(ctx, Message{To: "user@test.com"}))
+	assert.Len(t, fake.Messages(), 3)
+}
+
+func TestSMTP_Send(t *testing.T) {
+	tests := []struct {
+		name      string
+		msg       Message
+		sendErr   error
+		wantErr   bool
+		wantParts []string
+	}{
+		{
+			name: "текстовое письмо",
+			msg:  Message{To: "user@test.com", Subject: "Вход в LuxCarpets", Text: "Ссылка для входа"},
+			wantParts: []string{
+				"Content-Type: text/plain; charset=utf-8",
+			},
+		},
+		{
+			name: "письмо с HTML-версией",
+			msg:  Message{To: "user@test.com", Subject: "Вход", Text: "текст", HTML: "<p>html</p>"},
+			wantParts: []string{
+				"Content-Type: multipart/alternative",
+				"Content-Type: text/html; charset=utf-8",
+			},
+		},
+		{
+			name:    "некорректный получатель",
+			msg:     Message{To: "not an address"},
+			wantErr: true,
+		},
+		{
+			name:    "ошибка сервера",
+			msg:     Message{To: "user@test.com", Subject: "Вход", Text: "текст"},
+			sendErr: errors.New("554 rejected"),
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sender, err := NewSMTP("smtp.test", 587, "user", "secret", "LuxCarpets <noreply@luxcarpets.test>")
+			require.NoError(t, err)
+			sender.now = func() time.Time { return time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC) }
+
+			var gotAddr, gotFrom string
+			var gotTo []string
+			var gotBody []byte
+			sender.sendMail = func(addr string, a smtp.Auth, from string, to []string, msg []byte) error {
+				gotAddr, gotFrom, gotTo, gotBody = addr, from, to, msg
+				return tt.sendErr
+			}
+
+			err = sender.Send(context.Background(), tt.msg)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "smtp.test:587", gotAddr)
+			assert.Equal(t, "noreply@luxcarpets.test", gotFrom)
+			assert.Equal(t, []string{"user@test.com"}, gotTo)
+
+			parsed, err := mail.ReadMessage(strings.NewReader(string(gotBody)))
+			require.NoError(t, err)
+			subject, err := new(mime.WordDecoder).DecodeHeader(parsed.Header.Get("Subject"))
+			require.NoError(t, err)
+			assert.Equal(t, tt.msg.Subject, subject)
+			for _, part := range tt.wantParts {
+				assert.Contains(t, string(gotBody), part)
+			}
+			if tt.msg.HTML == "" {
+				text, err := readAllQuotedPrintable(parsed)
+				require.NoError(t, err)
+				assert.Equal(t, tt.msg.Text, text)
+			}
+		})
+	}
+}
+
+func TestNewSMTP_InvalidFrom(t *testing.T) {
+	_, err := NewSMTP("smtp.test", 587, "", "", "not an address")
+	assert.Error(t, err)
+}
+
+func readAllQuotedPrintable(msg *mail.Message) (string, error) {
+	raw, err := io.ReadAll(quotedprintable.NewReader(msg.Body))
+	return strings.ReplaceAll(string(raw), "\r\n", "\n"), err
+}
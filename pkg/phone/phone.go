@@ -0,0 +1,63 @@
+// Пакет phone нормализует номера телефонов в формат E.164.
+package phone
+
+import (
+	"errors"
+	"strings"
+)
+
+// ErrInvalidPhone возвращается, если строку нельзя привести к номеру E.164
+var ErrInvalidPhone = errors.New("invalid phone number")
+
+const (
+	// minDigits и maxDigits - допустимое количество цифр номера E.164 (без "+")
+	minDigits = 8
+	maxDigits = 15
+)
+
+// Normalize приводит номер к формату E.164 (+79123456789).
+// Пробелы, скобки, дефисы и точки игнорируются. Российские номера
+// принимаются также в формате 8XXXXXXXXXX и XXXXXXXXXX (10 цифр без кода страны).
+func Normalize(raw string) (string, error) {
+	raw = strings.TrimSpace(raw)
+	international := strings.HasPrefix(raw, "+")
+	if international {
+		raw = raw[1:]
+	}
+
+	digits := make([]byte, 0, len(raw))
+	for i := 0; i < len(raw); i++ {
+		c := raw[i]
+		switch {
+		case c >= '0' && c <= '9':
+			digits = append(digits, c)
+		case c == ' ' || c == '-' || c == '(' || c == ')' || c == '.':
+		default:
+			return "", ErrInvalidPhone
+		}
+	}
+
+	if !international {
+		switch {
+		case len(digits) == 11 && digits[0] == '8':
+			digits[0] = '7'
+		case len(digits) == 10 && digits[0] == '9':
+			digits = append([]byte{'7'}, digits...)
+		}
+	}
+
+	if len(digits) < minDigits || len(digits) > maxDigits || digits[0] == '0' {
+		return "", ErrInvalidPhone
+	}
+	// Российские номера всегда содержат 11 цифр
+	if digits[0] == '7' && len(digits) != 11 {
+		return "", ErrInvalidPhone
+	}
+	return "+" + string(digits), nil
+}
+
+// Valid проверяет, что номер уже записан в формате E.164
+func Valid(number string) bool {
+	normalized, err := Normalize(number)
+	return err == nil && normalized == number
+}
@@ -0,0 +1,46 @@
+package phone
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name     string
+		raw      string
+		expected string
+		wantErr  bool
+	}{
+		{"E.164", "+79123456789", "+79123456789", false},
+		{"с форматированием", "+7 (912) 345-67-89", "+79123456789", false},
+		{"через восьмерку", "8 912 345 67 89", "+79123456789", false},
+		{"без кода страны", "9123456789", "+79123456789", false},
+		{"казахстан", "+7 701 234 5678", "+77012345678", false},
+		{"германия", "+49 30 901820", "+4930901820", false},
+		{"слишком короткий", "+7912345", "", true},
+		{"слишком длинный", "+7912345678901", "", true},
+		{"буквы", "+7912abc6789", "", true},
+		{"ведущий ноль", "+0123456789", "", true},
+		{"пусто", "", "", true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Normalize(tt.raw)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, ErrInvalidPhone)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tt.expected, got)
+		})
+	}
+}
+
+func TestValid(t *testing.T) {
+	assert.True(t, Valid("+79123456789"))
+	assert.False(t, Valid("89123456789"))
+	assert.False(t, Valid("+7 912 345 67 89"))
+}
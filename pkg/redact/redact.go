@@ -0,0 +1,82 @@
+// Пакет redact скрывает секреты (хэши паролей, токены, коды) при форматировании значений
+// для ошибок и логов.
+//
+// Типы с секретными полями реализуют fmt.Formatter через Format и slog.LogValuer,
+// подставляя Mask вместо значения секрета. Пакет logger дополнительно маскирует атрибуты
+// логов с известными именами секретов (см. logger.RedactHandler).
+package redact
+
+import (
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Mask - текст, который выводится вместо секрета
+const Mask = "[REDACTED]"
+
+// masked выводится как Mask при любом глаголе форматирования
+type masked struct{}
+
+func (masked) String() string   { return Mask }
+func (masked) GoString() string { return Mask }
+
+// Secret возвращает значение для вывода секрета: Mask, если секрет задан, и nil, если нет.
+// Так в логе видно, передан ли секрет, но не видно его значение.
+func Secret[T any](secret *T) any {
+	if secret == nil {
+		return nil
+	}
+	return masked{}
+}
+
+// Value разыменовывает указатель для вывода: nil для nil, иначе значение.
+// Без этого fmt выводит адрес вместо значения поля.
+func Value[T any](p *T) any {
+	if p == nil {
+		return nil
+	}
+	return *p
+}
+
+// Field - поле структуры для Format
+type Field struct {
+	Name  string
+	Value any // Значение для вывода: секреты передаются через Secret
+}
+
+// Format выводит структуру name с полями fields в формате, который fmt использует для структур:
+// %v - {a b}, %+v - {A:a B:b}, %#v - name{A:"a", B:"b"}. Для остальных глаголов
+// выводится как %v. Используется в реализациях fmt.Formatter.
+func Format(f fmt.State, verb rune, name string, fields ...Field) {
+	var b strings.Builder
+	goSyntax := verb == 'v' && f.Flag('#')
+	named := goSyntax || (verb == 'v' && f.Flag('+'))
+	if goSyntax {
+		b.WriteString(name)
+	}
+	b.WriteByte('{')
+	for i, field := range fields {
+		if i > 0 {
+			if goSyntax {
+				b.WriteString(", ")
+			} else {
+				b.WriteByte(' ')
+			}
+		}
+		if named {
+			b.WriteString(field.Name)
+			b.WriteByte(':')
+		}
+		switch {
+		case goSyntax:
+			fmt.Fprintf(&b, "%#v", field.Value)
+		case named:
+			fmt.Fprintf(&b, "%+v", field.Value)
+		default:
+			fmt.Fprintf(&b, "%v", field.Value)
+		}
+	}
+	b.WriteByte('}')
+	_, _ = io.WriteString(f, b.String())
+}
@@ -0,0 +1,57 @@
+package redact
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type credentials struct {
+	Login    string
+	Password *string
+	Note     *string
+}
+
+func (c credentials) Format(f fmt.State, verb rune) {
+	Format(f, verb, "redact.credentials",
+		Field{Name: "Login", Value: c.Login},
+		Field{Name: "Password", Value: Secret(c.Password)},
+		Field{Name: "Note", Value: Value(c.Note)},
+	)
+}
+
+func TestFormat(t *testing.T) {
+	password := "hunter2"
+	note := "admin"
+	c := credentials{Login: "anna", Password: &password, Note: &note}
+
+	tests := []struct {
+		name     string
+		format   string
+		expected string
+	}{
+		{"%v", "%v", "{anna [REDACTED] admin}"},
+		{"%+v", "%+v", "{Login:anna Password:[REDACTED] Note:admin}"},
+		{"%#v", "%#v", `redact.credentials{Login:"anna", Password:[REDACTED], Note:"admin"}`},
+		{"%s", "%s", "{anna [REDACTED] admin}"},
+		{"внутри ошибки", "", "create: {anna [REDACTED] admin}"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := fmt.Errorf("create: %v", c).Error()
+			if tt.format != "" {
+				out = fmt.Sprintf(tt.format, c)
+			}
+			assert.Equal(t, tt.expected, out)
+			assert.NotContains(t, out, password)
+		})
+	}
+}
+
+func TestSecret_Nil(t *testing.T) {
+	c := credentials{Login: "anna"}
+
+	assert.Equal(t, "{Login:anna Password:<nil> Note:<nil>}", fmt.Sprintf("%+v", c))
+	assert.Equal(t, "[REDACTED]", fmt.Sprint(Secret(new(string))))
+}
@@ -0,0 +1,53 @@
+// Пакет requestid передает идентификатор HTTP-запроса через context.Context:
+// от middleware сервера через сервисы до логов и запросов к Postgres.
+package requestid
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+)
+
+// Header - заголовок, в котором идентификатор принимается от прокси и возвращается клиенту
+const Header = "X-Request-ID"
+
+// MaxLength - максимальная длина принимаемого идентификатора
+const MaxLength = 128
+
+type contextKey struct{}
+
+// New возвращает новый идентификатор: 16 случайных байт в hex
+func New() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// Valid проверяет идентификатор, полученный от клиента: непустой, не длиннее MaxLength,
+// только латинские буквы, цифры и символы "-", "_", ".", ":". Такой идентификатор
+// безопасно выводить в логи и заголовки.
+func Valid(id string) bool {
+	if id == "" || len(id) > MaxLength {
+		return false
+	}
+	for _, r := range id {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case r == '-', r == '_', r == '.', r == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// WithID возвращает контекст с идентификатором запроса
+func WithID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// FromContext возвращает идентификатор запроса или пустую строку, если его нет в контексте
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(contextKey{}).(string)
+	return id
+}
@@ -0,0 +1,47 @@
+package requestid
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNew(t *testing.T) {
+	id := New()
+
+	assert.Len(t, id, 32)
+	assert.True(t, Valid(id))
+	assert.NotEqual(t, id, New())
+}
+
+func TestValid(t *testing.T) {
+	tests := []struct {
+		name  string
+		id    string
+		valid bool
+	}{
+		{"hex", "0af7651916cd43dd8448eb211c80319c", true},
+		{"uuid", "6f1c2d3e-4a5b-4c6d-8e7f-9a0b1c2d3e4f", true},
+		{"с точкой и двоеточием", "lb-1:req.42", true},
+		{"пустой", "", false},
+		{"слишком длинный", strings.Repeat("a", MaxLength+1), false},
+		{"перевод строки", "abc\nlevel=ERROR", false},
+		{"пробел", "abc def", false},
+		{"не латиница", "запрос", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.valid, Valid(tt.id))
+		})
+	}
+}
+
+func TestContext(t *testing.T) {
+	assert.Empty(t, FromContext(context.Background()))
+
+	ctx := WithID(context.Background(), "req-1")
+
+	assert.Equal(t, "req-1", FromContext(ctx))
+}
@@ -0,0 +1,151 @@
+// Пакет sitemap формирует файлы карты сайта по протоколу sitemaps.org
+// с расширением для изображений Google (xmlns:image).
+//
+// Один файл может содержать не больше MaxURLs адресов. Если адресов больше, Build разбивает их
+// на файлы sitemap-1.xml, sitemap-2.xml, ..., а sitemap.xml становится индексом со ссылками на них.
+package sitemap
+
+import (
+	"bytes"
+	"encoding/xml"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MaxURLs - максимальное количество адресов в одном файле по протоколу
+const MaxURLs = 50_000
+
+// IndexName - имя главного файла: карта сайта или индекс карт
+const IndexName = "sitemap.xml"
+
+// Пространства имен XML
+const (
+	namespace      = "http://www.sitemaps.org/schemas/sitemap/0.9"
+	imageNamespace = "http://www.google.com/schemas/sitemap-image/1.1"
+)
+
+// URL - страница сайта
+type URL struct {
+	Loc     string    // Абсолютный адрес страницы
+	LastMod time.Time // Дата последнего изменения; нулевое значение не выводится
+	Images  []string  // Абсолютные адреса изображений страницы
+}
+
+// PartName возвращает имя n-го файла при разбиении (с 1): "sitemap-1.xml"
+func PartName(n int) string {
+	return "sitemap-" + strconv.Itoa(n) + ".xml"
+}
+
+// Build формирует файлы карты сайта по имени файла. baseURL - адрес сайта без завершающего слеша,
+// он нужен для ссылок из индекса на части. limit - количество адресов в одном файле;
+// 0 или больше MaxURLs означает MaxURLs.
+func Build(baseURL string, urls []URL, limit int) (map[string][]byte, error) {
+	if limit <= 0 || limit > MaxURLs {
+		limit = MaxURLs
+	}
+	if len(urls) <= limit {
+		data, err := marshalURLSet(urls)
+		if err != nil {
+			return nil, err
+		}
+		return map[string][]byte{IndexName: data}, nil
+	}
+
+	files := make(map[string][]byte)
+	index := sitemapIndex{XMLNS: namespace}
+	baseURL = strings.TrimSuffix(baseURL, "/")
+	for n, start := 1, 0; start < len(urls); n, start = n+1, start+limit {
+		part := urls[start:min(start+limit, len(urls))]
+		data, err := marshalURLSet(part)
+		if err != nil {
+			return nil, err
+		}
+		name := PartName(n)
+		files[name] = data
+		index.Sitemaps = append(index.Sitemaps, sitemapRef{
+			Loc:     baseURL + "/" + name,
+			LastMod: formatTime(latest(part)),
+		})
+	}
+	data, err := marshal(index)
+	if err != nil {
+		return nil, err
+	}
+	files[IndexName] = data
+	return files, nil
+}
+
+type urlSet struct {
+	XMLName    xml.Name     `xml:"urlset"`
+	XMLNS      string       `xml:"xmlns,attr"`
+	ImageXMLNS string       `xml:"xmlns:image,attr,omitempty"`
+	URLs       []urlElement `xml:"url"`
+}
+
+type urlElement struct {
+	Loc     string         `xml:"loc"`
+	LastMod string         `xml:"lastmod,omitempty"`
+	Images  []imageElement `xml:"image:image"`
+}
+
+type imageElement struct {
+	Loc string `xml:"image:loc"`
+}
+
+type sitemapIndex struct {
+	XMLName  xml.Name     `xml:"sitemapindex"`
+	XMLNS    string       `xml:"xmlns,attr"`
+	Sitemaps []sitemapRef `xml:"sitemap"`
+}
+
+type sitemapRef struct {
+	Loc     string `xml:"loc"`
+	LastMod string `xml:"lastmod,omitempty"`
+}
+
+func marshalURLSet(urls []URL) ([]byte, error) {
+	set := urlSet{XMLNS: namespace, URLs: make([]urlElement, 0, len(urls))}
+	for _, u := range urls {
+		el := urlElement{Loc: u.Loc, LastMod: formatTime(u.LastMod)}
+		for _, image := range u.Images {
+			el.Images = append(el.Images, imageElement{Loc: image})
+		}
+		if len(el.Images) > 0 {
+			set.ImageXMLNS = imageNamespace
+		}
+		set.URLs = append(set.URLs, el)
+	}
+	return marshal(set)
+}
+
+func marshal(v any) ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	enc := xml.NewEncoder(&buf)
+	enc.Indent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return nil, err
+	}
+	buf.WriteByte('\n')
+	return buf.Bytes(), nil
+}
+
+// formatTime возвращает дату в формате W3C Datetime
+func formatTime(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return t.UTC().Format(time.RFC3339)
+}
+
+// latest возвращает самую позднюю дату изменения
+func latest(urls []URL) time.Time {
+	var last time.Time
+	for _, u := range urls {
+		if u.LastMod.After(last) {
+			last = u.LastMod
+		}
+	}
+	return last
+}
@@ -0,0 +1,76 @@
+package sitemap
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBuild_SingleFile(t *testing.T) {
+	urls := []URL{
+		{Loc: "https://luxcarpets.ru/"},
+		{
+			Loc:     "https://luxcarpets.ru/catalog?category=kovry&page=1",
+			LastMod: time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC),
+		},
+		{
+			Loc:     "https://luxcarpets.ru/product/osta-diamond",
+			LastMod: time.Date(2025, 3, 2, 9, 30, 0, 0, time.FixedZone("MSK", 3*60*60)),
+			Images:  []string{"https://cdn.luxcarpets.ru/media/ab/abcd/1920.jpg"},
+		},
+	}
+
+	files, err := Build("https://luxcarpets.ru", urls, 0)
+
+	require.NoError(t, err)
+	require.Len(t, files, 1)
+	assert.Equal(t, `<?xml version="1.0" encoding="UTF-8"?>
+<urlset xmlns="http://www.sitemaps.org/schemas/sitemap/0.9" xmlns:image="http://www.google.com/schemas/sitemap-image/1.1">
+  <url>
+    <loc>https://luxcarpets.ru/</loc>
+  </url>
+  <url>
+    <loc>https://luxcarpets.ru/catalog?category=kovry&amp;page=1</loc>
+    <lastmod>2025-03-01T12:00:00Z</lastmod>
+  </url>
+  <url>
+    <loc>https://luxcarpets.ru/product/osta-diamond</loc>
+    <lastmod>2025-03-02T06:30:00Z</lastmod>
+    <image:image>
+      <image:loc>https://cdn.luxcarpets.ru/media/ab/abcd/1920.jpg</image:loc>
+    </image:image>
+  </url>
+</urlset>
+`, string(files[IndexName]))
+}
+
+func TestBuild_Index(t *testing.T) {
+	day := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	urls := []URL{
+		{Loc: "https://luxcarpets.ru/a", LastMod: day},
+		{Loc: "https://luxcarpets.ru/b", LastMod: day.AddDate(0, 0, 2)},
+		{Loc: "https://luxcarpets.ru/c", LastMod: day.AddDate(0, 0, 1)},
+	}
+
+	files, err := Build("https://luxcarpets.ru/", urls, 2)
+
+	require.NoError(t, err)
+	assert.Len(t, files, 3)
+	assert.Equal(t, `<?xml version="1.0" encoding="UTF-8"?>
+<sitemapindex xmlns="http://www.sitemaps.org/schemas/sitemap/0.9">
+  <sitemap>
+    <loc>https://luxcarpets.ru/sitemap-1.xml</loc>
+    <lastmod>2025-03-03T00:00:00Z</lastmod>
+  </sitemap>
+  <sitemap>
+    <loc>https://luxcarpets.ru/sitemap-2.xml</loc>
+    <lastmod>2025-03-02T00:00:00Z</lastmod>
+  </sitemap>
+</sitemapindex>
+`, string(files[IndexName]))
+	assert.Contains(t, string(files[PartName(1)]), "<loc>https://luxcarpets.ru/b</loc>")
+	assert.Contains(t, string(files[PartName(2)]), "<loc>https://luxcarpets.ru/c</loc>")
+	assert.NotContains(t, string(files[PartName(2)]), "xmlns:image")
+}
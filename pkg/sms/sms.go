@@ -0,0 +1,68 @@
+// Пакет sms отправляет SMS через подключаемые шлюзы.
+// Сервисы зависят только от интерфейса Sender: в разработке используется LogSender,
+// в тестах - Fake, в production - SMSRu.
+package sms
+
+import (
+	"context"
+	"log/slog"
+	"sync"
+)
+
+// Sender отправляет SMS на номер в формате E.164
+type Sender interface {
+	Send(ctx context.Context, phone, text string) error
+}
+
+// Message - отправленное сообщение
+type Message struct {
+	Phone string // Номер получателя в формате E.164
+	Text  string // Текст сообщения
+}
+
+// LogSender не отправляет SMS, а пишет их в лог. Используется при локальной разработке.
+type LogSender struct{}
+
+// Send записывает сообщение в лог
+func (LogSender) Send(ctx context.Context, phone, text string) error {
+	slog.InfoContext(ctx, "SMS", slog.String("phone", phone), slog.String("text", text))
+	return nil
+}
+
+// Fake запоминает отправленные сообщения. Используется в тестах.
+type Fake struct {
+	mu       sync.Mutex
+	messages []Message
+	// Err, если задана, возвращается из Send вместо отправки
+	Err error
+}
+
+// Send сохраняет сообщение или возвращает Err
+func (f *Fake) Send(_ context.Context, phone, text string) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.Err != nil {
+		return f.Err
+	}
+	f.messages = append(f.messages, Message{Phone: phone, Text: text})
+	return nil
+}
+
+// Messages возвращает копию отправленных сообщений
+func (f *Fake) Messages() []Message {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return append([]Message(nil), f.messages...)
+}
+
+// Last возвращает последнее сообщение на номер phone
+func (f *Fake) Last(phone string) (Message, bool) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for i := len(f.messages) - 1; i >= 0; i-- {
+		if f.messages[i].Phone == phone {
+			return f.messages[i], true
+		}
+	}
+	return Message{}, false
+}
@@ -0,0 +1,87 @@
+package sms
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestFake(t *testing.T) {
+	fake := &Fake{}
+	ctx := context.Background()
+
+	require.NoError(t, fake.Send(ctx, "+79123456789", "первое"))
+	require.NoError(t, fake.Send(ctx, "+79990000000", "другой номер"))
+	require.NoError(t, fake.Send(ctx, "+79123456789", "второе"))
+
+	assert.Len(t, fake.Messages(), 3)
+	last, ok := fake.Last("+79123456789")
+	require.True(t, ok)
+	assert.Equal(t, "второе", last.Text)
+	_, ok = fake.Last("+70000000000")
+	assert.False(t, ok)
+
+	fake.Err = errors.New("gateway down")
+	assert.Error(t, fake.Send(ctx, "+79123456789", "третье"))
+	assert.Len(t, fake.Messages(), 3)
+}
+
+func TestSMSRu_Send(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		response string
+		wantErr  error
+	}{
+		{
+			name:     "успешная отправка",
+			status:   http.StatusOK,
+			response: `{"status":"OK","status_code":100,"sms":{"79123456789":{"status":"OK","status_code":100,"sms_id":"1"}}}`,
+		},
+		{
+			name:     "ошибка запроса",
+			status:   http.StatusOK,
+			response: `{"status":"ERROR","status_code":200,"status_text":"Неправильный api_id"}`,
+			wantErr:  ErrDeliveryFailed,
+		},
+		{
+			name:     "ошибка по номеру",
+			status:   http.StatusOK,
+			response: `{"status":"OK","status_code":100,"sms":{"79123456789":{"status":"ERROR","status_code":207,"status_text":"На этот номер нельзя отправлять сообщения"}}}`,
+			wantErr:  ErrDeliveryFailed,
+		},
+		{
+			name:    "ошибка HTTP",
+			status:  http.StatusBadGateway,
+			wantErr: ErrDeliveryFailed,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				require.NoError(t, r.ParseForm())
+				assert.Equal(t, "secret", r.PostForm.Get("api_id"))
+				assert.Equal(t, "79123456789", r.PostForm.Get("to"))
+				assert.Equal(t, "Код: 123456", r.PostForm.Get("msg"))
+				assert.Equal(t, "LuxCarpets", r.PostForm.Get("from"))
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.response))
+			}))
+			defer srv.Close()
+
+			sender := NewSMSRu("secret", WithSMSRuEndpoint(srv.URL), WithSMSRuSender("LuxCarpets"))
+			err := sender.Send(context.Background(), "+79123456789", "Код: 123456")
+
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
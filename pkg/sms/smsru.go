@@ -0,0 +1,115 @@
+package sms
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// DefaultSMSRuEndpoint - адрес метода отправки SMS.ru
+const DefaultSMSRuEndpoint = "https://sms.ru/sms/send"
+
+// ErrDeliveryFailed возвращается, если шлюз отказался принять сообщение
+var ErrDeliveryFailed = errors.New("sms delivery failed")
+
+// SMSRu отправляет SMS через шлюз SMS.ru
+type SMSRu struct {
+	apiID    string
+	from     string
+	endpoint string
+	client   *http.Client
+}
+
+// SMSRuOption настраивает SMSRu при создании
+type SMSRuOption func(*SMSRu)
+
+// WithSMSRuSender задает согласованное имя отправителя
+func WithSMSRuSender(from string) SMSRuOption {
+	return func(s *SMSRu) {
+		s.from = from
+	}
+}
+
+// WithSMSRuEndpoint задает адрес API (используется в тестах)
+func WithSMSRuEndpoint(endpoint string) SMSRuOption {
+	return func(s *SMSRu) {
+		s.endpoint = endpoint
+	}
+}
+
+// WithSMSRuHTTPClient задает HTTP-клиент
+func WithSMSRuHTTPClient(client *http.Client) SMSRuOption {
+	return func(s *SMSRu) {
+		s.client = client
+	}
+}
+
+// NewSMSRu создает клиент SMS.ru с ключом API apiID
+func NewSMSRu(apiID string, opts ...SMSRuOption) *SMSRu {
+	s := &SMSRu{
+		apiID:    apiID,
+		endpoint: DefaultSMSRuEndpoint,
+		client:   &http.Client{Timeout: 10 * time.Second},
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+	return s
+}
+
+// smsRuResponse - ответ метода sms/send в формате JSON
+type smsRuResponse struct {
+	Status     string `json:"status"`
+	StatusCode int    `json:"status_code"`
+	StatusText string `json:"status_text"`
+	SMS        map[string]struct {
+		Status     string `json:"status"`
+		StatusCode int    `json:"status_code"`
+		StatusText string `json:"status_text"`
+	} `json:"sms"`
+}
+
+// Send отправляет сообщение. Ошибки шлюза оборачивают ErrDeliveryFailed.
+func (s *SMSRu) Send(ctx context.Context, phone, text string) error {
+	to := strings.TrimPrefix(phone, "+")
+	form := url.Values{
+		"api_id": {s.apiID},
+		"to":     {to},
+		"msg":    {text},
+		"json":   {"1"},
+	}
+	if s.from != "" {
+		form.Set("from", s.from)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, s.endpoint, strings.NewReader(form.Encode()))
+	if err != nil {
+		return fmt.Errorf("failed to build sms.ru request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to call sms.ru: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%w: sms.ru responded with HTTP %d", ErrDeliveryFailed, resp.StatusCode)
+	}
+
+	var body smsRuResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return fmt.Errorf("failed to decode sms.ru response: %w", err)
+	}
+	if body.Status != "OK" {
+		return fmt.Errorf("%w: sms.ru status %d: %s", ErrDeliveryFailed, body.StatusCode, body.StatusText)
+	}
+	if result, ok := body.SMS[to]; ok && result.Status != "OK" {
+		return fmt.Errorf("%w: sms.ru status %d: %s", ErrDeliveryFailed, result.StatusCode, result.StatusText)
+	}
+	return nil
+}
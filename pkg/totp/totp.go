@@ -0,0 +1,112 @@
+// Пакет totp реализует одноразовые пароли на основе времени (RFC 6238)
+// с параметрами, которые поддерживают все распространенные приложения-аутентификаторы:
+// HMAC-SHA1, 6 цифр, шаг 30 секунд.
+package totp
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	// SecretSize - длина секрета в байтах (160 бит, рекомендация RFC 4226)
+	SecretSize = 20
+	// Digits - количество цифр в коде
+	Digits = 6
+	// Period - длительность шага времени
+	Period = 30 * time.Second
+)
+
+// encoding - base32 без выравнивания, как принято в otpauth URI
+var encoding = base32.StdEncoding.WithPadding(base32.NoPadding)
+
+// GenerateSecret создает новый случайный секрет
+func GenerateSecret() ([]byte, error) {
+	secret := make([]byte, SecretSize)
+	if _, err := rand.Read(secret); err != nil {
+		return nil, fmt.Errorf("failed to generate totp secret: %w", err)
+	}
+	return secret, nil
+}
+
+// EncodeSecret кодирует секрет в base32 для ручного ввода в приложение
+func EncodeSecret(secret []byte) string {
+	return encoding.EncodeToString(secret)
+}
+
+// DecodeSecret декодирует секрет из base32 (регистр и пробелы не важны)
+func DecodeSecret(s string) ([]byte, error) {
+	s = strings.ToUpper(strings.ReplaceAll(s, " ", ""))
+	return encoding.DecodeString(strings.TrimRight(s, "="))
+}
+
+// Step возвращает номер шага времени для момента t
+func Step(t time.Time) int64 {
+	return t.Unix() / int64(Period/time.Second)
+}
+
+// Code вычисляет код из заданного числа цифр для шага времени (RFC 4226, раздел 5.3)
+func Code(secret []byte, step int64, digits int) string {
+	var msg [8]byte
+	binary.BigEndian.PutUint64(msg[:], uint64(step))
+
+	mac := hmac.New(sha1.New, secret)
+	mac.Write(msg[:])
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+
+	mod := uint32(1)
+	for range digits {
+		mod *= 10
+	}
+	return fmt.Sprintf("%0*d", digits, value%mod)
+}
+
+// Validate проверяет код для момента t с допуском skew шагов в обе стороны.
+// Возвращает шаг, которому соответствует код, чтобы вызывающий мог запретить его повторное использование.
+func Validate(secret []byte, code string, t time.Time, skew int) (step int64, ok bool) {
+	code = strings.TrimSpace(code)
+	if len(code) != Digits {
+		return 0, false
+	}
+	current := Step(t)
+	for i := -skew; i <= skew; i++ {
+		candidate := current + int64(i)
+		if subtle.ConstantTimeCompare([]byte(Code(secret, candidate, Digits)), []byte(code)) == 1 {
+			return candidate, true
+		}
+	}
+	return 0, false
+}
+
+// ProvisioningURI формирует otpauth:// URI для QR-кода в приложении-аутентификаторе
+//
+// Пример:
+//
+//	otpauth://totp/LuxCarpets:user@example.com?algorithm=SHA1&digits=6&issuer=LuxCarpets&period=30&secret=...
+func ProvisioningURI(issuer, account string, secret []byte) string {
+	q := url.Values{}
+	q.Set("secret", EncodeSecret(secret))
+	q.Set("issuer", issuer)
+	q.Set("algorithm", "SHA1")
+	q.Set("digits", fmt.Sprint(Digits))
+	q.Set("period", fmt.Sprint(int(Period/time.Second)))
+
+	u := url.URL{
+		Scheme:   "otpauth",
+		Host:     "totp",
+		Path:     "/" + issuer + ":" + account,
+		RawQuery: q.Encode(),
+	}
+	return u.String()
+}
@@ -0,0 +1,92 @@
+package totp
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCode_RFC6238Vectors(t *testing.T) {
+	// Тестовые векторы из приложения B RFC 6238 для SHA1
+	secret := []byte("12345678901234567890")
+
+	tests := []struct {
+		unix     int64
+		expected string
+	}{
+		{59, "94287082"},
+		{1111111109, "07081804"},
+		{1111111111, "14050471"},
+		{1234567890, "89005924"},
+		{2000000000, "69279037"},
+		{20000000000, "65353130"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.expected, func(t *testing.T) {
+			step := Step(time.Unix(tt.unix, 0))
+			assert.Equal(t, tt.expected, Code(secret, step, 8))
+		})
+	}
+}
+
+func TestValidate(t *testing.T) {
+	secret, err := GenerateSecret()
+	require.NoError(t, err)
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	current := Step(now)
+
+	tests := []struct {
+		name     string
+		code     string
+		wantOK   bool
+		wantStep int64
+	}{
+		{"текущий шаг", Code(secret, current, Digits), true, current},
+		{"предыдущий шаг", Code(secret, current-1, Digits), true, current - 1},
+		{"следующий шаг", Code(secret, current+1, Digits), true, current + 1},
+		{"вне допуска", Code(secret, current-2, Digits), false, 0},
+		{"неверная длина", "12345", false, 0},
+		{"пробелы по краям", " " + Code(secret, current, Digits) + " ", true, current},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			step, ok := Validate(secret, tt.code, now, 1)
+			assert.Equal(t, tt.wantOK, ok)
+			assert.Equal(t, tt.wantStep, step)
+		})
+	}
+}
+
+func TestEncodeDecodeSecret(t *testing.T) {
+	secret, err := GenerateSecret()
+	require.NoError(t, err)
+	assert.Len(t, secret, SecretSize)
+
+	encoded := EncodeSecret(secret)
+	assert.NotContains(t, encoded, "=")
+
+	decoded, err := DecodeSecret(encoded)
+	require.NoError(t, err)
+	assert.Equal(t, secret, decoded)
+}
+
+func TestProvisioningURI(t *testing.T) {
+	secret := []byte("12345678901234567890")
+
+	uri := ProvisioningURI("LuxCarpets", "user@example.com", secret)
+
+	parsed, err := url.Parse(uri)
+	require.NoError(t, err)
+	assert.Equal(t, "otpauth", parsed.Scheme)
+	assert.Equal(t, "totp", parsed.Host)
+	assert.Equal(t, "/LuxCarpets:user@example.com", parsed.Path)
+	assert.Equal(t, "GEZDGNBVGY3TQOJQGEZDGNBVGY3TQOJQ", parsed.Query().Get("secret"))
+	assert.Equal(t, "LuxCarpets", parsed.Query().Get("issuer"))
+	assert.Equal(t, "6", parsed.Query().Get("digits"))
+	assert.Equal(t, "30", parsed.Query().Get("period"))
+}